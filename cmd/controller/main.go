@@ -59,6 +59,8 @@ func main() {
 		op.GetClient(),
 		op.ImageProvider,
 		op.InstanceTypeStore,
+		op.SpotEvictionsCache,
+		op.QuotaProvider,
 	)
 
 	lo.Must0(op.AddHealthzCheck("cloud-provider", aksCloudProvider.LivenessProbe))
@@ -93,14 +95,24 @@ func main() {
 			op.ImageProvider,
 			op.InstanceTypesProvider,
 			op.QuotaProvider,
+			op.AZClient.QuotaRequestsClient,
+			op.UnavailableOfferingsCache,
+			op.SpotEvictionsCache,
+			op.MemoryOverheadsCache,
+			op.SpotPriceHistoryCache,
+			op.PricingProvider,
 			op.InClusterKubernetesInterface,
 			op.KubernetesInterface,
 			op.ManagedDynamicInterface,
 			op.AZClient.SubnetsClient(),
+			op.AZClient.ApplicationSecurityGroupsClient(),
+			op.AZClient.NetworkSecurityGroupsClient,
 			op.AZClient.DiskEncryptionSetsClient(),
 			options.FromContext(ctx).ParsedDiskEncryptionSetID,
 			options.FromContext(ctx).NetworkPolicy,
 			options.FromContext(ctx).NetworkPlugin,
+			op.CapacityReservationProvider,
+			op.SubnetCapacityProvider,
 		)...).
 		Start(ctx)
 }
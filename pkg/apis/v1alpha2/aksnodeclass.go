@@ -44,27 +44,45 @@ type ArtifactStreaming struct {
 // AKSNodeClassSpec is the top level specification for the AKS Karpenter Provider.
 // This will contain configuration necessary to launch instances in AKS.
 // +kubebuilder:validation:XValidation:message="FIPS is not yet supported for Ubuntu2404",rule="has(self.fipsMode) && self.fipsMode == 'FIPS' ? (has(self.imageFamily) && self.imageFamily != 'Ubuntu2404') : true"
+// +kubebuilder:validation:XValidation:message="FIPS is not yet supported for Windows",rule="has(self.fipsMode) && self.fipsMode == 'FIPS' ? (has(self.imageFamily) && !self.imageFamily.startsWith('Windows')) : true"
 // +kubebuilder:validation:XValidation:message="TrustedLaunch is required for FIPS support with Ubuntu2204",rule="has(self.fipsMode) && self.fipsMode == 'FIPS' ? (has(self.imageFamily) && (self.imageFamily != 'Ubuntu2204' || (has(self.security) && has(self.security.trustedLaunch) && ((has(self.security.trustedLaunch.vtpm) && self.security.trustedLaunch.vtpm) || (has(self.security.trustedLaunch.secureBoot) && self.security.trustedLaunch.secureBoot))))) : true"
 // +kubebuilder:validation:XValidation:message="TrustedLaunch with FIPSMode FIPS is only supported for Ubuntu and Ubuntu2204",rule="has(self.fipsMode) && self.fipsMode == 'FIPS' && has(self.security) && has(self.security.trustedLaunch) && ((has(self.security.trustedLaunch.vtpm) && self.security.trustedLaunch.vtpm) || (has(self.security.trustedLaunch.secureBoot) && self.security.trustedLaunch.secureBoot)) ? (!has(self.imageFamily) || self.imageFamily == 'Ubuntu' || self.imageFamily == 'Ubuntu2204') : true"
 // +kubebuilder:validation:XValidation:message="kubelet.failSwapOn must be set to false when linuxOSConfig.swapFileSize is specified",rule="!has(self.linuxOSConfig) || !has(self.linuxOSConfig.swapFileSize) || (has(self.kubelet) && has(self.kubelet.failSwapOn) && self.kubelet.failSwapOn == false)"
+// +kubebuilder:validation:XValidation:message="linuxOSConfig is not supported for Windows",rule="!has(self.linuxOSConfig) || !has(self.imageFamily) || !self.imageFamily.startsWith('Windows')"
+// +kubebuilder:validation:XValidation:message="dataDisks are not supported for Windows",rule="!has(self.dataDisks) || !has(self.imageFamily) || !self.imageFamily.startsWith('Windows')"
+// +kubebuilder:validation:XValidation:message="userData is not supported for Windows",rule="!has(self.userData) || !has(self.imageFamily) || !self.imageFamily.startsWith('Windows')"
+// +kubebuilder:validation:XValidation:message="vnetSubnetID and subnetSelectorTerms are mutually exclusive",rule="!(has(self.vnetSubnetID) && has(self.subnetSelectorTerms))"
 type AKSNodeClassSpec struct {
 	// vnetSubnetID is the subnet used by nics provisioned with this nodeclass.
 	// If not specified, we will use the default --vnet-subnet-id specified in karpenter's options config
 	// +kubebuilder:validation:Pattern=`(?i)^\/subscriptions\/[^\/]+\/resourceGroups\/[a-zA-Z0-9_\-().]{0,89}[a-zA-Z0-9_\-()]\/providers\/Microsoft\.Network\/virtualNetworks\/[^\/]+\/subnets\/[^\/]+$`
 	// +optional
 	VNETSubnetID *string `json:"vnetSubnetID,omitempty"`
+	// podSubnetID is the subnet pods of nodes provisioned with this nodeclass get their IPs from, with Azure CNI dynamic
+	// IP allocation. Nodes then don't reserve IPs for their pods in their own subnet. It is only supported with Azure CNI
+	// without overlay, and the subnet must be in the VNet of the cluster. Changing it drifts existing instances.
+	// +kubebuilder:validation:Pattern=`(?i)^\/subscriptions\/[^\/]+\/resourceGroups\/[a-zA-Z0-9_\-().]{0,89}[a-zA-Z0-9_\-()]\/providers\/Microsoft\.Network\/virtualNetworks\/[^\/]+\/subnets\/[^\/]+$`
+	// +optional
+	PodSubnetID *string `json:"podSubnetID,omitempty"`
 	// osDiskSizeGB is the size of the OS disk in GB.
 	// +default=128
 	// +kubebuilder:validation:Minimum=30
 	// +kubebuilder:validation:Maximum=2048
 	// +optional
 	OSDiskSizeGB *int32 `json:"osDiskSizeGB,omitempty"`
-	// ImageID is the ID of the image that instances use.
-	// Not exposed in the API yet
-	ImageID *string `json:"-"`
+	// imageID is the ID of a custom image in an Azure Compute Gallery (Shared Image Gallery) that instances use, in place of the
+	// default AKS node images of the imageFamily. It can reference either an image definition, in which case the latest version
+	// of that definition is used and newer versions are rolled out as drift, or a specific image version.
+	// The image must be built from (and compatible with) the imageFamily, which is still used to bootstrap the node.
+	// Custom images are not yet supported with the AKS machine API provision mode.
+	// +kubebuilder:validation:Pattern=`(?i)^\/subscriptions\/[^\/]+\/resourceGroups\/[^\/]+\/providers\/Microsoft\.Compute\/galleries\/[^\/]+\/images\/[^\/]+(\/versions\/[^\/]+)?$`
+	// +kubebuilder:validation:MaxLength=1024
+	// +optional
+	ImageID *string `json:"imageID,omitempty"`
 	// imageFamily is the image family that instances use.
+	// Windows2022 and Windows2025 provision Windows Server nodes, and require the cluster to support Windows node pools.
 	// +default="Ubuntu"
-	// +kubebuilder:validation:Enum:={Ubuntu,Ubuntu2204,Ubuntu2404,AzureLinux}
+	// +kubebuilder:validation:Enum:={Ubuntu,Ubuntu2204,Ubuntu2404,AzureLinux,Windows2022,Windows2025}
 	// +optional
 	ImageFamily *string `json:"imageFamily,omitempty"`
 	// fipsMode controls FIPS compliance for the provisioned nodes
@@ -82,6 +100,8 @@ type AKSNodeClassSpec struct {
 	// They are a subset of the upstream types, recognizing not all options may be supported.
 	// Wherever possible, the types and names should reflect the upstream kubelet types.
 	// +kubebuilder:validation:XValidation:message="imageGCHighThresholdPercent must be greater than imageGCLowThresholdPercent",rule="has(self.imageGCHighThresholdPercent) && has(self.imageGCLowThresholdPercent) ?  self.imageGCHighThresholdPercent > self.imageGCLowThresholdPercent  : true"
+	// +kubebuilder:validation:XValidation:message="evictionSoft key does not have a matching evictionSoftGracePeriod",rule="!has(self.evictionSoft) || self.evictionSoft.all(e, has(self.evictionSoftGracePeriod) && e in self.evictionSoftGracePeriod)"
+	// +kubebuilder:validation:XValidation:message="evictionSoftGracePeriod key does not have a matching evictionSoft",rule="!has(self.evictionSoftGracePeriod) || self.evictionSoftGracePeriod.all(e, has(self.evictionSoft) && e in self.evictionSoft)"
 	// +optional
 	Kubelet *KubeletConfiguration `json:"kubelet,omitempty"`
 	// maxPods is an override for the maximum number of pods that can run on a worker node instance.
@@ -118,6 +138,287 @@ type AKSNodeClassSpec struct {
 	// https://learn.microsoft.com/en-us/azure/aks/custom-node-configuration
 	// +optional
 	LinuxOSConfig *LinuxOSConfiguration `json:"linuxOSConfig,omitempty"`
+	// allocationStrategy controls how Karpenter picks an instance type and offering among the ones compatible with a NodeClaim.
+	// It can be overridden per NodePool with the karpenter.azure.com/allocation-strategy annotation.
+	// +optional
+	AllocationStrategy *AllocationStrategy `json:"allocationStrategy,omitempty" hash:"ignore"`
+	// capacityReservationGroupID is the ID of an Azure Capacity Reservation Group that on-demand instances are placed into,
+	// when it has unused reserved capacity for their VM size and zone. As reserved capacity is already paid for, offerings
+	// backed by it are preferred over the others. Once the reserved capacity is exhausted, instances are created without it.
+	// Changing it doesn't drift existing instances. The group must be in the subscription of the cluster, and
+	// capacity reservation groups are not yet supported with the AKS machine API provision mode.
+	// +kubebuilder:validation:Pattern=`(?i)^\/subscriptions\/[^\/]+\/resourceGroups\/[^\/]+\/providers\/Microsoft\.Compute\/capacityReservationGroups\/[^\/]+$`
+	// +kubebuilder:validation:MaxLength=1024
+	// +optional
+	CapacityReservationGroupID *string `json:"capacityReservationGroupID,omitempty" hash:"ignore"`
+	// placement constrains where instances are placed, for latency-sensitive or compliance workloads.
+	// Placement is not yet supported with the AKS machine API provision mode.
+	// +optional
+	Placement *Placement `json:"placement,omitempty"`
+	// dataDisks are managed disks attached to instances in addition to the OS disk. Each disk is formatted (ext4)
+	// on first boot and mounted at its mount path before kubelet starts. Data disks are deleted with the instance,
+	// and are not supported with Windows image families. AKSNodeClasses with data disks fail validation in the AKS machine API provision mode.
+	// +kubebuilder:validation:XValidation:message="mountPath must be unique",rule="self.all(x, self.exists_one(y, y.mountPath == x.mountPath))"
+	// +kubebuilder:validation:MaxItems=16
+	// +listType=atomic
+	// +optional
+	DataDisks []DataDisk `json:"dataDisks,omitempty"`
+	// applicationSecurityGroupIDs are the IDs of Application Security Groups that the network interfaces of instances are
+	// members of. Changing them updates the membership of existing instances in place, without drifting them.
+	// The groups must be in the subscription and region of the cluster, and are not yet supported with the AKS machine API
+	// provision mode.
+	// +kubebuilder:validation:items:Pattern=`(?i)^\/subscriptions\/[^\/]+\/resourceGroups\/[^\/]+\/providers\/Microsoft\.Network\/applicationSecurityGroups\/[^\/]+$`
+	// +kubebuilder:validation:items:MaxLength=1024
+	// +kubebuilder:validation:MaxItems=20
+	// +listType=set
+	// +optional
+	ApplicationSecurityGroupIDs []string `json:"applicationSecurityGroupIDs,omitempty" hash:"ignore"`
+	// networkSecurityGroupID is the ID of a Network Security Group that is associated with the network interfaces of
+	// instances, in place of the one managed by AKS. Changing it drifts existing instances.
+	// The group must be in the subscription and region of the cluster, and is not yet supported with the AKS machine API
+	// provision mode.
+	// +kubebuilder:validation:Pattern=`(?i)^\/subscriptions\/[^\/]+\/resourceGroups\/[^\/]+\/providers\/Microsoft\.Network\/networkSecurityGroups\/[^\/]+$`
+	// +kubebuilder:validation:MaxLength=1024
+	// +optional
+	NetworkSecurityGroupID *string `json:"networkSecurityGroupID,omitempty"`
+	// publicIP configures an instance-level public IP, which is created with the network interface of each instance,
+	// attached to its primary IP configuration, and deleted with the instance. Changing it drifts existing instances.
+	// Public IPs are not yet supported with the AKS machine API provision mode.
+	// +optional
+	PublicIP *PublicIPConfiguration `json:"publicIP,omitempty"`
+	// subnetSelectorTerms select the subnets used by nics provisioned with this nodeclass, in place of vnetSubnetID.
+	// Terms are ORed, and the selected subnets are resolved in status.subnets. Each instance is launched into the selected
+	// subnet with the most free IPs among the ones with affinity to its zone, and into the next one when that subnet is full.
+	// Changing the terms doesn't drift existing instances. The subnets must be in the VNet of the cluster, and are not yet
+	// supported with the AKS machine API provision mode.
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=30
+	// +listType=atomic
+	// +optional
+	SubnetSelectorTerms []SubnetSelectorTerm `json:"subnetSelectorTerms,omitempty" hash:"ignore"`
+	// userData is user-supplied configuration merged into the bootstrap of instances, e.g., to install agents, mount file
+	// systems or configure containerd before kubelet starts. Changing it drifts existing instances.
+	// User data is not yet supported with the AKS machine API provision mode, nor with Windows image families.
+	// +optional
+	UserData *UserData `json:"userData,omitempty"`
+	// spot configures the spot instances of this nodeclass: the maximum price paid for them and what happens to them when
+	// they are evicted. Changing it doesn't drift existing instances, which keep the settings they were created with.
+	// +optional
+	Spot *Spot `json:"spot,omitempty" hash:"ignore"`
+}
+
+// DataDisk is a managed disk attached to instances in addition to the OS disk.
+// +kubebuilder:validation:XValidation:message="diskIOPSReadWrite and diskMBpsReadWrite are only supported for PremiumV2_LRS and UltraSSD_LRS",rule="(has(self.diskIOPSReadWrite) || has(self.diskMBpsReadWrite)) ? (has(self.sku) && (self.sku == 'PremiumV2_LRS' || self.sku == 'UltraSSD_LRS')) : true"
+// +kubebuilder:validation:XValidation:message="caching must be None for PremiumV2_LRS and UltraSSD_LRS",rule="has(self.sku) && (self.sku == 'PremiumV2_LRS' || self.sku == 'UltraSSD_LRS') ? (!has(self.caching) || self.caching == 'None') : true"
+type DataDisk struct {
+	// sizeGB is the size of the disk in GB.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65536
+	// +required
+	SizeGB int32 `json:"sizeGB"`
+	// sku is the storage account type of the disk. PremiumV2_LRS and UltraSSD_LRS disks can only be attached to
+	// zonal instances, and UltraSSD_LRS requires a VM size that supports Ultra Disks.
+	// +default="Premium_LRS"
+	// +optional
+	SKU *DataDiskSKU `json:"sku,omitempty"`
+	// diskIOPSReadWrite is the provisioned IOPS of PremiumV2_LRS and UltraSSD_LRS disks. Azure's baseline is used if not set.
+	// +kubebuilder:validation:Minimum=100
+	// +optional
+	DiskIOPSReadWrite *int64 `json:"diskIOPSReadWrite,omitempty"`
+	// diskMBpsReadWrite is the provisioned throughput, in MBps, of PremiumV2_LRS and UltraSSD_LRS disks. Azure's baseline
+	// is used if not set.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	DiskMBpsReadWrite *int64 `json:"diskMBpsReadWrite,omitempty"`
+	// caching is the host caching of the disk.
+	// +default="None"
+	// +optional
+	Caching *DataDiskCaching `json:"caching,omitempty"`
+	// mountPath is the absolute path the disk is mounted at.
+	// +kubebuilder:validation:Pattern=`^/[A-Za-z0-9._/-]*[A-Za-z0-9._-]$`
+	// +kubebuilder:validation:MaxLength=255
+	// +kubebuilder:validation:XValidation:message="mountPath must not be a system path",rule="!['/', '/boot', '/etc', '/usr', '/var', '/var/lib', '/var/lib/kubelet', '/var/lib/containerd', '/mnt'].exists(p, self == p)"
+	// +required
+	MountPath string `json:"mountPath"`
+}
+
+// +kubebuilder:validation:Enum:={Premium_LRS,PremiumV2_LRS,UltraSSD_LRS,StandardSSD_LRS,Standard_LRS}
+type DataDiskSKU string
+
+const (
+	DataDiskSKUPremiumLRS     DataDiskSKU = "Premium_LRS"
+	DataDiskSKUPremiumV2LRS   DataDiskSKU = "PremiumV2_LRS"
+	DataDiskSKUUltraSSDLRS    DataDiskSKU = "UltraSSD_LRS"
+	DataDiskSKUStandardSSDLRS DataDiskSKU = "StandardSSD_LRS"
+	DataDiskSKUStandardLRS    DataDiskSKU = "Standard_LRS"
+)
+
+// +kubebuilder:validation:Enum:={None,ReadOnly,ReadWrite}
+type DataDiskCaching string
+
+const (
+	DataDiskCachingNone      DataDiskCaching = "None"
+	DataDiskCachingReadOnly  DataDiskCaching = "ReadOnly"
+	DataDiskCachingReadWrite DataDiskCaching = "ReadWrite"
+)
+
+// PublicIPConfiguration configures the instance-level public IPs of instances.
+type PublicIPConfiguration struct {
+	// publicIPPrefixID is the ID of a Public IP Prefix that the public IPs are allocated from, e.g. to give instances
+	// addresses from a known range. The prefix must be in the subscription and region of the cluster.
+	// If not set, the public IPs are allocated from Azure's pool.
+	// +kubebuilder:validation:Pattern=`(?i)^\/subscriptions\/[^\/]+\/resourceGroups\/[^\/]+\/providers\/Microsoft\.Network\/publicIPPrefixes\/[^\/]+$`
+	// +kubebuilder:validation:MaxLength=1024
+	// +optional
+	PublicIPPrefixID *string `json:"publicIPPrefixID,omitempty"`
+	// sku is the SKU of the public IPs.
+	// +default="Standard"
+	// +optional
+	SKU *PublicIPSKU `json:"sku,omitempty"`
+	// tags to be applied on the public IPs, in addition to the tags applied on all the Azure resources of an instance,
+	// which take precedence.
+	// +kubebuilder:validation:XValidation:message="tags keys must be less than 512 characters",rule="self.all(k, size(k) <= 512)"
+	// +kubebuilder:validation:XValidation:message="tags keys must not contain '<', '>', '%', '&', or '?'",rule="self.all(k, !k.matches('[<>%&?]'))"
+	// +kubebuilder:validation:XValidation:message="tags keys must not contain '\\'",rule="self.all(k, !k.contains('\\\\'))"
+	// +kubebuilder:validation:XValidation:message="tags values must be less than 256 characters",rule="self.all(k, size(self[k]) <= 256)"
+	// +optional
+	Tags map[string]string `json:"tags,omitempty"`
+	// ipTags are the IP tags of the public IPs, e.g. {type: RoutingPreference, tag: Internet}.
+	// +kubebuilder:validation:MaxItems=10
+	// +listType=atomic
+	// +optional
+	IPTags []PublicIPTag `json:"ipTags,omitempty"`
+}
+
+// +kubebuilder:validation:Enum:={Standard}
+type PublicIPSKU string
+
+const (
+	// PublicIPSKUStandard is the only SKU that public IPs can still be created with, since the retirement of Basic public IPs
+	PublicIPSKUStandard PublicIPSKU = "Standard"
+)
+
+// PublicIPTag is an IP tag of a public IP.
+type PublicIPTag struct {
+	// type is the IP tag type, e.g. RoutingPreference or FirstPartyUsage.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=128
+	// +required
+	Type string `json:"type"`
+	// tag is the value of the IP tag, e.g. Internet.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=128
+	// +required
+	Tag string `json:"tag"`
+}
+
+// SubnetSelectorTerm selects subnets, either by ID, or by the tags of the subnets of a VNet.
+// +kubebuilder:validation:XValidation:message="expected exactly one of ['id', 'tags']",rule="has(self.id) != has(self.tags)"
+// +kubebuilder:validation:XValidation:message="vnetID must be set with tags, and only with tags",rule="has(self.vnetID) == has(self.tags)"
+type SubnetSelectorTerm struct {
+	// id is the ID of a subnet.
+	// +kubebuilder:validation:Pattern=`(?i)^\/subscriptions\/[^\/]+\/resourceGroups\/[a-zA-Z0-9_\-().]{0,89}[a-zA-Z0-9_\-()]\/providers\/Microsoft\.Network\/virtualNetworks\/[^\/]+\/subnets\/[^\/]+$`
+	// +optional
+	ID *string `json:"id,omitempty"`
+	// vnetID is the ID of the virtual network whose subnets are selected by tags.
+	// +kubebuilder:validation:Pattern=`(?i)^\/subscriptions\/[^\/]+\/resourceGroups\/[a-zA-Z0-9_\-().]{0,89}[a-zA-Z0-9_\-()]\/providers\/Microsoft\.Network\/virtualNetworks\/[^\/]+$`
+	// +optional
+	VNETID *string `json:"vnetID,omitempty"`
+	// tags select the subnets of the virtual network that have all of them. A value of "*" matches any value.
+	// +kubebuilder:validation:MinProperties=1
+	// +kubebuilder:validation:MaxProperties=20
+	// +optional
+	Tags map[string]string `json:"tags,omitempty"`
+	// zones are the zones the selected subnets have affinity to, e.g. westus2-1. Subnets with zones are only used for
+	// instances in those zones, which prefer them over the subnets without zones.
+	// +kubebuilder:validation:items:Pattern=`^[a-z0-9]+-[1-9]$`
+	// +kubebuilder:validation:MaxItems=3
+	// +listType=set
+	// +optional
+	Zones []string `json:"zones,omitempty"`
+}
+
+// UserData is user-supplied configuration run during the bootstrap of instances. Scripts are run by bash as root, with
+// their output logged under /var/log/azure, and a script that fails fails the bootstrap of the instance.
+// The combined size of the fields is limited, as they must fit in the custom data of the instance along with the
+// generated bootstrap configuration.
+// +kubebuilder:validation:XValidation:message="the combined size of cloudConfig, preBootstrapScript and postBootstrapScript must be at most 32768 characters",rule="(has(self.cloudConfig) ? size(self.cloudConfig) : 0) + (has(self.preBootstrapScript) ? size(self.preBootstrapScript) : 0) + (has(self.postBootstrapScript) ? size(self.postBootstrapScript) : 0) <= 32768"
+type UserData struct {
+	// cloudConfig is a cloud-init cloud-config document merged into the custom data of instances. Its lists and maps are
+	// appended to, rather than replacing, the ones of the generated configuration.
+	// +kubebuilder:validation:XValidation:message="cloudConfig must start with #cloud-config",rule="self.startsWith('#cloud-config')"
+	// +kubebuilder:validation:MaxLength=32768
+	// +optional
+	CloudConfig *string `json:"cloudConfig,omitempty"`
+	// preBootstrapScript is a shell script run before containerd and kubelet are configured and started.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=32768
+	// +optional
+	PreBootstrapScript *string `json:"preBootstrapScript,omitempty"`
+	// postBootstrapScript is a shell script run once kubelet has been started.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=32768
+	// +optional
+	PostBootstrapScript *string `json:"postBootstrapScript,omitempty"`
+}
+
+// Spot configures the spot instances of a nodeclass.
+// +kubebuilder:validation:XValidation:message="maxPrice and maxPricePercentOfOnDemand are mutually exclusive",rule="!(has(self.maxPrice) && has(self.maxPricePercentOfOnDemand))"
+type Spot struct {
+	// maxPrice is the maximum price, in US dollars per hour, paid for a spot instance. Instances are evicted when the
+	// spot price of their VM size rises above it, and spot offerings whose current price is above it are not launched.
+	// If neither maxPrice nor maxPricePercentOfOnDemand is set, spot instances are paid up to the on-demand price.
+	// Spot max prices are not yet supported with the AKS machine API provision mode.
+	// +kubebuilder:validation:Pattern=`^(0|[1-9][0-9]*)(\.[0-9]{1,5})?$`
+	// +kubebuilder:validation:XValidation:message="maxPrice must be greater than 0",rule="double(self) > 0.0"
+	// +kubebuilder:validation:MaxLength=16
+	// +optional
+	MaxPrice *string `json:"maxPrice,omitempty"`
+	// maxPricePercentOfOnDemand caps the price paid for a spot instance at a percentage of the on-demand price of its
+	// VM size. Spot offerings of VM sizes without a known on-demand price are not launched.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	MaxPricePercentOfOnDemand *int32 `json:"maxPricePercentOfOnDemand,omitempty"`
+	// evictionPolicy is what happens to spot instances when they are evicted. Deallocated instances keep their disks,
+	// which are still billed, and are not replaced until they are deleted. Deallocate is not yet supported with the AKS
+	// machine API provision mode.
+	// +default="Delete"
+	// +optional
+	EvictionPolicy *SpotEvictionPolicy `json:"evictionPolicy,omitempty"`
+}
+
+// +kubebuilder:validation:Enum:={Delete,Deallocate}
+type SpotEvictionPolicy string
+
+const (
+	SpotEvictionPolicyDelete     SpotEvictionPolicy = "Delete"
+	SpotEvictionPolicyDeallocate SpotEvictionPolicy = "Deallocate"
+)
+
+// Placement constrains the physical placement of instances.
+// +kubebuilder:validation:XValidation:message="hostID and hostGroupID are mutually exclusive",rule="!(has(self.hostID) && has(self.hostGroupID))"
+type Placement struct {
+	// proximityPlacementGroupID is the ID of a Proximity Placement Group that instances are placed into, to co-locate them
+	// in the same datacenter for low network latency. VM sizes and zones that can't be allocated within the group are
+	// temporarily excluded for instances of this AKSNodeClass.
+	// +kubebuilder:validation:Pattern=`(?i)^\/subscriptions\/[^\/]+\/resourceGroups\/[^\/]+\/providers\/Microsoft\.Compute\/proximityPlacementGroups\/[^\/]+$`
+	// +kubebuilder:validation:MaxLength=1024
+	// +optional
+	ProximityPlacementGroupID *string `json:"proximityPlacementGroupID,omitempty"`
+	// hostGroupID is the ID of a Dedicated Host Group that instances are placed into. Azure picks a host in the group,
+	// which requires automatic placement to be enabled on the group. Spot instances can't be placed on dedicated hosts,
+	// so only on-demand offerings are available.
+	// +kubebuilder:validation:Pattern=`(?i)^\/subscriptions\/[^\/]+\/resourceGroups\/[^\/]+\/providers\/Microsoft\.Compute\/hostGroups\/[^\/]+$`
+	// +kubebuilder:validation:MaxLength=1024
+	// +optional
+	HostGroupID *string `json:"hostGroupID,omitempty"`
+	// hostID is the ID of a Dedicated Host that instances are placed onto. Spot instances can't be placed on dedicated hosts,
+	// so only on-demand offerings are available.
+	// +kubebuilder:validation:Pattern=`(?i)^\/subscriptions\/[^\/]+\/resourceGroups\/[^\/]+\/providers\/Microsoft\.Compute\/hostGroups\/[^\/]+\/hosts\/[^\/]+$`
+	// +kubebuilder:validation:MaxLength=1024
+	// +optional
+	HostID *string `json:"hostID,omitempty"`
 }
 
 // TrustedLaunch configures Trusted Launch security features for provisioned nodes.
@@ -343,6 +644,45 @@ type GPU struct {
 	Mode *GPUMode `json:"mode,omitempty"`
 }
 
+// +kubebuilder:validation:Enum:={lowest-price,capacity-optimized,lowest-price-diversified,prioritized,spot-eviction-aware}
+type AllocationStrategyType string
+
+const (
+	// AllocationStrategyLowestPrice picks the cheapest offering. This is the default behavior.
+	AllocationStrategyLowestPrice AllocationStrategyType = "lowest-price"
+	// AllocationStrategyCapacityOptimized prefers offerings that have not recently failed
+	// with insufficient capacity, and then the cheapest among them.
+	AllocationStrategyCapacityOptimized AllocationStrategyType = "capacity-optimized"
+	// AllocationStrategyLowestPriceDiversified spreads launches across the cheapest SKU families,
+	// so that a capacity shortage in a single family affects fewer nodes.
+	AllocationStrategyLowestPriceDiversified AllocationStrategyType = "lowest-price-diversified"
+	// AllocationStrategyPrioritized picks instance types in the order given by the user,
+	// and falls back to the cheapest of the remaining ones.
+	AllocationStrategyPrioritized AllocationStrategyType = "prioritized"
+	// AllocationStrategySpotEvictionAware picks the cheapest offering, after penalizing the price of spot offerings
+	// by the rate of spot evictions recently observed for them.
+	AllocationStrategySpotEvictionAware AllocationStrategyType = "spot-eviction-aware"
+)
+
+// AllocationStrategy controls how Karpenter picks an instance type and offering among the ones compatible with a NodeClaim.
+// +kubebuilder:validation:XValidation:message="priorities can only be set when type is prioritized",rule="!has(self.priorities) || (has(self.type) && self.type == 'prioritized')"
+type AllocationStrategy struct {
+	// type of the allocation strategy.
+	// lowest-price picks the cheapest offering.
+	// capacity-optimized prefers offerings that have not recently failed with insufficient capacity.
+	// lowest-price-diversified spreads launches across the cheapest SKU families.
+	// prioritized picks instance types in the order given by priorities.
+	// spot-eviction-aware picks the cheapest offering, ranking frequently evicted spot offerings as more expensive.
+	// +default="lowest-price"
+	// +optional
+	Type *AllocationStrategyType `json:"type,omitempty"`
+	// priorities is the ordered list of VM sizes preferred by the prioritized allocation strategy, most preferred first.
+	// VM sizes that are not listed are only used when none of the listed ones are available.
+	// +kubebuilder:validation:MaxItems=100
+	// +optional
+	Priorities []string `json:"priorities,omitempty"`
+}
+
 // KubeletConfiguration defines args to be used when configuring kubelet on provisioned nodes.
 // They are a subset of the upstream types, recognizing not all options may be supported.
 // Wherever possible, the types and names should reflect the upstream kubelet types.
@@ -429,6 +769,47 @@ type KubeletConfiguration struct {
 	// Must be set to false to allow linuxOSConfig.swapFileSize to take effect.
 	// +optional
 	FailSwapOn *bool `json:"failSwapOn,omitempty"`
+	// systemReserved contains resources reserved for OS system daemons and kernel memory.
+	// AKS does not reserve resources for OS system daemons by default.
+	// +kubebuilder:validation:XValidation:message="valid keys for systemReserved are ['cpu','memory','ephemeral-storage','pid']",rule="self.all(x, x=='cpu' || x=='memory' || x=='ephemeral-storage' || x=='pid')"
+	// +kubebuilder:validation:XValidation:message="systemReserved value must be a non-negative resource quantity",rule="self.all(x, self[x].matches('^[0-9]+([.][0-9]+)?(m|k|M|G|T|P|E|Ki|Mi|Gi|Ti|Pi|Ei)?$'))"
+	// +optional
+	//nolint:kubeapilinter // nomaps: using maps for compatibility with upstream kubelet types
+	SystemReserved map[string]string `json:"systemReserved,omitempty"`
+	// kubeReserved contains resources reserved for Kubernetes system components.
+	// The cpu and memory reserved by AKS by default depend on the VM size, they are replaced by the values set here.
+	// +kubebuilder:validation:XValidation:message="valid keys for kubeReserved are ['cpu','memory','ephemeral-storage','pid']",rule="self.all(x, x=='cpu' || x=='memory' || x=='ephemeral-storage' || x=='pid')"
+	// +kubebuilder:validation:XValidation:message="kubeReserved value must be a non-negative resource quantity",rule="self.all(x, self[x].matches('^[0-9]+([.][0-9]+)?(m|k|M|G|T|P|E|Ki|Mi|Gi|Ti|Pi|Ei)?$'))"
+	// +optional
+	//nolint:kubeapilinter // nomaps: using maps for compatibility with upstream kubelet types
+	KubeReserved map[string]string `json:"kubeReserved,omitempty"`
+	// evictionHard is the map of signal names to quantities that define hard eviction thresholds.
+	// Quantities are either absolute, e.g. "500Mi", or a percentage of capacity, e.g. "10%".
+	// They are merged with the default hard eviction threshold of AKS, memory.available<750Mi.
+	// +kubebuilder:validation:XValidation:message="valid keys for evictionHard are ['memory.available','nodefs.available','nodefs.inodesFree','imagefs.available','imagefs.inodesFree','pid.available']",rule="self.all(x, x in ['memory.available','nodefs.available','nodefs.inodesFree','imagefs.available','imagefs.inodesFree','pid.available'])"
+	// +kubebuilder:validation:XValidation:message="evictionHard value must be a non-negative resource quantity or a percentage",rule="self.all(x, self[x].matches('^[0-9]+([.][0-9]+)?(%|m|k|M|G|T|P|E|Ki|Mi|Gi|Ti|Pi|Ei)?$'))"
+	// +optional
+	//nolint:kubeapilinter // nomaps: using maps for compatibility with upstream kubelet types
+	EvictionHard map[string]string `json:"evictionHard,omitempty"`
+	// evictionSoft is the map of signal names to quantities that define soft eviction thresholds.
+	// Quantities are either absolute, e.g. "500Mi", or a percentage of capacity, e.g. "10%".
+	// Each signal must have a grace period in evictionSoftGracePeriod.
+	// +kubebuilder:validation:XValidation:message="valid keys for evictionSoft are ['memory.available','nodefs.available','nodefs.inodesFree','imagefs.available','imagefs.inodesFree','pid.available']",rule="self.all(x, x in ['memory.available','nodefs.available','nodefs.inodesFree','imagefs.available','imagefs.inodesFree','pid.available'])"
+	// +kubebuilder:validation:XValidation:message="evictionSoft value must be a non-negative resource quantity or a percentage",rule="self.all(x, self[x].matches('^[0-9]+([.][0-9]+)?(%|m|k|M|G|T|P|E|Ki|Mi|Gi|Ti|Pi|Ei)?$'))"
+	// +optional
+	//nolint:kubeapilinter // nomaps: using maps for compatibility with upstream kubelet types
+	EvictionSoft map[string]string `json:"evictionSoft,omitempty"`
+	// evictionSoftGracePeriod is the map of signal names to the durations a soft eviction threshold must be met for
+	// before pods are evicted.
+	// +kubebuilder:validation:XValidation:message="valid keys for evictionSoftGracePeriod are ['memory.available','nodefs.available','nodefs.inodesFree','imagefs.available','imagefs.inodesFree','pid.available']",rule="self.all(x, x in ['memory.available','nodefs.available','nodefs.inodesFree','imagefs.available','imagefs.inodesFree','pid.available'])"
+	// +optional
+	//nolint:kubeapilinter // nomaps,nodurations: using maps and Duration for compatibility with upstream kubelet types
+	EvictionSoftGracePeriod map[string]metav1.Duration `json:"evictionSoftGracePeriod,omitempty"`
+	// evictionMaxPodGracePeriod is the maximum allowed grace period (in seconds) to use when terminating pods in
+	// response to soft eviction thresholds being met.
+	// +kubebuilder:validation:Minimum:=0
+	// +optional
+	EvictionMaxPodGracePeriod *int32 `json:"evictionMaxPodGracePeriod,omitempty"`
 }
 
 // +kubebuilder:validation:Enum:={always,defer,"defer+madvise",madvise,never}
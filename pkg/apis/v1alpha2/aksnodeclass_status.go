@@ -64,6 +64,21 @@ type NodeImage struct {
 	Requirements []corev1.NodeSelectorRequirement `json:"requirements"`
 }
 
+// Subnet contains a resolved subnet selector value utilized for node launch
+type Subnet struct {
+	// id is the ID of the subnet
+	// +required
+	//nolint:kubeapilinter // requiredfields: validation is intentionally not enforced for this field
+	ID string `json:"id"`
+	// zones are the zones the subnet has affinity to. A subnet without zones can be used in any zone.
+	// +listType=set
+	// +optional
+	Zones []string `json:"zones,omitempty"`
+	// availableIPAddressCount is the number of free IPv4 addresses of the subnet when it was last resolved
+	// +optional
+	AvailableIPAddressCount *int64 `json:"availableIPAddressCount,omitempty"`
+}
+
 // AKSNodeClassStatus contains the resolved state of the AKSNodeClass
 type AKSNodeClassStatus struct {
 	// images contains the current set of images available to use
@@ -71,6 +86,10 @@ type AKSNodeClassStatus struct {
 	// +optional
 	//nolint:kubeapilinter // ssatags: adding listType marker would be a breaking change
 	Images []NodeImage `json:"images,omitempty"`
+	// subnets contains the current set of subnets selected by the subnetSelectorTerms, or the vnetSubnetID
+	// +listType=atomic
+	// +optional
+	Subnets []Subnet `json:"subnets,omitempty"`
 	// kubernetesVersion contains the current kubernetes version which should be
 	// used for nodes provisioned for the NodeClass
 	// +optional
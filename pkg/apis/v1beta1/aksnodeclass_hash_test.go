@@ -99,6 +99,15 @@ var _ = Describe("Hash", func() {
 		Entry("LocalDNS.VnetDNSOverrides.CacheDuration", v1beta1.AKSNodeClass{Spec: v1beta1.AKSNodeClassSpec{LocalDNS: &v1beta1.LocalDNS{VnetDNSOverrides: []v1beta1.LocalDNSZoneOverride{{Zone: "example.com", CacheDuration: karpv1.MustParseNillableDuration("2h")}}}}}),
 		Entry("LocalDNS.VnetDNSOverrides.ServeStaleDuration", v1beta1.AKSNodeClass{Spec: v1beta1.AKSNodeClassSpec{LocalDNS: &v1beta1.LocalDNS{VnetDNSOverrides: []v1beta1.LocalDNSZoneOverride{{Zone: "example.com", ServeStaleDuration: karpv1.MustParseNillableDuration("1h")}}}}}),
 		Entry("ArtifactStreaming.Enabled", v1beta1.AKSNodeClass{Spec: v1beta1.AKSNodeClassSpec{ArtifactStreaming: &v1beta1.ArtifactStreaming{Enabled: lo.ToPtr(true)}}}),
+		Entry("Placement.ProximityPlacementGroupID", v1beta1.AKSNodeClass{Spec: v1beta1.AKSNodeClassSpec{Placement: &v1beta1.Placement{ProximityPlacementGroupID: lo.ToPtr("ppg-id")}}}),
+		Entry("DataDisks", v1beta1.AKSNodeClass{Spec: v1beta1.AKSNodeClassSpec{DataDisks: []v1beta1.DataDisk{{SizeGB: 128, MountPath: "/data"}}}}),
+		Entry("NetworkSecurityGroupID", v1beta1.AKSNodeClass{Spec: v1beta1.AKSNodeClassSpec{NetworkSecurityGroupID: lo.ToPtr("nsg-id")}}),
+		Entry("PublicIP", v1beta1.AKSNodeClass{Spec: v1beta1.AKSNodeClassSpec{PublicIP: &v1beta1.PublicIPConfiguration{}}}),
+		Entry("PublicIP.PublicIPPrefixID", v1beta1.AKSNodeClass{Spec: v1beta1.AKSNodeClassSpec{PublicIP: &v1beta1.PublicIPConfiguration{PublicIPPrefixID: lo.ToPtr("prefix-id")}}}),
+		Entry("UserData.PreBootstrapScript", v1beta1.AKSNodeClass{Spec: v1beta1.AKSNodeClassSpec{UserData: &v1beta1.UserData{PreBootstrapScript: lo.ToPtr("echo pre")}}}),
+		Entry("UserData.CloudConfig", v1beta1.AKSNodeClass{Spec: v1beta1.AKSNodeClassSpec{UserData: &v1beta1.UserData{CloudConfig: lo.ToPtr("#cloud-config")}}}),
+		Entry("Kubelet.KubeReserved", v1beta1.AKSNodeClass{Spec: v1beta1.AKSNodeClassSpec{Kubelet: &v1beta1.KubeletConfiguration{KubeReserved: map[string]string{"cpu": "200m"}}}}),
+		Entry("Kubelet.EvictionHard", v1beta1.AKSNodeClass{Spec: v1beta1.AKSNodeClassSpec{Kubelet: &v1beta1.KubeletConfiguration{EvictionHard: map[string]string{"memory.available": "1Gi"}}}}),
 	)
 	It("should not change hash when tags are re-ordered", func() {
 		hash := nodeClass.Hash()
@@ -112,6 +121,30 @@ var _ = Describe("Hash", func() {
 		updatedHash := nodeClass.Hash()
 		Expect(hash).To(Equal(updatedHash))
 	})
+	It("should not change hash when the capacity reservation group is changed", func() {
+		hash := nodeClass.Hash()
+		nodeClass.Spec.CapacityReservationGroupID = lo.ToPtr("/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/capacityReservationGroups/crg")
+		updatedHash := nodeClass.Hash()
+		Expect(hash).To(Equal(updatedHash))
+	})
+	It("should not change hash when the application security groups are changed", func() {
+		hash := nodeClass.Hash()
+		nodeClass.Spec.ApplicationSecurityGroupIDs = []string{"/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/applicationSecurityGroups/asg"}
+		updatedHash := nodeClass.Hash()
+		Expect(hash).To(Equal(updatedHash))
+	})
+	It("should not change hash when the subnet selector terms are changed", func() {
+		hash := nodeClass.Hash()
+		nodeClass.Spec.SubnetSelectorTerms = []v1beta1.SubnetSelectorTerm{{ID: lo.ToPtr("/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet/subnets/subnet")}}
+		updatedHash := nodeClass.Hash()
+		Expect(hash).To(Equal(updatedHash))
+	})
+	It("should not change hash when the spot settings are changed", func() {
+		hash := nodeClass.Hash()
+		nodeClass.Spec.Spot = &v1beta1.Spot{MaxPrice: lo.ToPtr("0.5"), EvictionPolicy: lo.ToPtr(v1beta1.SpotEvictionPolicyDeallocate)}
+		updatedHash := nodeClass.Hash()
+		Expect(hash).To(Equal(updatedHash))
+	})
 	It("should expect two AKSNodeClasses with the same spec to have the same hash", func() {
 		otherNodeClass := &v1beta1.AKSNodeClass{
 			Spec: nodeClass.Spec,
@@ -29,6 +29,18 @@ const (
 	ConditionTypeSubnetsReady           = "SubnetsReady"
 	ConditionTypeValidationSucceeded    = "ValidationSucceeded"
 	ConditionTypeLocalDNSReady          = "LocalDNSReady"
+	// ConditionTypeNetworkSecurityGroupsReady reports whether the application security groups and the network security group
+	// of the AKSNodeClass exist and can be used
+	ConditionTypeNetworkSecurityGroupsReady = "NetworkSecurityGroupsReady"
+	// ConditionTypeCapacityReservationGroupReady reports whether the capacity reservation group can be used. It is not part of
+	// the readiness of the AKSNodeClass: while the group can't be used, instances are created without it.
+	ConditionTypeCapacityReservationGroupReady = "CapacityReservationGroupReady"
+	// ConditionTypeSubnetCapacityAvailable reports whether a subnet of the AKSNodeClass has enough free IPs for a node. It is
+	// not part of the readiness of the AKSNodeClass: subnets free up IPs as nodes are deleted.
+	ConditionTypeSubnetCapacityAvailable = "SubnetCapacityAvailable"
+	// ConditionTypePricingUpToDate reports whether the prices used to rank offerings are kept up to date, or offline prices are
+	// used on purpose. It is not part of the readiness of the AKSNodeClass: stale prices still provide a relative ordering.
+	ConditionTypePricingUpToDate = "PricingUpToDate"
 )
 
 // LocalDNSState is the resolved enable/disable decision for LocalDNS on the
@@ -65,6 +77,21 @@ type NodeImage struct {
 	Requirements []corev1.NodeSelectorRequirement `json:"requirements"`
 }
 
+// Subnet contains a resolved subnet selector value utilized for node launch
+type Subnet struct {
+	// id is the ID of the subnet
+	// +required
+	//nolint:kubeapilinter // requiredfields: validation is intentionally not enforced for this field
+	ID string `json:"id"`
+	// zones are the zones the subnet has affinity to. A subnet without zones can be used in any zone.
+	// +listType=set
+	// +optional
+	Zones []string `json:"zones,omitempty"`
+	// availableIPAddressCount is the number of free IPv4 addresses of the subnet when it was last resolved
+	// +optional
+	AvailableIPAddressCount *int64 `json:"availableIPAddressCount,omitempty"`
+}
+
 // AKSNodeClassStatus contains the resolved state of the AKSNodeClass
 type AKSNodeClassStatus struct {
 	// images contains the current set of images available to use
@@ -72,6 +99,10 @@ type AKSNodeClassStatus struct {
 	// +optional
 	//nolint:kubeapilinter // ssatags: adding listType marker would be a breaking change
 	Images []NodeImage `json:"images,omitempty"`
+	// subnets contains the current set of subnets selected by the subnetSelectorTerms, or the vnetSubnetID
+	// +listType=atomic
+	// +optional
+	Subnets []Subnet `json:"subnets,omitempty"`
 	// kubernetesVersion contains the current kubernetes version which should be
 	// used for nodes provisioned for the NodeClass
 	// +optional
@@ -101,6 +132,7 @@ func (in *AKSNodeClass) StatusConditions(opts ...status.ForOption) status.Condit
 		ConditionTypeSubnetsReady,
 		ConditionTypeValidationSucceeded,
 		ConditionTypeLocalDNSReady,
+		ConditionTypeNetworkSecurityGroupsReady,
 	}
 	return status.NewReadyConditions(conds...).For(in, opts...)
 }
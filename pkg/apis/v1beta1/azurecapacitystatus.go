@@ -0,0 +1,106 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AzureCapacityStatusName is the name of the AzureCapacityStatus kept up to date by Karpenter
+const AzureCapacityStatusName = "default"
+
+// AzureCapacityStatus exposes the offerings Karpenter currently considers unavailable, e.g. because of
+// allocation failures or quota errors returned by Azure. It is maintained by Karpenter and is read-only for users.
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=azurecapacitystatuses,scope=Cluster,categories=karpenter
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:subresource:status
+type AzureCapacityStatus struct {
+	metav1.TypeMeta `json:",inline"`
+	// metadata is standard object metadata.
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// status contains the offerings that are currently unavailable.
+	// +optional
+	Status AzureCapacityStatusStatus `json:"status,omitempty"`
+}
+
+// AzureCapacityStatusStatus contains the offerings that are currently unavailable
+type AzureCapacityStatusStatus struct {
+	// unavailableOfferings are the offerings that Karpenter will not launch until they expire.
+	// +optional
+	UnavailableOfferings []UnavailableOffering `json:"unavailableOfferings,omitempty"`
+	// unavailableVMFamilies are the VM families, or the larger VM sizes of VM families, that Karpenter will not
+	// launch until they expire. The VM sizes they block are not listed individually in unavailableOfferings.
+	// +optional
+	UnavailableVMFamilies []UnavailableVMFamily `json:"unavailableVMFamilies,omitempty"`
+}
+
+// UnavailableOffering is an instance type, zone and capacity type combination that is temporarily unavailable
+type UnavailableOffering struct {
+	// instanceType is the unavailable VM size. It is empty if the capacity type is unavailable for all VM sizes.
+	// +optional
+	InstanceType string `json:"instanceType,omitempty"`
+	// zone is the unavailable zone. It is empty if the capacity type is unavailable in all zones.
+	// +optional
+	Zone string `json:"zone,omitempty"`
+	// capacityType is the unavailable capacity type, either spot or on-demand.
+	// +required
+	CapacityType string `json:"capacityType"`
+	// reason is why the offering is unavailable, e.g. ZonalAllocationFailure or SubscriptionQuotaReached.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+	// errorCode is the Azure error code that caused the offering to be marked unavailable.
+	// +optional
+	ErrorCode string `json:"errorCode,omitempty"`
+	// markedTime is when the offering was last marked unavailable.
+	// +required
+	MarkedTime metav1.Time `json:"markedTime"`
+	// expirationTime is when the offering will be considered again.
+	// +required
+	ExpirationTime metav1.Time `json:"expirationTime"`
+}
+
+// UnavailableVMFamily is a VM family, zone and capacity type combination that is temporarily unavailable,
+// either for all VM sizes of the family or for the VM sizes with at least minCPU vCPUs
+type UnavailableVMFamily struct {
+	// family is the unavailable VM family, e.g. standardDSv3Family.
+	// +required
+	Family string `json:"family"`
+	// zone is the zone the VM family is unavailable in.
+	// +optional
+	Zone string `json:"zone,omitempty"`
+	// capacityType is the unavailable capacity type, either spot or on-demand.
+	// +required
+	CapacityType string `json:"capacityType"`
+	// minCPU is the vCPU count at or above which the VM sizes of the family are unavailable.
+	// It is omitted if all VM sizes of the family are unavailable.
+	// +optional
+	MinCPU int64 `json:"minCPU,omitempty"`
+	// expirationTime is when the VM family will be considered again.
+	// +required
+	ExpirationTime metav1.Time `json:"expirationTime"`
+}
+
+// AzureCapacityStatusList contains a list of AzureCapacityStatus
+// +kubebuilder:object:root=true
+type AzureCapacityStatusList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AzureCapacityStatus `json:"items"`
+}
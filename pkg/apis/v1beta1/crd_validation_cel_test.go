@@ -18,6 +18,7 @@ package v1beta1_test
 
 import (
 	"strings"
+	"time"
 
 	"github.com/Azure/karpenter-provider-azure/pkg/apis/v1beta1"
 	"github.com/Pallinder/go-randomdata"
@@ -125,6 +126,335 @@ var _ = Describe("CEL/Validation", func() {
 		)
 	})
 
+	Context("CapacityReservationGroupID", func() {
+		DescribeTable("Should only accept valid CapacityReservationGroupID", func(capacityReservationGroupID string, expected bool) {
+			nodeClass := &v1beta1.AKSNodeClass{
+				ObjectMeta: metav1.ObjectMeta{Name: strings.ToLower(randomdata.SillyName())},
+				Spec: v1beta1.AKSNodeClassSpec{
+					CapacityReservationGroupID: &capacityReservationGroupID,
+				},
+			}
+			if expected {
+				Expect(env.Client.Create(ctx, nodeClass)).To(Succeed())
+			} else {
+				Expect(env.Client.Create(ctx, nodeClass)).ToNot(Succeed())
+			}
+		},
+			Entry("valid CapacityReservationGroupID", "/subscriptions/12345678-1234-1234-1234-123456789012/resourceGroups/rgname/providers/Microsoft.Compute/capacityReservationGroups/crg", true),
+			Entry("should allow mixed casing", "/subscriptions/12345678-1234-1234-1234-123456789012/resourcegroups/rgName/providers/microsoft.compute/capacityreservationgroups/crgName", true),
+			Entry("invalid provider in path", "/subscriptions/12345678-1234-1234-1234-123456789012/resourceGroups/rgname/providers/Microsoft.Network/capacityReservationGroups/crg", false),
+			Entry("capacity reservation instead of group", "/subscriptions/12345678-1234-1234-1234-123456789012/resourceGroups/rgname/providers/Microsoft.Compute/capacityReservationGroups/crg/capacityReservations/cr", false),
+			Entry("name only", "crg", false),
+		)
+	})
+
+	Context("NetworkSecurityGroupID", func() {
+		DescribeTable("Should only accept valid NetworkSecurityGroupID", func(networkSecurityGroupID string, expected bool) {
+			nodeClass := &v1beta1.AKSNodeClass{
+				ObjectMeta: metav1.ObjectMeta{Name: strings.ToLower(randomdata.SillyName())},
+				Spec: v1beta1.AKSNodeClassSpec{
+					NetworkSecurityGroupID: &networkSecurityGroupID,
+				},
+			}
+			if expected {
+				Expect(env.Client.Create(ctx, nodeClass)).To(Succeed())
+			} else {
+				Expect(env.Client.Create(ctx, nodeClass)).ToNot(Succeed())
+			}
+		},
+			Entry("valid NetworkSecurityGroupID", "/subscriptions/12345678-1234-1234-1234-123456789012/resourceGroups/rgname/providers/Microsoft.Network/networkSecurityGroups/nsg", true),
+			Entry("should allow mixed casing", "/subscriptions/12345678-1234-1234-1234-123456789012/resourcegroups/rgName/providers/microsoft.network/networksecuritygroups/nsgName", true),
+			Entry("application security group instead of network security group", "/subscriptions/12345678-1234-1234-1234-123456789012/resourceGroups/rgname/providers/Microsoft.Network/applicationSecurityGroups/asg", false),
+			Entry("name only", "nsg", false),
+		)
+	})
+
+	Context("PublicIP", func() {
+		DescribeTable("Should only accept valid PublicIPPrefixID", func(publicIPPrefixID string, expected bool) {
+			nodeClass := &v1beta1.AKSNodeClass{
+				ObjectMeta: metav1.ObjectMeta{Name: strings.ToLower(randomdata.SillyName())},
+				Spec: v1beta1.AKSNodeClassSpec{
+					PublicIP: &v1beta1.PublicIPConfiguration{PublicIPPrefixID: &publicIPPrefixID},
+				},
+			}
+			if expected {
+				Expect(env.Client.Create(ctx, nodeClass)).To(Succeed())
+			} else {
+				Expect(env.Client.Create(ctx, nodeClass)).ToNot(Succeed())
+			}
+		},
+			Entry("valid PublicIPPrefixID", "/subscriptions/12345678-1234-1234-1234-123456789012/resourceGroups/rgname/providers/Microsoft.Network/publicIPPrefixes/prefix", true),
+			Entry("should allow mixed casing", "/subscriptions/12345678-1234-1234-1234-123456789012/resourcegroups/rgName/providers/microsoft.network/publicipprefixes/prefixName", true),
+			Entry("public IP instead of public IP prefix", "/subscriptions/12345678-1234-1234-1234-123456789012/resourceGroups/rgname/providers/Microsoft.Network/publicIPAddresses/pip", false),
+			Entry("name only", "prefix", false),
+		)
+
+		It("should default the SKU to Standard", func() {
+			nodeClass := &v1beta1.AKSNodeClass{
+				ObjectMeta: metav1.ObjectMeta{Name: strings.ToLower(randomdata.SillyName())},
+				Spec: v1beta1.AKSNodeClassSpec{
+					PublicIP: &v1beta1.PublicIPConfiguration{},
+				},
+			}
+			Expect(env.Client.Create(ctx, nodeClass)).To(Succeed())
+			Expect(nodeClass.Spec.PublicIP.SKU).To(Equal(lo.ToPtr(v1beta1.PublicIPSKUStandard)))
+		})
+
+		It("should reject an IP tag without a tag", func() {
+			nodeClass := &v1beta1.AKSNodeClass{
+				ObjectMeta: metav1.ObjectMeta{Name: strings.ToLower(randomdata.SillyName())},
+				Spec: v1beta1.AKSNodeClassSpec{
+					PublicIP: &v1beta1.PublicIPConfiguration{IPTags: []v1beta1.PublicIPTag{{Type: "RoutingPreference"}}},
+				},
+			}
+			Expect(env.Client.Create(ctx, nodeClass)).ToNot(Succeed())
+		})
+
+		It("should reject invalid tag keys", func() {
+			nodeClass := &v1beta1.AKSNodeClass{
+				ObjectMeta: metav1.ObjectMeta{Name: strings.ToLower(randomdata.SillyName())},
+				Spec: v1beta1.AKSNodeClassSpec{
+					PublicIP: &v1beta1.PublicIPConfiguration{Tags: map[string]string{"game<server": "true"}},
+				},
+			}
+			Expect(env.Client.Create(ctx, nodeClass)).ToNot(Succeed())
+		})
+	})
+
+	Context("SubnetSelectorTerms", func() {
+		const (
+			vnetID   = "/subscriptions/12345678-1234-1234-1234-123456789012/resourceGroups/rgname/providers/Microsoft.Network/virtualNetworks/vnet"
+			subnetID = vnetID + "/subnets/subnet"
+		)
+		DescribeTable("Should only accept valid SubnetSelectorTerms", func(term v1beta1.SubnetSelectorTerm, expected bool) {
+			nodeClass := &v1beta1.AKSNodeClass{
+				ObjectMeta: metav1.ObjectMeta{Name: strings.ToLower(randomdata.SillyName())},
+				Spec: v1beta1.AKSNodeClassSpec{
+					SubnetSelectorTerms: []v1beta1.SubnetSelectorTerm{term},
+				},
+			}
+			if expected {
+				Expect(env.Client.Create(ctx, nodeClass)).To(Succeed())
+			} else {
+				Expect(env.Client.Create(ctx, nodeClass)).ToNot(Succeed())
+			}
+		},
+			Entry("subnet ID", v1beta1.SubnetSelectorTerm{ID: lo.ToPtr(subnetID)}, true),
+			Entry("subnet ID with zones", v1beta1.SubnetSelectorTerm{ID: lo.ToPtr(subnetID), Zones: []string{"westus2-1"}}, true),
+			Entry("tags of the subnets of a VNet", v1beta1.SubnetSelectorTerm{VNETID: lo.ToPtr(vnetID), Tags: map[string]string{"karpenter": "*"}}, true),
+			Entry("VNet ID instead of subnet ID", v1beta1.SubnetSelectorTerm{ID: lo.ToPtr(vnetID)}, false),
+			Entry("tags without VNet ID", v1beta1.SubnetSelectorTerm{Tags: map[string]string{"karpenter": "*"}}, false),
+			Entry("VNet ID without tags", v1beta1.SubnetSelectorTerm{VNETID: lo.ToPtr(vnetID)}, false),
+			Entry("subnet ID and tags", v1beta1.SubnetSelectorTerm{ID: lo.ToPtr(subnetID), VNETID: lo.ToPtr(vnetID), Tags: map[string]string{"karpenter": "*"}}, false),
+			Entry("empty term", v1beta1.SubnetSelectorTerm{}, false),
+			Entry("zone without region", v1beta1.SubnetSelectorTerm{ID: lo.ToPtr(subnetID), Zones: []string{"1"}}, false),
+		)
+
+		It("should reject subnetSelectorTerms with vnetSubnetID", func() {
+			nodeClass := &v1beta1.AKSNodeClass{
+				ObjectMeta: metav1.ObjectMeta{Name: strings.ToLower(randomdata.SillyName())},
+				Spec: v1beta1.AKSNodeClassSpec{
+					VNETSubnetID:        lo.ToPtr(subnetID),
+					SubnetSelectorTerms: []v1beta1.SubnetSelectorTerm{{ID: lo.ToPtr(subnetID)}},
+				},
+			}
+			Expect(env.Client.Create(ctx, nodeClass)).ToNot(Succeed())
+		})
+	})
+
+	Context("ApplicationSecurityGroupIDs", func() {
+		DescribeTable("Should only accept valid ApplicationSecurityGroupIDs", func(applicationSecurityGroupIDs []string, expected bool) {
+			nodeClass := &v1beta1.AKSNodeClass{
+				ObjectMeta: metav1.ObjectMeta{Name: strings.ToLower(randomdata.SillyName())},
+				Spec: v1beta1.AKSNodeClassSpec{
+					ApplicationSecurityGroupIDs: applicationSecurityGroupIDs,
+				},
+			}
+			if expected {
+				Expect(env.Client.Create(ctx, nodeClass)).To(Succeed())
+			} else {
+				Expect(env.Client.Create(ctx, nodeClass)).ToNot(Succeed())
+			}
+		},
+			Entry("valid ApplicationSecurityGroupIDs", []string{
+				"/subscriptions/12345678-1234-1234-1234-123456789012/resourceGroups/rgname/providers/Microsoft.Network/applicationSecurityGroups/asg-1",
+				"/subscriptions/12345678-1234-1234-1234-123456789012/resourceGroups/rgname/providers/Microsoft.Network/applicationSecurityGroups/asg-2",
+			}, true),
+			Entry("duplicate ApplicationSecurityGroupIDs", []string{
+				"/subscriptions/12345678-1234-1234-1234-123456789012/resourceGroups/rgname/providers/Microsoft.Network/applicationSecurityGroups/asg",
+				"/subscriptions/12345678-1234-1234-1234-123456789012/resourceGroups/rgname/providers/Microsoft.Network/applicationSecurityGroups/asg",
+			}, false),
+			Entry("network security group instead of application security group", []string{
+				"/subscriptions/12345678-1234-1234-1234-123456789012/resourceGroups/rgname/providers/Microsoft.Network/networkSecurityGroups/nsg",
+			}, false),
+			Entry("name only", []string{"asg"}, false),
+		)
+	})
+
+	Context("Placement", func() {
+		DescribeTable("Should only accept valid Placement", func(placement v1beta1.Placement, expected bool) {
+			nodeClass := &v1beta1.AKSNodeClass{
+				ObjectMeta: metav1.ObjectMeta{Name: strings.ToLower(randomdata.SillyName())},
+				Spec: v1beta1.AKSNodeClassSpec{
+					Placement: &placement,
+				},
+			}
+			if expected {
+				Expect(env.Client.Create(ctx, nodeClass)).To(Succeed())
+			} else {
+				Expect(env.Client.Create(ctx, nodeClass)).ToNot(Succeed())
+			}
+		},
+			Entry("valid ProximityPlacementGroupID", v1beta1.Placement{ProximityPlacementGroupID: lo.ToPtr("/subscriptions/12345678-1234-1234-1234-123456789012/resourceGroups/rgname/providers/Microsoft.Compute/proximityPlacementGroups/ppg")}, true),
+			Entry("valid HostGroupID", v1beta1.Placement{HostGroupID: lo.ToPtr("/subscriptions/12345678-1234-1234-1234-123456789012/resourceGroups/rgname/providers/Microsoft.Compute/hostGroups/hg")}, true),
+			Entry("valid HostID", v1beta1.Placement{HostID: lo.ToPtr("/subscriptions/12345678-1234-1234-1234-123456789012/resourceGroups/rgname/providers/Microsoft.Compute/hostGroups/hg/hosts/host")}, true),
+			Entry("ProximityPlacementGroupID with HostGroupID", v1beta1.Placement{
+				ProximityPlacementGroupID: lo.ToPtr("/subscriptions/12345678-1234-1234-1234-123456789012/resourceGroups/rgname/providers/Microsoft.Compute/proximityPlacementGroups/ppg"),
+				HostGroupID:               lo.ToPtr("/subscriptions/12345678-1234-1234-1234-123456789012/resourceGroups/rgname/providers/Microsoft.Compute/hostGroups/hg"),
+			}, true),
+			Entry("HostID with HostGroupID", v1beta1.Placement{
+				HostGroupID: lo.ToPtr("/subscriptions/12345678-1234-1234-1234-123456789012/resourceGroups/rgname/providers/Microsoft.Compute/hostGroups/hg"),
+				HostID:      lo.ToPtr("/subscriptions/12345678-1234-1234-1234-123456789012/resourceGroups/rgname/providers/Microsoft.Compute/hostGroups/hg/hosts/host"),
+			}, false),
+			Entry("host group as HostID", v1beta1.Placement{HostID: lo.ToPtr("/subscriptions/12345678-1234-1234-1234-123456789012/resourceGroups/rgname/providers/Microsoft.Compute/hostGroups/hg")}, false),
+			Entry("invalid provider in ProximityPlacementGroupID", v1beta1.Placement{ProximityPlacementGroupID: lo.ToPtr("/subscriptions/12345678-1234-1234-1234-123456789012/resourceGroups/rgname/providers/Microsoft.Network/proximityPlacementGroups/ppg")}, false),
+		)
+	})
+
+	Context("Spot", func() {
+		DescribeTable("Should only accept valid Spot", func(spot v1beta1.Spot, expected bool) {
+			nodeClass := &v1beta1.AKSNodeClass{
+				ObjectMeta: metav1.ObjectMeta{Name: strings.ToLower(randomdata.SillyName())},
+				Spec: v1beta1.AKSNodeClassSpec{
+					Spot: &spot,
+				},
+			}
+			if expected {
+				Expect(env.Client.Create(ctx, nodeClass)).To(Succeed())
+			} else {
+				Expect(env.Client.Create(ctx, nodeClass)).ToNot(Succeed())
+			}
+		},
+			Entry("MaxPrice", v1beta1.Spot{MaxPrice: lo.ToPtr("0.12345")}, true),
+			Entry("integer MaxPrice", v1beta1.Spot{MaxPrice: lo.ToPtr("2")}, true),
+			Entry("zero MaxPrice", v1beta1.Spot{MaxPrice: lo.ToPtr("0.00000")}, false),
+			Entry("negative MaxPrice", v1beta1.Spot{MaxPrice: lo.ToPtr("-1")}, false),
+			Entry("MaxPrice with more than 5 decimal places", v1beta1.Spot{MaxPrice: lo.ToPtr("0.123456")}, false),
+			Entry("MaxPricePercentOfOnDemand", v1beta1.Spot{MaxPricePercentOfOnDemand: lo.ToPtr(int32(60))}, true),
+			Entry("MaxPricePercentOfOnDemand above 100", v1beta1.Spot{MaxPricePercentOfOnDemand: lo.ToPtr(int32(101))}, false),
+			Entry("zero MaxPricePercentOfOnDemand", v1beta1.Spot{MaxPricePercentOfOnDemand: lo.ToPtr(int32(0))}, false),
+			Entry("MaxPrice with MaxPricePercentOfOnDemand", v1beta1.Spot{MaxPrice: lo.ToPtr("0.5"), MaxPricePercentOfOnDemand: lo.ToPtr(int32(60))}, false),
+			Entry("Deallocate EvictionPolicy", v1beta1.Spot{EvictionPolicy: lo.ToPtr(v1beta1.SpotEvictionPolicyDeallocate)}, true),
+			Entry("unknown EvictionPolicy", v1beta1.Spot{EvictionPolicy: lo.ToPtr(v1beta1.SpotEvictionPolicy("Stop"))}, false),
+		)
+	})
+
+	Context("DataDisks", func() {
+		DescribeTable("Should only accept valid DataDisks", func(dataDisks []v1beta1.DataDisk, expected bool) {
+			nodeClass := &v1beta1.AKSNodeClass{
+				ObjectMeta: metav1.ObjectMeta{Name: strings.ToLower(randomdata.SillyName())},
+				Spec: v1beta1.AKSNodeClassSpec{
+					DataDisks: dataDisks,
+				},
+			}
+			if expected {
+				Expect(env.Client.Create(ctx, nodeClass)).To(Succeed())
+			} else {
+				Expect(env.Client.Create(ctx, nodeClass)).ToNot(Succeed())
+			}
+		},
+			Entry("valid disk", []v1beta1.DataDisk{{SizeGB: 128, MountPath: "/data"}}, true),
+			Entry("valid disks", []v1beta1.DataDisk{{SizeGB: 128, MountPath: "/data"}, {SizeGB: 256, MountPath: "/mnt/cache"}}, true),
+			Entry("valid PremiumV2_LRS disk with performance", []v1beta1.DataDisk{{SizeGB: 128, SKU: lo.ToPtr(v1beta1.DataDiskSKUPremiumV2LRS), DiskIOPSReadWrite: lo.ToPtr(int64(5000)), DiskMBpsReadWrite: lo.ToPtr(int64(200)), MountPath: "/data"}}, true),
+			Entry("duplicate mountPath", []v1beta1.DataDisk{{SizeGB: 128, MountPath: "/data"}, {SizeGB: 256, MountPath: "/data"}}, false),
+			Entry("zero size", []v1beta1.DataDisk{{SizeGB: 0, MountPath: "/data"}}, false),
+			Entry("relative mountPath", []v1beta1.DataDisk{{SizeGB: 128, MountPath: "data"}}, false),
+			Entry("trailing slash in mountPath", []v1beta1.DataDisk{{SizeGB: 128, MountPath: "/data/"}}, false),
+			Entry("system mountPath", []v1beta1.DataDisk{{SizeGB: 128, MountPath: "/var/lib/kubelet"}}, false),
+			Entry("performance on Premium_LRS disk", []v1beta1.DataDisk{{SizeGB: 128, DiskIOPSReadWrite: lo.ToPtr(int64(5000)), MountPath: "/data"}}, false),
+			Entry("caching on UltraSSD_LRS disk", []v1beta1.DataDisk{{SizeGB: 128, SKU: lo.ToPtr(v1beta1.DataDiskSKUUltraSSDLRS), Caching: lo.ToPtr(v1beta1.DataDiskCachingReadOnly), MountPath: "/data"}}, false),
+		)
+		It("should reject data disks for Windows", func() {
+			nodeClass := &v1beta1.AKSNodeClass{
+				ObjectMeta: metav1.ObjectMeta{Name: strings.ToLower(randomdata.SillyName())},
+				Spec: v1beta1.AKSNodeClassSpec{
+					ImageFamily: lo.ToPtr(v1beta1.Windows2022ImageFamily),
+					DataDisks:   []v1beta1.DataDisk{{SizeGB: 128, MountPath: "/data"}},
+				},
+			}
+			Expect(env.Client.Create(ctx, nodeClass)).ToNot(Succeed())
+		})
+	})
+
+	Context("UserData", func() {
+		DescribeTable("Should only accept valid UserData", func(userData *v1beta1.UserData, expected bool) {
+			nodeClass := &v1beta1.AKSNodeClass{
+				ObjectMeta: metav1.ObjectMeta{Name: strings.ToLower(randomdata.SillyName())},
+				Spec: v1beta1.AKSNodeClassSpec{
+					UserData: userData,
+				},
+			}
+			if expected {
+				Expect(env.Client.Create(ctx, nodeClass)).To(Succeed())
+			} else {
+				Expect(env.Client.Create(ctx, nodeClass)).ToNot(Succeed())
+			}
+		},
+			Entry("scripts", &v1beta1.UserData{PreBootstrapScript: lo.ToPtr("echo pre"), PostBootstrapScript: lo.ToPtr("echo post")}, true),
+			Entry("cloud-config", &v1beta1.UserData{CloudConfig: lo.ToPtr("#cloud-config\npackages:\n- jq\n")}, true),
+			Entry("cloud-config without header", &v1beta1.UserData{CloudConfig: lo.ToPtr("packages:\n- jq\n")}, false),
+			Entry("empty script", &v1beta1.UserData{PreBootstrapScript: lo.ToPtr("")}, false),
+			Entry("combined size too large", &v1beta1.UserData{PreBootstrapScript: lo.ToPtr(strings.Repeat("a", 20000)), PostBootstrapScript: lo.ToPtr(strings.Repeat("a", 20000))}, false),
+		)
+		It("should reject user data for Windows", func() {
+			nodeClass := &v1beta1.AKSNodeClass{
+				ObjectMeta: metav1.ObjectMeta{Name: strings.ToLower(randomdata.SillyName())},
+				Spec: v1beta1.AKSNodeClassSpec{
+					ImageFamily: lo.ToPtr(v1beta1.Windows2022ImageFamily),
+					UserData:    &v1beta1.UserData{PreBootstrapScript: lo.ToPtr("echo pre")},
+				},
+			}
+			Expect(env.Client.Create(ctx, nodeClass)).ToNot(Succeed())
+		})
+	})
+
+	Context("Kubelet reservations", func() {
+		DescribeTable("Should only accept valid reserved resources and eviction thresholds", func(kubelet *v1beta1.KubeletConfiguration, expected bool) {
+			nodeClass := &v1beta1.AKSNodeClass{
+				ObjectMeta: metav1.ObjectMeta{Name: strings.ToLower(randomdata.SillyName())},
+				Spec: v1beta1.AKSNodeClassSpec{
+					Kubelet: kubelet,
+				},
+			}
+			if expected {
+				Expect(env.Client.Create(ctx, nodeClass)).To(Succeed())
+			} else {
+				Expect(env.Client.Create(ctx, nodeClass)).ToNot(Succeed())
+			}
+		},
+			Entry("kubeReserved", &v1beta1.KubeletConfiguration{KubeReserved: map[string]string{"cpu": "200m", "memory": "1Gi", "ephemeral-storage": "1G", "pid": "1000"}}, true),
+			Entry("kubeReserved with an invalid key", &v1beta1.KubeletConfiguration{KubeReserved: map[string]string{"gpu": "1"}}, false),
+			Entry("kubeReserved with a negative quantity", &v1beta1.KubeletConfiguration{KubeReserved: map[string]string{"cpu": "-1"}}, false),
+			Entry("systemReserved", &v1beta1.KubeletConfiguration{SystemReserved: map[string]string{"cpu": "0.5", "memory": "500Mi"}}, true),
+			Entry("systemReserved with a percentage", &v1beta1.KubeletConfiguration{SystemReserved: map[string]string{"memory": "10%"}}, false),
+			Entry("evictionHard", &v1beta1.KubeletConfiguration{EvictionHard: map[string]string{"memory.available": "500Mi", "nodefs.available": "10%"}}, true),
+			Entry("evictionHard with an invalid key", &v1beta1.KubeletConfiguration{EvictionHard: map[string]string{"memory": "500Mi"}}, false),
+			Entry("evictionHard with an invalid value", &v1beta1.KubeletConfiguration{EvictionHard: map[string]string{"memory.available": "lots"}}, false),
+			Entry("evictionSoft with grace periods", &v1beta1.KubeletConfiguration{
+				EvictionSoft:              map[string]string{"memory.available": "1Gi"},
+				EvictionSoftGracePeriod:   map[string]metav1.Duration{"memory.available": {Duration: time.Minute}},
+				EvictionMaxPodGracePeriod: lo.ToPtr[int32](30),
+			}, true),
+			Entry("evictionSoft without grace period", &v1beta1.KubeletConfiguration{EvictionSoft: map[string]string{"memory.available": "1Gi"}}, false),
+			Entry("evictionSoft without a matching grace period", &v1beta1.KubeletConfiguration{
+				EvictionSoft:            map[string]string{"memory.available": "1Gi"},
+				EvictionSoftGracePeriod: map[string]metav1.Duration{"nodefs.available": {Duration: time.Minute}},
+			}, false),
+			Entry("evictionSoftGracePeriod without evictionSoft", &v1beta1.KubeletConfiguration{EvictionSoftGracePeriod: map[string]metav1.Duration{"memory.available": {Duration: time.Minute}}}, false),
+			Entry("negative evictionMaxPodGracePeriod", &v1beta1.KubeletConfiguration{EvictionMaxPodGracePeriod: lo.ToPtr[int32](-1)}, false),
+		)
+	})
+
 	Context("ImageFamily", func() {
 		It("should reject invalid ImageFamily", func() {
 			invalidImageFamily := "123"
@@ -726,6 +1056,54 @@ var _ = Describe("CEL/Validation", func() {
 		)
 	})
 
+	Context("AllocationStrategy", func() {
+		It("should accept the prioritized allocation strategy with priorities", func() {
+			nodeClass := &v1beta1.AKSNodeClass{
+				ObjectMeta: metav1.ObjectMeta{Name: strings.ToLower(randomdata.SillyName())},
+				Spec: v1beta1.AKSNodeClassSpec{
+					AllocationStrategy: &v1beta1.AllocationStrategy{
+						Type:       lo.ToPtr(v1beta1.AllocationStrategyPrioritized),
+						Priorities: []string{"Standard_D2s_v5", "Standard_D2s_v3"},
+					},
+				},
+			}
+			Expect(env.Client.Create(ctx, nodeClass)).To(Succeed())
+		})
+		It("should default the allocation strategy type to lowest-price", func() {
+			nodeClass := &v1beta1.AKSNodeClass{
+				ObjectMeta: metav1.ObjectMeta{Name: strings.ToLower(randomdata.SillyName())},
+				Spec: v1beta1.AKSNodeClassSpec{
+					AllocationStrategy: &v1beta1.AllocationStrategy{},
+				},
+			}
+			Expect(env.Client.Create(ctx, nodeClass)).To(Succeed())
+			Expect(nodeClass.Spec.AllocationStrategy.Type).To(Equal(lo.ToPtr(v1beta1.AllocationStrategyLowestPrice)))
+		})
+		It("should reject priorities with an allocation strategy other than prioritized", func() {
+			nodeClass := &v1beta1.AKSNodeClass{
+				ObjectMeta: metav1.ObjectMeta{Name: strings.ToLower(randomdata.SillyName())},
+				Spec: v1beta1.AKSNodeClassSpec{
+					AllocationStrategy: &v1beta1.AllocationStrategy{
+						Type:       lo.ToPtr(v1beta1.AllocationStrategyCapacityOptimized),
+						Priorities: []string{"Standard_D2s_v5"},
+					},
+				},
+			}
+			Expect(env.Client.Create(ctx, nodeClass)).ToNot(Succeed())
+		})
+		It("should reject an unknown allocation strategy type", func() {
+			nodeClass := &v1beta1.AKSNodeClass{
+				ObjectMeta: metav1.ObjectMeta{Name: strings.ToLower(randomdata.SillyName())},
+				Spec: v1beta1.AKSNodeClassSpec{
+					AllocationStrategy: &v1beta1.AllocationStrategy{
+						Type: lo.ToPtr(v1beta1.AllocationStrategyType("most-expensive")),
+					},
+				},
+			}
+			Expect(env.Client.Create(ctx, nodeClass)).ToNot(Succeed())
+		})
+	})
+
 	Context("GPU", func() {
 		It("should accept gpu.mode set to Driver", func() {
 			gpuMode := v1beta1.GPUModeDriver
@@ -33,5 +33,7 @@ func init() {
 	scheme.Scheme.AddKnownTypes(gv,
 		&AKSNodeClass{},
 		&AKSNodeClassList{},
+		&AzureCapacityStatus{},
+		&AzureCapacityStatusList{},
 	)
 }
@@ -19,6 +19,7 @@ package v1beta1
 import (
 	"strings"
 
+	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
 	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/scheduling"
@@ -183,6 +184,11 @@ var (
 	AnnotationAKSNodeClassHash        = apis.Group + "/aksnodeclass-hash"
 	AnnotationAKSNodeClassHashVersion = apis.Group + "/aksnodeclass-hash-version"
 	AnnotationAKSMachineResourceID    = apis.Group + "/aks-machine-resource-id" // resource ID of the associated AKS machine
+	AnnotationHourlyPrice             = apis.Group + "/hourly-price"            // estimated hourly price of the instance when it was created, in USD
+
+	// Set on a NodePool to override the allocation strategy of its AKSNodeClass
+	AnnotationAllocationStrategy           = apis.Group + "/allocation-strategy"            // one of the AllocationStrategyType values
+	AnnotationAllocationStrategyPriorities = apis.Group + "/allocation-strategy-priorities" // comma separated VM sizes, most preferred first
 )
 
 const (
@@ -195,11 +201,16 @@ const (
 	Ubuntu2204ImageFamily = "Ubuntu2204"
 	Ubuntu2404ImageFamily = "Ubuntu2404"
 	AzureLinuxImageFamily = "AzureLinux"
+
+	Windows2022ImageFamily = "Windows2022"
+	Windows2025ImageFamily = "Windows2025"
 )
 
 const (
-	OSSKUUbuntu     = "Ubuntu"
-	OSSKUAzureLinux = "AzureLinux"
+	OSSKUUbuntu      = "Ubuntu"
+	OSSKUAzureLinux  = "AzureLinux"
+	OSSKUWindows2022 = "Windows2022"
+	OSSKUWindows2025 = "Windows2025"
 )
 
 const (
@@ -218,6 +229,11 @@ var UbuntuFamilies = sets.New(
 	Ubuntu2404ImageFamily,
 )
 
+var WindowsFamilies = sets.New(
+	Windows2022ImageFamily,
+	Windows2025ImageFamily,
+)
+
 // imageFamilyToOSSKU maps imageFamily spec values to os-sku label values.
 // These values match what AKS writes for kubernetes.azure.com/os-sku.
 var imageFamilyToOSSKU = map[string]string{
@@ -225,6 +241,16 @@ var imageFamilyToOSSKU = map[string]string{
 	Ubuntu2204ImageFamily: OSSKUUbuntu,
 	Ubuntu2404ImageFamily: OSSKUUbuntu,
 	AzureLinuxImageFamily: OSSKUAzureLinux,
+
+	Windows2022ImageFamily: OSSKUWindows2022,
+	Windows2025ImageFamily: OSSKUWindows2025,
+}
+
+// imageFamilyToWindowsBuild maps Windows imageFamily spec values to node.kubernetes.io/windows-build label values,
+// which kubelet sets from the major, minor and build number of the OS.
+var imageFamilyToWindowsBuild = map[string]string{
+	Windows2022ImageFamily: "10.0.20348",
+	Windows2025ImageFamily: "10.0.26100",
 }
 
 // GetOSSKUFromImageFamily returns the kuberentes.azure.com/os-sku label value for the given imageFamily.
@@ -240,6 +266,25 @@ func GetOSSKUFromImageFamily(imageFamily string) string {
 	return imageFamily // fallback for unknown image families
 }
 
+// IsWindowsImageFamily returns whether the given imageFamily provisions Windows nodes.
+func IsWindowsImageFamily(imageFamily string) bool {
+	return WindowsFamilies.Has(imageFamily)
+}
+
+// GetOSFromImageFamily returns the kubernetes.io/os label value for the given imageFamily.
+func GetOSFromImageFamily(imageFamily string) string {
+	if IsWindowsImageFamily(imageFamily) {
+		return string(v1.Windows)
+	}
+	return string(v1.Linux)
+}
+
+// GetWindowsBuildFromImageFamily returns the node.kubernetes.io/windows-build label value for the given imageFamily,
+// or an empty string if the imageFamily is not a Windows one.
+func GetWindowsBuildFromImageFamily(imageFamily string) string {
+	return imageFamilyToWindowsBuild[imageFamily]
+}
+
 func IsAKSLabel(label string) bool {
 	return strings.HasPrefix(label, AKSLabelDomain+"/") || aksLegacyLabels.Has(label)
 }
@@ -130,6 +130,14 @@ func EnvironmentFromName(cloudName string) (*Environment, error) {
 	}, nil
 }
 
+// Name returns the name of the Azure environment (e.g. AzurePublicCloud, AzureUSGovernmentCloud), as expected by node provisioning.
+func (e *Environment) Name() string {
+	if e == nil || e.Environment == nil || e.Environment.Name == "" {
+		return "AzurePublicCloud"
+	}
+	return e.Environment.Name
+}
+
 // IsPublic returns if the specified configuration is public.
 // This takes the track2 format rather than being a method on Environment because
 // usage in api/sdk contexts use the track2 format and may not have access to the
@@ -25,6 +25,17 @@ const (
 	// UnavailableOfferingsTTL is the time before offerings that were marked as unavailable
 	// are removed from the cache and are available for launch again
 	UnavailableOfferingsTTL = 3 * time.Minute
+	// RecentlyUnavailableOfferingsTTL is the time for which offerings that were marked as unavailable
+	// are still remembered once available again, so that allocation can steer away from them
+	RecentlyUnavailableOfferingsTTL = 30 * time.Minute
+	// SpotEvictionsWindow is the sliding window over which spot evictions are counted
+	// to compute the eviction rate of spot offerings
+	SpotEvictionsWindow = 6 * time.Hour
+	// SpotPriceHistoryWindow is the sliding window over which the spot prices are kept, to compute their volatility and trend
+	SpotPriceHistoryWindow = 14 * 24 * time.Hour
+	// FullSubnetsTTL is the time for which subnets that ran out of IPs are tried last when launching instances.
+	// NRP holds on to the IPs of deleted NICs for 180 seconds, so a full subnet doesn't free up sooner.
+	FullSubnetsTTL = 3 * time.Minute
 
 	// DefaultCleanupInterval triggers cache cleanup (lazy eviction) at this interval.
 	DefaultCleanupInterval = 1 * time.Minute
@@ -0,0 +1,98 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"maps"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// MemoryOverheads tracks the VM memory overhead learned per instance type, i.e. the fraction of the advertised memory
+// of the SKU that isn't reported in the capacity of its nodes. It replaces the flat VMMemoryOverheadPercent for the
+// instance types it knows of.
+type MemoryOverheads struct {
+	mu sync.RWMutex
+	// key: instance type (lowercase), value: overhead as a fraction of the SKU memory
+	overheads map[string]float64
+	// seqNum is updated on any change to the learned overheads
+	seqNum atomic.Uint64
+}
+
+func NewMemoryOverheads() *MemoryOverheads {
+	return &MemoryOverheads{
+		overheads: map[string]float64{},
+	}
+}
+
+func (m *MemoryOverheads) SeqNum() uint64 {
+	return m.seqNum.Load()
+}
+
+// Get returns the memory overhead learned for the instance type, and false if there is none
+func (m *MemoryOverheads) Get(instanceType string) (float64, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	overhead, ok := m.overheads[strings.ToLower(instanceType)]
+	return overhead, ok
+}
+
+// Set records the memory overhead of the instance type. It returns true if the overhead changed.
+func (m *MemoryOverheads) Set(instanceType string, overhead float64) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := strings.ToLower(instanceType)
+	if current, ok := m.overheads[key]; ok && current == overhead {
+		return false
+	}
+	m.overheads[key] = overhead
+	m.seqNum.Add(1)
+	return true
+}
+
+// Snapshot returns the learned overheads, keyed by instance type (lowercase)
+func (m *MemoryOverheads) Snapshot() map[string]float64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return maps.Clone(m.overheads)
+}
+
+// Restore records the overheads of a snapshot, for the instance types that have none yet
+func (m *MemoryOverheads) Restore(snapshot map[string]float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	changed := false
+	for instanceType, overhead := range snapshot {
+		key := strings.ToLower(instanceType)
+		if _, ok := m.overheads[key]; ok {
+			continue
+		}
+		m.overheads[key] = overhead
+		changed = true
+	}
+	if changed {
+		m.seqNum.Add(1)
+	}
+}
+
+func (m *MemoryOverheads) Flush() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.overheads = map[string]float64{}
+	m.seqNum.Add(1)
+}
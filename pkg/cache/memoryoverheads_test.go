@@ -0,0 +1,67 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"testing"
+)
+
+func TestMemoryOverheads(t *testing.T) {
+	m := NewMemoryOverheads()
+
+	if _, ok := m.Get("Standard_D2s_v3"); ok {
+		t.Errorf("expected no overhead initially")
+	}
+
+	seqNum := m.SeqNum()
+	if !m.Set("Standard_D2s_v3", 0.05) {
+		t.Errorf("expected the overhead to change")
+	}
+	// instance types are matched case-insensitively
+	if overhead, ok := m.Get("standard_d2s_v3"); !ok || overhead != 0.05 {
+		t.Errorf("expected overhead 0.05, got %v (found: %v)", overhead, ok)
+	}
+	if m.SeqNum() == seqNum {
+		t.Errorf("expected the sequence number to change")
+	}
+
+	// setting the same overhead again is not a change
+	seqNum = m.SeqNum()
+	if m.Set("Standard_D2s_v3", 0.05) {
+		t.Errorf("expected the overhead not to change")
+	}
+	if m.SeqNum() != seqNum {
+		t.Errorf("expected the sequence number not to change")
+	}
+
+	// restoring doesn't override learned overheads
+	m.Restore(map[string]float64{"standard_d2s_v3": 0.1, "standard_d4s_v3": 0.04})
+	if overhead, _ := m.Get("Standard_D2s_v3"); overhead != 0.05 {
+		t.Errorf("expected overhead 0.05, got %v", overhead)
+	}
+	if overhead, _ := m.Get("Standard_D4s_v3"); overhead != 0.04 {
+		t.Errorf("expected restored overhead 0.04, got %v", overhead)
+	}
+	if snapshot := m.Snapshot(); len(snapshot) != 2 {
+		t.Errorf("expected 2 overheads in the snapshot, got %v", snapshot)
+	}
+
+	m.Flush()
+	if _, ok := m.Get("Standard_D2s_v3"); ok {
+		t.Errorf("expected no overhead after flush")
+	}
+}
@@ -0,0 +1,106 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	metrics "github.com/Azure/karpenter-provider-azure/pkg/metrics"
+)
+
+const (
+	spotSubsystem = "spot"
+)
+
+var (
+	// SpotEvictionsTotal tracks the spot evictions observed per instance type and zone.
+	//
+	// STABILITY: ALPHA - This metric may change or be removed without notice.
+	SpotEvictionsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: spotSubsystem,
+			Name:      "evictions_total",
+			Help:      "Total number of spot evictions observed, by instance type and zone.",
+		},
+		[]string{metrics.SizeLabel, metrics.ZoneLabel},
+	)
+
+	// SpotEvictionRate tracks the spot eviction rate per instance type and zone, as used to rank spot offerings.
+	//
+	// STABILITY: ALPHA - This metric may change or be removed without notice.
+	SpotEvictionRate = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: spotSubsystem,
+			Name:      "eviction_rate_per_hour",
+			Help:      "Spot evictions per hour observed over the eviction window, by instance type and zone.",
+		},
+		[]string{metrics.SizeLabel, metrics.ZoneLabel},
+	)
+
+	// SpotPriceRatio tracks the latest spot price per instance type, as a fraction of its on-demand price, only when rising
+	// spot prices are avoided.
+	//
+	// STABILITY: ALPHA - This metric may change or be removed without notice.
+	SpotPriceRatio = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: spotSubsystem,
+			Name:      "price_to_on_demand_ratio",
+			Help:      "Latest spot price as a fraction of the on-demand price, by instance type.",
+		},
+		[]string{metrics.SizeLabel},
+	)
+
+	// SpotPriceVolatility tracks the volatility of the spot price per instance type, only when rising spot prices are avoided.
+	//
+	// STABILITY: ALPHA - This metric may change or be removed without notice.
+	SpotPriceVolatility = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: spotSubsystem,
+			Name:      "price_volatility",
+			Help:      "Standard deviation of the spot price as a fraction of the on-demand price over the spot price history window, by instance type.",
+		},
+		[]string{metrics.SizeLabel},
+	)
+
+	// SpotPriceTrend tracks the trend of the spot price per instance type, only when rising spot prices are avoided.
+	//
+	// STABILITY: ALPHA - This metric may change or be removed without notice.
+	SpotPriceTrend = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: spotSubsystem,
+			Name:      "price_trend_per_day",
+			Help:      "Change per day of the spot price as a fraction of the on-demand price, fitted over the spot price history window, by instance type.",
+		},
+		[]string{metrics.SizeLabel},
+	)
+)
+
+func init() {
+	crmetrics.Registry.MustRegister(
+		SpotEvictionsTotal,
+		SpotEvictionRate,
+		SpotPriceRatio,
+		SpotPriceVolatility,
+		SpotPriceTrend,
+	)
+}
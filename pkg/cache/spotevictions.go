@@ -0,0 +1,138 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/utils/clock"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/Azure/karpenter-provider-azure/pkg/logging"
+)
+
+// SpotEvictions tracks the spot evictions (preemptions) observed per instance type and zone over
+// a sliding window of SpotEvictionsWindow, so that allocation can steer away from offerings that
+// are frequently evicted.
+type SpotEvictions struct {
+	mu  sync.Mutex
+	clk clock.Clock
+	// key: <instanceType>:<zone> (lowercase)
+	evictions map[string]*spotEvictionEntry
+}
+
+type spotEvictionEntry struct {
+	// instanceType and zone as first observed, used as the metric labels
+	instanceType string
+	zone         string
+	// eviction time keyed by a unique ID of the evicted instance, so that an eviction is only counted once,
+	// however many times it is observed
+	evictedAt map[string]time.Time
+}
+
+func NewSpotEvictions(clk clock.Clock) *SpotEvictions {
+	return &SpotEvictions{
+		clk:       clk,
+		evictions: map[string]*spotEvictionEntry{},
+	}
+}
+
+// MarkEvicted records the eviction of the spot instance identified by id, of the given instance type and zone
+func (s *SpotEvictions) MarkEvicted(ctx context.Context, id, instanceType, zone string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := spotEvictionKey(instanceType, zone)
+	entry, ok := s.evictions[key]
+	if !ok {
+		entry = &spotEvictionEntry{instanceType: instanceType, zone: zone, evictedAt: map[string]time.Time{}}
+		s.evictions[key] = entry
+	}
+	if _, ok := entry.evictedAt[id]; ok {
+		return
+	}
+	entry.evictedAt[id] = s.clk.Now()
+	SpotEvictionsTotal.WithLabelValues(instanceType, zone).Inc()
+
+	// refresh every key, so that the eviction rate of offerings that are no longer ranked doesn't go stale
+	s.refresh()
+	rate := s.evictionRate(key)
+	log.FromContext(ctx).V(1).Info("recorded spot eviction",
+		logging.InstanceType, instanceType,
+		"zone", zone,
+		"evictions-per-hour", rate,
+	)
+}
+
+// EvictionRate returns the number of evictions per hour observed for the instance type and zone,
+// averaged over SpotEvictionsWindow
+func (s *SpotEvictions) EvictionRate(instanceType, zone string) float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.evictionRate(spotEvictionKey(instanceType, zone))
+}
+
+// Refresh prunes the evictions that are out of the window for every instance type and zone, and refreshes
+// their eviction rate metric. It is called periodically, so that the metric decays even when nothing is evicted.
+func (s *SpotEvictions) Refresh() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refresh()
+}
+
+func (s *SpotEvictions) refresh() {
+	for key := range s.evictions {
+		s.evictionRate(key)
+	}
+}
+
+// evictionRate prunes evictions that are out of the window, and refreshes the eviction rate metric
+func (s *SpotEvictions) evictionRate(key string) float64 {
+	entry, ok := s.evictions[key]
+	if !ok {
+		return 0
+	}
+	cutoff := s.clk.Now().Add(-SpotEvictionsWindow)
+	for id, evictedAt := range entry.evictedAt {
+		if evictedAt.Before(cutoff) {
+			delete(entry.evictedAt, id)
+		}
+	}
+	if len(entry.evictedAt) == 0 {
+		delete(s.evictions, key)
+		SpotEvictionRate.DeleteLabelValues(entry.instanceType, entry.zone)
+		return 0
+	}
+	rate := float64(len(entry.evictedAt)) / SpotEvictionsWindow.Hours()
+	SpotEvictionRate.WithLabelValues(entry.instanceType, entry.zone).Set(rate)
+	return rate
+}
+
+func (s *SpotEvictions) Flush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.evictions = map[string]*spotEvictionEntry{}
+	SpotEvictionRate.Reset()
+}
+
+func spotEvictionKey(instanceType, zone string) string {
+	return strings.ToLower(fmt.Sprintf("%s:%s", instanceType, zone))
+}
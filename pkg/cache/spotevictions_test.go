@@ -0,0 +1,100 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	clock "k8s.io/utils/clock/testing"
+)
+
+func TestSpotEvictions(t *testing.T) {
+	clk := clock.NewFakeClock(time.Now())
+	s := NewSpotEvictions(clk)
+	ctx := context.TODO()
+
+	if rate := s.EvictionRate("Standard_D2s_v3", "westus-1"); rate != 0 {
+		t.Errorf("expected no evictions initially, got rate %v", rate)
+	}
+
+	s.MarkEvicted(ctx, "nodeclaim-a", "Standard_D2s_v3", "westus-1")
+	s.MarkEvicted(ctx, "nodeclaim-b", "Standard_D2s_v3", "westus-1")
+	// the same eviction observed again is only counted once
+	s.MarkEvicted(ctx, "nodeclaim-b", "Standard_D2s_v3", "westus-1")
+	s.MarkEvicted(ctx, "nodeclaim-c", "Standard_D2s_v3", "westus-2")
+
+	if rate, expected := s.EvictionRate("Standard_D2s_v3", "westus-1"), 2/SpotEvictionsWindow.Hours(); rate != expected {
+		t.Errorf("expected rate %v, got %v", expected, rate)
+	}
+	// instance type and zone are matched case-insensitively
+	if rate, expected := s.EvictionRate("standard_d2s_v3", "westus-2"), 1/SpotEvictionsWindow.Hours(); rate != expected {
+		t.Errorf("expected rate %v, got %v", expected, rate)
+	}
+	if rate := s.EvictionRate("Standard_D4s_v3", "westus-1"); rate != 0 {
+		t.Errorf("expected no evictions for another instance type, got rate %v", rate)
+	}
+	if value := testutil.ToFloat64(SpotEvictionRate.WithLabelValues("Standard_D2s_v3", "westus-1")); value != 2/SpotEvictionsWindow.Hours() {
+		t.Errorf("expected eviction rate metric %v, got %v", 2/SpotEvictionsWindow.Hours(), value)
+	}
+
+	// evictions that are out of the window are no longer counted
+	clk.Step(SpotEvictionsWindow / 2)
+	s.MarkEvicted(ctx, "nodeclaim-d", "Standard_D2s_v3", "westus-1")
+	clk.Step(SpotEvictionsWindow/2 + time.Second)
+	if rate, expected := s.EvictionRate("Standard_D2s_v3", "westus-1"), 1/SpotEvictionsWindow.Hours(); rate != expected {
+		t.Errorf("expected rate %v, got %v", expected, rate)
+	}
+	if rate := s.EvictionRate("Standard_D2s_v3", "westus-2"); rate != 0 {
+		t.Errorf("expected evictions to expire, got rate %v", rate)
+	}
+
+	s.Flush()
+	if rate := s.EvictionRate("Standard_D2s_v3", "westus-1"); rate != 0 {
+		t.Errorf("expected no evictions after flush, got rate %v", rate)
+	}
+}
+
+func TestSpotEvictionsRefresh(t *testing.T) {
+	clk := clock.NewFakeClock(time.Now())
+	s := NewSpotEvictions(clk)
+	ctx := context.TODO()
+	SpotEvictionRate.Reset()
+
+	s.MarkEvicted(ctx, "nodeclaim-a", "Standard_D2s_v3", "westus-1")
+	clk.Step(SpotEvictionsWindow / 2)
+	s.MarkEvicted(ctx, "nodeclaim-b", "Standard_D4s_v3", "westus-2")
+	if count := testutil.CollectAndCount(SpotEvictionRate); count != 2 {
+		t.Errorf("expected 2 eviction rate series, got %d", count)
+	}
+
+	// an eviction of another offering prunes the expired evictions of every offering
+	clk.Step(SpotEvictionsWindow/2 + time.Second)
+	s.MarkEvicted(ctx, "nodeclaim-c", "Standard_D8s_v3", "westus-3")
+	if count := testutil.CollectAndCount(SpotEvictionRate); count != 2 {
+		t.Errorf("expected the expired eviction rate series to be removed, got %d series", count)
+	}
+
+	// a refresh prunes expired evictions, without the offerings being ranked
+	clk.Step(SpotEvictionsWindow)
+	s.Refresh()
+	if count := testutil.CollectAndCount(SpotEvictionRate); count != 0 {
+		t.Errorf("expected all eviction rate series to be removed, got %d series", count)
+	}
+}
@@ -0,0 +1,278 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// SpotPriceHistoryMaxSamples bounds the number of samples kept per instance type, i.e. 15 days of pricing updates
+	SpotPriceHistoryMaxSamples = 30
+	// SpotPriceHistoryMaxTotalSamples bounds the number of samples kept across instance types, by keeping fewer samples per
+	// instance type when there are many, so that the persisted history stays well under the 1MiB limit of ConfigMaps at
+	// about 20 bytes per sample. The 2 samples a trend needs are always kept.
+	SpotPriceHistoryMaxTotalSamples = 20000
+	// SpotPriceTrendHorizon is how far ahead the trend of a spot price is projected to decide whether it is rising toward
+	// the on-demand price
+	SpotPriceTrendHorizon = 7 * 24 * time.Hour
+	// SpotPriceRisingRatio is the fraction of the on-demand price that a spot price projected over SpotPriceTrendHorizon
+	// has to reach to be considered rising toward the on-demand price
+	SpotPriceRisingRatio = 0.8
+)
+
+// SpotPriceSample is the spot price of an instance type at a point in time, as a fraction of its on-demand price.
+// It is serialized as a [unix time, ratio] pair to keep the persisted history small.
+type SpotPriceSample struct {
+	Time  time.Time
+	Ratio float64
+}
+
+func (s SpotPriceSample) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]any{s.Time.Unix(), s.Ratio})
+}
+
+func (s *SpotPriceSample) UnmarshalJSON(data []byte) error {
+	var pair []float64
+	if err := json.Unmarshal(data, &pair); err != nil {
+		return err
+	}
+	if len(pair) != 2 {
+		return fmt.Errorf("expected a [time, ratio] pair, got %s", data)
+	}
+	s.Time = time.Unix(int64(pair[0]), 0)
+	s.Ratio = pair[1]
+	return nil
+}
+
+// SpotPriceHistory keeps a bounded rolling history of the spot price of each instance type, as a fraction of its on-demand
+// price, over SpotPriceHistoryWindow. A run of unchanged prices is kept as its first and last samples only. It exposes the
+// volatility and trend of the spot prices, so that allocation can steer away from spot instances whose price is trending
+// toward on-demand.
+type SpotPriceHistory struct {
+	mu sync.RWMutex
+	// key: instance type (lowercase)
+	history map[string]*spotPriceEntry
+	// seqNum is updated on any change to the history
+	seqNum atomic.Uint64
+}
+
+type spotPriceEntry struct {
+	// instanceType as first recorded, used as the metric label
+	instanceType string
+	samples      []SpotPriceSample
+}
+
+func NewSpotPriceHistory() *SpotPriceHistory {
+	return &SpotPriceHistory{
+		history: map[string]*spotPriceEntry{},
+	}
+}
+
+func (h *SpotPriceHistory) SeqNum() uint64 {
+	return h.seqNum.Load()
+}
+
+// Record adds a sample of the spot price to on-demand price ratio of each instance type, taken at the given time, and
+// prunes the samples that are out of the window
+func (h *SpotPriceHistory) Record(at time.Time, ratios map[string]float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for instanceType, ratio := range ratios {
+		h.entry(instanceType).append(SpotPriceSample{Time: at, Ratio: math.Round(ratio*1e4) / 1e4})
+	}
+	// prune every instance type, including the ones that aren't priced anymore
+	for key := range h.history {
+		h.refresh(key, at)
+	}
+	h.seqNum.Add(1)
+}
+
+// Volatility returns the standard deviation of the spot price to on-demand price ratio of the instance type over the window
+func (h *SpotPriceHistory) Volatility(instanceType string) float64 {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	entry, ok := h.history[strings.ToLower(instanceType)]
+	if !ok {
+		return 0
+	}
+	return entry.volatility()
+}
+
+// Trend returns how much the spot price to on-demand price ratio of the instance type changes per day, fitted over the window
+func (h *SpotPriceHistory) Trend(instanceType string) float64 {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	entry, ok := h.history[strings.ToLower(instanceType)]
+	if !ok {
+		return 0
+	}
+	return entry.trend()
+}
+
+// RisingTowardOnDemand returns true if the spot price of the instance type is rising, and is projected to reach
+// SpotPriceRisingRatio of the on-demand price within SpotPriceTrendHorizon
+func (h *SpotPriceHistory) RisingTowardOnDemand(instanceType string) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	entry, ok := h.history[strings.ToLower(instanceType)]
+	if !ok {
+		return false
+	}
+	trend := entry.trend()
+	return trend > 0 && entry.latest().Ratio+trend*SpotPriceTrendHorizon.Hours()/24 >= SpotPriceRisingRatio
+}
+
+// Snapshot returns the samples of each instance type
+func (h *SpotPriceHistory) Snapshot() map[string][]SpotPriceSample {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	snapshot := make(map[string][]SpotPriceSample, len(h.history))
+	for _, entry := range h.history {
+		snapshot[entry.instanceType] = append([]SpotPriceSample{}, entry.samples...)
+	}
+	return snapshot
+}
+
+// Restore records the samples of a snapshot that predate the samples already recorded for each instance type
+func (h *SpotPriceHistory) Restore(snapshot map[string][]SpotPriceSample) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for instanceType, samples := range snapshot {
+		if len(samples) == 0 {
+			continue
+		}
+		entry := h.entry(instanceType)
+		restored := make([]SpotPriceSample, 0, len(samples)+len(entry.samples))
+		for _, sample := range samples {
+			if len(entry.samples) == 0 || sample.Time.Before(entry.samples[0].Time) {
+				restored = append(restored, sample)
+			}
+		}
+		entry.samples = append(restored, entry.samples...)
+		h.refresh(strings.ToLower(instanceType), entry.latest().Time)
+	}
+	h.seqNum.Add(1)
+}
+
+func (h *SpotPriceHistory) Flush() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.history = map[string]*spotPriceEntry{}
+	SpotPriceRatio.Reset()
+	SpotPriceVolatility.Reset()
+	SpotPriceTrend.Reset()
+	h.seqNum.Add(1)
+}
+
+func (h *SpotPriceHistory) entry(instanceType string) *spotPriceEntry {
+	key := strings.ToLower(instanceType)
+	entry, ok := h.history[key]
+	if !ok {
+		entry = &spotPriceEntry{instanceType: instanceType}
+		h.history[key] = entry
+	}
+	return entry
+}
+
+// refresh prunes the samples of the instance type that are out of the window as of the given time, and refreshes its metrics
+func (h *SpotPriceHistory) refresh(key string, at time.Time) {
+	entry := h.history[key]
+	cutoff := at.Add(-SpotPriceHistoryWindow)
+	for len(entry.samples) > 0 && entry.samples[0].Time.Before(cutoff) {
+		entry.samples = entry.samples[1:]
+	}
+	if maxSamples := h.maxSamples(); len(entry.samples) > maxSamples {
+		entry.samples = entry.samples[len(entry.samples)-maxSamples:]
+	}
+	if len(entry.samples) == 0 {
+		delete(h.history, key)
+		SpotPriceRatio.DeleteLabelValues(entry.instanceType)
+		SpotPriceVolatility.DeleteLabelValues(entry.instanceType)
+		SpotPriceTrend.DeleteLabelValues(entry.instanceType)
+		return
+	}
+	SpotPriceRatio.WithLabelValues(entry.instanceType).Set(entry.latest().Ratio)
+	SpotPriceVolatility.WithLabelValues(entry.instanceType).Set(entry.volatility())
+	SpotPriceTrend.WithLabelValues(entry.instanceType).Set(entry.trend())
+}
+
+// maxSamples is the number of samples kept per instance type, see SpotPriceHistoryMaxTotalSamples
+func (h *SpotPriceHistory) maxSamples() int {
+	return max(2, min(SpotPriceHistoryMaxSamples, SpotPriceHistoryMaxTotalSamples/max(1, len(h.history))))
+}
+
+func (e *spotPriceEntry) append(sample SpotPriceSample) {
+	n := len(e.samples)
+	if n > 0 && !sample.Time.After(e.samples[n-1].Time) {
+		return
+	}
+	// extend the run of unchanged prices rather than adding a sample
+	if n >= 2 && e.samples[n-1].Ratio == sample.Ratio && e.samples[n-2].Ratio == sample.Ratio {
+		e.samples[n-1] = sample
+		return
+	}
+	e.samples = append(e.samples, sample)
+}
+
+func (e *spotPriceEntry) latest() SpotPriceSample {
+	return e.samples[len(e.samples)-1]
+}
+
+func (e *spotPriceEntry) volatility() float64 {
+	var mean float64
+	for _, sample := range e.samples {
+		mean += sample.Ratio
+	}
+	mean /= float64(len(e.samples))
+	var variance float64
+	for _, sample := range e.samples {
+		variance += (sample.Ratio - mean) * (sample.Ratio - mean)
+	}
+	return math.Sqrt(variance / float64(len(e.samples)))
+}
+
+// trend is the least-squares slope of the ratio over time, per day
+func (e *spotPriceEntry) trend() float64 {
+	if len(e.samples) < 2 {
+		return 0
+	}
+	origin := e.samples[0].Time
+	days := func(sample SpotPriceSample) float64 { return sample.Time.Sub(origin).Hours() / 24 }
+	var meanDays, meanRatio float64
+	for _, sample := range e.samples {
+		meanDays += days(sample)
+		meanRatio += sample.Ratio
+	}
+	meanDays /= float64(len(e.samples))
+	meanRatio /= float64(len(e.samples))
+	var covariance, variance float64
+	for _, sample := range e.samples {
+		covariance += (days(sample) - meanDays) * (sample.Ratio - meanRatio)
+		variance += (days(sample) - meanDays) * (days(sample) - meanDays)
+	}
+	if variance == 0 {
+		return 0
+	}
+	return covariance / variance
+}
@@ -0,0 +1,134 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSpotPriceHistory(t *testing.T) {
+	h := NewSpotPriceHistory()
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if h.RisingTowardOnDemand("Standard_D2s_v3") || h.Volatility("Standard_D2s_v3") != 0 || h.Trend("Standard_D2s_v3") != 0 {
+		t.Errorf("expected no volatility nor trend without history")
+	}
+
+	// a stable spot price
+	for day := 0; day < 5; day++ {
+		h.Record(start.Add(time.Duration(day)*24*time.Hour), map[string]float64{"Standard_D2s_v3": 0.2, "Standard_D4s_v3": 0.2 + 0.1*float64(day)})
+	}
+	if samples := h.Snapshot()["Standard_D2s_v3"]; len(samples) != 2 {
+		t.Errorf("expected a run of unchanged prices to be kept as 2 samples, got %v", samples)
+	}
+	if h.Volatility("Standard_D2s_v3") != 0 || h.Trend("Standard_D2s_v3") != 0 || h.RisingTowardOnDemand("Standard_D2s_v3") {
+		t.Errorf("expected a stable spot price to have no volatility nor trend")
+	}
+
+	// a spot price rising by 0.1 of the on-demand price per day
+	if trend := h.Trend("standard_d4s_v3"); math.Abs(trend-0.1) > 1e-9 {
+		t.Errorf("expected a trend of 0.1 per day, got %v", trend)
+	}
+	if h.Volatility("Standard_D4s_v3") == 0 {
+		t.Errorf("expected a changing spot price to be volatile")
+	}
+	if !h.RisingTowardOnDemand("Standard_D4s_v3") {
+		t.Errorf("expected the spot price to be rising toward on-demand")
+	}
+}
+
+func TestSpotPriceHistoryWindow(t *testing.T) {
+	h := NewSpotPriceHistory()
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 2*SpotPriceHistoryMaxSamples; i++ {
+		h.Record(start.Add(time.Duration(i)*time.Hour), map[string]float64{"Standard_D2s_v3": float64(i%2) / 10})
+	}
+	if samples := h.Snapshot()["Standard_D2s_v3"]; len(samples) != SpotPriceHistoryMaxSamples {
+		t.Errorf("expected %d samples, got %d", SpotPriceHistoryMaxSamples, len(samples))
+	}
+
+	// instance types that aren't priced anymore are pruned once out of the window
+	h.Record(start.Add(SpotPriceHistoryWindow+3*24*time.Hour), map[string]float64{"Standard_D4s_v3": 0.3})
+	if _, ok := h.Snapshot()["Standard_D2s_v3"]; ok {
+		t.Errorf("expected the samples out of the window to be pruned")
+	}
+}
+
+func TestSpotPriceHistoryMaxTotalSamples(t *testing.T) {
+	h := NewSpotPriceHistory()
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	instanceTypeCount := SpotPriceHistoryMaxTotalSamples / 10
+
+	for i := 0; i < SpotPriceHistoryMaxSamples; i++ {
+		ratios := map[string]float64{}
+		for j := 0; j < instanceTypeCount; j++ {
+			ratios[fmt.Sprintf("Standard_D%d", j)] = float64(i%2) / 10
+		}
+		h.Record(start.Add(time.Duration(i)*time.Hour), ratios)
+	}
+	total := 0
+	for _, samples := range h.Snapshot() {
+		total += len(samples)
+	}
+	if total > SpotPriceHistoryMaxTotalSamples {
+		t.Errorf("expected at most %d samples, got %d", SpotPriceHistoryMaxTotalSamples, total)
+	}
+	if samples := h.Snapshot()["Standard_D0"]; len(samples) != 10 {
+		t.Errorf("expected 10 samples per instance type, got %d", len(samples))
+	}
+}
+
+func TestSpotPriceHistoryRestore(t *testing.T) {
+	h := NewSpotPriceHistory()
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	h.Record(start.Add(48*time.Hour), map[string]float64{"Standard_D2s_v3": 0.4})
+
+	data, err := json.Marshal(map[string][]SpotPriceSample{
+		"Standard_D2s_v3": {{Time: start, Ratio: 0.2}, {Time: start.Add(24 * time.Hour), Ratio: 0.3}, {Time: start.Add(48 * time.Hour), Ratio: 0.1}},
+		"Standard_D4s_v3": {{Time: start, Ratio: 0.5}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot := map[string][]SpotPriceSample{}
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		t.Fatal(err)
+	}
+	h.Restore(snapshot)
+
+	// restoring only adds the samples that predate the recorded ones
+	samples := h.Snapshot()["Standard_D2s_v3"]
+	if len(samples) != 3 || samples[2].Ratio != 0.4 {
+		t.Errorf("expected the 2 restored samples before the recorded one, got %v", samples)
+	}
+	if trend := h.Trend("Standard_D2s_v3"); math.Abs(trend-0.1) > 1e-9 {
+		t.Errorf("expected a trend of 0.1 per day, got %v", trend)
+	}
+	if samples := h.Snapshot()["Standard_D4s_v3"]; len(samples) != 1 || !samples[0].Time.Equal(start) {
+		t.Errorf("expected the restored sample, got %v", samples)
+	}
+
+	h.Flush()
+	if len(h.Snapshot()) != 0 {
+		t.Errorf("expected no history after flush")
+	}
+}
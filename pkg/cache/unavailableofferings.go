@@ -19,6 +19,7 @@ package cache
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -50,18 +51,27 @@ type UnavailableOfferings struct {
 	// TODO: I think this singleOfferingCache could basically be removed in favor of the family cache at this point, as we are now marking family unavailable at CPU count for all error cases.
 	// I didn't do that purely because of the defensive fallback we have in markFamilyUnavailableAtCPUCountImpl, but in practice I am not sure if we'll ever have a nil family (I don't
 	// see any evidence it can happen in logs)
-	// key: <capacityType>:<instanceType>:<zone>, value: struct{}{}
+	// key: <capacityType>:<instanceType>:<zone>, value: UnavailableOffering
 	singleOfferingCache *cache.Cache
-	// key: <skuFamilyName>:<zone>:<capacityType> (lowercase), value: int64 (CPU count at or above which we block, or wholeVMFamilyBlockedSentinel if entire family is blocked)
+	// key: <skuFamilyName>:<zone>:<capacityType> (lowercase), value: UnavailableVMFamily (with the CPU count at or above which we block,
+	// or wholeVMFamilyBlockedSentinel if entire family is blocked)
 	vmFamilyCache *cache.Cache
+	// key: <capacityType>:<instanceType>:<zone>, value: struct{}{}
+	// Outlives the entries above, to remember offerings that were recently unavailable even once they are available again
+	recentlyUnavailableCache *cache.Cache
+	// key: <placementKey>:<capacityType>:<instanceType>:<zone>, value: UnavailableOffering
+	// Offerings that can't be allocated within placement constraints (e.g. a proximity placement group), but may be available without them
+	placementCache *cache.Cache
 	// seqNum is updated on any material changes to unavailable offerings cache (not updated on TTL only changes)
 	seqNum atomic.Uint64
 }
 
 func NewUnavailableOfferingsWithCache(singleOfferingCache, vmFamilyCache *cache.Cache) *UnavailableOfferings {
 	uo := &UnavailableOfferings{
-		singleOfferingCache: singleOfferingCache,
-		vmFamilyCache:       vmFamilyCache,
+		singleOfferingCache:      singleOfferingCache,
+		vmFamilyCache:            vmFamilyCache,
+		recentlyUnavailableCache: cache.New(RecentlyUnavailableOfferingsTTL, DefaultCleanupInterval),
+		placementCache:           cache.New(UnavailableOfferingsTTL, UnavailableOfferingsCleanupInterval),
 	}
 	uo.singleOfferingCache.OnEvicted(func(_ string, _ any) {
 		uo.seqNum.Add(1)
@@ -69,6 +79,9 @@ func NewUnavailableOfferingsWithCache(singleOfferingCache, vmFamilyCache *cache.
 	uo.vmFamilyCache.OnEvicted(func(_ string, _ any) {
 		uo.seqNum.Add(1)
 	})
+	uo.placementCache.OnEvicted(func(_ string, _ any) {
+		uo.seqNum.Add(1)
+	})
 	return uo
 }
 
@@ -101,6 +114,28 @@ func (u *UnavailableOfferings) IsUnavailable(sku *skewer.SKU, zone, capacityType
 	return found
 }
 
+// WasRecentlyUnavailable returns true if the offering was marked unavailable within RecentlyUnavailableOfferingsTTL,
+// even if it is available again. Only marks of the specific offering and spot-wide marks are remembered.
+func (u *UnavailableOfferings) WasRecentlyUnavailable(instanceType, zone, capacityType string) bool {
+	if capacityType == karpv1.CapacityTypeSpot {
+		if _, found := u.recentlyUnavailableCache.Get(spotKey); found {
+			return true
+		}
+	}
+	_, found := u.recentlyUnavailableCache.Get(singleInstanceKey(instanceType, zone, capacityType))
+	return found
+}
+
+// IsUnavailableForPlacement returns true if the offering can't be allocated within the placement constraints identified by placementKey.
+// Offerings are never unavailable for an empty placementKey.
+func (u *UnavailableOfferings) IsUnavailableForPlacement(placementKey, instanceType, zone, capacityType string) bool {
+	if placementKey == "" {
+		return false
+	}
+	_, found := u.placementCache.Get(placementKey + ":" + singleInstanceKey(instanceType, zone, capacityType))
+	return found
+}
+
 func (u *UnavailableOfferings) isFamilyUnavailable(sku *skewer.SKU, zone, capacityType string) bool {
 	skuVCPUCount, err := sku.VCPU()
 	if err != nil {
@@ -109,7 +144,8 @@ func (u *UnavailableOfferings) isFamilyUnavailable(sku *skewer.SKU, zone, capaci
 	}
 	// Check if VM family is blocked in the specific zone
 	if val, found := u.vmFamilyCache.Get(vmFamilyKey(sku.GetFamilyName(), zone, capacityType)); found {
-		if blockedCPUCount, ok := val.(int64); ok {
+		if family, ok := val.(UnavailableVMFamily); ok {
+			blockedCPUCount := family.CPUCount
 			if blockedCPUCount == wholeVMFamilyBlockedSentinel {
 				// Entire VM family is blocked in this zone
 				return true
@@ -159,10 +195,10 @@ func (u *UnavailableOfferings) markFamilyUnavailableAtCPUCountImpl(ctx context.C
 	changed := true
 
 	if existing, found := u.vmFamilyCache.Get(key); found {
-		if currentBlockedCPUCount, ok := existing.(int64); ok {
+		if current, ok := existing.(UnavailableVMFamily); ok {
 			// Keep the more restrictive limit for CPU count(lower value, with -1 being most restrictive - wholeVMFamilyBlockedSentinel)
-			if currentBlockedCPUCount <= cpuCount {
-				cpuCount = currentBlockedCPUCount
+			if current.CPUCount <= cpuCount {
+				cpuCount = current.CPUCount
 				changed = false
 			}
 		}
@@ -176,22 +212,39 @@ func (u *UnavailableOfferings) markFamilyUnavailableAtCPUCountImpl(ctx context.C
 		"ttl", ttl)
 
 	// call Set to update the cache entry, even if it already exists, to extend its TTL
-	u.vmFamilyCache.Set(key, cpuCount, ttl)
+	u.vmFamilyCache.Set(key, UnavailableVMFamily{
+		Family:       skuFamilyName,
+		Zone:         zone,
+		CapacityType: capacityType,
+		CPUCount:     cpuCount,
+	}, ttl)
 	return changed
 }
 
-// MarkSpotUnavailable communicates recently observed temporary capacity shortages for spot
+// MarkSpotUnavailableWithTTL communicates recently observed temporary capacity shortages for spot
 func (u *UnavailableOfferings) MarkSpotUnavailableWithTTL(ctx context.Context, ttl time.Duration) {
+	u.MarkSpotUnavailableWithErrorCode(ctx, "SpotUnavailable", "", ttl)
+}
+
+// MarkSpotUnavailableWithErrorCode marks spot unavailable for all instance types and zones,
+// recording the reason and the Azure error code that triggered it.
+func (u *UnavailableOfferings) MarkSpotUnavailableWithErrorCode(ctx context.Context, unavailableReason, errorCode string, ttl time.Duration) {
 	u.mu.Lock()
 	defer u.mu.Unlock()
 	capacityType := karpv1.CapacityTypeSpot
 	_, wasUnavailable := u.singleOfferingCache.Get(spotKey)
 	// even if the key is already in the cache, we still need to call Set to extend the cached entry's TTL
 	log.FromContext(ctx).V(1).Info("removing offering from offerings",
-		"unavailable", "SpotUnavailable",
+		"unavailable", unavailableReason,
 		"capacity-type", capacityType,
 		"ttl", ttl)
-	u.singleOfferingCache.Set(spotKey, struct{}{}, ttl)
+	u.singleOfferingCache.Set(spotKey, UnavailableOffering{
+		CapacityType: capacityType,
+		Reason:       unavailableReason,
+		ErrorCode:    errorCode,
+		MarkedAt:     time.Now(),
+	}, ttl)
+	u.recentlyUnavailableCache.Set(spotKey, struct{}{}, max(ttl, RecentlyUnavailableOfferingsTTL))
 	if !wasUnavailable {
 		u.seqNum.Add(1)
 	}
@@ -201,6 +254,12 @@ func (u *UnavailableOfferings) MarkSpotUnavailableWithTTL(ctx context.Context, t
 // In addition to marking the specific instance type unavailable, it also marks the VM family
 // unavailable at the SKU's vCPU count, so that larger sizes of the same family are also blocked.
 func (u *UnavailableOfferings) MarkUnavailableWithTTL(ctx context.Context, unavailableReason string, sku *skewer.SKU, zone, capacityType string, ttl time.Duration) {
+	u.MarkUnavailableWithErrorCode(ctx, unavailableReason, "", sku, zone, capacityType, ttl)
+}
+
+// MarkUnavailableWithErrorCode behaves like MarkUnavailableWithTTL, additionally recording the Azure error code that
+// triggered the mark, so that it can be surfaced to users.
+func (u *UnavailableOfferings) MarkUnavailableWithErrorCode(ctx context.Context, unavailableReason, errorCode string, sku *skewer.SKU, zone, capacityType string, ttl time.Duration) {
 	u.mu.Lock()
 	defer u.mu.Unlock()
 	instanceType := sku.GetName()
@@ -212,7 +271,15 @@ func (u *UnavailableOfferings) MarkUnavailableWithTTL(ctx context.Context, unava
 		"zone", zone,
 		"capacity-type", capacityType,
 		"ttl", ttl)
-	u.singleOfferingCache.Set(singleInstanceKey(instanceType, zone, capacityType), struct{}{}, ttl)
+	u.singleOfferingCache.Set(singleInstanceKey(instanceType, zone, capacityType), UnavailableOffering{
+		InstanceType: instanceType,
+		Zone:         zone,
+		CapacityType: capacityType,
+		Reason:       unavailableReason,
+		ErrorCode:    errorCode,
+		MarkedAt:     time.Now(),
+	}, ttl)
+	u.recentlyUnavailableCache.Set(singleInstanceKey(instanceType, zone, capacityType), struct{}{}, max(ttl, RecentlyUnavailableOfferingsTTL))
 
 	// Also mark the VM family unavailable at this SKU's vCPU count, so larger sizes of the same family are blocked too
 	familyChanged := u.markFamilyUnavailableAtCPUCount(ctx, sku, zone, capacityType, ttl)
@@ -221,19 +288,200 @@ func (u *UnavailableOfferings) MarkUnavailableWithTTL(ctx context.Context, unava
 	}
 }
 
+// MarkUnavailableForPlacement marks an offering unavailable only for instances with the placement constraints identified by placementKey.
+// Unlike MarkUnavailableWithErrorCode, it doesn't mark the VM family, as the failure says nothing about capacity outside of the constraints.
+func (u *UnavailableOfferings) MarkUnavailableForPlacement(ctx context.Context, unavailableReason, errorCode, placementKey, instanceType, zone, capacityType string, ttl time.Duration) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	key := placementKey + ":" + singleInstanceKey(instanceType, zone, capacityType)
+	_, wasUnavailable := u.placementCache.Get(key)
+	log.FromContext(ctx).V(1).Info("removing offering from offerings for placement",
+		"unavailable", unavailableReason,
+		"placement", placementKey,
+		logging.InstanceType, instanceType,
+		"zone", zone,
+		"capacity-type", capacityType,
+		"ttl", ttl)
+	u.placementCache.Set(key, UnavailableOffering{
+		InstanceType: instanceType,
+		Zone:         zone,
+		CapacityType: capacityType,
+		Reason:       unavailableReason,
+		ErrorCode:    errorCode,
+		MarkedAt:     time.Now(),
+	}, ttl)
+	if !wasUnavailable {
+		u.seqNum.Add(1)
+	}
+}
+
 // MarkUnavailable communicates recently observed temporary capacity shortages in the provided offerings
 func (u *UnavailableOfferings) MarkUnavailable(ctx context.Context, unavailableReason string, sku *skewer.SKU, zone, capacityType string) {
 	u.MarkUnavailableWithTTL(ctx, unavailableReason, sku, zone, capacityType, UnavailableOfferingsTTL)
 }
 
+// UnavailableOffering describes why and until when an offering is unavailable
+type UnavailableOffering struct {
+	// InstanceType is empty when spot is unavailable for all instance types and zones
+	InstanceType string `json:"instanceType,omitempty"`
+	Zone         string `json:"zone,omitempty"`
+	CapacityType string `json:"capacityType"`
+	// Reason is the reason the offering was marked unavailable, e.g. ZonalAllocationFailure
+	Reason string `json:"reason,omitempty"`
+	// ErrorCode is the Azure error code that caused the offering to be marked unavailable, if any
+	ErrorCode  string    `json:"errorCode,omitempty"`
+	MarkedAt   time.Time `json:"markedAt"`
+	Expiration time.Time `json:"expiration"`
+}
+
+// List returns the offerings that are currently marked unavailable, sorted by capacity type, instance type and zone.
+// Offerings only blocked through their VM family are not listed individually.
+func (u *UnavailableOfferings) List() []UnavailableOffering {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	var offerings []UnavailableOffering
+	for key, item := range u.singleOfferingCache.Items() {
+		offerings = append(offerings, unavailableOfferingFromItem(key, item))
+	}
+	sort.Slice(offerings, func(i, j int) bool {
+		return singleInstanceKey(offerings[i].InstanceType, offerings[i].Zone, offerings[i].CapacityType) <
+			singleInstanceKey(offerings[j].InstanceType, offerings[j].Zone, offerings[j].CapacityType)
+	})
+	return offerings
+}
+
+// ListVMFamilies returns the VM families that are currently marked unavailable, sorted by family, zone and capacity type
+func (u *UnavailableOfferings) ListVMFamilies() []UnavailableVMFamily {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	var families []UnavailableVMFamily
+	for key, item := range u.vmFamilyCache.Items() {
+		if family, ok := unavailableVMFamilyFromItem(key, item); ok {
+			families = append(families, family)
+		}
+	}
+	sort.Slice(families, func(i, j int) bool {
+		return vmFamilyKey(families[i].Family, families[i].Zone, families[i].CapacityType) <
+			vmFamilyKey(families[j].Family, families[j].Zone, families[j].CapacityType)
+	})
+	return families
+}
+
 func (u *UnavailableOfferings) Flush() {
 	u.mu.Lock()
 	defer u.mu.Unlock()
 	u.singleOfferingCache.Flush()
 	u.vmFamilyCache.Flush()
+	u.recentlyUnavailableCache.Flush()
+	u.placementCache.Flush()
 	u.seqNum.Add(1)
 }
 
+// UnavailableOfferingsSnapshot is a serializable copy of the unavailable offerings, along with when each entry expires.
+// It is used to persist unavailable offerings across controller restarts.
+type UnavailableOfferingsSnapshot struct {
+	// SingleOfferings are the unavailable offerings, keyed by <capacityType>:<instanceType>:<zone>
+	SingleOfferings map[string]UnavailableOffering `json:"singleOfferings,omitempty"`
+	// VMFamilies are the unavailable VM families, keyed by <skuFamilyName>:<zone>:<capacityType>
+	VMFamilies map[string]UnavailableVMFamily `json:"vmFamilies,omitempty"`
+	// RecentlyUnavailable are the expiration times of offerings that were recently unavailable, keyed like SingleOfferings
+	RecentlyUnavailable map[string]time.Time `json:"recentlyUnavailable,omitempty"`
+}
+
+// UnavailableVMFamily is a VM family that is unavailable at or above a CPU count
+type UnavailableVMFamily struct {
+	// Family, Zone and CapacityType are empty in snapshots taken before they were recorded, and are then derived from the key
+	Family       string `json:"family,omitempty"`
+	Zone         string `json:"zone,omitempty"`
+	CapacityType string `json:"capacityType,omitempty"`
+	// CPUCount is the CPU count at or above which the VM family is blocked, or -1 if the entire family is blocked
+	CPUCount   int64     `json:"cpuCount"`
+	Expiration time.Time `json:"expiration"`
+}
+
+// Snapshot returns a copy of the unexpired unavailable offerings
+func (u *UnavailableOfferings) Snapshot() UnavailableOfferingsSnapshot {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	snapshot := UnavailableOfferingsSnapshot{
+		SingleOfferings:     map[string]UnavailableOffering{},
+		VMFamilies:          map[string]UnavailableVMFamily{},
+		RecentlyUnavailable: map[string]time.Time{},
+	}
+	for key, item := range u.singleOfferingCache.Items() {
+		snapshot.SingleOfferings[key] = unavailableOfferingFromItem(key, item)
+	}
+	for key, item := range u.vmFamilyCache.Items() {
+		if family, ok := unavailableVMFamilyFromItem(key, item); ok {
+			snapshot.VMFamilies[key] = family
+		}
+	}
+	for key, item := range u.recentlyUnavailableCache.Items() {
+		snapshot.RecentlyUnavailable[key] = time.Unix(0, item.Expiration)
+	}
+	return snapshot
+}
+
+// Restore adds the entries of the snapshot that have not expired yet, for their remaining TTL.
+// Entries already in the cache are more recent than the snapshot, and are kept as is.
+func (u *UnavailableOfferings) Restore(ctx context.Context, snapshot UnavailableOfferingsSnapshot) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	restored := 0
+	for key, offering := range snapshot.SingleOfferings {
+		if ttl := time.Until(offering.Expiration); ttl > 0 && u.singleOfferingCache.Add(key, offering, ttl) == nil {
+			restored++
+		}
+	}
+	for key, family := range snapshot.VMFamilies {
+		ttl := time.Until(family.Expiration)
+		if family.Family == "" {
+			family.Family, family.Zone, family.CapacityType = vmFamilyFromKey(key)
+		}
+		family.Expiration = time.Time{}
+		if ttl > 0 && u.vmFamilyCache.Add(key, family, ttl) == nil {
+			restored++
+		}
+	}
+	for key, expiration := range snapshot.RecentlyUnavailable {
+		if ttl := time.Until(expiration); ttl > 0 {
+			_ = u.recentlyUnavailableCache.Add(key, struct{}{}, ttl)
+		}
+	}
+	if restored > 0 {
+		log.FromContext(ctx).V(1).Info("restored unavailable offerings", "count", restored)
+		u.seqNum.Add(1)
+	}
+}
+
+// unavailableOfferingFromItem returns the details of a singleOfferingCache item, with its expiration
+func unavailableOfferingFromItem(key string, item cache.Item) UnavailableOffering {
+	offering, ok := item.Object.(UnavailableOffering)
+	if !ok {
+		// fall back to the key for entries without details
+		parts := strings.SplitN(key, ":", 3)
+		offering = UnavailableOffering{CapacityType: parts[0]}
+		if len(parts) == 3 {
+			offering.InstanceType, offering.Zone = parts[1], parts[2]
+		}
+	}
+	offering.Expiration = time.Unix(0, item.Expiration)
+	return offering
+}
+
+// unavailableVMFamilyFromItem returns the details of a vmFamilyCache item, with its expiration
+func unavailableVMFamilyFromItem(key string, item cache.Item) (UnavailableVMFamily, bool) {
+	family, ok := item.Object.(UnavailableVMFamily)
+	if !ok {
+		return UnavailableVMFamily{}, false
+	}
+	if family.Family == "" {
+		family.Family, family.Zone, family.CapacityType = vmFamilyFromKey(key)
+	}
+	family.Expiration = time.Unix(0, item.Expiration)
+	return family, true
+}
+
 // singleInstanceKey returns the cache singleInstanceKey for all offerings in the cache
 func singleInstanceKey(instanceType string, zone string, capacityType string) string {
 	return fmt.Sprintf("%s:%s:%s", capacityType, instanceType, zone)
@@ -243,3 +491,12 @@ func singleInstanceKey(instanceType string, zone string, capacityType string) st
 func vmFamilyKey(skuFamilyName, zone, capacityType string) string {
 	return strings.ToLower(fmt.Sprintf("skuFamily:%s:%s:%s", skuFamilyName, zone, capacityType))
 }
+
+// vmFamilyFromKey returns the (lowercase) family name, zone and capacity type of a vmFamilyKey
+func vmFamilyFromKey(key string) (skuFamilyName, zone, capacityType string) {
+	parts := strings.SplitN(key, ":", 4)
+	if len(parts) != 4 {
+		return key, "", ""
+	}
+	return parts[1], parts[2], parts[3]
+}
@@ -94,6 +94,74 @@ func TestUnavailableOfferings(t *testing.T) {
 	assertOfferingAvailable(t, u, largerSKU, "westus", karpv1.CapacityTypeSpot, "Larger offering should not be marked as unavailable after cache entry has expired")
 }
 
+func TestUnavailableOfferingsRemembersRecentlyUnavailable(t *testing.T) {
+	singleInstanceCache := cache.New(testUnavailableOfferingsTTL, testUnavailableOfferingsTTL)
+	vmFamilyCache := cache.New(testUnavailableOfferingsTTL, testUnavailableOfferingsTTL)
+	u := NewUnavailableOfferingsWithCache(singleInstanceCache, vmFamilyCache)
+	testSKU := createTestSKU("Standard_D2s_v3", "standardDSv3Family", "D2s_v3", 2)
+
+	if u.WasRecentlyUnavailable(testSKU.GetName(), "westus-1", karpv1.CapacityTypeOnDemand) {
+		t.Errorf("Offering should not be recently unavailable initially")
+	}
+
+	u.MarkUnavailableWithTTL(context.TODO(), "test reason", testSKU, "westus-1", karpv1.CapacityTypeOnDemand, testUnavailableOfferingsTTL)
+	time.Sleep(testUnavailableOfferingsTTL)
+
+	// the offering is available again, but is still remembered as recently unavailable
+	assertOfferingAvailable(t, u, testSKU, "westus-1", karpv1.CapacityTypeOnDemand, "Offering should not be marked as unavailable after cache entry has expired")
+	if !u.WasRecentlyUnavailable(testSKU.GetName(), "westus-1", karpv1.CapacityTypeOnDemand) {
+		t.Errorf("Offering should be recently unavailable after cache entry has expired")
+	}
+	if u.WasRecentlyUnavailable(testSKU.GetName(), "westus-2", karpv1.CapacityTypeOnDemand) {
+		t.Errorf("Offering in a different zone should not be recently unavailable")
+	}
+
+	// spot-wide marks apply to every spot offering
+	u.MarkSpotUnavailableWithTTL(context.TODO(), testUnavailableOfferingsTTL)
+	if !u.WasRecentlyUnavailable("Standard_D4s_v3", "westus-2", karpv1.CapacityTypeSpot) {
+		t.Errorf("Spot offering should be recently unavailable after spot was marked unavailable")
+	}
+
+	u.Flush()
+	if u.WasRecentlyUnavailable(testSKU.GetName(), "westus-1", karpv1.CapacityTypeOnDemand) {
+		t.Errorf("Offering should not be recently unavailable after flush")
+	}
+}
+
+func TestUnavailableOfferingsForPlacement(t *testing.T) {
+	u := NewUnavailableOfferings()
+	testSKU := createTestSKU("Standard_D2s_v3", "standardDSv3Family", "D2s_v3", 2)
+	seqNum := u.SeqNum()
+
+	u.MarkUnavailableForPlacement(context.TODO(), "test reason", "OverconstrainedAllocationRequest", "ppg", testSKU.GetName(), "westus-1", karpv1.CapacityTypeOnDemand, testUnavailableOfferingsTTL)
+
+	if !u.IsUnavailableForPlacement("ppg", testSKU.GetName(), "westus-1", karpv1.CapacityTypeOnDemand) {
+		t.Errorf("Offering should be unavailable for the placement")
+	}
+	if u.IsUnavailableForPlacement("other-ppg", testSKU.GetName(), "westus-1", karpv1.CapacityTypeOnDemand) {
+		t.Errorf("Offering should be available for a different placement")
+	}
+	if u.IsUnavailableForPlacement("", testSKU.GetName(), "westus-1", karpv1.CapacityTypeOnDemand) {
+		t.Errorf("Offering should be available without a placement")
+	}
+	// the offering itself, and the VM family, remain available
+	assertOfferingAvailable(t, u, testSKU, "westus-1", karpv1.CapacityTypeOnDemand, "Offering should remain available without the placement")
+	if u.SeqNum() == seqNum {
+		t.Errorf("SeqNum should change when an offering is marked unavailable for a placement")
+	}
+
+	seqNum = u.SeqNum()
+	u.MarkUnavailableForPlacement(context.TODO(), "test reason", "OverconstrainedAllocationRequest", "ppg", testSKU.GetName(), "westus-1", karpv1.CapacityTypeOnDemand, testUnavailableOfferingsTTL)
+	if u.SeqNum() != seqNum {
+		t.Errorf("SeqNum should not change when an offering is marked unavailable for a placement again")
+	}
+
+	u.Flush()
+	if u.IsUnavailableForPlacement("ppg", testSKU.GetName(), "westus-1", karpv1.CapacityTypeOnDemand) {
+		t.Errorf("Offering should be available for the placement after flush")
+	}
+}
+
 func TestUnavailableOfferingsVMFamilyCoreLimitAllowsFewerCores(t *testing.T) {
 	// create a new cache with a short TTL
 	singleInstanceCache := cache.New(testUnavailableOfferingsTTL, testUnavailableOfferingsTTL)
@@ -318,3 +386,132 @@ func TestUnavailableOfferingsConcurrentDuplicateMarksAdvanceOneGeneration(t *tes
 	}
 	assertOfferingUnavailable(t, u, sku, "westus-1", karpv1.CapacityTypeOnDemand, "Offering should be unavailable after concurrent marks")
 }
+
+func TestUnavailableOfferingsSnapshotAndRestore(t *testing.T) {
+	u := NewUnavailableOfferings()
+	nv16 := createTestSKU("Standard_NV16as_v4", "standardNVasv4Family", "NV16as_v4", 16)
+	nv24 := createTestSKU("Standard_NV24as_v4", "standardNVasv4Family", "NV24as_v4", 24)
+	d2 := createTestSKU("Standard_D2s_v3", "standardDSv3Family", "D2s_v3", 2)
+
+	u.MarkUnavailableWithErrorCode(context.TODO(), "test reason", "AllocationFailed", nv16, "westus-1", karpv1.CapacityTypeOnDemand, time.Hour)
+	u.MarkSpotUnavailableWithTTL(context.TODO(), time.Hour)
+	snapshot := u.Snapshot()
+	// an entry that expired while the controller was down is not restored
+	snapshot.SingleOfferings[singleInstanceKey(d2.GetName(), "westus-1", karpv1.CapacityTypeOnDemand)] = UnavailableOffering{
+		InstanceType: d2.GetName(),
+		Zone:         "westus-1",
+		CapacityType: karpv1.CapacityTypeOnDemand,
+		Expiration:   time.Now().Add(-time.Minute),
+	}
+
+	restored := NewUnavailableOfferings()
+	restored.Restore(context.TODO(), snapshot)
+	if got := restored.SeqNum(); got != 1 {
+		t.Fatalf("expected restore to advance generation to 1, got %d", got)
+	}
+	assertOfferingUnavailable(t, restored, nv16, "westus-1", karpv1.CapacityTypeOnDemand, "Offering should be unavailable after restore")
+	assertOfferingUnavailable(t, restored, nv24, "westus-1", karpv1.CapacityTypeOnDemand, "Larger size of the family should be unavailable after restore")
+	assertOfferingUnavailable(t, restored, d2, "westus-1", karpv1.CapacityTypeSpot, "Spot should be unavailable after restore")
+	assertOfferingAvailable(t, restored, d2, "westus-1", karpv1.CapacityTypeOnDemand, "Expired offering should not be restored")
+	assertOfferingAvailable(t, restored, nv16, "westus-2", karpv1.CapacityTypeOnDemand, "Offering in another zone should be available after restore")
+	if !restored.WasRecentlyUnavailable(nv16.GetName(), "westus-1", karpv1.CapacityTypeOnDemand) {
+		t.Errorf("expected offering to be recently unavailable after restore")
+	}
+
+	// entries are restored with their remaining TTL
+	for key, offering := range restored.Snapshot().SingleOfferings {
+		if diff := offering.Expiration.Sub(snapshot.SingleOfferings[key].Expiration).Abs(); diff > time.Second {
+			t.Errorf("expected %s to expire at %s, got %s", key, snapshot.SingleOfferings[key].Expiration, offering.Expiration)
+		}
+	}
+	// along with the details of why they are unavailable
+	if got := restored.Snapshot().SingleOfferings[singleInstanceKey(nv16.GetName(), "westus-1", karpv1.CapacityTypeOnDemand)].ErrorCode; got != "AllocationFailed" {
+		t.Errorf("expected restored offering to keep its error code, got %q", got)
+	}
+
+	// restoring an empty snapshot doesn't change anything
+	restored.Restore(context.TODO(), UnavailableOfferingsSnapshot{})
+	if got := restored.SeqNum(); got != 1 {
+		t.Fatalf("expected empty restore to keep generation 1, got %d", got)
+	}
+}
+
+func TestUnavailableOfferingsList(t *testing.T) {
+	u := NewUnavailableOfferings()
+	nv16 := createTestSKU("Standard_NV16as_v4", "standardNVasv4Family", "NV16as_v4", 16)
+	d2 := createTestSKU("Standard_D2s_v3", "standardDSv3Family", "D2s_v3", 2)
+
+	if got := u.List(); len(got) != 0 {
+		t.Fatalf("expected no unavailable offerings initially, got %v", got)
+	}
+
+	before := time.Now()
+	u.MarkUnavailableWithErrorCode(context.TODO(), "ZonalAllocationFailure", "ZonalAllocationFailed", nv16, "westus-1", karpv1.CapacityTypeOnDemand, time.Hour)
+	u.MarkUnavailableWithTTL(context.TODO(), "test reason", d2, "westus-2", karpv1.CapacityTypeOnDemand, time.Hour)
+	u.MarkSpotUnavailableWithErrorCode(context.TODO(), "SubscriptionQuotaReached", "OperationNotAllowed", 30*time.Minute)
+
+	offerings := u.List()
+	if len(offerings) != 3 {
+		t.Fatalf("expected 3 unavailable offerings, got %d", len(offerings))
+	}
+	// sorted by capacity type, instance type and zone
+	if offerings[0].InstanceType != d2.GetName() || offerings[1].InstanceType != nv16.GetName() || offerings[2].CapacityType != karpv1.CapacityTypeSpot {
+		t.Fatalf("unexpected order of unavailable offerings: %v", offerings)
+	}
+	if offerings[1].Zone != "westus-1" || offerings[1].Reason != "ZonalAllocationFailure" || offerings[1].ErrorCode != "ZonalAllocationFailed" {
+		t.Errorf("unexpected details for %s: %+v", nv16.GetName(), offerings[1])
+	}
+	if offerings[0].ErrorCode != "" {
+		t.Errorf("expected no error code for %s, got %q", d2.GetName(), offerings[0].ErrorCode)
+	}
+	if spot := offerings[2]; spot.InstanceType != "" || spot.Zone != "" || spot.ErrorCode != "OperationNotAllowed" {
+		t.Errorf("unexpected details for spot: %+v", spot)
+	}
+	for _, offering := range offerings {
+		if offering.MarkedAt.Before(before) {
+			t.Errorf("expected %+v to be marked after %s", offering, before)
+		}
+		if !offering.Expiration.After(offering.MarkedAt) {
+			t.Errorf("expected %+v to expire after it was marked", offering)
+		}
+	}
+}
+
+func TestUnavailableOfferingsListVMFamilies(t *testing.T) {
+	u := NewUnavailableOfferings()
+	nv16 := createTestSKU("Standard_NV16as_v4", "standardNVasv4Family", "NV16as_v4", 16)
+	d2 := createTestSKU("Standard_D2s_v3", "standardDSv3Family", "D2s_v3", 2)
+
+	if got := u.ListVMFamilies(); len(got) != 0 {
+		t.Fatalf("expected no unavailable VM families initially, got %v", got)
+	}
+
+	u.MarkUnavailableWithTTL(context.TODO(), "test reason", nv16, "westus-1", karpv1.CapacityTypeOnDemand, time.Hour)
+	u.MarkFamilyUnavailable(context.TODO(), d2, "westus-2", karpv1.CapacityTypeSpot, time.Hour)
+
+	families := u.ListVMFamilies()
+	if len(families) != 2 {
+		t.Fatalf("expected 2 unavailable VM families, got %d", len(families))
+	}
+	// sorted by family, zone and capacity type, keeping the family name as reported by the SKU
+	if got := families[0]; got.Family != "standardDSv3Family" || got.Zone != "westus-2" || got.CapacityType != karpv1.CapacityTypeSpot || got.CPUCount != wholeVMFamilyBlockedSentinel {
+		t.Errorf("unexpected details for the whole family block: %+v", got)
+	}
+	if got := families[1]; got.Family != "standardNVasv4Family" || got.Zone != "westus-1" || got.CapacityType != karpv1.CapacityTypeOnDemand || got.CPUCount != 16 {
+		t.Errorf("unexpected details for the CPU count block: %+v", got)
+	}
+	for _, family := range families {
+		if !family.Expiration.After(time.Now()) {
+			t.Errorf("expected %+v to expire in the future", family)
+		}
+	}
+
+	// snapshots taken before the family details were recorded are restored with the details derived from the key
+	restored := NewUnavailableOfferings()
+	restored.Restore(context.TODO(), UnavailableOfferingsSnapshot{VMFamilies: map[string]UnavailableVMFamily{
+		vmFamilyKey("standardDSv3Family", "westus-1", karpv1.CapacityTypeOnDemand): {CPUCount: 4, Expiration: time.Now().Add(time.Hour)},
+	}})
+	if got := restored.ListVMFamilies(); len(got) != 1 || got[0].Family != "standarddsv3family" || got[0].Zone != "westus-1" || got[0].CapacityType != karpv1.CapacityTypeOnDemand || got[0].CPUCount != 4 {
+		t.Errorf("unexpected restored VM families: %+v", got)
+	}
+}
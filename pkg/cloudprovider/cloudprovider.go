@@ -21,6 +21,7 @@ import (
 	stderrors "errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -43,6 +44,7 @@ import (
 
 	"github.com/Azure/karpenter-provider-azure/pkg/apis"
 	"github.com/Azure/karpenter-provider-azure/pkg/apis/v1beta1"
+	azurecache "github.com/Azure/karpenter-provider-azure/pkg/cache"
 	"github.com/Azure/karpenter-provider-azure/pkg/controllers/nodeclaim/inplaceupdate"
 	"github.com/Azure/karpenter-provider-azure/pkg/operator/options"
 
@@ -54,6 +56,8 @@ import (
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/instancetype"
 	labelspkg "github.com/Azure/karpenter-provider-azure/pkg/providers/labels"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/launchtemplate"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/pricing"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/quota"
 	"github.com/Azure/karpenter-provider-azure/pkg/utils"
 	nodeclaimutils "github.com/Azure/karpenter-provider-azure/pkg/utils/nodeclaim"
 	"github.com/Azure/karpenter-provider-azure/pkg/utils/zones"
@@ -86,6 +90,8 @@ type CloudProvider struct {
 	imageProvider              imagefamily.NodeImageProvider
 	recorder                   events.Recorder
 	instanceTypeStore          *nodeoverlay.InstanceTypeStore
+	spotEvictions              *azurecache.SpotEvictions
+	quotaProvider              quota.Provider
 	instancePromiseWg          sync.WaitGroup
 }
 
@@ -97,6 +103,8 @@ func New(
 	kubeClient client.Client,
 	imageProvider imagefamily.NodeImageProvider,
 	store *nodeoverlay.InstanceTypeStore,
+	spotEvictions *azurecache.SpotEvictions,
+	quotaProvider quota.Provider,
 ) *CloudProvider {
 	return &CloudProvider{
 		instanceTypeProvider:       instanceTypeProvider,
@@ -106,6 +114,8 @@ func New(
 		imageProvider:              imageProvider,
 		recorder:                   recorder,
 		instanceTypeStore:          store,
+		spotEvictions:              spotEvictions,
+		quotaProvider:              quotaProvider,
 	}
 }
 
@@ -179,12 +189,13 @@ func (c *CloudProvider) createVMInstance(ctx context.Context, nodeClass *v1beta1
 		return nil, toCreateError(err, "creating instance failed")
 	}
 
-	if err := c.handleInstancePromise(ctx, vmPromise, nodeClaim); err != nil {
+	vm := vmPromise.VM // This is best-effort populated by Karpenter to be used to create the VM server-side. Not all fields are guaranteed to be populated, especially status fields.
+	// Double-check the code before making assumptions on their presence.
+	releaseQuota := c.reserveQuota(ctx, string(lo.FromPtr(vm.Properties.HardwareProfile.VMSize)), instance.GetCapacityTypeFromVM(vm))
+	if err := c.handleInstancePromise(ctx, vmPromise, nodeClaim, releaseQuota); err != nil {
 		return nil, err
 	}
 
-	vm := vmPromise.VM // This is best-effort populated by Karpenter to be used to create the VM server-side. Not all fields are guaranteed to be populated, especially status fields.
-	// Double-check the code before making assumptions on their presence.
 	instanceType, _ := lo.Find(instanceTypes, func(i *cloudprovider.InstanceType) bool {
 		return i.Name == string(lo.FromPtr(vm.Properties.HardwareProfile.VMSize))
 	})
@@ -200,7 +211,7 @@ func (c *CloudProvider) createVMInstance(ctx context.Context, nodeClass *v1beta1
 	// TODO: should we do like AWS and smuggle all of these labels through VM tags rather than just setting them here?
 	newNodeClaim.Labels = lo.Assign(newNodeClaim.Labels, labelspkg.GetWellKnownSingleValuedRequirementLabels(scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...)))
 
-	if err := setAdditionalAnnotationsForNewNodeClaim(ctx, newNodeClaim, nodeClass); err != nil {
+	if err := setAdditionalAnnotationsForNewNodeClaim(ctx, newNodeClaim, nodeClass, instanceType); err != nil {
 		return nil, err
 	}
 	return newNodeClaim, nil
@@ -214,7 +225,8 @@ func (c *CloudProvider) createAKSMachineInstance(ctx context.Context, nodeClass
 	}
 
 	// Handle the promise
-	if err := c.handleInstancePromise(ctx, aksMachinePromise, nodeClaim); err != nil {
+	releaseQuota := c.reserveQuota(ctx, aksMachinePromise.InstanceType.Name, aksMachinePromise.CapacityType)
+	if err := c.handleInstancePromise(ctx, aksMachinePromise, nodeClaim, releaseQuota); err != nil {
 		return nil, err
 	}
 
@@ -241,15 +253,31 @@ func (c *CloudProvider) createAKSMachineInstance(ctx context.Context, nodeClass
 	// TODO: should we do like AWS and smuggle all of these labels through VM tags rather than just setting them here?
 	newNodeClaim.Labels = lo.Assign(newNodeClaim.Labels, labelspkg.GetWellKnownSingleValuedRequirementLabels(scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...)))
 
-	if err := setAdditionalAnnotationsForNewNodeClaim(ctx, newNodeClaim, nodeClass); err != nil {
+	if err := setAdditionalAnnotationsForNewNodeClaim(ctx, newNodeClaim, nodeClass, aksMachinePromise.InstanceType); err != nil {
 		return nil, err
 	}
 
 	return newNodeClaim, nil
 }
 
+// reserveQuota counts an on-demand create against the quota until it is reflected in the quota usages, so that
+// offerings that would exceed the quota are filtered out in the meantime. The returned function is called once
+// the create completes, with whether it succeeded. Spot VMs don't consume the family or regional vCPU quota.
+func (c *CloudProvider) reserveQuota(ctx context.Context, instanceTypeName, capacityType string) func(created bool) {
+	if capacityType != karpv1.CapacityTypeOnDemand {
+		return func(bool) {}
+	}
+	sku, err := c.instanceTypeProvider.Get(ctx, instanceTypeName)
+	if err != nil {
+		log.FromContext(ctx).V(1).Info("not counting in-flight create against quota, instance type not found", "instance-type", instanceTypeName, "error", err)
+		return func(bool) {}
+	}
+	return c.quotaProvider.Reserve(sku)
+}
+
 // handleInstancePromise handles the instance promise, primarily deciding on sync/async provisioning.
-func (c *CloudProvider) handleInstancePromise(ctx context.Context, instancePromise instance.Promise, nodeClaim *karpv1.NodeClaim) error {
+// releaseQuota is called once the instance is no longer in flight, with whether it was created.
+func (c *CloudProvider) handleInstancePromise(ctx context.Context, instancePromise instance.Promise, nodeClaim *karpv1.NodeClaim, releaseQuota func(created bool)) error {
 	if isNodeClaimStandalone(nodeClaim) {
 		// Standalone NodeClaims aren't re-queued for reconciliation in the provision_trigger controller,
 		// so we delete them synchronously. After marking Launched=true,
@@ -257,6 +285,7 @@ func (c *CloudProvider) handleInstancePromise(ctx context.Context, instancePromi
 		// the launch controller. This ensures we retry continuously until we hit the registration TTL
 		err := instancePromise.Wait()
 		if err != nil {
+			releaseQuota(false)
 			c.handleInstancePromiseWaitError(ctx, instancePromise, nodeClaim, err)
 			return toCreateError(err, "creating standalone instance failed")
 		}
@@ -281,6 +310,7 @@ func (c *CloudProvider) handleInstancePromise(ctx context.Context, instancePromi
 		}()
 
 		err := instancePromise.Wait()
+		releaseQuota(err == nil)
 
 		// Wait until the claim is Launched, to avoid racing with creation.
 		// This isn't strictly required, but without this, failure test scenarios are harder
@@ -463,6 +493,8 @@ func (c *CloudProvider) GetInstanceTypes(ctx context.Context, nodePool *karpv1.N
 func (c *CloudProvider) Delete(ctx context.Context, nodeClaim *karpv1.NodeClaim) error {
 	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithValues("NodeClaim", nodeClaim.Name))
 
+	c.recordSpotEviction(ctx, nodeClaim)
+
 	// AKS machine-based node
 	if aksMachineName, isAKSMachine := instance.GetAKSMachineNameFromNodeClaim(nodeClaim); isAKSMachine {
 		return c.aksMachineInstanceProvider.Delete(ctx, aksMachineName)
@@ -476,6 +508,27 @@ func (c *CloudProvider) Delete(ctx context.Context, nodeClaim *karpv1.NodeClaim)
 	return c.vmInstanceProvider.Delete(ctx, vmName)
 }
 
+// recordSpotEviction records the eviction of a spot NodeClaim whose Node was scheduled for preemption
+// (see SpotConditionPreemptionScheduled), so that frequently evicted spot offerings can be ranked lower.
+// Other deletions (e.g. consolidation) are not evictions, and are ignored.
+func (c *CloudProvider) recordSpotEviction(ctx context.Context, nodeClaim *karpv1.NodeClaim) {
+	if c.spotEvictions == nil || nodeClaim.Labels[karpv1.CapacityTypeLabelKey] != karpv1.CapacityTypeSpot || nodeClaim.Status.NodeName == "" {
+		return
+	}
+	node := &corev1.Node{}
+	if err := c.kubeClient.Get(ctx, types.NamespacedName{Name: nodeClaim.Status.NodeName}, node); err != nil {
+		log.FromContext(ctx).V(1).Info("failed to get Node to check for spot preemption", "Node", nodeClaim.Status.NodeName, "error", err)
+		return
+	}
+	preempted := lo.ContainsBy(node.Status.Conditions, func(condition corev1.NodeCondition) bool {
+		return condition.Type == SpotConditionPreemptionScheduled && condition.Status == corev1.ConditionTrue
+	})
+	if !preempted {
+		return
+	}
+	c.spotEvictions.MarkEvicted(ctx, string(nodeClaim.UID), nodeClaim.Labels[corev1.LabelInstanceTypeStable], nodeClaim.Labels[corev1.LabelTopologyZone])
+}
+
 // IsDrifted checks if the NodeClaim has drifted from our goal state.
 // Note: During the initial launch and registration of a NodeClaim,
 // core calls IsDrifted quite frequently as it waits for the Node to register and become ready. This is
@@ -722,7 +775,7 @@ func toCreateError(err error, wrapMsg string) error {
 	return cloudprovider.NewCreateError(fmt.Errorf("%s, %w", wrapMsg, err), reason, truncateMessage(message))
 }
 
-func setAdditionalAnnotationsForNewNodeClaim(ctx context.Context, nodeClaim *karpv1.NodeClaim, nodeClass *v1beta1.AKSNodeClass) error {
+func setAdditionalAnnotationsForNewNodeClaim(ctx context.Context, nodeClaim *karpv1.NodeClaim, nodeClass *v1beta1.AKSNodeClass, instanceType *cloudprovider.InstanceType) error {
 	// Additional annotations
 	// ASSUMPTION: this is not needed in other places that the core also wants NodeClaim (e.g., Get, List).
 	// As of the time of writing, AWS is doing something similar.
@@ -736,9 +789,27 @@ func setAdditionalAnnotationsForNewNodeClaim(ctx context.Context, nodeClaim *kar
 		v1beta1.AnnotationAKSNodeClassHashVersion: v1beta1.AKSNodeClassHashVersion,
 		v1beta1.AnnotationInPlaceUpdateHash:       inPlaceUpdateHash,
 	})
+	if price, ok := hourlyPrice(instanceType, nodeClaim.Labels[karpv1.CapacityTypeLabelKey]); ok {
+		nodeClaim.Annotations[v1beta1.AnnotationHourlyPrice] = strconv.FormatFloat(price, 'f', -1, 64)
+	}
 	return nil
 }
 
+// hourlyPrice returns the price of the offering of the instance type with the given capacity type, the same one that
+// was used to choose the instance type. Prices don't vary across the zones of a region.
+func hourlyPrice(instanceType *cloudprovider.InstanceType, capacityType string) (float64, bool) {
+	if instanceType == nil {
+		return 0, false
+	}
+	offering, ok := lo.Find(instanceType.Offerings, func(o *cloudprovider.Offering) bool {
+		return o.CapacityType() == capacityType
+	})
+	if !ok || offering.Price == pricing.MissingPrice {
+		return 0, false
+	}
+	return offering.Price, true
+}
+
 func (c *CloudProvider) resolveNodeClaimFromAKSMachine(ctx context.Context, aksMachine *armcontainerservice.Machine) (*karpv1.NodeClaim, error) {
 	var instanceTypes []*cloudprovider.InstanceType
 	nodePool, err := instance.FindNodePoolFromAKSMachine(ctx, aksMachine, c.kubeClient)
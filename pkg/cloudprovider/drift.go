@@ -183,8 +183,11 @@ func (c *CloudProvider) isImageVersionDrifted(
 			}
 		}
 	} else {
+		// Note: new versions of a custom image definition (spec.imageID) are surfaced through the available images in the same way,
+		// so they are covered here as well. Resource IDs are case-insensitive, and ARM does not always preserve the casing of
+		// user-supplied IDs (e.g. resource group names), so we compare them accordingly.
 		for _, availableImage := range nodeImages {
-			if availableImage.ID == nodeClaim.Status.ImageID {
+			if strings.EqualFold(availableImage.ID, nodeClaim.Status.ImageID) {
 				return "", nil
 			}
 		}
@@ -18,6 +18,7 @@ package cloudprovider
 
 import (
 	"fmt"
+	"time"
 
 	. "github.com/Azure/karpenter-provider-azure/pkg/test/expectations"
 	. "github.com/onsi/ginkgo/v2"
@@ -38,6 +39,7 @@ import (
 	. "sigs.k8s.io/karpenter/pkg/test/expectations"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice/v9"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork"
 	"github.com/Azure/karpenter-provider-azure/pkg/apis/v1beta1"
 	"github.com/Azure/karpenter-provider-azure/pkg/consts"
 	"github.com/Azure/karpenter-provider-azure/pkg/controllers/nodeclass/status"
@@ -60,10 +62,10 @@ var _ = Describe("CloudProvider", func() {
 
 			azureEnv = test.NewEnvironment(ctx, env)
 			azureEnvNonZonal = test.NewEnvironmentNonZonal(ctx, env)
-			statusController = status.NewController(env.Client, azureEnv.KubernetesVersionProvider, azureEnv.ImageProvider, env.KubernetesInterface, env.KubernetesInterface, azureEnv.DynamicInterface, azureEnv.SubnetsAPI, azureEnv.DiskEncryptionSetsAPI, testOptions.ParsedDiskEncryptionSetID, options.FromContext(ctx).NetworkPolicy, options.FromContext(ctx).NetworkPlugin)
+			statusController = status.NewController(env.Client, azureEnv.KubernetesVersionProvider, azureEnv.ImageProvider, env.KubernetesInterface, env.KubernetesInterface, azureEnv.DynamicInterface, azureEnv.SubnetsAPI, azureEnv.ApplicationSecurityGroupsAPI, azureEnv.NetworkSecurityGroupAPI, azureEnv.DiskEncryptionSetsAPI, testOptions.ParsedDiskEncryptionSetID, options.FromContext(ctx).NetworkPolicy, options.FromContext(ctx).NetworkPlugin, azureEnv.CapacityReservationProvider, azureEnv.SubnetCapacityProvider, azureEnv.PricingProvider)
 			test.ApplyDefaultStatus(nodeClass, env, testOptions.UseSIG)
-			cloudProvider = New(azureEnv.InstanceTypesProvider, azureEnv.VMInstanceProvider, azureEnv.AKSMachineProvider, recorder, env.Client, azureEnv.ImageProvider, azureEnv.InstanceTypeStore)
-			cloudProviderNonZonal = New(azureEnvNonZonal.InstanceTypesProvider, azureEnvNonZonal.VMInstanceProvider, azureEnvNonZonal.AKSMachineProvider, events.NewRecorder(&record.FakeRecorder{}), env.Client, azureEnvNonZonal.ImageProvider, azureEnvNonZonal.InstanceTypeStore)
+			cloudProvider = New(azureEnv.InstanceTypesProvider, azureEnv.VMInstanceProvider, azureEnv.AKSMachineProvider, recorder, env.Client, azureEnv.ImageProvider, azureEnv.InstanceTypeStore, azureEnv.SpotEvictionsCache, azureEnv.QuotaProvider)
+			cloudProviderNonZonal = New(azureEnvNonZonal.InstanceTypesProvider, azureEnvNonZonal.VMInstanceProvider, azureEnvNonZonal.AKSMachineProvider, events.NewRecorder(&record.FakeRecorder{}), env.Client, azureEnvNonZonal.ImageProvider, azureEnvNonZonal.InstanceTypeStore, azureEnvNonZonal.SpotEvictionsCache, azureEnvNonZonal.QuotaProvider)
 
 			cluster = state.NewCluster(fakeClock, env.Client, cloudProvider)
 			clusterNonZonal = state.NewCluster(fakeClock, env.Client, cloudProviderNonZonal)
@@ -320,7 +322,7 @@ var _ = Describe("CloudProvider", func() {
 
 				aksAzureEnv := test.NewEnvironment(aksCtx, env)
 				test.ApplyDefaultStatus(nodeClass, env, aksTestOptions.UseSIG)
-				aksCloudProvider := New(aksAzureEnv.InstanceTypesProvider, aksAzureEnv.VMInstanceProvider, aksAzureEnv.AKSMachineProvider, recorder, env.Client, aksAzureEnv.ImageProvider, aksAzureEnv.InstanceTypeStore)
+				aksCloudProvider := New(aksAzureEnv.InstanceTypesProvider, aksAzureEnv.VMInstanceProvider, aksAzureEnv.AKSMachineProvider, recorder, env.Client, aksAzureEnv.ImageProvider, aksAzureEnv.InstanceTypeStore, aksAzureEnv.SpotEvictionsCache, aksAzureEnv.QuotaProvider)
 				aksCluster := state.NewCluster(fakeClock, env.Client, aksCloudProvider)
 				aksProv := provisioning.NewProvisioner(env.Client, recorder, aksCloudProvider, aksCluster, fakeClock, deviceallocation.NewController(env.Client))
 
@@ -355,6 +357,38 @@ var _ = Describe("CloudProvider", func() {
 		// - VMs control detailed StorageProfile, DiffDiskSettings, Placement (NVMe/Cache)
 		// - AKS machines use OSDiskType (Managed/Ephemeral) and OSDiskSizeGB
 		// - AKS machines automatically handles placement decisions (NVMe vs Cache disk)
+		Context("Create - Subnet Capacity", func() {
+			updateSubnet := func(addressPrefix string, ipConfigurations int) string {
+				subnetID := lo.Ternary(nodeClass.Spec.VNETSubnetID != nil, lo.FromPtr(nodeClass.Spec.VNETSubnetID), options.FromContext(ctx).SubnetID)
+				azureEnv.SubnetCapacityProvider.Update(subnetID, &armnetwork.Subnet{Properties: &armnetwork.SubnetPropertiesFormat{
+					AddressPrefix:    lo.ToPtr(addressPrefix),
+					IPConfigurations: lo.Times(ipConfigurations, func(_ int) *armnetwork.IPConfiguration { return &armnetwork.IPConfiguration{} }),
+				}}, time.Now().Add(-time.Minute))
+				return subnetID
+			}
+
+			It("should not create AKS machines when the subnet has no free IPs", func() {
+				updateSubnet("10.0.0.0/29", 3) // 0 free IPs
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisionedAndWaitForPromises(ctx, env.Client, cluster, cloudProvider, coreProvisioner, azureEnv, pod)
+				ExpectNotScheduled(ctx, env.Client, pod)
+				Expect(azureEnv.AKSMachinesAPI.AKSMachineCreateOrUpdateBehavior.CalledWithInput.Len()).To(Equal(0))
+			})
+
+			It("should reserve the IP of the node in its subnet", func() {
+				subnetID := updateSubnet("10.0.0.0/28", 0) // 11 free IPs
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisionedAndWaitForPromises(ctx, env.Client, cluster, cloudProvider, coreProvisioner, azureEnv, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+
+				available, ok := azureEnv.SubnetCapacityProvider.Available(subnetID)
+				Expect(ok).To(BeTrue())
+				Expect(available).To(Equal(int64(10)))
+			})
+		})
+
 		Context("Create - Ephemeral Disk", func() {
 			// Ported from VM test: "should use ephemeral disk if supported, and has space of at least 128GB by default"
 			It("should use ephemeral disk if supported, and has space of at least 128GB by default", func() {
@@ -17,6 +17,8 @@ limitations under the License.
 package cloudprovider
 
 import (
+	"strings"
+
 	"github.com/blang/semver/v4"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -53,7 +55,7 @@ var _ = Describe("CloudProvider", func() {
 
 			azureEnv = test.NewEnvironment(ctx, env)
 			test.ApplyDefaultStatus(nodeClass, env, testOptions.UseSIG)
-			cloudProvider = New(azureEnv.InstanceTypesProvider, azureEnv.VMInstanceProvider, azureEnv.AKSMachineProvider, recorder, env.Client, azureEnv.ImageProvider, azureEnv.InstanceTypeStore)
+			cloudProvider = New(azureEnv.InstanceTypesProvider, azureEnv.VMInstanceProvider, azureEnv.AKSMachineProvider, recorder, env.Client, azureEnv.ImageProvider, azureEnv.InstanceTypeStore, azureEnv.SpotEvictionsCache, azureEnv.QuotaProvider)
 
 			cluster = state.NewCluster(fakeClock, env.Client, cloudProvider)
 			coreProvisioner = provisioning.NewProvisioner(env.Client, recorder, cloudProvider, cluster, fakeClock, deviceallocation.NewController(env.Client))
@@ -78,7 +80,7 @@ var _ = Describe("CloudProvider", func() {
 				ctx = options.ToContext(ctx, testOptions)
 				azureEnv = test.NewEnvironment(ctx, env)
 				test.ApplyDefaultStatus(nodeClass, env, testOptions.UseSIG)
-				cloudProvider = New(azureEnv.InstanceTypesProvider, azureEnv.VMInstanceProvider, azureEnv.AKSMachineProvider, recorder, env.Client, azureEnv.ImageProvider, azureEnv.InstanceTypeStore)
+				cloudProvider = New(azureEnv.InstanceTypesProvider, azureEnv.VMInstanceProvider, azureEnv.AKSMachineProvider, recorder, env.Client, azureEnv.ImageProvider, azureEnv.InstanceTypeStore, azureEnv.SpotEvictionsCache, azureEnv.QuotaProvider)
 				cluster = state.NewCluster(fakeClock, env.Client, cloudProvider)
 				coreProvisioner = provisioning.NewProvisioner(env.Client, recorder, cloudProvider, cluster, fakeClock, deviceallocation.NewController(env.Client))
 
@@ -184,6 +186,38 @@ var _ = Describe("CloudProvider", func() {
 					Expect(err).ToNot(HaveOccurred())
 					Expect(drifted).To(Equal(ImageDrift))
 				})
+
+				Context("Custom images", func() {
+					const customImageDefinitionID = "/subscriptions/12345678-1234-1234-1234-123456789012/resourceGroups/my-rg/providers/Microsoft.Compute/galleries/mygallery/images/hardened-ubuntu"
+
+					BeforeEach(func() {
+						nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+						nodeClass.Status.Images = []v1beta1.NodeImage{{ID: customImageDefinitionID + "/versions/1.0.0"}}
+						ExpectApplied(ctx, env.Client, nodeClass)
+						driftNodeClaim.Status.ImageID = customImageDefinitionID + "/versions/1.0.0"
+					})
+
+					It("should succeed with no drift when the custom image version is current", func() {
+						drifted, err := cloudProvider.IsDrifted(ctx, driftNodeClaim)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(drifted).To(Equal(NoDrift))
+					})
+
+					It("should succeed with no drift when the image ID only differs in casing", func() {
+						driftNodeClaim.Status.ImageID = strings.ToLower(driftNodeClaim.Status.ImageID)
+						drifted, err := cloudProvider.IsDrifted(ctx, driftNodeClaim)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(drifted).To(Equal(NoDrift))
+					})
+
+					It("should trigger drift when a new version of the custom image definition is available", func() {
+						nodeClass.Status.Images = []v1beta1.NodeImage{{ID: customImageDefinitionID + "/versions/1.0.1"}}
+						ExpectApplied(ctx, env.Client, nodeClass)
+						drifted, err := cloudProvider.IsDrifted(ctx, driftNodeClaim)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(drifted).To(Equal(ImageDrift))
+					})
+				})
 			})
 
 			Context("Kubernetes Version", func() {
@@ -19,6 +19,8 @@ package cloudprovider
 // TODO v1beta1 extra refactor into suite_test.go / cloudprovider_test.go
 import (
 	"context"
+	"fmt"
+	"strconv"
 	"testing"
 	"time"
 
@@ -49,12 +51,14 @@ import (
 
 	"github.com/Azure/karpenter-provider-azure/pkg/apis"
 	"github.com/Azure/karpenter-provider-azure/pkg/apis/v1beta1"
+	azurecache "github.com/Azure/karpenter-provider-azure/pkg/cache"
 	"github.com/Azure/karpenter-provider-azure/pkg/consts"
 	"github.com/Azure/karpenter-provider-azure/pkg/controllers/nodeclass/status"
 	"github.com/Azure/karpenter-provider-azure/pkg/fake"
 	"github.com/Azure/karpenter-provider-azure/pkg/operator/options"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/instance"
 	"github.com/Azure/karpenter-provider-azure/pkg/test"
+	"github.com/Azure/karpenter-provider-azure/pkg/utils"
 	"github.com/Azure/karpenter-provider-azure/pkg/utils/zones"
 )
 
@@ -291,7 +295,7 @@ var _ = Describe("CloudProvider", func() {
 
 			azureEnv = test.NewEnvironment(ctx, env)
 			test.ApplyDefaultStatus(nodeClass, env, testOptions.UseSIG)
-			cloudProvider = New(azureEnv.InstanceTypesProvider, azureEnv.VMInstanceProvider, azureEnv.AKSMachineProvider, recorder, env.Client, azureEnv.ImageProvider, azureEnv.InstanceTypeStore)
+			cloudProvider = New(azureEnv.InstanceTypesProvider, azureEnv.VMInstanceProvider, azureEnv.AKSMachineProvider, recorder, env.Client, azureEnv.ImageProvider, azureEnv.InstanceTypeStore, azureEnv.SpotEvictionsCache, azureEnv.QuotaProvider)
 
 			cluster = state.NewCluster(fakeClock, env.Client, cloudProvider)
 			coreProvisioner = provisioning.NewProvisioner(env.Client, recorder, cloudProvider, cluster, fakeClock, deviceallocation.NewController(env.Client))
@@ -361,6 +365,57 @@ var _ = Describe("CloudProvider", func() {
 			Expect(corecloudprovider.IsInsufficientCapacityError(err)).To(BeTrue())
 			Expect(cloudProviderMachine).To(BeNil())
 		})
+		It("should annotate the nodeclaim with the hourly price of its instance", func() {
+			nodeClaim.Spec.Requirements = []karpv1.NodeSelectorRequirementWithMinValues{
+				{Key: v1.LabelInstanceTypeStable, Operator: v1.NodeSelectorOpIn, Values: []string{"Standard_D2s_v3"}},
+				{Key: karpv1.CapacityTypeLabelKey, Operator: v1.NodeSelectorOpIn, Values: []string{karpv1.CapacityTypeOnDemand}},
+			}
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			createdNodeClaim, err := CreateAndWaitForPromises(ctx, cloudProvider, azureEnv, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+
+			price, ok := azureEnv.PricingProvider.OnDemandPrice("Standard_D2s_v3")
+			Expect(ok).To(BeTrue())
+			Expect(createdNodeClaim.Annotations).To(HaveKeyWithValue(v1beta1.AnnotationHourlyPrice, strconv.FormatFloat(price, 'f', -1, 64)))
+		})
+
+		Context("Spot evictions", func() {
+			var node *v1.Node
+
+			BeforeEach(func() {
+				node = coretest.Node(coretest.NodeOptions{
+					Conditions: []v1.NodeCondition{{Type: SpotConditionPreemptionScheduled, Status: v1.ConditionTrue}},
+				})
+				nodeClaim.Labels = lo.Assign(nodeClaim.Labels, map[string]string{
+					karpv1.CapacityTypeLabelKey: karpv1.CapacityTypeSpot,
+					v1.LabelInstanceTypeStable:  "Standard_D2_v2",
+					v1.LabelTopologyZone:        fakeZone1,
+				})
+				nodeClaim.Status.NodeName = node.Name
+				nodeClaim.Status.ProviderID = utils.VMResourceIDToProviderID(ctx, fmt.Sprintf("/subscriptions/subscriptionID/resourceGroups/test-resourceGroup/providers/Microsoft.Compute/virtualMachines/%s", nodeClaim.Name))
+			})
+
+			It("should record the eviction of a preempted spot node", func() {
+				ExpectApplied(ctx, env.Client, node)
+				_ = cloudProvider.Delete(ctx, nodeClaim)
+				// observing the same eviction again doesn't count it twice
+				_ = cloudProvider.Delete(ctx, nodeClaim)
+				Expect(azureEnv.SpotEvictionsCache.EvictionRate("Standard_D2_v2", fakeZone1)).To(
+					Equal(1 / azurecache.SpotEvictionsWindow.Hours()))
+			})
+			It("should not record the deletion of a spot node that wasn't preempted", func() {
+				node.Status.Conditions = nil
+				ExpectApplied(ctx, env.Client, node)
+				_ = cloudProvider.Delete(ctx, nodeClaim)
+				Expect(azureEnv.SpotEvictionsCache.EvictionRate("Standard_D2_v2", fakeZone1)).To(BeZero())
+			})
+			It("should not record the deletion of an on-demand node", func() {
+				nodeClaim.Labels[karpv1.CapacityTypeLabelKey] = karpv1.CapacityTypeOnDemand
+				ExpectApplied(ctx, env.Client, node)
+				_ = cloudProvider.Delete(ctx, nodeClaim)
+				Expect(azureEnv.SpotEvictionsCache.EvictionRate("Standard_D2_v2", fakeZone1)).To(BeZero())
+			})
+		})
 
 		runNodeOverlayCapacityTests(vmNodeOverlayCapacityTestOptions())
 
@@ -407,7 +462,7 @@ var _ = Describe("CloudProvider", func() {
 
 			azureEnv = test.NewEnvironment(ctx, env)
 			test.ApplyDefaultStatus(nodeClass, env, testOptions.UseSIG)
-			cloudProvider = New(azureEnv.InstanceTypesProvider, azureEnv.VMInstanceProvider, azureEnv.AKSMachineProvider, recorder, env.Client, azureEnv.ImageProvider, azureEnv.InstanceTypeStore)
+			cloudProvider = New(azureEnv.InstanceTypesProvider, azureEnv.VMInstanceProvider, azureEnv.AKSMachineProvider, recorder, env.Client, azureEnv.ImageProvider, azureEnv.InstanceTypeStore, azureEnv.SpotEvictionsCache, azureEnv.QuotaProvider)
 
 			cluster = state.NewCluster(fakeClock, env.Client, cloudProvider)
 			coreProvisioner = provisioning.NewProvisioner(env.Client, recorder, cloudProvider, cluster, fakeClock, deviceallocation.NewController(env.Client))
@@ -33,20 +33,32 @@ import (
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
 	"github.com/Azure/karpenter-provider-azure/pkg/apis/v1beta1"
+	azurecache "github.com/Azure/karpenter-provider-azure/pkg/cache"
 	nodeclaimgarbagecollection "github.com/Azure/karpenter-provider-azure/pkg/controllers/nodeclaim/garbagecollection"
 	nodeclasshash "github.com/Azure/karpenter-provider-azure/pkg/controllers/nodeclass/hash"
 	nodeclassstatus "github.com/Azure/karpenter-provider-azure/pkg/controllers/nodeclass/status"
 	nodeclasstermination "github.com/Azure/karpenter-provider-azure/pkg/controllers/nodeclass/termination"
 
 	instancetypecontroller "github.com/Azure/karpenter-provider-azure/pkg/controllers/instancetype"
+	"github.com/Azure/karpenter-provider-azure/pkg/controllers/memoryoverhead"
+	nodeclaimcost "github.com/Azure/karpenter-provider-azure/pkg/controllers/nodeclaim/cost"
 	"github.com/Azure/karpenter-provider-azure/pkg/controllers/nodeclaim/inplaceupdate"
+	"github.com/Azure/karpenter-provider-azure/pkg/controllers/pricingoverrides"
 	quotacontroller "github.com/Azure/karpenter-provider-azure/pkg/controllers/quota"
+	"github.com/Azure/karpenter-provider-azure/pkg/controllers/spotevictions"
+	"github.com/Azure/karpenter-provider-azure/pkg/controllers/spotpricehistory"
+	"github.com/Azure/karpenter-provider-azure/pkg/controllers/unavailableofferings"
+	"github.com/Azure/karpenter-provider-azure/pkg/operator/options"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/azclient/azapi"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/capacityreservation"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/imagefamily"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/instance"
 	instancetypeprovider "github.com/Azure/karpenter-provider-azure/pkg/providers/instancetype"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/kubernetesversion"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/networksecuritygroup"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/pricing"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/quota"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/subnetcapacity"
 )
 
 func NewControllers(
@@ -62,29 +74,57 @@ func NewControllers(
 	nodeImageProvider imagefamily.NodeImageProvider,
 	instanceTypesProvider instancetypeprovider.Provider,
 	quotaProvider quota.Provider,
+	quotaRequestsClient quota.RequestsAPI,
+	unavailableOfferings *azurecache.UnavailableOfferings,
+	spotEvictions *azurecache.SpotEvictions,
+	memoryOverheads *azurecache.MemoryOverheads,
+	spotPriceHistory *azurecache.SpotPriceHistory,
+	pricingProvider *pricing.Provider,
 	inClusterKubernetesInterface kubernetes.Interface,
 	managedKubernetesInterface kubernetes.Interface,
 	managedDynamicInterface dynamic.Interface,
 	subnetsClient azapi.SubnetsAPI,
+	applicationSecurityGroupsClient azapi.ApplicationSecurityGroupsAPI,
+	networkSecurityGroupsClient networksecuritygroup.API,
 	diskEncryptionSetsClient azapi.DiskEncryptionSetsAPI,
 	parsedDiskEncryptionSetID *arm.ResourceID,
 	networkPolicy string,
 	networkPlugin string,
+	capacityReservationProvider *capacityreservation.Provider,
+	subnetCapacityProvider *subnetcapacity.Provider,
 ) []controller.Controller {
 	controllers := []controller.Controller{
 		nodeclasshash.NewController(kubeClient),
-		nodeclassstatus.NewController(kubeClient, kubernetesVersionProvider, nodeImageProvider, inClusterKubernetesInterface, managedKubernetesInterface, managedDynamicInterface, subnetsClient, diskEncryptionSetsClient, parsedDiskEncryptionSetID, networkPolicy, networkPlugin),
+		nodeclassstatus.NewController(kubeClient, kubernetesVersionProvider, nodeImageProvider, inClusterKubernetesInterface, managedKubernetesInterface, managedDynamicInterface, subnetsClient, applicationSecurityGroupsClient, networkSecurityGroupsClient, diskEncryptionSetsClient, parsedDiskEncryptionSetID, networkPolicy, networkPlugin, capacityReservationProvider, subnetCapacityProvider, pricingProvider),
 		nodeclasstermination.NewController(kubeClient, recorder),
 
 		nodeclaimgarbagecollection.NewInstance(kubeClient, cloudProvider),
 		nodeclaimgarbagecollection.NewNetworkInterface(kubeClient, vmInstanceProvider),
+		nodeclaimgarbagecollection.NewPublicIP(kubeClient, vmInstanceProvider),
+		nodeclaimcost.NewController(kubeClient, pricingProvider),
 
 		// TODO: nodeclaim tagging
 		inplaceupdate.NewController(kubeClient, vmInstanceProvider, aksMachineInstanceProvider),
 		status.NewController[*v1beta1.AKSNodeClass](kubeClient, mgr.GetEventRecorderFor("karpenter")), //nolint:staticcheck // SA1019: will be replaced by mgr.GetEventRecorder once operatorpkg is updated
 
 		instancetypecontroller.NewController(instanceTypesProvider),
+		memoryoverhead.NewController(kubeClient, inClusterKubernetesInterface, instanceTypesProvider, memoryOverheads),
 		quotacontroller.NewController(quotaProvider, clk),
+		unavailableofferings.NewStatusController(kubeClient, unavailableOfferings),
+		spotevictions.NewController(spotEvictions),
+	}
+	// The spot price history, and its metrics, are only kept to avoid rising spot prices
+	if options.FromContext(ctx).AvoidRisingSpotPrices {
+		controllers = append(controllers, spotpricehistory.NewController(inClusterKubernetesInterface, pricingProvider, spotPriceHistory))
+	}
+	if options.FromContext(ctx).QuotaIncreaseMaxLimit > 0 {
+		controllers = append(controllers, quotacontroller.NewIncreaseController(kubeClient, inClusterKubernetesInterface, recorder, clk, quotaProvider, quotaRequestsClient, instanceTypesProvider, unavailableOfferings))
+	}
+	if options.FromContext(ctx).PersistUnavailableOfferings {
+		controllers = append(controllers, unavailableofferings.NewController(inClusterKubernetesInterface, unavailableOfferings))
+	}
+	if configMapName := options.FromContext(ctx).PricingOverridesConfigMap; configMapName != "" {
+		controllers = append(controllers, pricingoverrides.NewController(inClusterKubernetesInterface, pricingProvider, configMapName))
 	}
 	return controllers
 }
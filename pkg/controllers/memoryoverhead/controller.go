@@ -0,0 +1,168 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package memoryoverhead
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/awslabs/operatorpkg/reconciler"
+	"github.com/awslabs/operatorpkg/singleton"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/client-go/kubernetes"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+
+	azurecache "github.com/Azure/karpenter-provider-azure/pkg/cache"
+	"github.com/Azure/karpenter-provider-azure/pkg/logging"
+	instancetypeprovider "github.com/Azure/karpenter-provider-azure/pkg/providers/instancetype"
+	"github.com/Azure/karpenter-provider-azure/pkg/utils/configmapstore"
+)
+
+const (
+	// ConfigMapName is the name of the ConfigMap, in the system namespace, that the learned memory overheads are persisted to
+	ConfigMapName = "karpenter-memory-overheads"
+	// ConfigMapDataKey is the key of the ConfigMap data holding the serialized memory overheads
+	ConfigMapDataKey = "memoryOverheads"
+	// LearnInterval is how often the memory overheads are learned from the nodes, and persisted when they changed
+	LearnInterval = time.Minute
+	// MaxMemoryOverhead bounds the overheads that are learned, larger ones are assumed to come from nodes that don't
+	// report the memory of their VM (e.g. because of hugepages or a memory reservation of the image) and are ignored
+	MaxMemoryOverhead = 0.5
+)
+
+// Controller learns the memory overhead of each instance type by comparing the memory capacity reported by registered nodes
+// with the memory advertised by their SKU. The overheads replace the flat VMMemoryOverheadPercent when computing the
+// capacity of instance types, and are persisted to a ConfigMap so that they survive controller restarts.
+type Controller struct {
+	kubeClient           client.Client
+	instanceTypeProvider instancetypeprovider.Provider
+	memoryOverheads      *azurecache.MemoryOverheads
+	systemNamespace      string
+	store                *configmapstore.Store
+
+	restored bool
+}
+
+func NewController(
+	kubeClient client.Client,
+	inClusterKubernetesInterface kubernetes.Interface,
+	instanceTypeProvider instancetypeprovider.Provider,
+	memoryOverheads *azurecache.MemoryOverheads,
+) *Controller {
+	systemNamespace := strings.TrimSpace(os.Getenv("SYSTEM_NAMESPACE"))
+	return &Controller{
+		kubeClient:           kubeClient,
+		instanceTypeProvider: instanceTypeProvider,
+		memoryOverheads:      memoryOverheads,
+		systemNamespace:      systemNamespace,
+		store:                configmapstore.New(inClusterKubernetesInterface, systemNamespace, ConfigMapName, ConfigMapDataKey),
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context) (reconciler.Result, error) {
+	ctx = injection.WithControllerName(ctx, "instancetype.memoryoverhead")
+
+	// Restore before learning, so that overheads learned since are not overwritten by stale ones
+	if c.systemNamespace != "" && !c.restored {
+		snapshot := map[string]float64{}
+		restored, err := c.store.Restore(ctx, &snapshot)
+		if err != nil {
+			return reconciler.Result{}, err
+		}
+		if restored {
+			c.memoryOverheads.Restore(snapshot)
+		}
+		c.restored = true
+	}
+	if err := c.learn(ctx); err != nil {
+		return reconciler.Result{}, err
+	}
+	if c.systemNamespace != "" {
+		if err := c.store.Persist(ctx, c.memoryOverheads.Snapshot()); err != nil {
+			return reconciler.Result{}, err
+		}
+	}
+	return reconciler.Result{RequeueAfter: LearnInterval}, nil
+}
+
+// learn records the largest memory overhead observed across the registered nodes of each instance type
+func (c *Controller) learn(ctx context.Context) error {
+	nodes := &corev1.NodeList{}
+	if err := c.kubeClient.List(ctx, nodes, client.MatchingLabels{karpv1.NodeRegisteredLabelKey: "true"}); err != nil {
+		return fmt.Errorf("listing nodes, %w", err)
+	}
+	overheads := map[string]float64{}
+	for i := range nodes.Items {
+		instanceType, overhead, ok := c.nodeMemoryOverhead(ctx, &nodes.Items[i])
+		if !ok {
+			continue
+		}
+		overheads[instanceType] = max(overheads[instanceType], overhead)
+	}
+	for instanceType, overhead := range overheads {
+		if c.memoryOverheads.Set(instanceType, overhead) {
+			log.FromContext(ctx).V(1).Info("learned memory overhead", logging.InstanceType, instanceType, "overhead", overhead)
+		}
+	}
+	return nil
+}
+
+// nodeMemoryOverhead returns the instance type of the node, and the fraction of the memory of its SKU that the node doesn't report
+func (c *Controller) nodeMemoryOverhead(ctx context.Context, node *corev1.Node) (string, float64, bool) {
+	instanceType := node.Labels[corev1.LabelInstanceTypeStable]
+	capacity, ok := node.Status.Capacity[corev1.ResourceMemory]
+	if instanceType == "" || !ok || capacity.IsZero() {
+		return "", 0, false
+	}
+	sku, err := c.instanceTypeProvider.Get(ctx, instanceType)
+	if err != nil {
+		return "", 0, false
+	}
+	memoryGiB, err := sku.Memory()
+	if err != nil {
+		return "", 0, false
+	}
+	// the capacity of instance types is computed from whole GiBs, see instancetype.CalculateMemoryWithoutOverhead
+	skuMemory := resource.MustParse(fmt.Sprintf("%dGi", int64(memoryGiB)))
+	if skuMemory.IsZero() {
+		return "", 0, false
+	}
+	overhead := 1 - capacity.AsApproximateFloat64()/skuMemory.AsApproximateFloat64()
+	if overhead < 0 || overhead >= MaxMemoryOverhead {
+		log.FromContext(ctx).V(1).Info("ignoring unexpected memory overhead", "Node", node.Name, logging.InstanceType, instanceType, "overhead", overhead)
+		return "", 0, false
+	}
+	// rounded up, so that the capacity of the instance type doesn't exceed that of its nodes, nor changes with every node
+	return sku.GetName(), math.Ceil(overhead*10000) / 10000, true
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("instancetype.memoryoverhead").
+		WatchesRawSource(singleton.Source()).
+		Complete(singleton.AsReconciler(c))
+}
@@ -0,0 +1,191 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package memoryoverhead_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+
+	"github.com/Azure/karpenter-provider-azure/pkg/apis"
+	"github.com/Azure/karpenter-provider-azure/pkg/controllers/memoryoverhead"
+	"github.com/Azure/karpenter-provider-azure/pkg/operator/options"
+	"github.com/Azure/karpenter-provider-azure/pkg/test"
+)
+
+const systemNamespace = "karpenter"
+
+var ctx context.Context
+var env *coretest.Environment
+var azureEnv *test.Environment
+
+func TestController(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "MemoryOverheadController")
+}
+
+var _ = BeforeSuite(func() {
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	env = coretest.NewEnvironment(coretest.WithCRDs(apis.CRDs...), coretest.WithCRDs(v1alpha1.CRDs...))
+	azureEnv = test.NewEnvironment(ctx, env)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	azureEnv.Reset(ctx)
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("MemoryOverhead Controller", func() {
+	var kubernetesInterface *kubernetesfake.Clientset
+	var controller *memoryoverhead.Controller
+
+	BeforeEach(func() {
+		os.Setenv("SYSTEM_NAMESPACE", systemNamespace)
+		DeferCleanup(os.Unsetenv, "SYSTEM_NAMESPACE")
+		kubernetesInterface = kubernetesfake.NewClientset()
+		controller = memoryoverhead.NewController(env.Client, kubernetesInterface, azureEnv.InstanceTypesProvider, azureEnv.MemoryOverheadsCache)
+	})
+
+	registeredNode := func(instanceType string, memory string) *corev1.Node {
+		return coretest.Node(coretest.NodeOptions{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					karpv1.NodeRegisteredLabelKey:  "true",
+					corev1.LabelInstanceTypeStable: instanceType,
+				},
+			},
+			Capacity: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse(memory)},
+		})
+	}
+	getPersisted := func() map[string]float64 {
+		GinkgoHelper()
+		configMap, err := kubernetesInterface.CoreV1().ConfigMaps(systemNamespace).Get(ctx, memoryoverhead.ConfigMapName, metav1.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		snapshot := map[string]float64{}
+		Expect(json.Unmarshal([]byte(configMap.Data[memoryoverhead.ConfigMapDataKey]), &snapshot)).To(Succeed())
+		return snapshot
+	}
+
+	It("should return a requeue interval of 1 minute", func() {
+		result := ExpectSingletonReconciled(ctx, controller)
+		Expect(result.RequeueAfter).To(Equal(memoryoverhead.LearnInterval))
+	})
+	It("should learn the largest memory overhead across the registered nodes of an instance type", func() {
+		// Standard_D2s_v3 advertises 8GiB
+		ExpectApplied(ctx, env.Client, registeredNode("Standard_D2s_v3", "7864320Ki"), registeredNode("Standard_D2s_v3", "7549747Ki"))
+		ExpectSingletonReconciled(ctx, controller)
+
+		overhead, ok := azureEnv.MemoryOverheadsCache.Get("Standard_D2s_v3")
+		Expect(ok).To(BeTrue())
+		Expect(overhead).To(BeNumerically("~", 0.1, 0.0002))
+		Expect(getPersisted()).To(HaveKeyWithValue("standard_d2s_v3", overhead))
+	})
+	It("should use the learned memory overhead for the capacity of the instance type", func() {
+		ExpectApplied(ctx, env.Client, registeredNode("Standard_D2s_v3", "7549747Ki"))
+		ExpectSingletonReconciled(ctx, controller)
+
+		instanceTypes, err := azureEnv.InstanceTypesProvider.List(ctx, test.AKSNodeClass())
+		Expect(err).ToNot(HaveOccurred())
+		for _, it := range instanceTypes {
+			if it.Name == "Standard_D2s_v3" {
+				Expect(it.Capacity.Memory().Value()).To(BeNumerically("<=", int64(7549747*1024)))
+				Expect(it.Capacity.Memory().Value()).To(BeNumerically(">", int64(7549747*1024)-int64(1024*1024)))
+			}
+		}
+	})
+	It("should ignore nodes that aren't registered or report an unexpected capacity", func() {
+		unregistered := registeredNode("Standard_D2s_v3", "7Gi")
+		delete(unregistered.Labels, karpv1.NodeRegisteredLabelKey)
+		ExpectApplied(ctx, env.Client,
+			unregistered,
+			// larger than the memory of the SKU
+			registeredNode("Standard_D4s_v3", "20Gi"),
+			// less than half the memory of the SKU
+			registeredNode("Standard_D8s_v3", "8Gi"),
+			// unknown SKU
+			registeredNode("Standard_Unknown", "8Gi"),
+		)
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(azureEnv.MemoryOverheadsCache.Snapshot()).To(BeEmpty())
+	})
+	It("should restore persisted memory overheads before learning new ones", func() {
+		data, err := json.Marshal(map[string]float64{"standard_d2s_v3": 0.2, "standard_d4s_v3": 0.05})
+		Expect(err).ToNot(HaveOccurred())
+		_, err = kubernetesInterface.CoreV1().ConfigMaps(systemNamespace).Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: memoryoverhead.ConfigMapName, Namespace: systemNamespace},
+			Data:       map[string]string{memoryoverhead.ConfigMapDataKey: string(data)},
+		}, metav1.CreateOptions{})
+		Expect(err).ToNot(HaveOccurred())
+
+		ExpectApplied(ctx, env.Client, registeredNode("Standard_D2s_v3", "7864320Ki"))
+		ExpectSingletonReconciled(ctx, controller)
+
+		// learned from the node
+		overhead, _ := azureEnv.MemoryOverheadsCache.Get("Standard_D2s_v3")
+		Expect(overhead).To(BeNumerically("~", 0.0625, 0.0001))
+		// restored
+		overhead, _ = azureEnv.MemoryOverheadsCache.Get("Standard_D4s_v3")
+		Expect(overhead).To(Equal(0.05))
+		Expect(getPersisted()).To(HaveLen(2))
+	})
+	It("should ignore unreadable persisted memory overheads", func() {
+		_, err := kubernetesInterface.CoreV1().ConfigMaps(systemNamespace).Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: memoryoverhead.ConfigMapName, Namespace: systemNamespace},
+			Data:       map[string]string{memoryoverhead.ConfigMapDataKey: "not json"},
+		}, metav1.CreateOptions{})
+		Expect(err).ToNot(HaveOccurred())
+
+		ExpectApplied(ctx, env.Client, registeredNode("Standard_D2s_v3", "7864320Ki"))
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(getPersisted()).To(HaveKey("standard_d2s_v3"))
+	})
+	It("should only learn in memory without a system namespace", func() {
+		os.Unsetenv("SYSTEM_NAMESPACE")
+		controller = memoryoverhead.NewController(env.Client, kubernetesInterface, azureEnv.InstanceTypesProvider, azureEnv.MemoryOverheadsCache)
+
+		ExpectApplied(ctx, env.Client, registeredNode("Standard_D2s_v3", "7864320Ki"))
+		ExpectSingletonReconciled(ctx, controller)
+		_, ok := azureEnv.MemoryOverheadsCache.Get("Standard_D2s_v3")
+		Expect(ok).To(BeTrue())
+		configMaps, err := kubernetesInterface.CoreV1().ConfigMaps("").List(ctx, metav1.ListOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(configMaps.Items).To(BeEmpty())
+	})
+})
@@ -0,0 +1,133 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cost
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/awslabs/operatorpkg/reconciler"
+	"github.com/awslabs/operatorpkg/singleton"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+
+	"github.com/Azure/karpenter-provider-azure/pkg/apis/v1beta1"
+	"github.com/Azure/karpenter-provider-azure/pkg/metrics"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/pricing"
+)
+
+// RefreshInterval is how often the estimated spend is recomputed, so that it follows both the NodeClaims and the prices
+const RefreshInterval = time.Minute
+
+// Controller estimates the hourly spend of the NodeClaims, with the current prices and pricing overrides, and exposes
+// it as metrics broken down by NodePool, AKSNodeClass, SKU family and capacity type
+type Controller struct {
+	kubeClient      client.Client
+	pricingProvider *pricing.Provider
+	// published are the label sets of the series of the last reconcile
+	published sets.Set[spendKey]
+}
+
+func NewController(kubeClient client.Client, pricingProvider *pricing.Provider) *Controller {
+	return &Controller{
+		kubeClient:      kubeClient,
+		pricingProvider: pricingProvider,
+	}
+}
+
+type spendKey struct {
+	nodePool     string
+	nodeClass    string
+	skuFamily    string
+	capacityType string
+}
+
+func (c *Controller) Reconcile(ctx context.Context) (reconciler.Result, error) {
+	ctx = injection.WithControllerName(ctx, "nodeclaim.cost")
+
+	nodeClaims := &karpv1.NodeClaimList{}
+	if err := c.kubeClient.List(ctx, nodeClaims); err != nil {
+		return reconciler.Result{}, fmt.Errorf("listing nodeclaims, %w", err)
+	}
+	spend := map[spendKey]float64{}
+	for i := range nodeClaims.Items {
+		nodeClaim := &nodeClaims.Items[i]
+		price, ok := c.hourlyPrice(nodeClaim)
+		if !ok {
+			continue
+		}
+		spend[spendKey{
+			nodePool:     nodeClaim.Labels[karpv1.NodePoolLabelKey],
+			nodeClass:    nodeClaim.Spec.NodeClassRef.Name,
+			skuFamily:    nodeClaim.Labels[v1beta1.LabelSKUFamily],
+			capacityType: nodeClaim.Labels[karpv1.CapacityTypeLabelKey],
+		}] += price
+	}
+	// Remove the series of the NodePools, AKSNodeClasses, etc. that don't have NodeClaims anymore, without resetting the
+	// others so that they are never scraped missing
+	for key := range c.published {
+		if _, ok := spend[key]; !ok {
+			EstimatedHourlySpend.DeleteLabelValues(key.nodePool, key.nodeClass, key.skuFamily, key.capacityType)
+		}
+	}
+	for key, price := range spend {
+		EstimatedHourlySpend.With(prometheus.Labels{
+			metrics.NodePoolLabel:     key.nodePool,
+			metrics.NodeClassLabel:    key.nodeClass,
+			metrics.SKUFamilyLabel:    key.skuFamily,
+			metrics.CapacityTypeLabel: key.capacityType,
+		}).Set(price)
+	}
+	c.published = sets.KeySet(spend)
+	return reconciler.Result{RequeueAfter: RefreshInterval}, nil
+}
+
+// hourlyPrice returns the current price of the instance of the NodeClaim, falling back to the price it was created with
+// when the instance type isn't priced anymore. NodeClaims that aren't launched yet don't cost anything.
+func (c *Controller) hourlyPrice(nodeClaim *karpv1.NodeClaim) (float64, bool) {
+	instanceType := nodeClaim.Labels[corev1.LabelInstanceTypeStable]
+	if instanceType == "" || nodeClaim.Status.ProviderID == "" {
+		return 0, false
+	}
+	var price float64
+	var ok bool
+	if nodeClaim.Labels[karpv1.CapacityTypeLabelKey] == karpv1.CapacityTypeSpot {
+		price, ok = c.pricingProvider.SpotPrice(instanceType)
+	} else {
+		price, ok = c.pricingProvider.OnDemandPrice(instanceType)
+	}
+	if ok {
+		return price, true
+	}
+	price, err := strconv.ParseFloat(nodeClaim.Annotations[v1beta1.AnnotationHourlyPrice], 64)
+	return price, err == nil
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("nodeclaim.cost").
+		WatchesRawSource(singleton.Source()).
+		Complete(singleton.AsReconciler(c))
+}
@@ -0,0 +1,50 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cost
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/Azure/karpenter-provider-azure/pkg/metrics"
+)
+
+const (
+	costSubsystem = "cost"
+)
+
+var (
+	// EstimatedHourlySpend tracks the estimated hourly spend of the launched NodeClaims, with the current prices and
+	// pricing overrides.
+	//
+	// STABILITY: ALPHA - This metric may change or be removed without notice.
+	EstimatedHourlySpend = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: costSubsystem,
+			Name:      "estimated_hourly_spend",
+			Help:      "Estimated hourly spend of the launched NodeClaims, in USD, by NodePool, AKSNodeClass, SKU family and capacity type.",
+		},
+		[]string{metrics.NodePoolLabel, metrics.NodeClassLabel, metrics.SKUFamilyLabel, metrics.CapacityTypeLabel},
+	)
+)
+
+func init() {
+	crmetrics.Registry.MustRegister(
+		EstimatedHourlySpend,
+	)
+}
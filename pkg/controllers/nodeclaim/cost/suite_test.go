@@ -0,0 +1,203 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cost_test
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	"sigs.k8s.io/karpenter/pkg/test/v1alpha1"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+
+	"github.com/Azure/karpenter-provider-azure/pkg/apis"
+	"github.com/Azure/karpenter-provider-azure/pkg/apis/v1beta1"
+	"github.com/Azure/karpenter-provider-azure/pkg/controllers/nodeclaim/cost"
+	"github.com/Azure/karpenter-provider-azure/pkg/metrics"
+	"github.com/Azure/karpenter-provider-azure/pkg/operator/options"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/pricing"
+	"github.com/Azure/karpenter-provider-azure/pkg/test"
+)
+
+var ctx context.Context
+var env *coretest.Environment
+var azureEnv *test.Environment
+
+func TestController(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "NodeClaimCostController")
+}
+
+var _ = BeforeSuite(func() {
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	env = coretest.NewEnvironment(coretest.WithCRDs(apis.CRDs...), coretest.WithCRDs(v1alpha1.CRDs...))
+	azureEnv = test.NewEnvironment(ctx, env)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	azureEnv.Reset(ctx)
+	cost.EstimatedHourlySpend.Reset()
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("NodeClaim Cost Controller", func() {
+	var controller *cost.Controller
+
+	BeforeEach(func() {
+		controller = cost.NewController(env.Client, azureEnv.PricingProvider)
+	})
+
+	launchedNodeClaim := func(nodePool, instanceType, family, capacityType string) *karpv1.NodeClaim {
+		return coretest.NodeClaim(karpv1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					karpv1.NodePoolLabelKey:        nodePool,
+					corev1.LabelInstanceTypeStable: instanceType,
+					v1beta1.LabelSKUFamily:         family,
+					karpv1.CapacityTypeLabelKey:    capacityType,
+				},
+			},
+			Spec: karpv1.NodeClaimSpec{
+				NodeClassRef: &karpv1.NodeClassReference{Group: apis.Group, Kind: "AKSNodeClass", Name: "default"},
+			},
+			Status: karpv1.NodeClaimStatus{
+				ProviderID: "azure:///subscriptions/subscriptionID/resourceGroups/test-resourceGroup/providers/Microsoft.Compute/virtualMachines/" + instanceType,
+			},
+		})
+	}
+	spend := func(nodePool, family, capacityType string) (float64, bool) {
+		GinkgoHelper()
+		metric, err := metrics.FindMetricWithLabelValues("karpenter_cost_estimated_hourly_spend", map[string]string{
+			metrics.NodePoolLabel:     nodePool,
+			metrics.NodeClassLabel:    "default",
+			metrics.SKUFamilyLabel:    family,
+			metrics.CapacityTypeLabel: capacityType,
+		})
+		Expect(err).ToNot(HaveOccurred())
+		if metric == nil {
+			return 0, false
+		}
+		return metric.GetGauge().GetValue(), true
+	}
+	onDemandPrice := func(instanceType string) float64 {
+		GinkgoHelper()
+		price, ok := azureEnv.PricingProvider.OnDemandPrice(instanceType)
+		Expect(ok).To(BeTrue())
+		return price
+	}
+
+	It("should return a requeue interval of 1 minute", func() {
+		result := ExpectSingletonReconciled(ctx, controller)
+		Expect(result.RequeueAfter).To(Equal(cost.RefreshInterval))
+	})
+	It("should sum the prices of the launched nodeclaims by nodepool, sku family and capacity type", func() {
+		ExpectApplied(ctx, env.Client,
+			launchedNodeClaim("default", "Standard_D2s_v3", "D", karpv1.CapacityTypeOnDemand),
+			launchedNodeClaim("default", "Standard_D4s_v3", "D", karpv1.CapacityTypeOnDemand),
+			launchedNodeClaim("default", "Standard_D2s_v3", "D", karpv1.CapacityTypeSpot),
+			launchedNodeClaim("other", "Standard_D2s_v3", "D", karpv1.CapacityTypeOnDemand),
+		)
+		ExpectSingletonReconciled(ctx, controller)
+
+		value, ok := spend("default", "D", karpv1.CapacityTypeOnDemand)
+		Expect(ok).To(BeTrue())
+		Expect(value).To(BeNumerically("~", onDemandPrice("Standard_D2s_v3")+onDemandPrice("Standard_D4s_v3"), 1e-9))
+		spotPrice, _ := azureEnv.PricingProvider.SpotPrice("Standard_D2s_v3")
+		value, ok = spend("default", "D", karpv1.CapacityTypeSpot)
+		Expect(ok).To(BeTrue())
+		Expect(value).To(BeNumerically("~", spotPrice, 1e-9))
+		value, ok = spend("other", "D", karpv1.CapacityTypeOnDemand)
+		Expect(ok).To(BeTrue())
+		Expect(value).To(BeNumerically("~", onDemandPrice("Standard_D2s_v3"), 1e-9))
+	})
+	It("should not count nodeclaims that aren't launched", func() {
+		nodeClaim := launchedNodeClaim("default", "Standard_D2s_v3", "D", karpv1.CapacityTypeOnDemand)
+		nodeClaim.Status.ProviderID = ""
+		ExpectApplied(ctx, env.Client, nodeClaim)
+		ExpectSingletonReconciled(ctx, controller)
+
+		_, ok := spend("default", "D", karpv1.CapacityTypeOnDemand)
+		Expect(ok).To(BeFalse())
+	})
+	It("should update the spend when the prices change", func() {
+		retailPrice := onDemandPrice("Standard_D2s_v3")
+		ExpectApplied(ctx, env.Client, launchedNodeClaim("default", "Standard_D2s_v3", "D", karpv1.CapacityTypeOnDemand))
+		ExpectSingletonReconciled(ctx, controller)
+		value, _ := spend("default", "D", karpv1.CapacityTypeOnDemand)
+		Expect(value).To(BeNumerically("~", retailPrice, 1e-9))
+
+		azureEnv.PricingProvider.SetOverrides(&pricing.Overrides{Default: &pricing.Override{OnDemandDiscountPercent: lo.ToPtr(50.0)}})
+		ExpectSingletonReconciled(ctx, controller)
+		value, _ = spend("default", "D", karpv1.CapacityTypeOnDemand)
+		Expect(value).To(BeNumerically("~", retailPrice/2, 1e-9))
+	})
+	It("should fall back to the price the nodeclaim was created with for instance types that aren't priced", func() {
+		nodeClaim := launchedNodeClaim("default", "Standard_Unpriced", "U", karpv1.CapacityTypeOnDemand)
+		nodeClaim.Annotations = map[string]string{v1beta1.AnnotationHourlyPrice: "0.25"}
+		ExpectApplied(ctx, env.Client, nodeClaim)
+		ExpectSingletonReconciled(ctx, controller)
+
+		value, ok := spend("default", "U", karpv1.CapacityTypeOnDemand)
+		Expect(ok).To(BeTrue())
+		Expect(value).To(Equal(0.25))
+	})
+	It("should remove the spend of nodepools without nodeclaims anymore", func() {
+		nodeClaim := launchedNodeClaim("default", "Standard_D2s_v3", "D", karpv1.CapacityTypeOnDemand)
+		ExpectApplied(ctx, env.Client, nodeClaim)
+		ExpectSingletonReconciled(ctx, controller)
+		_, ok := spend("default", "D", karpv1.CapacityTypeOnDemand)
+		Expect(ok).To(BeTrue())
+
+		ExpectDeleted(ctx, env.Client, nodeClaim)
+		ExpectSingletonReconciled(ctx, controller)
+		_, ok = spend("default", "D", karpv1.CapacityTypeOnDemand)
+		Expect(ok).To(BeFalse())
+	})
+	It("should keep the spend of the nodepools that still have nodeclaims", func() {
+		removed := launchedNodeClaim("default", "Standard_D2s_v3", "D", karpv1.CapacityTypeOnDemand)
+		kept := launchedNodeClaim("default", "Standard_D2s_v3", "D", karpv1.CapacityTypeSpot)
+		ExpectApplied(ctx, env.Client, removed, kept)
+		ExpectSingletonReconciled(ctx, controller)
+		keptSpend, ok := spend("default", "D", karpv1.CapacityTypeSpot)
+		Expect(ok).To(BeTrue())
+
+		ExpectDeleted(ctx, env.Client, removed)
+		ExpectSingletonReconciled(ctx, controller)
+		_, ok = spend("default", "D", karpv1.CapacityTypeOnDemand)
+		Expect(ok).To(BeFalse())
+		value, ok := spend("default", "D", karpv1.CapacityTypeSpot)
+		Expect(ok).To(BeTrue())
+		Expect(value).To(Equal(keptSpend))
+	})
+})
@@ -0,0 +1,117 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package garbagecollection
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/samber/lo"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/awslabs/operatorpkg/reconciler"
+	"github.com/awslabs/operatorpkg/singleton"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/util/workqueue"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/instance"
+)
+
+const (
+	// Public IPs share the NRP limits with NICs, see NicGarbageCollectionInterval
+	PublicIPGarbageCollectionInterval = time.Minute * 5
+)
+
+// PublicIP garbage collects the instance-level public IPs that are left behind when their instance is deleted,
+// e.g., because the NIC they were attached to could only be deleted by the NetworkInterface garbage collector
+type PublicIP struct {
+	kubeClient         client.Client
+	vmInstanceProvider instance.VMProvider
+}
+
+func NewPublicIP(kubeClient client.Client, vmInstanceProvider instance.VMProvider) *PublicIP {
+	return &PublicIP{
+		kubeClient:         kubeClient,
+		vmInstanceProvider: vmInstanceProvider,
+	}
+}
+
+func (c *PublicIP) populateUnremovablePublicIPs(ctx context.Context) (sets.Set[string], error) {
+	unremovablePublicIPs := sets.New[string]()
+	vms, err := c.vmInstanceProvider.List(ctx)
+	if err != nil {
+		return unremovablePublicIPs, fmt.Errorf("listing VMs: %w", err)
+	}
+	for _, vm := range vms {
+		unremovablePublicIPs.Insert(lo.FromPtr(vm.Name))
+	}
+	nodeClaimList := &karpv1.NodeClaimList{}
+	if err := c.kubeClient.List(ctx, nodeClaimList); err != nil {
+		return unremovablePublicIPs, fmt.Errorf("listing NodeClaims for public IP GC: %w", err)
+	}
+
+	for _, nodeClaim := range nodeClaimList.Items {
+		unremovablePublicIPs.Insert(instance.GenerateResourceName(nodeClaim.Name))
+	}
+	return unremovablePublicIPs, nil
+}
+
+func (c *PublicIP) Reconcile(ctx context.Context) (reconciler.Result, error) {
+	ctx = injection.WithControllerName(ctx, "publicip.garbagecollection")
+	publicIPs, err := c.vmInstanceProvider.ListPublicIPs(ctx)
+	if err != nil {
+		return reconciler.Result{}, fmt.Errorf("listing public IPs: %w", err)
+	}
+
+	unremovablePublicIPs, err := c.populateUnremovablePublicIPs(ctx)
+	if err != nil {
+		return reconciler.Result{}, fmt.Errorf("error listing resources needed to populate unremovable public IPs %w", err)
+	}
+	workqueue.ParallelizeUntil(ctx, 100, len(publicIPs), func(i int) {
+		publicIPName := lo.FromPtr(publicIPs[i].Name)
+		if unremovablePublicIPs.Has(publicIPName) {
+			return
+		}
+		// A public IP that is still attached can't be deleted, it will be deleted once the NIC is garbage collected
+		if publicIPs[i].Properties != nil && publicIPs[i].Properties.IPConfiguration != nil {
+			return
+		}
+		err := c.vmInstanceProvider.DeletePublicIP(ctx, publicIPName)
+		if err != nil {
+			log.FromContext(ctx).Error(err, "")
+			return
+		}
+
+		log.FromContext(ctx).Info("garbage collected public IP", "publicIPName", publicIPName)
+	})
+	return reconciler.Result{
+		RequeueAfter: PublicIPGarbageCollectionInterval,
+	}, nil
+}
+
+func (c *PublicIP) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("publicip.garbagecollection").
+		WatchesRawSource(singleton.Source()).
+		Complete(singleton.AsReconciler(c))
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/awslabs/operatorpkg/object"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v7"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork"
 	"github.com/Azure/karpenter-provider-azure/pkg/apis"
 	"github.com/Azure/karpenter-provider-azure/pkg/apis/v1beta1"
 	"github.com/Azure/karpenter-provider-azure/pkg/cloudprovider"
@@ -70,6 +71,7 @@ var cloudProvider *cloudprovider.CloudProvider
 var InstanceGCController *garbagecollection.Instance
 var inPlaceUpdateController *inplaceupdate.Controller
 var networkInterfaceGCController *garbagecollection.NetworkInterface
+var publicIPGCController *garbagecollection.PublicIP
 var prov *provisioning.Provisioner
 
 func TestAPIs(t *testing.T) {
@@ -85,10 +87,11 @@ var _ = BeforeSuite(func() {
 	env = coretest.NewEnvironment(coretest.WithCRDs(apis.CRDs...), coretest.WithCRDs(v1alpha1.CRDs...))
 	//	ctx, stop = context.WithCancel(ctx)
 	azureEnv = test.NewEnvironment(ctx, env)
-	cloudProvider = cloudprovider.New(azureEnv.InstanceTypesProvider, azureEnv.VMInstanceProvider, azureEnv.AKSMachineProvider, events.NewRecorder(&record.FakeRecorder{}), env.Client, azureEnv.ImageProvider, azureEnv.InstanceTypeStore)
+	cloudProvider = cloudprovider.New(azureEnv.InstanceTypesProvider, azureEnv.VMInstanceProvider, azureEnv.AKSMachineProvider, events.NewRecorder(&record.FakeRecorder{}), env.Client, azureEnv.ImageProvider, azureEnv.InstanceTypeStore, azureEnv.SpotEvictionsCache, azureEnv.QuotaProvider)
 	InstanceGCController = garbagecollection.NewInstance(env.Client, cloudProvider)
 	inPlaceUpdateController = inplaceupdate.NewController(env.Client, azureEnv.VMInstanceProvider, azureEnv.AKSMachineProvider)
 	networkInterfaceGCController = garbagecollection.NewNetworkInterface(env.Client, azureEnv.VMInstanceProvider)
+	publicIPGCController = garbagecollection.NewPublicIP(env.Client, azureEnv.VMInstanceProvider)
 	fakeClock = &clock.FakeClock{}
 	cluster = state.NewCluster(fakeClock, env.Client, cloudProvider)
 	prov = provisioning.NewProvisioner(env.Client, events.NewRecorder(&record.FakeRecorder{}), cloudProvider, cluster, fakeClock, deviceallocation.NewController(env.Client))
@@ -418,3 +421,68 @@ var _ = Describe("NetworkInterface Garbage Collection", func() {
 		})
 	})
 })
+
+var _ = Describe("PublicIP Garbage Collection", func() {
+	var _ = Context("VM instances", func() {
+		It("should not delete a public IP if a nodeclaim exists for it", func() {
+			nodeClaim := coretest.NodeClaim()
+			ExpectApplied(ctx, env.Client, nodeClaim)
+
+			publicIP := test.PublicIPAddress(test.PublicIPAddressOptions{
+				Name:         instance.GenerateResourceName(nodeClaim.Name),
+				NodepoolName: nodePool.Name,
+			})
+			azureEnv.PublicIPAddressesAPI.PublicIPAddresses.Store(lo.FromPtr(publicIP.ID), *publicIP)
+
+			ExpectSingletonReconciled(ctx, publicIPGCController)
+
+			publicIPsAfterGC, err := azureEnv.VMInstanceProvider.ListPublicIPs(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(publicIPsAfterGC).To(HaveLen(1))
+		})
+		It("should delete a public IP if there is no associated VM", func() {
+			publicIP := test.PublicIPAddress(test.PublicIPAddressOptions{
+				NodepoolName: nodePool.Name,
+			})
+			azureEnv.PublicIPAddressesAPI.PublicIPAddresses.Store(lo.FromPtr(publicIP.ID), *publicIP)
+			publicIPsBeforeGC, err := azureEnv.VMInstanceProvider.ListPublicIPs(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(publicIPsBeforeGC).To(HaveLen(1))
+
+			ExpectSingletonReconciled(ctx, publicIPGCController)
+
+			publicIPsAfterGC, err := azureEnv.VMInstanceProvider.ListPublicIPs(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(publicIPsAfterGC).To(BeEmpty())
+		})
+		It("should not delete a public IP if there is an associated VM", func() {
+			publicIP := test.PublicIPAddress(test.PublicIPAddressOptions{
+				NodepoolName: nodePool.Name,
+			})
+			azureEnv.PublicIPAddressesAPI.PublicIPAddresses.Store(lo.FromPtr(publicIP.ID), *publicIP)
+			managedVM := test.VirtualMachine(test.VirtualMachineOptions{Name: lo.FromPtr(publicIP.Name), NodepoolName: nodePool.Name})
+			azureEnv.VirtualMachinesAPI.Instances.Store(lo.FromPtr(managedVM.ID), *managedVM)
+
+			ExpectSingletonReconciled(ctx, publicIPGCController)
+
+			publicIPsAfterGC, err := azureEnv.VMInstanceProvider.ListPublicIPs(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(publicIPsAfterGC).To(HaveLen(1))
+		})
+		It("should not delete a public IP that is still attached to a network interface", func() {
+			publicIP := test.PublicIPAddress(test.PublicIPAddressOptions{
+				NodepoolName: nodePool.Name,
+				Properties: &armnetwork.PublicIPAddressPropertiesFormat{
+					IPConfiguration: &armnetwork.IPConfiguration{ID: lo.ToPtr("/subscriptions/subscriptionID/resourceGroups/test-resourceGroup/providers/Microsoft.Network/networkInterfaces/leaked/ipConfigurations/leaked")},
+				},
+			})
+			azureEnv.PublicIPAddressesAPI.PublicIPAddresses.Store(lo.FromPtr(publicIP.ID), *publicIP)
+
+			ExpectSingletonReconciled(ctx, publicIPGCController)
+
+			publicIPsAfterGC, err := azureEnv.VMInstanceProvider.ListPublicIPs(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(publicIPsAfterGC).To(HaveLen(1))
+		})
+	})
+})
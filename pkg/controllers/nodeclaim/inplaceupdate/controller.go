@@ -36,6 +36,7 @@ import (
 	"sigs.k8s.io/karpenter/pkg/operator/injection"
 	corenodeclaimutils "sigs.k8s.io/karpenter/pkg/utils/nodeclaim"
 
+	sdkerrors "github.com/Azure/azure-sdk-for-go-extensions/pkg/errors"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v7"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice/v9"
 	"github.com/Azure/karpenter-provider-azure/pkg/apis/v1beta1"
@@ -160,6 +161,11 @@ func (c *Controller) processVMInstance(
 		return fmt.Errorf("applying patch to VM for nodeClaim %s: %w", nodeClaim.Name, err)
 	}
 
+	err = c.applyNICPatch(ctx, options, nodeClaim, nodeClass, lo.FromPtr(vm.Name))
+	if err != nil {
+		return fmt.Errorf("applying patch to NIC for nodeClaim %s: %w", nodeClaim.Name, err)
+	}
+
 	return nil
 }
 
@@ -206,6 +212,34 @@ func (c *Controller) applyVMPatch(
 	return nil
 }
 
+func (c *Controller) applyNICPatch(
+	ctx context.Context,
+	options *options.Options,
+	nodeClaim *karpv1.NodeClaim,
+	nodeClass *v1beta1.AKSNodeClass,
+	vmName string,
+) error {
+	nic, err := c.vmInstanceProvider.GetNic(ctx, options.NodeResourceGroup, vmName) // NIC is named the same as the VM
+	if err != nil {
+		// Without application security groups there is nothing to add to, or remove from, a NIC that doesn't exist
+		if sdkerrors.IsNotFoundErr(err) && len(nodeClass.Spec.ApplicationSecurityGroupIDs) == 0 {
+			return nil
+		}
+		return fmt.Errorf("getting NIC %s: %w", vmName, err)
+	}
+
+	// Apply the update, if one is needed
+	if CalculateNICPatch(options, nodeClaim, nodeClass, nic) {
+		logNICPatch(ctx, nic)
+		err := c.vmInstanceProvider.UpdateNic(ctx, vmName, *nic)
+		if err != nil {
+			return fmt.Errorf("failed to apply update to NIC, %w", err)
+		}
+	}
+
+	return nil
+}
+
 func (c *Controller) applyAKSMachinePatch(
 	ctx context.Context,
 	options *options.Options,
@@ -257,7 +291,7 @@ func (c *Controller) Register(_ context.Context, m manager.Manager) error {
 					predicate.GenerationChangedPredicate{}, // Note that this will trigger on pod restart for all Machines.
 				),
 			)).
-		Watches(&v1beta1.AKSNodeClass{}, corenodeclaimutils.NodeClassEventHandler(m.GetClient()), builder.WithPredicates(tagsChangedPredicate{})).
+		Watches(&v1beta1.AKSNodeClass{}, corenodeclaimutils.NodeClassEventHandler(m.GetClient()), builder.WithPredicates(inPlaceUpdateFieldsChangedPredicate{})).
 		// TODO: Can add .Watches(&karpv1.NodePool{}, nodeclaimutil.NodePoolEventHandler(c.kubeClient))
 		// TODO: similar to https://github.com/kubernetes-sigs/karpenter/blob/main/pkg/controllers/nodeclaim/disruption/controller.go#L214C3-L217C5
 		// TODO: if/when we need to monitor provisioner changes and flow updates on the NodePool down to the underlying VMs.
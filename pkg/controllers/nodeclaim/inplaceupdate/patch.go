@@ -20,6 +20,7 @@ import (
 	"context"
 	"encoding/json"
 	"maps"
+	"strings"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/samber/lo"
@@ -28,6 +29,7 @@ import (
 
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v7"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice/v9"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork"
 	"github.com/Azure/karpenter-provider-azure/pkg/apis/v1beta1"
 	"github.com/Azure/karpenter-provider-azure/pkg/operator/options"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/instance"
@@ -47,6 +49,15 @@ func logVMPatch(ctx context.Context, update *armcompute.VirtualMachineUpdate) {
 	}
 }
 
+func logNICPatch(ctx context.Context, nic *armnetwork.Interface) {
+	if log.FromContext(ctx).V(1).Enabled() {
+		raw, _ := json.Marshal(nic)
+		log.FromContext(ctx).V(1).Info("patching Azure NIC", "nic", string(raw))
+	} else {
+		log.FromContext(ctx).V(0).Info("patching Azure NIC")
+	}
+}
+
 func logAKSMachinePatch(ctx context.Context, before, after *armcontainerservice.Machine) {
 	if log.FromContext(ctx).V(1).Enabled() {
 		diff := cmp.Diff(before, after)
@@ -71,6 +82,12 @@ var vmPatchers = []func(*armcompute.VirtualMachineUpdate, *patchParameters, *arm
 	patchVMTags,
 }
 
+// NICs support PATCH for tags only, which are patched along with the VM. The other fields are patched locally on the NIC object,
+// before the object is sent to the API.
+var nicPatchers = []func(*patchParameters, *armnetwork.Interface) bool{
+	patchNICApplicationSecurityGroups,
+}
+
 var aksMachinePatchers = []func(*patchParameters, *armcontainerservice.Machine) bool{
 	// VM identities are handled server-side for AKS machines. No need here.
 	patchAKSMachineTags,
@@ -116,6 +133,28 @@ func CalculateVMPatch(
 	return update
 }
 
+// CalculateNICPatch patches the current NIC locally, and returns whether it was changed
+func CalculateNICPatch(
+	options *options.Options,
+	nodeClaim *karpv1.NodeClaim,
+	nodeClass *v1beta1.AKSNodeClass,
+	patchingNIC *armnetwork.Interface,
+) bool {
+	hasPatches := false
+	params := &patchParameters{
+		opts:      options,
+		nodeClass: nodeClass,
+		nodeClaim: nodeClaim,
+	}
+
+	for _, patcher := range nicPatchers {
+		patched := patcher(params, patchingNIC)
+		hasPatches = hasPatches || patched
+	}
+
+	return hasPatches
+}
+
 // Note: AKS machine patching flow is different from VM patching, given AKS machine API supports PUT but not PATCH (i.e., send only diff to the API rather than the whole object).
 // Thus, the patch will be applied locally on the AKS machine object, before the object is sent to the API.
 func CalculateAKSMachinePatch(
@@ -211,3 +250,45 @@ func patchAKSMachineTags(
 	patchingAKSMachine.Properties.Tags = expectedTags
 	return true
 }
+
+func patchNICApplicationSecurityGroups(
+	params *patchParameters,
+	patchingNIC *armnetwork.Interface,
+) bool {
+	if patchingNIC.Properties == nil {
+		// Should not be possible, but handle it gracefully
+		return false
+	}
+
+	// Unlike identities, membership is fully owned by the AKSNodeClass, so groups removed from it are removed from the NIC as well
+	expectedIDs := params.nodeClass.Spec.ApplicationSecurityGroupIDs
+	hasPatches := false
+	for _, ipConfig := range patchingNIC.Properties.IPConfigurations {
+		if ipConfig.Properties == nil {
+			continue
+		}
+		if applicationSecurityGroupsEqual(expectedIDs, ipConfig.Properties.ApplicationSecurityGroups) {
+			continue
+		}
+		// An empty, rather than nil, list is sent so the PUT clears the groups removed from the AKSNodeClass
+		ipConfig.Properties.ApplicationSecurityGroups = lo.Ternary(
+			len(expectedIDs) == 0,
+			[]*armnetwork.ApplicationSecurityGroup{},
+			instance.ApplicationSecurityGroupReferences(expectedIDs),
+		)
+		hasPatches = true
+	}
+	return hasPatches
+}
+
+// applicationSecurityGroupsEqual compares the IDs case-insensitively, as Azure doesn't preserve the casing of resource IDs
+func applicationSecurityGroupsEqual(expectedIDs []string, current []*armnetwork.ApplicationSecurityGroup) bool {
+	currentIDs := lo.FilterMap(current, func(asg *armnetwork.ApplicationSecurityGroup, _ int) (string, bool) {
+		if asg == nil || asg.ID == nil {
+			return "", false
+		}
+		return strings.ToLower(*asg.ID), true
+	})
+	missing, extra := lo.Difference(lo.Map(expectedIDs, func(id string, _ int) string { return strings.ToLower(id) }), currentIDs)
+	return len(missing) == 0 && len(extra) == 0
+}
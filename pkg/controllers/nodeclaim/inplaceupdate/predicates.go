@@ -18,6 +18,7 @@ package inplaceupdate
 
 import (
 	"maps"
+	"slices"
 
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
@@ -25,18 +26,19 @@ import (
 	"github.com/Azure/karpenter-provider-azure/pkg/apis/v1beta1"
 )
 
-type tagsChangedPredicate struct {
+// inPlaceUpdateFieldsChangedPredicate filters AKSNodeClass events down to changes of the fields that are updated in place
+type inPlaceUpdateFieldsChangedPredicate struct {
 	predicate.Funcs
 }
 
-var _ predicate.Predicate = tagsChangedPredicate{}
+var _ predicate.Predicate = inPlaceUpdateFieldsChangedPredicate{}
 
-func (p tagsChangedPredicate) Delete(e event.DeleteEvent) bool {
+func (p inPlaceUpdateFieldsChangedPredicate) Delete(e event.DeleteEvent) bool {
 	// We never want updates on delete
 	return false
 }
 
-func (p tagsChangedPredicate) Update(e event.UpdateEvent) bool {
+func (p inPlaceUpdateFieldsChangedPredicate) Update(e event.UpdateEvent) bool {
 	if e.ObjectOld == nil {
 		return true // This isn't expected, so propagate the event so we don't miss anything
 	}
@@ -53,5 +55,6 @@ func (p tagsChangedPredicate) Update(e event.UpdateEvent) bool {
 		return true // If we don't know the type, we assume it has changed
 	}
 
-	return !maps.Equal(typedOld.Spec.Tags, typedNew.Spec.Tags)
+	return !maps.Equal(typedOld.Spec.Tags, typedNew.Spec.Tags) ||
+		!slices.Equal(typedOld.Spec.ApplicationSecurityGroupIDs, typedNew.Spec.ApplicationSecurityGroupIDs)
 }
@@ -29,9 +29,9 @@ import (
 	"github.com/Azure/karpenter-provider-azure/pkg/test"
 )
 
-func TestTagsChangedPredicate_Delete(t *testing.T) {
+func TestInPlaceUpdateFieldsChangedPredicate_Delete(t *testing.T) {
 	g := NewWithT(t)
-	predicate := tagsChangedPredicate{}
+	predicate := inPlaceUpdateFieldsChangedPredicate{}
 
 	nodeClass := test.AKSNodeClass(v1beta1.AKSNodeClass{
 		Spec: v1beta1.AKSNodeClassSpec{
@@ -50,7 +50,7 @@ func TestTagsChangedPredicate_Delete(t *testing.T) {
 	g.Expect(result).To(BeFalse())
 }
 
-func TestTagsChangedPredicate_Update(t *testing.T) {
+func TestInPlaceUpdateFieldsChangedPredicate_Update(t *testing.T) {
 	tests := []struct {
 		name           string
 		oldObject      client.Object
@@ -123,6 +123,24 @@ func TestTagsChangedPredicate_Update(t *testing.T) {
 			newObject:      newTestNodeClass(map[string]string{}),
 			expectedResult: true,
 		},
+		{
+			name:           "application security groups are identical",
+			oldObject:      newTestNodeClassWithApplicationSecurityGroups([]string{"asg-1", "asg-2"}),
+			newObject:      newTestNodeClassWithApplicationSecurityGroups([]string{"asg-1", "asg-2"}),
+			expectedResult: false,
+		},
+		{
+			name:           "application security groups added",
+			oldObject:      newTestNodeClassWithApplicationSecurityGroups(nil),
+			newObject:      newTestNodeClassWithApplicationSecurityGroups([]string{"asg-1"}),
+			expectedResult: true,
+		},
+		{
+			name:           "application security groups removed",
+			oldObject:      newTestNodeClassWithApplicationSecurityGroups([]string{"asg-1", "asg-2"}),
+			newObject:      newTestNodeClassWithApplicationSecurityGroups([]string{"asg-1"}),
+			expectedResult: true,
+		},
 		{
 			name:           "ObjectOld is nil",
 			oldObject:      nil,
@@ -152,7 +170,7 @@ func TestTagsChangedPredicate_Update(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			g := NewWithT(t)
-			predicate := tagsChangedPredicate{}
+			predicate := inPlaceUpdateFieldsChangedPredicate{}
 
 			updateEvent := event.UpdateEvent{
 				ObjectOld: tt.oldObject,
@@ -165,6 +183,17 @@ func TestTagsChangedPredicate_Update(t *testing.T) {
 	}
 }
 
+func newTestNodeClassWithApplicationSecurityGroups(applicationSecurityGroupIDs []string) client.Object {
+	return test.AKSNodeClass(v1beta1.AKSNodeClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test-nodeclass",
+		},
+		Spec: v1beta1.AKSNodeClassSpec{
+			ApplicationSecurityGroupIDs: applicationSecurityGroupIDs,
+		},
+	})
+}
+
 func newTestNodeClass(tags map[string]string) client.Object {
 	return test.AKSNodeClass(v1beta1.AKSNodeClass{
 		ObjectMeta: metav1.ObjectMeta{
@@ -18,6 +18,7 @@ package inplaceupdate_test
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
@@ -710,6 +711,52 @@ var _ = Describe("Unit tests", func() {
 			}))
 		})
 	})
+
+	Context("calculateNICPatch", func() {
+		const asgID = "/subscriptions/1234/resourceGroups/security/providers/Microsoft.Network/applicationSecurityGroups/asg"
+		var currentNIC *armnetwork.Interface
+
+		BeforeEach(func() {
+			currentNIC = &armnetwork.Interface{
+				Properties: &armnetwork.InterfacePropertiesFormat{
+					IPConfigurations: []*armnetwork.InterfaceIPConfiguration{
+						{Name: lo.ToPtr("ipconfig0"), Properties: &armnetwork.InterfaceIPConfigurationPropertiesFormat{}},
+						{Name: lo.ToPtr("ipconfig1"), Properties: &armnetwork.InterfaceIPConfigurationPropertiesFormat{}},
+					},
+				},
+			}
+		})
+
+		It("should add missing application security groups to every ip configuration", func() {
+			nodeClass.Spec.ApplicationSecurityGroupIDs = []string{asgID}
+
+			Expect(inplaceupdate.CalculateNICPatch(test.Options(), nodeClaim, nodeClass, currentNIC)).To(BeTrue())
+			for _, ipConfig := range currentNIC.Properties.IPConfigurations {
+				Expect(ipConfig.Properties.ApplicationSecurityGroups).To(HaveLen(1))
+				Expect(lo.FromPtr(ipConfig.Properties.ApplicationSecurityGroups[0].ID)).To(Equal(asgID))
+			}
+		})
+
+		It("should not patch when the application security groups already match, ignoring case", func() {
+			nodeClass.Spec.ApplicationSecurityGroupIDs = []string{asgID}
+			for _, ipConfig := range currentNIC.Properties.IPConfigurations {
+				ipConfig.Properties.ApplicationSecurityGroups = []*armnetwork.ApplicationSecurityGroup{{ID: lo.ToPtr(strings.ToUpper(asgID))}}
+			}
+
+			Expect(inplaceupdate.CalculateNICPatch(test.Options(), nodeClaim, nodeClass, currentNIC)).To(BeFalse())
+		})
+
+		It("should remove application security groups no longer in the NodeClass", func() {
+			for _, ipConfig := range currentNIC.Properties.IPConfigurations {
+				ipConfig.Properties.ApplicationSecurityGroups = []*armnetwork.ApplicationSecurityGroup{{ID: lo.ToPtr(asgID)}}
+			}
+
+			Expect(inplaceupdate.CalculateNICPatch(test.Options(), nodeClaim, nodeClass, currentNIC)).To(BeTrue())
+			for _, ipConfig := range currentNIC.Properties.IPConfigurations {
+				Expect(ipConfig.Properties.ApplicationSecurityGroups).To(BeEmpty())
+			}
+		})
+	})
 })
 
 var _ = Describe("In Place Update Controller", func() {
@@ -957,6 +1004,42 @@ var _ = Describe("In Place Update Controller", func() {
 			})
 		})
 
+		Context("Application security group tests", func() {
+			It("should add the application security groups to the NIC", func() {
+				asgID := "/subscriptions/1234/resourceGroups/security/providers/Microsoft.Network/applicationSecurityGroups/asg"
+				nic.Properties = &armnetwork.InterfacePropertiesFormat{
+					IPConfigurations: []*armnetwork.InterfaceIPConfiguration{
+						{Name: lo.ToPtr("ipconfig0"), Properties: &armnetwork.InterfaceIPConfigurationPropertiesFormat{}},
+					},
+				}
+				azureEnv.VirtualMachinesAPI.Instances.Store(lo.FromPtr(vm.ID), *vm)
+				azureEnv.NetworkInterfacesAPI.NetworkInterfaces.Store(lo.FromPtr(nic.ID), *nic)
+				nodeClass.Spec.ApplicationSecurityGroupIDs = []string{asgID}
+
+				ExpectApplied(ctx, env.Client, nodeClaim, nodeClass)
+				ExpectObjectReconciled(ctx, env.Client, inPlaceUpdateController, nodeClaim)
+
+				updatedNIC, ok := azureEnv.NetworkInterfacesAPI.NetworkInterfaces.Load(lo.FromPtr(nic.ID))
+				Expect(ok).To(BeTrue())
+				ipConfigs := updatedNIC.Properties.IPConfigurations
+				Expect(ipConfigs[0].Properties.ApplicationSecurityGroups).To(HaveLen(1))
+				Expect(lo.FromPtr(ipConfigs[0].Properties.ApplicationSecurityGroups[0].ID)).To(Equal(asgID))
+
+				nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+				Expect(nodeClaim.Annotations).To(HaveKey(v1beta1.AnnotationInPlaceUpdateHash))
+			})
+
+			It("should not update the NIC if the application security groups already match", func() {
+				azureEnv.VirtualMachinesAPI.Instances.Store(lo.FromPtr(vm.ID), *vm)
+				azureEnv.NetworkInterfacesAPI.NetworkInterfaces.Store(lo.FromPtr(nic.ID), *nic)
+
+				ExpectApplied(ctx, env.Client, nodeClaim)
+				ExpectObjectReconciled(ctx, env.Client, inPlaceUpdateController, nodeClaim)
+
+				Expect(azureEnv.NetworkInterfacesAPI.NetworkInterfacesCreateOrUpdateBehavior.Calls()).To(Equal(0))
+			})
+		})
+
 		Context("Tags tests", func() {
 			It("should add a hash annotation to NodeClaim and update VM, NIC, and Extensions if there are missing tags", func() {
 				azureEnv.VirtualMachinesAPI.Instances.Store(lo.FromPtr(vm.ID), *vm)
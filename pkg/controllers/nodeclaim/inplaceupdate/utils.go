@@ -39,8 +39,9 @@ type aksMachineInPlaceUpdateFields struct {
 }
 
 type vmInPlaceUpdateFields struct {
-	Identities sets.Set[string]  `json:"identities,omitempty"`
-	Tags       map[string]string `json:"tags,omitempty"`
+	Identities                  sets.Set[string]  `json:"identities,omitempty"`
+	Tags                        map[string]string `json:"tags,omitempty"`
+	ApplicationSecurityGroupIDs sets.Set[string]  `json:"applicationSecurityGroupIDs,omitempty"`
 }
 
 // CalculateHash computes a hash for any JSON-marshalable struct
@@ -74,8 +75,9 @@ func HashFromNodeClaim(options *options.Options, nodeClaim *karpv1.NodeClaim, no
 	} else {
 		// VM instance-based node
 		hashStruct = &vmInPlaceUpdateFields{
-			Identities: sets.New(options.NodeIdentities...),
-			Tags:       tagsForHash,
+			Identities:                  sets.New(options.NodeIdentities...),
+			Tags:                        tagsForHash,
+			ApplicationSecurityGroupIDs: sets.New(nodeClass.Spec.ApplicationSecurityGroupIDs...),
 		}
 	}
 
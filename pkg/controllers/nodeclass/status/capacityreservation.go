@@ -0,0 +1,78 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"context"
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/Azure/karpenter-provider-azure/pkg/apis/v1beta1"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/capacityreservation"
+)
+
+// CapacityReservationReconciler refreshes the reservations of the AKSNodeClass capacity reservation group, so that the
+// launch path never has to retrieve them, and reports whether the group can be used
+type CapacityReservationReconciler struct {
+	capacityReservationProvider *capacityreservation.Provider
+}
+
+func NewCapacityReservationReconciler(capacityReservationProvider *capacityreservation.Provider) *CapacityReservationReconciler {
+	return &CapacityReservationReconciler{
+		capacityReservationProvider: capacityReservationProvider,
+	}
+}
+
+const (
+	CapacityReservationGroupUnreadyReasonUnknownError = "CapacityReservationGroupUnknownError"
+
+	capacityReservationReconcilerName = "nodeclass.capacityreservation"
+)
+
+func (r *CapacityReservationReconciler) Reconcile(ctx context.Context, nodeClass *v1beta1.AKSNodeClass) (reconcile.Result, error) {
+	groupID := nodeClass.GetCapacityReservationGroupID()
+	if groupID == "" || r.capacityReservationProvider == nil {
+		nodeClass.StatusConditions().SetTrue(v1beta1.ConditionTypeCapacityReservationGroupReady)
+		return reconcile.Result{}, nil
+	}
+	logger := log.FromContext(ctx).WithName(capacityReservationReconcilerName).WithValues("capacityReservationGroupID", groupID)
+
+	if err := r.capacityReservationProvider.Refresh(ctx, groupID); err != nil {
+		// A missing group, or one Karpenter isn't allowed to read, is not retried until the next refresh:
+		// instances are created without it in the meantime
+		if reason := capacityreservation.GroupUnavailableReason(err); reason != "" {
+			nodeClass.StatusConditions().SetFalse(
+				v1beta1.ConditionTypeCapacityReservationGroupReady,
+				reason,
+				fmt.Sprintf("capacity reservation group %s can't be used, instances are created without it: %s", groupID, err.Error()),
+			)
+			return reconcile.Result{RequeueAfter: capacityreservation.RefreshInterval}, nil
+		}
+		nodeClass.StatusConditions().SetFalse(
+			v1beta1.ConditionTypeCapacityReservationGroupReady,
+			CapacityReservationGroupUnreadyReasonUnknownError,
+			fmt.Sprintf("unknown error getting capacity reservation group: %s", err.Error()),
+		)
+		logger.Error(err, "refreshing capacity reservations failed during reconciliation with unknown error")
+		return reconcile.Result{}, err
+	}
+
+	nodeClass.StatusConditions().SetTrue(v1beta1.ConditionTypeCapacityReservationGroupReady)
+	return reconcile.Result{RequeueAfter: capacityreservation.RefreshInterval}, nil
+}
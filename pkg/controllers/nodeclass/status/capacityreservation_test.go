@@ -0,0 +1,94 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status_test
+
+import (
+	"net/http"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v7"
+	opstatus "github.com/awslabs/operatorpkg/status"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+
+	"github.com/Azure/karpenter-provider-azure/pkg/apis/v1beta1"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/capacityreservation"
+	"github.com/Azure/karpenter-provider-azure/pkg/test"
+)
+
+var _ = Describe("CapacityReservationStatus", func() {
+	const capacityReservationGroupID = "/subscriptions/subscriptionID/resourceGroups/reservations/providers/Microsoft.Compute/capacityReservationGroups/crg"
+	var nodeClass *v1beta1.AKSNodeClass
+
+	BeforeEach(func() {
+		nodeClass = test.AKSNodeClass()
+	})
+
+	It("should mark the capacity reservation group ready when the nodeclass has none", func() {
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+
+		Expect(nodeClass.StatusConditions().Get(v1beta1.ConditionTypeCapacityReservationGroupReady).IsTrue()).To(BeTrue())
+	})
+
+	It("should refresh the reservations of the capacity reservation group", func() {
+		azureEnv.CapacityReservationsAPI.CapacityReservations.Append(&armcompute.CapacityReservation{
+			Name: lo.ToPtr("Standard_D2_v2"),
+			SKU:  &armcompute.SKU{Name: lo.ToPtr("Standard_D2_v2"), Capacity: lo.ToPtr[int64](1)},
+		})
+		nodeClass.Spec.CapacityReservationGroupID = lo.ToPtr(capacityReservationGroupID)
+
+		ExpectApplied(ctx, env.Client, nodeClass)
+		result := ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+
+		Expect(nodeClass.StatusConditions().Get(v1beta1.ConditionTypeCapacityReservationGroupReady).IsTrue()).To(BeTrue())
+		Expect(result.RequeueAfter).To(BeNumerically("<=", capacityreservation.RefreshInterval))
+		Expect(azureEnv.CapacityReservationProvider.Get(capacityReservationGroupID).Available("Standard_D2_v2", "westus-1")).To(BeTrue())
+	})
+
+	It("should mark the capacity reservation group not ready, but keep the nodeclass ready, when the group is not found", func() {
+		azureEnv.CapacityReservationsAPI.Error = &azcore.ResponseError{ErrorCode: "ResourceNotFound", StatusCode: http.StatusNotFound}
+		nodeClass.Spec.CapacityReservationGroupID = lo.ToPtr(capacityReservationGroupID)
+
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+
+		cond := nodeClass.StatusConditions().Get(v1beta1.ConditionTypeCapacityReservationGroupReady)
+		Expect(cond.IsFalse()).To(BeTrue())
+		Expect(cond.Reason).To(Equal(capacityreservation.GroupUnavailableReasonNotFound))
+		Expect(nodeClass.StatusConditions().Get(opstatus.ConditionReady).IsTrue()).To(BeTrue())
+		Expect(azureEnv.CapacityReservationProvider.Get(capacityReservationGroupID)).To(BeNil())
+	})
+
+	It("should mark the capacity reservation group not ready when Karpenter isn't allowed to read it", func() {
+		azureEnv.CapacityReservationsAPI.Error = &azcore.ResponseError{ErrorCode: "AuthorizationFailed", StatusCode: http.StatusForbidden}
+		nodeClass.Spec.CapacityReservationGroupID = lo.ToPtr(capacityReservationGroupID)
+
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+
+		cond := nodeClass.StatusConditions().Get(v1beta1.ConditionTypeCapacityReservationGroupReady)
+		Expect(cond.IsFalse()).To(BeTrue())
+		Expect(cond.Reason).To(Equal(capacityreservation.GroupUnavailableReasonUnauthorized))
+	})
+})
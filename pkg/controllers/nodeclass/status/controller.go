@@ -36,8 +36,12 @@ import (
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
 	"github.com/Azure/karpenter-provider-azure/pkg/apis/v1beta1"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/azclient/azapi"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/capacityreservation"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/imagefamily"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/kubernetesversion"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/networksecuritygroup"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/pricing"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/subnetcapacity"
 	"github.com/awslabs/operatorpkg/reasonable"
 )
 
@@ -48,11 +52,14 @@ type reconciler interface {
 type Controller struct {
 	kubeClient client.Client
 
-	kubernetesVersion *KubernetesVersionReconciler
-	nodeImage         *NodeImageReconciler
-	subnet            *SubnetReconciler
-	validation        *ValidationReconciler
-	localDNS          *LocalDNSReconciler
+	kubernetesVersion     *KubernetesVersionReconciler
+	nodeImage             *NodeImageReconciler
+	subnet                *SubnetReconciler
+	networkSecurityGroups *NetworkSecurityGroupsReconciler
+	validation            *ValidationReconciler
+	localDNS              *LocalDNSReconciler
+	capacityReservation   *CapacityReservationReconciler
+	pricing               *PricingReconciler
 }
 
 // TODO: Consider splitting this (and other similar constructors)
@@ -65,20 +72,28 @@ func NewController(
 	managedKubernetesInterface kubernetes.Interface,
 	managedDynamicInterface dynamic.Interface,
 	subnetClient azapi.SubnetsAPI,
+	applicationSecurityGroupsClient azapi.ApplicationSecurityGroupsAPI,
+	networkSecurityGroupsClient networksecuritygroup.API,
 	diskEncryptionSetsClient azapi.DiskEncryptionSetsAPI,
 	parsedDiskEncryptionSetID *arm.ResourceID,
 	networkPolicy string,
 	networkPlugin string,
+	capacityReservationProvider *capacityreservation.Provider,
+	subnetCapacityProvider *subnetcapacity.Provider,
+	pricingProvider *pricing.Provider,
 ) *Controller {
 	return &Controller{
 
 		kubeClient: kubeClient,
 
-		kubernetesVersion: NewKubernetesVersionReconciler(kubernetesVersionProvider),
-		nodeImage:         NewNodeImageReconciler(nodeImageProvider, inClusterKubernetesInterface),
-		subnet:            NewSubnetReconciler(subnetClient),
-		validation:        NewValidationReconciler(diskEncryptionSetsClient, parsedDiskEncryptionSetID),
-		localDNS:          NewLocalDNSReconciler(managedKubernetesInterface, managedDynamicInterface, networkPolicy, networkPlugin),
+		kubernetesVersion:     NewKubernetesVersionReconciler(kubernetesVersionProvider),
+		nodeImage:             NewNodeImageReconciler(nodeImageProvider, inClusterKubernetesInterface),
+		subnet:                NewSubnetReconciler(subnetClient, subnetCapacityProvider),
+		networkSecurityGroups: NewNetworkSecurityGroupsReconciler(applicationSecurityGroupsClient, networkSecurityGroupsClient),
+		validation:            NewValidationReconciler(diskEncryptionSetsClient, parsedDiskEncryptionSetID),
+		localDNS:              NewLocalDNSReconciler(managedKubernetesInterface, managedDynamicInterface, networkPolicy, networkPlugin),
+		capacityReservation:   NewCapacityReservationReconciler(capacityReservationProvider),
+		pricing:               NewPricingReconciler(pricingProvider),
 	}
 }
 
@@ -100,8 +115,11 @@ func (c *Controller) Reconcile(ctx context.Context, nodeClass *v1beta1.AKSNodeCl
 		c.kubernetesVersion,
 		c.nodeImage,
 		c.subnet,
+		c.networkSecurityGroups,
 		c.validation,
 		c.localDNS,
+		c.capacityReservation,
+		c.pricing,
 	} {
 		res, err := reconciler.Reconcile(ctx, nodeClass)
 		errs = multierr.Append(errs, err)
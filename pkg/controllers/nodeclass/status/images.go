@@ -47,6 +47,11 @@ import (
 	"sigs.k8s.io/karpenter/pkg/utils/pretty"
 )
 
+const (
+	ImagesUnreadyReasonCustomImageInvalid   = "CustomImageInvalid"
+	ImagesUnreadyReasonCustomImageNotUsable = "CustomImageNotUsable"
+)
+
 const (
 	nodeImageReconcilerName = "nodeclass.images"
 
@@ -104,6 +109,9 @@ func (r *NodeImageReconciler) Register(_ context.Context, m manager.Manager) err
 //   - 5. Handles update cases when customer changes image family, SIG usage, or other means of image selectors
 //   - 6. Handles softly adding newest image version of any newly supported SKUs by Karpenter
 //
+// Custom images (spec.imageID) follow the same flow: new versions of a custom image definition are only picked up
+// within a maintenance window, while a pinned custom image version is applied right away.
+//
 // Note: While we'd currently only need to store a SKU -> version mapping in the status for avilaible Images
 // we decided to store the full image ID, plus Requirements associated with it. Storing the complete ID is a simple
 // and clean approach while allowing us to extend future capabilities off of it. Additionally, while the decision to
@@ -113,18 +121,44 @@ func (r *NodeImageReconciler) Reconcile(ctx context.Context, nodeClass *v1beta1.
 	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithName(nodeImageReconcilerName))
 	logger := log.FromContext(ctx)
 
+	// validate the custom image reference, if any
+	var customImage *imagefamily.CustomImageReference
+	if nodeClass.UsesCustomImage() {
+		var err error
+		customImage, err = imagefamily.ParseCustomImageID(lo.FromPtr(nodeClass.Spec.ImageID))
+		if err != nil {
+			nodeClass.Status.Images = nil
+			nodeClass.StatusConditions().SetFalse(v1beta1.ConditionTypeImagesReady, ImagesUnreadyReasonCustomImageInvalid, err.Error())
+			logger.Info("invalid custom image", "error", err)
+			return reconcile.Result{}, nil
+		}
+	}
+
 	// validate FIPS + useSIG
+	// Note: custom images are always referenced through their Shared Image Gallery ID, so this doesn't apply to them.
 	fipsMode := nodeClass.Spec.FIPSMode
 	useSIG := options.FromContext(ctx).UseSIG
-	if lo.FromPtr(fipsMode) == v1beta1.FIPSModeFIPS && !useSIG {
+	if customImage == nil && lo.FromPtr(fipsMode) == v1beta1.FIPSModeFIPS && !useSIG {
 		nodeClass.Status.Images = nil
 		nodeClass.StatusConditions().SetFalse(v1beta1.ConditionTypeImagesReady, "SIGRequiredForFIPS", "FIPS images require UseSIG to be enabled, but UseSIG is false (note: UseSIG is only supported in AKS managed NAP)")
 		logger.Info("FIPS images require SIG", "error", fmt.Errorf("FIPS images require UseSIG to be enabled, but UseSIG is false (note: UseSIG is only supported in AKS managed NAP)"))
 		return reconcile.Result{}, nil
 	}
+	if customImage == nil && nodeClass.IsWindows() && !useSIG {
+		nodeClass.Status.Images = nil
+		nodeClass.StatusConditions().SetFalse(v1beta1.ConditionTypeImagesReady, "SIGRequiredForWindows", "Windows images require UseSIG to be enabled, but UseSIG is false (note: UseSIG is only supported in AKS managed NAP)")
+		logger.Info("Windows images require SIG", "error", fmt.Errorf("windows images require UseSIG to be enabled, but UseSIG is false (note: UseSIG is only supported in AKS managed NAP)"))
+		return reconcile.Result{}, nil
+	}
 
 	nodeImages, err := r.nodeImageProvider.List(ctx, nodeClass)
 	if err != nil {
+		if imagefamily.IsCustomImageNotUsableError(err) {
+			nodeClass.Status.Images = nil
+			nodeClass.StatusConditions().SetFalse(v1beta1.ConditionTypeImagesReady, ImagesUnreadyReasonCustomImageNotUsable, err.Error())
+			logger.Info("custom image is not usable", "error", err)
+			return reconcile.Result{RequeueAfter: 5 * time.Minute}, nil
+		}
 		return reconcile.Result{}, fmt.Errorf("getting nodeimages, %w", err)
 	}
 	goalImages := lo.Map(nodeImages, func(nodeImage imagefamily.NodeImage, _ int) v1beta1.NodeImage {
@@ -158,7 +192,8 @@ func (r *NodeImageReconciler) Reconcile(ctx context.Context, nodeClass *v1beta1.
 			return reconcile.Result{}, fmt.Errorf("checking maintenance window, %w", err)
 		}
 	}
-	if !shouldUpdate {
+	// A pinned custom image version is an explicit user choice, which we roll out regardless of maintenance windows
+	if !shouldUpdate && !(customImage != nil && customImage.IsVersionPinned()) {
 		// Scenario B: Calculate any partial update based on image selectors, or newly supports SKUs
 		goalImages = overrideAnyGoalStateVersionsWithExisting(nodeClass, goalImages)
 	}
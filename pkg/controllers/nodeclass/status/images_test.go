@@ -218,6 +218,157 @@ var _ = Describe("NodeClass NodeImage Status Controller", func() {
 			})
 		})
 
+		Context("Windows Validation With UseSIG", func() {
+			var (
+				imageReconciler *status.NodeImageReconciler
+			)
+
+			BeforeEach(func() {
+				imageReconciler = status.NewNodeImageReconciler(azureEnv.ImageProvider, env.KubernetesInterface)
+				nodeClass.Spec.ImageFamily = lo.ToPtr(v1beta1.Windows2022ImageFamily)
+			})
+
+			It("images ready status should be false if a Windows image family is used but UseSIG is false", func() {
+				ctx = test.Options(test.OptionsFields{
+					UseSIG: lo.ToPtr(false),
+				}).ToContext(ctx)
+
+				result, err := imageReconciler.Reconcile(ctx, nodeClass)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result.RequeueAfter).To(BeZero())
+				Expect(nodeClass.Status.Images).To(BeNil())
+
+				condition := nodeClass.StatusConditions().Get(v1beta1.ConditionTypeImagesReady)
+				Expect(condition.IsFalse()).To(BeTrue())
+				Expect(condition.Reason).To(Equal("SIGRequiredForWindows"))
+			})
+			It("should resolve Windows images when UseSIG is true", func() {
+				ctx = test.Options(test.OptionsFields{
+					UseSIG: lo.ToPtr(true),
+				}).ToContext(ctx)
+
+				_, err := imageReconciler.Reconcile(ctx, nodeClass)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(nodeClass.Status.Images).ToNot(BeEmpty())
+				for _, image := range nodeClass.Status.Images {
+					Expect(image.ID).To(ContainSubstring("/galleries/AKSWindows/images/windows-2022-containerd"))
+				}
+				Expect(nodeClass.StatusConditions().Get(v1beta1.ConditionTypeImagesReady).IsTrue()).To(BeTrue())
+			})
+		})
+
+		Context("Custom images", func() {
+			const customImageDefinitionID = "/subscriptions/12345678-1234-1234-1234-123456789012/resourceGroups/my-rg/providers/Microsoft.Compute/galleries/mygallery/images/hardened-ubuntu"
+			var (
+				imageReconciler *status.NodeImageReconciler
+			)
+
+			addCustomImageVersions := func(versions ...string) {
+				for _, version := range versions {
+					azureEnv.GalleryImagesAPI.ImageVersions.Append(&armcompute.GalleryImageVersion{
+						ID:   lo.ToPtr(customImageDefinitionID + "/versions/" + version),
+						Name: lo.ToPtr(version),
+						Properties: &armcompute.GalleryImageVersionProperties{
+							ProvisioningState: lo.ToPtr(armcompute.GalleryProvisioningStateSucceeded),
+						},
+					})
+				}
+				azureEnv.NodeImagesCache.Flush()
+			}
+
+			BeforeEach(func() {
+				os.Setenv("SYSTEM_NAMESPACE", "kube-system")
+				imageReconciler = status.NewNodeImageReconciler(azureEnv.ImageProvider, env.KubernetesInterface)
+				azureEnv.GalleryImagesAPI.Images.Append(&armcompute.GalleryImage{
+					ID: lo.ToPtr(customImageDefinitionID),
+					Properties: &armcompute.GalleryImageProperties{
+						OSType:           lo.ToPtr(armcompute.OperatingSystemTypesLinux),
+						Architecture:     lo.ToPtr(armcompute.ArchitectureX64),
+						HyperVGeneration: lo.ToPtr(armcompute.HyperVGenerationV2),
+					},
+				})
+				addCustomImageVersions("1.0.0")
+				nodeClass.Spec.ImageID = lo.ToPtr(customImageDefinitionID)
+			})
+
+			It("should resolve the latest version of the custom image definition", func() {
+				_, err := imageReconciler.Reconcile(ctx, nodeClass)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(nodeClass.Status.Images).To(HaveLen(1))
+				Expect(nodeClass.Status.Images[0].ID).To(Equal(customImageDefinitionID + "/versions/1.0.0"))
+				Expect(nodeClass.StatusConditions().IsTrue(v1beta1.ConditionTypeImagesReady)).To(BeTrue())
+			})
+
+			It("should only pick up new versions of the custom image definition when the maintenance window is open", func() {
+				_, err := imageReconciler.Reconcile(ctx, nodeClass)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(nodeClass.Status.Images[0].ID).To(Equal(customImageDefinitionID + "/versions/1.0.0"))
+
+				addCustomImageVersions("1.1.0")
+				ExpectApplied(ctx, env.Client, getClosedMWConfigMap())
+				_, err = imageReconciler.Reconcile(ctx, nodeClass)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(nodeClass.Status.Images[0].ID).To(Equal(customImageDefinitionID + "/versions/1.0.0"))
+
+				ExpectApplied(ctx, env.Client, getOpenMWConfigMap())
+				_, err = imageReconciler.Reconcile(ctx, nodeClass)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(nodeClass.Status.Images[0].ID).To(Equal(customImageDefinitionID + "/versions/1.1.0"))
+			})
+
+			It("should apply a pinned custom image version regardless of the maintenance window", func() {
+				addCustomImageVersions("1.1.0")
+				nodeClass.Spec.ImageID = lo.ToPtr(customImageDefinitionID + "/versions/1.0.0")
+				nodeClass.Status.Images = []v1beta1.NodeImage{{ID: customImageDefinitionID + "/versions/1.1.0"}}
+				ExpectApplied(ctx, env.Client, getClosedMWConfigMap())
+
+				_, err := imageReconciler.Reconcile(ctx, nodeClass)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(nodeClass.Status.Images).To(HaveLen(1))
+				Expect(nodeClass.Status.Images[0].ID).To(Equal(customImageDefinitionID + "/versions/1.0.0"))
+			})
+
+			It("images ready status should be false if the custom image ID is invalid", func() {
+				nodeClass.Spec.ImageID = lo.ToPtr("/subscriptions/12345678-1234-1234-1234-123456789012/resourceGroups/my-rg/providers/Microsoft.Compute/images/my-managed-image")
+
+				result, err := imageReconciler.Reconcile(ctx, nodeClass)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result.RequeueAfter).To(BeZero())
+				Expect(nodeClass.Status.Images).To(BeNil())
+
+				condition := nodeClass.StatusConditions().Get(v1beta1.ConditionTypeImagesReady)
+				Expect(condition.IsFalse()).To(BeTrue())
+				Expect(condition.Reason).To(Equal(status.ImagesUnreadyReasonCustomImageInvalid))
+			})
+
+			It("images ready status should be false if the custom image is not found", func() {
+				nodeClass.Spec.ImageID = lo.ToPtr(customImageDefinitionID + "-does-not-exist")
+
+				result, err := imageReconciler.Reconcile(ctx, nodeClass)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result.RequeueAfter).ToNot(BeZero())
+				Expect(nodeClass.Status.Images).To(BeNil())
+
+				condition := nodeClass.StatusConditions().Get(v1beta1.ConditionTypeImagesReady)
+				Expect(condition.IsFalse()).To(BeTrue())
+				Expect(condition.Reason).To(Equal(status.ImagesUnreadyReasonCustomImageNotUsable))
+			})
+
+			It("should not require UseSIG for FIPS with a custom image", func() {
+				options := test.Options(test.OptionsFields{
+					UseSIG: lo.ToPtr(false),
+				})
+				ctx = options.ToContext(ctx)
+				nodeClass.Spec.FIPSMode = &v1beta1.FIPSModeFIPS
+				nodeClass.Spec.ImageFamily = lo.ToPtr(v1beta1.AzureLinuxImageFamily)
+
+				_, err := imageReconciler.Reconcile(ctx, nodeClass)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(nodeClass.StatusConditions().IsTrue(v1beta1.ConditionTypeImagesReady)).To(BeTrue())
+			})
+		})
+
 		When("SYSTEM_NAMESPACE is set", func() {
 			var (
 				imageReconciler *status.NodeImageReconciler
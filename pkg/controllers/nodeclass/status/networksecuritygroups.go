@@ -0,0 +1,144 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	sdkerrors "github.com/Azure/azure-sdk-for-go-extensions/pkg/errors"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/karpenter-provider-azure/pkg/apis/v1beta1"
+	"github.com/Azure/karpenter-provider-azure/pkg/operator/options"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/azclient/azapi"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/networksecuritygroup"
+	"github.com/Azure/karpenter-provider-azure/pkg/utils"
+)
+
+// NetworkSecurityGroupsReconciler validates the application security groups and the network security group that the
+// network interfaces of the AKSNodeClass instances are associated with
+type NetworkSecurityGroupsReconciler struct {
+	applicationSecurityGroupsClient azapi.ApplicationSecurityGroupsAPI
+	networkSecurityGroupsClient     networksecuritygroup.API
+}
+
+func NewNetworkSecurityGroupsReconciler(
+	applicationSecurityGroupsClient azapi.ApplicationSecurityGroupsAPI,
+	networkSecurityGroupsClient networksecuritygroup.API,
+) *NetworkSecurityGroupsReconciler {
+	return &NetworkSecurityGroupsReconciler{
+		applicationSecurityGroupsClient: applicationSecurityGroupsClient,
+		networkSecurityGroupsClient:     networkSecurityGroupsClient,
+	}
+}
+
+const (
+	NetworkSecurityGroupsUnreadyReasonNotFound     = "NetworkSecurityGroupsNotFound"
+	NetworkSecurityGroupsUnreadyReasonIDInvalid    = "NetworkSecurityGroupsIDInvalid"
+	NetworkSecurityGroupsUnreadyReasonUnknownError = "NetworkSecurityGroupsUnknownError"
+
+	networkSecurityGroupsReconcilerName = "nodeclass.networksecuritygroups"
+)
+
+func (r *NetworkSecurityGroupsReconciler) Reconcile(ctx context.Context, nodeClass *v1beta1.AKSNodeClass) (reconcile.Result, error) {
+	if len(nodeClass.Spec.ApplicationSecurityGroupIDs) == 0 && nodeClass.Spec.NetworkSecurityGroupID == nil {
+		nodeClass.StatusConditions().SetTrue(v1beta1.ConditionTypeNetworkSecurityGroupsReady)
+		return reconcile.Result{}, nil
+	}
+
+	for _, asgID := range nodeClass.Spec.ApplicationSecurityGroupIDs {
+		ready, result, err := r.validate(ctx, nodeClass, "application security group", asgID, func(id *arm.ResourceID) error {
+			_, err := r.applicationSecurityGroupsClient.Get(ctx, id.ResourceGroupName, id.Name, nil)
+			return err
+		})
+		if !ready {
+			return result, err
+		}
+	}
+	if nsgID := nodeClass.Spec.NetworkSecurityGroupID; nsgID != nil {
+		ready, result, err := r.validate(ctx, nodeClass, "network security group", *nsgID, func(id *arm.ResourceID) error {
+			_, err := r.networkSecurityGroupsClient.Get(ctx, id.ResourceGroupName, id.Name, nil)
+			return err
+		})
+		if !ready {
+			return result, err
+		}
+	}
+
+	nodeClass.StatusConditions().SetTrue(v1beta1.ConditionTypeNetworkSecurityGroupsReady)
+
+	// Periodically requeue just in case the groups have been removed
+	return reconcile.Result{RequeueAfter: healthyRequeueInterval}, nil
+}
+
+// validate checks that the group is in the subscription of the cluster, which the clients are bound to, and that it exists.
+// If it isn't, it sets the condition to false and returns false.
+func (r *NetworkSecurityGroupsReconciler) validate(
+	ctx context.Context,
+	nodeClass *v1beta1.AKSNodeClass,
+	kind string,
+	resourceID string,
+	get func(*arm.ResourceID) error,
+) (bool, reconcile.Result, error) {
+	logger := log.FromContext(ctx).WithName(networkSecurityGroupsReconcilerName).WithValues("resourceID", resourceID)
+
+	id, err := arm.ParseResourceID(resourceID)
+	if err != nil {
+		logger.Error(err, "failed to parse resource ID")
+		nodeClass.StatusConditions().SetFalse(
+			v1beta1.ConditionTypeNetworkSecurityGroupsReady,
+			NetworkSecurityGroupsUnreadyReasonIDInvalid,
+			fmt.Sprintf("Failed to parse %s ID %s", kind, resourceID),
+		)
+		return false, reconcile.Result{}, nil
+	}
+	clusterSubnetIDParts, err := utils.GetVnetSubnetIDComponents(options.FromContext(ctx).SubnetID) // Assume valid cluster subnet id
+	if err == nil && !strings.EqualFold(clusterSubnetIDParts.SubscriptionID, id.SubscriptionID) {
+		nodeClass.StatusConditions().SetFalse(
+			v1beta1.ConditionTypeNetworkSecurityGroupsReady,
+			NetworkSecurityGroupsUnreadyReasonIDInvalid,
+			fmt.Sprintf("%s %s is not in the subscription of the cluster", kind, resourceID),
+		)
+		return false, reconcile.Result{}, nil
+	}
+
+	if err := get(id); err != nil {
+		azErr := sdkerrors.IsResponseError(err)
+		if azErr != nil && azErr.StatusCode == http.StatusNotFound {
+			nodeClass.StatusConditions().SetFalse(
+				v1beta1.ConditionTypeNetworkSecurityGroupsReady,
+				NetworkSecurityGroupsUnreadyReasonNotFound,
+				fmt.Sprintf("resource not found: %s", resourceID),
+			)
+			return false, reconcile.Result{RequeueAfter: time.Minute}, err
+		}
+		nodeClass.StatusConditions().SetFalse(
+			v1beta1.ConditionTypeNetworkSecurityGroupsReady,
+			NetworkSecurityGroupsUnreadyReasonUnknownError,
+			fmt.Sprintf("unknown error getting %s: %s", kind, err.Error()),
+		)
+		logger.Error(err, "getting group failed during reconciliation with unknown error", "kind", kind)
+		return false, reconcile.Result{}, err
+	}
+	return true, reconcile.Result{}, nil
+}
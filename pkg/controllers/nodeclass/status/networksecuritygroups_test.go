@@ -0,0 +1,139 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status_test
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork"
+	opstatus "github.com/awslabs/operatorpkg/status"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+
+	"github.com/Azure/karpenter-provider-azure/pkg/apis/v1beta1"
+	"github.com/Azure/karpenter-provider-azure/pkg/controllers/nodeclass/status"
+	"github.com/Azure/karpenter-provider-azure/pkg/fake"
+	"github.com/Azure/karpenter-provider-azure/pkg/test"
+)
+
+var _ = Describe("NetworkSecurityGroupsStatus", func() {
+	const applicationSecurityGroupID = "/subscriptions/12345678-1234-1234-1234-123456789012/resourceGroups/security/providers/Microsoft.Network/applicationSecurityGroups/asg"
+	var nodeClass *v1beta1.AKSNodeClass
+
+	BeforeEach(func() {
+		nodeClass = test.AKSNodeClass()
+	})
+
+	It("should mark the network security groups ready when the nodeclass has none", func() {
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+
+		Expect(nodeClass.StatusConditions().Get(v1beta1.ConditionTypeNetworkSecurityGroupsReady).IsTrue()).To(BeTrue())
+	})
+
+	It("should mark the nodeclass not ready when an application security group is not found", func() {
+		azureEnv.ApplicationSecurityGroupsAPI.GetFunc = func(_ context.Context, _ string, _ string, _ *armnetwork.ApplicationSecurityGroupsClientGetOptions) (armnetwork.ApplicationSecurityGroupsClientGetResponse, error) {
+			return armnetwork.ApplicationSecurityGroupsClientGetResponse{}, &azcore.ResponseError{ErrorCode: "ResourceNotFound", StatusCode: http.StatusNotFound}
+		}
+		nodeClass.Spec.ApplicationSecurityGroupIDs = []string{applicationSecurityGroupID}
+
+		ExpectApplied(ctx, env.Client, nodeClass)
+		_, err := controller.Reconcile(ctx, nodeClass)
+		Expect(err).To(HaveOccurred())
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+
+		cond := nodeClass.StatusConditions().Get(v1beta1.ConditionTypeNetworkSecurityGroupsReady)
+		Expect(cond.IsFalse()).To(BeTrue())
+		Expect(cond.Reason).To(Equal(status.NetworkSecurityGroupsUnreadyReasonNotFound))
+		Expect(nodeClass.StatusConditions().Get(opstatus.ConditionReady).IsFalse()).To(BeTrue())
+	})
+
+	Context("NetworkSecurityGroupsReconciler direct tests", func() {
+		var reconciler *status.NetworkSecurityGroupsReconciler
+
+		BeforeEach(func() {
+			reconciler = status.NewNetworkSecurityGroupsReconciler(azureEnv.ApplicationSecurityGroupsAPI, azureEnv.NetworkSecurityGroupAPI)
+		})
+
+		It("should mark the network security groups ready when the groups exist", func() {
+			nsg := test.MakeNetworkSecurityGroup("security", "strict-nsg")
+			azureEnv.NetworkSecurityGroupAPI.NSGs.Store(lo.FromPtr(nsg.ID), nsg)
+			var gets []string
+			azureEnv.ApplicationSecurityGroupsAPI.GetFunc = func(_ context.Context, resourceGroupName string, applicationSecurityGroupName string, _ *armnetwork.ApplicationSecurityGroupsClientGetOptions) (armnetwork.ApplicationSecurityGroupsClientGetResponse, error) {
+				gets = append(gets, resourceGroupName+"/"+applicationSecurityGroupName)
+				return armnetwork.ApplicationSecurityGroupsClientGetResponse{}, nil
+			}
+			nodeClass.Spec.ApplicationSecurityGroupIDs = []string{applicationSecurityGroupID}
+			nodeClass.Spec.NetworkSecurityGroupID = nsg.ID
+
+			result, err := reconciler.Reconcile(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(Equal(reconcile.Result{RequeueAfter: time.Minute * 3}))
+			Expect(gets).To(ConsistOf("security/asg"))
+
+			Expect(nodeClass.StatusConditions().Get(v1beta1.ConditionTypeNetworkSecurityGroupsReady).IsTrue()).To(BeTrue())
+		})
+
+		It("should mark the network security groups not ready when the network security group is not found", func() {
+			nodeClass.Spec.NetworkSecurityGroupID = lo.ToPtr(fake.MakeNetworkSecurityGroupID("security", "missing-nsg"))
+
+			result, err := reconciler.Reconcile(ctx, nodeClass)
+			Expect(err).To(HaveOccurred())
+			Expect(result).To(Equal(reconcile.Result{RequeueAfter: time.Minute}))
+
+			cond := nodeClass.StatusConditions().Get(v1beta1.ConditionTypeNetworkSecurityGroupsReady)
+			Expect(cond.IsFalse()).To(BeTrue())
+			Expect(cond.Reason).To(Equal(status.NetworkSecurityGroupsUnreadyReasonNotFound))
+			Expect(cond.Message).To(ContainSubstring("missing-nsg"))
+		})
+
+		It("should mark the network security groups not ready when a group is in another subscription", func() {
+			nodeClass.Spec.ApplicationSecurityGroupIDs = []string{"/subscriptions/87654321-1234-1234-1234-123456789012/resourceGroups/security/providers/Microsoft.Network/applicationSecurityGroups/asg"}
+
+			result, err := reconciler.Reconcile(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(Equal(reconcile.Result{}))
+
+			cond := nodeClass.StatusConditions().Get(v1beta1.ConditionTypeNetworkSecurityGroupsReady)
+			Expect(cond.IsFalse()).To(BeTrue())
+			Expect(cond.Reason).To(Equal(status.NetworkSecurityGroupsUnreadyReasonIDInvalid))
+			Expect(cond.Message).To(ContainSubstring("is not in the subscription of the cluster"))
+		})
+
+		It("should mark the network security groups not ready when getting a group hits an unknown error", func() {
+			azureEnv.ApplicationSecurityGroupsAPI.GetFunc = func(_ context.Context, _ string, _ string, _ *armnetwork.ApplicationSecurityGroupsClientGetOptions) (armnetwork.ApplicationSecurityGroupsClientGetResponse, error) {
+				return armnetwork.ApplicationSecurityGroupsClientGetResponse{}, &azcore.ResponseError{ErrorCode: "InternalServerError", StatusCode: http.StatusInternalServerError}
+			}
+			nodeClass.Spec.ApplicationSecurityGroupIDs = []string{applicationSecurityGroupID}
+
+			result, err := reconciler.Reconcile(ctx, nodeClass)
+			Expect(err).To(HaveOccurred())
+			Expect(result).To(Equal(reconcile.Result{}))
+
+			cond := nodeClass.StatusConditions().Get(v1beta1.ConditionTypeNetworkSecurityGroupsReady)
+			Expect(cond.IsFalse()).To(BeTrue())
+			Expect(cond.Reason).To(Equal(status.NetworkSecurityGroupsUnreadyReasonUnknownError))
+		})
+	})
+})
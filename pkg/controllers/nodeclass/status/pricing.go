@@ -0,0 +1,73 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/Azure/karpenter-provider-azure/pkg/apis/v1beta1"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/pricing"
+)
+
+const (
+	PricingUpToDateReasonOffline = "OfflinePricing"
+	PricingUpToDateReasonStale   = "PricingStale"
+
+	// pricingRecheckInterval is how often the staleness of the prices is checked
+	pricingRecheckInterval = time.Hour
+)
+
+// PricingReconciler reports whether the prices used to rank offerings are up to date
+type PricingReconciler struct {
+	pricingProvider *pricing.Provider
+}
+
+func NewPricingReconciler(pricingProvider *pricing.Provider) *PricingReconciler {
+	return &PricingReconciler{
+		pricingProvider: pricingProvider,
+	}
+}
+
+func (r *PricingReconciler) Reconcile(_ context.Context, nodeClass *v1beta1.AKSNodeClass) (reconcile.Result, error) {
+	if r.pricingProvider == nil {
+		nodeClass.StatusConditions().SetTrue(v1beta1.ConditionTypePricingUpToDate)
+		return reconcile.Result{}, nil
+	}
+	lastUpdated := r.pricingProvider.LastUpdated()
+
+	switch {
+	case r.pricingProvider.Offline():
+		nodeClass.StatusConditions().SetTrueWithReason(
+			v1beta1.ConditionTypePricingUpToDate,
+			PricingUpToDateReasonOffline,
+			fmt.Sprintf("using offline prices from the %s, retrieved at %s", r.pricingProvider.Source(), lastUpdated.Format(time.RFC3339)),
+		)
+	case r.pricingProvider.Stale():
+		nodeClass.StatusConditions().SetFalse(
+			v1beta1.ConditionTypePricingUpToDate,
+			PricingUpToDateReasonStale,
+			fmt.Sprintf("prices were last updated at %s, updates from the %s keep failing", lastUpdated.Format(time.RFC3339), r.pricingProvider.Source()),
+		)
+	default:
+		nodeClass.StatusConditions().SetTrue(v1beta1.ConditionTypePricingUpToDate)
+	}
+	return reconcile.Result{RequeueAfter: pricingRecheckInterval}, nil
+}
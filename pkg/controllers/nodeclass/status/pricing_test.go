@@ -0,0 +1,73 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status_test
+
+import (
+	opstatus "github.com/awslabs/operatorpkg/status"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+
+	"github.com/Azure/karpenter-provider-azure/pkg/apis/v1beta1"
+	"github.com/Azure/karpenter-provider-azure/pkg/auth"
+	"github.com/Azure/karpenter-provider-azure/pkg/controllers/nodeclass/status"
+	"github.com/Azure/karpenter-provider-azure/pkg/fake"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/pricing"
+	"github.com/Azure/karpenter-provider-azure/pkg/test"
+)
+
+var _ = Describe("PricingStatus", func() {
+	var nodeClass *v1beta1.AKSNodeClass
+
+	BeforeEach(func() {
+		nodeClass = test.AKSNodeClass()
+	})
+
+	It("should mark the pricing up to date when the prices are updated", func() {
+		ExpectApplied(ctx, env.Client, nodeClass)
+		result := ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+
+		Expect(nodeClass.StatusConditions().Get(v1beta1.ConditionTypePricingUpToDate).IsTrue()).To(BeTrue())
+		Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+	})
+
+	It("should mark the pricing up to date, but offline, with offline pricing", func() {
+		cloudEnv, err := auth.EnvironmentFromName("AzurePublicCloud")
+		Expect(err).ToNot(HaveOccurred())
+		pricingProvider := pricing.NewProvider(ctx, cloudEnv, &fake.PricingAPI{}, fake.Region, make(chan struct{}), pricing.WithOfflinePricing(nil))
+		reconciler := status.NewPricingReconciler(pricingProvider)
+
+		_, err = reconciler.Reconcile(ctx, nodeClass)
+		Expect(err).ToNot(HaveOccurred())
+
+		cond := nodeClass.StatusConditions().Get(v1beta1.ConditionTypePricingUpToDate)
+		Expect(cond.IsTrue()).To(BeTrue())
+		Expect(cond.Reason).To(Equal(status.PricingUpToDateReasonOffline))
+		Expect(cond.Message).To(ContainSubstring("static pricing data"))
+	})
+
+	It("should not affect the readiness of the nodeclass", func() {
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectObjectReconciled(ctx, env.Client, controller, nodeClass)
+		nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+
+		Expect(nodeClass.StatusConditions().Get(opstatus.ConditionReady).IsTrue()).To(BeTrue())
+		nodeClass.StatusConditions().SetFalse(v1beta1.ConditionTypePricingUpToDate, status.PricingUpToDateReasonStale, "stale")
+		Expect(nodeClass.StatusConditions().Get(opstatus.ConditionReady).IsTrue()).To(BeTrue())
+	})
+})
@@ -18,28 +18,39 @@ package status
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/samber/lo"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	sdkerrors "github.com/Azure/azure-sdk-for-go-extensions/pkg/errors"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork"
 	"github.com/Azure/karpenter-provider-azure/pkg/apis/v1beta1"
+	"github.com/Azure/karpenter-provider-azure/pkg/consts"
+	"github.com/Azure/karpenter-provider-azure/pkg/metrics"
 	"github.com/Azure/karpenter-provider-azure/pkg/operator/options"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/azclient/azapi"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/subnetcapacity"
 	"github.com/Azure/karpenter-provider-azure/pkg/utils"
 )
 
 type SubnetReconciler struct {
-	subnetClient azapi.SubnetsAPI
+	subnetClient           azapi.SubnetsAPI
+	subnetCapacityProvider *subnetcapacity.Provider
 }
 
-func NewSubnetReconciler(subnetClient azapi.SubnetsAPI) *SubnetReconciler {
+func NewSubnetReconciler(subnetClient azapi.SubnetsAPI, subnetCapacityProvider *subnetcapacity.Provider) *SubnetReconciler {
 	return &SubnetReconciler{
-		subnetClient: subnetClient,
+		subnetClient:           subnetClient,
+		subnetCapacityProvider: subnetCapacityProvider,
 	}
 }
 
@@ -47,6 +58,8 @@ const (
 	SubnetUnreadyReasonNotFound     = "SubnetNotFound"
 	SubnetUnreadyReasonIDInvalid    = "SubnetIDInvalid"
 	SubnetUnreadyReasonUnknownError = "SubnetUnknownError"
+
+	SubnetCapacityUnavailableReasonExhausted = "SubnetCapacityExhausted"
 )
 
 const (
@@ -58,12 +71,18 @@ const (
 )
 
 func (r *SubnetReconciler) Reconcile(ctx context.Context, nodeClass *v1beta1.AKSNodeClass) (reconcile.Result, error) {
-	// TODO: Handle podSubnetID readiness here as well
+	if nodeClass.Spec.PodSubnetID != nil {
+		if ready, result, err := r.validatePodSubnetID(ctx, nodeClass); !ready {
+			return result, err
+		}
+	}
+	if len(nodeClass.Spec.SubnetSelectorTerms) > 0 {
+		return r.resolveSubnetSelectorTerms(ctx, nodeClass)
+	}
 	return r.validateVNETSubnetID(ctx, nodeClass)
 }
 
 func (r *SubnetReconciler) validateVNETSubnetID(ctx context.Context, nodeClass *v1beta1.AKSNodeClass) (reconcile.Result, error) {
-	clusterSubnetID := options.FromContext(ctx).SubnetID
 	subnetID := lo.Ternary(nodeClass.Spec.VNETSubnetID != nil, lo.FromPtr(nodeClass.Spec.VNETSubnetID), options.FromContext(ctx).SubnetID)
 	logger := log.FromContext(ctx).WithName(subnetReconcilerName).WithValues("subnetID", subnetID)
 
@@ -77,62 +96,267 @@ func (r *SubnetReconciler) validateVNETSubnetID(ctx context.Context, nodeClass *
 		)
 		return reconcile.Result{}, nil
 	}
-	if subnetID != clusterSubnetID {
-		clusterSubnetIDParts, err := utils.GetVnetSubnetIDComponents(clusterSubnetID) // Assume valid cluster subnet id
-		if err != nil {                                                               // Highly unlikely case but putting it in nonetheless
-			logger.Error(err, "failed to parse cluster subnet ID", "clusterSubnetID", clusterSubnetID)
-			return reconcile.Result{}, nil
-		}
+	if err := validateSubnetVNET(ctx, subnetID, nodeClassSubnetComponents); err != nil {
+		logger.Error(err, "invalid vnetSubnetID", "subnetID", subnetID)
+		nodeClass.StatusConditions().SetFalse(v1beta1.ConditionTypeSubnetsReady, SubnetUnreadyReasonIDInvalid, err.Error())
+		return reconcile.Result{}, nil
+	}
+
+	if _, err := r.subnetCapacityProvider.Refresh(ctx, subnetID); err != nil {
+		return r.handleSubnetError(ctx, nodeClass, subnetID, err)
+	}
+
+	nodeClass.Status.Subnets = []v1beta1.Subnet{{ID: subnetID, AvailableIPAddressCount: r.availableIPAddressCount(subnetID)}}
+	nodeClass.StatusConditions().SetTrue(v1beta1.ConditionTypeSubnetsReady)
+	r.reconcileSubnetCapacity(ctx, nodeClass)
+
+	// Periodically requeue just in case subnet has been removed or later revalidating things like fullness etc
+	return reconcile.Result{RequeueAfter: healthyRequeueInterval}, nil
+}
+
+// validatePodSubnetID validates the podSubnetID like the vnetSubnetID, and that the cluster allocates pod IPs from it,
+// i.e., uses Azure CNI without overlay. It returns false when SubnetsReady has been set to False.
+func (r *SubnetReconciler) validatePodSubnetID(ctx context.Context, nodeClass *v1beta1.AKSNodeClass) (bool, reconcile.Result, error) {
+	podSubnetID := lo.FromPtr(nodeClass.Spec.PodSubnetID)
+	logger := log.FromContext(ctx).WithName(subnetReconcilerName).WithValues("podSubnetID", podSubnetID)
+
+	opts := options.FromContext(ctx)
+	if opts.NetworkPlugin != consts.NetworkPluginAzure || opts.NetworkPluginMode == consts.NetworkPluginModeOverlay {
+		nodeClass.StatusConditions().SetFalse(
+			v1beta1.ConditionTypeSubnetsReady,
+			SubnetUnreadyReasonIDInvalid,
+			fmt.Sprintf("podSubnetID is only supported with Azure CNI without overlay, the cluster uses network plugin %q in mode %q", opts.NetworkPlugin, opts.NetworkPluginMode),
+		)
+		return false, reconcile.Result{}, nil
+	}
+	podSubnetComponents, err := utils.GetVnetSubnetIDComponents(podSubnetID)
+	if err != nil {
+		logger.Error(err, "failed to parse podSubnetID")
+		nodeClass.StatusConditions().SetFalse(
+			v1beta1.ConditionTypeSubnetsReady,
+			SubnetUnreadyReasonIDInvalid,
+			fmt.Sprintf("Failed to parse podSubnetID %s", podSubnetID),
+		)
+		return false, reconcile.Result{}, nil
+	}
+	if err := validateSubnetVNET(ctx, podSubnetID, podSubnetComponents); err != nil {
+		logger.Error(err, "invalid podSubnetID")
+		nodeClass.StatusConditions().SetFalse(v1beta1.ConditionTypeSubnetsReady, SubnetUnreadyReasonIDInvalid, err.Error())
+		return false, reconcile.Result{}, nil
+	}
+	if _, err := r.subnetClient.Get(ctx, podSubnetComponents.ResourceGroupName, podSubnetComponents.VNetName, podSubnetComponents.SubnetName, nil); err != nil {
+		result, err := r.handleSubnetError(ctx, nodeClass, podSubnetID, err)
+		return false, result, err
+	}
+	return true, reconcile.Result{}, nil
+}
 
-		isClusterManagedVNET, err := utils.IsAKSManagedVNET(options.FromContext(ctx).NodeResourceGroup, clusterSubnetID)
+// resolveSubnetSelectorTerms resolves the subnets selected by the subnetSelectorTerms into the status, validating each of them
+// like the vnetSubnetID. A subnet selected by several terms has affinity to the zones of all of them.
+func (r *SubnetReconciler) resolveSubnetSelectorTerms(ctx context.Context, nodeClass *v1beta1.AKSNodeClass) (reconcile.Result, error) {
+	logger := log.FromContext(ctx).WithName(subnetReconcilerName)
+
+	subnets := map[string]*v1beta1.Subnet{}
+	for _, term := range nodeClass.Spec.SubnetSelectorTerms {
+		selected, err := r.selectSubnets(ctx, term)
 		if err != nil {
-			logger.Error(err, "failed to determine if cluster VNet is managed", "clusterSubnetID", clusterSubnetID)
-			return reconcile.Result{}, nil
+			var invalidErr *invalidSubnetError
+			if errors.As(err, &invalidErr) {
+				logger.Error(err, "invalid subnetSelectorTerms")
+				nodeClass.StatusConditions().SetFalse(v1beta1.ConditionTypeSubnetsReady, SubnetUnreadyReasonIDInvalid, err.Error())
+				return reconcile.Result{}, nil
+			}
+			return r.handleSubnetError(ctx, nodeClass, lo.FromPtr(lo.CoalesceOrEmpty(term.ID, term.VNETID)), err)
+		}
+		for _, subnet := range selected {
+			id := lo.FromPtr(subnet.ID)
+			resolved, ok := subnets[strings.ToLower(id)]
+			if !ok {
+				resolved = &v1beta1.Subnet{ID: id, AvailableIPAddressCount: r.availableIPAddressCount(id)}
+				subnets[strings.ToLower(id)] = resolved
+			}
+			// A term without zones gives the subnet affinity to every zone
+			if len(term.Zones) == 0 || (ok && len(resolved.Zones) == 0) {
+				resolved.Zones = nil
+			} else {
+				resolved.Zones = lo.Uniq(append(resolved.Zones, term.Zones...))
+			}
 		}
+	}
+	if len(subnets) == 0 {
+		nodeClass.StatusConditions().SetFalse(
+			v1beta1.ConditionTypeSubnetsReady,
+			SubnetUnreadyReasonNotFound,
+			"no subnets matched the subnetSelectorTerms",
+		)
+		return reconcile.Result{RequeueAfter: time.Minute}, nil
+	}
 
-		if isClusterManagedVNET && clusterSubnetIDParts.IsSameVNET(nodeClassSubnetComponents) {
-			logger.Error(nil, "custom subnet cannot be in the same VNet as cluster managed VNet", "subnetID", subnetID)
-			nodeClass.StatusConditions().SetFalse(
-				v1beta1.ConditionTypeSubnetsReady,
-				SubnetUnreadyReasonIDInvalid,
-				fmt.Sprintf("custom subnet cannot be in the same VNet as cluster managed VNet: %s", subnetID),
-			)
-			return reconcile.Result{}, nil
+	nodeClass.Status.Subnets = lo.Map(lo.Values(subnets), func(subnet *v1beta1.Subnet, _ int) v1beta1.Subnet {
+		sort.Strings(subnet.Zones)
+		return *subnet
+	})
+	sort.Slice(nodeClass.Status.Subnets, func(i, j int) bool {
+		return nodeClass.Status.Subnets[i].ID < nodeClass.Status.Subnets[j].ID
+	})
+	nodeClass.StatusConditions().SetTrue(v1beta1.ConditionTypeSubnetsReady)
+	r.reconcileSubnetCapacity(ctx, nodeClass)
+	return reconcile.Result{RequeueAfter: healthyRequeueInterval}, nil
+}
+
+// reconcileSubnetCapacity reports whether any subnet of the status has enough free IPs for a node, and publishes their free IPs.
+// Subnets whose free IPs are unknown are assumed to fit a node.
+func (r *SubnetReconciler) reconcileSubnetCapacity(ctx context.Context, nodeClass *v1beta1.AKSNodeClass) {
+	opts := options.FromContext(ctx)
+	required := subnetcapacity.RequiredIPAddressCount(utils.GetMaxPods(nodeClass, opts.NetworkPlugin, opts.NetworkPluginMode), opts.NetworkPlugin, opts.NetworkPluginMode, nodeClass.Spec.PodSubnetID != nil)
+
+	subnetcapacity.AvailableIPAddresses.DeletePartialMatch(prometheus.Labels{metrics.NodeClassLabel: nodeClass.Name})
+	var exhausted []string
+	for _, subnet := range nodeClass.Status.Subnets {
+		if subnet.AvailableIPAddressCount == nil {
+			continue
+		}
+		subnetcapacity.AvailableIPAddresses.With(prometheus.Labels{
+			metrics.NodeClassLabel: nodeClass.Name,
+			metrics.SubnetLabel:    subnet.ID,
+		}).Set(float64(*subnet.AvailableIPAddressCount))
+		if *subnet.AvailableIPAddressCount < required {
+			exhausted = append(exhausted, fmt.Sprintf("%s (%d available)", subnet.ID, *subnet.AvailableIPAddressCount))
 		}
+	}
+	if len(exhausted) > 0 && len(exhausted) == len(nodeClass.Status.Subnets) {
+		nodeClass.StatusConditions().SetFalse(
+			v1beta1.ConditionTypeSubnetCapacityAvailable,
+			SubnetCapacityUnavailableReasonExhausted,
+			fmt.Sprintf("no subnet has the %d free IP addresses a node needs: %s", required, strings.Join(exhausted, ", ")),
+		)
+		return
+	}
+	nodeClass.StatusConditions().SetTrue(v1beta1.ConditionTypeSubnetCapacityAvailable)
+}
+
+// availableIPAddressCount returns the free IPs of the subnet, less the ones of the NICs created since it was observed
+func (r *SubnetReconciler) availableIPAddressCount(subnetID string) *int64 {
+	available, ok := r.subnetCapacityProvider.Available(subnetID)
+	if !ok {
+		return nil
+	}
+	return lo.ToPtr(available)
+}
 
-		if !clusterSubnetIDParts.IsSameVNET(nodeClassSubnetComponents) {
-			logger.Error(nil, "subnet does not match cluster subscription, resource group, or virtual network", "subnetID", subnetID)
-			nodeClass.StatusConditions().SetFalse(
-				v1beta1.ConditionTypeSubnetsReady,
-				SubnetUnreadyReasonIDInvalid,
-				fmt.Sprintf("vnetSubnetID does not match the cluster subscription, resource group, or virtual network: %s", subnetID),
-			)
-			return reconcile.Result{}, nil
+// selectSubnets returns the subnets selected by the term, with their IDs set
+func (r *SubnetReconciler) selectSubnets(ctx context.Context, term v1beta1.SubnetSelectorTerm) ([]*armnetwork.Subnet, error) {
+	if term.ID != nil {
+		components, err := utils.GetVnetSubnetIDComponents(*term.ID)
+		if err != nil {
+			return nil, &invalidSubnetError{err: err}
+		}
+		if err := validateSubnetVNET(ctx, *term.ID, components); err != nil {
+			return nil, &invalidSubnetError{err: err}
 		}
+		subnet, err := r.subnetCapacityProvider.Refresh(ctx, *term.ID)
+		if err != nil {
+			return nil, err
+		}
+		subnet.ID = term.ID
+		return []*armnetwork.Subnet{subnet}, nil
 	}
 
-	_, err = r.subnetClient.Get(ctx, nodeClassSubnetComponents.ResourceGroupName, nodeClassSubnetComponents.VNetName, nodeClassSubnetComponents.SubnetName, nil)
+	vnetID, err := arm.ParseResourceID(lo.FromPtr(term.VNETID))
 	if err != nil {
-		azErr := sdkerrors.IsResponseError(err)
-		if azErr != nil && (azErr.StatusCode == http.StatusNotFound) {
-			nodeClass.StatusConditions().SetFalse(
-				v1beta1.ConditionTypeSubnetsReady,
-				SubnetUnreadyReasonNotFound,
-				fmt.Sprintf("resource not found: %s", subnetID),
-			)
-			return reconcile.Result{RequeueAfter: time.Minute}, err
+		return nil, &invalidSubnetError{err: fmt.Errorf("failed to parse vnetID %s: %w", lo.FromPtr(term.VNETID), err)}
+	}
+	var subnets []*armnetwork.Subnet
+	observedAt := time.Now()
+	pager := r.subnetClient.NewListPager(vnetID.ResourceGroupName, vnetID.Name, nil)
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, subnet := range page.Value {
+			if subnet == nil || !matchesSubnetTags(subnet.Tags, term.Tags) {
+				continue
+			}
+			subnetID := utils.GetSubnetResourceID(vnetID.SubscriptionID, vnetID.ResourceGroupName, vnetID.Name, lo.FromPtr(subnet.Name))
+			components, err := utils.GetVnetSubnetIDComponents(subnetID)
+			if err != nil {
+				return nil, &invalidSubnetError{err: err}
+			}
+			if err := validateSubnetVNET(ctx, subnetID, components); err != nil {
+				return nil, &invalidSubnetError{err: err}
+			}
+			subnet.ID = lo.ToPtr(subnetID)
+			r.subnetCapacityProvider.Update(subnetID, subnet, observedAt)
+			subnets = append(subnets, subnet)
 		}
+	}
+	return subnets, nil
+}
+
+func (r *SubnetReconciler) handleSubnetError(ctx context.Context, nodeClass *v1beta1.AKSNodeClass, id string, err error) (reconcile.Result, error) {
+	azErr := sdkerrors.IsResponseError(err)
+	if azErr != nil && (azErr.StatusCode == http.StatusNotFound) {
 		nodeClass.StatusConditions().SetFalse(
 			v1beta1.ConditionTypeSubnetsReady,
-			SubnetUnreadyReasonUnknownError,
-			fmt.Sprintf("unknown error getting subnet: %s", err.Error()),
+			SubnetUnreadyReasonNotFound,
+			fmt.Sprintf("resource not found: %s", id),
 		)
-		logger.Error(err, "getting subnet failed during reconciliation with unknown error", "error", err.Error())
-		return reconcile.Result{}, err
+		return reconcile.Result{RequeueAfter: time.Minute}, err
 	}
+	nodeClass.StatusConditions().SetFalse(
+		v1beta1.ConditionTypeSubnetsReady,
+		SubnetUnreadyReasonUnknownError,
+		fmt.Sprintf("unknown error getting subnet: %s", err.Error()),
+	)
+	log.FromContext(ctx).WithName(subnetReconcilerName).Error(err, "getting subnet failed during reconciliation with unknown error", "subnetID", id, "error", err.Error())
+	return reconcile.Result{}, err
+}
 
-	nodeClass.StatusConditions().SetTrue(v1beta1.ConditionTypeSubnetsReady)
+// invalidSubnetError is a subnet that can't be used regardless of its state in Azure, which won't be fixed by retrying
+type invalidSubnetError struct {
+	err error
+}
 
-	// Periodically requeue just in case subnet has been removed or later revalidating things like fullness etc
-	return reconcile.Result{RequeueAfter: healthyRequeueInterval}, nil
+func (e *invalidSubnetError) Error() string {
+	return e.err.Error()
+}
+
+func (e *invalidSubnetError) Unwrap() error {
+	return e.err
+}
+
+// validateSubnetVNET returns an error if a subnet other than the cluster one is not in the VNet of the cluster,
+// or is in a managed VNet, where only the cluster subnet can be used.
+func validateSubnetVNET(ctx context.Context, subnetID string, subnetComponents utils.VnetSubnetResource) error {
+	clusterSubnetID := options.FromContext(ctx).SubnetID
+	if subnetID == clusterSubnetID {
+		return nil
+	}
+	clusterSubnetIDParts, err := utils.GetVnetSubnetIDComponents(clusterSubnetID) // Assume valid cluster subnet id
+	if err != nil {                                                               // Highly unlikely case but putting it in nonetheless
+		return fmt.Errorf("failed to parse cluster subnet ID %s: %w", clusterSubnetID, err)
+	}
+	isClusterManagedVNET, err := utils.IsAKSManagedVNET(options.FromContext(ctx).NodeResourceGroup, clusterSubnetID)
+	if err != nil {
+		return fmt.Errorf("failed to determine if cluster VNet is managed: %w", err)
+	}
+	if isClusterManagedVNET && clusterSubnetIDParts.IsSameVNET(subnetComponents) {
+		return fmt.Errorf("custom subnet cannot be in the same VNet as cluster managed VNet: %s", subnetID)
+	}
+	if !clusterSubnetIDParts.IsSameVNET(subnetComponents) {
+		return fmt.Errorf("subnet does not match the cluster subscription, resource group, or virtual network: %s", subnetID)
+	}
+	return nil
+}
+
+// matchesSubnetTags returns whether the subnet has all the selector tags, where a "*" value matches any value
+func matchesSubnetTags(subnetTags map[string]*string, selector map[string]string) bool {
+	for key, value := range selector {
+		subnetValue, ok := subnetTags[key]
+		if !ok || (value != "*" && lo.FromPtr(subnetValue) != value) {
+			return false
+		}
+	}
+	return true
 }
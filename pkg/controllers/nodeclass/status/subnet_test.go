@@ -27,7 +27,9 @@ import (
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork"
 	"github.com/Azure/karpenter-provider-azure/pkg/apis/v1beta1"
+	"github.com/Azure/karpenter-provider-azure/pkg/consts"
 	"github.com/Azure/karpenter-provider-azure/pkg/controllers/nodeclass/status"
+	"github.com/Azure/karpenter-provider-azure/pkg/metrics"
 	"github.com/Azure/karpenter-provider-azure/pkg/operator/options"
 	"github.com/Azure/karpenter-provider-azure/pkg/test"
 	opstatus "github.com/awslabs/operatorpkg/status"
@@ -108,7 +110,7 @@ var _ = Describe("SubnetStatus", func() {
 		var reconciler *status.SubnetReconciler
 
 		BeforeEach(func() {
-			reconciler = status.NewSubnetReconciler(azureEnv.SubnetsAPI)
+			reconciler = status.NewSubnetReconciler(azureEnv.SubnetsAPI, azureEnv.SubnetCapacityProvider)
 			nodeClass = test.AKSNodeClass()
 		})
 
@@ -132,6 +134,9 @@ var _ = Describe("SubnetStatus", func() {
 
 			cond := nodeClass.StatusConditions().Get(v1beta1.ConditionTypeSubnetsReady)
 			Expect(cond.IsTrue()).To(BeTrue())
+			Expect(nodeClass.Status.Subnets).To(Equal([]v1beta1.Subnet{
+				{ID: options.FromContext(ctx).SubnetID, AvailableIPAddressCount: lo.ToPtr[int64](65526)},
+			}))
 		})
 
 		It("should mark nodeclass as not ready when subnet doesn't exist", func() {
@@ -198,6 +203,237 @@ var _ = Describe("SubnetStatus", func() {
 			Expect(cond.Message).To(ContainSubstring("custom subnet cannot be in the same VNet as cluster managed VNet"))
 		})
 
+		Context("SubnetSelectorTerms", func() {
+			const vnetID = "/subscriptions/12345678-1234-1234-1234-123456789012/resourceGroups/test-resourceGroup/providers/Microsoft.Network/virtualNetworks/byo-vnet-customname"
+			var byoCtx context.Context
+
+			BeforeEach(func() {
+				byoCtx = options.ToContext(ctx, test.Options(test.OptionsFields{
+					SubnetID: lo.ToPtr(vnetID + "/subnets/cluster-subnet"),
+				}))
+				azureEnv.SubnetsAPI.Subnets.Store(vnetID+"/subnets/cluster-subnet", armnetwork.Subnet{
+					Properties: &armnetwork.SubnetPropertiesFormat{AddressPrefix: lo.ToPtr("10.0.0.0/24")},
+				})
+				azureEnv.SubnetsAPI.Subnets.Store(vnetID+"/subnets/nodes-1", armnetwork.Subnet{
+					Properties: &armnetwork.SubnetPropertiesFormat{
+						AddressPrefix:    lo.ToPtr("10.1.0.0/24"),
+						IPConfigurations: []*armnetwork.IPConfiguration{{}, {}, {}},
+					},
+					Tags: map[string]*string{"karpenter": lo.ToPtr("nodes")},
+				})
+				azureEnv.SubnetsAPI.Subnets.Store(vnetID+"/subnets/nodes-2", armnetwork.Subnet{
+					Properties: &armnetwork.SubnetPropertiesFormat{AddressPrefix: lo.ToPtr("10.2.0.0/28")},
+					Tags:       map[string]*string{"karpenter": lo.ToPtr("other")},
+				})
+			})
+
+			It("should resolve the subnets selected by ID and by tags into the status", func() {
+				nodeClass.Spec.SubnetSelectorTerms = []v1beta1.SubnetSelectorTerm{
+					{ID: lo.ToPtr(vnetID + "/subnets/cluster-subnet")},
+					{VNETID: lo.ToPtr(vnetID), Tags: map[string]string{"karpenter": "*"}, Zones: []string{"westus2-2"}},
+					{VNETID: lo.ToPtr(vnetID), Tags: map[string]string{"karpenter": "nodes"}, Zones: []string{"westus2-1"}},
+				}
+
+				result, err := reconciler.Reconcile(byoCtx, nodeClass)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result).To(Equal(reconcile.Result{RequeueAfter: time.Minute * 3}))
+
+				Expect(nodeClass.StatusConditions().Get(v1beta1.ConditionTypeSubnetsReady).IsTrue()).To(BeTrue())
+				Expect(nodeClass.Status.Subnets).To(Equal([]v1beta1.Subnet{
+					{ID: vnetID + "/subnets/cluster-subnet", AvailableIPAddressCount: lo.ToPtr[int64](251)},
+					{ID: vnetID + "/subnets/nodes-1", Zones: []string{"westus2-1", "westus2-2"}, AvailableIPAddressCount: lo.ToPtr[int64](248)},
+					{ID: vnetID + "/subnets/nodes-2", Zones: []string{"westus2-2"}, AvailableIPAddressCount: lo.ToPtr[int64](11)},
+				}))
+			})
+
+			It("should mark nodeclass as not ready when no subnets match the terms", func() {
+				nodeClass.Spec.SubnetSelectorTerms = []v1beta1.SubnetSelectorTerm{
+					{VNETID: lo.ToPtr(vnetID), Tags: map[string]string{"karpenter": "missing"}},
+				}
+
+				result, err := reconciler.Reconcile(byoCtx, nodeClass)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result).To(Equal(reconcile.Result{RequeueAfter: time.Minute}))
+
+				cond := nodeClass.StatusConditions().Get(v1beta1.ConditionTypeSubnetsReady)
+				Expect(cond.IsFalse()).To(BeTrue())
+				Expect(cond.Reason).To(Equal(status.SubnetUnreadyReasonNotFound))
+			})
+
+			It("should mark nodeclass as not ready when a selected subnet is not found", func() {
+				nodeClass.Spec.SubnetSelectorTerms = []v1beta1.SubnetSelectorTerm{
+					{ID: lo.ToPtr(vnetID + "/subnets/nodes-1")},
+					{ID: lo.ToPtr(vnetID + "/subnets/missing")},
+				}
+
+				result, err := reconciler.Reconcile(byoCtx, nodeClass)
+				Expect(err).To(HaveOccurred())
+				Expect(result).To(Equal(reconcile.Result{RequeueAfter: time.Minute}))
+
+				cond := nodeClass.StatusConditions().Get(v1beta1.ConditionTypeSubnetsReady)
+				Expect(cond.IsFalse()).To(BeTrue())
+				Expect(cond.Reason).To(Equal(status.SubnetUnreadyReasonNotFound))
+				Expect(cond.Message).To(ContainSubstring("missing"))
+			})
+
+			It("should mark nodeclass as not ready when a selected subnet is not in the cluster VNet", func() {
+				nodeClass.Spec.SubnetSelectorTerms = []v1beta1.SubnetSelectorTerm{
+					{ID: lo.ToPtr("/subscriptions/12345678-1234-1234-1234-123456789012/resourceGroups/test-resourceGroup/providers/Microsoft.Network/virtualNetworks/other-vnet/subnets/nodes")},
+				}
+
+				result, err := reconciler.Reconcile(byoCtx, nodeClass)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result).To(Equal(reconcile.Result{}))
+
+				cond := nodeClass.StatusConditions().Get(v1beta1.ConditionTypeSubnetsReady)
+				Expect(cond.IsFalse()).To(BeTrue())
+				Expect(cond.Reason).To(Equal(status.SubnetUnreadyReasonIDInvalid))
+				Expect(cond.Message).To(ContainSubstring("does not match the cluster subscription, resource group, or virtual network"))
+			})
+		})
+
+		Context("SubnetCapacity", func() {
+			// Azure CNI without overlay, where a node needs MaxPods+1 free IPs
+			var podIPsCtx context.Context
+
+			BeforeEach(func() {
+				podIPsCtx = options.ToContext(ctx, test.Options(test.OptionsFields{
+					NetworkPluginMode: lo.ToPtr(consts.NetworkPluginModeNone),
+				}))
+				nodeClass.Spec.MaxPods = lo.ToPtr[int32](30)
+			})
+
+			It("should mark the subnet capacity available and publish the free IPs of the subnet", func() {
+				azureEnv.SubnetsAPI.GetFunc = func(ctx context.Context, resourceGroupName string, virtualNetworkName string, subnetName string, options *armnetwork.SubnetsClientGetOptions) (armnetwork.SubnetsClientGetResponse, error) {
+					return armnetwork.SubnetsClientGetResponse{
+						Subnet: armnetwork.Subnet{Properties: &armnetwork.SubnetPropertiesFormat{AddressPrefix: lo.ToPtr("10.0.0.0/26")}},
+					}, nil
+				}
+
+				_, err := reconciler.Reconcile(podIPsCtx, nodeClass)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(nodeClass.StatusConditions().Get(v1beta1.ConditionTypeSubnetCapacityAvailable).IsTrue()).To(BeTrue())
+				metric, err := metrics.FindMetricWithLabelValues("karpenter_subnet_available_ip_addresses", map[string]string{
+					metrics.NodeClassLabel: nodeClass.Name,
+					metrics.SubnetLabel:    options.FromContext(ctx).SubnetID,
+				})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(metric).ToNot(BeNil())
+				Expect(metric.GetGauge().GetValue()).To(BeNumerically("==", 59))
+			})
+
+			It("should mark the subnet capacity exhausted when the subnet can't fit a node", func() {
+				azureEnv.SubnetsAPI.GetFunc = func(ctx context.Context, resourceGroupName string, virtualNetworkName string, subnetName string, options *armnetwork.SubnetsClientGetOptions) (armnetwork.SubnetsClientGetResponse, error) {
+					return armnetwork.SubnetsClientGetResponse{
+						Subnet: armnetwork.Subnet{Properties: &armnetwork.SubnetPropertiesFormat{AddressPrefix: lo.ToPtr("10.0.0.0/27")}},
+					}, nil
+				}
+
+				result, err := reconciler.Reconcile(podIPsCtx, nodeClass)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result).To(Equal(reconcile.Result{RequeueAfter: time.Minute * 3}))
+
+				// the subnet exists, the AKSNodeClass stays ready
+				Expect(nodeClass.StatusConditions().Get(v1beta1.ConditionTypeSubnetsReady).IsTrue()).To(BeTrue())
+				cond := nodeClass.StatusConditions().Get(v1beta1.ConditionTypeSubnetCapacityAvailable)
+				Expect(cond.IsFalse()).To(BeTrue())
+				Expect(cond.Reason).To(Equal(status.SubnetCapacityUnavailableReasonExhausted))
+				Expect(cond.Message).To(ContainSubstring("no subnet has the 31 free IP addresses a node needs"))
+				Expect(cond.Message).To(ContainSubstring("(27 available)"))
+			})
+
+			It("should only need the IP of the node with overlay", func() {
+				azureEnv.SubnetsAPI.GetFunc = func(ctx context.Context, resourceGroupName string, virtualNetworkName string, subnetName string, options *armnetwork.SubnetsClientGetOptions) (armnetwork.SubnetsClientGetResponse, error) {
+					return armnetwork.SubnetsClientGetResponse{
+						Subnet: armnetwork.Subnet{Properties: &armnetwork.SubnetPropertiesFormat{AddressPrefix: lo.ToPtr("10.0.0.0/29")}},
+					}, nil
+				}
+
+				_, err := reconciler.Reconcile(ctx, nodeClass)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(nodeClass.StatusConditions().Get(v1beta1.ConditionTypeSubnetCapacityAvailable).IsTrue()).To(BeTrue())
+			})
+		})
+
+		Context("PodSubnetID", func() {
+			const vnetID = "/subscriptions/12345678-1234-1234-1234-123456789012/resourceGroups/test-resourceGroup/providers/Microsoft.Network/virtualNetworks/byo-vnet-customname"
+			var podSubnetCtx context.Context
+
+			BeforeEach(func() {
+				// Azure CNI without overlay, on a BYO VNet
+				podSubnetCtx = options.ToContext(ctx, test.Options(test.OptionsFields{
+					SubnetID:          lo.ToPtr(vnetID + "/subnets/cluster-subnet"),
+					NetworkPluginMode: lo.ToPtr(consts.NetworkPluginModeNone),
+				}))
+				azureEnv.SubnetsAPI.Subnets.Store(vnetID+"/subnets/cluster-subnet", armnetwork.Subnet{
+					Properties: &armnetwork.SubnetPropertiesFormat{AddressPrefix: lo.ToPtr("10.0.0.0/27")},
+				})
+				azureEnv.SubnetsAPI.Subnets.Store(vnetID+"/subnets/pods", armnetwork.Subnet{
+					Properties: &armnetwork.SubnetPropertiesFormat{AddressPrefix: lo.ToPtr("10.1.0.0/16")},
+				})
+				nodeClass.Spec.MaxPods = lo.ToPtr[int32](30)
+			})
+
+			It("should mark nodeclass as ready when the pod subnet exists, and only need the IP of the node in the node subnet", func() {
+				nodeClass.Spec.PodSubnetID = lo.ToPtr(vnetID + "/subnets/pods")
+
+				result, err := reconciler.Reconcile(podSubnetCtx, nodeClass)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result).To(Equal(reconcile.Result{RequeueAfter: time.Minute * 3}))
+
+				Expect(nodeClass.StatusConditions().Get(v1beta1.ConditionTypeSubnetsReady).IsTrue()).To(BeTrue())
+				// 27 free IPs would not fit the 30 pods of a node without the pod subnet
+				Expect(nodeClass.StatusConditions().Get(v1beta1.ConditionTypeSubnetCapacityAvailable).IsTrue()).To(BeTrue())
+				Expect(nodeClass.Status.Subnets).To(Equal([]v1beta1.Subnet{
+					{ID: vnetID + "/subnets/cluster-subnet", AvailableIPAddressCount: lo.ToPtr[int64](27)},
+				}))
+			})
+
+			It("should mark nodeclass as not ready when the pod subnet is not found", func() {
+				nodeClass.Spec.PodSubnetID = lo.ToPtr(vnetID + "/subnets/missing")
+
+				result, err := reconciler.Reconcile(podSubnetCtx, nodeClass)
+				Expect(err).To(HaveOccurred())
+				Expect(result).To(Equal(reconcile.Result{RequeueAfter: time.Minute}))
+
+				cond := nodeClass.StatusConditions().Get(v1beta1.ConditionTypeSubnetsReady)
+				Expect(cond.IsFalse()).To(BeTrue())
+				Expect(cond.Reason).To(Equal(status.SubnetUnreadyReasonNotFound))
+				Expect(cond.Message).To(ContainSubstring("missing"))
+			})
+
+			It("should mark nodeclass as not ready when the pod subnet is not in the cluster VNet", func() {
+				nodeClass.Spec.PodSubnetID = lo.ToPtr("/subscriptions/12345678-1234-1234-1234-123456789012/resourceGroups/test-resourceGroup/providers/Microsoft.Network/virtualNetworks/other-vnet/subnets/pods")
+
+				result, err := reconciler.Reconcile(podSubnetCtx, nodeClass)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result).To(Equal(reconcile.Result{}))
+
+				cond := nodeClass.StatusConditions().Get(v1beta1.ConditionTypeSubnetsReady)
+				Expect(cond.IsFalse()).To(BeTrue())
+				Expect(cond.Reason).To(Equal(status.SubnetUnreadyReasonIDInvalid))
+				Expect(cond.Message).To(ContainSubstring("does not match the cluster subscription, resource group, or virtual network"))
+			})
+
+			It("should mark nodeclass as not ready when the cluster uses Azure CNI overlay", func() {
+				nodeClass.Spec.PodSubnetID = lo.ToPtr(vnetID + "/subnets/pods")
+				overlayCtx := options.ToContext(ctx, test.Options(test.OptionsFields{
+					SubnetID: lo.ToPtr(vnetID + "/subnets/cluster-subnet"),
+				}))
+
+				result, err := reconciler.Reconcile(overlayCtx, nodeClass)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result).To(Equal(reconcile.Result{}))
+
+				cond := nodeClass.StatusConditions().Get(v1beta1.ConditionTypeSubnetsReady)
+				Expect(cond.IsFalse()).To(BeTrue())
+				Expect(cond.Reason).To(Equal(status.SubnetUnreadyReasonIDInvalid))
+				Expect(cond.Message).To(ContainSubstring("only supported with Azure CNI without overlay"))
+			})
+		})
+
 		It("should mark nodeclass as not ready when subnet hits unknown error", func() {
 			const errString = "An unexpected internal server error occurred while processing the request. The service encountered an unrecoverable condition and was unable to complete the operation. Please retry the request after some time. If the problem persists, contact Azure support with the correlation ID and timestamp for further investigation."
 			azureEnv.SubnetsAPI.GetFunc = func(ctx context.Context, resourceGroupName string, virtualNetworkName string, subnetName string, options *armnetwork.SubnetsClientGetOptions) (armnetwork.SubnetsClientGetResponse, error) {
@@ -19,11 +19,13 @@ package status
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	sdkerrors "github.com/Azure/azure-sdk-for-go-extensions/pkg/errors"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
 	"github.com/Azure/karpenter-provider-azure/pkg/apis/v1beta1"
+	"github.com/Azure/karpenter-provider-azure/pkg/operator/options"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/azclient/azapi"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -31,6 +33,8 @@ import (
 
 const (
 	DiskEncryptionSetRBACMissing = "DiskEncryptionSetRBACMissing"
+	// UnsupportedByAKSMachineAPI is the reason used when the AKSNodeClass sets fields that can't be honored in an AKS machine API provision mode
+	UnsupportedByAKSMachineAPI = "UnsupportedByAKSMachineAPI"
 	// TODO: May want to rethink how we handle successful validation + potential for RBAC removal.
 	// See this PR comment for considerations:
 	// https://github.com/Azure/karpenter-provider-azure/pull/1372#discussion_r2795367386
@@ -62,6 +66,18 @@ func NewValidationReconciler(
 func (r *ValidationReconciler) Reconcile(ctx context.Context, nodeClass *v1beta1.AKSNodeClass) (reconcile.Result, error) {
 	logger := log.FromContext(ctx)
 
+	// Reject fields the AKS machine API can't honor, rather than failing every launch
+	if opts := options.FromContext(ctx); opts != nil && opts.IsAKSMachineAPIMode() {
+		if fields := unsupportedByAKSMachineAPI(nodeClass); len(fields) > 0 {
+			nodeClass.StatusConditions().SetFalse(
+				v1beta1.ConditionTypeValidationSucceeded,
+				UnsupportedByAKSMachineAPI,
+				fmt.Sprintf("%s not supported with the AKS machine API, remove them or use another provision mode", strings.Join(fields, ", ")),
+			)
+			return reconcile.Result{RequeueAfter: ValidationSuccessRequeueInterval}, nil
+		}
+	}
+
 	// Check BYOK RBAC if DES ID is configured
 	if r.parsedDiskEncryptionSetID != nil {
 		logger.V(1).Info("validating Disk Encryption Set RBAC")
@@ -88,6 +104,24 @@ func (r *ValidationReconciler) Reconcile(ctx context.Context, nodeClass *v1beta1
 	return reconcile.Result{RequeueAfter: ValidationSuccessRequeueInterval}, nil
 }
 
+// unsupportedByAKSMachineAPI returns the AKSNodeClass fields that are set, but can't be passed to the AKS machine API
+func unsupportedByAKSMachineAPI(nodeClass *v1beta1.AKSNodeClass) []string {
+	var fields []string
+	if len(nodeClass.Spec.DataDisks) > 0 {
+		fields = append(fields, "spec.dataDisks")
+	}
+	if nodeClass.GetSpotMaxPrice() != "" {
+		fields = append(fields, "spec.spot.maxPrice")
+	}
+	if nodeClass.GetSpotMaxPricePercentOfOnDemand() != 0 {
+		fields = append(fields, "spec.spot.maxPricePercentOfOnDemand")
+	}
+	if nodeClass.GetSpotEvictionPolicy() == v1beta1.SpotEvictionPolicyDeallocate {
+		fields = append(fields, "spec.spot.evictionPolicy")
+	}
+	return fields
+}
+
 func (r *ValidationReconciler) validateDiskEncryptionSetRBAC(ctx context.Context) error {
 	// Attempt to read the DiskEncryptionSet
 	// This uses the controller's current credentials (DefaultAzureCredential)
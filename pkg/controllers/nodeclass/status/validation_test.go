@@ -25,8 +25,11 @@ import (
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v7"
 	"github.com/Azure/karpenter-provider-azure/pkg/apis/v1beta1"
+	"github.com/Azure/karpenter-provider-azure/pkg/consts"
 	"github.com/Azure/karpenter-provider-azure/pkg/controllers/nodeclass/status"
 	"github.com/Azure/karpenter-provider-azure/pkg/fake"
+	"github.com/Azure/karpenter-provider-azure/pkg/operator/options"
+	"github.com/Azure/karpenter-provider-azure/pkg/test"
 	"github.com/samber/lo"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
@@ -235,4 +238,59 @@ var _ = Describe("Validation Reconciler", func() {
 			Expect(condition.IsTrue()).To(BeTrue())
 		})
 	})
+
+	Context("AKS machine API provision mode", func() {
+		var machineCtx context.Context
+
+		BeforeEach(func() {
+			machineCtx = options.ToContext(ctx, test.Options(test.OptionsFields{ProvisionMode: lo.ToPtr(consts.ProvisionModeAKSMachineAPI)}))
+		})
+
+		It("should set ValidationSucceeded to false when data disks are set", func() {
+			nodeClass.Spec.DataDisks = []v1beta1.DataDisk{{SizeGB: 128, MountPath: "/data"}}
+
+			result, err := reconciler.Reconcile(machineCtx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(status.ValidationSuccessRequeueInterval))
+
+			condition := nodeClass.StatusConditions().Get(v1beta1.ConditionTypeValidationSucceeded)
+			Expect(condition.IsFalse()).To(BeTrue())
+			Expect(condition.Reason).To(Equal(status.UnsupportedByAKSMachineAPI))
+			Expect(condition.Message).To(ContainSubstring("spec.dataDisks"))
+		})
+		It("should set ValidationSucceeded to false when spot max prices are set", func() {
+			nodeClass.Spec.Spot = &v1beta1.Spot{MaxPrice: lo.ToPtr("0.5"), MaxPricePercentOfOnDemand: lo.ToPtr[int32](50)}
+
+			_, err := reconciler.Reconcile(machineCtx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+
+			condition := nodeClass.StatusConditions().Get(v1beta1.ConditionTypeValidationSucceeded)
+			Expect(condition.IsFalse()).To(BeTrue())
+			Expect(condition.Reason).To(Equal(status.UnsupportedByAKSMachineAPI))
+			Expect(condition.Message).To(ContainSubstring("spec.spot.maxPrice"))
+			Expect(condition.Message).To(ContainSubstring("spec.spot.maxPricePercentOfOnDemand"))
+		})
+		It("should set ValidationSucceeded to false when the Deallocate spot eviction policy is set", func() {
+			nodeClass.Spec.Spot = &v1beta1.Spot{EvictionPolicy: lo.ToPtr(v1beta1.SpotEvictionPolicyDeallocate)}
+
+			_, err := reconciler.Reconcile(machineCtx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+
+			condition := nodeClass.StatusConditions().Get(v1beta1.ConditionTypeValidationSucceeded)
+			Expect(condition.IsFalse()).To(BeTrue())
+			Expect(condition.Message).To(ContainSubstring("spec.spot.evictionPolicy"))
+		})
+		It("should accept data disks in other provision modes", func() {
+			nodeClass.Spec.DataDisks = []v1beta1.DataDisk{{SizeGB: 128, MountPath: "/data"}}
+
+			_, err := reconciler.Reconcile(options.ToContext(ctx, test.Options()), nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(nodeClass.StatusConditions().Get(v1beta1.ConditionTypeValidationSucceeded).IsTrue()).To(BeTrue())
+		})
+		It("should set ValidationSucceeded to true when only supported fields are set", func() {
+			_, err := reconciler.Reconcile(machineCtx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(nodeClass.StatusConditions().Get(v1beta1.ConditionTypeValidationSucceeded).IsTrue()).To(BeTrue())
+		})
+	})
 })
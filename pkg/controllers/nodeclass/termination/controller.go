@@ -25,6 +25,9 @@ import (
 	"sigs.k8s.io/karpenter/pkg/operator/injection"
 
 	"github.com/Azure/karpenter-provider-azure/pkg/apis/v1beta1"
+	"github.com/Azure/karpenter-provider-azure/pkg/metrics"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/subnetcapacity"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/samber/lo"
 	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/types"
@@ -80,6 +83,7 @@ func (c *Controller) finalize(ctx context.Context, nodeClass *v1beta1.AKSNodeCla
 	}
 
 	// any other processing before removing NodeClass goes here
+	subnetcapacity.AvailableIPAddresses.DeletePartialMatch(prometheus.Labels{metrics.NodeClassLabel: nodeClass.Name})
 
 	controllerutil.RemoveFinalizer(nodeClass, v1beta1.TerminationFinalizer)
 	if !equality.Semantic.DeepEqual(stored, nodeClass) {
@@ -0,0 +1,109 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pricingoverrides
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/awslabs/operatorpkg/reconciler"
+	"github.com/awslabs/operatorpkg/singleton"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/pricing"
+)
+
+const (
+	// ConfigMapDataKey is the key of the ConfigMap data holding the JSON serialized pricing overrides
+	ConfigMapDataKey = "pricingOverrides"
+	// RefreshInterval is how often the pricing overrides are read from the ConfigMap
+	RefreshInterval = time.Minute
+)
+
+// Controller reads the pricing overrides from a ConfigMap in the system namespace, and applies them to the retail prices
+// of the pricing provider. A missing ConfigMap removes the overrides, while invalid overrides are ignored in favor of the
+// ones last read.
+type Controller struct {
+	inClusterKubernetesInterface kubernetes.Interface
+	pricingProvider              *pricing.Provider
+	configMapName                string
+	systemNamespace              string
+}
+
+func NewController(inClusterKubernetesInterface kubernetes.Interface, pricingProvider *pricing.Provider, configMapName string) *Controller {
+	return &Controller{
+		inClusterKubernetesInterface: inClusterKubernetesInterface,
+		pricingProvider:              pricingProvider,
+		configMapName:                configMapName,
+		systemNamespace:              strings.TrimSpace(os.Getenv("SYSTEM_NAMESPACE")),
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context) (reconciler.Result, error) {
+	ctx = injection.WithControllerName(ctx, "pricing.overrides")
+
+	if c.systemNamespace == "" {
+		log.FromContext(ctx).V(1).Info("SYSTEM_NAMESPACE is not set, not reading pricing overrides")
+		return reconciler.Result{}, nil
+	}
+	overrides, err := c.read(ctx)
+	if err != nil {
+		return reconciler.Result{}, err
+	}
+	if c.pricingProvider.SetOverrides(overrides) {
+		log.FromContext(ctx).Info("updated pricing overrides", "ConfigMap", c.configMapName, "found", overrides != nil)
+	}
+	return reconciler.Result{RequeueAfter: RefreshInterval}, nil
+}
+
+// read returns the overrides of the ConfigMap, nil if there are none, and the current ones if they can't be parsed
+func (c *Controller) read(ctx context.Context) (*pricing.Overrides, error) {
+	configMap, err := c.inClusterKubernetesInterface.CoreV1().ConfigMaps(c.systemNamespace).Get(ctx, c.configMapName, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("getting ConfigMap %s/%s, %w", c.systemNamespace, c.configMapName, err)
+	}
+	data, ok := configMap.Data[ConfigMapDataKey]
+	if !ok {
+		return nil, nil
+	}
+	overrides, err := pricing.ParseOverrides([]byte(data))
+	if err != nil {
+		// Keep pricing with the overrides last read rather than falling back to retail prices on a typo
+		log.FromContext(ctx).Error(err, "ignoring invalid pricing overrides", "ConfigMap", c.configMapName)
+		return c.pricingProvider.Overrides(), nil
+	}
+	return overrides, nil
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("pricing.overrides").
+		WatchesRawSource(singleton.Source()).
+		Complete(singleton.AsReconciler(c))
+}
@@ -0,0 +1,144 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pricingoverrides_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/Azure/karpenter-provider-azure/pkg/auth"
+	"github.com/Azure/karpenter-provider-azure/pkg/controllers/pricingoverrides"
+	"github.com/Azure/karpenter-provider-azure/pkg/fake"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/pricing"
+)
+
+const (
+	systemNamespace = "karpenter"
+	configMapName   = "karpenter-pricing-overrides"
+)
+
+var ctx context.Context
+
+func TestController(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "PricingOverridesController")
+}
+
+var _ = Describe("PricingOverrides Controller", func() {
+	var kubernetesInterface *kubernetesfake.Clientset
+	var pricingProvider *pricing.Provider
+	var controller *pricingoverrides.Controller
+
+	BeforeEach(func() {
+		os.Setenv("SYSTEM_NAMESPACE", systemNamespace)
+		DeferCleanup(os.Unsetenv, "SYSTEM_NAMESPACE")
+		kubernetesInterface = kubernetesfake.NewClientset()
+		// the static pricing data is not updated outside of the public cloud
+		pricingProvider = pricing.NewProvider(ctx, &auth.Environment{Cloud: cloud.AzureGovernment}, &fake.PricingAPI{}, "eastus", make(chan struct{}))
+		controller = pricingoverrides.NewController(kubernetesInterface, pricingProvider, configMapName)
+	})
+
+	applyConfigMap := func(data string) {
+		GinkgoHelper()
+		configMap := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: configMapName, Namespace: systemNamespace},
+			Data:       map[string]string{pricingoverrides.ConfigMapDataKey: data},
+		}
+		configMaps := kubernetesInterface.CoreV1().ConfigMaps(systemNamespace)
+		if _, err := configMaps.Get(ctx, configMapName, metav1.GetOptions{}); err == nil {
+			_, err = configMaps.Update(ctx, configMap, metav1.UpdateOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			return
+		}
+		_, err := configMaps.Create(ctx, configMap, metav1.CreateOptions{})
+		Expect(err).ToNot(HaveOccurred())
+	}
+	expectOnDemandPrice := func(instanceType string, discount float64) {
+		GinkgoHelper()
+		retailPrice, ok := pricingProvider.RetailOnDemandPrice(instanceType)
+		Expect(ok).To(BeTrue())
+		price, ok := pricingProvider.OnDemandPrice(instanceType)
+		Expect(ok).To(BeTrue())
+		Expect(price).To(BeNumerically("~", retailPrice*(1-discount), 1e-9))
+	}
+
+	It("should return a requeue interval of 1 minute", func() {
+		result := ExpectSingletonReconciled(ctx, controller)
+		Expect(result.RequeueAfter).To(Equal(pricingoverrides.RefreshInterval))
+	})
+	It("should apply the overrides of the ConfigMap", func() {
+		applyConfigMap(`{"default": {"onDemandDiscountPercent": 10}, "skus": {"Standard_D2s_v3": {"onDemandDiscountPercent": 30}}}`)
+		ExpectSingletonReconciled(ctx, controller)
+		expectOnDemandPrice("Standard_D4s_v3", 0.1)
+		expectOnDemandPrice("Standard_D2s_v3", 0.3)
+	})
+	It("should update the overrides when the ConfigMap changes", func() {
+		applyConfigMap(`{"default": {"onDemandDiscountPercent": 10}}`)
+		ExpectSingletonReconciled(ctx, controller)
+		seqNum := pricingProvider.SeqNum()
+
+		// unchanged
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(pricingProvider.SeqNum()).To(Equal(seqNum))
+
+		applyConfigMap(`{"default": {"onDemandDiscountPercent": 20}}`)
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(pricingProvider.SeqNum()).ToNot(Equal(seqNum))
+		expectOnDemandPrice("Standard_D2s_v3", 0.2)
+	})
+	It("should remove the overrides when the ConfigMap is deleted", func() {
+		applyConfigMap(`{"default": {"onDemandDiscountPercent": 10}}`)
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(kubernetesInterface.CoreV1().ConfigMaps(systemNamespace).Delete(ctx, configMapName, metav1.DeleteOptions{})).To(Succeed())
+
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(pricingProvider.Overrides()).To(BeNil())
+		expectOnDemandPrice("Standard_D2s_v3", 0)
+	})
+	It("should keep the last overrides when the ConfigMap holds invalid ones", func() {
+		applyConfigMap(`{"default": {"onDemandDiscountPercent": 10}}`)
+		ExpectSingletonReconciled(ctx, controller)
+
+		applyConfigMap(`{"default": {"onDemandDiscountPercent": 200}}`)
+		ExpectSingletonReconciled(ctx, controller)
+		expectOnDemandPrice("Standard_D2s_v3", 0.1)
+
+		applyConfigMap(`not json`)
+		ExpectSingletonReconciled(ctx, controller)
+		expectOnDemandPrice("Standard_D2s_v3", 0.1)
+	})
+	It("should not read the overrides without a system namespace", func() {
+		os.Unsetenv("SYSTEM_NAMESPACE")
+		controller = pricingoverrides.NewController(kubernetesInterface, pricingProvider, configMapName)
+		applyConfigMap(`{"default": {"onDemandDiscountPercent": 10}}`)
+
+		result := ExpectSingletonReconciled(ctx, controller)
+		Expect(result.RequeueAfter).To(BeZero())
+		Expect(pricingProvider.Overrides()).To(BeNil())
+	})
+})
@@ -0,0 +1,55 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package quota
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/events"
+)
+
+func QuotaIncreaseRequestedEvent(nodePool *karpv1.NodePool, family string, limit int64) events.Event {
+	return events.Event{
+		InvolvedObject: nodePool,
+		Type:           corev1.EventTypeNormal,
+		Reason:         "QuotaIncreaseRequested",
+		Message:        fmt.Sprintf("Requested quota increase for %s to %d vCPUs", family, limit),
+		DedupeValues:   []string{string(nodePool.UID), family, fmt.Sprint(limit)},
+	}
+}
+
+func QuotaIncreaseSucceededEvent(nodePool *karpv1.NodePool, family string, limit int64) events.Event {
+	return events.Event{
+		InvolvedObject: nodePool,
+		Type:           corev1.EventTypeNormal,
+		Reason:         "QuotaIncreaseSucceeded",
+		Message:        fmt.Sprintf("Increased quota for %s to %d vCPUs", family, limit),
+		DedupeValues:   []string{string(nodePool.UID), family, fmt.Sprint(limit)},
+	}
+}
+
+func QuotaIncreaseFailedEvent(nodePool *karpv1.NodePool, family string, limit int64, reason string) events.Event {
+	return events.Event{
+		InvolvedObject: nodePool,
+		Type:           corev1.EventTypeWarning,
+		Reason:         "QuotaIncreaseFailed",
+		Message:        fmt.Sprintf("Failed to increase quota for %s to %d vCPUs: %s", family, limit, reason),
+		DedupeValues:   []string{string(nodePool.UID), family, fmt.Sprint(limit)},
+	}
+}
@@ -0,0 +1,359 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package quota
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/awslabs/operatorpkg/reconciler"
+	"github.com/awslabs/operatorpkg/singleton"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/clock"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/events"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v7"
+	azurecache "github.com/Azure/karpenter-provider-azure/pkg/cache"
+	"github.com/Azure/karpenter-provider-azure/pkg/operator/options"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/instance/offerings"
+	instancetypeprovider "github.com/Azure/karpenter-provider-azure/pkg/providers/instancetype"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/quota"
+	"github.com/Azure/karpenter-provider-azure/pkg/utils/configmapstore"
+)
+
+const (
+	IncreaseInterval = 5 * time.Minute
+	// NearLimitThreshold is the fraction of its quota limit a VM family has to use to be considered near its limit
+	NearLimitThreshold = 0.9
+	// NearLimitDuration is how long a VM family has to stay near its limit before an increase is requested.
+	// Recent quota failures for the family count as having stayed near the limit.
+	NearLimitDuration = 30 * time.Minute
+	// MinIncrease is the smallest increase, in vCPUs, that is requested. Otherwise, the limit is doubled.
+	MinIncrease = 16
+	// FailedRequestBackoff is how long to wait before requesting another increase for a VM family, after a request failed
+	FailedRequestBackoff = 24 * time.Hour
+	// ConfigMapName is the name of the ConfigMap, in the system namespace, that quota increases are persisted to
+	ConfigMapName = "karpenter-quota-increases"
+	// ConfigMapDataKey is the key of the ConfigMap data holding the serialized quota increases
+	ConfigMapDataKey = "quotaIncreases"
+)
+
+type increaseRequest struct {
+	quota.Request
+	Limit int64 `json:"limit"`
+}
+
+// increase is a quota increase that succeeded
+type increase struct {
+	Limit       int64     `json:"limit"`
+	SucceededAt time.Time `json:"succeededAt"`
+}
+
+// increaseState is the state of the IncreaseController that is persisted, so that in-flight requests are not submitted
+// again and backoffs are honored across controller restarts and leader failovers
+type increaseState struct {
+	Requests     map[string]increaseRequest `json:"requests,omitempty"`
+	BackoffUntil map[string]time.Time       `json:"backoffUntil,omitempty"`
+	Increases    map[string]increase        `json:"increases,omitempty"`
+}
+
+// IncreaseController requests quota increases, through the Microsoft.Quota API, for VM families that stay near their
+// quota limit, up to the limit configured with --quota-increase-max-limit. Requests are tracked until they complete,
+// and their progress is surfaced as events on the NodePools using the VM family. The in-flight requests and backoffs
+// are persisted to their own ConfigMap, in the system namespace.
+type IncreaseController struct {
+	kubeClient           client.Client
+	recorder             events.Recorder
+	clock                clock.PassiveClock
+	quotaProvider        quota.Provider
+	requestsAPI          quota.RequestsAPI
+	instanceTypeProvider instancetypeprovider.Provider
+	unavailableOfferings *azurecache.UnavailableOfferings
+	systemNamespace      string
+	store                *configmapstore.Store
+
+	// when each VM family was first observed near its limit
+	nearLimitSince map[string]time.Time
+	// in-flight quota increase requests, by VM family
+	requests map[string]increaseRequest
+	// when increases can be requested again for VM families whose last request failed
+	backoffUntil map[string]time.Time
+	// the last quota increase that succeeded, by VM family
+	increases map[string]increase
+
+	restored bool
+}
+
+func NewIncreaseController(
+	kubeClient client.Client,
+	inClusterKubernetesInterface kubernetes.Interface,
+	recorder events.Recorder,
+	clk clock.PassiveClock,
+	quotaProvider quota.Provider,
+	requestsAPI quota.RequestsAPI,
+	instanceTypeProvider instancetypeprovider.Provider,
+	unavailableOfferings *azurecache.UnavailableOfferings,
+) *IncreaseController {
+	systemNamespace := strings.TrimSpace(os.Getenv("SYSTEM_NAMESPACE"))
+	return &IncreaseController{
+		kubeClient:           kubeClient,
+		recorder:             recorder,
+		clock:                clk,
+		quotaProvider:        quotaProvider,
+		requestsAPI:          requestsAPI,
+		instanceTypeProvider: instanceTypeProvider,
+		unavailableOfferings: unavailableOfferings,
+		systemNamespace:      systemNamespace,
+		store:                configmapstore.New(inClusterKubernetesInterface, systemNamespace, ConfigMapName, ConfigMapDataKey),
+		nearLimitSince:       map[string]time.Time{},
+		requests:             map[string]increaseRequest{},
+		backoffUntil:         map[string]time.Time{},
+		increases:            map[string]increase{},
+	}
+}
+
+func (c *IncreaseController) Reconcile(ctx context.Context) (reconciler.Result, error) {
+	ctx = injection.WithControllerName(ctx, "quota.increase")
+
+	// Restore before tracking requests, so that in-flight requests are not submitted again
+	if c.systemNamespace != "" && !c.restored {
+		if err := c.restore(ctx); err != nil {
+			return reconciler.Result{}, err
+		}
+		c.restored = true
+	}
+
+	nodePools, err := c.nodePoolsByFamily(ctx)
+	if err != nil {
+		return reconciler.Result{}, err
+	}
+
+	c.trackRequests(ctx, nodePools)
+
+	quotaFailures := c.familiesWithQuotaFailures(ctx)
+	for _, usage := range c.quotaProvider.ListFamilyUsages() {
+		if usage.Name == nil || usage.Name.Value == nil || usage.Limit == nil || usage.CurrentValue == nil {
+			continue
+		}
+		family := *usage.Name.Value
+		// the usage data is refreshed separately, don't act on it until it reflects the last increase
+		if *usage.Limit < c.increases[family].Limit {
+			continue
+		}
+		// quota failures recorded before the last increase are not caused by the current limit
+		lastFailure, ok := quotaFailures[family]
+		quotaFailure := ok && lastFailure.After(c.increases[family].SucceededAt)
+		if !c.stayedNearLimit(family, usage, quotaFailure) {
+			continue
+		}
+		if _, ok := c.requests[family]; ok || c.clock.Now().Before(c.backoffUntil[family]) {
+			continue
+		}
+		c.requestIncrease(ctx, nodePools[family], family, *usage.Limit)
+	}
+
+	if c.systemNamespace != "" {
+		if err := c.persist(ctx); err != nil {
+			return reconciler.Result{}, err
+		}
+	}
+	return reconciler.Result{RequeueAfter: IncreaseInterval}, nil
+}
+
+// stayedNearLimit returns true if the VM family has been near its quota limit for at least NearLimitDuration,
+// or if creates recently failed because of its quota
+func (c *IncreaseController) stayedNearLimit(family string, usage *armcompute.Usage, quotaFailure bool) bool {
+	if !quotaFailure && float64(*usage.CurrentValue) < NearLimitThreshold*float64(*usage.Limit) {
+		delete(c.nearLimitSince, family)
+		return false
+	}
+	since, ok := c.nearLimitSince[family]
+	if !ok {
+		since = c.clock.Now()
+		c.nearLimitSince[family] = since
+	}
+	return quotaFailure || c.clock.Since(since) >= NearLimitDuration
+}
+
+// familiesWithQuotaFailures returns the VM families of the offerings currently marked unavailable due to quota,
+// with when the last of their offerings was marked
+func (c *IncreaseController) familiesWithQuotaFailures(ctx context.Context) map[string]time.Time {
+	families := map[string]time.Time{}
+	for _, offering := range c.unavailableOfferings.List() {
+		if offering.Reason != offerings.SubscriptionQuotaReachedReason || offering.InstanceType == "" {
+			continue
+		}
+		sku, err := c.instanceTypeProvider.Get(ctx, offering.InstanceType)
+		if err != nil {
+			log.FromContext(ctx).V(1).Info("failed to get instance type of unavailable offering", "instance-type", offering.InstanceType, "error", err)
+			continue
+		}
+		if family := sku.GetFamilyName(); family != "" && offering.MarkedAt.After(families[family]) {
+			families[family] = offering.MarkedAt
+		}
+	}
+	return families
+}
+
+// nodePoolsByFamily returns the NodePools whose NodeClaims use each VM family, keyed by VM family and NodePool name
+func (c *IncreaseController) nodePoolsByFamily(ctx context.Context) (map[string]map[string]*karpv1.NodePool, error) {
+	nodePoolList := &karpv1.NodePoolList{}
+	if err := c.kubeClient.List(ctx, nodePoolList); err != nil {
+		return nil, fmt.Errorf("listing nodepools, %w", err)
+	}
+	nodeClaimList := &karpv1.NodeClaimList{}
+	if err := c.kubeClient.List(ctx, nodeClaimList); err != nil {
+		return nil, fmt.Errorf("listing nodeclaims, %w", err)
+	}
+	nodePools := lo.SliceToMap(nodePoolList.Items, func(nodePool karpv1.NodePool) (string, *karpv1.NodePool) {
+		return nodePool.Name, &nodePool
+	})
+	families := map[string]map[string]*karpv1.NodePool{}
+	for _, nodeClaim := range nodeClaimList.Items {
+		nodePool, ok := nodePools[nodeClaim.Labels[karpv1.NodePoolLabelKey]]
+		instanceType := nodeClaim.Labels[corev1.LabelInstanceTypeStable]
+		if !ok || instanceType == "" {
+			continue
+		}
+		sku, err := c.instanceTypeProvider.Get(ctx, instanceType)
+		if err != nil {
+			log.FromContext(ctx).V(1).Info("failed to get instance type of nodeclaim", "NodeClaim", nodeClaim.Name, "instance-type", instanceType, "error", err)
+			continue
+		}
+		family := sku.GetFamilyName()
+		if family == "" {
+			continue
+		}
+		if _, ok := families[family]; !ok {
+			families[family] = map[string]*karpv1.NodePool{}
+		}
+		families[family][nodePool.Name] = nodePool
+	}
+	return families, nil
+}
+
+func (c *IncreaseController) requestIncrease(ctx context.Context, nodePools map[string]*karpv1.NodePool, family string, limit int64) {
+	maxLimit := int64(options.FromContext(ctx).QuotaIncreaseMaxLimit)
+	if limit >= maxLimit {
+		log.FromContext(ctx).V(1).Info("quota is near its limit, but already at the configured maximum", "family", family, "limit", limit, "maxLimit", maxLimit)
+		return
+	}
+	newLimit := lo.Min([]int64{maxLimit, lo.Max([]int64{2 * limit, limit + MinIncrease})})
+	request, err := c.requestsAPI.RequestIncrease(ctx, family, newLimit)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "failed to request quota increase", "family", family, "limit", newLimit)
+		c.backoffUntil[family] = c.clock.Now().Add(FailedRequestBackoff)
+		c.publish(nodePools, func(nodePool *karpv1.NodePool) events.Event {
+			return QuotaIncreaseFailedEvent(nodePool, family, newLimit, err.Error())
+		})
+		return
+	}
+	log.FromContext(ctx).Info("requested quota increase", "family", family, "limit", newLimit, "request", request.ID)
+	c.publish(nodePools, func(nodePool *karpv1.NodePool) events.Event {
+		return QuotaIncreaseRequestedEvent(nodePool, family, newLimit)
+	})
+	c.complete(ctx, nodePools, family, increaseRequest{Request: *request, Limit: newLimit})
+}
+
+// trackRequests polls the in-flight quota increase requests, and completes the ones that finished
+func (c *IncreaseController) trackRequests(ctx context.Context, nodePools map[string]map[string]*karpv1.NodePool) {
+	for family, request := range c.requests {
+		current, err := c.requestsAPI.GetRequest(ctx, request.ID)
+		if err != nil {
+			log.FromContext(ctx).Error(err, "failed to get quota increase request", "family", family, "request", request.ID)
+			continue
+		}
+		request.Request = *current
+		c.complete(ctx, nodePools[family], family, request)
+	}
+}
+
+// complete records the outcome of the quota increase request, if it finished. Otherwise, it keeps tracking it.
+func (c *IncreaseController) complete(ctx context.Context, nodePools map[string]*karpv1.NodePool, family string, request increaseRequest) {
+	if !request.State.IsTerminal() {
+		c.requests[family] = request
+		return
+	}
+	delete(c.requests, family)
+	if request.State == quota.RequestStateSucceeded {
+		log.FromContext(ctx).Info("quota increase succeeded", "family", family, "limit", request.Limit)
+		delete(c.nearLimitSince, family)
+		c.increases[family] = increase{Limit: request.Limit, SucceededAt: c.clock.Now()}
+		c.publish(nodePools, func(nodePool *karpv1.NodePool) events.Event {
+			return QuotaIncreaseSucceededEvent(nodePool, family, request.Limit)
+		})
+		return
+	}
+	log.FromContext(ctx).Info("quota increase failed", "family", family, "limit", request.Limit, "state", request.State, "message", request.Message)
+	c.backoffUntil[family] = c.clock.Now().Add(FailedRequestBackoff)
+	c.publish(nodePools, func(nodePool *karpv1.NodePool) events.Event {
+		return QuotaIncreaseFailedEvent(nodePool, family, request.Limit, lo.Ternary(request.Message != "", request.Message, string(request.State)))
+	})
+}
+
+// publish publishes the event on the NodePools using the VM family
+func (c *IncreaseController) publish(nodePools map[string]*karpv1.NodePool, event func(*karpv1.NodePool) events.Event) {
+	for _, nodePool := range nodePools {
+		c.recorder.Publish(event(nodePool))
+	}
+}
+
+func (c *IncreaseController) restore(ctx context.Context) error {
+	state := increaseState{}
+	restored, err := c.store.Restore(ctx, &state)
+	if err != nil || !restored {
+		return err
+	}
+	for family, request := range state.Requests {
+		c.requests[family] = request
+	}
+	for family, backoffUntil := range state.BackoffUntil {
+		c.backoffUntil[family] = backoffUntil
+	}
+	for family, increase := range state.Increases {
+		c.increases[family] = increase
+	}
+	log.FromContext(ctx).V(1).Info("restored quota increases", "requests", len(state.Requests), "backoffs", len(state.BackoffUntil))
+	return nil
+}
+
+func (c *IncreaseController) persist(ctx context.Context) error {
+	return c.store.Persist(ctx, increaseState{
+		Requests: c.requests,
+		// expired backoffs don't need to be persisted
+		BackoffUntil: lo.PickBy(c.backoffUntil, func(_ string, backoffUntil time.Time) bool { return c.clock.Now().Before(backoffUntil) }),
+		Increases:    c.increases,
+	})
+}
+
+func (c *IncreaseController) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("quota.increase").
+		WatchesRawSource(singleton.Source()).
+		Complete(singleton.AsReconciler(c))
+}
@@ -0,0 +1,248 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package quota_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v7"
+	quotacontroller "github.com/Azure/karpenter-provider-azure/pkg/controllers/quota"
+	"github.com/Azure/karpenter-provider-azure/pkg/controllers/unavailableofferings"
+	"github.com/Azure/karpenter-provider-azure/pkg/fake"
+	"github.com/Azure/karpenter-provider-azure/pkg/operator/options"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/instance/offerings"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/quota"
+	"github.com/Azure/karpenter-provider-azure/pkg/test"
+)
+
+var _ = Describe("Quota Increase Controller", func() {
+	var increaseCtx context.Context
+	var increaseController *quotacontroller.IncreaseController
+	var recorder *coretest.EventRecorder
+	var kubernetesInterface *kubernetesfake.Clientset
+
+	setUsage := func(family string, current int32, limit int64) {
+		azureEnv.UsageAPI.Usages.Append(&armcompute.Usage{
+			Name:         &armcompute.UsageName{Value: lo.ToPtr(family)},
+			CurrentValue: lo.ToPtr(current),
+			Limit:        lo.ToPtr(limit),
+		})
+		Expect(azureEnv.QuotaProvider.Update(increaseCtx)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		increaseCtx = options.ToContext(ctx, test.Options(test.OptionsFields{QuotaIncreaseMaxLimit: lo.ToPtr(150)}))
+		recorder = coretest.NewEventRecorder()
+		kubernetesInterface = kubernetesfake.NewClientset()
+		increaseController = quotacontroller.NewIncreaseController(env.Client, kubernetesInterface, recorder, fakeClock, azureEnv.QuotaProvider,
+			azureEnv.QuotaRequestsAPI, azureEnv.InstanceTypesProvider, azureEnv.UnavailableOfferingsCache)
+		// events are only published on the NodePools using the VM family
+		nodePool := coretest.NodePool()
+		nodeClaim := coretest.NodeClaim(karpv1.NodeClaim{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+			karpv1.NodePoolLabelKey:        nodePool.Name,
+			corev1.LabelInstanceTypeStable: "Standard_D2s_v3",
+		}}})
+		ExpectApplied(increaseCtx, env.Client, nodePool, coretest.NodePool(), nodeClaim)
+	})
+
+	It("should not request an increase until the family stayed near its limit", func() {
+		setUsage("standardDSv3Family", 95, 100)
+
+		ExpectSingletonReconciled(increaseCtx, increaseController)
+		Expect(azureEnv.QuotaRequestsAPI.RequestIncreaseBehavior.CalledWithInput.Len()).To(Equal(0))
+
+		fakeClock.Step(quotacontroller.NearLimitDuration)
+		ExpectSingletonReconciled(increaseCtx, increaseController)
+		Expect(azureEnv.QuotaRequestsAPI.RequestIncreaseBehavior.CalledWithInput.Len()).To(Equal(1))
+		input := azureEnv.QuotaRequestsAPI.RequestIncreaseBehavior.CalledWithInput.Pop()
+		Expect(input.Name).To(Equal("standardDSv3Family"))
+		Expect(input.Limit).To(Equal(int64(150)), "the doubled limit should be capped at the configured maximum")
+		Expect(recorder.Calls("QuotaIncreaseRequested")).To(Equal(1))
+	})
+	It("should not request an increase for families below the threshold", func() {
+		setUsage("standardDSv3Family", 50, 100)
+
+		ExpectSingletonReconciled(increaseCtx, increaseController)
+		fakeClock.Step(quotacontroller.NearLimitDuration)
+		ExpectSingletonReconciled(increaseCtx, increaseController)
+		Expect(azureEnv.QuotaRequestsAPI.RequestIncreaseBehavior.CalledWithInput.Len()).To(Equal(0))
+	})
+	It("should not request an increase beyond the configured maximum", func() {
+		setUsage("standardDSv3Family", 150, 150)
+
+		ExpectSingletonReconciled(increaseCtx, increaseController)
+		fakeClock.Step(quotacontroller.NearLimitDuration)
+		ExpectSingletonReconciled(increaseCtx, increaseController)
+		Expect(azureEnv.QuotaRequestsAPI.RequestIncreaseBehavior.CalledWithInput.Len()).To(Equal(0))
+	})
+	It("should request an increase right away for families with recent quota failures", func() {
+		setUsage("standardDv5Family", 2, 8)
+		azureEnv.UnavailableOfferingsCache.MarkUnavailableWithErrorCode(increaseCtx, offerings.SubscriptionQuotaReachedReason, "OperationNotAllowed",
+			fake.MakeSKU("Standard_D2_v5"), fmt.Sprintf("%s-1", fake.Region), karpv1.CapacityTypeOnDemand, offerings.SubscriptionQuotaReachedTTL)
+
+		ExpectSingletonReconciled(increaseCtx, increaseController)
+		Expect(azureEnv.QuotaRequestsAPI.RequestIncreaseBehavior.CalledWithInput.Len()).To(Equal(1))
+		input := azureEnv.QuotaRequestsAPI.RequestIncreaseBehavior.CalledWithInput.Pop()
+		Expect(input.Name).To(Equal("standardDv5Family"))
+		Expect(input.Limit).To(Equal(int64(8+quotacontroller.MinIncrease)), "small limits should be increased by at least MinIncrease")
+	})
+	It("should track the request until it succeeds", func() {
+		setUsage("standardDSv3Family", 95, 100)
+		ExpectSingletonReconciled(increaseCtx, increaseController)
+		fakeClock.Step(quotacontroller.NearLimitDuration)
+		ExpectSingletonReconciled(increaseCtx, increaseController)
+
+		// the request is in progress, so no other request is submitted
+		ExpectSingletonReconciled(increaseCtx, increaseController)
+		Expect(azureEnv.QuotaRequestsAPI.RequestIncreaseBehavior.CalledWithInput.Len()).To(Equal(1))
+		Expect(recorder.Calls("QuotaIncreaseSucceeded")).To(Equal(0))
+
+		azureEnv.QuotaRequestsAPI.Requests.Store("1", quota.Request{ID: "1", State: quota.RequestStateSucceeded})
+		ExpectSingletonReconciled(increaseCtx, increaseController)
+		Expect(recorder.Calls("QuotaIncreaseSucceeded")).To(Equal(1))
+	})
+	It("should not request another increase for a succeeded request while its quota failure is still cached", func() {
+		increaseCtx = options.ToContext(ctx, test.Options(test.OptionsFields{QuotaIncreaseMaxLimit: lo.ToPtr(1000)}))
+		setUsage("standardDSv3Family", 95, 100)
+		azureEnv.UnavailableOfferingsCache.MarkUnavailableWithErrorCode(increaseCtx, offerings.SubscriptionQuotaReachedReason, "OperationNotAllowed",
+			fake.MakeSKU("Standard_D2s_v3"), fmt.Sprintf("%s-1", fake.Region), karpv1.CapacityTypeOnDemand, offerings.SubscriptionQuotaReachedTTL)
+		ExpectSingletonReconciled(increaseCtx, increaseController)
+		Expect(azureEnv.QuotaRequestsAPI.RequestIncreaseBehavior.CalledWithInput.Len()).To(Equal(1))
+		Expect(azureEnv.QuotaRequestsAPI.RequestIncreaseBehavior.CalledWithInput.Pop().Limit).To(Equal(int64(200)))
+
+		// the quota failure is marked with the wall clock, the increase succeeds after it
+		fakeClock.SetTime(time.Now().Add(time.Minute))
+		azureEnv.QuotaRequestsAPI.Requests.Store("1", quota.Request{ID: "1", State: quota.RequestStateSucceeded})
+		ExpectSingletonReconciled(increaseCtx, increaseController)
+		Expect(recorder.Calls("QuotaIncreaseSucceeded")).To(Equal(1))
+
+		// the usage data doesn't reflect the increase yet
+		fakeClock.Step(quotacontroller.IncreaseInterval)
+		ExpectSingletonReconciled(increaseCtx, increaseController)
+		Expect(azureEnv.QuotaRequestsAPI.RequestIncreaseBehavior.CalledWithInput.Len()).To(Equal(0))
+
+		// once it does, the quota failure from before the increase is ignored
+		setUsage("standardDSv3Family", 190, 200)
+		fakeClock.Step(quotacontroller.IncreaseInterval)
+		ExpectSingletonReconciled(increaseCtx, increaseController)
+		Expect(azureEnv.QuotaRequestsAPI.RequestIncreaseBehavior.CalledWithInput.Len()).To(Equal(0))
+
+		// and the family has to stay near its new limit to be increased again
+		fakeClock.Step(quotacontroller.NearLimitDuration)
+		ExpectSingletonReconciled(increaseCtx, increaseController)
+		Expect(azureEnv.QuotaRequestsAPI.RequestIncreaseBehavior.CalledWithInput.Len()).To(Equal(1))
+		Expect(azureEnv.QuotaRequestsAPI.RequestIncreaseBehavior.CalledWithInput.Pop().Limit).To(Equal(int64(400)))
+	})
+	It("should persist in-flight requests and backoffs across restarts", func() {
+		os.Setenv("SYSTEM_NAMESPACE", "karpenter")
+		DeferCleanup(os.Unsetenv, "SYSTEM_NAMESPACE")
+		newIncreaseController := func() *quotacontroller.IncreaseController {
+			return quotacontroller.NewIncreaseController(env.Client, kubernetesInterface, recorder, fakeClock, azureEnv.QuotaProvider,
+				azureEnv.QuotaRequestsAPI, azureEnv.InstanceTypesProvider, azureEnv.UnavailableOfferingsCache)
+		}
+		increaseController = newIncreaseController()
+		setUsage("standardDSv3Family", 95, 100)
+		setUsage("standardDSv2Family", 8, 8)
+		// the request for standardDSv2Family fails, the one for standardDSv3Family stays in progress
+		azureEnv.QuotaRequestsAPI.RequestIncreaseBehavior.Error.Set(fmt.Errorf("forbidden"), fake.MaxCalls(1))
+		ExpectSingletonReconciled(increaseCtx, increaseController)
+		fakeClock.Step(quotacontroller.NearLimitDuration)
+		ExpectSingletonReconciled(increaseCtx, increaseController)
+		Expect(azureEnv.QuotaRequestsAPI.RequestIncreaseBehavior.CalledWithInput.Len()).To(Equal(2))
+
+		configMap, err := kubernetesInterface.CoreV1().ConfigMaps("karpenter").Get(increaseCtx, quotacontroller.ConfigMapName, metav1.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		state := map[string]any{}
+		Expect(json.Unmarshal([]byte(configMap.Data[quotacontroller.ConfigMapDataKey]), &state)).To(Succeed())
+		Expect(state).To(HaveKey("requests"))
+		Expect(state).To(HaveKey("backoffUntil"))
+
+		// after a restart, neither the in-flight request nor the failed one is submitted again
+		increaseController = newIncreaseController()
+		ExpectSingletonReconciled(increaseCtx, increaseController)
+		fakeClock.Step(quotacontroller.NearLimitDuration)
+		ExpectSingletonReconciled(increaseCtx, increaseController)
+		Expect(azureEnv.QuotaRequestsAPI.RequestIncreaseBehavior.CalledWithInput.Len()).To(Equal(2))
+
+		// and the restored request is still tracked
+		azureEnv.QuotaRequestsAPI.Requests.Store("1", quota.Request{ID: "1", State: quota.RequestStateSucceeded})
+		ExpectSingletonReconciled(increaseCtx, increaseController)
+		Expect(recorder.Calls("QuotaIncreaseSucceeded")).To(Equal(1))
+	})
+	It("should persist to its own ConfigMap when unavailable offerings are not persisted", func() {
+		os.Setenv("SYSTEM_NAMESPACE", "karpenter")
+		DeferCleanup(os.Unsetenv, "SYSTEM_NAMESPACE")
+		// without persistUnavailableOfferings, the chart doesn't grant access to the unavailable offerings ConfigMap
+		kubernetesInterface.PrependReactor("*", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			var name string
+			switch action := action.(type) {
+			case k8stesting.GetAction:
+				name = action.GetName()
+			case k8stesting.CreateAction:
+				name = action.GetObject().(*corev1.ConfigMap).Name
+			}
+			if name != unavailableofferings.ConfigMapName {
+				return false, nil, nil
+			}
+			return true, nil, apierrors.NewForbidden(corev1.Resource("configmaps"), name, fmt.Errorf("not granted"))
+		})
+		increaseController = quotacontroller.NewIncreaseController(env.Client, kubernetesInterface, recorder, fakeClock, azureEnv.QuotaProvider,
+			azureEnv.QuotaRequestsAPI, azureEnv.InstanceTypesProvider, azureEnv.UnavailableOfferingsCache)
+		setUsage("standardDSv3Family", 95, 100)
+
+		ExpectSingletonReconciled(increaseCtx, increaseController)
+		fakeClock.Step(quotacontroller.NearLimitDuration)
+		ExpectSingletonReconciled(increaseCtx, increaseController)
+		Expect(azureEnv.QuotaRequestsAPI.RequestIncreaseBehavior.CalledWithInput.Len()).To(Equal(1))
+
+		configMap, err := kubernetesInterface.CoreV1().ConfigMaps("karpenter").Get(increaseCtx, quotacontroller.ConfigMapName, metav1.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(configMap.Data).To(HaveKey(quotacontroller.ConfigMapDataKey))
+	})
+	It("should back off after a failed request", func() {
+		setUsage("standardDSv3Family", 95, 100)
+		azureEnv.QuotaRequestsAPI.RequestIncreaseBehavior.Error.Set(fmt.Errorf("forbidden"))
+		ExpectSingletonReconciled(increaseCtx, increaseController)
+		fakeClock.Step(quotacontroller.NearLimitDuration)
+		ExpectSingletonReconciled(increaseCtx, increaseController)
+		Expect(recorder.Calls("QuotaIncreaseFailed")).To(Equal(1))
+
+		ExpectSingletonReconciled(increaseCtx, increaseController)
+		Expect(azureEnv.QuotaRequestsAPI.RequestIncreaseBehavior.CalledWithInput.Len()).To(Equal(1))
+
+		fakeClock.Step(quotacontroller.FailedRequestBackoff)
+		ExpectSingletonReconciled(increaseCtx, increaseController)
+		Expect(azureEnv.QuotaRequestsAPI.RequestIncreaseBehavior.CalledWithInput.Len()).To(Equal(2))
+	})
+})
@@ -0,0 +1,57 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spotevictions
+
+import (
+	"context"
+	"time"
+
+	"github.com/awslabs/operatorpkg/reconciler"
+	"github.com/awslabs/operatorpkg/singleton"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	azurecache "github.com/Azure/karpenter-provider-azure/pkg/cache"
+)
+
+const (
+	SpotEvictionsRefreshInterval = 5 * time.Minute
+)
+
+// Controller periodically prunes the spot evictions that are out of the window, so that the
+// eviction rate metric of every instance type and zone decays even when it isn't ranked.
+type Controller struct {
+	spotEvictions *azurecache.SpotEvictions
+}
+
+func NewController(spotEvictions *azurecache.SpotEvictions) *Controller {
+	return &Controller{
+		spotEvictions: spotEvictions,
+	}
+}
+
+func (c *Controller) Reconcile(_ context.Context) (reconciler.Result, error) {
+	c.spotEvictions.Refresh()
+	return reconciler.Result{RequeueAfter: SpotEvictionsRefreshInterval}, nil
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("spotevictions").
+		WatchesRawSource(singleton.Source()).
+		Complete(singleton.AsReconciler(c))
+}
@@ -0,0 +1,127 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spotpricehistory
+
+import (
+	"context"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/awslabs/operatorpkg/reconciler"
+	"github.com/awslabs/operatorpkg/singleton"
+	"github.com/samber/lo"
+	"k8s.io/client-go/kubernetes"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+
+	azurecache "github.com/Azure/karpenter-provider-azure/pkg/cache"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/pricing"
+	"github.com/Azure/karpenter-provider-azure/pkg/utils/configmapstore"
+)
+
+const (
+	// ConfigMapName is the name of the ConfigMap, in the system namespace, that the spot price history is persisted to
+	ConfigMapName = "karpenter-spot-price-history"
+	// ConfigMapDataKey is the key of the ConfigMap data holding the serialized spot price history
+	ConfigMapDataKey = "spotPriceHistory"
+	// RecordInterval is how often the spot prices are checked for updates to record, and the history persisted when it changed
+	RecordInterval = time.Minute
+)
+
+// Controller records the spot prices, as a fraction of the on-demand prices, to the spot price history every time they are
+// updated from the Azure retail prices API. The history is persisted to a ConfigMap so that it survives controller restarts.
+type Controller struct {
+	pricingProvider  *pricing.Provider
+	spotPriceHistory *azurecache.SpotPriceHistory
+	systemNamespace  string
+	store            *configmapstore.Store
+
+	restored     bool
+	lastRecorded time.Time
+}
+
+func NewController(
+	inClusterKubernetesInterface kubernetes.Interface,
+	pricingProvider *pricing.Provider,
+	spotPriceHistory *azurecache.SpotPriceHistory,
+) *Controller {
+	systemNamespace := strings.TrimSpace(os.Getenv("SYSTEM_NAMESPACE"))
+	return &Controller{
+		pricingProvider:  pricingProvider,
+		spotPriceHistory: spotPriceHistory,
+		systemNamespace:  systemNamespace,
+		store:            configmapstore.New(inClusterKubernetesInterface, systemNamespace, ConfigMapName, ConfigMapDataKey),
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context) (reconciler.Result, error) {
+	ctx = injection.WithControllerName(ctx, "pricing.spotpricehistory")
+
+	// Restore before recording, so that the restored samples predate the recorded ones
+	if c.systemNamespace != "" && !c.restored {
+		snapshot := map[string][]azurecache.SpotPriceSample{}
+		restored, err := c.store.Restore(ctx, &snapshot)
+		if err != nil {
+			return reconciler.Result{}, err
+		}
+		if restored {
+			c.spotPriceHistory.Restore(snapshot)
+		}
+		c.restored = true
+	}
+	c.record(ctx)
+	if c.systemNamespace != "" {
+		if err := c.store.Persist(ctx, c.spotPriceHistory.Snapshot()); err != nil {
+			return reconciler.Result{}, err
+		}
+	}
+	return reconciler.Result{RequeueAfter: RecordInterval}, nil
+}
+
+// record adds the spot prices to the history if they were updated since they were last recorded. Offline prices and the
+// static pricing data are not recorded, they are never updated.
+func (c *Controller) record(ctx context.Context) {
+	if c.pricingProvider.Offline() {
+		return
+	}
+	updated := c.pricingProvider.SpotLastUpdated()
+	if !updated.After(lo.Latest(c.lastRecorded, pricing.InitialPriceUpdate)) {
+		return
+	}
+	ratios := map[string]float64{}
+	for _, instanceType := range c.pricingProvider.InstanceTypes() {
+		onDemandPrice, onDemandOK := c.pricingProvider.RetailOnDemandPrice(instanceType)
+		spotPrice, spotOK := c.pricingProvider.RetailSpotPrice(instanceType)
+		if !onDemandOK || !spotOK || onDemandPrice <= 0 {
+			continue
+		}
+		ratios[instanceType] = spotPrice / onDemandPrice
+	}
+	c.spotPriceHistory.Record(updated, ratios)
+	c.lastRecorded = updated
+	log.FromContext(ctx).V(1).Info("recorded spot prices", "instanceTypeCount", len(ratios), "updated", updated)
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("pricing.spotpricehistory").
+		WatchesRawSource(singleton.Source()).
+		Complete(singleton.AsReconciler(c))
+}
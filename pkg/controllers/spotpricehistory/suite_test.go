@@ -0,0 +1,168 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spotpricehistory_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+
+	"github.com/Azure/karpenter-provider-azure/pkg/auth"
+	azurecache "github.com/Azure/karpenter-provider-azure/pkg/cache"
+	"github.com/Azure/karpenter-provider-azure/pkg/controllers/spotpricehistory"
+	"github.com/Azure/karpenter-provider-azure/pkg/fake"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/pricing"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/pricing/client"
+)
+
+const systemNamespace = "karpenter"
+
+var mainCtx context.Context
+var ctx context.Context
+var stop context.CancelFunc
+var env *auth.Environment
+
+func TestController(t *testing.T) {
+	mainCtx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "SpotPriceHistoryController")
+}
+
+var _ = BeforeSuite(func() {
+	var err error
+	env, err = auth.EnvironmentFromName("AzurePublicCloud")
+	Expect(err).ToNot(HaveOccurred())
+})
+
+var _ = Describe("SpotPriceHistory Controller", func() {
+	var fakePricingAPI *fake.PricingAPI
+	var pricingProvider *pricing.Provider
+	var spotPriceHistory *azurecache.SpotPriceHistory
+	var kubernetesInterface *kubernetesfake.Clientset
+	var controller *spotpricehistory.Controller
+
+	BeforeEach(func() {
+		os.Setenv("SYSTEM_NAMESPACE", systemNamespace)
+		DeferCleanup(os.Unsetenv, "SYSTEM_NAMESPACE")
+		// the pricing provider updates the prices in the background until the context is canceled
+		ctx, stop = context.WithCancel(mainCtx)
+		fakePricingAPI = &fake.PricingAPI{}
+		spotPriceHistory = azurecache.NewSpotPriceHistory()
+		kubernetesInterface = kubernetesfake.NewClientset()
+	})
+
+	AfterEach(func() {
+		stop()
+		Expect(pricingProvider.WaitUntilDone()).To(Succeed())
+	})
+
+	startPricingProvider := func(opts ...pricing.Option) {
+		GinkgoHelper()
+		updateStart := time.Now()
+		pricingProvider = pricing.NewProvider(ctx, env, fakePricingAPI, fake.Region, make(chan struct{}), opts...)
+		if !pricingProvider.Offline() {
+			Eventually(func() bool { return pricingProvider.SpotLastUpdated().After(updateStart) }).Should(BeTrue())
+		}
+		controller = spotpricehistory.NewController(kubernetesInterface, pricingProvider, spotPriceHistory)
+	}
+	getPersisted := func() map[string][]azurecache.SpotPriceSample {
+		GinkgoHelper()
+		configMap, err := kubernetesInterface.CoreV1().ConfigMaps(systemNamespace).Get(ctx, spotpricehistory.ConfigMapName, metav1.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		snapshot := map[string][]azurecache.SpotPriceSample{}
+		Expect(json.Unmarshal([]byte(configMap.Data[spotpricehistory.ConfigMapDataKey]), &snapshot)).To(Succeed())
+		return snapshot
+	}
+
+	BeforeEach(func() {
+		fakePricingAPI.ProductsPricePage.Set(&client.ProductsPricePage{
+			Items: []client.Item{
+				fake.NewProductPrice("Standard_D2s_v3", 0.1),
+				fake.NewSpotProductPrice("Standard_D2s_v3", 0.02),
+				fake.NewProductPrice("Standard_D4s_v3", 0.2),
+			},
+		})
+	})
+
+	It("should return a requeue interval of 1 minute", func() {
+		startPricingProvider()
+		result := ExpectSingletonReconciled(ctx, controller)
+		Expect(result.RequeueAfter).To(Equal(spotpricehistory.RecordInterval))
+	})
+	It("should record and persist the spot prices as a fraction of the on-demand prices", func() {
+		startPricingProvider()
+		ExpectSingletonReconciled(ctx, controller)
+
+		snapshot := spotPriceHistory.Snapshot()
+		// Standard_D4s_v3 doesn't have a spot price
+		Expect(snapshot).To(HaveLen(1))
+		Expect(snapshot["Standard_D2s_v3"]).To(HaveLen(1))
+		Expect(snapshot["Standard_D2s_v3"][0].Ratio).To(Equal(0.2))
+		Expect(snapshot["Standard_D2s_v3"][0].Time.Unix()).To(Equal(pricingProvider.SpotLastUpdated().Unix()))
+		Expect(getPersisted()).To(HaveKey("Standard_D2s_v3"))
+
+		// the same prices are only recorded once
+		ExpectSingletonReconciled(ctx, controller)
+		Expect(spotPriceHistory.Snapshot()["Standard_D2s_v3"]).To(HaveLen(1))
+	})
+	It("should restore the persisted history before recording", func() {
+		persisted := time.Now().Add(-24 * time.Hour)
+		data, err := json.Marshal(map[string][]azurecache.SpotPriceSample{"Standard_D2s_v3": {{Time: persisted, Ratio: 0.1}}})
+		Expect(err).ToNot(HaveOccurred())
+		_, err = kubernetesInterface.CoreV1().ConfigMaps(systemNamespace).Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: spotpricehistory.ConfigMapName, Namespace: systemNamespace},
+			Data:       map[string]string{spotpricehistory.ConfigMapDataKey: string(data)},
+		}, metav1.CreateOptions{})
+		Expect(err).ToNot(HaveOccurred())
+
+		startPricingProvider()
+		ExpectSingletonReconciled(ctx, controller)
+
+		samples := spotPriceHistory.Snapshot()["Standard_D2s_v3"]
+		Expect(samples).To(HaveLen(2))
+		Expect(samples[0].Time.Unix()).To(Equal(persisted.Unix()))
+		Expect(samples[1].Ratio).To(Equal(0.2))
+		Expect(spotPriceHistory.Trend("Standard_D2s_v3")).To(BeNumerically(">", 0))
+		Expect(getPersisted()["Standard_D2s_v3"]).To(HaveLen(2))
+	})
+	It("should not record offline prices", func() {
+		startPricingProvider(pricing.WithOfflinePricing(nil))
+		ExpectSingletonReconciled(ctx, controller)
+
+		Expect(spotPriceHistory.Snapshot()).To(BeEmpty())
+	})
+	It("should not persist without a system namespace", func() {
+		os.Unsetenv("SYSTEM_NAMESPACE")
+		startPricingProvider()
+		ExpectSingletonReconciled(ctx, controller)
+
+		Expect(spotPriceHistory.Snapshot()).To(HaveKey("Standard_D2s_v3"))
+		_, err := kubernetesInterface.CoreV1().ConfigMaps(systemNamespace).Get(ctx, spotpricehistory.ConfigMapName, metav1.GetOptions{})
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+	})
+})
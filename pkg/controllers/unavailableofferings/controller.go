@@ -0,0 +1,95 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package unavailableofferings
+
+import (
+	"context"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/awslabs/operatorpkg/reconciler"
+	"github.com/awslabs/operatorpkg/singleton"
+	"k8s.io/client-go/kubernetes"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+
+	azurecache "github.com/Azure/karpenter-provider-azure/pkg/cache"
+	"github.com/Azure/karpenter-provider-azure/pkg/utils/configmapstore"
+)
+
+const (
+	// ConfigMapName is the name of the ConfigMap, in the system namespace, that unavailable offerings are persisted to
+	ConfigMapName = "karpenter-unavailable-offerings"
+	// ConfigMapDataKey is the key of the ConfigMap data holding the serialized unavailable offerings
+	ConfigMapDataKey = "unavailableOfferings"
+	// PersistInterval is how often unavailable offerings are persisted, when they changed
+	PersistInterval = 15 * time.Second
+)
+
+// Controller persists the unavailable offerings to a ConfigMap, and restores them on startup, so that offerings that
+// just failed (e.g. AllocationFailed, or insufficient quota) are not retried after controller restarts and leader failovers.
+type Controller struct {
+	unavailableOfferings *azurecache.UnavailableOfferings
+	systemNamespace      string
+	store                *configmapstore.Store
+
+	restored bool
+}
+
+func NewController(inClusterKubernetesInterface kubernetes.Interface, unavailableOfferings *azurecache.UnavailableOfferings) *Controller {
+	systemNamespace := strings.TrimSpace(os.Getenv("SYSTEM_NAMESPACE"))
+	return &Controller{
+		unavailableOfferings: unavailableOfferings,
+		systemNamespace:      systemNamespace,
+		store:                configmapstore.New(inClusterKubernetesInterface, systemNamespace, ConfigMapName, ConfigMapDataKey),
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context) (reconciler.Result, error) {
+	ctx = injection.WithControllerName(ctx, "unavailableofferings.persistence")
+
+	if c.systemNamespace == "" {
+		log.FromContext(ctx).V(1).Info("SYSTEM_NAMESPACE is not set, not persisting unavailable offerings")
+		return reconciler.Result{}, nil
+	}
+	// Restore before the first write, so that persisted unavailable offerings are not overwritten
+	if !c.restored {
+		snapshot := azurecache.UnavailableOfferingsSnapshot{}
+		restored, err := c.store.Restore(ctx, &snapshot)
+		if err != nil {
+			return reconciler.Result{}, err
+		}
+		if restored {
+			c.unavailableOfferings.Restore(ctx, snapshot)
+		}
+		c.restored = true
+	}
+	if err := c.store.Persist(ctx, c.unavailableOfferings.Snapshot()); err != nil {
+		return reconciler.Result{}, err
+	}
+	return reconciler.Result{RequeueAfter: PersistInterval}, nil
+}
+
+func (c *Controller) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("unavailableofferings.persistence").
+		WatchesRawSource(singleton.Source()).
+		Complete(singleton.AsReconciler(c))
+}
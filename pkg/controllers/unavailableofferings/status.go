@@ -0,0 +1,106 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package unavailableofferings
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/awslabs/operatorpkg/reconciler"
+	"github.com/awslabs/operatorpkg/singleton"
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/karpenter/pkg/operator/injection"
+
+	"github.com/Azure/karpenter-provider-azure/pkg/apis/v1beta1"
+	azurecache "github.com/Azure/karpenter-provider-azure/pkg/cache"
+)
+
+// StatusInterval is how often the AzureCapacityStatus is brought up to date with the unavailable offerings
+const StatusInterval = 30 * time.Second
+
+// StatusController exposes the unavailable offerings and VM families through the AzureCapacityStatus, so that users
+// can see which offerings Karpenter is currently avoiding, why, and until when.
+type StatusController struct {
+	kubeClient           client.Client
+	unavailableOfferings *azurecache.UnavailableOfferings
+}
+
+func NewStatusController(kubeClient client.Client, unavailableOfferings *azurecache.UnavailableOfferings) *StatusController {
+	return &StatusController{
+		kubeClient:           kubeClient,
+		unavailableOfferings: unavailableOfferings,
+	}
+}
+
+func (c *StatusController) Reconcile(ctx context.Context) (reconciler.Result, error) {
+	ctx = injection.WithControllerName(ctx, "unavailableofferings.status")
+
+	capacityStatus := &v1beta1.AzureCapacityStatus{}
+	if err := c.kubeClient.Get(ctx, client.ObjectKey{Name: v1beta1.AzureCapacityStatusName}, capacityStatus); err != nil {
+		if !errors.IsNotFound(err) {
+			return reconciler.Result{}, fmt.Errorf("getting AzureCapacityStatus, %w", err)
+		}
+		capacityStatus = &v1beta1.AzureCapacityStatus{ObjectMeta: metav1.ObjectMeta{Name: v1beta1.AzureCapacityStatusName}}
+		if err := c.kubeClient.Create(ctx, capacityStatus); err != nil {
+			return reconciler.Result{}, fmt.Errorf("creating AzureCapacityStatus, %w", err)
+		}
+	}
+
+	stored := capacityStatus.DeepCopy()
+	capacityStatus.Status.UnavailableOfferings = lo.Map(c.unavailableOfferings.List(), func(offering azurecache.UnavailableOffering, _ int) v1beta1.UnavailableOffering {
+		return v1beta1.UnavailableOffering{
+			InstanceType: offering.InstanceType,
+			Zone:         offering.Zone,
+			CapacityType: offering.CapacityType,
+			Reason:       offering.Reason,
+			ErrorCode:    offering.ErrorCode,
+			// the API server stores times with a precision of seconds
+			MarkedTime:     metav1.NewTime(offering.MarkedAt.Truncate(time.Second)),
+			ExpirationTime: metav1.NewTime(offering.Expiration.Truncate(time.Second)),
+		}
+	})
+	capacityStatus.Status.UnavailableVMFamilies = lo.Map(c.unavailableOfferings.ListVMFamilies(), func(family azurecache.UnavailableVMFamily, _ int) v1beta1.UnavailableVMFamily {
+		return v1beta1.UnavailableVMFamily{
+			Family:       family.Family,
+			Zone:         family.Zone,
+			CapacityType: family.CapacityType,
+			// a CPU count that isn't positive blocks the whole family, which is reported without minCPU
+			MinCPU:         max(family.CPUCount, 0),
+			ExpirationTime: metav1.NewTime(family.Expiration.Truncate(time.Second)),
+		}
+	})
+	if !equality.Semantic.DeepEqual(stored.Status, capacityStatus.Status) {
+		if err := c.kubeClient.Status().Patch(ctx, capacityStatus, client.MergeFrom(stored)); err != nil {
+			return reconciler.Result{}, fmt.Errorf("patching AzureCapacityStatus, %w", err)
+		}
+	}
+	return reconciler.Result{RequeueAfter: StatusInterval}, nil
+}
+
+func (c *StatusController) Register(_ context.Context, m manager.Manager) error {
+	return controllerruntime.NewControllerManagedBy(m).
+		Named("unavailableofferings.status").
+		WatchesRawSource(singleton.Source()).
+		Complete(singleton.AsReconciler(c))
+}
@@ -0,0 +1,110 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package unavailableofferings_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+
+	"github.com/Azure/karpenter-provider-azure/pkg/apis/v1beta1"
+	azurecache "github.com/Azure/karpenter-provider-azure/pkg/cache"
+	"github.com/Azure/karpenter-provider-azure/pkg/controllers/unavailableofferings"
+	"github.com/Azure/karpenter-provider-azure/pkg/fake"
+)
+
+var _ = Describe("Unavailable Offerings Status", func() {
+	var kubeClient client.Client
+	var unavailableOfferingsCache *azurecache.UnavailableOfferings
+	var controller *unavailableofferings.StatusController
+	sku := fake.MakeSKU("Standard_D2s_v3")
+
+	BeforeEach(func() {
+		kubeClient = clientfake.NewClientBuilder().WithStatusSubresource(&v1beta1.AzureCapacityStatus{}).Build()
+		unavailableOfferingsCache = azurecache.NewUnavailableOfferings()
+		controller = unavailableofferings.NewStatusController(kubeClient, unavailableOfferingsCache)
+	})
+
+	getCapacityStatus := func() *v1beta1.AzureCapacityStatus {
+		GinkgoHelper()
+		capacityStatus := &v1beta1.AzureCapacityStatus{}
+		Expect(kubeClient.Get(ctx, client.ObjectKey{Name: v1beta1.AzureCapacityStatusName}, capacityStatus)).To(Succeed())
+		return capacityStatus
+	}
+
+	It("should create an empty AzureCapacityStatus when no offerings are unavailable", func() {
+		result, err := controller.Reconcile(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(unavailableofferings.StatusInterval))
+		Expect(getCapacityStatus().Status.UnavailableOfferings).To(BeEmpty())
+	})
+	It("should list the unavailable offerings with their reason, error code and times", func() {
+		before := time.Now().Truncate(time.Second)
+		unavailableOfferingsCache.MarkUnavailableWithErrorCode(ctx, "ZonalAllocationFailure", "ZonalAllocationFailed", sku, "westus-1", karpv1.CapacityTypeOnDemand, time.Hour)
+		unavailableOfferingsCache.MarkSpotUnavailableWithErrorCode(ctx, "SubscriptionQuotaReached", "OperationNotAllowed", time.Hour)
+		_, err := controller.Reconcile(ctx)
+		Expect(err).ToNot(HaveOccurred())
+
+		offerings := getCapacityStatus().Status.UnavailableOfferings
+		Expect(offerings).To(HaveLen(2))
+		Expect(offerings[0].InstanceType).To(Equal("Standard_D2s_v3"))
+		Expect(offerings[0].Zone).To(Equal("westus-1"))
+		Expect(offerings[0].CapacityType).To(Equal(karpv1.CapacityTypeOnDemand))
+		Expect(offerings[0].Reason).To(Equal("ZonalAllocationFailure"))
+		Expect(offerings[0].ErrorCode).To(Equal("ZonalAllocationFailed"))
+		Expect(offerings[0].MarkedTime.Time).ToNot(BeTemporally("<", before))
+		Expect(offerings[0].ExpirationTime.Time).To(BeTemporally("~", offerings[0].MarkedTime.Add(time.Hour), time.Second))
+		Expect(offerings[1].InstanceType).To(BeEmpty())
+		Expect(offerings[1].Zone).To(BeEmpty())
+		Expect(offerings[1].CapacityType).To(Equal(karpv1.CapacityTypeSpot))
+		Expect(offerings[1].ErrorCode).To(Equal("OperationNotAllowed"))
+	})
+	It("should list the unavailable VM families with their CPU threshold", func() {
+		unavailableOfferingsCache.MarkUnavailable(ctx, "AllocationFailure", fake.MakeSKU("Standard_D4s_v3"), "westus-1", karpv1.CapacityTypeOnDemand)
+		unavailableOfferingsCache.MarkFamilyUnavailable(ctx, sku, "westus-2", karpv1.CapacityTypeSpot, time.Hour)
+		_, err := controller.Reconcile(ctx)
+		Expect(err).ToNot(HaveOccurred())
+
+		families := getCapacityStatus().Status.UnavailableVMFamilies
+		Expect(families).To(HaveLen(2))
+		Expect(families[0].Family).To(Equal(sku.GetFamilyName()))
+		Expect(families[0].Zone).To(Equal("westus-1"))
+		Expect(families[0].CapacityType).To(Equal(karpv1.CapacityTypeOnDemand))
+		Expect(families[0].MinCPU).To(BeNumerically("==", 4))
+		Expect(families[0].ExpirationTime.Time).To(BeTemporally(">", time.Now()))
+		Expect(families[1].Zone).To(Equal("westus-2"))
+		Expect(families[1].CapacityType).To(Equal(karpv1.CapacityTypeSpot))
+		// the whole family is unavailable
+		Expect(families[1].MinCPU).To(BeZero())
+	})
+	It("should remove offerings that are available again", func() {
+		unavailableOfferingsCache.MarkUnavailable(ctx, "AllocationFailure", sku, "westus-1", karpv1.CapacityTypeOnDemand)
+		_, err := controller.Reconcile(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(getCapacityStatus().Status.UnavailableOfferings).To(HaveLen(1))
+
+		unavailableOfferingsCache.Flush()
+		_, err = controller.Reconcile(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(getCapacityStatus().Status.UnavailableOfferings).To(BeEmpty())
+		Expect(getCapacityStatus().Status.UnavailableVMFamilies).To(BeEmpty())
+	})
+})
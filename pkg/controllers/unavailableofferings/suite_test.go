@@ -0,0 +1,133 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package unavailableofferings_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	. "sigs.k8s.io/karpenter/pkg/utils/testing"
+
+	azurecache "github.com/Azure/karpenter-provider-azure/pkg/cache"
+	"github.com/Azure/karpenter-provider-azure/pkg/controllers/unavailableofferings"
+	"github.com/Azure/karpenter-provider-azure/pkg/fake"
+)
+
+const systemNamespace = "karpenter"
+
+var ctx context.Context
+
+func TestController(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "UnavailableOfferingsPersistence")
+}
+
+var _ = Describe("Unavailable Offerings Persistence", func() {
+	var kubernetesInterface *kubernetesfake.Clientset
+	var unavailableOfferingsCache *azurecache.UnavailableOfferings
+	var controller *unavailableofferings.Controller
+	sku := fake.MakeSKU("Standard_D2s_v3")
+
+	BeforeEach(func() {
+		os.Setenv("SYSTEM_NAMESPACE", systemNamespace)
+		DeferCleanup(os.Unsetenv, "SYSTEM_NAMESPACE")
+		kubernetesInterface = kubernetesfake.NewClientset()
+		unavailableOfferingsCache = azurecache.NewUnavailableOfferings()
+		controller = unavailableofferings.NewController(kubernetesInterface, unavailableOfferingsCache)
+	})
+
+	getPersisted := func() azurecache.UnavailableOfferingsSnapshot {
+		GinkgoHelper()
+		configMap, err := kubernetesInterface.CoreV1().ConfigMaps(systemNamespace).Get(ctx, unavailableofferings.ConfigMapName, metav1.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		snapshot := azurecache.UnavailableOfferingsSnapshot{}
+		Expect(json.Unmarshal([]byte(configMap.Data[unavailableofferings.ConfigMapDataKey]), &snapshot)).To(Succeed())
+		return snapshot
+	}
+
+	It("should persist unavailable offerings to a ConfigMap", func() {
+		unavailableOfferingsCache.MarkUnavailable(ctx, "AllocationFailed", sku, "westus-1", karpv1.CapacityTypeOnDemand)
+		result, err := controller.Reconcile(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(unavailableofferings.PersistInterval))
+		Expect(getPersisted().SingleOfferings).To(HaveKey("on-demand:Standard_D2s_v3:westus-1"))
+
+		// changes are persisted on the next reconcile
+		unavailableOfferingsCache.MarkUnavailable(ctx, "AllocationFailed", sku, "westus-2", karpv1.CapacityTypeOnDemand)
+		_, err = controller.Reconcile(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(getPersisted().SingleOfferings).To(HaveKey("on-demand:Standard_D2s_v3:westus-2"))
+	})
+	It("should restore persisted unavailable offerings with their remaining TTL", func() {
+		snapshot := azurecache.UnavailableOfferingsSnapshot{
+			SingleOfferings: map[string]azurecache.UnavailableOffering{
+				"on-demand:Standard_D2s_v3:westus-1": {
+					InstanceType: "Standard_D2s_v3", Zone: "westus-1", CapacityType: karpv1.CapacityTypeOnDemand, Expiration: time.Now().Add(time.Minute),
+				},
+				"on-demand:Standard_D2s_v3:westus-2": {
+					InstanceType: "Standard_D2s_v3", Zone: "westus-2", CapacityType: karpv1.CapacityTypeOnDemand, Expiration: time.Now().Add(-time.Minute),
+				},
+			},
+		}
+		data, err := json.Marshal(snapshot)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = kubernetesInterface.CoreV1().ConfigMaps(systemNamespace).Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: unavailableofferings.ConfigMapName, Namespace: systemNamespace},
+			Data:       map[string]string{unavailableofferings.ConfigMapDataKey: string(data)},
+		}, metav1.CreateOptions{})
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = controller.Reconcile(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(unavailableOfferingsCache.IsUnavailable(sku, "westus-1", karpv1.CapacityTypeOnDemand)).To(BeTrue())
+		Expect(unavailableOfferingsCache.IsUnavailable(sku, "westus-2", karpv1.CapacityTypeOnDemand)).To(BeFalse())
+		// the expired entry is dropped from the persisted unavailable offerings
+		Expect(getPersisted().SingleOfferings).To(HaveLen(1))
+	})
+	It("should ignore unreadable persisted unavailable offerings", func() {
+		_, err := kubernetesInterface.CoreV1().ConfigMaps(systemNamespace).Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: unavailableofferings.ConfigMapName, Namespace: systemNamespace},
+			Data:       map[string]string{unavailableofferings.ConfigMapDataKey: "not json"},
+		}, metav1.CreateOptions{})
+		Expect(err).ToNot(HaveOccurred())
+
+		unavailableOfferingsCache.MarkUnavailable(ctx, "AllocationFailed", sku, "westus-1", karpv1.CapacityTypeOnDemand)
+		_, err = controller.Reconcile(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(getPersisted().SingleOfferings).To(HaveKey("on-demand:Standard_D2s_v3:westus-1"))
+	})
+	It("should not persist without a system namespace", func() {
+		os.Unsetenv("SYSTEM_NAMESPACE")
+		controller = unavailableofferings.NewController(kubernetesInterface, unavailableOfferingsCache)
+		result, err := controller.Reconcile(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.RequeueAfter).To(BeZero())
+		configMaps, err := kubernetesInterface.CoreV1().ConfigMaps("").List(ctx, metav1.ListOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(configMaps.Items).To(BeEmpty())
+	})
+})
@@ -0,0 +1,47 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"context"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/azclient/azapi"
+	"github.com/samber/lo"
+)
+
+type ApplicationSecurityGroupsAPI struct {
+	GetFunc func(ctx context.Context, resourceGroupName string, applicationSecurityGroupName string, options *armnetwork.ApplicationSecurityGroupsClientGetOptions) (armnetwork.ApplicationSecurityGroupsClientGetResponse, error)
+}
+
+var _ azapi.ApplicationSecurityGroupsAPI = &ApplicationSecurityGroupsAPI{}
+
+func (a *ApplicationSecurityGroupsAPI) Get(ctx context.Context, resourceGroupName string, applicationSecurityGroupName string, options *armnetwork.ApplicationSecurityGroupsClientGetOptions) (armnetwork.ApplicationSecurityGroupsClientGetResponse, error) {
+	if a.GetFunc != nil {
+		return a.GetFunc(ctx, resourceGroupName, applicationSecurityGroupName, options)
+	}
+	// Default: return success as if the application security group exists
+	return armnetwork.ApplicationSecurityGroupsClientGetResponse{
+		ApplicationSecurityGroup: armnetwork.ApplicationSecurityGroup{
+			Name: lo.ToPtr(applicationSecurityGroupName),
+		},
+	}, nil
+}
+
+func (a *ApplicationSecurityGroupsAPI) Reset() {
+	a.GetFunc = nil
+}
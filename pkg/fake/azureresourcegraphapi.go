@@ -39,6 +39,7 @@ type AzureResourceGraphBehavior struct {
 	AzureResourceGraphResourcesBehavior MockedFunction[AzureResourceGraphResourcesInput, armresourcegraph.ClientResourcesResponse]
 	VirtualMachinesAPI                  *VirtualMachinesAPI
 	NetworkInterfacesAPI                *NetworkInterfacesAPI
+	PublicIPAddressesAPI                *PublicIPAddressesAPI
 	ResourceGroup                       string
 }
 
@@ -46,18 +47,26 @@ type AzureResourceGraphBehavior struct {
 var _ azapi.AzureResourceGraphAPI = &AzureResourceGraphAPI{}
 
 type AzureResourceGraphAPI struct {
-	vmListQuery  string
-	nicListQuery string
+	vmListQuery       string
+	nicListQuery      string
+	publicIPListQuery string
 	AzureResourceGraphBehavior
 }
 
-func NewAzureResourceGraphAPI(resourceGroup string, virtualMachinesAPI *VirtualMachinesAPI, networkInterfacesAPI *NetworkInterfacesAPI) *AzureResourceGraphAPI {
+func NewAzureResourceGraphAPI(
+	resourceGroup string,
+	virtualMachinesAPI *VirtualMachinesAPI,
+	networkInterfacesAPI *NetworkInterfacesAPI,
+	publicIPAddressesAPI *PublicIPAddressesAPI,
+) *AzureResourceGraphAPI {
 	return &AzureResourceGraphAPI{
-		vmListQuery:  instance.GetVMListQueryBuilder(resourceGroup).String(),
-		nicListQuery: instance.GetNICListQueryBuilder(resourceGroup).String(),
+		vmListQuery:       instance.GetVMListQueryBuilder(resourceGroup).String(),
+		nicListQuery:      instance.GetNICListQueryBuilder(resourceGroup).String(),
+		publicIPListQuery: instance.GetPublicIPListQueryBuilder(resourceGroup).String(),
 		AzureResourceGraphBehavior: AzureResourceGraphBehavior{
 			VirtualMachinesAPI:   virtualMachinesAPI,
 			NetworkInterfacesAPI: networkInterfacesAPI,
+			PublicIPAddressesAPI: publicIPAddressesAPI,
 			ResourceGroup:        resourceGroup,
 		},
 	}
@@ -104,6 +113,16 @@ func (c *AzureResourceGraphAPI) getResourceList(query string) []interface{} {
 			return convertBytesToInterface(b)
 		})
 		return resourceList
+	case c.publicIPListQuery:
+		publicIPList := lo.Filter(c.loadPublicIPObjects(), func(publicIP armnetwork.PublicIPAddress, _ int) bool {
+			return publicIP.Tags != nil && publicIP.Tags[launchtemplate.NodePoolTagKey] != nil &&
+				publicIP.Tags[launchtemplate.KarpenterAKSMachineNodeClaimTagKey] == nil
+		})
+		resourceList := lo.Map(publicIPList, func(publicIP armnetwork.PublicIPAddress, _ int) interface{} {
+			b, _ := json.Marshal(publicIP)
+			return convertBytesToInterface(b)
+		})
+		return resourceList
 	}
 	return nil
 }
@@ -124,6 +143,14 @@ func (c *AzureResourceGraphAPI) loadNicObjects() (nicList []armnetwork.Interface
 	return nicList
 }
 
+func (c *AzureResourceGraphAPI) loadPublicIPObjects() (publicIPList []armnetwork.PublicIPAddress) {
+	c.PublicIPAddressesAPI.PublicIPAddresses.Range(func(k string, v armnetwork.PublicIPAddress) bool {
+		publicIPList = append(publicIPList, v)
+		return true
+	})
+	return publicIPList
+}
+
 func convertBytesToInterface(b []byte) interface{} {
 	jsonObj := instance.Resource{}
 	_ = json.Unmarshal(b, &jsonObj)
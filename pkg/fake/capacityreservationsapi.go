@@ -0,0 +1,87 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v7"
+	"github.com/samber/lo"
+
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/capacityreservation"
+)
+
+// CapacityReservationsAPI is a fake capacity reservations API, serving the reservations of a single capacity reservation group.
+// Reservations are returned by Get as they are stored, so tests can set their utilization through their instance view.
+type CapacityReservationsAPI struct {
+	CapacityReservations AtomicPtrSlice[armcompute.CapacityReservation]
+	Error                error
+}
+
+// assert that the fake implements the interface
+var _ capacityreservation.API = &CapacityReservationsAPI{}
+
+func (c *CapacityReservationsAPI) NewListByCapacityReservationGroupPager(_ string, _ string, _ *armcompute.CapacityReservationsClientListByCapacityReservationGroupOptions) *runtime.Pager[armcompute.CapacityReservationsClientListByCapacityReservationGroupResponse] {
+	pagingHandler := runtime.PagingHandler[armcompute.CapacityReservationsClientListByCapacityReservationGroupResponse]{
+		More: func(page armcompute.CapacityReservationsClientListByCapacityReservationGroupResponse) bool {
+			return false
+		},
+		Fetcher: func(ctx context.Context, _ *armcompute.CapacityReservationsClientListByCapacityReservationGroupResponse) (armcompute.CapacityReservationsClientListByCapacityReservationGroupResponse, error) {
+			if c.Error != nil {
+				return armcompute.CapacityReservationsClientListByCapacityReservationGroupResponse{}, c.Error
+			}
+			return armcompute.CapacityReservationsClientListByCapacityReservationGroupResponse{
+				CapacityReservationListResult: armcompute.CapacityReservationListResult{
+					Value: c.list(),
+				},
+			}, nil
+		},
+	}
+	return runtime.NewPager(pagingHandler)
+}
+
+func (c *CapacityReservationsAPI) Get(_ context.Context, _ string, _ string, capacityReservationName string, _ *armcompute.CapacityReservationsClientGetOptions) (armcompute.CapacityReservationsClientGetResponse, error) {
+	if c.Error != nil {
+		return armcompute.CapacityReservationsClientGetResponse{}, c.Error
+	}
+	capacityReservation, ok := lo.Find(c.list(), func(capacityReservation *armcompute.CapacityReservation) bool {
+		return lo.FromPtr(capacityReservation.Name) == capacityReservationName
+	})
+	if !ok {
+		return armcompute.CapacityReservationsClientGetResponse{}, &azcore.ResponseError{ErrorCode: "ResourceNotFound", StatusCode: http.StatusNotFound}
+	}
+	return armcompute.CapacityReservationsClientGetResponse{CapacityReservation: *capacityReservation}, nil
+}
+
+func (c *CapacityReservationsAPI) list() []*armcompute.CapacityReservation {
+	capacityReservations := make([]*armcompute.CapacityReservation, 0, c.CapacityReservations.Len())
+	for i := range c.CapacityReservations.Len() {
+		capacityReservations = append(capacityReservations, c.CapacityReservations.Get(i))
+	}
+	return capacityReservations
+}
+
+func (c *CapacityReservationsAPI) Reset() {
+	if c == nil {
+		return
+	}
+	c.CapacityReservations.Reset()
+	c.Error = nil
+}
@@ -0,0 +1,86 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v7"
+	"github.com/samber/lo"
+
+	imagefamilytypes "github.com/Azure/karpenter-provider-azure/pkg/providers/imagefamily/types"
+)
+
+const galleryImageIDFormat = "/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Compute/galleries/%s/images/%s"
+
+var GalleryImagesAPIErrorFromImageNotFound = &azcore.ResponseError{
+	ErrorCode:  "ResourceNotFound",
+	StatusCode: http.StatusNotFound,
+}
+
+// GalleryImagesAPI is a fake for user-owned Shared Image Galleries.
+// Images and ImageVersions are matched on their IDs, so they must be set.
+type GalleryImagesAPI struct {
+	Images        AtomicPtrSlice[armcompute.GalleryImage]
+	ImageVersions AtomicPtrSlice[armcompute.GalleryImageVersion]
+	Error         AtomicError
+}
+
+// assert that the fake implements the interface
+var _ imagefamilytypes.GalleryImagesAPI = &GalleryImagesAPI{}
+
+func (c *GalleryImagesAPI) GetImage(_ context.Context, subscriptionID, resourceGroupName, galleryName, galleryImageName string) (*armcompute.GalleryImage, error) {
+	if err := c.Error.Get(); err != nil {
+		return nil, err
+	}
+	id := fmt.Sprintf(galleryImageIDFormat, subscriptionID, resourceGroupName, galleryName, galleryImageName)
+	for i := 0; i < c.Images.Len(); i++ {
+		image := c.Images.Get(i)
+		if strings.EqualFold(lo.FromPtr(image.ID), id) {
+			return image, nil
+		}
+	}
+	return nil, GalleryImagesAPIErrorFromImageNotFound
+}
+
+func (c *GalleryImagesAPI) ListImageVersions(_ context.Context, subscriptionID, resourceGroupName, galleryName, galleryImageName string) ([]*armcompute.GalleryImageVersion, error) {
+	if err := c.Error.Get(); err != nil {
+		return nil, err
+	}
+	prefix := strings.ToLower(fmt.Sprintf(galleryImageIDFormat, subscriptionID, resourceGroupName, galleryName, galleryImageName) + "/versions/")
+	var versions []*armcompute.GalleryImageVersion
+	for i := 0; i < c.ImageVersions.Len(); i++ {
+		version := c.ImageVersions.Get(i)
+		if strings.HasPrefix(strings.ToLower(lo.FromPtr(version.ID)), prefix) {
+			versions = append(versions, version)
+		}
+	}
+	return versions, nil
+}
+
+func (c *GalleryImagesAPI) Reset() {
+	if c == nil {
+		return
+	}
+	c.Images.Reset()
+	c.ImageVersions.Reset()
+	c.Error.Reset()
+}
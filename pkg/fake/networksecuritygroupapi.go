@@ -19,8 +19,10 @@ package fake
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"sort"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork"
 	fakesync "github.com/Azure/karpenter-provider-azure/pkg/fake/sync"
@@ -54,7 +56,7 @@ func (api *NetworkSecurityGroupAPI) Get(
 	id := MakeNetworkSecurityGroupID(resourceGroupName, securityGroupName)
 	nsg, ok := api.NSGs.Load(id)
 	if !ok {
-		return armnetwork.SecurityGroupsClientGetResponse{}, fmt.Errorf("not found")
+		return armnetwork.SecurityGroupsClientGetResponse{}, &azcore.ResponseError{StatusCode: http.StatusNotFound}
 	}
 	return armnetwork.SecurityGroupsClientGetResponse{
 		SecurityGroup: nsg,
@@ -0,0 +1,111 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/samber/lo"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork"
+	fakesync "github.com/Azure/karpenter-provider-azure/pkg/fake/sync"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/azclient/azapi"
+)
+
+type PublicIPAddressCreateOrUpdateInput struct {
+	ResourceGroupName   string
+	PublicIPAddressName string
+	PublicIPAddress     armnetwork.PublicIPAddress
+	Options             *armnetwork.PublicIPAddressesClientBeginCreateOrUpdateOptions
+}
+
+type PublicIPAddressDeleteInput struct {
+	ResourceGroupName, PublicIPAddressName string
+}
+
+type PublicIPAddressesBehavior struct {
+	PublicIPAddressesCreateOrUpdateBehavior MockedLRO[PublicIPAddressCreateOrUpdateInput, armnetwork.PublicIPAddressesClientCreateOrUpdateResponse]
+	PublicIPAddressesDeleteBehavior         MockedLRO[PublicIPAddressDeleteInput, armnetwork.PublicIPAddressesClientDeleteResponse]
+	PublicIPAddresses                       fakesync.Map[string, armnetwork.PublicIPAddress]
+}
+
+// assert that the fake implements the interface
+var _ azapi.PublicIPAddressesAPI = &PublicIPAddressesAPI{}
+
+type PublicIPAddressesAPI struct {
+	PublicIPAddressesBehavior
+}
+
+// Reset must be called between tests otherwise tests will pollute each other.
+func (c *PublicIPAddressesAPI) Reset() {
+	c.PublicIPAddressesCreateOrUpdateBehavior.Reset()
+	c.PublicIPAddressesDeleteBehavior.Reset()
+	c.PublicIPAddresses.Clear()
+}
+
+func (c *PublicIPAddressesAPI) BeginCreateOrUpdate(_ context.Context, resourceGroupName string, publicIPAddressName string, publicIPAddress armnetwork.PublicIPAddress, options *armnetwork.PublicIPAddressesClientBeginCreateOrUpdateOptions) (*runtime.Poller[armnetwork.PublicIPAddressesClientCreateOrUpdateResponse], error) {
+	input := &PublicIPAddressCreateOrUpdateInput{
+		ResourceGroupName:   resourceGroupName,
+		PublicIPAddressName: publicIPAddressName,
+		PublicIPAddress:     publicIPAddress,
+		Options:             options,
+	}
+
+	return c.PublicIPAddressesCreateOrUpdateBehavior.Invoke(input, func(input *PublicIPAddressCreateOrUpdateInput) (*armnetwork.PublicIPAddressesClientCreateOrUpdateResponse, error) {
+		publicIPAddress := input.PublicIPAddress
+		publicIPAddress.Name = lo.ToPtr(input.PublicIPAddressName)
+		id := MakePublicIPAddressID(input.ResourceGroupName, input.PublicIPAddressName)
+		publicIPAddress.ID = lo.ToPtr(id)
+		c.PublicIPAddresses.Store(id, publicIPAddress)
+		return &armnetwork.PublicIPAddressesClientCreateOrUpdateResponse{
+			PublicIPAddress: publicIPAddress,
+		}, nil
+	})
+}
+
+func (c *PublicIPAddressesAPI) Get(_ context.Context, resourceGroupName string, publicIPAddressName string, _ *armnetwork.PublicIPAddressesClientGetOptions) (armnetwork.PublicIPAddressesClientGetResponse, error) {
+	id := MakePublicIPAddressID(resourceGroupName, publicIPAddressName)
+	publicIPAddress, ok := c.PublicIPAddresses.Load(id)
+	if !ok {
+		return armnetwork.PublicIPAddressesClientGetResponse{}, &azcore.ResponseError{StatusCode: http.StatusNotFound}
+	}
+	return armnetwork.PublicIPAddressesClientGetResponse{
+		PublicIPAddress: publicIPAddress,
+	}, nil
+}
+
+func (c *PublicIPAddressesAPI) BeginDelete(_ context.Context, resourceGroupName string, publicIPAddressName string, _ *armnetwork.PublicIPAddressesClientBeginDeleteOptions) (*runtime.Poller[armnetwork.PublicIPAddressesClientDeleteResponse], error) {
+	input := &PublicIPAddressDeleteInput{
+		ResourceGroupName:   resourceGroupName,
+		PublicIPAddressName: publicIPAddressName,
+	}
+	return c.PublicIPAddressesDeleteBehavior.Invoke(input, func(input *PublicIPAddressDeleteInput) (*armnetwork.PublicIPAddressesClientDeleteResponse, error) {
+		id := MakePublicIPAddressID(input.ResourceGroupName, input.PublicIPAddressName)
+		c.PublicIPAddresses.Delete(id)
+		return &armnetwork.PublicIPAddressesClientDeleteResponse{}, nil
+	})
+}
+
+func MakePublicIPAddressID(resourceGroupName, publicIPAddressName string) string {
+	const subscriptionID = "subscriptionID" // not important for fake
+	const idFormat = "/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Network/publicIPAddresses/%s"
+	return fmt.Sprintf(idFormat, subscriptionID, resourceGroupName, publicIPAddressName)
+}
@@ -0,0 +1,95 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	fakesync "github.com/Azure/karpenter-provider-azure/pkg/fake/sync"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/quota"
+)
+
+type RequestIncreaseInput struct {
+	Name  string
+	Limit int64
+}
+
+type GetQuotaRequestInput struct {
+	ID string
+}
+
+type QuotaRequestsAPIBehavior struct {
+	RequestIncreaseBehavior MockedFunction[RequestIncreaseInput, quota.Request]
+	GetRequestBehavior      MockedFunction[GetQuotaRequestInput, quota.Request]
+	// Requests are the submitted quota increase requests, by ID. Tests can update them to simulate their progress.
+	Requests fakesync.Map[string, quota.Request]
+}
+
+// assert that the fake implements the interface
+var _ quota.RequestsAPI = &QuotaRequestsAPI{}
+
+// QuotaRequestsAPI is a fake Microsoft.Quota API. Quota increase requests are accepted and stay in progress,
+// until tests update them in Requests.
+type QuotaRequestsAPI struct {
+	QuotaRequestsAPIBehavior
+	nextID atomic.Int64
+}
+
+func (api *QuotaRequestsAPI) RequestIncrease(_ context.Context, name string, limit int64) (*quota.Request, error) {
+	input := &RequestIncreaseInput{
+		Name:  name,
+		Limit: limit,
+	}
+	request, err := api.RequestIncreaseBehavior.Invoke(input, func(input *RequestIncreaseInput) (quota.Request, error) {
+		request := quota.Request{
+			ID:    fmt.Sprintf("%d", api.nextID.Add(1)),
+			State: quota.RequestStateAccepted,
+		}
+		api.Requests.Store(request.ID, request)
+		return request, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+func (api *QuotaRequestsAPI) GetRequest(_ context.Context, id string) (*quota.Request, error) {
+	input := &GetQuotaRequestInput{
+		ID: id,
+	}
+	request, err := api.GetRequestBehavior.Invoke(input, func(input *GetQuotaRequestInput) (quota.Request, error) {
+		request, ok := api.Requests.Load(input.ID)
+		if !ok {
+			return quota.Request{}, fmt.Errorf("quota request %s not found", input.ID)
+		}
+		return request, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+func (api *QuotaRequestsAPI) Reset() {
+	api.RequestIncreaseBehavior.Reset()
+	api.GetRequestBehavior.Reset()
+	api.Requests.Clear()
+	api.nextID.Store(0)
+}
@@ -18,14 +18,23 @@ package fake
 
 import (
 	"context"
+	"net/http"
+	"sort"
+	"strings"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork"
+	fakesync "github.com/Azure/karpenter-provider-azure/pkg/fake/sync"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/azclient/azapi"
+	"github.com/Azure/karpenter-provider-azure/pkg/utils"
 	"github.com/samber/lo"
 )
 
 type SubnetsAPI struct {
 	GetFunc func(ctx context.Context, resourceGroupName string, virtualNetworkName string, subnetName string, options *armnetwork.SubnetsClientGetOptions) (armnetwork.SubnetsClientGetResponse, error)
+	// Subnets are the subnets that can be listed, keyed by ID. When a VNet has none, Get returns a default subnet.
+	Subnets fakesync.Map[string, armnetwork.Subnet]
 }
 
 var _ azapi.SubnetsAPI = &SubnetsAPI{}
@@ -34,6 +43,14 @@ func (s *SubnetsAPI) Get(ctx context.Context, resourceGroupName string, virtualN
 	if s.GetFunc != nil {
 		return s.GetFunc(ctx, resourceGroupName, virtualNetworkName, subnetName, options)
 	}
+	subnets := s.list(resourceGroupName, virtualNetworkName)
+	if len(subnets) > 0 {
+		subnet, ok := lo.Find(subnets, func(subnet *armnetwork.Subnet) bool { return strings.EqualFold(lo.FromPtr(subnet.Name), subnetName) })
+		if !ok {
+			return armnetwork.SubnetsClientGetResponse{}, &azcore.ResponseError{ErrorCode: "NotFound", StatusCode: http.StatusNotFound}
+		}
+		return armnetwork.SubnetsClientGetResponse{Subnet: *subnet}, nil
+	}
 	return armnetwork.SubnetsClientGetResponse{
 		Subnet: armnetwork.Subnet{
 			Properties: &armnetwork.SubnetPropertiesFormat{
@@ -43,6 +60,40 @@ func (s *SubnetsAPI) Get(ctx context.Context, resourceGroupName string, virtualN
 	}, nil
 }
 
+func (s *SubnetsAPI) NewListPager(resourceGroupName string, virtualNetworkName string, _ *armnetwork.SubnetsClientListOptions) *runtime.Pager[armnetwork.SubnetsClientListResponse] {
+	return runtime.NewPager(runtime.PagingHandler[armnetwork.SubnetsClientListResponse]{
+		More: func(page armnetwork.SubnetsClientListResponse) bool {
+			return false
+		},
+		Fetcher: func(ctx context.Context, _ *armnetwork.SubnetsClientListResponse) (armnetwork.SubnetsClientListResponse, error) {
+			return armnetwork.SubnetsClientListResponse{
+				SubnetListResult: armnetwork.SubnetListResult{
+					Value: s.list(resourceGroupName, virtualNetworkName),
+				},
+			}, nil
+		},
+	})
+}
+
+// list returns the subnets of the VNet, sorted by ID so that we have a stable base to write asserts upon
+func (s *SubnetsAPI) list(resourceGroupName string, virtualNetworkName string) []*armnetwork.Subnet {
+	var subnets []*armnetwork.Subnet
+	s.Subnets.Range(func(id string, subnet armnetwork.Subnet) bool {
+		components, err := utils.GetVnetSubnetIDComponents(id)
+		if err == nil && strings.EqualFold(components.ResourceGroupName, resourceGroupName) && strings.EqualFold(components.VNetName, virtualNetworkName) {
+			subnet.ID = lo.ToPtr(id)
+			subnet.Name = lo.ToPtr(components.SubnetName)
+			subnets = append(subnets, &subnet)
+		}
+		return true
+	})
+	sort.Slice(subnets, func(i, j int) bool {
+		return lo.FromPtr(subnets[i].ID) < lo.FromPtr(subnets[j].ID)
+	})
+	return subnets
+}
+
 func (s *SubnetsAPI) Reset() {
 	s.GetFunc = nil
+	s.Subnets.Clear()
 }
@@ -31,4 +31,7 @@ const (
 	CapacityTypeLabel = "capacity_type"
 	NodePoolLabel     = "nodepool"
 	PhaseLabel        = "phase"
+	NodeClassLabel    = "nodeclass"
+	SKUFamilyLabel    = "sku_family"
+	SubnetLabel       = "subnet"
 )
@@ -57,7 +57,9 @@ import (
 	"github.com/Azure/karpenter-provider-azure/pkg/consts"
 	"github.com/Azure/karpenter-provider-azure/pkg/operator/options"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/allocationstrategy"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/allocationstrategy/stages"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/azclient"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/capacityreservation"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/imagefamily"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/instance"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/instance/machinecache"
@@ -68,6 +70,7 @@ import (
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/networksecuritygroup"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/pricing"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/quota"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/subnetcapacity"
 	"github.com/Azure/karpenter-provider-azure/pkg/utils"
 	armopts "github.com/Azure/karpenter-provider-azure/pkg/utils/clientopts"
 	"github.com/Azure/karpenter-provider-azure/pkg/utils/zones"
@@ -91,18 +94,23 @@ type Operator struct {
 	ManagedDynamicInterface dynamic.Interface
 
 	UnavailableOfferingsCache *azurecache.UnavailableOfferings
-
-	KubernetesVersionProvider kubernetesversion.KubernetesVersionProvider
-	ImageProvider             imagefamily.NodeImageProvider
-	ImageResolver             imagefamily.Resolver
-	LaunchTemplateProvider    *launchtemplate.Provider
-	PricingProvider           *pricing.Provider
-	InstanceTypesProvider     instancetype.Provider
-	VMInstanceProvider        *instance.DefaultVMProvider
-	AKSMachineProvider        *instance.DefaultAKSMachineProvider
-	LoadBalancerProvider      *loadbalancer.Provider
-	QuotaProvider             *quota.DefaultProvider
-	AZClient                  *azclient.AZClient
+	SpotEvictionsCache        *azurecache.SpotEvictions
+	MemoryOverheadsCache      *azurecache.MemoryOverheads
+	SpotPriceHistoryCache     *azurecache.SpotPriceHistory
+
+	KubernetesVersionProvider   kubernetesversion.KubernetesVersionProvider
+	ImageProvider               imagefamily.NodeImageProvider
+	ImageResolver               imagefamily.Resolver
+	LaunchTemplateProvider      *launchtemplate.Provider
+	PricingProvider             *pricing.Provider
+	InstanceTypesProvider       instancetype.Provider
+	VMInstanceProvider          *instance.DefaultVMProvider
+	AKSMachineProvider          *instance.DefaultAKSMachineProvider
+	LoadBalancerProvider        *loadbalancer.Provider
+	QuotaProvider               *quota.DefaultProvider
+	CapacityReservationProvider *capacityreservation.Provider
+	SubnetCapacityProvider      *subnetcapacity.Provider
+	AZClient                    *azclient.AZClient
 }
 
 func kubeDNSIP(ctx context.Context, kubernetesInterface kubernetes.Interface) (net.IP, error) {
@@ -171,12 +179,25 @@ func NewOperator(ctx context.Context, operator *operator.Operator) (context.Cont
 	}
 
 	unavailableOfferingsCache := azurecache.NewUnavailableOfferings()
+	spotEvictionsCache := azurecache.NewSpotEvictions(operator.Clock)
+	memoryOverheadsCache := azurecache.NewMemoryOverheads()
+	spotPriceHistoryCache := azurecache.NewSpotPriceHistory()
+	var pricingOptions []pricing.Option
+	if options.FromContext(ctx).OfflinePricing {
+		var prices *pricing.PriceFile
+		if pricingFile := options.FromContext(ctx).PricingFile; pricingFile != "" {
+			prices, err = pricing.LoadPriceFile(pricingFile)
+			lo.Must0(err, "loading price file")
+		}
+		pricingOptions = append(pricingOptions, pricing.WithOfflinePricing(prices))
+	}
 	pricingProvider := pricing.NewProvider(
 		ctx,
 		env,
 		pricing.NewAPI(env.Cloud),
 		azConfig.Location,
 		operator.Elected(),
+		pricingOptions...,
 	)
 
 	kubernetesVersionProvider := kubernetesversion.NewKubernetesVersionProvider(
@@ -189,6 +210,7 @@ func NewOperator(ctx context.Context, operator *operator.Operator) (context.Cont
 		azConfig.Location,
 		azConfig.SubscriptionID,
 		azClient.NodeImageVersionsClient,
+		azClient.GalleryImagesClient,
 		cache.New(imagefamily.ImageExpirationInterval,
 			imagefamily.ImageCacheCleaningInterval),
 	)
@@ -200,6 +222,7 @@ func NewOperator(ctx context.Context, operator *operator.Operator) (context.Cont
 		pricingProvider,
 		unavailableOfferingsCache,
 		quotaProvider,
+		memoryOverheadsCache,
 	)
 
 	// Ensure we're able to hydrate instance types before starting any controllers
@@ -231,13 +254,20 @@ func NewOperator(ctx context.Context, operator *operator.Operator) (context.Cont
 		options.FromContext(ctx).NodeResourceGroup,
 		azConfig.Location,
 		options.FromContext(ctx).ProvisionMode,
+		env,
 	)
 	loadBalancerProvider := loadbalancer.NewProvider(
 		azClient.LoadBalancersClient,
 		cache.New(loadbalancer.LoadBalancersCacheTTL, azurecache.DefaultCleanupInterval),
 		options.FromContext(ctx).NodeResourceGroup,
 	)
-	allocationStrategyProvider := allocationstrategy.NewProvider()
+	capacityReservationProvider := capacityreservation.NewProvider(azClient.CapacityReservationsClient, azConfig.Location)
+	subnetCapacityProvider := subnetcapacity.NewProvider(azClient.SubnetsClient())
+	var spotPriceTrends stages.SpotPriceTrends
+	if options.FromContext(ctx).AvoidRisingSpotPrices {
+		spotPriceTrends = spotPriceHistoryCache
+	}
+	allocationStrategyProvider := allocationstrategy.NewProvider(operator.GetClient(), unavailableOfferingsCache, spotEvictionsCache, spotPriceTrends)
 	vmInstanceProvider := instance.NewDefaultVMProvider(
 		azClient,
 		instanceTypeProvider,
@@ -246,6 +276,8 @@ func NewOperator(ctx context.Context, operator *operator.Operator) (context.Cont
 		loadBalancerProvider,
 		networkSecurityGroupProvider,
 		unavailableOfferingsCache,
+		capacityReservationProvider,
+		subnetCapacityProvider,
 		azConfig.Location,
 		options.FromContext(ctx).NodeResourceGroup,
 		azConfig.SubscriptionID,
@@ -275,6 +307,7 @@ func NewOperator(ctx context.Context, operator *operator.Operator) (context.Cont
 		azConfig.Location,
 		options.FromContext(ctx).ProvisionMode == consts.ProvisionModeAKSMachineAPIHeaderBatch,
 		aksMachineCache,
+		subnetCapacityProvider,
 	)
 
 	return ctx, &Operator{
@@ -282,6 +315,9 @@ func NewOperator(ctx context.Context, operator *operator.Operator) (context.Cont
 		InClusterKubernetesInterface: inClusterClient,
 		ManagedDynamicInterface:      managedDynamicClient,
 		UnavailableOfferingsCache:    unavailableOfferingsCache,
+		SpotEvictionsCache:           spotEvictionsCache,
+		MemoryOverheadsCache:         memoryOverheadsCache,
+		SpotPriceHistoryCache:        spotPriceHistoryCache,
 		KubernetesVersionProvider:    kubernetesVersionProvider,
 		ImageProvider:                imageProvider,
 		ImageResolver:                imageResolver,
@@ -292,6 +328,8 @@ func NewOperator(ctx context.Context, operator *operator.Operator) (context.Cont
 		AKSMachineProvider:           aksMachineInstanceProvider,
 		LoadBalancerProvider:         loadBalancerProvider,
 		QuotaProvider:                quotaProvider,
+		CapacityReservationProvider:  capacityReservationProvider,
+		SubnetCapacityProvider:       subnetCapacityProvider,
 		AZClient:                     azClient,
 	}
 }
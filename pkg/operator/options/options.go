@@ -75,6 +75,8 @@ type Options struct {
 	NetworkPluginMode string `json:"networkPluginMode,omitempty"` // => Network Plugin Mode is used to control the mode the network plugin should operate in. For example, "overlay" used with --network-plugin=azure will use an overlay network (non-VNET IPs) for pods in the cluster. Learn more about overlay networking here: https://learn.microsoft.com/en-us/azure/aks/azure-cni-overlay?tabs=kubectl#overview-of-overlay-networking
 	NetworkDataplane  string `json:"networkDataplane,omitempty"`
 	DNSServiceIP      string `json:"dnsServiceIP,omitempty"`
+	// If set to true, nodes get IPv6 addresses in addition to IPv4 ones, and join the IPv6 load balancer backend pools. Only supported with Azure CNI Overlay and network plugin none.
+	IPv6DualStackEnabled bool `json:"ipv6DualStackEnabled,omitempty"` // => IPv6DualStackEnabled in bootstrap
 
 	NodeIdentities          []string `json:"nodeIdentities,omitempty"`          // => Applied onto each VM
 	KubeletIdentityClientID string   `json:"kubeletIdentityClientID,omitempty"` // => Flows to bootstrap and used in drift
@@ -100,6 +102,23 @@ type Options struct {
 	ProviderBatchMaxDuration  time.Duration `json:"providerBatchMaxDuration,omitempty"`  // Maximum duration for provider batch accumulation (default 5s). Only used on provision mode aksmachineapiheaderbatch.
 	ProviderBatchMaxSize      int           `json:"providerBatchMaxSize,omitempty"`      // Maximum number of machines per provider batch (default 50, AKS API limit). Only used on provision mode aksmachineapiheaderbatch.
 
+	// If set to true, unavailable offerings are persisted to a ConfigMap in the system namespace, so that they survive controller restarts and leader failovers.
+	PersistUnavailableOfferings bool `json:"persistUnavailableOfferings,omitempty"`
+
+	// The vCPU limit up to which Karpenter requests quota increases for VM families that stay near their quota limit. 0 disables quota increase requests.
+	QuotaIncreaseMaxLimit int `json:"quotaIncreaseMaxLimit,omitempty"`
+
+	// The name of a ConfigMap, in the system namespace, holding overrides of the retail prices (e.g. negotiated discounts, Reserved Instance and Savings Plan coverage). Empty disables pricing overrides.
+	PricingOverridesConfigMap string `json:"pricingOverridesConfigMap,omitempty"`
+
+	// If set to true, prices are never retrieved from the Azure retail prices API, they come from PricingFile or the static pricing data instead, e.g. in air-gapped clusters.
+	OfflinePricing bool `json:"offlinePricing,omitempty"`
+	// The path of a JSON file holding the prices to use with offline pricing, instead of the static pricing data.
+	PricingFile string `json:"pricingFile,omitempty"`
+
+	// If set to true, spot offerings of instance types whose spot price is trending toward their on-demand price are avoided.
+	AvoidRisingSpotPrices bool `json:"avoidRisingSpotPrices,omitempty"`
+
 	// computed options; do not set.
 	ParsedDiskEncryptionSetID *arm.ResourceID `json:"-"`
 }
@@ -115,6 +134,7 @@ func (o *Options) AddFlags(fs *coreoptions.FlagSet) {
 	fs.StringVar(&o.DNSServiceIP, "dns-service-ip", env.WithDefaultString("DNS_SERVICE_IP", ""), "The IP address of cluster DNS service.")
 	fs.StringVar(&o.NetworkPluginMode, "network-plugin-mode", env.WithDefaultString("NETWORK_PLUGIN_MODE", consts.NetworkPluginModeOverlay), "network plugin mode of the cluster.")
 	fs.StringVar(&o.NetworkPolicy, "network-policy", env.WithDefaultString("NETWORK_POLICY", ""), "The network policy used by the cluster.")
+	fs.BoolVar(&o.IPv6DualStackEnabled, "ipv6-dual-stack-enabled", env.WithDefaultBool("IPV6_DUAL_STACK_ENABLED", false), "If set to true, nodes are given IPv6 addresses in addition to IPv4 ones, for clusters with IPv4/IPv6 dual-stack networking. Not supported with the AKS machine API provision modes.")
 	fs.StringVar(&o.NetworkDataplane, "network-dataplane", env.WithDefaultString("NETWORK_DATAPLANE", "cilium"), "The network dataplane used by the cluster.")
 	fs.StringVar(&o.VnetGUID, "vnet-guid", env.WithDefaultString("VNET_GUID", ""), "The vnet guid of the clusters vnet, only required by azure cni with overlay + byo vnet")
 	fs.StringVar(&o.SubnetID, "vnet-subnet-id", env.WithDefaultString("VNET_SUBNET_ID", ""), "[REQUIRED] The default subnet ID to use for new nodes. This must be a valid ARM resource ID for subnet that does not overlap with the service CIDR or the pod CIDR.")
@@ -140,6 +160,12 @@ func (o *Options) AddFlags(fs *coreoptions.FlagSet) {
 	// See https://github.com/Azure/karpenter-provider-azure/issues/1042 for issue discussing improvements around this
 	fs.Var(additionalTagsFlag, "additional-tags", "Additional tags to apply to the resources in Azure. Format is key1=value1,key2=value2. These tags will be merged with the tags specified on the NodePool. In the case of a tag collision, the NodePool tag wins. These tags only apply to new nodes and do not trigger drift, which means that adding tags to this collection will not update existing nodes until drift triggers for some other reason.")
 	fs.BoolVar(&o.EnableAzureSDKLogging, "enable-azure-sdk-logging", env.WithDefaultBool("ENABLE_AZURE_SDK_LOGGING", true), "If set to false then Azure SDK middleware logging is disabled for debugging, and won't be logging all HTTP requests/responses to Azure APIs.")
+	fs.BoolVar(&o.PersistUnavailableOfferings, "persist-unavailable-offerings", env.WithDefaultBool("PERSIST_UNAVAILABLE_OFFERINGS", false), "If set to true, offerings marked as unavailable (e.g. due to allocation failures or insufficient quota) are persisted to a ConfigMap in the system namespace along with their remaining TTLs, and restored after controller restarts and leader failovers.")
+	fs.IntVar(&o.QuotaIncreaseMaxLimit, "quota-increase-max-limit", env.WithDefaultInt("QUOTA_INCREASE_MAX_LIMIT", 0), "The vCPU limit up to which Karpenter automatically requests quota increases, through the Microsoft.Quota API, for VM families that stay near their quota limit. Requires the Quota Request Operator role. 0 disables quota increase requests.")
+	fs.StringVar(&o.PricingOverridesConfigMap, "pricing-overrides-configmap", env.WithDefaultString("PRICING_OVERRIDES_CONFIGMAP", ""), "The name of a ConfigMap, in the system namespace, holding overrides of the Azure retail prices, such as negotiated discounts, absolute prices, and Reserved Instance and Savings Plan coverage, per instance type, VM family or by default. The overridden prices are used to rank offerings and for consolidation. Empty disables pricing overrides.")
+	fs.BoolVar(&o.OfflinePricing, "offline-pricing", env.WithDefaultBool("OFFLINE_PRICING", false), "If set to true, prices are never retrieved from the Azure retail prices API, they come from the pricing file, or the static pricing data embedded in Karpenter when there is none. For clusters that can't reach the API, e.g. air-gapped ones.")
+	fs.StringVar(&o.PricingFile, "pricing-file", env.WithDefaultString("PRICING_FILE", ""), "The path of a JSON file holding the on-demand and spot prices of the region, used instead of the static pricing data with offline pricing. Requires offline-pricing.")
+	fs.BoolVar(&o.AvoidRisingSpotPrices, "avoid-rising-spot-prices", env.WithDefaultBool("AVOID_RISING_SPOT_PRICES", false), "If set to true, the spot offerings of instance types whose spot price is trending toward their on-demand price, according to the spot price history, are avoided when there are other offerings.")
 }
 
 // IsAKSMachineAPIMode returns true if the current provision mode creates instances via the AKS Machine API.
@@ -48,6 +48,8 @@ func (o *Options) Validate() error {
 		o.validateAdditionalTags(),
 		o.validateDiskEncryptionSetID(),
 		o.validateClusterDNSIP(),
+		o.validateQuotaIncreaseMaxLimit(),
+		o.validatePricingFile(),
 		validate.Struct(o),
 	)
 }
@@ -61,6 +63,20 @@ func (o *Options) validateClusterDNSIP() error {
 	return nil
 }
 
+func (o *Options) validateQuotaIncreaseMaxLimit() error {
+	if o.QuotaIncreaseMaxLimit < 0 {
+		return fmt.Errorf("quota-increase-max-limit cannot be negative")
+	}
+	return nil
+}
+
+func (o *Options) validatePricingFile() error {
+	if o.PricingFile != "" && !o.OfflinePricing {
+		return fmt.Errorf("pricing-file requires offline-pricing")
+	}
+	return nil
+}
+
 func (o *Options) validateVNETGUID() error {
 	if o.VnetGUID != "" && uuid.Validate(o.VnetGUID) != nil {
 		return fmt.Errorf("vnet-guid %s is malformed", o.VnetGUID)
@@ -89,6 +105,10 @@ func (o *Options) validateNetworkingOptions() error {
 	if o.NetworkPlugin == consts.NetworkPluginNone && o.NetworkPluginMode != consts.NetworkPluginModeNone {
 		return fmt.Errorf("network-plugin-mode '%s' is invalid when network-plugin is 'none'. network-plugin-mode must be empty", o.NetworkPluginMode)
 	}
+	// Azure CNI without overlay gives pods VNET IPs through secondary ipconfigs, which are IPv4 only
+	if o.IPv6DualStackEnabled && !o.IsAzureCNIOverlay() && !o.IsNetworkPluginNone() {
+		return fmt.Errorf("ipv6-dual-stack-enabled is only supported with network-plugin 'azure' and network-plugin-mode 'overlay', or network-plugin 'none'")
+	}
 	return nil
 }
 
@@ -133,6 +153,10 @@ func (o *Options) validateProvisionMode() error {
 		if !o.UseSIG {
 			return fmt.Errorf("use-sig is required to be true when provision-mode is %s", o.ProvisionMode)
 		}
+		// The AKS machine network profile has no setting for the IP families of the machine
+		if o.IPv6DualStackEnabled {
+			return fmt.Errorf("ipv6-dual-stack-enabled is not supported when provision-mode is %s", o.ProvisionMode)
+		}
 		if o.ProvisionMode == consts.ProvisionModeAKSMachineAPIHeaderBatch {
 			if err := o.validateBatchOptions(); err != nil {
 				return err
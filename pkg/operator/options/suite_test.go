@@ -76,6 +76,13 @@ var _ = Describe("Options", func() {
 		"PROVIDER_BATCH_IDLE_DURATION",
 		"PROVIDER_BATCH_MAX_DURATION",
 		"PROVIDER_BATCH_MAX_SIZE",
+		"PERSIST_UNAVAILABLE_OFFERINGS",
+		"QUOTA_INCREASE_MAX_LIMIT",
+		"PRICING_OVERRIDES_CONFIGMAP",
+		"OFFLINE_PRICING",
+		"PRICING_FILE",
+		"AVOID_RISING_SPOT_PRICES",
+		"IPV6_DUAL_STACK_ENABLED",
 	}
 
 	var fs *coreoptions.FlagSet
@@ -134,6 +141,13 @@ var _ = Describe("Options", func() {
 			os.Setenv("PROVIDER_BATCH_IDLE_DURATION", "1500ms")
 			os.Setenv("PROVIDER_BATCH_MAX_DURATION", "6s")
 			os.Setenv("PROVIDER_BATCH_MAX_SIZE", "42")
+			os.Setenv("PERSIST_UNAVAILABLE_OFFERINGS", "true")
+			os.Setenv("QUOTA_INCREASE_MAX_LIMIT", "400")
+			os.Setenv("PRICING_OVERRIDES_CONFIGMAP", "karpenter-pricing-overrides")
+			os.Setenv("OFFLINE_PRICING", "true")
+			os.Setenv("PRICING_FILE", "/etc/karpenter/prices.json")
+			os.Setenv("AVOID_RISING_SPOT_PRICES", "true")
+			os.Setenv("IPV6_DUAL_STACK_ENABLED", "true")
 			fs = &coreoptions.FlagSet{
 				FlagSet: flag.NewFlagSet("karpenter", flag.ContinueOnError),
 			}
@@ -167,6 +181,13 @@ var _ = Describe("Options", func() {
 				ProviderBatchIdleDuration:      lo.ToPtr(1500 * time.Millisecond),
 				ProviderBatchMaxDuration:       lo.ToPtr(6 * time.Second),
 				ProviderBatchMaxSize:           lo.ToPtr(42),
+				PersistUnavailableOfferings:    lo.ToPtr(true),
+				QuotaIncreaseMaxLimit:          lo.ToPtr(400),
+				PricingOverridesConfigMap:      lo.ToPtr("karpenter-pricing-overrides"),
+				OfflinePricing:                 lo.ToPtr(true),
+				PricingFile:                    lo.ToPtr("/etc/karpenter/prices.json"),
+				AvoidRisingSpotPrices:          lo.ToPtr(true),
+				IPv6DualStackEnabled:           lo.ToPtr(true),
 			})
 			Expect(opts).To(BeComparableTo(expectedOpts, cmpopts.IgnoreUnexported(options.Options{})))
 		})
@@ -364,6 +385,41 @@ var _ = Describe("Options", func() {
 			)
 			Expect(err).To(MatchError(ContainSubstring("vm-memory-overhead-percent cannot be negative")))
 		})
+		It("should fail when quota-increase-max-limit is negative", func() {
+			err := opts.Parse(
+				fs,
+				"--cluster-name", "my-name",
+				"--cluster-endpoint", "https://karpenter-000000000000.hcp.westus2.staging.azmk8s.io",
+				"--kubelet-bootstrap-token", "flag-bootstrap-token",
+				"--ssh-public-key", "flag-ssh-public-key",
+				"--quota-increase-max-limit", "-1",
+			)
+			Expect(err).To(MatchError(ContainSubstring("quota-increase-max-limit cannot be negative")))
+		})
+		It("should fail when pricing-file is set without offline-pricing", func() {
+			err := opts.Parse(
+				fs,
+				"--cluster-name", "my-name",
+				"--cluster-endpoint", "https://karpenter-000000000000.hcp.westus2.staging.azmk8s.io",
+				"--kubelet-bootstrap-token", "flag-bootstrap-token",
+				"--ssh-public-key", "flag-ssh-public-key",
+				"--pricing-file", "/etc/karpenter/prices.json",
+			)
+			Expect(err).To(MatchError(ContainSubstring("pricing-file requires offline-pricing")))
+		})
+		It("should fail when ipv6-dual-stack-enabled is set with Azure CNI without overlay", func() {
+			err := opts.Parse(
+				fs,
+				"--cluster-name", "my-name",
+				"--cluster-endpoint", "https://karpenter-000000000000.hcp.westus2.staging.azmk8s.io",
+				"--kubelet-bootstrap-token", "flag-bootstrap-token",
+				"--ssh-public-key", "flag-ssh-public-key",
+				"--network-plugin", "azure",
+				"--network-plugin-mode", "",
+				"--ipv6-dual-stack-enabled",
+			)
+			Expect(err).To(MatchError(ContainSubstring("ipv6-dual-stack-enabled is only supported with network-plugin 'azure' and network-plugin-mode 'overlay', or network-plugin 'none'")))
+		})
 		It("should fail when network-plugin is empty", func() {
 			errMsg := "network-plugin  is invalid. network-plugin must equal 'azure' or 'none'"
 
@@ -806,6 +862,24 @@ var _ = Describe("Options", func() {
 			Expect(err).ToNot(HaveOccurred())
 		})
 
+		It("should fail when ipv6-dual-stack-enabled is set with provision-mode aksmachineapi", func() {
+			err := opts.Parse(
+				fs,
+				"--cluster-name", "my-name",
+				"--cluster-endpoint", "https://karpenter-000000000000.hcp.westus2.staging.azmk8s.io",
+				"--kubelet-bootstrap-token", "flag-bootstrap-token",
+				"--ssh-public-key", "flag-ssh-public-key",
+				"--vnet-subnet-id", "/subscriptions/12345678-1234-1234-1234-123456789012/resourceGroups/sillygeese/providers/Microsoft.Network/virtualNetworks/karpentervnet/subnets/karpentersub",
+				"--node-resource-group", "my-node-rg",
+				"--provision-mode", "aksmachineapi",
+				"--aks-machines-pool-name", "testmpool",
+				"--use-sig",
+				"--sig-subscription-id", "92345678-1234-1234-1234-123456789012",
+				"--ipv6-dual-stack-enabled",
+			)
+			Expect(err).To(MatchError(ContainSubstring("ipv6-dual-stack-enabled is not supported when provision-mode is aksmachineapi")))
+		})
+
 		It("should succeed with provision-mode other than aksmachineapi", func() {
 			err := opts.Parse(
 				fs,
@@ -55,10 +55,11 @@ var _ = AfterSuite(func() {
 var _ = Describe("getRequiredGVKs", func() {
 	It("should return the GVKs of the CRDs", func() {
 		gvks := getRequiredGVKs()
-		Expect(gvks).To(HaveLen(4))
+		Expect(gvks).To(HaveLen(5))
 		Expect(gvks).To(ContainElement(object.GVK(&karpv1.NodePool{})))
 		Expect(gvks).To(ContainElement(object.GVK(&karpv1.NodeClaim{})))
 		Expect(gvks).To(ContainElement(object.GVK(&karpv1alpha1.NodeOverlay{})))
 		Expect(gvks).To(ContainElement(object.GVK(&v1beta1.AKSNodeClass{})))
+		Expect(gvks).To(ContainElement(object.GVK(&v1beta1.AzureCapacityStatus{})))
 	})
 })
@@ -19,10 +19,13 @@ package allocationstrategy
 import (
 	"context"
 
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	corecloudprovider "sigs.k8s.io/karpenter/pkg/cloudprovider"
 	"sigs.k8s.io/karpenter/pkg/scheduling"
 
+	"github.com/Azure/karpenter-provider-azure/pkg/apis/v1beta1"
 	"github.com/Azure/karpenter-provider-azure/pkg/logging"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/allocationstrategy/stages"
 )
@@ -43,19 +46,35 @@ type Provider interface {
 	// they would replace this provider rather than implement it. Those paths
 	// can still reuse the default filtering/ranking stages when they need an
 	// ordered candidate set to pass to the server-side API.
-	Allocate(ctx context.Context, instanceTypes []*corecloudprovider.InstanceType, requirements scheduling.Requirements) *Selection
+	Allocate(ctx context.Context, instanceTypes []*corecloudprovider.InstanceType, requirements scheduling.Requirements, strategy Strategy) *Selection
+	// ResolveStrategy returns the allocation strategy for a NodeClaim, from the annotations of its NodePool
+	// or else from its AKSNodeClass.
+	ResolveStrategy(ctx context.Context, nodeClass *v1beta1.AKSNodeClass, nodeClaim *karpv1.NodeClaim) Strategy
 }
 
 var _ Provider = &DefaultProvider{}
 
-type DefaultProvider struct{}
+type DefaultProvider struct {
+	kubeClient client.Client
+	// unavailableOfferings is consulted by the capacity-optimized strategy
+	unavailableOfferings stages.UnavailableOfferingsHistory
+	// spotEvictions is consulted by the spot-eviction-aware strategy, to penalize frequently evicted spot offerings
+	spotEvictions stages.SpotEvictionHistory
+	// spotPriceTrends is consulted by every strategy when set, to avoid spot offerings whose price is rising toward on-demand
+	spotPriceTrends stages.SpotPriceTrends
+}
 
-func NewProvider() *DefaultProvider {
-	return &DefaultProvider{}
+func NewProvider(kubeClient client.Client, unavailableOfferings stages.UnavailableOfferingsHistory, spotEvictions stages.SpotEvictionHistory, spotPriceTrends stages.SpotPriceTrends) *DefaultProvider {
+	return &DefaultProvider{
+		kubeClient:           kubeClient,
+		unavailableOfferings: unavailableOfferings,
+		spotEvictions:        spotEvictions,
+		spotPriceTrends:      spotPriceTrends,
+	}
 }
 
-func (p *DefaultProvider) Allocate(ctx context.Context, instanceTypes []*corecloudprovider.InstanceType, requirements scheduling.Requirements) *Selection {
-	candidates := p.FilterInstanceOfferings(ctx, NewInstanceOfferings(instanceTypes), requirements)
+func (p *DefaultProvider) Allocate(ctx context.Context, instanceTypes []*corecloudprovider.InstanceType, requirements scheduling.Requirements, strategy Strategy) *Selection {
+	candidates := p.FilterInstanceOfferings(ctx, NewInstanceOfferings(instanceTypes), requirements, strategy)
 	if len(candidates) == 0 {
 		return nil
 	}
@@ -63,21 +82,27 @@ func (p *DefaultProvider) Allocate(ctx context.Context, instanceTypes []*coreclo
 	if best.InstanceType == nil || len(best.Offerings) == 0 {
 		return nil
 	}
-	log.FromContext(ctx).Info("selected instance type", logging.InstanceType, best.InstanceType.Name)
+	log.FromContext(ctx).Info("selected instance type", logging.InstanceType, best.InstanceType.Name, "allocation-strategy", strategy.GetType())
 	return &Selection{
 		InstanceType: best.InstanceType,
 		Offering:     best.Offerings[0],
 	}
 }
 
-func (p *DefaultProvider) FilterInstanceOfferings(ctx context.Context, instanceOfferings []InstanceOffering, requirements scheduling.Requirements) []InstanceOffering {
-	stages := []stages.Stage{
+func (p *DefaultProvider) FilterInstanceOfferings(ctx context.Context, instanceOfferings []InstanceOffering, requirements scheduling.Requirements, strategy Strategy) []InstanceOffering {
+	pipeline := []stages.Stage{
 		stages.NewAvailabilityCompatibilityFilterStage(requirements),
-		// Keep offering ranking in a single stage so future customizable allocation strategy work can swap or parameterize the ranker
-		// without introducing multiple reorder stages where the last reorder wins.
-		stages.NewDefaultOfferingRankStage(),
 	}
-	for _, stage := range stages {
+	if p.spotPriceTrends != nil {
+		pipeline = append(pipeline, stages.NewSpotPriceTrendFilterStage(p.spotPriceTrends))
+	}
+	if strategy.CapacityReservations != nil {
+		pipeline = append(pipeline, stages.NewCapacityReservationPriceStage(strategy.CapacityReservations))
+	}
+	// Keep offering ranking in a single stage, picked by the allocation strategy, rather than
+	// introducing multiple reorder stages where the last reorder wins.
+	pipeline = append(pipeline, p.rankStage(strategy))
+	for _, stage := range pipeline {
 		instanceOfferings = stage.Process(ctx, instanceOfferings)
 	}
 	return instanceOfferings
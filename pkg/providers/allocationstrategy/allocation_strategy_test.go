@@ -32,7 +32,7 @@ import (
 
 func TestFilterInstanceOfferings_RemovesUnavailable(t *testing.T) {
 	g := NewWithT(t)
-	provider := allocationstrategy.NewProvider()
+	provider := allocationstrategy.NewProvider(nil, nil, nil, nil)
 	requirements := scheduling.NewRequirements(
 		scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, "In", karpv1.CapacityTypeOnDemand),
 	)
@@ -53,7 +53,7 @@ func TestFilterInstanceOfferings_RemovesUnavailable(t *testing.T) {
 		},
 	}
 
-	filtered := provider.FilterInstanceOfferings(context.Background(), allocationstrategy.NewInstanceOfferings(instanceTypes), requirements)
+	filtered := provider.FilterInstanceOfferings(context.Background(), allocationstrategy.NewInstanceOfferings(instanceTypes), requirements, allocationstrategy.Strategy{})
 	g.Expect(filtered).To(HaveLen(1))
 	g.Expect(filtered[0].InstanceType.Name).To(Equal("Standard_D2s_v3"))
 	g.Expect(filtered[0].Offerings).To(HaveLen(1))
@@ -187,9 +187,9 @@ func TestFilterInstanceOfferings_ZerothItemHasExpectedPriority(t *testing.T) {
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
 			g := NewWithT(t)
-			provider := allocationstrategy.NewProvider()
+			provider := allocationstrategy.NewProvider(nil, nil, nil, nil)
 
-			filtered := provider.FilterInstanceOfferings(context.Background(), allocationstrategy.NewInstanceOfferings(c.instanceTypes), c.requirements)
+			filtered := provider.FilterInstanceOfferings(context.Background(), allocationstrategy.NewInstanceOfferings(c.instanceTypes), c.requirements, allocationstrategy.Strategy{})
 
 			if c.expectedPriority == "" {
 				g.Expect(filtered).To(BeEmpty())
@@ -207,7 +207,7 @@ func TestFilterInstanceOfferings_ZerothItemHasExpectedPriority(t *testing.T) {
 
 func TestFilterInstanceOfferings_Requirements_FiltersByZone(t *testing.T) {
 	g := NewWithT(t)
-	provider := allocationstrategy.NewProvider()
+	provider := allocationstrategy.NewProvider(nil, nil, nil, nil)
 	requirements := scheduling.NewRequirements(
 		scheduling.NewRequirement(corev1.LabelTopologyZone, corev1.NodeSelectorOpIn, "westus-1"),
 	)
@@ -229,7 +229,7 @@ func TestFilterInstanceOfferings_Requirements_FiltersByZone(t *testing.T) {
 		},
 	}
 
-	filtered := provider.FilterInstanceOfferings(context.Background(), allocationstrategy.NewInstanceOfferings(instanceTypes), requirements)
+	filtered := provider.FilterInstanceOfferings(context.Background(), allocationstrategy.NewInstanceOfferings(instanceTypes), requirements, allocationstrategy.Strategy{})
 	g.Expect(filtered).To(HaveLen(1))
 	g.Expect(filtered[0].InstanceType.Name).To(Equal("Standard_D2s_v3"))
 	g.Expect(filtered[0].Offerings).To(HaveLen(1))
@@ -238,7 +238,7 @@ func TestFilterInstanceOfferings_Requirements_FiltersByZone(t *testing.T) {
 
 func TestFilterInstanceOfferings_OrdersByPrice(t *testing.T) {
 	g := NewWithT(t)
-	provider := allocationstrategy.NewProvider()
+	provider := allocationstrategy.NewProvider(nil, nil, nil, nil)
 	requirements := scheduling.NewRequirements(
 		scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, "In", karpv1.CapacityTypeOnDemand),
 	)
@@ -270,7 +270,7 @@ func TestFilterInstanceOfferings_OrdersByPrice(t *testing.T) {
 		},
 	}
 
-	filtered := provider.FilterInstanceOfferings(context.Background(), allocationstrategy.NewInstanceOfferings(instanceTypes), requirements)
+	filtered := provider.FilterInstanceOfferings(context.Background(), allocationstrategy.NewInstanceOfferings(instanceTypes), requirements, allocationstrategy.Strategy{})
 	g.Expect(filtered).To(HaveLen(3))
 	g.Expect([]string{filtered[0].InstanceType.Name, filtered[1].InstanceType.Name, filtered[2].InstanceType.Name}).To(Equal([]string{"Standard_D4s_v3", "Standard_D64s_v3", "Standard_F16s_v2"}))
 	g.Expect(filtered[0].Offerings).To(HaveLen(1))
@@ -280,7 +280,7 @@ func TestFilterInstanceOfferings_OrdersByPrice(t *testing.T) {
 
 func TestFilterInstanceOfferings_SpotOfferingsBeforeOnDemandAtSamePrice(t *testing.T) {
 	g := NewWithT(t)
-	provider := allocationstrategy.NewProvider()
+	provider := allocationstrategy.NewProvider(nil, nil, nil, nil)
 	requirements := scheduling.NewRequirements(
 		scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, karpv1.CapacityTypeOnDemand, karpv1.CapacityTypeSpot),
 		scheduling.NewRequirement(corev1.LabelTopologyZone, corev1.NodeSelectorOpIn, "westus-1", "westus-2", "westus-3"),
@@ -300,7 +300,7 @@ func TestFilterInstanceOfferings_SpotOfferingsBeforeOnDemandAtSamePrice(t *testi
 		},
 	}
 
-	filtered := provider.FilterInstanceOfferings(context.Background(), allocationstrategy.NewInstanceOfferings(instanceTypes), requirements)
+	filtered := provider.FilterInstanceOfferings(context.Background(), allocationstrategy.NewInstanceOfferings(instanceTypes), requirements, allocationstrategy.Strategy{})
 	g.Expect(filtered).To(HaveLen(1))
 	g.Expect(filtered[0].Offerings).To(HaveLen(6))
 
@@ -316,7 +316,7 @@ func TestFilterInstanceOfferings_SpotOfferingsBeforeOnDemandAtSamePrice(t *testi
 
 func TestFilterInstanceOfferings_ZonalOfferingsBeforeRegionalAtSamePriceAndCapacityType(t *testing.T) {
 	g := NewWithT(t)
-	provider := allocationstrategy.NewProvider()
+	provider := allocationstrategy.NewProvider(nil, nil, nil, nil)
 	requirements := scheduling.NewRequirements(
 		scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, karpv1.CapacityTypeOnDemand),
 		scheduling.NewRequirement(corev1.LabelTopologyZone, corev1.NodeSelectorOpIn, "westus-1", zones.Regional),
@@ -332,7 +332,7 @@ func TestFilterInstanceOfferings_ZonalOfferingsBeforeRegionalAtSamePriceAndCapac
 		},
 	}
 
-	filtered := provider.FilterInstanceOfferings(context.Background(), allocationstrategy.NewInstanceOfferings(instanceTypes), requirements)
+	filtered := provider.FilterInstanceOfferings(context.Background(), allocationstrategy.NewInstanceOfferings(instanceTypes), requirements, allocationstrategy.Strategy{})
 	g.Expect(filtered).To(HaveLen(1))
 	g.Expect(filtered[0].Offerings).To(HaveLen(2))
 	g.Expect(filtered[0].Offerings[0].Requirements.Get(corev1.LabelTopologyZone).Any()).To(Equal("westus-1"))
@@ -340,7 +340,7 @@ func TestFilterInstanceOfferings_ZonalOfferingsBeforeRegionalAtSamePriceAndCapac
 
 func TestFilterInstanceOfferings_SpotRegionalOfferingBeforeOnDemandZonalAtSamePrice(t *testing.T) {
 	g := NewWithT(t)
-	provider := allocationstrategy.NewProvider()
+	provider := allocationstrategy.NewProvider(nil, nil, nil, nil)
 	requirements := scheduling.NewRequirements(
 		scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, karpv1.CapacityTypeOnDemand, karpv1.CapacityTypeSpot),
 		scheduling.NewRequirement(corev1.LabelTopologyZone, corev1.NodeSelectorOpIn, "westus-1", zones.Regional),
@@ -356,7 +356,7 @@ func TestFilterInstanceOfferings_SpotRegionalOfferingBeforeOnDemandZonalAtSamePr
 		},
 	}
 
-	filtered := provider.FilterInstanceOfferings(context.Background(), allocationstrategy.NewInstanceOfferings(instanceTypes), requirements)
+	filtered := provider.FilterInstanceOfferings(context.Background(), allocationstrategy.NewInstanceOfferings(instanceTypes), requirements, allocationstrategy.Strategy{})
 	g.Expect(filtered).To(HaveLen(1))
 	g.Expect(filtered[0].Offerings).To(HaveLen(2))
 	g.Expect(filtered[0].Offerings[0].Requirements.Get(karpv1.CapacityTypeLabelKey).Any()).To(Equal(karpv1.CapacityTypeSpot))
@@ -365,7 +365,7 @@ func TestFilterInstanceOfferings_SpotRegionalOfferingBeforeOnDemandZonalAtSamePr
 
 func TestFilterInstanceOfferings_ZonalInstanceTypeBeforeRegionalAtSamePriceAndCapacityType(t *testing.T) {
 	g := NewWithT(t)
-	provider := allocationstrategy.NewProvider()
+	provider := allocationstrategy.NewProvider(nil, nil, nil, nil)
 	requirements := scheduling.NewRequirements(
 		scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, karpv1.CapacityTypeOnDemand),
 		scheduling.NewRequirement(corev1.LabelTopologyZone, corev1.NodeSelectorOpIn, "westus-1", zones.Regional),
@@ -386,7 +386,7 @@ func TestFilterInstanceOfferings_ZonalInstanceTypeBeforeRegionalAtSamePriceAndCa
 		},
 	}
 
-	filtered := provider.FilterInstanceOfferings(context.Background(), allocationstrategy.NewInstanceOfferings(instanceTypes), requirements)
+	filtered := provider.FilterInstanceOfferings(context.Background(), allocationstrategy.NewInstanceOfferings(instanceTypes), requirements, allocationstrategy.Strategy{})
 	g.Expect(filtered).To(HaveLen(2))
 	g.Expect(filtered[0].InstanceType.Name).To(Equal("Standard_Zonal"))
 }
@@ -399,7 +399,7 @@ func TestFilterInstanceOfferings_ZonalInstanceTypeBeforeRegionalAtSamePriceAndCa
 // TODO: Consider a property-based test helper if we add more randomized ranker checks.
 func TestFilterInstanceOfferings_ZoneTiesAreShuffled(t *testing.T) {
 	g := NewWithT(t)
-	provider := allocationstrategy.NewProvider()
+	provider := allocationstrategy.NewProvider(nil, nil, nil, nil)
 	requirements := scheduling.NewRequirements(
 		scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, karpv1.CapacityTypeOnDemand),
 		scheduling.NewRequirement(corev1.LabelTopologyZone, corev1.NodeSelectorOpIn, "westus-1", "westus-2", "westus-3"),
@@ -417,7 +417,7 @@ func TestFilterInstanceOfferings_ZoneTiesAreShuffled(t *testing.T) {
 				},
 			},
 		}
-		filtered := provider.FilterInstanceOfferings(context.Background(), allocationstrategy.NewInstanceOfferings(instanceTypes), requirements)
+		filtered := provider.FilterInstanceOfferings(context.Background(), allocationstrategy.NewInstanceOfferings(instanceTypes), requirements, allocationstrategy.Strategy{})
 		g.Expect(filtered).To(HaveLen(1))
 		g.Expect(filtered[0].Offerings).NotTo(BeEmpty())
 		seen[filtered[0].Offerings[0].Requirements.Get(corev1.LabelTopologyZone).Any()]++
@@ -427,7 +427,7 @@ func TestFilterInstanceOfferings_ZoneTiesAreShuffled(t *testing.T) {
 
 func TestAllocate(t *testing.T) {
 	g := NewWithT(t)
-	provider := allocationstrategy.NewProvider()
+	provider := allocationstrategy.NewProvider(nil, nil, nil, nil)
 	requirements := scheduling.NewRequirements(
 		scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, karpv1.CapacityTypeOnDemand),
 		scheduling.NewRequirement(corev1.LabelTopologyZone, corev1.NodeSelectorOpIn, "westus-1", zones.Regional),
@@ -448,7 +448,7 @@ func TestAllocate(t *testing.T) {
 		},
 	}
 
-	selection := provider.Allocate(context.Background(), instanceTypes, requirements)
+	selection := provider.Allocate(context.Background(), instanceTypes, requirements, allocationstrategy.Strategy{})
 	g.Expect(selection).ToNot(BeNil())
 	g.Expect(selection.InstanceType.Name).To(Equal("Standard_Zonal"))
 	g.Expect(selection.CapacityType()).To(Equal(karpv1.CapacityTypeOnDemand))
@@ -458,7 +458,7 @@ func TestAllocate(t *testing.T) {
 
 func TestAllocate_NoCompatibleOfferings(t *testing.T) {
 	g := NewWithT(t)
-	provider := allocationstrategy.NewProvider()
+	provider := allocationstrategy.NewProvider(nil, nil, nil, nil)
 	requirements := scheduling.NewRequirements(
 		scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, karpv1.CapacityTypeSpot),
 	)
@@ -472,7 +472,7 @@ func TestAllocate_NoCompatibleOfferings(t *testing.T) {
 		},
 	}
 
-	selection := provider.Allocate(context.Background(), instanceTypes, requirements)
+	selection := provider.Allocate(context.Background(), instanceTypes, requirements, allocationstrategy.Strategy{})
 	g.Expect(selection).To(BeNil())
 }
 
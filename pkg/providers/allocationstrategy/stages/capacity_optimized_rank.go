@@ -0,0 +1,78 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stages
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	corecloudprovider "sigs.k8s.io/karpenter/pkg/cloudprovider"
+)
+
+// UnavailableOfferingsHistory reports offerings that were recently marked unavailable (e.g. due to insufficient capacity),
+// even if they are available again.
+type UnavailableOfferingsHistory interface {
+	WasRecentlyUnavailable(instanceType, zone, capacityType string) bool
+}
+
+// capacityOptimizedRankStage ranks offerings that were not recently marked unavailable first,
+// and then falls back to the default ranking (price, capacity type, placement scope).
+type capacityOptimizedRankStage struct {
+	history UnavailableOfferingsHistory
+}
+
+func NewCapacityOptimizedRankStage(history UnavailableOfferingsHistory) Stage {
+	return &capacityOptimizedRankStage{
+		history: history,
+	}
+}
+
+func (s *capacityOptimizedRankStage) Process(_ context.Context, instanceOfferings []InstanceOffering) []InstanceOffering {
+	for idx := range instanceOfferings {
+		name := instanceOfferingName(instanceOfferings[idx])
+		rankOfferings(instanceOfferings[idx].Offerings, func(i, j *corecloudprovider.Offering) int {
+			return s.compare(name, i, name, j)
+		})
+	}
+
+	sortInstanceOfferings(instanceOfferings, func(i, j InstanceOffering) int {
+		return s.compare(instanceOfferingName(i), firstOffering(i), instanceOfferingName(j), firstOffering(j))
+	})
+	return instanceOfferings
+}
+
+func (s *capacityOptimizedRankStage) compare(iName string, i *corecloudprovider.Offering, jName string, j *corecloudprovider.Offering) int {
+	if i != nil && j != nil {
+		if iRank, jRank := s.recentlyUnavailableRank(iName, i), s.recentlyUnavailableRank(jName, j); iRank != jRank {
+			return iRank - jRank
+		}
+	}
+	return compareOfferings(i, j)
+}
+
+func (s *capacityOptimizedRankStage) recentlyUnavailableRank(instanceType string, offering *corecloudprovider.Offering) int {
+	if s.history == nil {
+		return 0
+	}
+	zone := offering.Requirements.Get(corev1.LabelTopologyZone).Any()
+	capacityType := offering.Requirements.Get(karpv1.CapacityTypeLabelKey).Any()
+	if s.history.WasRecentlyUnavailable(instanceType, zone, capacityType) {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,61 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stages
+
+import (
+	"context"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	corecloudprovider "sigs.k8s.io/karpenter/pkg/cloudprovider"
+)
+
+// CapacityReservations reports the unused reserved capacity of a capacity reservation group
+type CapacityReservations interface {
+	Available(instanceType, zone string) bool
+}
+
+// capacityReservationPriceStage prices on-demand offerings backed by unused reserved capacity at zero,
+// since the reserved capacity is already paid for whether it is used or not. It does not reorder offerings,
+// so that the ranking stage that follows ranks reserved offerings first under every allocation strategy.
+type capacityReservationPriceStage struct {
+	reservations CapacityReservations
+}
+
+func NewCapacityReservationPriceStage(reservations CapacityReservations) Stage {
+	return &capacityReservationPriceStage{
+		reservations: reservations,
+	}
+}
+
+func (s *capacityReservationPriceStage) Process(_ context.Context, instanceOfferings []InstanceOffering) []InstanceOffering {
+	for idx := range instanceOfferings {
+		name := instanceOfferingName(instanceOfferings[idx])
+		instanceOfferings[idx].Offerings = lo.Map(instanceOfferings[idx].Offerings, func(offering *corecloudprovider.Offering, _ int) *corecloudprovider.Offering {
+			if offering.Requirements.Get(karpv1.CapacityTypeLabelKey).Any() != karpv1.CapacityTypeOnDemand ||
+				!s.reservations.Available(name, offering.Requirements.Get(corev1.LabelTopologyZone).Any()) {
+				return offering
+			}
+			// The offerings are shared with the instance type cache, so price a copy rather than the offering itself
+			reserved := *offering
+			reserved.Price = 0
+			return &reserved
+		})
+	}
+	return instanceOfferings
+}
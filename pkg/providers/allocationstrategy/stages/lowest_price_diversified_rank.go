@@ -0,0 +1,86 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stages
+
+import (
+	"context"
+	"math/rand"
+
+	"github.com/Azure/karpenter-provider-azure/pkg/apis/v1beta1"
+)
+
+// diversifiedFamilyCount is the number of cheapest SKU families launches are spread across
+const diversifiedFamilyCount = 3
+
+// lowestPriceDiversifiedRankStage spreads launches across SKU families, so that a capacity shortage
+// in a single family affects fewer nodes. Instance offerings are first ranked like the default stage,
+// and the cheapest instance type of one of the diversifiedFamilyCount cheapest families is then
+// picked at random to lead. The rest keeps the default order, so that fallbacks are still the cheapest.
+type lowestPriceDiversifiedRankStage struct {
+	defaultRankStage Stage
+}
+
+func NewLowestPriceDiversifiedRankStage() Stage {
+	return &lowestPriceDiversifiedRankStage{
+		defaultRankStage: NewDefaultOfferingRankStage(),
+	}
+}
+
+func (s *lowestPriceDiversifiedRankStage) Process(ctx context.Context, instanceOfferings []InstanceOffering) []InstanceOffering {
+	instanceOfferings = s.defaultRankStage.Process(ctx, instanceOfferings)
+
+	// indices of the cheapest instance offering of each of the cheapest families, in price order
+	var familyLeaders []int
+	seenFamilies := map[string]struct{}{}
+	for idx, instanceOffering := range instanceOfferings {
+		if len(familyLeaders) == diversifiedFamilyCount {
+			break
+		}
+		if firstOffering(instanceOffering) == nil {
+			continue
+		}
+		family := skuFamily(instanceOffering)
+		if _, ok := seenFamilies[family]; ok {
+			continue
+		}
+		seenFamilies[family] = struct{}{}
+		familyLeaders = append(familyLeaders, idx)
+	}
+	if len(familyLeaders) < 2 {
+		return instanceOfferings
+	}
+
+	// Non-cryptographic randomness is intentional here, see rankOfferings.
+	leader := familyLeaders[rand.Intn(len(familyLeaders))]
+	result := make([]InstanceOffering, 0, len(instanceOfferings))
+	result = append(result, instanceOfferings[leader])
+	result = append(result, instanceOfferings[:leader]...)
+	result = append(result, instanceOfferings[leader+1:]...)
+	return result
+}
+
+// skuFamily returns the SKU family used to diversify launches. The SKU series (e.g. Dsv5) is used rather than the
+// broader family (e.g. D), as it is the unit Azure capacity and quota are managed in.
+func skuFamily(instanceOffering InstanceOffering) string {
+	if instanceOffering.InstanceType == nil {
+		return ""
+	}
+	if series := instanceOffering.InstanceType.Requirements.Get(v1beta1.LabelSKUSeries); series.Len() > 0 {
+		return series.Any()
+	}
+	return instanceOffering.InstanceType.Name
+}
@@ -35,20 +35,16 @@ func NewDefaultOfferingRankStage() Stage {
 
 func (s *defaultOfferingRankStage) Process(_ context.Context, instanceOfferings []InstanceOffering) []InstanceOffering {
 	for idx := range instanceOfferings {
-		rankOfferings(instanceOfferings[idx].Offerings)
+		rankOfferings(instanceOfferings[idx].Offerings, compareOfferings)
 	}
 
-	sort.Slice(instanceOfferings, func(i, j int) bool {
-		comparison := compareOfferings(firstOffering(instanceOfferings[i]), firstOffering(instanceOfferings[j]))
-		if comparison == 0 {
-			return instanceOfferingName(instanceOfferings[i]) < instanceOfferingName(instanceOfferings[j])
-		}
-		return comparison < 0
+	sortInstanceOfferings(instanceOfferings, func(i, j InstanceOffering) int {
+		return compareOfferings(firstOffering(i), firstOffering(j))
 	})
 	return instanceOfferings
 }
 
-func rankOfferings(offerings corecloudprovider.Offerings) {
+func rankOfferings(offerings corecloudprovider.Offerings, compare func(i, j *corecloudprovider.Offering) int) {
 	// Shuffle before the stable sort so that offerings tied on every comparison
 	// dimension (price, capacity type, placement scope) end up in random order.
 	// This avoids concentrating launches in the lexically first zone when zonal
@@ -56,7 +52,19 @@ func rankOfferings(offerings corecloudprovider.Offerings) {
 	// intentional here.
 	rand.Shuffle(len(offerings), func(i, j int) { offerings[i], offerings[j] = offerings[j], offerings[i] })
 	sort.SliceStable(offerings, func(i, j int) bool {
-		return compareOfferings(offerings[i], offerings[j]) < 0
+		return compare(offerings[i], offerings[j]) < 0
+	})
+}
+
+// sortInstanceOfferings sorts instance offerings with the given comparison, breaking ties
+// by instance type name so that the order is deterministic.
+func sortInstanceOfferings(instanceOfferings []InstanceOffering, compare func(i, j InstanceOffering) int) {
+	sort.Slice(instanceOfferings, func(i, j int) bool {
+		comparison := compare(instanceOfferings[i], instanceOfferings[j])
+		if comparison == 0 {
+			return instanceOfferingName(instanceOfferings[i]) < instanceOfferingName(instanceOfferings[j])
+		}
+		return comparison < 0
 	})
 }
 
@@ -0,0 +1,65 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stages
+
+import (
+	"context"
+	"strings"
+)
+
+// prioritizedRankStage ranks instance types in the order given by the user, most preferred first.
+// Instance types that are not listed rank after the listed ones. Offerings of an instance type,
+// and instance types of equal priority, fall back to the default ranking.
+type prioritizedRankStage struct {
+	// key: lowercase instance type name, value: index in the user provided priorities
+	priorities map[string]int
+}
+
+func NewPrioritizedRankStage(priorities []string) Stage {
+	s := &prioritizedRankStage{
+		priorities: make(map[string]int, len(priorities)),
+	}
+	for idx, instanceType := range priorities {
+		key := strings.ToLower(strings.TrimSpace(instanceType))
+		// keep the first occurrence if an instance type is listed more than once
+		if _, ok := s.priorities[key]; !ok {
+			s.priorities[key] = idx
+		}
+	}
+	return s
+}
+
+func (s *prioritizedRankStage) Process(_ context.Context, instanceOfferings []InstanceOffering) []InstanceOffering {
+	for idx := range instanceOfferings {
+		rankOfferings(instanceOfferings[idx].Offerings, compareOfferings)
+	}
+
+	sortInstanceOfferings(instanceOfferings, func(i, j InstanceOffering) int {
+		if iRank, jRank := s.priorityRank(i), s.priorityRank(j); iRank != jRank {
+			return iRank - jRank
+		}
+		return compareOfferings(firstOffering(i), firstOffering(j))
+	})
+	return instanceOfferings
+}
+
+func (s *prioritizedRankStage) priorityRank(instanceOffering InstanceOffering) int {
+	if rank, ok := s.priorities[strings.ToLower(instanceOfferingName(instanceOffering))]; ok {
+		return rank
+	}
+	return len(s.priorities)
+}
@@ -0,0 +1,82 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stages
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	corecloudprovider "sigs.k8s.io/karpenter/pkg/cloudprovider"
+)
+
+// spotEvictionPenalty is the fraction of its price added to a spot offering for each eviction per hour
+// observed for it. For example, a spot offering evicted once per hour ranks as if it was twice as expensive.
+const spotEvictionPenalty = 1.0
+
+// SpotEvictionHistory reports the rate of spot evictions observed per instance type and zone
+type SpotEvictionHistory interface {
+	EvictionRate(instanceType, zone string) float64
+}
+
+// spotEvictionRankStage ranks offerings like the default ranking, but penalizes the price of spot
+// offerings by their observed eviction rate, so that frequently evicted spot offerings rank after
+// cheaper-to-run alternatives. Without observed evictions it ranks exactly like the default ranking.
+type spotEvictionRankStage struct {
+	history SpotEvictionHistory
+}
+
+func NewSpotEvictionRankStage(history SpotEvictionHistory) Stage {
+	return &spotEvictionRankStage{
+		history: history,
+	}
+}
+
+func (s *spotEvictionRankStage) Process(_ context.Context, instanceOfferings []InstanceOffering) []InstanceOffering {
+	for idx := range instanceOfferings {
+		name := instanceOfferingName(instanceOfferings[idx])
+		rankOfferings(instanceOfferings[idx].Offerings, func(i, j *corecloudprovider.Offering) int {
+			return s.compare(name, i, name, j)
+		})
+	}
+
+	sortInstanceOfferings(instanceOfferings, func(i, j InstanceOffering) int {
+		return s.compare(instanceOfferingName(i), firstOffering(i), instanceOfferingName(j), firstOffering(j))
+	})
+	return instanceOfferings
+}
+
+func (s *spotEvictionRankStage) compare(iName string, i *corecloudprovider.Offering, jName string, j *corecloudprovider.Offering) int {
+	if i != nil && j != nil {
+		iPrice, jPrice := s.evictionAdjustedPrice(iName, i), s.evictionAdjustedPrice(jName, j)
+		if iPrice < jPrice {
+			return -1
+		}
+		if iPrice > jPrice {
+			return 1
+		}
+	}
+	return compareOfferings(i, j)
+}
+
+func (s *spotEvictionRankStage) evictionAdjustedPrice(instanceType string, offering *corecloudprovider.Offering) float64 {
+	if s.history == nil || offering.Requirements.Get(karpv1.CapacityTypeLabelKey).Any() != karpv1.CapacityTypeSpot {
+		return offering.Price
+	}
+	rate := s.history.EvictionRate(instanceType, offering.Requirements.Get(corev1.LabelTopologyZone).Any())
+	return offering.Price * (1 + spotEvictionPenalty*rate)
+}
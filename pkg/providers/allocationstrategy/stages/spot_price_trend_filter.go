@@ -0,0 +1,61 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stages
+
+import (
+	"context"
+
+	"github.com/samber/lo"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	corecloudprovider "sigs.k8s.io/karpenter/pkg/cloudprovider"
+)
+
+// SpotPriceTrends reports whether the spot price of an instance type is trending toward its on-demand price
+type SpotPriceTrends interface {
+	RisingTowardOnDemand(instanceType string) bool
+}
+
+// spotPriceTrendFilterStage removes the spot offerings of instance types whose spot price is trending toward their
+// on-demand price, as they are about to lose their discount. If that would leave no offerings, it keeps them all rather
+// than failing the launch.
+type spotPriceTrendFilterStage struct {
+	trends SpotPriceTrends
+}
+
+func NewSpotPriceTrendFilterStage(trends SpotPriceTrends) Stage {
+	return &spotPriceTrendFilterStage{
+		trends: trends,
+	}
+}
+
+func (s *spotPriceTrendFilterStage) Process(ctx context.Context, instanceOfferings []InstanceOffering) []InstanceOffering {
+	filtered := lo.FilterMap(instanceOfferings, func(instanceOffering InstanceOffering, _ int) (InstanceOffering, bool) {
+		if !s.trends.RisingTowardOnDemand(instanceOfferingName(instanceOffering)) {
+			return instanceOffering, true
+		}
+		instanceOffering.Offerings = lo.Filter(instanceOffering.Offerings, func(offering *corecloudprovider.Offering, _ int) bool {
+			return offering.Requirements.Get(karpv1.CapacityTypeLabelKey).Any() != karpv1.CapacityTypeSpot
+		})
+		return instanceOffering, len(instanceOffering.Offerings) > 0
+	})
+	if len(filtered) == 0 {
+		log.FromContext(ctx).V(1).Info("keeping spot offerings with prices rising toward on-demand, there are no other offerings")
+		return instanceOfferings
+	}
+	return filtered
+}
@@ -0,0 +1,129 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package allocationstrategy
+
+import (
+	"context"
+	"strings"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+
+	"github.com/Azure/karpenter-provider-azure/pkg/apis/v1beta1"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/allocationstrategy/stages"
+)
+
+// Strategy is the allocation strategy resolved for a NodeClaim
+type Strategy struct {
+	// Type is the allocation strategy type, defaulting to lowest-price when empty
+	Type v1beta1.AllocationStrategyType
+	// Priorities is the ordered list of preferred VM sizes, only used by the prioritized strategy
+	Priorities []string
+	// CapacityReservations is the unused reserved capacity of the AKSNodeClass capacity reservation group, if any.
+	// On-demand offerings backed by it are priced at zero, so that every strategy ranks them first.
+	CapacityReservations stages.CapacityReservations
+}
+
+// GetType returns the effective allocation strategy type
+func (s Strategy) GetType() v1beta1.AllocationStrategyType {
+	return lo.Ternary(s.Type == "", v1beta1.AllocationStrategyLowestPrice, s.Type)
+}
+
+// rankStages is the registry of offering ranking stages, keyed by allocation strategy type.
+// Only the ranking stage differs between strategies; filtering is shared.
+var rankStages = map[v1beta1.AllocationStrategyType]func(p *DefaultProvider, strategy Strategy) stages.Stage{
+	v1beta1.AllocationStrategyLowestPrice: func(_ *DefaultProvider, _ Strategy) stages.Stage {
+		return stages.NewDefaultOfferingRankStage()
+	},
+	v1beta1.AllocationStrategyCapacityOptimized: func(p *DefaultProvider, _ Strategy) stages.Stage {
+		return stages.NewCapacityOptimizedRankStage(p.unavailableOfferings)
+	},
+	v1beta1.AllocationStrategyLowestPriceDiversified: func(_ *DefaultProvider, _ Strategy) stages.Stage {
+		return stages.NewLowestPriceDiversifiedRankStage()
+	},
+	v1beta1.AllocationStrategyPrioritized: func(_ *DefaultProvider, strategy Strategy) stages.Stage {
+		return stages.NewPrioritizedRankStage(strategy.Priorities)
+	},
+	v1beta1.AllocationStrategySpotEvictionAware: func(p *DefaultProvider, _ Strategy) stages.Stage {
+		if p.spotEvictions == nil {
+			return stages.NewDefaultOfferingRankStage()
+		}
+		return stages.NewSpotEvictionRankStage(p.spotEvictions)
+	},
+}
+
+// IsValidStrategyType returns true if there is a ranking stage registered for the allocation strategy type
+func IsValidStrategyType(strategyType v1beta1.AllocationStrategyType) bool {
+	_, ok := rankStages[strategyType]
+	return ok
+}
+
+func (p *DefaultProvider) rankStage(strategy Strategy) stages.Stage {
+	newRankStage, ok := rankStages[strategy.GetType()]
+	if !ok {
+		newRankStage = rankStages[v1beta1.AllocationStrategyLowestPrice]
+	}
+	return newRankStage(p, strategy)
+}
+
+func (p *DefaultProvider) ResolveStrategy(ctx context.Context, nodeClass *v1beta1.AKSNodeClass, nodeClaim *karpv1.NodeClaim) Strategy {
+	strategy := Strategy{
+		Type:       nodeClass.GetAllocationStrategyType(),
+		Priorities: nodeClass.GetAllocationStrategyPriorities(),
+	}
+
+	nodePool := p.resolveNodePool(ctx, nodeClaim)
+	if nodePool == nil {
+		return strategy
+	}
+	if strategyType, ok := nodePool.Annotations[v1beta1.AnnotationAllocationStrategy]; ok {
+		if !IsValidStrategyType(v1beta1.AllocationStrategyType(strategyType)) {
+			log.FromContext(ctx).Error(nil, "ignoring invalid allocation strategy annotation on NodePool",
+				"NodePool", nodePool.Name,
+				"allocation-strategy", strategyType)
+			return strategy
+		}
+		strategy.Type = v1beta1.AllocationStrategyType(strategyType)
+	}
+	if priorities, ok := nodePool.Annotations[v1beta1.AnnotationAllocationStrategyPriorities]; ok {
+		strategy.Priorities = lo.Compact(lo.Map(strings.Split(priorities, ","), func(instanceType string, _ int) string {
+			return strings.TrimSpace(instanceType)
+		}))
+	}
+	return strategy
+}
+
+// resolveNodePool returns the NodePool owning the NodeClaim, or nil if there is none or it can't be retrieved
+func (p *DefaultProvider) resolveNodePool(ctx context.Context, nodeClaim *karpv1.NodeClaim) *karpv1.NodePool {
+	if p.kubeClient == nil || nodeClaim == nil {
+		return nil
+	}
+	nodePoolName, ok := nodeClaim.Labels[karpv1.NodePoolLabelKey]
+	if !ok {
+		return nil
+	}
+	nodePool := &karpv1.NodePool{}
+	if err := p.kubeClient.Get(ctx, types.NamespacedName{Name: nodePoolName}, nodePool); err != nil {
+		log.FromContext(ctx).V(1).Info("failed to get NodePool to resolve the allocation strategy, using the AKSNodeClass allocation strategy",
+			"NodePool", nodePoolName,
+			"error", err)
+		return nil
+	}
+	return nodePool
+}
@@ -0,0 +1,346 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package allocationstrategy_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Azure/skewer"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/clock"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	corecloudprovider "sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+
+	"github.com/Azure/karpenter-provider-azure/pkg/apis/v1beta1"
+	azurecache "github.com/Azure/karpenter-provider-azure/pkg/cache"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/allocationstrategy"
+	"github.com/Azure/karpenter-provider-azure/pkg/test"
+)
+
+func TestFilterInstanceOfferings_CapacityOptimizedPrefersNotRecentlyUnavailable(t *testing.T) {
+	g := NewWithT(t)
+	unavailableOfferings := azurecache.NewUnavailableOfferings()
+	provider := allocationstrategy.NewProvider(nil, unavailableOfferings, nil, nil)
+	requirements := scheduling.NewRequirements(
+		scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, karpv1.CapacityTypeOnDemand),
+	)
+	// The cheapest offering was marked unavailable, and is available again (e.g. the mark expired)
+	unavailableOfferings.MarkUnavailableWithTTL(context.Background(), "test reason", &skewer.SKU{Name: lo.ToPtr("Standard_D2s_v3")}, "westus-1", karpv1.CapacityTypeOnDemand, 0)
+
+	instanceTypes := []*corecloudprovider.InstanceType{
+		{
+			Name: "Standard_D2s_v3",
+			Offerings: corecloudprovider.Offerings{
+				newOfferingWithZone(0.1, karpv1.CapacityTypeOnDemand, "westus-1"),
+				newOfferingWithZone(0.2, karpv1.CapacityTypeOnDemand, "westus-2"),
+			},
+		},
+		{
+			Name: "Standard_F2s_v2",
+			Offerings: corecloudprovider.Offerings{
+				newOfferingWithZone(0.15, karpv1.CapacityTypeOnDemand, "westus-1"),
+			},
+		},
+	}
+
+	filtered := provider.FilterInstanceOfferings(context.Background(), allocationstrategy.NewInstanceOfferings(instanceTypes), requirements,
+		allocationstrategy.Strategy{Type: v1beta1.AllocationStrategyCapacityOptimized})
+	g.Expect(filtered).To(HaveLen(2))
+	g.Expect(filtered[0].InstanceType.Name).To(Equal("Standard_F2s_v2"))
+	g.Expect(filtered[1].InstanceType.Name).To(Equal("Standard_D2s_v3"))
+	// within the instance type, the offering without a recent mark ranks first despite its price
+	g.Expect(filtered[1].Offerings[0].Price).To(Equal(0.2))
+
+	// the default strategy still ranks by price only
+	filtered = provider.FilterInstanceOfferings(context.Background(), allocationstrategy.NewInstanceOfferings(instanceTypes), requirements, allocationstrategy.Strategy{})
+	g.Expect(filtered[0].InstanceType.Name).To(Equal("Standard_D2s_v3"))
+	g.Expect(filtered[0].Offerings[0].Price).To(Equal(0.1))
+}
+
+func TestFilterInstanceOfferings_PrioritizedFollowsUserOrder(t *testing.T) {
+	g := NewWithT(t)
+	provider := allocationstrategy.NewProvider(nil, nil, nil, nil)
+	requirements := scheduling.NewRequirements(
+		scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, karpv1.CapacityTypeOnDemand),
+	)
+
+	instanceTypes := []*corecloudprovider.InstanceType{
+		newInstanceType("Standard_D2s_v3", "Dsv3", 0.1),
+		newInstanceType("Standard_D2s_v5", "Dsv5", 0.3),
+		newInstanceType("Standard_E2s_v5", "Esv5", 0.2),
+		newInstanceType("Standard_F2s_v2", "Fsv2", 0.4),
+	}
+
+	filtered := provider.FilterInstanceOfferings(context.Background(), allocationstrategy.NewInstanceOfferings(instanceTypes), requirements,
+		allocationstrategy.Strategy{Type: v1beta1.AllocationStrategyPrioritized, Priorities: []string{"standard_f2s_v2", "Standard_D2s_v5"}})
+	g.Expect(lo.Map(filtered, func(io allocationstrategy.InstanceOffering, _ int) string { return io.InstanceType.Name })).To(Equal(
+		[]string{"Standard_F2s_v2", "Standard_D2s_v5", "Standard_D2s_v3", "Standard_E2s_v5"}))
+}
+
+func TestFilterInstanceOfferings_LowestPriceDiversifiedSpreadsAcrossFamilies(t *testing.T) {
+	g := NewWithT(t)
+	provider := allocationstrategy.NewProvider(nil, nil, nil, nil)
+	requirements := scheduling.NewRequirements(
+		scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, karpv1.CapacityTypeOnDemand),
+	)
+
+	seen := map[string]int{}
+	for i := 0; i < 200; i++ {
+		instanceTypes := []*corecloudprovider.InstanceType{
+			newInstanceType("Standard_D2s_v3", "Dsv3", 0.1),
+			newInstanceType("Standard_D4s_v3", "Dsv3", 0.11),
+			newInstanceType("Standard_E2s_v5", "Esv5", 0.2),
+			newInstanceType("Standard_F2s_v2", "Fsv2", 0.3),
+			newInstanceType("Standard_L8s_v3", "Lsv3", 0.9),
+		}
+		filtered := provider.FilterInstanceOfferings(context.Background(), allocationstrategy.NewInstanceOfferings(instanceTypes), requirements,
+			allocationstrategy.Strategy{Type: v1beta1.AllocationStrategyLowestPriceDiversified})
+		g.Expect(filtered).To(HaveLen(5))
+		seen[filtered[0].InstanceType.Name]++
+	}
+	// only the cheapest instance type of each of the three cheapest families leads
+	g.Expect(seen).To(HaveLen(3), "expected three families to lead across 200 trials, got %v", seen)
+	g.Expect(seen).To(HaveKey("Standard_D2s_v3"))
+	g.Expect(seen).To(HaveKey("Standard_E2s_v5"))
+	g.Expect(seen).To(HaveKey("Standard_F2s_v2"))
+}
+
+func TestFilterInstanceOfferings_SpotEvictionAwarePenalizesEvictedSpotOfferings(t *testing.T) {
+	g := NewWithT(t)
+	spotEvictions := azurecache.NewSpotEvictions(clock.RealClock{})
+	provider := allocationstrategy.NewProvider(nil, nil, spotEvictions, nil)
+	requirements := scheduling.NewRequirements()
+	strategy := allocationstrategy.Strategy{Type: v1beta1.AllocationStrategySpotEvictionAware}
+
+	instanceTypes := []*corecloudprovider.InstanceType{
+		{
+			Name: "Standard_D2s_v3",
+			Offerings: corecloudprovider.Offerings{
+				newOfferingWithZone(0.1, karpv1.CapacityTypeSpot, "westus-1"),
+				newOfferingWithZone(0.11, karpv1.CapacityTypeSpot, "westus-2"),
+			},
+		},
+		{
+			Name: "Standard_F2s_v2",
+			Offerings: corecloudprovider.Offerings{
+				newOfferingWithZone(0.105, karpv1.CapacityTypeSpot, "westus-1"),
+				newOfferingWithZone(0.3, karpv1.CapacityTypeOnDemand, "westus-1"),
+			},
+		},
+	}
+
+	// without evictions, ranks by price only
+	filtered := provider.FilterInstanceOfferings(context.Background(), allocationstrategy.NewInstanceOfferings(instanceTypes), requirements, strategy)
+	g.Expect(filtered[0].InstanceType.Name).To(Equal("Standard_D2s_v3"))
+	g.Expect(filtered[0].Offerings[0].Price).To(Equal(0.1))
+
+	// the cheapest spot offering is frequently evicted
+	for i := range 3 {
+		spotEvictions.MarkEvicted(context.Background(), fmt.Sprintf("nodeclaim-%d", i), "Standard_D2s_v3", "westus-1")
+	}
+	filtered = provider.FilterInstanceOfferings(context.Background(), allocationstrategy.NewInstanceOfferings(instanceTypes), requirements, strategy)
+	g.Expect(filtered[0].InstanceType.Name).To(Equal("Standard_F2s_v2"))
+	g.Expect(filtered[0].Offerings[0].Price).To(Equal(0.105))
+	// within the instance type, the offering in the zone without evictions ranks first
+	g.Expect(filtered[1].InstanceType.Name).To(Equal("Standard_D2s_v3"))
+	g.Expect(filtered[1].Offerings[0].Price).To(Equal(0.11))
+
+	// the default lowest-price strategy ignores evictions
+	filtered = provider.FilterInstanceOfferings(context.Background(), allocationstrategy.NewInstanceOfferings(instanceTypes), requirements, allocationstrategy.Strategy{})
+	g.Expect(filtered[0].InstanceType.Name).To(Equal("Standard_D2s_v3"))
+	g.Expect(filtered[0].Offerings[0].Price).To(Equal(0.1))
+}
+
+func TestFilterInstanceOfferings_AvoidsSpotOfferingsWithPricesRisingTowardOnDemand(t *testing.T) {
+	g := NewWithT(t)
+	spotPriceHistory := azurecache.NewSpotPriceHistory()
+	provider := allocationstrategy.NewProvider(nil, nil, nil, spotPriceHistory)
+	requirements := scheduling.NewRequirements()
+
+	instanceTypes := []*corecloudprovider.InstanceType{
+		{
+			Name: "Standard_D2s_v3",
+			Offerings: corecloudprovider.Offerings{
+				newOfferingWithZone(0.1, karpv1.CapacityTypeSpot, "westus-1"),
+				newOfferingWithZone(0.3, karpv1.CapacityTypeOnDemand, "westus-1"),
+			},
+		},
+		{
+			Name: "Standard_F2s_v2",
+			Offerings: corecloudprovider.Offerings{
+				newOfferingWithZone(0.15, karpv1.CapacityTypeSpot, "westus-1"),
+			},
+		},
+	}
+
+	// without history, ranks by price only
+	filtered := provider.FilterInstanceOfferings(context.Background(), allocationstrategy.NewInstanceOfferings(instanceTypes), requirements, allocationstrategy.Strategy{})
+	g.Expect(filtered[0].InstanceType.Name).To(Equal("Standard_D2s_v3"))
+	g.Expect(filtered[0].Offerings[0].Price).To(Equal(0.1))
+
+	// the spot price of Standard_D2s_v3 rises toward its on-demand price
+	start := time.Now().Add(-5 * 24 * time.Hour)
+	for day := range 5 {
+		spotPriceHistory.Record(start.Add(time.Duration(day)*24*time.Hour), map[string]float64{"Standard_D2s_v3": 0.1 + 0.1*float64(day), "Standard_F2s_v2": 0.2})
+	}
+	filtered = provider.FilterInstanceOfferings(context.Background(), allocationstrategy.NewInstanceOfferings(instanceTypes), requirements, allocationstrategy.Strategy{})
+	g.Expect(filtered[0].InstanceType.Name).To(Equal("Standard_F2s_v2"))
+	// its on-demand offering is kept
+	g.Expect(filtered[1].InstanceType.Name).To(Equal("Standard_D2s_v3"))
+	g.Expect(filtered[1].Offerings).To(HaveLen(1))
+	g.Expect(filtered[1].Offerings[0].Requirements.Get(karpv1.CapacityTypeLabelKey).Any()).To(Equal(karpv1.CapacityTypeOnDemand))
+
+	// the spot offerings are kept when there are no others
+	spotOnly := scheduling.NewRequirements(scheduling.NewRequirement(karpv1.CapacityTypeLabelKey, corev1.NodeSelectorOpIn, karpv1.CapacityTypeSpot))
+	filtered = provider.FilterInstanceOfferings(context.Background(), allocationstrategy.NewInstanceOfferings(instanceTypes[:1]), spotOnly, allocationstrategy.Strategy{})
+	g.Expect(filtered).To(HaveLen(1))
+	g.Expect(filtered[0].Offerings[0].Price).To(Equal(0.1))
+}
+
+func TestResolveStrategy(t *testing.T) {
+	nodePoolWithAnnotations := func(annotations map[string]string) *karpv1.NodePool {
+		return &karpv1.NodePool{ObjectMeta: metav1.ObjectMeta{Name: "default", Annotations: annotations}}
+	}
+	cases := []struct {
+		name               string
+		nodeClassStrategy  *v1beta1.AllocationStrategy
+		nodePool           *karpv1.NodePool
+		expectedType       v1beta1.AllocationStrategyType
+		expectedPriorities []string
+	}{
+		{
+			name:         "defaults to lowest-price",
+			expectedType: v1beta1.AllocationStrategyLowestPrice,
+		},
+		{
+			name: "uses the AKSNodeClass allocation strategy",
+			nodeClassStrategy: &v1beta1.AllocationStrategy{
+				Type:       lo.ToPtr(v1beta1.AllocationStrategyPrioritized),
+				Priorities: []string{"Standard_D2s_v5"},
+			},
+			nodePool:           nodePoolWithAnnotations(nil),
+			expectedType:       v1beta1.AllocationStrategyPrioritized,
+			expectedPriorities: []string{"Standard_D2s_v5"},
+		},
+		{
+			name:              "NodePool annotation overrides the AKSNodeClass",
+			nodeClassStrategy: &v1beta1.AllocationStrategy{Type: lo.ToPtr(v1beta1.AllocationStrategyCapacityOptimized)},
+			nodePool: nodePoolWithAnnotations(map[string]string{
+				v1beta1.AnnotationAllocationStrategy:           string(v1beta1.AllocationStrategyPrioritized),
+				v1beta1.AnnotationAllocationStrategyPriorities: "Standard_E2s_v5, Standard_D2s_v5,",
+			}),
+			expectedType:       v1beta1.AllocationStrategyPrioritized,
+			expectedPriorities: []string{"Standard_E2s_v5", "Standard_D2s_v5"},
+		},
+		{
+			name:              "invalid NodePool annotation is ignored",
+			nodeClassStrategy: &v1beta1.AllocationStrategy{Type: lo.ToPtr(v1beta1.AllocationStrategyCapacityOptimized)},
+			nodePool: nodePoolWithAnnotations(map[string]string{
+				v1beta1.AnnotationAllocationStrategy: "most-expensive",
+			}),
+			expectedType: v1beta1.AllocationStrategyCapacityOptimized,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			g := NewWithT(t)
+			clientBuilder := fake.NewClientBuilder()
+			if c.nodePool != nil {
+				clientBuilder = clientBuilder.WithObjects(c.nodePool)
+			}
+			provider := allocationstrategy.NewProvider(clientBuilder.Build(), nil, nil, nil)
+			nodeClass := test.AKSNodeClass()
+			nodeClass.Spec.AllocationStrategy = c.nodeClassStrategy
+			nodeClaim := &karpv1.NodeClaim{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{karpv1.NodePoolLabelKey: "default"}}}
+
+			strategy := provider.ResolveStrategy(context.Background(), nodeClass, nodeClaim)
+			g.Expect(strategy.GetType()).To(Equal(c.expectedType))
+			g.Expect(strategy.Priorities).To(Equal(c.expectedPriorities))
+		})
+	}
+}
+
+func newInstanceType(name, series string, price float64) *corecloudprovider.InstanceType {
+	return &corecloudprovider.InstanceType{
+		Name: name,
+		Requirements: scheduling.NewRequirements(
+			scheduling.NewRequirement(v1beta1.LabelSKUSeries, corev1.NodeSelectorOpIn, series),
+		),
+		Offerings: corecloudprovider.Offerings{
+			newOfferingWithZone(price, karpv1.CapacityTypeOnDemand, "westus-1"),
+		},
+	}
+}
+
+type fakeCapacityReservations map[string]bool
+
+func (r fakeCapacityReservations) Available(instanceType, zone string) bool {
+	return r[instanceType+"/"+zone]
+}
+
+func TestFilterInstanceOfferings_ReservedOfferingsRankFirst(t *testing.T) {
+	g := NewWithT(t)
+	provider := allocationstrategy.NewProvider(nil, nil, nil, nil)
+	requirements := scheduling.NewRequirements()
+
+	instanceTypes := []*corecloudprovider.InstanceType{
+		{
+			Name: "Standard_D2s_v3",
+			Offerings: corecloudprovider.Offerings{
+				newOfferingWithZone(0.05, karpv1.CapacityTypeSpot, "westus-1"),
+				newOfferingWithZone(0.1, karpv1.CapacityTypeOnDemand, "westus-1"),
+			},
+		},
+		{
+			Name: "Standard_F16s_v2",
+			Offerings: corecloudprovider.Offerings{
+				newOfferingWithZone(0.6, karpv1.CapacityTypeOnDemand, "westus-1"),
+				newOfferingWithZone(0.6, karpv1.CapacityTypeOnDemand, "westus-2"),
+			},
+		},
+	}
+	strategy := allocationstrategy.Strategy{
+		CapacityReservations: fakeCapacityReservations{"Standard_F16s_v2/westus-2": true},
+	}
+
+	for _, strategyType := range []v1beta1.AllocationStrategyType{v1beta1.AllocationStrategyLowestPrice, v1beta1.AllocationStrategyCapacityOptimized, v1beta1.AllocationStrategyLowestPriceDiversified} {
+		strategy.Type = strategyType
+		filtered := provider.FilterInstanceOfferings(context.Background(), allocationstrategy.NewInstanceOfferings(instanceTypes), requirements, strategy)
+		g.Expect(filtered[0].InstanceType.Name).To(Equal("Standard_F16s_v2"), "strategy %s", strategyType)
+		g.Expect(filtered[0].Offerings[0].Price).To(Equal(0.0))
+		g.Expect(filtered[0].Offerings[0].Requirements.Get(corev1.LabelTopologyZone).Any()).To(Equal("westus-2"))
+	}
+	// the cached offerings are not modified
+	g.Expect(instanceTypes[1].Offerings[1].Price).To(Equal(0.6))
+
+	// reserved on-demand offerings rank before cheaper spot offerings
+	strategy = allocationstrategy.Strategy{CapacityReservations: fakeCapacityReservations{"Standard_D2s_v3/westus-1": true}}
+	filtered := provider.FilterInstanceOfferings(context.Background(), allocationstrategy.NewInstanceOfferings(instanceTypes), requirements, strategy)
+	g.Expect(filtered[0].InstanceType.Name).To(Equal("Standard_D2s_v3"))
+	g.Expect(filtered[0].Offerings[0].Price).To(Equal(0.0))
+	g.Expect(filtered[0].Offerings[0].Requirements.Get(karpv1.CapacityTypeLabelKey).Any()).To(Equal(karpv1.CapacityTypeOnDemand))
+	g.Expect(filtered[0].Offerings[1].Price).To(Equal(0.05))
+}
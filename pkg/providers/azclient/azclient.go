@@ -32,6 +32,7 @@ import (
 	"github.com/Azure/karpenter-provider-azure/pkg/operator/options"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/azclient/aksmachinesheaderbatch"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/azclient/azapi"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/capacityreservation"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/imagefamily"
 	imagefamilytypes "github.com/Azure/karpenter-provider-azure/pkg/providers/imagefamily/types"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/instance/skuclient"
@@ -46,18 +47,21 @@ import (
 )
 
 type AZClient struct {
-	azureResourceGraphClient       azapi.AzureResourceGraphAPI
-	virtualMachinesClient          azapi.VirtualMachinesAPI
-	aksMachinesClient              azapi.AKSMachinesAPI
-	aksMachinesBatchClient         aksmachinesheaderbatch.AKSMachinesHeaderBatchAPI
-	agentPoolsClient               azapi.AKSAgentPoolsAPI
-	virtualMachinesExtensionClient azapi.VirtualMachineExtensionsAPI
-	networkInterfacesClient        azapi.NetworkInterfacesAPI
-	subnetsClient                  azapi.SubnetsAPI
-	diskEncryptionSetsClient       azapi.DiskEncryptionSetsAPI
+	azureResourceGraphClient        azapi.AzureResourceGraphAPI
+	virtualMachinesClient           azapi.VirtualMachinesAPI
+	aksMachinesClient               azapi.AKSMachinesAPI
+	aksMachinesBatchClient          aksmachinesheaderbatch.AKSMachinesHeaderBatchAPI
+	agentPoolsClient                azapi.AKSAgentPoolsAPI
+	virtualMachinesExtensionClient  azapi.VirtualMachineExtensionsAPI
+	networkInterfacesClient         azapi.NetworkInterfacesAPI
+	publicIPAddressesClient         azapi.PublicIPAddressesAPI
+	subnetsClient                   azapi.SubnetsAPI
+	applicationSecurityGroupsClient azapi.ApplicationSecurityGroupsAPI
+	diskEncryptionSetsClient        azapi.DiskEncryptionSetsAPI
 
 	NodeImageVersionsClient imagefamilytypes.NodeImageVersionsAPI
 	ImageVersionsClient     imagefamilytypes.CommunityGalleryImageVersionsAPI
+	GalleryImagesClient     imagefamilytypes.GalleryImagesAPI
 	NodeBootstrappingClient imagefamilytypes.NodeBootstrappingAPI
 	// SKU CLIENT is still using track 1 because skewer does not support the track 2 path. We need to refactor this once skewer supports track 2
 	SKUClient                   skewer.ResourceClient
@@ -65,12 +69,18 @@ type AZClient struct {
 	NetworkSecurityGroupsClient networksecuritygroup.API
 	SubscriptionsClient         zone.SubscriptionsAPI
 	UsageClient                 quota.UsageAPI
+	QuotaRequestsClient         quota.RequestsAPI
+	CapacityReservationsClient  capacityreservation.API
 }
 
 func (c *AZClient) SubnetsClient() azapi.SubnetsAPI {
 	return c.subnetsClient
 }
 
+func (c *AZClient) ApplicationSecurityGroupsClient() azapi.ApplicationSecurityGroupsAPI {
+	return c.applicationSecurityGroupsClient
+}
+
 func (c *AZClient) DiskEncryptionSetsClient() azapi.DiskEncryptionSetsAPI {
 	return c.diskEncryptionSetsClient
 }
@@ -99,6 +109,10 @@ func (c *AZClient) NetworkInterfacesClient() azapi.NetworkInterfacesAPI {
 	return c.networkInterfacesClient
 }
 
+func (c *AZClient) PublicIPAddressesClient() azapi.PublicIPAddressesAPI {
+	return c.publicIPAddressesClient
+}
+
 func (c *AZClient) AzureResourceGraphClient() azapi.AzureResourceGraphAPI {
 	return c.azureResourceGraphClient
 }
@@ -111,35 +125,45 @@ func NewAZClientFromAPI(
 	agentPoolsClient azapi.AKSAgentPoolsAPI,
 	virtualMachinesExtensionClient azapi.VirtualMachineExtensionsAPI,
 	interfacesClient azapi.NetworkInterfacesAPI,
+	publicIPAddressesClient azapi.PublicIPAddressesAPI,
 	subnetsClient azapi.SubnetsAPI,
+	applicationSecurityGroupsClient azapi.ApplicationSecurityGroupsAPI,
 	diskEncryptionSetsClient azapi.DiskEncryptionSetsAPI,
 	loadBalancersClient loadbalancer.LoadBalancersAPI,
 	networkSecurityGroupsClient networksecuritygroup.API,
 	imageVersionsClient imagefamilytypes.CommunityGalleryImageVersionsAPI,
 	nodeImageVersionsClient imagefamilytypes.NodeImageVersionsAPI,
+	galleryImagesClient imagefamilytypes.GalleryImagesAPI,
 	nodeBootstrappingClient imagefamilytypes.NodeBootstrappingAPI,
 	skuClient skewer.ResourceClient,
 	subscriptionsClient zone.SubscriptionsAPI,
 	usageClient quota.UsageAPI,
+	quotaRequestsClient quota.RequestsAPI,
+	capacityReservationsClient capacityreservation.API,
 ) *AZClient {
 	return &AZClient{
-		virtualMachinesClient:          virtualMachinesClient,
-		azureResourceGraphClient:       azureResourceGraphClient,
-		aksMachinesClient:              aksMachinesClient,
-		aksMachinesBatchClient:         aksMachinesBatchClient,
-		agentPoolsClient:               agentPoolsClient,
-		virtualMachinesExtensionClient: virtualMachinesExtensionClient,
-		networkInterfacesClient:        interfacesClient,
-		subnetsClient:                  subnetsClient,
-		diskEncryptionSetsClient:       diskEncryptionSetsClient,
-		ImageVersionsClient:            imageVersionsClient,
-		NodeImageVersionsClient:        nodeImageVersionsClient,
-		NodeBootstrappingClient:        nodeBootstrappingClient,
-		SKUClient:                      skuClient,
-		LoadBalancersClient:            loadBalancersClient,
-		NetworkSecurityGroupsClient:    networkSecurityGroupsClient,
-		SubscriptionsClient:            subscriptionsClient,
-		UsageClient:                    usageClient,
+		virtualMachinesClient:           virtualMachinesClient,
+		azureResourceGraphClient:        azureResourceGraphClient,
+		aksMachinesClient:               aksMachinesClient,
+		aksMachinesBatchClient:          aksMachinesBatchClient,
+		agentPoolsClient:                agentPoolsClient,
+		virtualMachinesExtensionClient:  virtualMachinesExtensionClient,
+		networkInterfacesClient:         interfacesClient,
+		publicIPAddressesClient:         publicIPAddressesClient,
+		subnetsClient:                   subnetsClient,
+		applicationSecurityGroupsClient: applicationSecurityGroupsClient,
+		diskEncryptionSetsClient:        diskEncryptionSetsClient,
+		ImageVersionsClient:             imageVersionsClient,
+		NodeImageVersionsClient:         nodeImageVersionsClient,
+		GalleryImagesClient:             galleryImagesClient,
+		NodeBootstrappingClient:         nodeBootstrappingClient,
+		SKUClient:                       skuClient,
+		LoadBalancersClient:             loadBalancersClient,
+		NetworkSecurityGroupsClient:     networkSecurityGroupsClient,
+		SubscriptionsClient:             subscriptionsClient,
+		UsageClient:                     usageClient,
+		QuotaRequestsClient:             quotaRequestsClient,
+		CapacityReservationsClient:      capacityReservationsClient,
 	}
 }
 
@@ -191,6 +215,9 @@ func NewAZClient(ctx context.Context, cfg *auth.Config, env *auth.Environment, c
 		return nil, err
 	}
 
+	// Custom images may live in any subscription, so this client isn't bound to the cluster's
+	galleryImagesClient := imagefamily.NewGalleryImagesClient(cred, opts)
+
 	loadBalancersClient, err := armnetwork.NewLoadBalancersClient(cfg.SubscriptionID, cred, opts)
 	if err != nil {
 		return nil, err
@@ -201,6 +228,16 @@ func NewAZClient(ctx context.Context, cfg *auth.Config, env *auth.Environment, c
 		return nil, err
 	}
 
+	applicationSecurityGroupsClient, err := armnetwork.NewApplicationSecurityGroupsClient(cfg.SubscriptionID, cred, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	publicIPAddressesClient, err := armnetwork.NewPublicIPAddressesClient(cfg.SubscriptionID, cred, opts)
+	if err != nil {
+		return nil, err
+	}
+
 	subscriptionsClient, err := armsubscriptions.NewClient(cred, opts)
 	if err != nil {
 		return nil, err
@@ -222,6 +259,19 @@ func NewAZClient(ctx context.Context, cfg *auth.Config, env *auth.Environment, c
 		return nil, err
 	}
 
+	// The Microsoft.Quota API is only used to request quota increases (see --quota-increase-max-limit),
+	// and requires the Quota Request Operator role.
+	quotaRequestsClient, err := quota.NewRequestsClient(cfg.SubscriptionID, cfg.Location, cred, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Used to discover the unused reserved capacity of AKSNodeClass capacity reservation groups
+	capacityReservationsClient, err := armcompute.NewCapacityReservationsClient(cfg.SubscriptionID, cred, opts)
+	if err != nil {
+		return nil, err
+	}
+
 	// TODO: this one is not enabled for rate limiting / throttling ...
 	// TODO Move this over to track 2 when skewer is migrated
 	skuClient := skuclient.NewSkuClient(cfg.SubscriptionID, cred, env.Cloud)
@@ -298,15 +348,20 @@ func NewAZClient(ctx context.Context, cfg *auth.Config, env *auth.Environment, c
 		agentPoolsClient,
 		extensionsClient,
 		interfacesClient,
+		publicIPAddressesClient,
 		subnetsClient,
+		applicationSecurityGroupsClient,
 		diskEncryptionSetsClient,
 		loadBalancersClient,
 		networkSecurityGroupsClient,
 		communityImageVersionsClient,
 		nodeImageVersionsClient,
+		galleryImagesClient,
 		nodeBootstrappingClient,
 		skuClient,
 		subscriptionsClient,
 		usageClient,
+		quotaRequestsClient,
+		capacityReservationsClient,
 	), nil
 }
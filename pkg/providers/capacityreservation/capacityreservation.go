@@ -0,0 +1,265 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package capacityreservation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v7"
+	"github.com/patrickmn/go-cache"
+	"github.com/samber/lo"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/Azure/karpenter-provider-azure/pkg/utils/zones"
+)
+
+const (
+	// RefreshInterval is how often the reservations of a capacity reservation group, and their utilization, are refreshed
+	RefreshInterval = 1 * time.Minute
+	// CapacityReservationsCacheTTL is how long the reservations of a capacity reservation group are used without being refreshed
+	CapacityReservationsCacheTTL = 5 * time.Minute
+	// ExhaustedReservationTTL is how long a reservation is considered exhausted after a create failed because of it,
+	// regardless of the utilization reported for it
+	ExhaustedReservationTTL = 10 * time.Minute
+	// UnavailableGroupTTL is how long a capacity reservation group is not used after it was found missing, or not usable by Karpenter
+	UnavailableGroupTTL = 10 * time.Minute
+
+	GroupUnavailableReasonNotFound     = "CapacityReservationGroupNotFound"
+	GroupUnavailableReasonUnauthorized = "CapacityReservationGroupUnauthorized"
+)
+
+type API interface {
+	NewListByCapacityReservationGroupPager(resourceGroupName string, capacityReservationGroupName string, options *armcompute.CapacityReservationsClientListByCapacityReservationGroupOptions) *runtime.Pager[armcompute.CapacityReservationsClientListByCapacityReservationGroupResponse]
+	Get(ctx context.Context, resourceGroupName string, capacityReservationGroupName string, capacityReservationName string, options *armcompute.CapacityReservationsClientGetOptions) (armcompute.CapacityReservationsClientGetResponse, error)
+}
+
+// Reservation is the reserved capacity for a VM size in a zone, within a capacity reservation group
+type Reservation struct {
+	Name         string
+	InstanceType string
+	// Zone is the AKS zone label of the reservation, or zones.Regional for regional reservations
+	Zone      string
+	Capacity  int64
+	Allocated int64
+}
+
+// Reservations is the unused reserved capacity of a capacity reservation group
+type Reservations struct {
+	groupID   string
+	available map[string]Reservation
+	// key: <instanceType>:<zone> (lowercase) of the VM sizes and zones whose reservations were found exhausted
+	exhausted map[string]bool
+}
+
+// GroupID returns the ID of the capacity reservation group the reservations belong to
+func (r *Reservations) GroupID() string {
+	if r == nil {
+		return ""
+	}
+	return r.groupID
+}
+
+// Available returns true if the capacity reservation group has unused reserved capacity for the VM size in the zone,
+// either reserved in that zone or regionally
+func (r *Reservations) Available(instanceType, zone string) bool {
+	if r == nil || r.exhausted[key(instanceType, zone)] {
+		return false
+	}
+	_, zonal := r.available[key(instanceType, zone)]
+	_, regional := r.available[key(instanceType, zones.Regional)]
+	return zonal || regional
+}
+
+type cachedReservations struct {
+	reservations []Reservation
+	refreshedAt  time.Time
+}
+
+// Provider retrieves the reserved capacity of capacity reservation groups. The reservations are refreshed in the
+// background, through Refresh, so that retrieving their utilization doesn't delay launches.
+type Provider struct {
+	api      API
+	location string
+
+	mu           sync.Mutex
+	reservations *cache.Cache
+	exhausted    *cache.Cache
+	// key: capacity reservation group ID (lowercase), value: the reason it is unavailable
+	unavailableGroups *cache.Cache
+}
+
+func NewProvider(api API, location string) *Provider {
+	return &Provider{
+		api:               api,
+		location:          location,
+		reservations:      cache.New(CapacityReservationsCacheTTL, time.Minute),
+		exhausted:         cache.New(ExhaustedReservationTTL, time.Minute),
+		unavailableGroups: cache.New(UnavailableGroupTTL, time.Minute),
+	}
+}
+
+// Get returns the unused reserved capacity of the capacity reservation group. It returns nil if the reservations of the
+// group have not been refreshed yet, or if the group is unavailable.
+func (p *Provider) Get(groupID string) *Reservations {
+	if _, unavailable := p.unavailableGroups.Get(strings.ToLower(groupID)); unavailable {
+		return nil
+	}
+	cached, ok := p.reservations.Get(strings.ToLower(groupID))
+	if !ok {
+		return nil
+	}
+
+	available := map[string]Reservation{}
+	for _, reservation := range cached.(cachedReservations).reservations {
+		if reservation.Allocated < reservation.Capacity {
+			available[key(reservation.InstanceType, reservation.Zone)] = reservation
+		}
+	}
+	exhausted := map[string]bool{}
+	prefix := strings.ToLower(groupID) + ":"
+	for k := range p.exhausted.Items() {
+		if strings.HasPrefix(k, prefix) {
+			exhausted[strings.TrimPrefix(k, prefix)] = true
+		}
+	}
+	return &Reservations{groupID: groupID, available: available, exhausted: exhausted}
+}
+
+// Refresh retrieves the reservations of the capacity reservation group and their utilization, unless they were refreshed
+// within RefreshInterval. If the group is missing, or Karpenter isn't allowed to read it, it is marked unavailable.
+func (p *Provider) Refresh(ctx context.Context, groupID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if cached, ok := p.reservations.Get(strings.ToLower(groupID)); ok && time.Since(cached.(cachedReservations).refreshedAt) < RefreshInterval {
+		return nil
+	}
+	reservations, err := p.list(ctx, groupID)
+	if err != nil {
+		if reason := GroupUnavailableReason(err); reason != "" {
+			p.MarkGroupUnavailable(ctx, groupID, reason)
+		}
+		return err
+	}
+	p.unavailableGroups.Delete(strings.ToLower(groupID))
+	p.reservations.SetDefault(strings.ToLower(groupID), cachedReservations{reservations: reservations, refreshedAt: time.Now()})
+	return nil
+}
+
+// MarkExhausted marks the reservation of the VM size in the zone as exhausted, so that instances are created without it
+// until ExhaustedReservationTTL passes
+func (p *Provider) MarkExhausted(ctx context.Context, groupID, instanceType, zone string) {
+	log.FromContext(ctx).V(1).Info("marking capacity reservation as exhausted", "capacityReservationGroupID", groupID, "instance-type", instanceType, "zone", zone, "ttl", ExhaustedReservationTTL)
+	p.exhausted.SetDefault(exhaustedKey(groupID, instanceType, zone), struct{}{})
+}
+
+// MarkGroupUnavailable marks the whole capacity reservation group as unavailable, so that instances are created without it
+// until UnavailableGroupTTL passes, or a refresh finds it again
+func (p *Provider) MarkGroupUnavailable(ctx context.Context, groupID, reason string) {
+	log.FromContext(ctx).V(1).Info("marking capacity reservation group as unavailable", "capacityReservationGroupID", groupID, "reason", reason, "ttl", UnavailableGroupTTL)
+	p.unavailableGroups.SetDefault(strings.ToLower(groupID), reason)
+}
+
+func (p *Provider) Reset() {
+	p.reservations.Flush()
+	p.exhausted.Flush()
+	p.unavailableGroups.Flush()
+}
+
+// GroupUnavailableReason returns why the capacity reservation group can't be used at all according to the error, or an empty
+// string if the error doesn't concern the whole group
+func GroupUnavailableReason(err error) string {
+	var respErr *azcore.ResponseError
+	if !errors.As(err, &respErr) {
+		return ""
+	}
+	switch {
+	case respErr.StatusCode == http.StatusNotFound || strings.EqualFold(respErr.ErrorCode, GroupUnavailableReasonNotFound):
+		return GroupUnavailableReasonNotFound
+	case respErr.StatusCode == http.StatusForbidden || strings.EqualFold(respErr.ErrorCode, "AuthorizationFailed") || strings.EqualFold(respErr.ErrorCode, "LinkedAuthorizationFailed"):
+		return GroupUnavailableReasonUnauthorized
+	}
+	return ""
+}
+
+func (p *Provider) list(ctx context.Context, groupID string) ([]Reservation, error) {
+	id, err := arm.ParseResourceID(groupID)
+	if err != nil {
+		return nil, fmt.Errorf("parsing capacity reservation group ID %q, %w", groupID, err)
+	}
+
+	var reservations []Reservation
+	pager := p.api.NewListByCapacityReservationGroupPager(id.ResourceGroupName, id.Name, nil)
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("listing capacity reservations of %q, %w", groupID, err)
+		}
+		for _, capacityReservation := range page.Value {
+			if capacityReservation == nil || capacityReservation.SKU == nil {
+				continue
+			}
+			zone, err := zones.MakeAKSLabelZoneFromARMZones(p.location, capacityReservation.Zones)
+			if err != nil {
+				return nil, fmt.Errorf("getting zone of capacity reservation %q, %w", lo.FromPtr(capacityReservation.Name), err)
+			}
+			// The utilization of reservations is only returned with their instance view
+			resp, err := p.api.Get(ctx, id.ResourceGroupName, id.Name, lo.FromPtr(capacityReservation.Name), &armcompute.CapacityReservationsClientGetOptions{
+				Expand: lo.ToPtr(armcompute.CapacityReservationInstanceViewTypesInstanceView),
+			})
+			if err != nil {
+				// deleted since it was listed
+				if respErr := (*azcore.ResponseError)(nil); errors.As(err, &respErr) && respErr.StatusCode == http.StatusNotFound {
+					continue
+				}
+				return nil, fmt.Errorf("getting capacity reservation %q, %w", lo.FromPtr(capacityReservation.Name), err)
+			}
+			reservations = append(reservations, Reservation{
+				Name:         lo.FromPtr(capacityReservation.Name),
+				InstanceType: lo.FromPtr(capacityReservation.SKU.Name),
+				Zone:         zone,
+				Capacity:     lo.FromPtr(capacityReservation.SKU.Capacity),
+				Allocated:    int64(len(virtualMachinesAllocated(&resp.CapacityReservation))),
+			})
+		}
+	}
+	log.FromContext(ctx).V(1).Info("discovered capacity reservations", "capacityReservationGroupID", groupID, "reservations", reservations)
+	return reservations, nil
+}
+
+func virtualMachinesAllocated(capacityReservation *armcompute.CapacityReservation) []*armcompute.SubResourceReadOnly {
+	if capacityReservation.Properties == nil || capacityReservation.Properties.InstanceView == nil || capacityReservation.Properties.InstanceView.UtilizationInfo == nil {
+		return nil
+	}
+	return capacityReservation.Properties.InstanceView.UtilizationInfo.VirtualMachinesAllocated
+}
+
+func key(instanceType, zone string) string {
+	return strings.ToLower(fmt.Sprintf("%s:%s", instanceType, zone))
+}
+
+func exhaustedKey(groupID, instanceType, zone string) string {
+	return strings.ToLower(groupID) + ":" + key(instanceType, zone)
+}
@@ -0,0 +1,169 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package capacityreservation_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v7"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+
+	"github.com/Azure/karpenter-provider-azure/pkg/fake"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/capacityreservation"
+)
+
+const testGroupID = "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/capacityReservationGroups/crg"
+
+func newCapacityReservation(name string, zones []*string, capacity int64, allocated int) *armcompute.CapacityReservation {
+	return &armcompute.CapacityReservation{
+		Name:  lo.ToPtr(name),
+		SKU:   &armcompute.SKU{Name: lo.ToPtr(name), Capacity: lo.ToPtr(capacity)},
+		Zones: zones,
+		Properties: &armcompute.CapacityReservationProperties{
+			InstanceView: &armcompute.CapacityReservationInstanceView{
+				UtilizationInfo: &armcompute.CapacityReservationUtilization{
+					VirtualMachinesAllocated: lo.Times(allocated, func(_ int) *armcompute.SubResourceReadOnly { return &armcompute.SubResourceReadOnly{} }),
+				},
+			},
+		},
+	}
+}
+
+func TestGet_ReturnsReservationsWithUnusedCapacity(t *testing.T) {
+	g := NewWithT(t)
+	api := &fake.CapacityReservationsAPI{}
+	api.CapacityReservations.Append(
+		newCapacityReservation("Standard_D2s_v3", []*string{lo.ToPtr("1")}, 2, 1),
+		newCapacityReservation("Standard_D4s_v3", []*string{lo.ToPtr("2")}, 2, 2),
+		newCapacityReservation("Standard_F16s_v2", nil, 1, 0),
+	)
+	provider := capacityreservation.NewProvider(api, "westus")
+
+	// not refreshed yet
+	g.Expect(provider.Get(testGroupID)).To(BeNil())
+
+	g.Expect(provider.Refresh(context.Background(), testGroupID)).To(Succeed())
+	reservations := provider.Get(testGroupID)
+	g.Expect(reservations.GroupID()).To(Equal(testGroupID))
+	g.Expect(reservations.Available("Standard_D2s_v3", "westus-1")).To(BeTrue())
+	g.Expect(reservations.Available("standard_d2s_v3", "westus-1")).To(BeTrue())
+	g.Expect(reservations.Available("Standard_D2s_v3", "westus-2")).To(BeFalse())
+	// fully allocated
+	g.Expect(reservations.Available("Standard_D4s_v3", "westus-2")).To(BeFalse())
+	// regional reservations can be used in any zone
+	g.Expect(reservations.Available("Standard_F16s_v2", "0")).To(BeTrue())
+	g.Expect(reservations.Available("Standard_F16s_v2", "westus-1")).To(BeTrue())
+}
+
+func TestRefresh_CachesReservations(t *testing.T) {
+	g := NewWithT(t)
+	api := &fake.CapacityReservationsAPI{}
+	api.CapacityReservations.Append(newCapacityReservation("Standard_D2s_v3", []*string{lo.ToPtr("1")}, 2, 1))
+	provider := capacityreservation.NewProvider(api, "westus")
+
+	g.Expect(provider.Refresh(context.Background(), testGroupID)).To(Succeed())
+
+	// refreshed within the refresh interval, the reservations are not listed again
+	api.Error = &azcore.ResponseError{ErrorCode: "TooManyRequests", StatusCode: http.StatusTooManyRequests}
+	g.Expect(provider.Refresh(context.Background(), testGroupID)).To(Succeed())
+	g.Expect(provider.Get(testGroupID).Available("Standard_D2s_v3", "westus-1")).To(BeTrue())
+
+	provider.Reset()
+	g.Expect(provider.Refresh(context.Background(), testGroupID)).ToNot(Succeed())
+	// transient errors don't make the group unavailable
+	g.Expect(provider.Get(testGroupID)).To(BeNil())
+	api.Error = nil
+	g.Expect(provider.Refresh(context.Background(), testGroupID)).To(Succeed())
+	g.Expect(provider.Get(testGroupID)).ToNot(BeNil())
+}
+
+func TestRefresh_InvalidGroupID(t *testing.T) {
+	g := NewWithT(t)
+	provider := capacityreservation.NewProvider(&fake.CapacityReservationsAPI{}, "westus")
+
+	g.Expect(provider.Refresh(context.Background(), "crg")).ToNot(Succeed())
+}
+
+func TestRefresh_MarksMissingOrUnauthorizedGroupUnavailable(t *testing.T) {
+	for _, tc := range []struct {
+		err    error
+		reason string
+	}{
+		{err: &azcore.ResponseError{ErrorCode: "ResourceNotFound", StatusCode: http.StatusNotFound}, reason: capacityreservation.GroupUnavailableReasonNotFound},
+		{err: &azcore.ResponseError{ErrorCode: "AuthorizationFailed", StatusCode: http.StatusForbidden}, reason: capacityreservation.GroupUnavailableReasonUnauthorized},
+	} {
+		t.Run(tc.reason, func(t *testing.T) {
+			g := NewWithT(t)
+			api := &fake.CapacityReservationsAPI{}
+			api.CapacityReservations.Append(newCapacityReservation("Standard_D2s_v3", []*string{lo.ToPtr("1")}, 2, 0))
+			provider := capacityreservation.NewProvider(api, "westus")
+			g.Expect(provider.Refresh(context.Background(), testGroupID)).To(Succeed())
+			g.Expect(provider.Get(testGroupID)).ToNot(BeNil())
+
+			provider.Reset()
+			api.Error = tc.err
+			err := provider.Refresh(context.Background(), testGroupID)
+			g.Expect(err).To(HaveOccurred())
+			g.Expect(capacityreservation.GroupUnavailableReason(err)).To(Equal(tc.reason))
+			g.Expect(provider.Get(testGroupID)).To(BeNil())
+
+			// found again
+			api.Error = nil
+			g.Expect(provider.Refresh(context.Background(), testGroupID)).To(Succeed())
+			g.Expect(provider.Get(testGroupID).Available("Standard_D2s_v3", "westus-1")).To(BeTrue())
+		})
+	}
+}
+
+func TestMarkExhausted(t *testing.T) {
+	g := NewWithT(t)
+	api := &fake.CapacityReservationsAPI{}
+	api.CapacityReservations.Append(
+		newCapacityReservation("Standard_D2s_v3", []*string{lo.ToPtr("1")}, 2, 0),
+		newCapacityReservation("Standard_F16s_v2", nil, 2, 0),
+	)
+	provider := capacityreservation.NewProvider(api, "westus")
+	g.Expect(provider.Refresh(context.Background(), testGroupID)).To(Succeed())
+
+	provider.MarkExhausted(context.Background(), testGroupID, "Standard_D2s_v3", "westus-1")
+	provider.MarkExhausted(context.Background(), testGroupID, "Standard_F16s_v2", "westus-2")
+	reservations := provider.Get(testGroupID)
+	g.Expect(reservations.Available("Standard_D2s_v3", "westus-1")).To(BeFalse())
+	// a regional reservation exhausted in one zone can still be used in the others
+	g.Expect(reservations.Available("Standard_F16s_v2", "westus-2")).To(BeFalse())
+	g.Expect(reservations.Available("Standard_F16s_v2", "westus-1")).To(BeTrue())
+
+	// a nil set of reservations has no available capacity
+	var none *capacityreservation.Reservations
+	g.Expect(none.Available("Standard_D2s_v3", "westus-1")).To(BeFalse())
+	g.Expect(none.GroupID()).To(BeEmpty())
+}
+
+func TestMarkGroupUnavailable(t *testing.T) {
+	g := NewWithT(t)
+	api := &fake.CapacityReservationsAPI{}
+	api.CapacityReservations.Append(newCapacityReservation("Standard_D2s_v3", []*string{lo.ToPtr("1")}, 2, 0))
+	provider := capacityreservation.NewProvider(api, "westus")
+	g.Expect(provider.Refresh(context.Background(), testGroupID)).To(Succeed())
+
+	provider.MarkGroupUnavailable(context.Background(), testGroupID, capacityreservation.GroupUnavailableReasonNotFound)
+	g.Expect(provider.Get(testGroupID)).To(BeNil())
+}
@@ -155,6 +155,10 @@ func (u AzureLinux) ScriptlessCustomData(
 			GPUImageSHA:                  u.Options.GPUImageSHA,
 			GPUDriverInstallationEnabled: u.Options.GPUDriverInstallationEnabled,
 			SubnetID:                     u.Options.SubnetID,
+			PodSubnetID:                  u.Options.PodSubnetID,
+			DataDisks:                    u.Options.DataDisks,
+			PreBootstrapScript:           u.Options.PreBootstrapScript,
+			PostBootstrapScript:          u.Options.PostBootstrapScript,
 		},
 		Arch:                           u.Options.Arch,
 		TenantID:                       u.Options.TenantID,
@@ -168,6 +172,7 @@ func (u AzureLinux) ScriptlessCustomData(
 		KubeletClientTLSBootstrapToken: u.Options.KubeletClientTLSBootstrapToken,
 		NetworkPlugin:                  u.Options.NetworkPlugin,
 		NetworkPolicy:                  u.Options.NetworkPolicy,
+		IPv6DualStackEnabled:           u.Options.IPv6DualStackEnabled,
 		KubernetesVersion:              u.Options.KubernetesVersion,
 	}
 }
@@ -196,6 +201,7 @@ func (u AzureLinux) CustomScriptsNodeBootstrapping(
 		StartupTaints:                  startupTaints,
 		Labels:                         labels,
 		SubnetID:                       u.Options.SubnetID,
+		PodSubnetID:                    u.Options.PodSubnetID,
 		Arch:                           u.Options.Arch,
 		SubscriptionID:                 u.Options.SubscriptionID,
 		ResourceGroup:                  u.Options.ResourceGroup,
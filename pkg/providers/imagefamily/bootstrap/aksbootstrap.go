@@ -46,6 +46,7 @@ type AKS struct {
 	KubeletClientTLSBootstrapToken string
 	NetworkPlugin                  string
 	NetworkPolicy                  string
+	IPv6DualStackEnabled           bool
 	KubernetesVersion              string
 }
 
@@ -168,7 +169,7 @@ type NodeBootstrapVariables struct {
 	IsKrustlet                              bool     // t   user input
 	GPUNeedsFabricManager                   bool     // v   determined by GPU hardware type
 	NeedsDockerLogin                        bool     // t   user input [still needed?]
-	IPv6DualStackEnabled                    bool     // t   user input
+	IPv6DualStackEnabled                    bool     // x   unique per cluster
 	OutboundCommand                         string   // s   mostly static/can be
 	EnableUnattendedUpgrades                bool     // c   user input [presumably cluster level, correct?]
 	EnsureNoDupePromiscuousBridge           bool     // k   derived {{ and NeedsContainerd IsKubenet (not HasCalicoNetworkPolicy) }} [could be computed by template ...]
@@ -220,6 +221,9 @@ type NodeBootstrapVariables struct {
 	KubeCACrt                               string   // x   unique per cluster
 	ContainerdConfigContent                 string   // k   determined by GPU VM size, WASM support, Kata support
 	IsKata                                  bool     // n   user-specified
+	DataDisksScript                         string   // t   user-specified
+	PreBootstrapCommand                     string   // t   user-specified
+	PostBootstrapCommand                    string   // t   user-specified
 }
 
 func (a AKS) aksBootstrapScript() (string, error) {
@@ -235,6 +239,11 @@ func (a AKS) aksBootstrapScript() (string, error) {
 	}
 
 	nbv.ContainerdConfigContent = base64.StdEncoding.EncodeToString([]byte(containerdConfigTemplate))
+	if nbv.DataDisksScript, err = DataDisksScript(a.DataDisks); err != nil {
+		return "", err
+	}
+	nbv.PreBootstrapCommand = HookCommand(HookPreBootstrap, a.PreBootstrapScript)
+	nbv.PostBootstrapCommand = HookCommand(HookPostBootstrap, a.PostBootstrapScript)
 	// generate script from template using the variables
 	customData, err := getCustomDataFromNodeBootstrapVars(nbv)
 	if err != nil {
@@ -297,10 +306,11 @@ func (a AKS) applyOptions(nbv *NodeBootstrapVariables) {
 	nbv.NetworkPlugin = a.NetworkPlugin
 
 	nbv.NetworkPolicy = a.NetworkPolicy
+	nbv.IPv6DualStackEnabled = a.IPv6DualStackEnabled
 	nbv.KubernetesVersion = a.KubernetesVersion
 
 	nbv.KubeBinaryURL = kubeBinaryURL(a.KubernetesVersion, a.Arch)
-	nbv.VNETCNILinuxPluginsURL = fmt.Sprintf("%s/azure-cni/v1.4.32/binaries/azure-vnet-cni-linux-%s-v1.4.32.tgz", globalAKSMirror, a.Arch)
+	nbv.VNETCNILinuxPluginsURL = fmt.Sprintf("%s/azure-cni/%s/binaries/%s-linux-%s-%s.tgz", globalAKSMirror, vnetCNIVersion, vnetCNIPackage(a.PodSubnetID), a.Arch, vnetCNIVersion)
 	nbv.CNIPluginsURL = fmt.Sprintf("%s/cni-plugins/v1.1.1/binaries/cni-plugins-linux-%s-v1.1.1.tgz", globalAKSMirror, a.Arch)
 	// calculated values
 	nbv.NetworkSecurityGroup = a.NetworkSecurityGroupName
@@ -322,7 +332,7 @@ func (a AKS) applyOptions(nbv *NodeBootstrapVariables) {
 	}
 
 	// merge and stringify labels
-	kubeletLabels := a.Labels
+	kubeletLabels := lo.Assign(a.Labels, podSubnetLabels(a.PodSubnetID))
 
 	subnetParts, _ := utils.GetVnetSubnetIDComponents(a.SubnetID)
 	nbv.Subnet = subnetParts.SubnetName
@@ -381,6 +391,19 @@ func containerdConfigFromNodeBootstrapVars(nbv *NodeBootstrapVariables) (string,
 	return buffer.String(), nil
 }
 
+// DataDisksScript returns a bash script that waits for the data disks to be attached, formats them on first boot,
+// and mounts them. It is empty if there are no data disks.
+func DataDisksScript(dataDisks []DataDisk) (string, error) {
+	if len(dataDisks) == 0 {
+		return "", nil
+	}
+	var buffer bytes.Buffer
+	if err := getDataDisksTemplate().Execute(&buffer, dataDisks); err != nil {
+		return "", fmt.Errorf("error executing data disks template: %w", err)
+	}
+	return buffer.String(), nil
+}
+
 func getCustomDataFromNodeBootstrapVars(nbv *NodeBootstrapVariables) (string, error) {
 	var buffer bytes.Buffer
 	if err := getCustomDataTemplate().Execute(&buffer, *nbv); err != nil {
@@ -18,6 +18,7 @@ package bootstrap
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -206,3 +207,51 @@ func TestKubeletConfigMap(t *testing.T) {
 		g.Expect(actualKubeletConfig[k]).To(Equal(v), fmt.Sprintf("parameter mismatch for %s", k))
 	}
 }
+
+func TestDataDisksScript(t *testing.T) {
+	g := NewWithT(t)
+
+	script, err := DataDisksScript(nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(script).To(BeEmpty())
+
+	script, err = DataDisksScript([]DataDisk{{LUN: 0, MountPath: "/data"}, {LUN: 1, MountPath: "/mnt/cache"}})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(script).To(ContainSubstring("/dev/disk/azure/data/by-lun/0 /dev/disk/azure/scsi1/lun0"))
+	g.Expect(script).To(ContainSubstring("/dev/disk/azure/data/by-lun/1 /dev/disk/azure/scsi1/lun1"))
+	g.Expect(script).To(ContainSubstring(`mkdir -p "/data"`))
+	g.Expect(script).To(ContainSubstring(`mount "/mnt/cache"`))
+	g.Expect(script).To(HaveSuffix("\n"))
+}
+
+func TestApplyOptionsPodSubnet(t *testing.T) {
+	g := NewWithT(t)
+	a := AKS{
+		Options: Options{
+			Labels:   map[string]string{"kubernetes.azure.com/mode": "user"},
+			CABundle: lo.ToPtr("Y2EtYnVuZGxl"),
+			SubnetID: "/subscriptions/sub/resourceGroups/vnet-rg/providers/Microsoft.Network/virtualNetworks/vnet/subnets/nodes",
+		},
+		Arch:              "amd64",
+		KubernetesVersion: "1.33.2",
+	}
+
+	nbv := &NodeBootstrapVariables{}
+	a.applyOptions(nbv)
+	g.Expect(nbv.VNETCNILinuxPluginsURL).To(HaveSuffix("/azure-cni/v1.4.32/binaries/azure-vnet-cni-linux-amd64-v1.4.32.tgz"))
+	g.Expect(nbv.KubeletNodeLabels).ToNot(ContainSubstring("podnetwork"))
+
+	a.PodSubnetID = "/subscriptions/pod-sub/resourceGroups/pod-rg/providers/Microsoft.Network/virtualNetworks/pod-vnet/subnets/pods"
+	nbv = &NodeBootstrapVariables{}
+	a.applyOptions(nbv)
+	g.Expect(nbv.VNETCNILinuxPluginsURL).To(HaveSuffix("/azure-cni/v1.4.32/binaries/azure-vnet-cni-swift-linux-amd64-v1.4.32.tgz"))
+	g.Expect(strings.Split(nbv.KubeletNodeLabels, ",")).To(ContainElements(
+		"kubernetes.azure.com/mode=user",
+		"kubernetes.azure.com/podnetwork-subnet=pods",
+		"kubernetes.azure.com/podnetwork-name=pod-vnet",
+		"kubernetes.azure.com/podnetwork-resourcegroup=pod-rg",
+		"kubernetes.azure.com/podnetwork-subscription=pod-sub",
+	))
+	// the labels of the caller are left as they are
+	g.Expect(a.Labels).To(HaveLen(1))
+}
@@ -0,0 +1,168 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bootstrap
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/Azure/karpenter-provider-azure/pkg/utils"
+)
+
+// AKSWindows bootstraps Windows nodes. Unlike Linux nodes, Windows does not execute custom data on its own,
+// so the custom data only carries the bootstrap script, which is then run by a Custom Script Extension (see CSE).
+type AKSWindows struct {
+	Options
+
+	TenantID                       string
+	SubscriptionID                 string
+	KubeletIdentityClientID        string
+	Location                       string
+	ResourceGroup                  string
+	NetworkSecurityGroupName       string
+	RouteTableName                 string
+	APIServerName                  string
+	KubeletClientTLSBootstrapToken string
+	NetworkPlugin                  string
+	NetworkPolicy                  string
+	KubernetesVersion              string
+	// TargetEnvironment is the name of the Azure environment, e.g. AzurePublicCloud; defaults to AzurePublicCloud
+	TargetEnvironment string
+}
+
+var _ WindowsBootstrapper = (*AKSWindows)(nil) // assert AKSWindows implements WindowsBootstrapper
+
+// WindowsNodeBootstrapVariables carries all variables needed to bootstrap a Windows node
+// It is used as input rendering the Windows bootstrap script Go template (retrieved from getWindowsCustomDataTemplate)
+type WindowsNodeBootstrapVariables struct {
+	KubernetesVersion           string // ?   cluster/node pool specific, derived from user input
+	KubeBinariesPackageURL      string // s   static-ish, per k8s version
+	CSEScriptsPackageURL        string // s   only used if the package is not cached on the image
+	VNETCNIPluginsURL           string // s   static-ish
+	TenantID                    string // p   environment derived
+	SubscriptionID              string // a   can be derived from environment/imds
+	ResourceGroup               string // a   can be derived from environment/imds
+	Subnet                      string // xd  derived from cluster but only used by CCM
+	NetworkSecurityGroup        string // xk  derived from cluster but only used by CCM
+	VirtualNetwork              string // xd  derived from cluster but only used by CCM
+	VirtualNetworkResourceGroup string // xd  derived from cluster but only used by CCM
+	RouteTable                  string // xk  derived from cluster but only used by CCM
+	NetworkPlugin               string // x   unique per cluster
+	NetworkPolicy               string // x   unique per cluster
+	TLSBootstrapToken           string // X   nodepool or node specific
+	KubeCACrt                   string // x   unique per cluster
+	KubeletNodeLabels           string // pk  node-pool specific. user-specified.
+	KubeletConfigArgs           string // psX unique per nodepool. partially user-specified, static, and RP-generated
+}
+
+// Script returns the base64 encoded Windows bootstrap script, to be used as VM custom data
+func (a AKSWindows) Script() (string, error) {
+	nbv := a.nodeBootstrapVars()
+
+	var buffer bytes.Buffer
+	if err := getWindowsCustomDataTemplate().Execute(&buffer, *nbv); err != nil {
+		return "", fmt.Errorf("error executing windows custom data template: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(buffer.Bytes()), nil
+}
+
+// CSE returns the command for the Custom Script Extension, which runs the bootstrap script passed as custom data
+func (a AKSWindows) CSE() (string, error) {
+	if a.APIServerName == "" {
+		return "", fmt.Errorf("API server name is required to bootstrap windows nodes")
+	}
+	clusterDNSServiceIP := defaultClusterDNSServiceIP
+	if a.KubeletConfig != nil && a.KubeletConfig.ClusterDNSServiceIP != "" {
+		clusterDNSServiceIP = a.KubeletConfig.ClusterDNSServiceIP
+	}
+	arguments := []string{
+		"-MasterIP " + a.APIServerName,
+		"-KubeDnsServiceIp " + clusterDNSServiceIP,
+		"-MasterFQDNPrefix " + strings.Split(a.APIServerName, ".")[0],
+		"-Location " + a.Location,
+		"-TargetEnvironment " + lo.Ternary(a.TargetEnvironment != "", a.TargetEnvironment, defaultTargetEnvironment),
+		"-LogFile " + windowsCustomDataDir + `\CustomDataSetupScript.log`,
+		"-CSEResultFilePath " + windowsCustomDataDir + `\CSEResult.log`,
+	}
+	if a.KubeletIdentityClientID != "" {
+		arguments = append(arguments, "-UserAssignedClientID "+a.KubeletIdentityClientID)
+	}
+
+	// This is the same mechanism AKS uses for Windows nodes: copy the custom data into a script and run it
+	return fmt.Sprintf(
+		`powershell.exe -ExecutionPolicy Unrestricted -command "$arguments = '%[1]s'; $inputFile = '%[2]s\CustomData.bin'; $outputFile = '%[2]s\CustomDataSetupScript.ps1'; `+
+			`if (!(Test-Path $inputFile)) { throw 'ExitCode: |49|, Output: |WINDOWS_CSE_ERROR_NO_CUSTOM_DATA_BIN|, Error: |%[2]s\CustomData.bin does not exist.|' }; `+
+			`Copy-Item $inputFile $outputFile; Invoke-Expression('{0} {1}' -f $outputFile, $arguments); "`,
+		strings.Join(arguments, " "), windowsCustomDataDir,
+	), nil
+}
+
+func (a AKSWindows) nodeBootstrapVars() *WindowsNodeBootstrapVariables {
+	nbv := &WindowsNodeBootstrapVariables{
+		KubernetesVersion:      a.KubernetesVersion,
+		KubeBinariesPackageURL: windowsKubeBinariesPackageURL(a.KubernetesVersion),
+		CSEScriptsPackageURL:   windowsCSEScriptsPackageURL,
+		VNETCNIPluginsURL:      fmt.Sprintf("%s/azure-cni/%s/binaries/%s-windows-amd64-%s.zip", globalAKSMirror, vnetCNIVersion, vnetCNIPackage(a.PodSubnetID), vnetCNIVersion),
+		TenantID:               a.TenantID,
+		SubscriptionID:         a.SubscriptionID,
+		ResourceGroup:          a.ResourceGroup,
+		NetworkSecurityGroup:   a.NetworkSecurityGroupName,
+		RouteTable:             a.RouteTableName,
+		NetworkPlugin:          a.NetworkPlugin,
+		NetworkPolicy:          a.NetworkPolicy,
+		TLSBootstrapToken:      a.KubeletClientTLSBootstrapToken,
+		KubeCACrt:              lo.FromPtr(a.CABundle),
+	}
+
+	subnetParts, _ := utils.GetVnetSubnetIDComponents(a.SubnetID)
+	nbv.Subnet = subnetParts.SubnetName
+	nbv.VirtualNetworkResourceGroup = subnetParts.ResourceGroupName
+	nbv.VirtualNetwork = subnetParts.VNetName
+
+	labels := lo.MapToSlice(lo.Assign(a.Labels, podSubnetLabels(a.PodSubnetID)), func(k, v string) string {
+		return fmt.Sprintf("%s=%s", k, v)
+	})
+	sort.Strings(labels)
+	nbv.KubeletNodeLabels = strings.Join(labels, ",")
+
+	kubeletFlags := getBaseWindowsKubeletFlags()
+	if len(a.Taints) > 0 {
+		taintStrs := lo.Map(a.Taints, func(taint v1.Taint, _ int) string { return taint.ToString() })
+		kubeletFlags["--register-with-taints"] = strings.Join(taintStrs, ",")
+	}
+	kubeletFlags = lo.Assign(kubeletFlags, kubeletConfigToMap(a.KubeletConfig))
+
+	// Rendered as the items of a PowerShell array, single quoted so that values are taken literally.
+	// Sorted so that the script is stable across calls.
+	args := lo.MapToSlice(kubeletFlags, func(k, v string) string {
+		return fmt.Sprintf(`'%s=%s'`, k, v)
+	})
+	sort.Strings(args)
+	nbv.KubeletConfigArgs = strings.Join(args, ", ")
+	return nbv
+}
+
+// Download URL for the Windows kubernetes node binaries, published for each k8s version
+func windowsKubeBinariesPackageURL(kubernetesVersion string) string {
+	return fmt.Sprintf("%s/kubernetes/v%s/windowszip/v%s-1int.zip", globalAKSMirror, kubernetesVersion, kubernetesVersion)
+}
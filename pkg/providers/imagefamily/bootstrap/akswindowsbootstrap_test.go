@@ -0,0 +1,128 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bootstrap
+
+import (
+	"encoding/base64"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+)
+
+func newTestAKSWindows() AKSWindows {
+	return AKSWindows{
+		Options: Options{
+			ClusterName:     "test-cluster",
+			ClusterEndpoint: "https://test-cluster-abcdef.hcp.eastus.azmk8s.io:443",
+			KubeletConfig: &KubeletConfiguration{
+				MaxPods:             30,
+				ClusterDNSServiceIP: "10.0.0.53",
+			},
+			Taints: []v1.Taint{{Key: "os", Value: "windows", Effect: v1.TaintEffectNoSchedule}},
+			Labels: map[string]string{
+				"kubernetes.azure.com/mode": "user",
+				"karpenter.sh/nodepool":     "windows",
+			},
+			CABundle: lo.ToPtr("Y2EtYnVuZGxl"),
+			SubnetID: "/subscriptions/sub/resourceGroups/vnet-rg/providers/Microsoft.Network/virtualNetworks/vnet/subnets/subnet",
+		},
+		TenantID:                       "tenant",
+		SubscriptionID:                 "sub",
+		KubeletIdentityClientID:        "client-id",
+		Location:                       "eastus",
+		ResourceGroup:                  "node-rg",
+		NetworkSecurityGroupName:       "aks-agentpool-nsg",
+		RouteTableName:                 "aks-agentpool-routetable",
+		APIServerName:                  "test-cluster-abcdef.hcp.eastus.azmk8s.io",
+		KubeletClientTLSBootstrapToken: "abcdef.0123456789abcdef",
+		NetworkPlugin:                  "azure",
+		KubernetesVersion:              "1.33.2",
+	}
+}
+
+func TestAKSWindowsScript(t *testing.T) {
+	g := NewWithT(t)
+
+	encoded, err := newTestAKSWindows().Script()
+	g.Expect(err).ToNot(HaveOccurred())
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	g.Expect(err).ToNot(HaveOccurred())
+	script := string(decoded)
+
+	g.Expect(script).To(ContainSubstring(`$global:KubeBinariesPackageSASURL = "https://acs-mirror.azureedge.net/kubernetes/v1.33.2/windowszip/v1.33.2-1int.zip"`))
+	g.Expect(script).To(ContainSubstring(`$global:KubeletNodeLabels = "karpenter.sh/nodepool=windows,kubernetes.azure.com/mode=user"`))
+	g.Expect(script).To(ContainSubstring(`'--max-pods=30'`))
+	g.Expect(script).To(ContainSubstring(`'--cluster-dns=10.0.0.53'`))
+	g.Expect(script).To(ContainSubstring(`'--register-with-taints=os=windows:NoSchedule'`))
+	g.Expect(script).To(ContainSubstring(`'--cgroups-per-qos=false'`))
+	g.Expect(script).To(ContainSubstring(`$global:SubnetName = "subnet"`))
+	g.Expect(script).To(ContainSubstring(`$global:VNetName = "vnet"`))
+	g.Expect(script).To(ContainSubstring(`$global:VNetResourceGroup = "vnet-rg"`))
+	g.Expect(script).To(ContainSubstring(`$global:TLSBootstrapToken = "abcdef.0123456789abcdef"`))
+	g.Expect(script).To(ContainSubstring(`$global:CACertificate = "Y2EtYnVuZGxl"`))
+	g.Expect(script).To(ContainSubstring(`/azure-cni/v1.4.32/binaries/azure-vnet-cni-windows-amd64-v1.4.32.zip`))
+	g.Expect(script).ToNot(ContainSubstring("<no value>"))
+
+	// the script must be stable, so that the custom data doesn't change across calls
+	again, err := newTestAKSWindows().Script()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(again).To(Equal(encoded))
+}
+
+func TestAKSWindowsScriptPodSubnet(t *testing.T) {
+	g := NewWithT(t)
+
+	aksWindows := newTestAKSWindows()
+	aksWindows.PodSubnetID = "/subscriptions/sub/resourceGroups/vnet-rg/providers/Microsoft.Network/virtualNetworks/vnet/subnets/pods"
+	encoded, err := aksWindows.Script()
+	g.Expect(err).ToNot(HaveOccurred())
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	g.Expect(err).ToNot(HaveOccurred())
+	script := string(decoded)
+
+	g.Expect(script).To(ContainSubstring(`/azure-cni/v1.4.32/binaries/azure-vnet-cni-swift-windows-amd64-v1.4.32.zip`))
+	g.Expect(script).To(ContainSubstring(`kubernetes.azure.com/podnetwork-subnet=pods`))
+	g.Expect(script).To(ContainSubstring(`kubernetes.azure.com/podnetwork-name=vnet`))
+}
+
+func TestAKSWindowsCSE(t *testing.T) {
+	g := NewWithT(t)
+
+	cse, err := newTestAKSWindows().CSE()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(cse).To(HavePrefix("powershell.exe -ExecutionPolicy Unrestricted -command"))
+	g.Expect(cse).To(ContainSubstring("-MasterIP test-cluster-abcdef.hcp.eastus.azmk8s.io"))
+	g.Expect(cse).To(ContainSubstring("-MasterFQDNPrefix test-cluster-abcdef "))
+	g.Expect(cse).To(ContainSubstring("-KubeDnsServiceIp 10.0.0.53"))
+	g.Expect(cse).To(ContainSubstring("-Location eastus"))
+	g.Expect(cse).To(ContainSubstring("-UserAssignedClientID client-id"))
+	g.Expect(cse).To(ContainSubstring(`$inputFile = '%SYSTEMDRIVE%\AzureData\CustomData.bin'`))
+	g.Expect(cse).To(ContainSubstring("-TargetEnvironment AzurePublicCloud "))
+
+	sovereign := newTestAKSWindows()
+	sovereign.TargetEnvironment = "AzureUSGovernmentCloud"
+	cse, err = sovereign.CSE()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(cse).To(ContainSubstring("-TargetEnvironment AzureUSGovernmentCloud "))
+
+	noAPIServer := newTestAKSWindows()
+	noAPIServer.APIServerName = ""
+	_, err = noAPIServer.CSE()
+	g.Expect(err).To(HaveOccurred())
+}
@@ -17,7 +17,10 @@ limitations under the License.
 package bootstrap
 
 import (
+	"github.com/samber/lo"
+
 	"github.com/Azure/karpenter-provider-azure/pkg/apis/v1beta1"
+	"github.com/Azure/karpenter-provider-azure/pkg/utils"
 	core "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -58,6 +61,48 @@ type Options struct {
 	GPUImageSHA                  string
 	GPUDriverInstallationEnabled bool
 	SubnetID                     string
+	// PodSubnetID is the subnet pods get their IPs from with Azure CNI dynamic IP allocation, if any
+	PodSubnetID string
+	// DataDisks are formatted, if needed, and mounted before kubelet starts
+	DataDisks []DataDisk
+	// PreBootstrapScript and PostBootstrapScript are user-supplied scripts, run before containerd and kubelet are
+	// configured and once kubelet has been started, respectively
+	PreBootstrapScript  string
+	PostBootstrapScript string
+}
+
+// DataDisk is a data disk attached to the node, identified by its LUN, to mount at MountPath
+type DataDisk struct {
+	LUN       int32
+	MountPath string
+}
+
+var (
+	labelPodNetworkSubnet        = v1beta1.AKSLabelDomain + "/podnetwork-subnet"
+	labelPodNetworkName          = v1beta1.AKSLabelDomain + "/podnetwork-name"
+	labelPodNetworkResourceGroup = v1beta1.AKSLabelDomain + "/podnetwork-resourcegroup"
+	labelPodNetworkSubscription  = v1beta1.AKSLabelDomain + "/podnetwork-subscription"
+)
+
+// podSubnetLabels returns the node labels that tell the Azure CNI which subnet to allocate the IPs of pods from,
+// with dynamic IP allocation. There are none without a pod subnet.
+func podSubnetLabels(podSubnetID string) map[string]string {
+	podSubnet, err := utils.GetVnetSubnetIDComponents(podSubnetID)
+	if err != nil {
+		return nil
+	}
+	return map[string]string{
+		labelPodNetworkSubnet:        podSubnet.SubnetName,
+		labelPodNetworkName:          podSubnet.VNetName,
+		labelPodNetworkResourceGroup: podSubnet.ResourceGroupName,
+		labelPodNetworkSubscription:  podSubnet.SubscriptionID,
+	}
+}
+
+// vnetCNIPackage returns the name of the Azure VNET CNI package to install. With a pod subnet, that is the SWIFT flavor,
+// whose CNI config gets the IPs of pods from the Azure CNS, rather than from secondary ipconfigs of the NIC.
+func vnetCNIPackage(podSubnetID string) string {
+	return lo.Ternary(podSubnetID != "", "azure-vnet-cni-swift", "azure-vnet-cni")
 }
 
 // Bootstrapper can be implemented to generate a bootstrap script
@@ -67,3 +112,10 @@ type Options struct {
 type Bootstrapper interface {
 	Script() (string, error)
 }
+
+// WindowsBootstrapper generates the bootstrap script for Windows nodes, which is passed as custom data,
+// along with the Custom Script Extension command that runs it.
+type WindowsBootstrapper interface {
+	Bootstrapper
+	CSE() (string, error)
+}
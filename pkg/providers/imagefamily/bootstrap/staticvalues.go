@@ -25,6 +25,16 @@ import (
 
 const (
 	globalAKSMirror = "https://acs-mirror.azureedge.net"
+
+	// Windows nodes keep the custom data, and the logs of running it, under this directory
+	windowsCustomDataDir = `%SYSTEMDRIVE%\AzureData`
+	// defaultTargetEnvironment is the Azure environment nodes are provisioned in, unless configured otherwise
+	defaultTargetEnvironment = "AzurePublicCloud"
+	// vnetCNIVersion is the version of the Azure VNET CNI plugins installed on Linux and Windows nodes
+	vnetCNIVersion = "v1.4.32"
+	// The node provisioning scripts are cached on AKS Windows node images; this is only used when they are missing
+	windowsCSEScriptsPackageURL = globalAKSMirror + "/aks/windows/cse/aks-windows-cse-scripts-current.zip"
+	defaultClusterDNSServiceIP  = "10.0.0.10"
 )
 
 // NOTE: embed only works on vars not defined in a function, so without putting this into an internal package for encapulation, we are stuck with these remaining vars.
@@ -37,12 +47,26 @@ var (
 
 	//go:embed sysctl.conf
 	sysctlContent []byte
+
+	//go:embed windows_customdata.ps1.gtpl
+	windowsCustomDataTemplateText string
+
+	//go:embed datadisks.sh.gtpl
+	dataDisksTemplateText string
 )
 
 func getCustomDataTemplate() *template.Template {
 	return template.Must(template.New("customdata").Parse(customDataTemplateText))
 }
 
+func getWindowsCustomDataTemplate() *template.Template {
+	return template.Must(template.New("windowscustomdata").Parse(windowsCustomDataTemplateText))
+}
+
+func getDataDisksTemplate() *template.Template {
+	return template.Must(template.New("datadisks").Parse(dataDisksTemplateText))
+}
+
 func getContainerdConfigTemplate() *template.Template {
 	return template.Must(template.New("containerdconfig").Parse(containerdConfigTemplateText))
 }
@@ -86,8 +110,42 @@ func getBaseKubeletFlags() map[string]string {
 	}
 }
 
+func getBaseWindowsKubeletFlags() map[string]string {
+	// source note: mirrors the kubelet flags AKS sets for Windows node pools.
+	// Windows has no cgroups, so QoS cgroups and node allocatable enforcement are disabled.
+	return map[string]string{
+		"--address":                           "0.0.0.0",
+		"--anonymous-auth":                    "false",
+		"--authentication-token-webhook":      "true",
+		"--authorization-mode":                "Webhook",
+		"--bootstrap-kubeconfig":              `c:\k\bootstrap-config`,
+		"--cgroups-per-qos":                   "false",
+		"--client-ca-file":                    `c:\k\ca.crt`,
+		"--cloud-config":                      `c:\k\azure.json`,
+		"--cloud-provider":                    "external",
+		"--cluster-dns":                       defaultClusterDNSServiceIP,
+		"--cluster-domain":                    "cluster.local",
+		"--enforce-node-allocatable":          `""`,
+		"--event-qps":                         "0",
+		"--eviction-hard":                     `""`,
+		"--hairpin-mode":                      "promiscuous-bridge",
+		"--image-gc-high-threshold":           "85",
+		"--image-gc-low-threshold":            "80",
+		"--kubeconfig":                        `c:\k\config`,
+		"--max-pods":                          "30",
+		"--node-status-update-frequency":      "10s",
+		"--pod-infra-container-image":         "mcr.microsoft.com/oss/kubernetes/pause:3.6",
+		"--pod-max-pids":                      "-1",
+		"--read-only-port":                    "0",
+		"--resolv-conf":                       `""`,
+		"--rotate-certificates":               "true",
+		"--streaming-connection-idle-timeout": "4h",
+		"--tls-cipher-suites":                 "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305,TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,TLS_RSA_WITH_AES_256_GCM_SHA384,TLS_RSA_WITH_AES_128_GCM_SHA256",
+	}
+}
+
 func getStaticNodeBootstrapVars() *NodeBootstrapVariables {
-	vnetCNILinuxPluginsURL := fmt.Sprintf("%s/azure-cni/v1.4.32/binaries/azure-vnet-cni-linux-amd64-v1.4.32.tgz", globalAKSMirror)
+	vnetCNILinuxPluginsURL := fmt.Sprintf("%s/azure-cni/%s/binaries/azure-vnet-cni-linux-amd64-%s.tgz", globalAKSMirror, vnetCNIVersion, vnetCNIVersion)
 	cniPluginsURL := fmt.Sprintf("%s/cni-plugins/v1.1.1/binaries/cni-plugins-linux-amd64-v1.1.1.tgz", globalAKSMirror)
 
 	// baseline, covering unused (-), static (s), and unsupported (n) fields,
@@ -0,0 +1,90 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bootstrap
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+const (
+	// MaxCustomDataSize is the maximum size of the custom data of a VM, in bytes, before base64 encoding
+	MaxCustomDataSize = 65535
+
+	// HookPreBootstrap runs before containerd and kubelet are configured and started
+	HookPreBootstrap = "pre-bootstrap"
+	// HookPostBootstrap runs once kubelet has been started
+	HookPostBootstrap = "post-bootstrap"
+
+	userDataMIMEBoundary = "==KARPENTER-USER-DATA=="
+	// The cloud-config of the user is merged into the generated one, appending to its lists and maps rather than replacing them
+	userDataMergeType = "list(append)+dict(no_replace,recurse_list)+str()"
+)
+
+// HookCommand returns a bash command that runs the user-supplied script at the bootstrap hook, logging its output
+// under /var/log/azure. The command fails if the script fails. It is empty if there is no script.
+func HookCommand(hook, script string) string {
+	if script == "" {
+		return ""
+	}
+	return fmt.Sprintf("echo %s | base64 -d > /opt/azure/karpenter-%[2]s.sh && /bin/bash /opt/azure/karpenter-%[2]s.sh >> /var/log/azure/karpenter-%[2]s.log 2>&1",
+		base64.StdEncoding.EncodeToString([]byte(script)), hook)
+}
+
+// MergeCloudConfig merges the cloud-config of the user into the base64 encoded custom data, as a multi-part MIME archive
+// processed by cloud-init. The custom data is returned unchanged if there is no cloud-config.
+func MergeCloudConfig(customData, cloudConfig string) (string, error) {
+	if cloudConfig == "" {
+		return customData, nil
+	}
+	decoded, err := base64.StdEncoding.DecodeString(customData)
+	if err != nil {
+		return "", fmt.Errorf("decoding custom data: %w", err)
+	}
+	contentType, err := cloudInitContentType(string(decoded))
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Content-Type: multipart/mixed; boundary=\"%s\"\nMIME-Version: 1.0\n\n", userDataMIMEBoundary)
+	fmt.Fprintf(&sb, "--%s\nContent-Type: %s; charset=\"us-ascii\"\n\n%s\n", userDataMIMEBoundary, contentType, strings.TrimSuffix(string(decoded), "\n"))
+	fmt.Fprintf(&sb, "--%s\nContent-Type: text/cloud-config; charset=\"us-ascii\"\nMerge-Type: %s\n\n%s\n", userDataMIMEBoundary, userDataMergeType, strings.TrimSuffix(cloudConfig, "\n"))
+	fmt.Fprintf(&sb, "--%s--\n", userDataMIMEBoundary)
+	return base64.StdEncoding.EncodeToString([]byte(sb.String())), nil
+}
+
+// ValidateCustomDataSize returns an error if the base64 encoded custom data doesn't fit in a VM
+func ValidateCustomDataSize(customData string) error {
+	if size := base64.StdEncoding.DecodedLen(len(customData)); size > MaxCustomDataSize {
+		return fmt.Errorf("custom data is %d bytes, more than the %d bytes a VM accepts, consider reducing the size of userData", size, MaxCustomDataSize)
+	}
+	return nil
+}
+
+// cloudInitContentType returns the MIME type cloud-init processes the custom data as
+func cloudInitContentType(customData string) (string, error) {
+	switch {
+	case strings.HasPrefix(customData, "#cloud-config"):
+		return "text/cloud-config", nil
+	case strings.HasPrefix(customData, "#!"):
+		return "text/x-shellscript", nil
+	default:
+		return "", fmt.Errorf("cloudConfig can't be merged into custom data that is neither a cloud-config nor a script")
+	}
+}
@@ -0,0 +1,75 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bootstrap
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestHookCommand(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(HookCommand(HookPreBootstrap, "")).To(BeEmpty())
+
+	command := HookCommand(HookPreBootstrap, "echo hello")
+	g.Expect(command).To(HavePrefix("echo " + base64.StdEncoding.EncodeToString([]byte("echo hello")) + " | base64 -d > /opt/azure/karpenter-pre-bootstrap.sh"))
+	g.Expect(command).To(HaveSuffix("/bin/bash /opt/azure/karpenter-pre-bootstrap.sh >> /var/log/azure/karpenter-pre-bootstrap.log 2>&1"))
+}
+
+func TestMergeCloudConfig(t *testing.T) {
+	g := NewWithT(t)
+	script := base64.StdEncoding.EncodeToString([]byte("#!/bin/bash\necho bootstrap\n"))
+
+	// unchanged without a cloud-config
+	merged, err := MergeCloudConfig(script, "")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(merged).To(Equal(script))
+
+	merged, err = MergeCloudConfig(script, "#cloud-config\npackages:\n- jq\n")
+	g.Expect(err).ToNot(HaveOccurred())
+	decoded, err := base64.StdEncoding.DecodeString(merged)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(string(decoded)).To(HavePrefix("Content-Type: multipart/mixed"))
+	// the generated custom data comes first, the cloud-config of the user is merged into it
+	parts := strings.Split(string(decoded), "--"+userDataMIMEBoundary)
+	g.Expect(parts).To(HaveLen(4))
+	g.Expect(parts[1]).To(ContainSubstring("Content-Type: text/x-shellscript"))
+	g.Expect(parts[1]).To(ContainSubstring("echo bootstrap"))
+	g.Expect(parts[2]).To(ContainSubstring("Content-Type: text/cloud-config"))
+	g.Expect(parts[2]).To(ContainSubstring("Merge-Type: " + userDataMergeType))
+	g.Expect(parts[2]).To(ContainSubstring("- jq"))
+	g.Expect(parts[3]).To(Equal("--\n"))
+
+	cloudConfig := base64.StdEncoding.EncodeToString([]byte("#cloud-config\nwrite_files: []\n"))
+	merged, err = MergeCloudConfig(cloudConfig, "#cloud-config\npackages:\n- jq\n")
+	g.Expect(err).ToNot(HaveOccurred())
+	decoded, _ = base64.StdEncoding.DecodeString(merged)
+	g.Expect(strings.Count(string(decoded), "Content-Type: text/cloud-config")).To(Equal(2))
+
+	_, err = MergeCloudConfig(base64.StdEncoding.EncodeToString([]byte("unknown")), "#cloud-config\n")
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestValidateCustomDataSize(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(ValidateCustomDataSize(base64.StdEncoding.EncodeToString(make([]byte, MaxCustomDataSize-1)))).To(Succeed())
+	g.Expect(ValidateCustomDataSize(base64.StdEncoding.EncodeToString(make([]byte, MaxCustomDataSize+3)))).ToNot(Succeed())
+}
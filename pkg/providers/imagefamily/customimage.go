@@ -0,0 +1,219 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package imagefamily
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	sdkerrors "github.com/Azure/azure-sdk-for-go-extensions/pkg/errors"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v7"
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+
+	"github.com/Azure/karpenter-provider-azure/pkg/apis/v1beta1"
+)
+
+const (
+	// CustomImageExpirationInterval is how long resolved custom images are cached for. It is much shorter than
+	// ImageExpirationInterval, as users publishing their own images expect new versions to be picked up promptly.
+	CustomImageExpirationInterval = time.Hour
+
+	galleryImageResourceType        = "Microsoft.Compute/galleries/images"
+	galleryImageVersionResourceType = "Microsoft.Compute/galleries/images/versions"
+)
+
+// CustomImageReference is the parsed form of AKSNodeClass spec.imageID
+type CustomImageReference struct {
+	SubscriptionID  string
+	ResourceGroup   string
+	GalleryName     string
+	ImageDefinition string
+	// Version is empty when the reference is to an image definition, rather than a specific version
+	Version string
+}
+
+// ParseCustomImageID parses a Shared Image Gallery image definition or image version ID
+// Examples:
+//   - /subscriptions/{sub}/resourceGroups/{rg}/providers/Microsoft.Compute/galleries/{gallery}/images/{definition}
+//   - /subscriptions/{sub}/resourceGroups/{rg}/providers/Microsoft.Compute/galleries/{gallery}/images/{definition}/versions/{version}
+func ParseCustomImageID(imageID string) (*CustomImageReference, error) {
+	res, err := arm.ParseResourceID(imageID)
+	if err != nil {
+		return nil, fmt.Errorf("parsing custom image ID %q, %w", imageID, err)
+	}
+
+	ref := &CustomImageReference{
+		SubscriptionID: res.SubscriptionID,
+		ResourceGroup:  res.ResourceGroupName,
+	}
+	switch {
+	case strings.EqualFold(res.ResourceType.String(), galleryImageResourceType):
+		ref.ImageDefinition = res.Name
+		ref.GalleryName = lo.FromPtr(res.Parent).Name
+	case strings.EqualFold(res.ResourceType.String(), galleryImageVersionResourceType):
+		ref.Version = res.Name
+		ref.ImageDefinition = res.Parent.Name
+		ref.GalleryName = lo.FromPtr(res.Parent.Parent).Name
+	default:
+		return nil, fmt.Errorf("custom image ID %q must reference a %s or %s, got %s", imageID, galleryImageResourceType, galleryImageVersionResourceType, res.ResourceType.String())
+	}
+
+	if ref.SubscriptionID == "" || ref.ResourceGroup == "" || ref.GalleryName == "" || ref.ImageDefinition == "" {
+		return nil, fmt.Errorf("custom image ID %q is missing a subscription, resource group, gallery or image definition", imageID)
+	}
+	return ref, nil
+}
+
+// IsVersionPinned returns whether the reference points at a specific image version
+func (r *CustomImageReference) IsVersionPinned() bool {
+	return r.Version != ""
+}
+
+// ImageVersionID returns the full image version ID for the given version of the referenced image definition
+func (r *CustomImageReference) ImageVersionID(version string) string {
+	return BuildImageIDSIG(r.SubscriptionID, r.ResourceGroup, r.GalleryName, r.ImageDefinition, version)
+}
+
+// CustomImageNotUsableError indicates the custom image referenced by an AKSNodeClass cannot be used to launch nodes,
+// and that retrying won't help until either the AKSNodeClass or the gallery changes.
+type CustomImageNotUsableError struct {
+	ImageID string
+	Reason  string
+}
+
+func (e *CustomImageNotUsableError) Error() string {
+	return fmt.Sprintf("custom image %s is not usable, %s", e.ImageID, e.Reason)
+}
+
+func IsCustomImageNotUsableError(err error) bool {
+	var notUsableErr *CustomImageNotUsableError
+	return errors.As(err, &notUsableErr)
+}
+
+// listCustomImage resolves the custom image referenced by the AKSNodeClass into a single NodeImage,
+// with requirements derived from the image definition. The image OS must match the OS of the image family.
+func (p *provider) listCustomImage(ctx context.Context, imageID string, isWindows bool) ([]NodeImage, error) {
+	ref, err := ParseCustomImageID(imageID)
+	if err != nil {
+		return nil, &CustomImageNotUsableError{ImageID: imageID, Reason: err.Error()}
+	}
+
+	image, err := p.galleryImages.GetImage(ctx, ref.SubscriptionID, ref.ResourceGroup, ref.GalleryName, ref.ImageDefinition)
+	if err != nil {
+		if azErr := sdkerrors.IsResponseError(err); azErr != nil && azErr.StatusCode == http.StatusNotFound {
+			return nil, &CustomImageNotUsableError{ImageID: imageID, Reason: "image definition not found"}
+		}
+		return nil, fmt.Errorf("getting custom image definition, %w", err)
+	}
+	expectedOSType := lo.Ternary(isWindows, armcompute.OperatingSystemTypesWindows, armcompute.OperatingSystemTypesLinux)
+	if image.Properties != nil && lo.FromPtr(image.Properties.OSType) != expectedOSType {
+		return nil, &CustomImageNotUsableError{ImageID: imageID, Reason: fmt.Sprintf("OS type %s does not match the image family, which requires %s", lo.FromPtr(image.Properties.OSType), expectedOSType)}
+	}
+
+	versions, err := p.galleryImages.ListImageVersions(ctx, ref.SubscriptionID, ref.ResourceGroup, ref.GalleryName, ref.ImageDefinition)
+	if err != nil {
+		return nil, fmt.Errorf("listing custom image versions, %w", err)
+	}
+	version, err := p.selectCustomImageVersion(ref, versions)
+	if err != nil {
+		return nil, &CustomImageNotUsableError{ImageID: imageID, Reason: err.Error()}
+	}
+
+	return []NodeImage{
+		{
+			ID:           ref.ImageVersionID(version),
+			Requirements: customImageRequirements(image),
+		},
+	}, nil
+}
+
+// selectCustomImageVersion returns the pinned version if set, and otherwise the latest version, following the same semantics
+// as the gallery's own "latest": the highest version replicated to our region which is not excluded from latest.
+func (p *provider) selectCustomImageVersion(ref *CustomImageReference, versions []*armcompute.GalleryImageVersion) (string, error) {
+	usable := lo.Filter(versions, func(version *armcompute.GalleryImageVersion, _ int) bool {
+		return version != nil && version.Properties != nil &&
+			lo.FromPtr(version.Properties.ProvisioningState) == armcompute.GalleryProvisioningStateSucceeded &&
+			isReplicatedTo(version, p.location)
+	})
+
+	if ref.IsVersionPinned() {
+		if _, ok := lo.Find(usable, func(version *armcompute.GalleryImageVersion) bool {
+			return strings.EqualFold(lo.FromPtr(version.Name), ref.Version)
+		}); !ok {
+			return "", fmt.Errorf("version %s was not found, is not successfully provisioned, or is not replicated to %s", ref.Version, p.location)
+		}
+		return ref.Version, nil
+	}
+
+	var latest string
+	for _, version := range usable {
+		if version.Properties.PublishingProfile != nil && lo.FromPtr(version.Properties.PublishingProfile.ExcludeFromLatest) {
+			continue
+		}
+		if name := lo.FromPtr(version.Name); latest == "" || isNewerVersion(name, latest) {
+			latest = name
+		}
+	}
+	if latest == "" {
+		return "", fmt.Errorf("no successfully provisioned versions replicated to %s were found", p.location)
+	}
+	return latest, nil
+}
+
+func isReplicatedTo(version *armcompute.GalleryImageVersion, location string) bool {
+	// Versions without a publishing profile are only available in the gallery's own region, which we can't verify here,
+	// so we let the VM create surface any error.
+	if version.Properties.PublishingProfile == nil || len(version.Properties.PublishingProfile.TargetRegions) == 0 {
+		return true
+	}
+	return lo.ContainsBy(version.Properties.PublishingProfile.TargetRegions, func(region *armcompute.TargetRegion) bool {
+		// Target regions are reported by display name (e.g. "East US"), so normalize them to the location name (e.g. "eastus")
+		return region != nil && strings.EqualFold(strings.ReplaceAll(lo.FromPtr(region.Name), " ", ""), location)
+	})
+}
+
+func customImageRequirements(image *armcompute.GalleryImage) scheduling.Requirements {
+	arch := karpv1.ArchitectureAmd64
+	var hyperVGeneration string
+	if image.Properties != nil {
+		if lo.FromPtr(image.Properties.Architecture) == armcompute.ArchitectureArm64 {
+			arch = karpv1.ArchitectureArm64
+		}
+		switch lo.FromPtr(image.Properties.HyperVGeneration) {
+		case armcompute.HyperVGenerationV1:
+			hyperVGeneration = v1beta1.HyperVGenerationV1
+		case armcompute.HyperVGenerationV2:
+			hyperVGeneration = v1beta1.HyperVGenerationV2
+		}
+	}
+
+	requirements := scheduling.NewRequirements(
+		scheduling.NewRequirement(v1.LabelArchStable, v1.NodeSelectorOpIn, arch),
+	)
+	if hyperVGeneration != "" {
+		requirements.Add(scheduling.NewRequirement(v1beta1.LabelSKUHyperVGeneration, v1.NodeSelectorOpIn, hyperVGeneration))
+	}
+	return requirements
+}
@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"strconv"
 
 	"github.com/samber/lo"
 
@@ -41,6 +42,8 @@ const (
 	ImageFamilyOSSKUUbuntu2404  = "Ubuntu2404"
 	ImageFamilyOSSKUAzureLinux2 = "AzureLinux2"
 	ImageFamilyOSSKUAzureLinux3 = "AzureLinux3"
+	ImageFamilyOSSKUWindows2022 = "Windows2022"
+	ImageFamilyOSSKUWindows2025 = "Windows2025"
 )
 
 type ProvisionClientBootstrap struct {
@@ -50,6 +53,7 @@ type ProvisionClientBootstrap struct {
 	StartupTaints                  []v1.Taint        `hash:"set"`
 	Labels                         map[string]string `hash:"set"`
 	SubnetID                       string
+	PodSubnetID                    string
 	Arch                           string
 	SubscriptionID                 string
 	ClusterResourceGroup           string
@@ -101,11 +105,6 @@ func (p ProvisionClientBootstrap) GetCustomDataAndCSE(ctx context.Context) (stri
 //
 //nolint:gocyclo
 func (p *ProvisionClientBootstrap) ConstructProvisionValues(ctx context.Context) (*models.ProvisionValues, error) {
-	if p.IsWindows {
-		// TODO(Windows)
-		return nil, fmt.Errorf("windows is not supported")
-	}
-
 	nodeLabels := lo.Assign(map[string]string{}, p.Labels)
 
 	enableArtifactStreaming := p.ArtifactStreaming.IsEnabled(p.Arch)
@@ -122,19 +121,19 @@ func (p *ProvisionClientBootstrap) ConstructProvisionValues(ctx context.Context)
 		CustomNodeLabels:         nodeLabels,
 		OrchestratorVersion:      lo.ToPtr(p.KubernetesVersion),
 		VnetSubnetID:             lo.ToPtr(p.SubnetID),
+		PodSubnetID:              lo.EmptyableToPtr(p.PodSubnetID),
 		StorageProfile:           lo.ToPtr(p.StorageProfile),
 		NodeInitializationTaints: lo.Map(p.StartupTaints, func(taint v1.Taint, _ int) string { return taint.ToString() }),
 		NodeTaints:               lo.Map(p.Taints, func(taint v1.Taint, _ int) string { return taint.ToString() }),
 		SecurityProfile: &models.AgentPoolSecurityProfile{
-			SSHAccess:        lo.ToPtr(models.SSHAccessLocalUser),
+			SSHAccess:        lo.Ternary(p.IsWindows, nil, lo.ToPtr(models.SSHAccessLocalUser)),
 			EnableVTPM:       p.VTPMEnabled,
 			EnableSecureBoot: p.SecureBootEnabled,
 		},
 		MaxPods: lo.ToPtr(p.KubeletConfig.MaxPods),
 
-		VnetCidrs: []string{}, // Unsupported as of now; TODO(Windows)
+		VnetCidrs: []string{}, // Unsupported as of now
 		// MessageOfTheDay:         lo.ToPtr(""),                                    // Unsupported as of now
-		// AgentPoolWindowsProfile: &models.AgentPoolWindowsProfile{},               // Unsupported as of now; TODO(Windows)
 		// KubeletDiskType:         lo.ToPtr(models.KubeletDiskTypeUnspecified),    // Unsupported as of now
 		// CustomLinuxOSConfig:     &models.CustomLinuxOSConfig{},                   // Unsupported as of now (sysctl)
 		CustomLinuxOSConfig: convertLinuxOSConfigToModel(p.LinuxOSConfig),
@@ -155,10 +154,21 @@ func (p *ProvisionClientBootstrap) ConstructProvisionValues(ctx context.Context)
 		provisionProfile.OsSku = lo.ToPtr(models.OSSKUUbuntu)
 	case ImageFamilyOSSKUAzureLinux2, ImageFamilyOSSKUAzureLinux3:
 		provisionProfile.OsSku = lo.ToPtr(models.OSSKUAzureLinux)
+	case ImageFamilyOSSKUWindows2022:
+		provisionProfile.OsSku = lo.ToPtr(models.OSSKUWindows2022)
+	case ImageFamilyOSSKUWindows2025:
+		// TODO: the node bootstrapping API does not expose Windows2025 yet; use ProvisionModeAKSScriptless meanwhile.
+		return nil, fmt.Errorf("OSSKU %s is not yet supported by the node bootstrapping API", p.OSSKU)
 	default:
 		return nil, fmt.Errorf("unsupported OSSKU %s", p.OSSKU)
 	}
 
+	if p.IsWindows {
+		provisionProfile.AgentPoolWindowsProfile = &models.AgentPoolWindowsProfile{
+			DisableOutboundNat: lo.ToPtr(false),
+		}
+	}
+
 	if p.KubeletConfig != nil {
 		provisionProfile.CustomKubeletConfig = &models.CustomKubeletConfig{
 			CPUCfsQuota:          p.KubeletConfig.CPUCFSQuota,
@@ -186,6 +196,21 @@ func (p *ProvisionClientBootstrap) ConstructProvisionValues(ctx context.Context)
 		if len(p.KubeletConfig.AllowedUnsafeSysctls) > 0 {
 			provisionProfile.CustomKubeletConfig.AllowedUnsafeSysctls = p.KubeletConfig.AllowedUnsafeSysctls
 		}
+
+		// The node bootstrapping API computes the kube-reserved cpu and memory on its own, the overrides of the node class
+		// (the embedded configuration, not the computed one) replace them. It doesn't accept other reservations or eviction thresholds.
+		overrides := p.KubeletConfig.KubeletConfiguration
+		if len(overrides.SystemReserved) > 0 || len(overrides.EvictionHard) > 0 || len(overrides.EvictionSoft) > 0 ||
+			len(overrides.EvictionSoftGracePeriod) > 0 || overrides.EvictionMaxPodGracePeriod != nil ||
+			len(lo.OmitByKeys(overrides.KubeReserved, []string{string(v1.ResourceCPU), string(v1.ResourceMemory)})) > 0 {
+			return nil, fmt.Errorf("kubelet reserved resources other than kubeReserved cpu and memory, and eviction thresholds, are not yet supported by the node bootstrapping API")
+		}
+		if cpu, ok := overrides.KubeReserved[string(v1.ResourceCPU)]; ok {
+			provisionProfile.CustomKubeletConfig.CPUReserved = ConvertCPUReservedToMilli(cpu)
+		}
+		if memory, ok := overrides.KubeReserved[string(v1.ResourceMemory)]; ok {
+			provisionProfile.CustomKubeletConfig.MemoryReserved = ConvertMemoryReservedToMB(memory)
+		}
 	}
 
 	if modeString, ok := p.Labels[v1beta1.AKSLabelMode]; ok && modeString == v1beta1.ModeSystem {
@@ -203,7 +228,7 @@ func (p *ProvisionClientBootstrap) ConstructProvisionValues(ctx context.Context)
 
 	provisionHelperValues := &models.ProvisionHelperValues{
 		SkuCPU:    lo.ToPtr(p.InstanceType.Capacity.Cpu().AsApproximateFloat64()),
-		SkuMemory: lo.ToPtr(math.Ceil(reverseVMMemoryOverhead(options.FromContext(ctx).VMMemoryOverheadPercent, p.InstanceType.Capacity.Memory().AsApproximateFloat64()) / 1024 / 1024 / 1024)),
+		SkuMemory: lo.ToPtr(skuMemoryGiB(ctx, p.InstanceType)),
 	}
 
 	return &models.ProvisionValues{
@@ -211,3 +236,15 @@ func (p *ProvisionClientBootstrap) ConstructProvisionValues(ctx context.Context)
 		ProvisionHelperValues: provisionHelperValues,
 	}, nil
 }
+
+// skuMemoryGiB returns the memory advertised by the SKU of the instance type. It is read from the SKU memory requirement,
+// as the memory overhead subtracted from the capacity may have been learned per instance type, rather than be the flat
+// VMMemoryOverheadPercent.
+func skuMemoryGiB(ctx context.Context, instanceType *cloudprovider.InstanceType) float64 {
+	if instanceType.Requirements.Has(v1beta1.LabelSKUMemory) {
+		if memoryMiB, err := strconv.ParseFloat(instanceType.Requirements.Get(v1beta1.LabelSKUMemory).Any(), 64); err == nil {
+			return math.Ceil(memoryMiB / 1024)
+		}
+	}
+	return math.Ceil(reverseVMMemoryOverhead(options.FromContext(ctx).VMMemoryOverheadPercent, instanceType.Capacity.Memory().AsApproximateFloat64()) / 1024 / 1024 / 1024)
+}
@@ -36,6 +36,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
 )
 
 func TestGetCustomDataAndCSE(t *testing.T) {
@@ -100,7 +101,7 @@ func TestGetCustomDataAndCSE(t *testing.T) {
 			expectError: true,
 		},
 		{
-			name: "Error with Windows OS",
+			name: "Error with unknown Windows OSSKU",
 			bootstrapper: &customscriptsbootstrap.ProvisionClientBootstrap{ //nolint:gosec // G101: fake bootstrap token in test fixture
 				ClusterName:                    "test-cluster",
 				KubeletConfig:                  &bootstrap.KubeletConfiguration{MaxPods: int32(110)},
@@ -386,7 +387,7 @@ func TestConstructProvisionValues(t *testing.T) {
 			},
 		},
 		{
-			name: "Windows configuration - should error",
+			name: "Windows configuration with unknown OSSKU - should error",
 			bootstrapper: &customscriptsbootstrap.ProvisionClientBootstrap{
 				ClusterName:               "test-cluster",
 				KubeletConfig:             &bootstrap.KubeletConfiguration{MaxPods: int32(110)},
@@ -395,9 +396,67 @@ func TestConstructProvisionValues(t *testing.T) {
 				ResourceGroup:             "test-rg",
 				KubernetesVersion:         "1.31.0",
 				ImageDistro:               "aks-windows",
-				IsWindows:                 true, // This should cause an error
+				IsWindows:                 true,
 				StorageProfile:            consts.StorageProfileManagedDisks,
-				OSSKU:                     "Windows",
+				OSSKU:                     "Windows", // This should cause an error
+				NodeBootstrappingProvider: &fake.NodeBootstrappingAPI{},
+				InstanceType: &cloudprovider.InstanceType{
+					Name: "Standard_D2s_v3",
+					Capacity: v1.ResourceList{
+						v1.ResourceCPU:    resource.MustParse("2"),
+						v1.ResourceMemory: resource.MustParse("8Gi"),
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "Windows 2022 configuration",
+			bootstrapper: &customscriptsbootstrap.ProvisionClientBootstrap{
+				ClusterName:               "test-cluster",
+				KubeletConfig:             &bootstrap.KubeletConfiguration{MaxPods: int32(30)},
+				SubnetID:                  "/subscriptions/test-sub/resourceGroups/test-rg/providers/Microsoft.Network/virtualNetworks/vnet/subnets/subnet",
+				Arch:                      karpv1.ArchitectureAmd64,
+				ResourceGroup:             "test-rg",
+				KubernetesVersion:         "1.31.0",
+				ImageDistro:               "aks-windows-2022-containerd-gen2",
+				IsWindows:                 true,
+				StorageProfile:            consts.StorageProfileManagedDisks,
+				OSSKU:                     customscriptsbootstrap.ImageFamilyOSSKUWindows2022,
+				NodeBootstrappingProvider: &fake.NodeBootstrappingAPI{},
+				InstanceType: &cloudprovider.InstanceType{
+					Name: "Standard_D2s_v3",
+					Capacity: v1.ResourceList{
+						v1.ResourceCPU:    resource.MustParse("2"),
+						v1.ResourceMemory: resource.MustParse("8Gi"),
+					},
+				},
+			},
+			expectError: false,
+			validate: func(t *testing.T, values *models.ProvisionValues) {
+				g := NewWithT(t)
+				profile := values.ProvisionProfile
+				g.Expect(*profile.OsType).To(Equal(models.OSTypeWindows))
+				g.Expect(*profile.OsSku).To(Equal(models.OSSKUWindows2022))
+				g.Expect(*profile.Distro).To(Equal("aks-windows-2022-containerd-gen2"))
+				g.Expect(profile.AgentPoolWindowsProfile).ToNot(BeNil())
+				g.Expect(profile.SecurityProfile.SSHAccess).To(BeNil())
+				g.Expect(profile.CustomLinuxOSConfig).To(BeNil())
+			},
+		},
+		{
+			name: "Windows 2025 configuration - should error until supported by the API",
+			bootstrapper: &customscriptsbootstrap.ProvisionClientBootstrap{
+				ClusterName:               "test-cluster",
+				KubeletConfig:             &bootstrap.KubeletConfiguration{MaxPods: int32(30)},
+				SubnetID:                  "/subscriptions/test-sub/resourceGroups/test-rg/providers/Microsoft.Network/virtualNetworks/vnet/subnets/subnet",
+				Arch:                      karpv1.ArchitectureAmd64,
+				ResourceGroup:             "test-rg",
+				KubernetesVersion:         "1.31.0",
+				ImageDistro:               "aks-windows-2025-gen2",
+				IsWindows:                 true,
+				StorageProfile:            consts.StorageProfileManagedDisks,
+				OSSKU:                     customscriptsbootstrap.ImageFamilyOSSKUWindows2025,
 				NodeBootstrappingProvider: &fake.NodeBootstrappingAPI{},
 				InstanceType: &cloudprovider.InstanceType{
 					Name: "Standard_D2s_v3",
@@ -674,6 +733,103 @@ func TestConstructProvisionValues(t *testing.T) {
 				g.Expect(*values.ProvisionProfile.CustomKubeletConfig.FailSwapOn).To(BeFalse())
 			},
 		},
+		{
+			name: "With kubeReserved overrides",
+			bootstrapper: &customscriptsbootstrap.ProvisionClientBootstrap{
+				ClusterName: "test-cluster",
+				KubeletConfig: &bootstrap.KubeletConfiguration{
+					MaxPods:      int32(110),
+					KubeReserved: map[string]string{"cpu": "180m", "memory": "2662Mi"},
+					KubeletConfiguration: v1beta1.KubeletConfiguration{
+						KubeReserved: map[string]string{"cpu": "500m", "memory": "3Gi"},
+					},
+				},
+				SubnetID:                  "/subscriptions/test-sub/resourceGroups/test-rg/providers/Microsoft.Network/virtualNetworks/vnet/subnets/subnet",
+				Arch:                      karpv1.ArchitectureAmd64,
+				ResourceGroup:             "test-rg",
+				KubernetesVersion:         "1.31.0",
+				ImageDistro:               "aks-ubuntu-containerd-22.04-gen2",
+				IsWindows:                 false,
+				StorageProfile:            consts.StorageProfileManagedDisks,
+				OSSKU:                     customscriptsbootstrap.ImageFamilyOSSKUUbuntu2204,
+				NodeBootstrappingProvider: &fake.NodeBootstrappingAPI{},
+				InstanceType: &cloudprovider.InstanceType{
+					Name: "Standard_D8s_v3",
+					Capacity: v1.ResourceList{
+						v1.ResourceCPU:    resource.MustParse("8"),
+						v1.ResourceMemory: resource.MustParse("32Gi"),
+					},
+				},
+			},
+			expectError: false,
+			validate: func(t *testing.T, values *models.ProvisionValues) {
+				g := NewWithT(t)
+				// the overrides of the node class are passed, not the computed reservations
+				g.Expect(values.ProvisionProfile.CustomKubeletConfig).ToNot(BeNil())
+				g.Expect(*values.ProvisionProfile.CustomKubeletConfig.CPUReserved).To(Equal(int32(500)))
+				g.Expect(*values.ProvisionProfile.CustomKubeletConfig.MemoryReserved).To(Equal(int32(3072)))
+			},
+		},
+		{
+			name: "SKU memory from the instance type requirements",
+			bootstrapper: &customscriptsbootstrap.ProvisionClientBootstrap{
+				ClusterName:               "test-cluster",
+				KubeletConfig:             &bootstrap.KubeletConfiguration{MaxPods: int32(110)},
+				SubnetID:                  "/subscriptions/test-sub/resourceGroups/test-rg/providers/Microsoft.Network/virtualNetworks/vnet/subnets/subnet",
+				Arch:                      karpv1.ArchitectureAmd64,
+				ResourceGroup:             "test-rg",
+				KubernetesVersion:         "1.31.0",
+				ImageDistro:               "aks-ubuntu-containerd-22.04-gen2",
+				IsWindows:                 false,
+				StorageProfile:            consts.StorageProfileManagedDisks,
+				OSSKU:                     customscriptsbootstrap.ImageFamilyOSSKUUbuntu2204,
+				NodeBootstrappingProvider: &fake.NodeBootstrappingAPI{},
+				InstanceType: &cloudprovider.InstanceType{
+					Name: "Standard_D8s_v3",
+					// capacity with a learned overhead larger than the flat VMMemoryOverheadPercent
+					Requirements: scheduling.NewRequirements(scheduling.NewRequirement(v1beta1.LabelSKUMemory, v1.NodeSelectorOpIn, "32768")),
+					Capacity: v1.ResourceList{
+						v1.ResourceCPU:    resource.MustParse("8"),
+						v1.ResourceMemory: resource.MustParse("30Gi"),
+					},
+				},
+			},
+			expectError: false,
+			validate: func(t *testing.T, values *models.ProvisionValues) {
+				g := NewWithT(t)
+				g.Expect(*values.ProvisionHelperValues.SkuMemory).To(Equal(float64(32)))
+			},
+		},
+		{
+			name: "With eviction threshold overrides - should error until supported by the API",
+			bootstrapper: &customscriptsbootstrap.ProvisionClientBootstrap{
+				ClusterName: "test-cluster",
+				KubeletConfig: &bootstrap.KubeletConfiguration{
+					MaxPods:      int32(110),
+					KubeReserved: map[string]string{"cpu": "180m", "memory": "2662Mi"},
+					KubeletConfiguration: v1beta1.KubeletConfiguration{
+						EvictionHard: map[string]string{"memory.available": "1Gi"},
+					},
+				},
+				SubnetID:                  "/subscriptions/test-sub/resourceGroups/test-rg/providers/Microsoft.Network/virtualNetworks/vnet/subnets/subnet",
+				Arch:                      karpv1.ArchitectureAmd64,
+				ResourceGroup:             "test-rg",
+				KubernetesVersion:         "1.31.0",
+				ImageDistro:               "aks-ubuntu-containerd-22.04-gen2",
+				IsWindows:                 false,
+				StorageProfile:            consts.StorageProfileManagedDisks,
+				OSSKU:                     customscriptsbootstrap.ImageFamilyOSSKUUbuntu2204,
+				NodeBootstrappingProvider: &fake.NodeBootstrappingAPI{},
+				InstanceType: &cloudprovider.InstanceType{
+					Name: "Standard_D8s_v3",
+					Capacity: v1.ResourceList{
+						v1.ResourceCPU:    resource.MustParse("8"),
+						v1.ResourceMemory: resource.MustParse("32Gi"),
+					},
+				},
+			},
+			expectError: true,
+		},
 	}
 
 	for _, tt := range tests {
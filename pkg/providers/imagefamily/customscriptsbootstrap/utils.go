@@ -56,6 +56,24 @@ func ConvertContainerLogMaxSizeToMB(containerLogMaxSize string) *int32 {
 	return nil
 }
 
+// ConvertCPUReservedToMilli converts a cpu quantity to millicores, as expected by the node bootstrapping API
+func ConvertCPUReservedToMilli(cpu string) *int32 {
+	q, err := resource.ParseQuantity(cpu)
+	if err != nil || q.MilliValue() > int64(math.MaxInt32) {
+		return nil
+	}
+	return lo.ToPtr(int32(q.MilliValue())) // golint:ignore G115 already check overflow
+}
+
+// ConvertMemoryReservedToMB converts a memory quantity to MiB, rounding up, as expected by the node bootstrapping API
+func ConvertMemoryReservedToMB(memory string) *int32 {
+	q, err := resource.ParseQuantity(memory)
+	if err != nil {
+		return nil
+	}
+	return lo.ToPtr(int32(math.Ceil(q.AsApproximateFloat64() / 1024 / 1024)))
+}
+
 func ConvertPodMaxPids(podPidsLimit *int64) *int32 {
 	if podPidsLimit != nil {
 		podPidsLimitInt64 := *podPidsLimit
@@ -112,6 +112,16 @@ func TestConvertContainerLogMaxSizeToMB(t *testing.T) {
 	}
 }
 
+func TestConvertReserved(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(ConvertCPUReservedToMilli("500m")).To(Equal(lo.ToPtr(int32(500))))
+	g.Expect(ConvertCPUReservedToMilli("2")).To(Equal(lo.ToPtr(int32(2000))))
+	g.Expect(ConvertCPUReservedToMilli("invalid")).To(BeNil())
+	g.Expect(ConvertMemoryReservedToMB("1Gi")).To(Equal(lo.ToPtr(int32(1024))))
+	g.Expect(ConvertMemoryReservedToMB("1G")).To(Equal(lo.ToPtr(int32(954))))
+	g.Expect(ConvertMemoryReservedToMB("invalid")).To(BeNil())
+}
+
 func TestConvertPodMaxPids(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -0,0 +1,93 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package imagefamily
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v7"
+
+	types "github.com/Azure/karpenter-provider-azure/pkg/providers/imagefamily/types"
+)
+
+// assert that GalleryImagesClient implements the GalleryImagesAPI interface
+var _ types.GalleryImagesAPI = &GalleryImagesClient{}
+
+// GalleryImagesClient reads image definitions and versions from user-owned Shared Image Galleries.
+// ARM compute clients are bound to a subscription, so one client factory is lazily created per subscription.
+type GalleryImagesClient struct {
+	cred azcore.TokenCredential
+	opts *arm.ClientOptions
+
+	mu        sync.Mutex
+	factories map[string]*armcompute.ClientFactory
+}
+
+func NewGalleryImagesClient(cred azcore.TokenCredential, opts *arm.ClientOptions) *GalleryImagesClient {
+	return &GalleryImagesClient{
+		cred:      cred,
+		opts:      opts,
+		factories: map[string]*armcompute.ClientFactory{},
+	}
+}
+
+func (c *GalleryImagesClient) GetImage(ctx context.Context, subscriptionID, resourceGroupName, galleryName, galleryImageName string) (*armcompute.GalleryImage, error) {
+	factory, err := c.factoryFor(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := factory.NewGalleryImagesClient().Get(ctx, resourceGroupName, galleryName, galleryImageName, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &resp.GalleryImage, nil
+}
+
+func (c *GalleryImagesClient) ListImageVersions(ctx context.Context, subscriptionID, resourceGroupName, galleryName, galleryImageName string) ([]*armcompute.GalleryImageVersion, error) {
+	factory, err := c.factoryFor(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	pager := factory.NewGalleryImageVersionsClient().NewListByGalleryImagePager(resourceGroupName, galleryName, galleryImageName, nil)
+
+	var allVersions []*armcompute.GalleryImageVersion
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		allVersions = append(allVersions, page.Value...)
+	}
+	return allVersions, nil
+}
+
+func (c *GalleryImagesClient) factoryFor(subscriptionID string) (*armcompute.ClientFactory, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if factory, ok := c.factories[subscriptionID]; ok {
+		return factory, nil
+	}
+	factory, err := armcompute.NewClientFactory(subscriptionID, c.cred, c.opts)
+	if err != nil {
+		return nil, err
+	}
+	c.factories[subscriptionID] = factory
+	return factory, nil
+}
@@ -19,6 +19,7 @@ package imagefamily
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v7"
@@ -57,17 +58,19 @@ type provider struct {
 
 	imageVersionsClient types.CommunityGalleryImageVersionsAPI
 	nodeImageVersions   types.NodeImageVersionsAPI
+	galleryImages       types.GalleryImagesAPI
 
 	nodeImagesCache *cache.Cache
 	cm              *pretty.ChangeMonitor
 }
 
-func NewProvider(versionsClient types.CommunityGalleryImageVersionsAPI, location, subscription string, nodeImageVersionsClient types.NodeImageVersionsAPI, nodeImagesCache *cache.Cache) *provider {
+func NewProvider(versionsClient types.CommunityGalleryImageVersionsAPI, location, subscription string, nodeImageVersionsClient types.NodeImageVersionsAPI, galleryImagesClient types.GalleryImagesAPI, nodeImagesCache *cache.Cache) *provider {
 	return &provider{
 		subscription:        subscription,
 		location:            location,
 		imageVersionsClient: versionsClient,
 		nodeImageVersions:   nodeImageVersionsClient,
+		galleryImages:       galleryImagesClient,
 		nodeImagesCache:     nodeImagesCache,
 		cm:                  pretty.NewChangeMonitor(),
 	}
@@ -75,6 +78,10 @@ func NewProvider(versionsClient types.CommunityGalleryImageVersionsAPI, location
 
 // Returns the list of available NodeImages for the given AKSNodeClass sorted in priority ordering
 func (p *provider) List(ctx context.Context, nodeClass *v1beta1.AKSNodeClass) ([]NodeImage, error) {
+	if nodeClass.UsesCustomImage() {
+		return p.listCustomImageCached(ctx, lo.FromPtr(nodeClass.Spec.ImageID), nodeClass.IsWindows())
+	}
+
 	// TODO: refactor to be part of construction, since this is a karpenter setting and won't change across the process.
 	useSIG := options.FromContext(ctx).UseSIG
 
@@ -83,6 +90,10 @@ func (p *provider) List(ctx context.Context, nodeClass *v1beta1.AKSNodeClass) ([
 	if lo.FromPtr(nodeClass.Spec.FIPSMode) == v1beta1.FIPSModeFIPS && !useSIG {
 		return []NodeImage{}, nil
 	}
+	// Likewise, Windows images are only published to SIG
+	if nodeClass.IsWindows() && !useSIG {
+		return []NodeImage{}, nil
+	}
 
 	kubernetesVersion, err := nodeClass.GetKubernetesVersion()
 	if err != nil {
@@ -121,6 +132,21 @@ func (p *provider) List(ctx context.Context, nodeClass *v1beta1.AKSNodeClass) ([
 	return nodeImages, nil
 }
 
+func (p *provider) listCustomImageCached(ctx context.Context, imageID string, isWindows bool) ([]NodeImage, error) {
+	// Custom images are independent of the kubernetes version, as the user is responsible for publishing new versions
+	key := fmt.Sprintf("custom-%s-%s", strings.ToLower(imageID), lo.Ternary(isWindows, "windows", "linux"))
+	if nodeImages, ok := p.nodeImagesCache.Get(key); ok {
+		return nodeImages.([]NodeImage), nil
+	}
+
+	nodeImages, err := p.listCustomImage(ctx, imageID, isWindows)
+	if err != nil {
+		return []NodeImage{}, err
+	}
+	p.nodeImagesCache.Set(key, nodeImages, CustomImageExpirationInterval)
+	return nodeImages, nil
+}
+
 func (p *provider) listSIG(ctx context.Context, supportedImages []types.DefaultImageOutput) ([]NodeImage, error) {
 	nodeImages := []NodeImage{}
 	retrievedLatestImages, err := p.nodeImageVersions.List(ctx, p.location)
@@ -28,6 +28,8 @@ import (
 	"github.com/blang/semver/v4"
 	"github.com/patrickmn/go-cache"
 	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
 	coretest "sigs.k8s.io/karpenter/pkg/test"
 
@@ -80,6 +82,7 @@ var _ = Describe("NodeImageProvider tests", func() {
 	var (
 		testOptions               *options.Options
 		communityImageVersionsAPI *fake.CommunityGalleryImageVersionsAPI
+		galleryImagesAPI          *fake.GalleryImagesAPI
 
 		nodeImageProvider imagefamily.NodeImageProvider
 		nodeClass         *v1beta1.AKSNodeClass
@@ -95,7 +98,8 @@ var _ = Describe("NodeImageProvider tests", func() {
 		cigImageVersionTest := cigImageVersion
 		communityImageVersionsAPI.ImageVersions.Append(&armcompute.CommunityGalleryImageVersion{Name: &cigImageVersionTest})
 		nodeImageVersionsAPI := &fake.NodeImageVersionsAPI{}
-		nodeImageProvider = imagefamily.NewProvider(communityImageVersionsAPI, fake.Region, customerSubscription, nodeImageVersionsAPI, cache.New(imagefamily.ImageExpirationInterval, imagefamily.ImageCacheCleaningInterval))
+		galleryImagesAPI = &fake.GalleryImagesAPI{}
+		nodeImageProvider = imagefamily.NewProvider(communityImageVersionsAPI, fake.Region, customerSubscription, nodeImageVersionsAPI, galleryImagesAPI, cache.New(imagefamily.ImageExpirationInterval, imagefamily.ImageCacheCleaningInterval))
 		kubernetesVersion = lo.Must(env.KubernetesInterface.Discovery().ServerVersion()).String()
 
 		nodeClass = test.AKSNodeClass()
@@ -171,6 +175,14 @@ var _ = Describe("NodeImageProvider tests", func() {
 			Expect(foundImages).To(ContainElement(HaveField("ID", ContainSubstring(imagefamily.AzureLinux3Gen2ArmImageDefinition))))
 		})
 
+		It("should not return any images for Windows, which are only published to SIG", func() {
+			nodeClass.Spec.ImageFamily = lo.ToPtr(v1beta1.Windows2022ImageFamily)
+
+			foundImages, err := nodeImageProvider.List(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(foundImages).To(BeEmpty())
+		})
+
 		Context("List TrustedLaunch Images", func() {
 			BeforeEach(func() {
 				nodeClass.Spec.Security = &v1beta1.Security{
@@ -240,6 +252,34 @@ var _ = Describe("NodeImageProvider tests", func() {
 			)
 		})
 
+		DescribeTable("should match expected images for Windows",
+			func(imageFamily string, version string, expectedDefinitions ...string) {
+				nodeClass.Spec.ImageFamily = lo.ToPtr(imageFamily)
+
+				foundImages, err := nodeImageProvider.List(ctx, nodeClass)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(lo.Map(foundImages, func(image imagefamily.NodeImage, _ int) string { return image.ID })).To(Equal(
+					lo.Map(expectedDefinitions, func(definition string, _ int) string {
+						return imagefamily.BuildImageIDSIG(sigSubscription, imagefamily.AKSWindowsResourceGroup, imagefamily.AKSWindowsGalleryName, definition, version)
+					}),
+				))
+				for _, image := range foundImages {
+					Expect(image.Requirements.Get(corev1.LabelArchStable).Values()).To(ConsistOf(karpv1.ArchitectureAmd64))
+				}
+			},
+			Entry("for Windows2022", v1beta1.Windows2022ImageFamily, "20348.4529.251212", imagefamily.Windows2022Gen2ImageDefinition, imagefamily.Windows2022Gen1ImageDefinition),
+			Entry("for Windows2025", v1beta1.Windows2025ImageFamily, "26100.7462.251212", imagefamily.Windows2025Gen2ImageDefinition, imagefamily.Windows2025Gen1ImageDefinition),
+		)
+
+		It("should not return any Windows images for FIPS", func() {
+			nodeClass.Spec.ImageFamily = lo.ToPtr(v1beta1.Windows2022ImageFamily)
+			nodeClass.Spec.FIPSMode = &v1beta1.FIPSModeFIPS
+
+			foundImages, err := nodeImageProvider.List(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(foundImages).To(BeEmpty())
+		})
+
 		Context("List FIPS Images When FIPSMode Is Explicitly FIPS", func() {
 			BeforeEach(func() {
 				nodeClass.Spec.FIPSMode = &v1beta1.FIPSModeFIPS
@@ -602,4 +642,115 @@ var _ = Describe("NodeImageProvider tests", func() {
 			Expect(foundImages).To(Equal(expectedImages))
 		})
 	})
+
+	Context("List Custom Images", func() {
+		const (
+			customImageDefinitionID = "/subscriptions/" + customerSubscription + "/resourceGroups/my-rg/providers/Microsoft.Compute/galleries/mygallery/images/hardened-ubuntu"
+		)
+
+		newVersion := func(name string, mutate ...func(*armcompute.GalleryImageVersion)) *armcompute.GalleryImageVersion {
+			version := &armcompute.GalleryImageVersion{
+				ID:   lo.ToPtr(customImageDefinitionID + "/versions/" + name),
+				Name: lo.ToPtr(name),
+				Properties: &armcompute.GalleryImageVersionProperties{
+					ProvisioningState: lo.ToPtr(armcompute.GalleryProvisioningStateSucceeded),
+					PublishingProfile: &armcompute.GalleryImageVersionPublishingProfile{
+						TargetRegions: []*armcompute.TargetRegion{{Name: lo.ToPtr(fake.Region)}},
+					},
+				},
+			}
+			for _, m := range mutate {
+				m(version)
+			}
+			return version
+		}
+
+		BeforeEach(func() {
+			galleryImagesAPI.Images.Append(&armcompute.GalleryImage{
+				ID: lo.ToPtr(customImageDefinitionID),
+				Properties: &armcompute.GalleryImageProperties{
+					OSType:           lo.ToPtr(armcompute.OperatingSystemTypesLinux),
+					Architecture:     lo.ToPtr(armcompute.ArchitectureArm64),
+					HyperVGeneration: lo.ToPtr(armcompute.HyperVGenerationV2),
+				},
+			})
+		})
+
+		It("should resolve an image definition to its latest usable version", func() {
+			galleryImagesAPI.ImageVersions.Append(
+				newVersion("1.0.2"),
+				newVersion("1.0.10"),
+				newVersion("1.1.0", func(v *armcompute.GalleryImageVersion) {
+					v.Properties.PublishingProfile.ExcludeFromLatest = lo.ToPtr(true)
+				}),
+				newVersion("2.0.0", func(v *armcompute.GalleryImageVersion) {
+					v.Properties.ProvisioningState = lo.ToPtr(armcompute.GalleryProvisioningStateCreating)
+				}),
+				newVersion("3.0.0", func(v *armcompute.GalleryImageVersion) {
+					v.Properties.PublishingProfile.TargetRegions = []*armcompute.TargetRegion{{Name: lo.ToPtr("Some Other Region")}}
+				}),
+			)
+			nodeClass.Spec.ImageID = lo.ToPtr(customImageDefinitionID)
+
+			foundImages, err := nodeImageProvider.List(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(foundImages).To(HaveLen(1))
+			Expect(foundImages[0].ID).To(Equal(customImageDefinitionID + "/versions/1.0.10"))
+			Expect(foundImages[0].Requirements.Get(corev1.LabelArchStable).Values()).To(ConsistOf(karpv1.ArchitectureArm64))
+			Expect(foundImages[0].Requirements.Get(v1beta1.LabelSKUHyperVGeneration).Values()).To(ConsistOf(v1beta1.HyperVGenerationV2))
+		})
+
+		It("should use a pinned image version", func() {
+			galleryImagesAPI.ImageVersions.Append(newVersion("1.0.0"), newVersion("1.0.1"))
+			nodeClass.Spec.ImageID = lo.ToPtr(customImageDefinitionID + "/versions/1.0.0")
+
+			foundImages, err := nodeImageProvider.List(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(foundImages).To(HaveLen(1))
+			Expect(foundImages[0].ID).To(Equal(customImageDefinitionID + "/versions/1.0.0"))
+		})
+
+		It("should not depend on the kubernetes version being ready", func() {
+			galleryImagesAPI.ImageVersions.Append(newVersion("1.0.0"))
+			nodeClass.Spec.ImageID = lo.ToPtr(customImageDefinitionID)
+			nodeClass.StatusConditions().SetFalse(v1beta1.ConditionTypeKubernetesVersionReady, "KubernetesVersionFalseForTesting", "testing false kubernetes version status")
+
+			_, err := nodeImageProvider.List(ctx, nodeClass)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		DescribeTable("should return a not usable error",
+			func(imageID string, versions ...*armcompute.GalleryImageVersion) {
+				galleryImagesAPI.ImageVersions.Append(versions...)
+				nodeClass.Spec.ImageID = lo.ToPtr(imageID)
+
+				_, err := nodeImageProvider.List(ctx, nodeClass)
+				Expect(err).To(HaveOccurred())
+				Expect(imagefamily.IsCustomImageNotUsableError(err)).To(BeTrue())
+			},
+			Entry("when the image definition does not exist", "/subscriptions/"+customerSubscription+"/resourceGroups/my-rg/providers/Microsoft.Compute/galleries/mygallery/images/does-not-exist"),
+			Entry("when the image definition has no versions", customImageDefinitionID),
+			Entry("when the pinned version does not exist", customImageDefinitionID+"/versions/9.9.9", newVersion("1.0.0")),
+			Entry("when the image ID is not a gallery image", "/subscriptions/"+customerSubscription+"/resourceGroups/my-rg/providers/Microsoft.Compute/images/my-managed-image"),
+		)
+
+		It("should return a not usable error when the image OS does not match the image family", func() {
+			galleryImagesAPI.ImageVersions.Append(newVersion("1.0.0"))
+			nodeClass.Spec.ImageID = lo.ToPtr(customImageDefinitionID)
+			nodeClass.Spec.ImageFamily = lo.ToPtr(v1beta1.Windows2022ImageFamily)
+
+			_, err := nodeImageProvider.List(ctx, nodeClass)
+			Expect(err).To(HaveOccurred())
+			Expect(imagefamily.IsCustomImageNotUsableError(err)).To(BeTrue())
+		})
+
+		It("should surface other errors as is", func() {
+			galleryImagesAPI.Error.Set(fmt.Errorf("test error"))
+			nodeClass.Spec.ImageID = lo.ToPtr(customImageDefinitionID)
+
+			_, err := nodeImageProvider.List(ctx, nodeClass)
+			Expect(err).To(HaveOccurred())
+			Expect(imagefamily.IsCustomImageNotUsableError(err)).To(BeFalse())
+		})
+	})
 })
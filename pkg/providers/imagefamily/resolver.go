@@ -19,6 +19,7 @@ package imagefamily
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	corev1 "k8s.io/api/core/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -139,7 +140,12 @@ func (r *defaultResolver) Resolve(
 
 	// TODO: as ProvisionModeBootstrappingClient path develops, we will eventually be able to drop the retrieval of imageDistro here.
 	useSIG := options.FromContext(ctx).UseSIG
-	imageDistro, err := mapToImageDistro(imageID, nodeClass.Spec.FIPSMode, imageFamily, useSIG, nodeClass.IsTrustedLaunchEnabled())
+	var imageDistro string
+	if nodeClass.UsesCustomImage() {
+		imageDistro, err = mapToCustomImageDistro(imageFamily, instanceType, nodeClass.Spec.FIPSMode, useSIG, nodeClass.IsTrustedLaunchEnabled())
+	} else {
+		imageDistro, err = mapToImageDistro(imageID, nodeClass.Spec.FIPSMode, imageFamily, useSIG, nodeClass.IsTrustedLaunchEnabled())
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -192,7 +198,7 @@ func (r *defaultResolver) Resolve(
 		// traditional AKS, so putting this here along with the other settings
 		StorageProfileSizeGB: lo.FromPtr(nodeClass.Spec.OSDiskSizeGB),
 		ImageID:              imageID,
-		IsWindows:            false, // TODO(Windows)
+		IsWindows:            nodeClass.IsWindows(),
 	}
 
 	return template, nil
@@ -223,6 +229,17 @@ func mapToImageDistro(imageID string, fipsMode *v1beta1.FIPSMode, imageFamily Im
 	return "", fmt.Errorf("no distro found for image id %s", imageID)
 }
 
+// mapToCustomImageDistro picks the distro for a custom image. Custom images are expected to be built on top of the
+// AKS node image of the image family, so we use the distro of the family's default image matching the instance type.
+func mapToCustomImageDistro(imageFamily ImageFamily, instanceType *cloudprovider.InstanceType, fipsMode *v1beta1.FIPSMode, useSIG bool, trustedLaunch bool) (string, error) {
+	for _, defaultImage := range imageFamily.DefaultImages(useSIG, fipsMode, trustedLaunch) {
+		if err := instanceType.Requirements.Compatible(defaultImage.Requirements, v1beta1.AllowUndefinedWellKnownAndRestrictedLabels); err == nil {
+			return defaultImage.Distro, nil
+		}
+	}
+	return "", fmt.Errorf("no distro found in image family %s for custom image on instance type %s", imageFamily.Name(), instanceType.Name)
+}
+
 // ATTENTION!!!: changes here may NOT be effective on AKS machine nodes (ProvisionModeAKSMachineAPI); See aksmachineinstance.go/aksmachineinstancehelpers.go.
 // Refactoring for code unification is not being invested immediately.
 func prepareKubeletConfiguration(ctx context.Context, instanceType *cloudprovider.InstanceType, nodeClass *v1beta1.AKSNodeClass) *bootstrap.KubeletConfiguration {
@@ -236,9 +253,19 @@ func prepareKubeletConfiguration(ctx context.Context, instanceType *cloudprovide
 	kubeletConfig.ClusterDNSServiceIP = options.FromContext(ctx).DNSServiceIP
 
 	// TODO: revisit computeResources implementation
+	// The overhead of the instance type accounts for the overrides of the node class, which may also reserve resources
+	// that don't count towards allocatable (e.g. pid), or set eviction signals other than memory.available.
 	kubeletConfig.KubeReserved = utils.StringMap(instanceType.Overhead.KubeReserved)
 	kubeletConfig.SystemReserved = utils.StringMap(instanceType.Overhead.SystemReserved)
-	kubeletConfig.EvictionHard = map[string]string{instancetype.MemoryAvailable: instanceType.Overhead.EvictionThreshold.Memory().String()}
+	kubeletConfig.EvictionHard = map[string]string{instancetype.MemoryAvailable: instancetype.DefaultMemoryAvailable}
+	if nodeClass.Spec.Kubelet != nil {
+		kubeletConfig.KubeReserved = lo.Assign(kubeletConfig.KubeReserved, nodeClass.Spec.Kubelet.KubeReserved)
+		kubeletConfig.SystemReserved = lo.Assign(kubeletConfig.SystemReserved, nodeClass.Spec.Kubelet.SystemReserved)
+		kubeletConfig.EvictionHard = lo.Assign(kubeletConfig.EvictionHard, nodeClass.Spec.Kubelet.EvictionHard)
+		kubeletConfig.EvictionSoft = nodeClass.Spec.Kubelet.EvictionSoft
+		kubeletConfig.EvictionSoftGracePeriod = nodeClass.Spec.Kubelet.EvictionSoftGracePeriod
+		kubeletConfig.EvictionMaxPodGracePeriod = nodeClass.Spec.Kubelet.EvictionMaxPodGracePeriod
+	}
 	return kubeletConfig
 }
 
@@ -259,6 +286,10 @@ func GetImageFamily(familyName *string, fipsMode *v1beta1.FIPSMode, trustedLaunc
 			return &AzureLinux3{Options: parameters}
 		}
 		return &AzureLinux{Options: parameters}
+	case v1beta1.Windows2022ImageFamily:
+		return &Windows2022{Options: parameters}
+	case v1beta1.Windows2025ImageFamily:
+		return &Windows2025{Options: parameters}
 	case v1beta1.UbuntuImageFamily:
 		fallthrough
 	default:
@@ -290,7 +321,18 @@ func (r *defaultResolver) ResolveNodeImageFromNodeClass(nodeClass *v1beta1.AKSNo
 	if err != nil {
 		return "", err
 	}
+	var customImage *CustomImageReference
+	if nodeClass.UsesCustomImage() {
+		customImage, err = ParseCustomImageID(lo.FromPtr(nodeClass.Spec.ImageID))
+		if err != nil {
+			return "", err
+		}
+	}
 	for _, availableImage := range nodeImages {
+		// Guard against using images that were not resolved from the custom image, in case the status is lagging behind the spec
+		if customImage != nil && !isImageOfCustomImage(availableImage.ID, customImage) {
+			continue
+		}
 		if err := instanceType.Requirements.Compatible(
 			scheduling.NewNodeSelectorRequirements(availableImage.Requirements...),
 			v1beta1.AllowUndefinedWellKnownAndRestrictedLabels,
@@ -300,3 +342,10 @@ func (r *defaultResolver) ResolveNodeImageFromNodeClass(nodeClass *v1beta1.AKSNo
 	}
 	return "", fmt.Errorf("no compatible images found for instance type %s", instanceType.Name)
 }
+
+func isImageOfCustomImage(imageID string, customImage *CustomImageReference) bool {
+	if customImage.IsVersionPinned() {
+		return strings.EqualFold(imageID, customImage.ImageVersionID(customImage.Version))
+	}
+	return strings.HasPrefix(strings.ToLower(imageID), strings.ToLower(customImage.ImageVersionID("")))
+}
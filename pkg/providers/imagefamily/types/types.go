@@ -59,6 +59,13 @@ type CommunityGalleryImageVersionsAPI interface {
 	NewListPager(location string, publicGalleryName string, galleryImageName string, options *armcomputev5.CommunityGalleryImageVersionsClientListOptions) *runtime.Pager[armcomputev5.CommunityGalleryImageVersionsClientListResponse]
 }
 
+// GalleryImagesAPI is used for resolving custom images from a user-owned Shared Image Gallery.
+// The gallery may live in a different subscription than the cluster, so the subscription is passed per call.
+type GalleryImagesAPI interface {
+	GetImage(ctx context.Context, subscriptionID, resourceGroupName, galleryName, galleryImageName string) (*armcomputev5.GalleryImage, error)
+	ListImageVersions(ctx context.Context, subscriptionID, resourceGroupName, galleryName, galleryImageName string) ([]*armcomputev5.GalleryImageVersion, error)
+}
+
 type NodeImageVersionsAPI interface {
 	List(ctx context.Context, location string) ([]*armcontainerservice.NodeImageVersion, error)
 }
@@ -107,6 +107,10 @@ func (u Ubuntu2004) ScriptlessCustomData(
 			GPUImageSHA:                  u.Options.GPUImageSHA,
 			GPUDriverInstallationEnabled: u.Options.GPUDriverInstallationEnabled,
 			SubnetID:                     u.Options.SubnetID,
+			PodSubnetID:                  u.Options.PodSubnetID,
+			DataDisks:                    u.Options.DataDisks,
+			PreBootstrapScript:           u.Options.PreBootstrapScript,
+			PostBootstrapScript:          u.Options.PostBootstrapScript,
 		},
 		Arch:                           u.Options.Arch,
 		TenantID:                       u.Options.TenantID,
@@ -120,6 +124,7 @@ func (u Ubuntu2004) ScriptlessCustomData(
 		KubeletClientTLSBootstrapToken: u.Options.KubeletClientTLSBootstrapToken,
 		NetworkPlugin:                  u.Options.NetworkPlugin,
 		NetworkPolicy:                  u.Options.NetworkPolicy,
+		IPv6DualStackEnabled:           u.Options.IPv6DualStackEnabled,
 		KubernetesVersion:              u.Options.KubernetesVersion,
 	}
 }
@@ -148,6 +153,7 @@ func (u Ubuntu2004) CustomScriptsNodeBootstrapping(
 		StartupTaints:                  startupTaints,
 		Labels:                         labels,
 		SubnetID:                       u.Options.SubnetID,
+		PodSubnetID:                    u.Options.PodSubnetID,
 		Arch:                           u.Options.Arch,
 		SubscriptionID:                 u.Options.SubscriptionID,
 		ResourceGroup:                  u.Options.ResourceGroup,
@@ -130,6 +130,10 @@ func (u Ubuntu2404) ScriptlessCustomData(
 			GPUImageSHA:                  u.Options.GPUImageSHA,
 			GPUDriverInstallationEnabled: u.Options.GPUDriverInstallationEnabled,
 			SubnetID:                     u.Options.SubnetID,
+			PodSubnetID:                  u.Options.PodSubnetID,
+			DataDisks:                    u.Options.DataDisks,
+			PreBootstrapScript:           u.Options.PreBootstrapScript,
+			PostBootstrapScript:          u.Options.PostBootstrapScript,
 		},
 		Arch:                           u.Options.Arch,
 		TenantID:                       u.Options.TenantID,
@@ -143,6 +147,7 @@ func (u Ubuntu2404) ScriptlessCustomData(
 		KubeletClientTLSBootstrapToken: u.Options.KubeletClientTLSBootstrapToken,
 		NetworkPlugin:                  u.Options.NetworkPlugin,
 		NetworkPolicy:                  u.Options.NetworkPolicy,
+		IPv6DualStackEnabled:           u.Options.IPv6DualStackEnabled,
 		KubernetesVersion:              u.Options.KubernetesVersion,
 	}
 }
@@ -171,6 +176,7 @@ func (u Ubuntu2404) CustomScriptsNodeBootstrapping(
 		StartupTaints:                  startupTaints,
 		Labels:                         labels,
 		SubnetID:                       u.Options.SubnetID,
+		PodSubnetID:                    u.Options.PodSubnetID,
 		Arch:                           u.Options.Arch,
 		SubscriptionID:                 u.Options.SubscriptionID,
 		ResourceGroup:                  u.Options.ResourceGroup,
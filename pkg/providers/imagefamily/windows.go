@@ -0,0 +1,250 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package imagefamily
+
+import (
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/Azure/karpenter-provider-azure/pkg/apis/v1beta1"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/imagefamily/bootstrap"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/imagefamily/customscriptsbootstrap"
+	types "github.com/Azure/karpenter-provider-azure/pkg/providers/imagefamily/types"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/launchtemplate/parameters"
+	"github.com/samber/lo"
+
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+)
+
+const (
+	Windows2022Gen2ImageDefinition = "windows-2022-containerd-gen2"
+	Windows2022Gen1ImageDefinition = "windows-2022-containerd"
+	Windows2025Gen2ImageDefinition = "windows-2025-gen2"
+	Windows2025Gen1ImageDefinition = "windows-2025"
+)
+
+type Windows2022 struct {
+	Options *parameters.StaticParameters
+}
+
+func (w Windows2022) Name() string {
+	return v1beta1.Windows2022ImageFamily
+}
+
+func (w Windows2022) DefaultImages(useSIG bool, fipsMode *v1beta1.FIPSMode, trustedLaunch bool) []types.DefaultImageOutput {
+	return windowsDefaultImages(useSIG, fipsMode, trustedLaunch, []types.DefaultImageOutput{
+		{
+			ImageDefinition: Windows2022Gen2ImageDefinition,
+			Requirements:    windowsImageRequirements(v1beta1.HyperVGenerationV2),
+			Distro:          "aks-windows-2022-containerd-gen2",
+		},
+		{
+			ImageDefinition: Windows2022Gen1ImageDefinition,
+			Requirements:    windowsImageRequirements(v1beta1.HyperVGenerationV1),
+			Distro:          "aks-windows-2022-containerd",
+		},
+	})
+}
+
+// UserData returns the default userdata script for the image Family
+func (w Windows2022) ScriptlessCustomData(
+	kubeletConfig *bootstrap.KubeletConfiguration,
+	taints []v1.Taint,
+	labels map[string]string,
+	caBundle *string,
+	_ *cloudprovider.InstanceType,
+) bootstrap.Bootstrapper {
+	return windowsScriptlessCustomData(w.Options, kubeletConfig, taints, labels, caBundle)
+}
+
+// UserData returns the default userdata script for the image Family
+func (w Windows2022) CustomScriptsNodeBootstrapping(
+	kubeletConfig *bootstrap.KubeletConfiguration,
+	taints []v1.Taint,
+	startupTaints []v1.Taint,
+	labels map[string]string,
+	instanceType *cloudprovider.InstanceType,
+	imageDistro string,
+	storageProfile string,
+	nodeBootstrappingClient types.NodeBootstrappingAPI,
+	_ *v1beta1.FIPSMode,
+	_ *v1beta1.LocalDNS,
+	_ *v1beta1.ArtifactStreaming,
+	_ *v1beta1.LinuxOSConfiguration,
+	vtpmEnabled *bool,
+	secureBootEnabled *bool,
+) customscriptsbootstrap.Bootstrapper {
+	return windowsCustomScriptsNodeBootstrapping(w.Options, customscriptsbootstrap.ImageFamilyOSSKUWindows2022,
+		kubeletConfig, taints, startupTaints, labels, instanceType, imageDistro, storageProfile, nodeBootstrappingClient, vtpmEnabled, secureBootEnabled)
+}
+
+type Windows2025 struct {
+	Options *parameters.StaticParameters
+}
+
+func (w Windows2025) Name() string {
+	return v1beta1.Windows2025ImageFamily
+}
+
+func (w Windows2025) DefaultImages(useSIG bool, fipsMode *v1beta1.FIPSMode, trustedLaunch bool) []types.DefaultImageOutput {
+	return windowsDefaultImages(useSIG, fipsMode, trustedLaunch, []types.DefaultImageOutput{
+		{
+			ImageDefinition: Windows2025Gen2ImageDefinition,
+			Requirements:    windowsImageRequirements(v1beta1.HyperVGenerationV2),
+			Distro:          "aks-windows-2025-gen2",
+		},
+		{
+			ImageDefinition: Windows2025Gen1ImageDefinition,
+			Requirements:    windowsImageRequirements(v1beta1.HyperVGenerationV1),
+			Distro:          "aks-windows-2025",
+		},
+	})
+}
+
+// UserData returns the default userdata script for the image Family
+func (w Windows2025) ScriptlessCustomData(
+	kubeletConfig *bootstrap.KubeletConfiguration,
+	taints []v1.Taint,
+	labels map[string]string,
+	caBundle *string,
+	_ *cloudprovider.InstanceType,
+) bootstrap.Bootstrapper {
+	return windowsScriptlessCustomData(w.Options, kubeletConfig, taints, labels, caBundle)
+}
+
+// UserData returns the default userdata script for the image Family
+func (w Windows2025) CustomScriptsNodeBootstrapping(
+	kubeletConfig *bootstrap.KubeletConfiguration,
+	taints []v1.Taint,
+	startupTaints []v1.Taint,
+	labels map[string]string,
+	instanceType *cloudprovider.InstanceType,
+	imageDistro string,
+	storageProfile string,
+	nodeBootstrappingClient types.NodeBootstrappingAPI,
+	_ *v1beta1.FIPSMode,
+	_ *v1beta1.LocalDNS,
+	_ *v1beta1.ArtifactStreaming,
+	_ *v1beta1.LinuxOSConfiguration,
+	vtpmEnabled *bool,
+	secureBootEnabled *bool,
+) customscriptsbootstrap.Bootstrapper {
+	return windowsCustomScriptsNodeBootstrapping(w.Options, customscriptsbootstrap.ImageFamilyOSSKUWindows2025,
+		kubeletConfig, taints, startupTaints, labels, instanceType, imageDistro, storageProfile, nodeBootstrappingClient, vtpmEnabled, secureBootEnabled)
+}
+
+// windowsDefaultImages fills in the gallery of the given images, and filters them according to the node class.
+// Windows images are only published to the AKS shared image galleries, and there are no Windows FIPS or arm64 images.
+// Image provider will select these images in order, first match wins, so gen2 images go first.
+func windowsDefaultImages(useSIG bool, fipsMode *v1beta1.FIPSMode, trustedLaunch bool, images []types.DefaultImageOutput) []types.DefaultImageOutput {
+	if !useSIG || lo.FromPtr(fipsMode) == v1beta1.FIPSModeFIPS {
+		return []types.DefaultImageOutput{}
+	}
+	images = lo.Map(images, func(image types.DefaultImageOutput, _ int) types.DefaultImageOutput {
+		image.GalleryResourceGroup = AKSWindowsResourceGroup
+		image.GalleryName = AKSWindowsGalleryName
+		return image
+	})
+	if trustedLaunch {
+		// Trusted launch requires a gen2 image
+		return lo.Filter(images, func(image types.DefaultImageOutput, _ int) bool {
+			return image.Requirements.Get(v1beta1.LabelSKUHyperVGeneration).Has(v1beta1.HyperVGenerationV2)
+		})
+	}
+	return images
+}
+
+func windowsImageRequirements(hyperVGeneration string) scheduling.Requirements {
+	return scheduling.NewRequirements(
+		scheduling.NewRequirement(v1.LabelArchStable, v1.NodeSelectorOpIn, karpv1.ArchitectureAmd64),
+		scheduling.NewRequirement(v1beta1.LabelSKUHyperVGeneration, v1.NodeSelectorOpIn, hyperVGeneration),
+	)
+}
+
+func windowsScriptlessCustomData(
+	options *parameters.StaticParameters,
+	kubeletConfig *bootstrap.KubeletConfiguration,
+	taints []v1.Taint,
+	labels map[string]string,
+	caBundle *string,
+) bootstrap.Bootstrapper {
+	return bootstrap.AKSWindows{
+		Options: bootstrap.Options{
+			ClusterName:     options.ClusterName,
+			ClusterEndpoint: options.ClusterEndpoint,
+			KubeletConfig:   kubeletConfig,
+			Taints:          taints,
+			Labels:          labels,
+			CABundle:        caBundle,
+			SubnetID:        options.SubnetID,
+			PodSubnetID:     options.PodSubnetID,
+		},
+		TenantID:                       options.TenantID,
+		SubscriptionID:                 options.SubscriptionID,
+		Location:                       options.Location,
+		KubeletIdentityClientID:        options.KubeletIdentityClientID,
+		ResourceGroup:                  options.ResourceGroup,
+		NetworkSecurityGroupName:       options.NetworkSecurityGroupName,
+		RouteTableName:                 options.RouteTableName,
+		APIServerName:                  options.APIServerName,
+		KubeletClientTLSBootstrapToken: options.KubeletClientTLSBootstrapToken,
+		NetworkPlugin:                  options.NetworkPlugin,
+		NetworkPolicy:                  options.NetworkPolicy,
+		KubernetesVersion:              options.KubernetesVersion,
+		TargetEnvironment:              options.TargetEnvironment,
+	}
+}
+
+func windowsCustomScriptsNodeBootstrapping(
+	options *parameters.StaticParameters,
+	ossku string,
+	kubeletConfig *bootstrap.KubeletConfiguration,
+	taints []v1.Taint,
+	startupTaints []v1.Taint,
+	labels map[string]string,
+	instanceType *cloudprovider.InstanceType,
+	imageDistro string,
+	storageProfile string,
+	nodeBootstrappingClient types.NodeBootstrappingAPI,
+	vtpmEnabled *bool,
+	secureBootEnabled *bool,
+) customscriptsbootstrap.Bootstrapper {
+	return customscriptsbootstrap.ProvisionClientBootstrap{
+		ClusterName:                    options.ClusterName,
+		KubeletConfig:                  kubeletConfig,
+		Taints:                         taints,
+		StartupTaints:                  startupTaints,
+		Labels:                         labels,
+		SubnetID:                       options.SubnetID,
+		PodSubnetID:                    options.PodSubnetID,
+		Arch:                           options.Arch,
+		SubscriptionID:                 options.SubscriptionID,
+		ResourceGroup:                  options.ResourceGroup,
+		KubeletClientTLSBootstrapToken: options.KubeletClientTLSBootstrapToken,
+		KubernetesVersion:              options.KubernetesVersion,
+		ImageDistro:                    imageDistro,
+		IsWindows:                      true,
+		InstanceType:                   instanceType,
+		StorageProfile:                 storageProfile,
+		ClusterResourceGroup:           options.ClusterResourceGroup,
+		NodeBootstrappingProvider:      nodeBootstrappingClient,
+		OSSKU:                          ossku,
+		VTPMEnabled:                    vtpmEnabled,
+		SecureBootEnabled:              secureBootEnabled,
+	}
+}
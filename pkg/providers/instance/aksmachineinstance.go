@@ -33,6 +33,7 @@ import (
 	"github.com/Azure/karpenter-provider-azure/pkg/apis/v1beta1"
 	"github.com/Azure/karpenter-provider-azure/pkg/cache"
 	"github.com/Azure/karpenter-provider-azure/pkg/consts"
+	"github.com/Azure/karpenter-provider-azure/pkg/operator/options"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/allocationstrategy"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/azclient"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/imagefamily"
@@ -40,6 +41,8 @@ import (
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/instance/offerings"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/instancetype"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/launchtemplate"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/subnetcapacity"
+	"github.com/Azure/karpenter-provider-azure/pkg/utils"
 	machineUtils "github.com/Azure/karpenter-provider-azure/pkg/utils/machine"
 	"github.com/Azure/karpenter-provider-azure/pkg/utils/zones"
 )
@@ -159,6 +162,7 @@ type DefaultAKSMachineProvider struct {
 	deletingMachines           sets.Set[string] // tracks in-flight delete operations by machine name
 	deletingMachinesMu         sync.RWMutex
 	machineCache               *machinecache.MachineCache
+	subnetCapacityProvider     *subnetcapacity.Provider
 }
 
 func NewAKSMachineProvider(
@@ -174,6 +178,7 @@ func NewAKSMachineProvider(
 	aksMachinesPoolLocation string,
 	batchCreationEnabled bool,
 	machineCache *machinecache.MachineCache,
+	subnetCapacityProvider *subnetcapacity.Provider,
 ) *DefaultAKSMachineProvider {
 	provider := &DefaultAKSMachineProvider{
 		azClient:                   azClient,
@@ -190,6 +195,7 @@ func NewAKSMachineProvider(
 		beginCreateErrorHandling:   offerings.NewAKSMachineBeginCreateErrorHandler(offeringsCache),
 		deletingMachines:           sets.New[string](),
 		machineCache:               machineCache,
+		subnetCapacityProvider:     subnetCapacityProvider,
 	}
 
 	return provider
@@ -433,6 +439,7 @@ func (p *DefaultAKSMachineProvider) beginCreateMachine(
 		ctx,
 		instanceTypes,
 		scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...),
+		p.allocationStrategyProvider.ResolveStrategy(ctx, nodeClass, nodeClaim),
 	)
 	if selection == nil {
 		return nil, corecloudprovider.NewInsufficientCapacityError(fmt.Errorf("no instance types available"))
@@ -443,6 +450,15 @@ func (p *DefaultAKSMachineProvider) beginCreateMachine(
 	placementScope := selection.PlacementScope()
 	ultraSSD := resolveUltraSSDRequested(nodeClaim)
 
+	// Without subnetSelectorTerms, the machine is created in the vnetSubnetID (or the cluster subnet) only
+	networkPlugin := options.FromContext(ctx).NetworkPlugin
+	networkPluginMode := options.FromContext(ctx).NetworkPluginMode
+	requiredIPs := subnetcapacity.RequiredIPAddressCount(utils.GetMaxPods(nodeClass, networkPlugin, networkPluginMode), networkPlugin, networkPluginMode, nodeClass.Spec.PodSubnetID != nil)
+	subnetID := lo.Ternary(nodeClass.Spec.VNETSubnetID != nil, lo.FromPtr(nodeClass.Spec.VNETSubnetID), options.FromContext(ctx).SubnetID)
+	if _, err := subnetsWithCapacity(p.subnetCapacityProvider, []string{subnetID}, requiredIPs); err != nil {
+		return nil, err
+	}
+
 	// Build the AKS machine template
 	aksMachineTemplate, err := p.buildAKSMachineTemplate(ctx, instanceType, capacityType, placementScope, zone, ultraSSD, nodeClass, nodeClaim)
 	if err != nil {
@@ -455,10 +471,17 @@ func (p *DefaultAKSMachineProvider) beginCreateMachine(
 	}
 
 	// Branch between batch and non-batch creation paths.
+	var aksMachinePromise *AKSMachinePromise
 	if p.batchCreationEnabled {
-		return p.beginCreateMachineBatch(ctx, aksMachineTemplate, aksMachineName, instanceType, capacityType, zone)
+		aksMachinePromise, err = p.beginCreateMachineBatch(ctx, aksMachineTemplate, aksMachineName, instanceType, capacityType, zone)
+	} else {
+		aksMachinePromise, err = p.beginCreateMachineNonBatch(ctx, aksMachineTemplate, aksMachineName, instanceType, capacityType, zone)
 	}
-	return p.beginCreateMachineNonBatch(ctx, aksMachineTemplate, aksMachineName, instanceType, capacityType, zone)
+	if err != nil {
+		return nil, err
+	}
+	p.subnetCapacityProvider.Reserve(subnetID, aksMachineName, requiredIPs)
+	return aksMachinePromise, nil
 }
 
 // beginCreateMachineBatch handles the batch creation path using the AKS machines header batch API and GET-based poller.
@@ -61,6 +61,52 @@ func (p *DefaultAKSMachineProvider) buildAKSMachineTemplate(ctx context.Context,
 		return nil, fmt.Errorf("NodeClaim is not set")
 	}
 
+	// TODO: custom images require the custom OS image headers on create (see parseVMImageID)
+	if nodeClass.UsesCustomImage() {
+		return nil, fmt.Errorf("custom images (spec.imageID) are not yet supported with the AKS machine API, consider not using an AKS machine API provision mode")
+	}
+	// The AKS machine API doesn't accept a spot max price, launching without it would pay more than the AKSNodeClass allows
+	if nodeClass.GetSpotMaxPrice() != "" || nodeClass.GetSpotMaxPricePercentOfOnDemand() != 0 {
+		return nil, fmt.Errorf("spot max prices (spec.spot.maxPrice, spec.spot.maxPricePercentOfOnDemand) are not supported with the AKS machine API, consider not using an AKS machine API provision mode")
+	}
+	// TODO: the AKS machine API doesn't accept a capacity reservation group yet
+	if nodeClass.GetCapacityReservationGroupID() != "" {
+		return nil, fmt.Errorf("capacity reservation groups (spec.capacityReservationGroupID) are not yet supported with the AKS machine API, consider not using an AKS machine API provision mode")
+	}
+	// TODO: the AKS machine API doesn't accept proximity placement groups or dedicated hosts yet
+	if nodeClass.HasPlacement() {
+		return nil, fmt.Errorf("placement (spec.placement) is not yet supported with the AKS machine API, consider not using an AKS machine API provision mode")
+	}
+	// The AKS machine API doesn't accept data disks, AKSNodeClasses with data disks fail validation in this mode
+	if len(nodeClass.Spec.DataDisks) > 0 {
+		return nil, fmt.Errorf("data disks (spec.dataDisks) are not yet supported with the AKS machine API, consider not using an AKS machine API provision mode")
+	}
+	// TODO: the AKS machine API doesn't accept per-machine application or network security groups yet
+	if len(nodeClass.Spec.ApplicationSecurityGroupIDs) > 0 || nodeClass.Spec.NetworkSecurityGroupID != nil {
+		return nil, fmt.Errorf("application security groups (spec.applicationSecurityGroupIDs) and network security groups (spec.networkSecurityGroupID) are not yet supported with the AKS machine API, consider not using an AKS machine API provision mode")
+	}
+	// TODO: map to the node public IP settings of the AKS machine API, once the tags and SKU can be set there
+	if nodeClass.Spec.PublicIP != nil {
+		return nil, fmt.Errorf("public IPs (spec.publicIP) are not yet supported with the AKS machine API, consider not using an AKS machine API provision mode")
+	}
+	// TODO: pass the chosen subnet in the AKS machine network profile, falling back to other subnets like for VMs
+	if len(nodeClass.Spec.SubnetSelectorTerms) > 0 {
+		return nil, fmt.Errorf("subnet selector terms (spec.subnetSelectorTerms) are not yet supported with the AKS machine API, consider not using an AKS machine API provision mode")
+	}
+	// TODO: the AKS machine API doesn't accept user-supplied custom data or scripts yet
+	if nodeClass.Spec.UserData != nil {
+		return nil, fmt.Errorf("user data (spec.userData) is not yet supported with the AKS machine API, consider not using an AKS machine API provision mode")
+	}
+	// TODO: the AKS machine API reserves resources and sets eviction thresholds on its own, pass the overrides once it accepts them
+	if nodeClass.HasKubeletReservationOverrides() {
+		return nil, fmt.Errorf("kubelet reserved resources and eviction thresholds (spec.kubelet) are not yet supported with the AKS machine API, consider not using an AKS machine API provision mode")
+	}
+
+	// TODO: the AKS machine API deletes evicted spot machines, pass the eviction policy once it accepts one
+	if nodeClass.GetSpotEvictionPolicy() == v1beta1.SpotEvictionPolicyDeallocate {
+		return nil, fmt.Errorf("the Deallocate spot eviction policy (spec.spot.evictionPolicy) is not yet supported with the AKS machine API, consider not using an AKS machine API provision mode")
+	}
+
 	// NodeImageVersion
 	// E.g., "AKSUbuntu-2204gen2containerd-2023.11.15"
 	vmImageID, err := p.imageResolver.ResolveNodeImageFromNodeClass(nodeClass, instanceType)
@@ -119,8 +165,8 @@ func (p *DefaultAKSMachineProvider) buildAKSMachineTemplate(ctx context.Context,
 			NodeImageVersion: lo.ToPtr(nodeImageVersion),
 			Network: &armcontainerservice.MachineNetworkProperties{
 				VnetSubnetID: nodeClass.Spec.VNETSubnetID, // AKS machine API take control, if nil
+				PodSubnetID:  nodeClass.Spec.PodSubnetID,  // Pod IPs are allocated dynamically from it, if set
 				// As of the time of writing, the current version of AKS machine API support just that with nil. That is unlikely to change.
-				// PodSubnetID:          "",
 				// EnableNodePublicIP:   nil,
 				// NodePublicIPPrefixID: "",
 				// IPTags:               nil,
@@ -132,7 +178,7 @@ func (p *DefaultAKSMachineProvider) buildAKSMachineTemplate(ctx context.Context,
 				UltraSsdEnabled: lo.ToPtr(ultraSSD),
 			},
 			OperatingSystem: &armcontainerservice.MachineOSProfile{
-				OSType:       lo.ToPtr(armcontainerservice.OSTypeLinux),
+				OSType:       lo.ToPtr(lo.Ternary(nodeClass.IsWindows(), armcontainerservice.OSTypeWindows, armcontainerservice.OSTypeLinux)),
 				OSSKU:        osSku,
 				OSDiskSizeGB: nodeClass.Spec.OSDiskSizeGB, // AKS machine API defaults it if nil
 				OSDiskType:   osDiskType,
@@ -163,7 +209,7 @@ func (p *DefaultAKSMachineProvider) buildAKSMachineTemplate(ctx context.Context,
 
 			Mode: modePtr,
 			Security: &armcontainerservice.MachineSecurityProfile{
-				SSHAccess:              lo.ToPtr(armcontainerservice.AgentPoolSSHAccessLocalUser),
+				SSHAccess:              lo.Ternary(nodeClass.IsWindows(), nil, lo.ToPtr(armcontainerservice.AgentPoolSSHAccessLocalUser)),
 				EnableEncryptionAtHost: lo.ToPtr(nodeClass.GetEncryptionAtHost()),
 				EnableVTPM:             lo.ToPtr(nodeClass.IsVTPMEnabled()),
 				EnableSecureBoot:       lo.ToPtr(nodeClass.IsSecureBootEnabled()),
@@ -302,6 +348,10 @@ func configureOSSKUAndFIPs(nodeClass *v1beta1.AKSNodeClass, orchestratorVersion
 		ossku = armcontainerservice.OSSKUUbuntu2404
 	case v1beta1.AzureLinuxImageFamily:
 		ossku = armcontainerservice.OSSKUAzureLinux
+	case v1beta1.Windows2022ImageFamily:
+		ossku = armcontainerservice.OSSKUWindows2022
+	case v1beta1.Windows2025ImageFamily:
+		ossku = armcontainerservice.OSSKUWindows2025
 	case v1beta1.UbuntuImageFamily:
 		fallthrough
 	default:
@@ -174,6 +174,24 @@ var _ = Describe("AKSMachineInstance Helper Functions", func() {
 			})
 		})
 
+		Context("Windows Image Families", func() {
+			DescribeTable("should configure the Windows OS SKU",
+				func(imageFamily string, expected armcontainerservice.OSSKU) {
+					nodeClass.Spec.ImageFamily = lo.ToPtr(imageFamily)
+
+					ossku, enableFIPs, err := configureOSSKUAndFIPs(nodeClass, "1.33.0")
+
+					Expect(err).ToNot(HaveOccurred())
+					Expect(ossku).ToNot(BeNil())
+					Expect(*ossku).To(Equal(expected))
+					Expect(enableFIPs).ToNot(BeNil())
+					Expect(*enableFIPs).To(BeFalse())
+				},
+				Entry("Windows2022", v1beta1.Windows2022ImageFamily, armcontainerservice.OSSKUWindows2022),
+				Entry("Windows2025", v1beta1.Windows2025ImageFamily, armcontainerservice.OSSKUWindows2025),
+			)
+		})
+
 		Context("Error Cases", func() {
 			It("should return error when ImageFamily is nil", func() {
 				nodeClass.Spec.ImageFamily = nil
@@ -29,8 +29,9 @@ import (
 )
 
 const (
-	vmResourceType  = "microsoft.compute/virtualmachines"
-	nicResourceType = "microsoft.network/networkinterfaces"
+	vmResourceType       = "microsoft.compute/virtualmachines"
+	nicResourceType      = "microsoft.network/networkinterfaces"
+	publicIPResourceType = "microsoft.network/publicipaddresses"
 )
 
 // getResourceListQueryBuilder returns a KQL query builder for listing resources with nodepool tags
@@ -53,6 +54,11 @@ func GetNICListQueryBuilder(rg string) *kql.Builder {
 	return getResourceListQueryBuilder(rg, nicResourceType)
 }
 
+// GetPublicIPListQueryBuilder returns a KQL query builder for listing public IPs with nodepool tags
+func GetPublicIPListQueryBuilder(rg string) *kql.Builder {
+	return getResourceListQueryBuilder(rg, publicIPResourceType)
+}
+
 // createVMFromQueryResponseData converts ARG query response data into a VirtualMachine object
 func createVMFromQueryResponseData(data map[string]interface{}) (*armcompute.VirtualMachine, error) {
 	jsonString, err := json.Marshal(data)
@@ -109,3 +115,31 @@ func createNICFromQueryResponseData(data map[string]interface{}) (*armnetwork.In
 	nic.ID = lo.ToPtr(strings.Join(parts, "/"))
 	return &nic, nil
 }
+
+// createPublicIPFromQueryResponseData converts ARG query response data into a Public IP Address object
+func createPublicIPFromQueryResponseData(data map[string]interface{}) (*armnetwork.PublicIPAddress, error) {
+	jsonString, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	publicIP := armnetwork.PublicIPAddress{}
+	err = json.Unmarshal(jsonString, &publicIP)
+	if err != nil {
+		return nil, err
+	}
+	if publicIP.ID == nil {
+		return nil, fmt.Errorf("public IP address is missing id")
+	}
+	if publicIP.Name == nil {
+		return nil, fmt.Errorf("public IP address is missing name")
+	}
+	if publicIP.Tags == nil {
+		return nil, fmt.Errorf("public IP address is missing tags")
+	}
+	// As with network interfaces, force the last segment of the ID to be lowercase
+	parts := strings.Split(lo.FromPtr(publicIP.ID), "/")
+	parts[len(parts)-1] = strings.ToLower(parts[len(parts)-1])
+	publicIP.ID = lo.ToPtr(strings.Join(parts, "/"))
+	return &publicIP, nil
+}
@@ -134,3 +134,52 @@ func deleteNicIfExists(ctx context.Context, client azapi.NetworkInterfacesAPI, r
 	}
 	return deleteNic(ctx, client, rg, nicName)
 }
+
+func createPublicIP(
+	ctx context.Context,
+	client azapi.PublicIPAddressesAPI,
+	rg string,
+	publicIPName string,
+	publicIP armnetwork.PublicIPAddress,
+) (*armnetwork.PublicIPAddress, error) {
+	poller, err := client.BeginCreateOrUpdate(ctx, rg, publicIPName, publicIP, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := poller.PollUntilDone(ctx, defaultPollerOptions())
+	if err != nil {
+		return nil, err
+	}
+
+	return &res.PublicIPAddress, nil
+}
+
+func deletePublicIP(ctx context.Context, client azapi.PublicIPAddressesAPI, rg, publicIPName string) error {
+	poller, err := client.BeginDelete(ctx, rg, publicIPName, nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = poller.PollUntilDone(ctx, defaultPollerOptions())
+	if err != nil {
+		if sdkerrors.IsNotFoundErr(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func deletePublicIPIfExists(ctx context.Context, client azapi.PublicIPAddressesAPI, rg, publicIPName string) error {
+	_, err := client.Get(ctx, rg, publicIPName, nil)
+	if err != nil {
+		if sdkerrors.IsNotFoundErr(err) {
+			return nil
+		}
+		return err
+	}
+	return deletePublicIP(ctx, client, rg, publicIPName)
+}
@@ -0,0 +1,101 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package offerings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	corecloudprovider "sigs.k8s.io/karpenter/pkg/cloudprovider"
+
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/capacityreservation"
+)
+
+var (
+	CapacityReservationExhaustedReason        = "CapacityReservationExhausted"
+	CapacityReservationGroupUnavailableReason = "CapacityReservationGroupUnavailable"
+)
+
+// CapacityReservations records reservations, or whole capacity reservation groups, that could not be used
+type CapacityReservations interface {
+	MarkExhausted(ctx context.Context, groupID, instanceType, zone string)
+	MarkGroupUnavailable(ctx context.Context, groupID, reason string)
+}
+
+// CapacityReservationErrorHandler handles errors of creates that were placed into a capacity reservation group.
+// Unlike the other handlers, it does not mark the offering unavailable: the reservation is marked exhausted instead,
+// so that the retry creates the same offering as plain on-demand.
+type CapacityReservationErrorHandler struct {
+	CapacityReservations CapacityReservations
+}
+
+func NewCapacityReservationErrorHandler(capacityReservations CapacityReservations) *CapacityReservationErrorHandler {
+	return &CapacityReservationErrorHandler{
+		CapacityReservations: capacityReservations,
+	}
+}
+
+// Handle returns a CreateError if the create failed because of the capacity reservation group, and nil otherwise
+func (h *CapacityReservationErrorHandler) Handle(ctx context.Context, groupID string, instanceType *corecloudprovider.InstanceType, zone string, responseError error) error {
+	if groupID == "" {
+		return nil
+	}
+	// A missing group, or one Karpenter isn't allowed to use, affects every VM size and zone
+	if reason := groupUnavailableReason(responseError); reason != "" {
+		h.CapacityReservations.MarkGroupUnavailable(ctx, groupID, reason)
+		err := fmt.Errorf("unable to use capacity reservation group %s, %s. (will fall back to on-demand capacity outside of the reservation)", groupID, reason)
+		return corecloudprovider.NewCreateError(err, CapacityReservationGroupUnavailableReason, err.Error())
+	}
+	if !isCapacityReservationError(responseError) {
+		return nil
+	}
+	h.CapacityReservations.MarkExhausted(ctx, groupID, instanceType.Name, zone)
+
+	err := fmt.Errorf("unable to use the reserved capacity of capacity reservation group %s for VM size %s in zone %s. (will fall back to on-demand capacity outside of the reservation)", groupID, instanceType.Name, zone)
+	return corecloudprovider.NewCreateError(err, CapacityReservationExhaustedReason, err.Error())
+}
+
+// groupUnavailableReason returns why the capacity reservation group the VM was placed into can't be used at all, or an empty
+// string if the error isn't about the group as a whole
+func groupUnavailableReason(err error) string {
+	var respErr *azcore.ResponseError
+	if !errors.As(err, &respErr) {
+		return ""
+	}
+	if strings.EqualFold(respErr.ErrorCode, capacityreservation.GroupUnavailableReasonNotFound) {
+		return capacityreservation.GroupUnavailableReasonNotFound
+	}
+	// Authorization errors are only about the group if they name it, e.g. a missing permission to deploy VMs into it
+	if (strings.EqualFold(respErr.ErrorCode, "AuthorizationFailed") || strings.EqualFold(respErr.ErrorCode, "LinkedAuthorizationFailed")) &&
+		strings.Contains(strings.ToLower(respErr.Error()), "capacityreservationgroups") {
+		return capacityreservation.GroupUnavailableReasonUnauthorized
+	}
+	return ""
+}
+
+// isCapacityReservationError returns true for errors caused by a reservation of the capacity reservation group the VM was
+// placed into, e.g. a reservation that can't accommodate the VM anymore
+func isCapacityReservationError(err error) bool {
+	var respErr *azcore.ResponseError
+	if !errors.As(err, &respErr) {
+		return false
+	}
+	return strings.Contains(strings.ToLower(respErr.ErrorCode), "capacityreservation")
+}
@@ -0,0 +1,114 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package offerings
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+)
+
+const testCapacityReservationGroupID = "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/capacityReservationGroups/crg"
+
+type fakeCapacityReservations struct {
+	exhausted         []string
+	unavailableGroups []string
+}
+
+func (f *fakeCapacityReservations) MarkExhausted(_ context.Context, groupID, instanceType, zone string) {
+	f.exhausted = append(f.exhausted, groupID+"/"+instanceType+"/"+zone)
+}
+
+func (f *fakeCapacityReservations) MarkGroupUnavailable(_ context.Context, groupID, reason string) {
+	f.unavailableGroups = append(f.unavailableGroups, groupID+"/"+reason)
+}
+
+func TestCapacityReservationErrorHandler(t *testing.T) {
+	instanceType := createInstanceType(testInstanceName)
+	tests := []struct {
+		name                      string
+		groupID                   string
+		err                       error
+		expectedExhausted         []string
+		expectedUnavailableGroups []string
+	}{
+		{
+			name:              "reservation error marks the reservation exhausted",
+			groupID:           testCapacityReservationGroupID,
+			err:               createResponseError("CapacityReservationExhausted", "The capacity reservation has no capacity left"),
+			expectedExhausted: []string{testCapacityReservationGroupID + "/" + testInstanceName + "/westus-1"},
+		},
+		{
+			name:                      "missing reservation group marks the group unavailable",
+			groupID:                   testCapacityReservationGroupID,
+			err:                       createResponseError("CapacityReservationGroupNotFound", "The capacity reservation group was not found"),
+			expectedUnavailableGroups: []string{testCapacityReservationGroupID + "/CapacityReservationGroupNotFound"},
+		},
+		{
+			name:    "authorization error on the reservation group marks the group unavailable",
+			groupID: testCapacityReservationGroupID,
+			err: createResponseError("LinkedAuthorizationFailed", "The client has permission to perform action 'Microsoft.Compute/virtualMachines/write', "+
+				"however it does not have permission to perform action 'Microsoft.Compute/capacityReservationGroups/deploy/action' on the linked scope(s) "+testCapacityReservationGroupID),
+			expectedUnavailableGroups: []string{testCapacityReservationGroupID + "/CapacityReservationGroupUnauthorized"},
+		},
+		{
+			name:    "authorization errors on other resources are left to the other handlers",
+			groupID: testCapacityReservationGroupID,
+			err:     createResponseError("LinkedAuthorizationFailed", "The client does not have permission to perform action 'Microsoft.Network/virtualNetworks/subnets/join/action'"),
+		},
+		{
+			name:    "other errors are left to the other handlers",
+			groupID: testCapacityReservationGroupID,
+			err:     createResponseError("ZonalAllocationFailed", "Allocation failed"),
+		},
+		{
+			name:    "errors that are not response errors are ignored",
+			groupID: testCapacityReservationGroupID,
+			err:     errors.New("CapacityReservationExhausted"),
+		},
+		{
+			name: "creates without a reservation group are ignored",
+			err:  createResponseError("CapacityReservationExhausted", "The capacity reservation has no capacity left"),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			reservations := &fakeCapacityReservations{}
+			handler := NewCapacityReservationErrorHandler(reservations)
+
+			err := handler.Handle(context.Background(), tc.groupID, instanceType, "westus-1", tc.err)
+			g.Expect(reservations.exhausted).To(Equal(tc.expectedExhausted))
+			g.Expect(reservations.unavailableGroups).To(Equal(tc.expectedUnavailableGroups))
+			if tc.expectedExhausted == nil && tc.expectedUnavailableGroups == nil {
+				g.Expect(err).ToNot(HaveOccurred())
+				return
+			}
+			var createErr *cloudprovider.CreateError
+			g.Expect(errors.As(err, &createErr)).To(BeTrue())
+			if tc.expectedUnavailableGroups != nil {
+				g.Expect(createErr.ConditionReason).To(Equal(CapacityReservationGroupUnavailableReason))
+			} else {
+				g.Expect(createErr.ConditionReason).To(Equal(CapacityReservationExhaustedReason))
+			}
+		})
+	}
+}
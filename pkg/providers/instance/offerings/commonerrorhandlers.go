@@ -79,6 +79,7 @@ func markOfferingsUnavailableForCapacityTypeAndPlacement(
 	zone string,
 	capacityType string,
 	reason string,
+	errorCode string,
 	ttl time.Duration,
 ) {
 	selectedPlacementScope := zones.PlacementScopeForZone(zone)
@@ -86,7 +87,7 @@ func markOfferingsUnavailableForCapacityTypeAndPlacement(
 		if getOfferingCapacityType(offering) != capacityType || zones.PlacementScopeForOffering(offering) != selectedPlacementScope {
 			continue
 		}
-		unavailableOfferings.MarkUnavailableWithTTL(ctx, reason, sku, getOfferingZone(offering), capacityType, ttl)
+		unavailableOfferings.MarkUnavailableWithErrorCode(ctx, reason, errorCode, sku, getOfferingZone(offering), capacityType, ttl)
 	}
 }
 
@@ -101,6 +102,7 @@ func markOfferingsUnavailableForPlacementForBothCapacityTypes(
 	instanceType *corecloudprovider.InstanceType,
 	zone string,
 	reason string,
+	errorCode string,
 	ttl time.Duration,
 ) {
 	selectedPlacementScope := zones.PlacementScopeForZone(zone)
@@ -113,8 +115,8 @@ func markOfferingsUnavailableForPlacementForBothCapacityTypes(
 		zonesToBlock[offeringZone] = struct{}{}
 	}
 	for blockedZone := range zonesToBlock {
-		unavailableOfferings.MarkUnavailableWithTTL(ctx, reason, sku, blockedZone, karpv1.CapacityTypeOnDemand, ttl)
-		unavailableOfferings.MarkUnavailableWithTTL(ctx, reason, sku, blockedZone, karpv1.CapacityTypeSpot, ttl)
+		unavailableOfferings.MarkUnavailableWithErrorCode(ctx, reason, errorCode, sku, blockedZone, karpv1.CapacityTypeOnDemand, ttl)
+		unavailableOfferings.MarkUnavailableWithErrorCode(ctx, reason, errorCode, sku, blockedZone, karpv1.CapacityTypeSpot, ttl)
 	}
 }
 
@@ -127,6 +129,7 @@ func markAllPlacementsUnavailableForBothCapacityTypes(
 	sku *skewer.SKU,
 	instanceType *corecloudprovider.InstanceType,
 	reason string,
+	errorCode string,
 	ttl time.Duration,
 ) {
 	zonesToBlock := make(map[string]struct{})
@@ -135,8 +138,8 @@ func markAllPlacementsUnavailableForBothCapacityTypes(
 		zonesToBlock[offeringZone] = struct{}{}
 	}
 	for blockedZone := range zonesToBlock {
-		unavailableOfferings.MarkUnavailableWithTTL(ctx, reason, sku, blockedZone, karpv1.CapacityTypeOnDemand, ttl)
-		unavailableOfferings.MarkUnavailableWithTTL(ctx, reason, sku, blockedZone, karpv1.CapacityTypeSpot, ttl)
+		unavailableOfferings.MarkUnavailableWithErrorCode(ctx, reason, errorCode, sku, blockedZone, karpv1.CapacityTypeOnDemand, ttl)
+		unavailableOfferings.MarkUnavailableWithErrorCode(ctx, reason, errorCode, sku, blockedZone, karpv1.CapacityTypeSpot, ttl)
 	}
 }
 
@@ -151,7 +154,7 @@ func handleLowPriorityQuotaError(
 	errorMessage string,
 ) error {
 	// Mark in cache that spot quota has been reached for this subscription
-	unavailableOfferings.MarkSpotUnavailableWithTTL(ctx, SubscriptionQuotaReachedTTL)
+	unavailableOfferings.MarkSpotUnavailableWithErrorCode(ctx, SubscriptionQuotaReachedReason, errorCode, SubscriptionQuotaReachedTTL)
 	err := fmt.Errorf("this subscription has reached the regional vCPU quota for spot (LowPriorityQuota). To scale beyond this limit, please review the quota increase process here: https://docs.microsoft.com/en-us/azure/azure-portal/supportability/low-priority-quota")
 	return corecloudprovider.NewCreateError(err, SubscriptionQuotaReachedReason, err.Error())
 }
@@ -176,9 +179,9 @@ func handleSKUFamilyQuotaError(
 		// If we have a quota limit of 0 vcpus, we mark the offerings unavailable for an hour.
 		// CPU limits of 0 are usually due to a subscription having no allocated quota for that instance type at all on the subscription.
 		if cpuLimitIsZero(errorMessage) {
-			unavailableOfferings.MarkUnavailableWithTTL(ctx, SubscriptionQuotaReachedReason, sku, getOfferingZone(offering), capacityType, SubscriptionQuotaReachedTTL)
+			unavailableOfferings.MarkUnavailableWithErrorCode(ctx, SubscriptionQuotaReachedReason, errorCode, sku, getOfferingZone(offering), capacityType, SubscriptionQuotaReachedTTL)
 		} else {
-			unavailableOfferings.MarkUnavailableWithTTL(ctx, SubscriptionQuotaReachedReason, sku, getOfferingZone(offering), capacityType, LowQuotaTTL)
+			unavailableOfferings.MarkUnavailableWithErrorCode(ctx, SubscriptionQuotaReachedReason, errorCode, sku, getOfferingZone(offering), capacityType, LowQuotaTTL)
 		}
 	}
 	err := fmt.Errorf("subscription level %s vCPU quota for %s has been reached (may try provision an alternative instance type)", capacityType, instanceType.Name)
@@ -203,7 +206,7 @@ func handleSKUNotAvailableError(
 	if capacityType == karpv1.CapacityTypeOnDemand { // should not happen, defensive check
 		skuNotAvailableTTL = SKUNotAvailableOnDemandTTL // still mark all offerings as unavailable, but with a longer TTL
 	}
-	markOfferingsUnavailableForCapacityTypeAndPlacement(ctx, unavailableOfferings, sku, instanceType, zone, capacityType, SKUNotAvailableReason, skuNotAvailableTTL)
+	markOfferingsUnavailableForCapacityTypeAndPlacement(ctx, unavailableOfferings, sku, instanceType, zone, capacityType, SKUNotAvailableReason, errorCode, skuNotAvailableTTL)
 
 	err := fmt.Errorf(
 		"the requested SKU is unavailable for instance type %s in zone %s with capacity type %s, for more details please visit: https://aka.ms/azureskunotavailable",
@@ -224,8 +227,8 @@ func handleZonalAllocationFailureError(
 	errorCode,
 	errorMessage string,
 ) error {
-	unavailableOfferings.MarkUnavailableWithTTL(ctx, ZonalAllocationFailureReason, sku, zone, karpv1.CapacityTypeOnDemand, AllocationFailureTTL)
-	unavailableOfferings.MarkUnavailableWithTTL(ctx, ZonalAllocationFailureReason, sku, zone, karpv1.CapacityTypeSpot, AllocationFailureTTL)
+	unavailableOfferings.MarkUnavailableWithErrorCode(ctx, ZonalAllocationFailureReason, errorCode, sku, zone, karpv1.CapacityTypeOnDemand, AllocationFailureTTL)
+	unavailableOfferings.MarkUnavailableWithErrorCode(ctx, ZonalAllocationFailureReason, errorCode, sku, zone, karpv1.CapacityTypeSpot, AllocationFailureTTL)
 
 	err := fmt.Errorf("unable to allocate resources in the selected zone (%s). (will try a different zone to fulfill your request)", zone)
 	return corecloudprovider.NewCreateError(err, ZonalAllocationFailureReason, err.Error())
@@ -250,7 +253,7 @@ func handleAllocationFailureError(
 	errorCode,
 	errorMessage string,
 ) error {
-	markOfferingsUnavailableForPlacementForBothCapacityTypes(ctx, unavailableOfferings, sku, instanceType, zone, AllocationFailureReason, AllocationFailureTTL)
+	markOfferingsUnavailableForPlacementForBothCapacityTypes(ctx, unavailableOfferings, sku, instanceType, zone, AllocationFailureReason, errorCode, AllocationFailureTTL)
 
 	err := fmt.Errorf("unable to allocate resources with selected VM size (%s). (will try a different VM size to fulfill your request)", instanceType.Name)
 	return corecloudprovider.NewCreateError(err, AllocationFailureReason, err.Error())
@@ -268,7 +271,7 @@ func handleOverconstrainedZonalAllocationFailureError(
 	errorMessage string,
 ) error {
 	// OverconstrainedZonalAllocationFailure means that specific zone cannot accommodate the selected size and capacity combination.
-	unavailableOfferings.MarkUnavailableWithTTL(ctx, OverconstrainedZonalAllocationFailureReason, sku, zone, capacityType, AllocationFailureTTL)
+	unavailableOfferings.MarkUnavailableWithErrorCode(ctx, OverconstrainedZonalAllocationFailureReason, errorCode, sku, zone, capacityType, AllocationFailureTTL)
 
 	err := fmt.Errorf("unable to allocate resources in the selected zone (%s) with %s capacity type and %s VM size. (will try a different zone, capacity type or VM size to fulfill your request)", zone, capacityType, instanceType.Name)
 	return corecloudprovider.NewCreateError(err, OverconstrainedZonalAllocationFailureReason, err.Error())
@@ -285,7 +288,7 @@ func handleOverconstrainedAllocationFailureError(
 	errorCode,
 	errorMessage string,
 ) error {
-	markOfferingsUnavailableForCapacityTypeAndPlacement(ctx, unavailableOfferings, sku, instanceType, zone, capacityType, OverconstrainedAllocationFailureReason, AllocationFailureTTL)
+	markOfferingsUnavailableForCapacityTypeAndPlacement(ctx, unavailableOfferings, sku, instanceType, zone, capacityType, OverconstrainedAllocationFailureReason, errorCode, AllocationFailureTTL)
 
 	err := fmt.Errorf("unable to allocate resources in all zones with %s capacity type and %s VM size. (will try a different capacity type or VM size to fulfill your request)", capacityType, instanceType.Name)
 	return corecloudprovider.NewCreateError(err, OverconstrainedAllocationFailureReason, err.Error())
@@ -174,12 +174,19 @@ func TestMarkOfferingsUnavailableForCapacityTypeAndPlacement(t *testing.T) {
 	instanceType := createCommonErrorInstanceType(
 		zone1OnDemand, zone1Spot, zone2OnDemand, zone2Spot, regionalOnDemand, regionalSpot)
 
-	markOfferingsUnavailableForCapacityTypeAndPlacement(ctx, unavailableOfferings, sku, instanceType, testZone1, karpv1.CapacityTypeSpot, AllocationFailureReason, AllocationFailureTTL)
+	markOfferingsUnavailableForCapacityTypeAndPlacement(ctx, unavailableOfferings, sku, instanceType, testZone1, karpv1.CapacityTypeSpot, AllocationFailureReason, "AllocationFailed", AllocationFailureTTL)
 
 	g.Expect(unavailableOfferings.IsUnavailable(sku, testZone1, karpv1.CapacityTypeSpot)).To(BeTrue())
 	g.Expect(unavailableOfferings.IsUnavailable(sku, testZone2, karpv1.CapacityTypeSpot)).To(BeTrue())
 	g.Expect(unavailableOfferings.IsUnavailable(sku, zones.Regional, karpv1.CapacityTypeSpot)).To(BeFalse())
 	g.Expect(unavailableOfferings.IsUnavailable(sku, testZone1, karpv1.CapacityTypeOnDemand)).To(BeFalse())
+
+	marked := unavailableOfferings.List()
+	g.Expect(marked).To(HaveLen(2))
+	for _, offering := range marked {
+		g.Expect(offering.Reason).To(Equal(AllocationFailureReason))
+		g.Expect(offering.ErrorCode).To(Equal("AllocationFailed"))
+	}
 }
 
 func TestMarkOfferingsUnavailableForRegionalPlacement(t *testing.T) {
@@ -190,7 +197,7 @@ func TestMarkOfferingsUnavailableForRegionalPlacement(t *testing.T) {
 	instanceType := createCommonErrorInstanceType(
 		zone1OnDemand, zone1Spot, zone2OnDemand, zone2Spot, regionalOnDemand, regionalSpot)
 
-	markOfferingsUnavailableForPlacementForBothCapacityTypes(ctx, unavailableOfferings, sku, instanceType, zones.Regional, AllocationFailureReason, AllocationFailureTTL)
+	markOfferingsUnavailableForPlacementForBothCapacityTypes(ctx, unavailableOfferings, sku, instanceType, zones.Regional, AllocationFailureReason, "AllocationFailed", AllocationFailureTTL)
 
 	g.Expect(unavailableOfferings.IsUnavailable(sku, zones.Regional, karpv1.CapacityTypeOnDemand)).To(BeTrue())
 	g.Expect(unavailableOfferings.IsUnavailable(sku, zones.Regional, karpv1.CapacityTypeSpot)).To(BeTrue())
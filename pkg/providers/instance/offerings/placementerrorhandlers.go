@@ -0,0 +1,77 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package offerings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	sdkerrors "github.com/Azure/azure-sdk-for-go-extensions/pkg/errors"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	corecloudprovider "sigs.k8s.io/karpenter/pkg/cloudprovider"
+
+	"github.com/Azure/karpenter-provider-azure/pkg/cache"
+)
+
+var PlacementAllocationFailureReason = "PlacementAllocationFailure"
+
+// PlacementErrorHandler handles allocation errors of creates that were constrained by a proximity placement group or dedicated hosts.
+// Within these constraints, allocation failures (e.g. OverconstrainedAllocationRequest once the proximity placement group
+// is pinned to a datacenter) say nothing about capacity outside of them. So unlike the other handlers, the offerings are only
+// marked unavailable for instances with the same placement constraints.
+type PlacementErrorHandler struct {
+	UnavailableOfferings *cache.UnavailableOfferings
+}
+
+func NewPlacementErrorHandler(unavailableOfferings *cache.UnavailableOfferings) *PlacementErrorHandler {
+	return &PlacementErrorHandler{
+		UnavailableOfferings: unavailableOfferings,
+	}
+}
+
+// Handle returns a CreateError if the create failed to allocate within the placement constraints, and nil otherwise
+func (h *PlacementErrorHandler) Handle(ctx context.Context, placementKey string, instanceType *corecloudprovider.InstanceType, zone, capacityType string, responseError error) error {
+	if placementKey == "" {
+		return nil
+	}
+	var errorCode string
+	var respErr *azcore.ResponseError
+	if errors.As(responseError, &respErr) {
+		errorCode = respErr.ErrorCode
+	}
+
+	switch {
+	case sdkerrors.OverconstrainedZonalAllocationFailureOccurred(responseError), sdkerrors.ZonalAllocationFailureOccurred(responseError):
+		// Only the zone can't accommodate the VM size within the constraints
+		h.UnavailableOfferings.MarkUnavailableForPlacement(ctx, PlacementAllocationFailureReason, errorCode, placementKey, instanceType.Name, zone, capacityType, AllocationFailureTTL)
+	case sdkerrors.OverconstrainedAllocationFailureOccurred(responseError), sdkerrors.AllocationFailureOccurred(responseError):
+		// No zone can accommodate the VM size within the constraints, e.g. the datacenter the proximity placement group
+		// is pinned to doesn't offer it, or the dedicated hosts don't support it or have no room left for it
+		for _, offering := range instanceType.Offerings {
+			if getOfferingCapacityType(offering) != capacityType {
+				continue
+			}
+			h.UnavailableOfferings.MarkUnavailableForPlacement(ctx, PlacementAllocationFailureReason, errorCode, placementKey, instanceType.Name, getOfferingZone(offering), capacityType, AllocationFailureTTL)
+		}
+	default:
+		return nil
+	}
+
+	err := fmt.Errorf("unable to allocate %s VM size %s in zone %s within the placement constraints (proximity placement group or dedicated hosts). (will try a different zone or VM size to fulfill your request)", capacityType, instanceType.Name, zone)
+	return corecloudprovider.NewCreateError(err, PlacementAllocationFailureReason, err.Error())
+}
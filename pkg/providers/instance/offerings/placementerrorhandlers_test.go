@@ -0,0 +1,96 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package offerings
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	sdkerrors "github.com/Azure/azure-sdk-for-go-extensions/pkg/errors"
+	. "github.com/onsi/gomega"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+
+	"github.com/Azure/karpenter-provider-azure/pkg/cache"
+)
+
+const testPlacementKey = "/subscriptions/sub/resourcegroups/rg/providers/microsoft.compute/proximityplacementgroups/ppg,,"
+
+func TestPlacementErrorHandler(t *testing.T) {
+	instanceType := createInstanceType(testInstanceName, zone1OnDemand, zone1Spot, zone2OnDemand, zone2Spot)
+	tests := []struct {
+		name                string
+		placementKey        string
+		err                 error
+		expectedUnavailable []offering
+	}{
+		{
+			name:                "overconstrained allocation marks all zones of the capacity type within the placement",
+			placementKey:        testPlacementKey,
+			err:                 createResponseError(sdkerrors.OverconstrainedAllocationRequest, "Allocation failed. VM(s) with the following constraints cannot be allocated"),
+			expectedUnavailable: []offering{zone1OnDemand, zone2OnDemand},
+		},
+		{
+			name:                "overconstrained zonal allocation marks the zone within the placement",
+			placementKey:        testPlacementKey,
+			err:                 createResponseError(sdkerrors.OverconstrainedZonalAllocationRequest, "Allocation failed. VM(s) with the following constraints cannot be allocated"),
+			expectedUnavailable: []offering{zone1OnDemand},
+		},
+		{
+			name:                "allocation failure marks all zones of the capacity type within the placement",
+			placementKey:        testPlacementKey,
+			err:                 createResponseError(sdkerrors.AllocationFailed, "Allocation failed"),
+			expectedUnavailable: []offering{zone1OnDemand, zone2OnDemand},
+		},
+		{
+			name:         "other errors are left to the other handlers",
+			placementKey: testPlacementKey,
+			err:          createResponseError(sdkerrors.SKUNotAvailableErrorCode, "The requested VM size is not available"),
+		},
+		{
+			name: "creates without placement constraints are left to the other handlers",
+			err:  createResponseError(sdkerrors.OverconstrainedAllocationRequest, "Allocation failed"),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			unavailableOfferings := cache.NewUnavailableOfferings()
+			handler := NewPlacementErrorHandler(unavailableOfferings)
+
+			err := handler.Handle(context.Background(), tc.placementKey, instanceType, testZone1, karpv1.CapacityTypeOnDemand, tc.err)
+			for _, o := range []offering{zone1OnDemand, zone1Spot, zone2OnDemand, zone2Spot} {
+				expected := false
+				for _, unavailable := range tc.expectedUnavailable {
+					expected = expected || unavailable == o
+				}
+				g.Expect(unavailableOfferings.IsUnavailableForPlacement(tc.placementKey, testInstanceName, o.zone, o.capacityType)).To(Equal(expected), "%s/%s", o.zone, o.capacityType)
+				// the offering itself remains available outside of the placement
+				g.Expect(unavailableOfferings.IsUnavailable(createTestSKU(testInstanceName, testInstanceVMSize, testInstanceFamilyName, "2"), o.zone, o.capacityType)).To(BeFalse())
+			}
+			if tc.expectedUnavailable == nil {
+				g.Expect(err).ToNot(HaveOccurred())
+				return
+			}
+			var createErr *cloudprovider.CreateError
+			g.Expect(errors.As(err, &createErr)).To(BeTrue())
+			g.Expect(createErr.ConditionReason).To(Equal(PlacementAllocationFailureReason))
+		})
+	}
+}
@@ -0,0 +1,102 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	sdkerrors "github.com/Azure/azure-sdk-for-go-extensions/pkg/errors"
+	gocache "github.com/patrickmn/go-cache"
+	"github.com/samber/lo"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	corecloudprovider "sigs.k8s.io/karpenter/pkg/cloudprovider"
+
+	"github.com/Azure/karpenter-provider-azure/pkg/apis/v1beta1"
+	"github.com/Azure/karpenter-provider-azure/pkg/operator/options"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/subnetcapacity"
+)
+
+var subnetFullCodes = []string{"SubnetIsFull", "InsufficientSubnetSize"}
+
+// isSubnetFullError returns true if err is an Azure error about the subnet of a NIC not having enough free IPs
+func isSubnetFullError(err error) bool {
+	azErr := sdkerrors.IsResponseError(err)
+	if azErr == nil {
+		return false
+	}
+	return lo.ContainsBy(subnetFullCodes, func(code string) bool { return strings.EqualFold(azErr.ErrorCode, code) })
+}
+
+// candidateSubnetIDs returns the subnets that an instance in the zone can be launched into, in order of preference.
+// Without subnetSelectorTerms, that is only the vnetSubnetID (or the cluster subnet). Otherwise, these are the subnets
+// resolved in the status that have affinity to the zone: the ones with the zone before the ones without zones, the ones
+// that were not recently full before the others, each by decreasing number of free IPs (less the ones of the NICs created
+// since the subnet was observed).
+func (p *DefaultVMProvider) candidateSubnetIDs(ctx context.Context, nodeClass *v1beta1.AKSNodeClass, zone string) []string {
+	if len(nodeClass.Spec.SubnetSelectorTerms) == 0 {
+		return []string{lo.Ternary(nodeClass.Spec.VNETSubnetID != nil, lo.FromPtr(nodeClass.Spec.VNETSubnetID), options.FromContext(ctx).SubnetID)}
+	}
+	subnets := lo.Filter(nodeClass.Status.Subnets, func(subnet v1beta1.Subnet, _ int) bool {
+		return zone == "" || len(subnet.Zones) == 0 || slices.Contains(subnet.Zones, zone)
+	})
+	rank := func(subnet v1beta1.Subnet) (bool, bool, int64) {
+		_, full := p.fullSubnets.Get(strings.ToLower(subnet.ID))
+		available, ok := p.subnetCapacityProvider.Available(subnet.ID)
+		if !ok {
+			available = lo.FromPtr(subnet.AvailableIPAddressCount)
+		}
+		return zone != "" && len(subnet.Zones) > 0, !full, available
+	}
+	sort.SliceStable(subnets, func(i, j int) bool {
+		iZonal, iNotFull, iAvailable := rank(subnets[i])
+		jZonal, jNotFull, jAvailable := rank(subnets[j])
+		if iZonal != jZonal {
+			return iZonal
+		}
+		if iNotFull != jNotFull {
+			return iNotFull
+		}
+		return iAvailable > jAvailable
+	})
+	return lo.Map(subnets, func(subnet v1beta1.Subnet, _ int) string { return subnet.ID })
+}
+
+// subnetsWithCapacity filters out the subnets that don't have the free IPs a node needs, the ones whose free IPs are unknown
+// are kept. It returns an insufficient capacity error when none of the subnets have them.
+func subnetsWithCapacity(subnetCapacityProvider *subnetcapacity.Provider, subnetIDs []string, requiredIPs int64) ([]string, error) {
+	fitting := lo.Filter(subnetIDs, func(subnetID string, _ int) bool {
+		return subnetCapacityProvider.Fits(subnetID, requiredIPs)
+	})
+	if len(fitting) == 0 {
+		exhausted := lo.Map(subnetIDs, func(subnetID string, _ int) string {
+			available, _ := subnetCapacityProvider.Available(subnetID)
+			return fmt.Sprintf("%s (%d available)", subnetID, available)
+		})
+		return nil, corecloudprovider.NewInsufficientCapacityError(fmt.Errorf("subnet IPs exhausted, a node needs %d free IP addresses: %s", requiredIPs, strings.Join(exhausted, ", ")))
+	}
+	return fitting, nil
+}
+
+// markSubnetFull makes the subnet be tried last for new instances, until NRP may have freed up some of its IPs
+func (p *DefaultVMProvider) markSubnetFull(ctx context.Context, subnetID string, err error) {
+	log.FromContext(ctx).Info("subnet is full", "subnetID", subnetID, "error", err.Error())
+	p.fullSubnets.Set(strings.ToLower(subnetID), struct{}{}, gocache.DefaultExpiration)
+}
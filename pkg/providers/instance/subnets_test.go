@@ -0,0 +1,174 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork"
+	. "github.com/onsi/gomega"
+	gocache "github.com/patrickmn/go-cache"
+	"github.com/samber/lo"
+	corecloudprovider "sigs.k8s.io/karpenter/pkg/cloudprovider"
+
+	"github.com/Azure/karpenter-provider-azure/pkg/apis/v1beta1"
+	"github.com/Azure/karpenter-provider-azure/pkg/cache"
+	"github.com/Azure/karpenter-provider-azure/pkg/operator/options"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/subnetcapacity"
+)
+
+func TestIsSubnetFullError(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		expect bool
+	}{
+		{
+			name:   "SubnetIsFull",
+			err:    fmt.Errorf("creating nic: %w", &azcore.ResponseError{ErrorCode: "SubnetIsFull", StatusCode: 400}),
+			expect: true,
+		},
+		{
+			name:   "InsufficientSubnetSize",
+			err:    &azcore.ResponseError{ErrorCode: "InsufficientSubnetSize", StatusCode: 400},
+			expect: true,
+		},
+		{
+			name:   "different error code does not match",
+			err:    &azcore.ResponseError{ErrorCode: "SubnetNotFound", StatusCode: 400},
+			expect: false,
+		},
+		{
+			name:   "non-Azure error does not match",
+			err:    errors.New("SubnetIsFull"),
+			expect: false,
+		},
+		{
+			name:   "nil error does not match",
+			err:    nil,
+			expect: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(isSubnetFullError(tt.err)).To(Equal(tt.expect))
+		})
+	}
+}
+
+func TestCandidateSubnetIDs(t *testing.T) {
+	const clusterSubnetID = "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet/subnets/cluster"
+	ctx := options.ToContext(context.Background(), &options.Options{SubnetID: clusterSubnetID})
+	subnets := []v1beta1.Subnet{
+		{ID: "small", AvailableIPAddressCount: lo.ToPtr[int64](10)},
+		{ID: "large", AvailableIPAddressCount: lo.ToPtr[int64](1000)},
+		{ID: "zone-1", Zones: []string{"westus2-1"}, AvailableIPAddressCount: lo.ToPtr[int64](5)},
+		{ID: "zone-2", Zones: []string{"westus2-2"}, AvailableIPAddressCount: lo.ToPtr[int64](5000)},
+	}
+	tests := []struct {
+		name        string
+		nodeClass   *v1beta1.AKSNodeClass
+		zone        string
+		fullSubnets []string
+		expect      []string
+	}{
+		{
+			name:      "cluster subnet without subnetSelectorTerms",
+			nodeClass: &v1beta1.AKSNodeClass{},
+			zone:      "westus2-1",
+			expect:    []string{clusterSubnetID},
+		},
+		{
+			name:      "vnetSubnetID without subnetSelectorTerms",
+			nodeClass: &v1beta1.AKSNodeClass{Spec: v1beta1.AKSNodeClassSpec{VNETSubnetID: lo.ToPtr("nodeclass")}},
+			zone:      "westus2-1",
+			expect:    []string{"nodeclass"},
+		},
+		{
+			name:      "subnets with affinity to the zone first, then by free IPs",
+			nodeClass: &v1beta1.AKSNodeClass{Spec: v1beta1.AKSNodeClassSpec{SubnetSelectorTerms: []v1beta1.SubnetSelectorTerm{{}}}, Status: v1beta1.AKSNodeClassStatus{Subnets: subnets}},
+			zone:      "westus2-1",
+			expect:    []string{"zone-1", "large", "small"},
+		},
+		{
+			name:      "every subnet without a zone",
+			nodeClass: &v1beta1.AKSNodeClass{Spec: v1beta1.AKSNodeClassSpec{SubnetSelectorTerms: []v1beta1.SubnetSelectorTerm{{}}}, Status: v1beta1.AKSNodeClassStatus{Subnets: subnets}},
+			zone:      "",
+			expect:    []string{"zone-2", "large", "small", "zone-1"},
+		},
+		{
+			name:        "recently full subnets last",
+			nodeClass:   &v1beta1.AKSNodeClass{Spec: v1beta1.AKSNodeClassSpec{SubnetSelectorTerms: []v1beta1.SubnetSelectorTerm{{}}}, Status: v1beta1.AKSNodeClassStatus{Subnets: subnets}},
+			zone:        "westus2-3",
+			fullSubnets: []string{"large"},
+			expect:      []string{"small", "large"},
+		},
+		{
+			name:      "no subnets resolved",
+			nodeClass: &v1beta1.AKSNodeClass{Spec: v1beta1.AKSNodeClassSpec{SubnetSelectorTerms: []v1beta1.SubnetSelectorTerm{{}}}},
+			zone:      "westus2-1",
+			expect:    []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			p := &DefaultVMProvider{
+				fullSubnets:            gocache.New(cache.FullSubnetsTTL, cache.DefaultCleanupInterval),
+				subnetCapacityProvider: subnetcapacity.NewProvider(nil),
+			}
+			for _, subnetID := range tt.fullSubnets {
+				p.markSubnetFull(ctx, subnetID, errors.New("full"))
+			}
+			g.Expect(p.candidateSubnetIDs(ctx, tt.nodeClass, tt.zone)).To(Equal(tt.expect))
+		})
+	}
+}
+
+func TestSubnetsWithCapacity(t *testing.T) {
+	g := NewWithT(t)
+	subnetWithPrefix := func(prefix string) *armnetwork.Subnet {
+		return &armnetwork.Subnet{Properties: &armnetwork.SubnetPropertiesFormat{AddressPrefix: lo.ToPtr(prefix)}}
+	}
+	subnetCapacityProvider := subnetcapacity.NewProvider(nil)
+	subnetCapacityProvider.Update("small", subnetWithPrefix("10.0.0.0/27"), time.Now().Add(-time.Minute)) // 27 free IPs
+	subnetCapacityProvider.Update("large", subnetWithPrefix("10.1.0.0/24"), time.Now().Add(-time.Minute)) // 251 free IPs
+
+	// the free IPs of "unknown" are unknown, it is assumed to fit
+	subnetIDs, err := subnetsWithCapacity(subnetCapacityProvider, []string{"small", "large", "unknown"}, 31)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(subnetIDs).To(Equal([]string{"large", "unknown"}))
+
+	_, err = subnetsWithCapacity(subnetCapacityProvider, []string{"small"}, 31)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(corecloudprovider.IsInsufficientCapacityError(err)).To(BeTrue())
+	g.Expect(err.Error()).To(ContainSubstring("a node needs 31 free IP addresses: small (27 available)"))
+
+	// the NICs created since the subnet was observed use its IPs
+	subnetCapacityProvider.Reserve("large", "nic-1", 240)
+	_, err = subnetsWithCapacity(subnetCapacityProvider, []string{"small", "large"}, 31)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("large (11 available)"))
+}
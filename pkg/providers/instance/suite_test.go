@@ -57,8 +57,11 @@ import (
 	"github.com/Azure/karpenter-provider-azure/pkg/fake"
 	metrics "github.com/Azure/karpenter-provider-azure/pkg/metrics"
 	"github.com/Azure/karpenter-provider-azure/pkg/operator/options"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/imagefamily/bootstrap"
 	instancemetrics "github.com/Azure/karpenter-provider-azure/pkg/providers/instance"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/instance/offerings"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/launchtemplate"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/loadbalancer"
 	"github.com/Azure/karpenter-provider-azure/pkg/test"
 	. "github.com/Azure/karpenter-provider-azure/pkg/test/expectations"
 	"github.com/Azure/karpenter-provider-azure/pkg/utils/zones"
@@ -89,8 +92,8 @@ func TestAzure(t *testing.T) {
 	ctx, stop = context.WithCancel(ctx) //nolint:gosec // G118: stop is called in AfterSuite
 	azureEnv = test.NewEnvironment(ctx, env)
 	azureEnvNonZonal = test.NewEnvironmentNonZonal(ctx, env)
-	cloudProvider = cloudprovider.New(azureEnv.InstanceTypesProvider, azureEnv.VMInstanceProvider, azureEnv.AKSMachineProvider, events.NewRecorder(&record.FakeRecorder{}), env.Client, azureEnv.ImageProvider, azureEnv.InstanceTypeStore)
-	cloudProviderNonZonal = cloudprovider.New(azureEnvNonZonal.InstanceTypesProvider, azureEnvNonZonal.VMInstanceProvider, azureEnvNonZonal.AKSMachineProvider, events.NewRecorder(&record.FakeRecorder{}), env.Client, azureEnvNonZonal.ImageProvider, azureEnv.InstanceTypeStore)
+	cloudProvider = cloudprovider.New(azureEnv.InstanceTypesProvider, azureEnv.VMInstanceProvider, azureEnv.AKSMachineProvider, events.NewRecorder(&record.FakeRecorder{}), env.Client, azureEnv.ImageProvider, azureEnv.InstanceTypeStore, azureEnv.SpotEvictionsCache, azureEnv.QuotaProvider)
+	cloudProviderNonZonal = cloudprovider.New(azureEnvNonZonal.InstanceTypesProvider, azureEnvNonZonal.VMInstanceProvider, azureEnvNonZonal.AKSMachineProvider, events.NewRecorder(&record.FakeRecorder{}), env.Client, azureEnvNonZonal.ImageProvider, azureEnv.InstanceTypeStore, azureEnvNonZonal.SpotEvictionsCache, azureEnvNonZonal.QuotaProvider)
 	fakeClock = &clock.FakeClock{}
 	cluster = state.NewCluster(fakeClock, env.Client, cloudProvider)
 	coreProvisioner = provisioning.NewProvisioner(env.Client, events.NewRecorder(&record.FakeRecorder{}), cloudProvider, cluster, fakeClock, deviceallocation.NewController(env.Client))
@@ -382,6 +385,8 @@ var _ = Describe("VMInstanceProvider", func() {
 				env.Client,
 				azureEnv.ImageProvider,
 				azureEnv.InstanceTypeStore,
+				azureEnv.SpotEvictionsCache,
+				azureEnv.QuotaProvider,
 			)
 			test.ApplyDefaultStatus(nodeClass, env, newOptions.UseSIG)
 		})
@@ -482,6 +487,22 @@ var _ = Describe("VMInstanceProvider", func() {
 			}
 			ExpectKubeletFlags(azureEnv, customData, expectedFlags)
 		})
+		It("should not include secondary ips when pods get their IPs from a pod subnet", func() {
+			nodeClass.Spec.PodSubnetID = lo.ToPtr("/subscriptions/subscriptionID/resourceGroups/test-resourceGroup/providers/Microsoft.Network/virtualNetworks/aks-vnet-12345678/subnets/pods")
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+
+			pod := coretest.UnschedulablePod(coretest.PodOptions{})
+			ExpectProvisionedAndWaitForPromises(ctx, env.Client, cluster, cloudProvider, coreProvisioner, azureEnv, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+
+			Expect(azureEnv.NetworkInterfacesAPI.NetworkInterfacesCreateOrUpdateBehavior.CalledWithInput.Len()).To(Equal(1))
+			nic := azureEnv.NetworkInterfacesAPI.NetworkInterfacesCreateOrUpdateBehavior.CalledWithInput.Pop().Interface
+			Expect(nic.Properties.IPConfigurations).To(HaveLen(1))
+			customData := ExpectDecodedCustomData(azureEnv)
+			ExpectKubeletFlags(azureEnv, customData, map[string]string{
+				"max-pods": "30",
+			})
+		})
 		It("should include 1 ip config for Azure CNI Overlay", func() {
 			ctx = options.ToContext(
 				ctx,
@@ -510,6 +531,50 @@ var _ = Describe("VMInstanceProvider", func() {
 			}
 			ExpectKubeletFlags(azureEnv, customData, expectedFlags)
 		})
+		It("should include an IPv6 ip config in the IPv6 backend pools for dual-stack Azure CNI Overlay", func() {
+			ctx = options.ToContext(
+				ctx,
+				test.Options(test.OptionsFields{
+					NetworkPlugin:        lo.ToPtr(consts.NetworkPluginAzure),
+					NetworkPluginMode:    lo.ToPtr(consts.NetworkPluginModeOverlay),
+					IPv6DualStackEnabled: lo.ToPtr(true),
+				}))
+			nodeResourceGroup := options.FromContext(ctx).NodeResourceGroup
+			standardLB := test.MakeStandardLoadBalancer(nodeResourceGroup, loadbalancer.SLBName, true)
+			ipv6LB := test.MakeStandardLoadBalancer(nodeResourceGroup, loadbalancer.SLBNameIPv6, true)
+			azureEnv.LoadBalancersAPI.LoadBalancers.Store(lo.FromPtr(standardLB.ID), standardLB)
+			azureEnv.LoadBalancersAPI.LoadBalancers.Store(lo.FromPtr(ipv6LB.ID), ipv6LB)
+
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+
+			pod := coretest.UnschedulablePod(coretest.PodOptions{})
+			ExpectProvisionedAndWaitForPromises(ctx, env.Client, cluster, cloudProvider, coreProvisioner, azureEnv, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+
+			Expect(azureEnv.NetworkInterfacesAPI.NetworkInterfacesCreateOrUpdateBehavior.CalledWithInput.Len()).To(Equal(1))
+			nic := azureEnv.NetworkInterfacesAPI.NetworkInterfacesCreateOrUpdateBehavior.CalledWithInput.Pop().Interface
+			Expect(nic.Properties.IPConfigurations).To(HaveLen(2))
+			poolIDs := func(ipConfig *armnetwork.InterfaceIPConfiguration) []string {
+				return lo.Map(ipConfig.Properties.LoadBalancerBackendAddressPools, func(pool *armnetwork.BackendAddressPool, _ int) string { return lo.FromPtr(pool.ID) })
+			}
+			primary, ipv6 := nic.Properties.IPConfigurations[0], nic.Properties.IPConfigurations[1]
+			Expect(lo.FromPtr(primary.Properties.Primary)).To(BeTrue())
+			Expect(poolIDs(primary)).To(ConsistOf(
+				fake.MakeBackendAddressPoolID(nodeResourceGroup, loadbalancer.SLBName, loadbalancer.SLBInboundBackendPoolName),
+				fake.MakeBackendAddressPoolID(nodeResourceGroup, loadbalancer.SLBName, loadbalancer.SLBOutboundBackendPoolName),
+			))
+			Expect(lo.FromPtr(ipv6.Name)).To(Equal(instancemetrics.IPv6IPConfigName))
+			Expect(lo.FromPtr(ipv6.Properties.Primary)).To(BeFalse())
+			Expect(lo.FromPtr(ipv6.Properties.PrivateIPAddressVersion)).To(Equal(armnetwork.IPVersionIPv6))
+			Expect(poolIDs(ipv6)).To(ConsistOf(
+				fake.MakeBackendAddressPoolID(nodeResourceGroup, loadbalancer.SLBNameIPv6, loadbalancer.SLBInboundBackendPoolName),
+				fake.MakeBackendAddressPoolID(nodeResourceGroup, loadbalancer.SLBNameIPv6, loadbalancer.SLBOutboundBackendPoolName),
+			))
+			Expect(lo.FromPtr(ipv6.Properties.Subnet.ID)).To(Equal(lo.FromPtr(primary.Properties.Subnet.ID)))
+
+			customData := ExpectDecodedCustomData(azureEnv)
+			Expect(customData).To(ContainSubstring(`IPV6_DUAL_STACK_ENABLED="true"`))
+		})
 		It("should set the number of secondary ips equal to max pods (NodeSubnet)", func() {
 			nodeClass.Spec.MaxPods = lo.ToPtr(int32(11))
 			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
@@ -676,6 +741,212 @@ var _ = Describe("VMInstanceProvider", func() {
 		Expect(lo.FromPtr(nic.Properties.NetworkSecurityGroup.ID)).To(Equal(expectedNSGID))
 	})
 
+	It("should attach the NodeClass application security groups and network security group to the nic", func() {
+		asgID := "/subscriptions/12345678-1234-1234-1234-123456789012/resourceGroups/security/providers/Microsoft.Network/applicationSecurityGroups/asg"
+		nsgID := "/subscriptions/12345678-1234-1234-1234-123456789012/resourceGroups/security/providers/Microsoft.Network/networkSecurityGroups/strict-nsg"
+		nodeClass.Spec.ApplicationSecurityGroupIDs = []string{asgID}
+		nodeClass.Spec.NetworkSecurityGroupID = lo.ToPtr(nsgID)
+
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+
+		pod := coretest.UnschedulablePod(coretest.PodOptions{})
+		ExpectProvisionedAndWaitForPromises(ctx, env.Client, cluster, cloudProvider, coreProvisioner, azureEnv, pod)
+		ExpectScheduled(ctx, env.Client, pod)
+
+		Expect(azureEnv.NetworkInterfacesAPI.NetworkInterfacesCreateOrUpdateBehavior.CalledWithInput.Len()).To(Equal(1))
+		nic := azureEnv.NetworkInterfacesAPI.NetworkInterfacesCreateOrUpdateBehavior.CalledWithInput.Pop().Interface
+		Expect(nic.Properties.NetworkSecurityGroup).ToNot(BeNil())
+		Expect(lo.FromPtr(nic.Properties.NetworkSecurityGroup.ID)).To(Equal(nsgID))
+		Expect(nic.Properties.IPConfigurations).ToNot(BeEmpty())
+		for _, ipConfig := range nic.Properties.IPConfigurations {
+			Expect(ipConfig.Properties.ApplicationSecurityGroups).To(HaveLen(1))
+			Expect(lo.FromPtr(ipConfig.Properties.ApplicationSecurityGroups[0].ID)).To(Equal(asgID))
+		}
+	})
+
+	Context("PublicIP", func() {
+		It("should create a public IP from the prefix and attach it to the primary ipconfig of the nic", func() {
+			prefixID := "/subscriptions/12345678-1234-1234-1234-123456789012/resourceGroups/game/providers/Microsoft.Network/publicIPPrefixes/servers"
+			nodeClass.Spec.PublicIP = &v1beta1.PublicIPConfiguration{
+				PublicIPPrefixID: lo.ToPtr(prefixID),
+				Tags:             map[string]string{"game": "servers", launchtemplate.NodePoolTagKey: "overridden"},
+				IPTags:           []v1beta1.PublicIPTag{{Type: "RoutingPreference", Tag: "Internet"}},
+			}
+
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod(coretest.PodOptions{})
+			ExpectProvisionedAndWaitForPromises(ctx, env.Client, cluster, cloudProvider, coreProvisioner, azureEnv, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+
+			Expect(azureEnv.PublicIPAddressesAPI.PublicIPAddressesCreateOrUpdateBehavior.CalledWithInput.Len()).To(Equal(1))
+			input := azureEnv.PublicIPAddressesAPI.PublicIPAddressesCreateOrUpdateBehavior.CalledWithInput.Pop()
+			publicIP := input.PublicIPAddress
+			Expect(lo.FromPtr(publicIP.SKU.Name)).To(Equal(armnetwork.PublicIPAddressSKUNameStandard))
+			Expect(lo.FromPtr(publicIP.Properties.PublicIPAllocationMethod)).To(Equal(armnetwork.IPAllocationMethodStatic))
+			Expect(lo.FromPtr(publicIP.Properties.PublicIPPrefix.ID)).To(Equal(prefixID))
+			Expect(publicIP.Properties.IPTags).To(ConsistOf(&armnetwork.IPTag{IPTagType: lo.ToPtr("RoutingPreference"), Tag: lo.ToPtr("Internet")}))
+			Expect(lo.FromPtr(publicIP.Tags["game"])).To(Equal("servers"))
+			Expect(lo.FromPtr(publicIP.Tags[launchtemplate.NodePoolTagKey])).To(Equal(nodePool.Name))
+
+			nic := azureEnv.NetworkInterfacesAPI.NetworkInterfacesCreateOrUpdateBehavior.CalledWithInput.Pop().Interface
+			Expect(input.PublicIPAddressName).To(Equal(lo.FromPtr(nic.Properties.IPConfigurations[0].Name)))
+			Expect(lo.FromPtr(nic.Properties.IPConfigurations[0].Properties.PublicIPAddress.ID)).To(Equal(fake.MakePublicIPAddressID(azureEnv.AzureResourceGraphAPI.ResourceGroup, input.PublicIPAddressName)))
+		})
+
+		It("should not create a public IP by default", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod(coretest.PodOptions{})
+			ExpectProvisionedAndWaitForPromises(ctx, env.Client, cluster, cloudProvider, coreProvisioner, azureEnv, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+
+			Expect(azureEnv.PublicIPAddressesAPI.PublicIPAddressesCreateOrUpdateBehavior.Calls()).To(Equal(0))
+			nic := azureEnv.NetworkInterfacesAPI.NetworkInterfacesCreateOrUpdateBehavior.CalledWithInput.Pop().Interface
+			Expect(nic.Properties.IPConfigurations[0].Properties.PublicIPAddress).To(BeNil())
+		})
+
+		It("should delete the public IP with the instance", func() {
+			nodeClass.Spec.PublicIP = &v1beta1.PublicIPConfiguration{}
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+
+			promise, err := azureEnv.VMInstanceProvider.BeginCreate(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(promise.Wait()).To(Succeed())
+			publicIPID := fake.MakePublicIPAddressID(azureEnv.AzureResourceGraphAPI.ResourceGroup, promise.GetInstanceName())
+			_, ok := azureEnv.PublicIPAddressesAPI.PublicIPAddresses.Load(publicIPID)
+			Expect(ok).To(BeTrue())
+
+			Expect(azureEnv.VMInstanceProvider.Delete(ctx, promise.GetInstanceName())).To(Succeed())
+			Expect(azureEnv.PublicIPAddressesAPI.PublicIPAddressesDeleteBehavior.Calls()).To(Equal(1))
+			_, ok = azureEnv.PublicIPAddressesAPI.PublicIPAddresses.Load(publicIPID)
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Context("SubnetSelectorTerms", func() {
+		const vnetID = "/subscriptions/12345678-1234-1234-1234-123456789012/resourceGroups/test-resourceGroup/providers/Microsoft.Network/virtualNetworks/aks-vnet-12345678"
+
+		BeforeEach(func() {
+			nodeClass.Spec.SubnetSelectorTerms = []v1beta1.SubnetSelectorTerm{{VNETID: lo.ToPtr(vnetID), Tags: map[string]string{"karpenter": "*"}}}
+		})
+
+		It("should launch the instance into the subnet with the most free IPs", func() {
+			nodeClass.Status.Subnets = []v1beta1.Subnet{
+				{ID: vnetID + "/subnets/most-free-small", AvailableIPAddressCount: lo.ToPtr[int64](100)},
+				{ID: vnetID + "/subnets/most-free-large", AvailableIPAddressCount: lo.ToPtr[int64](1000)},
+			}
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+
+			promise, err := azureEnv.VMInstanceProvider.BeginCreate(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(promise.Wait()).To(Succeed())
+
+			Expect(azureEnv.NetworkInterfacesAPI.NetworkInterfacesCreateOrUpdateBehavior.CalledWithInput.Len()).To(Equal(1))
+			nic := azureEnv.NetworkInterfacesAPI.NetworkInterfacesCreateOrUpdateBehavior.CalledWithInput.Pop().Interface
+			Expect(lo.FromPtr(nic.Properties.IPConfigurations[0].Properties.Subnet.ID)).To(Equal(vnetID + "/subnets/most-free-large"))
+		})
+
+		It("should fall back to the next subnet when the subnet is full", func() {
+			nodeClass.Status.Subnets = []v1beta1.Subnet{
+				{ID: vnetID + "/subnets/fallback-full", AvailableIPAddressCount: lo.ToPtr[int64](1000)},
+				{ID: vnetID + "/subnets/fallback-next", AvailableIPAddressCount: lo.ToPtr[int64](100)},
+			}
+			azureEnv.NetworkInterfacesAPI.NetworkInterfacesCreateOrUpdateBehavior.BeginError.Set(&azcore.ResponseError{ErrorCode: "SubnetIsFull", StatusCode: http.StatusBadRequest})
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+
+			promise, err := azureEnv.VMInstanceProvider.BeginCreate(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(promise.Wait()).To(Succeed())
+
+			Expect(azureEnv.NetworkInterfacesAPI.NetworkInterfacesCreateOrUpdateBehavior.CalledWithInput.Len()).To(Equal(2))
+			full := azureEnv.NetworkInterfacesAPI.NetworkInterfacesCreateOrUpdateBehavior.CalledWithInput.Get(0).Interface
+			Expect(lo.FromPtr(full.Properties.IPConfigurations[0].Properties.Subnet.ID)).To(Equal(vnetID + "/subnets/fallback-full"))
+			next := azureEnv.NetworkInterfacesAPI.NetworkInterfacesCreateOrUpdateBehavior.CalledWithInput.Get(1).Interface
+			Expect(lo.FromPtr(next.Properties.IPConfigurations[0].Properties.Subnet.ID)).To(Equal(vnetID + "/subnets/fallback-next"))
+			Expect(azureEnv.VirtualMachinesAPI.VirtualMachineCreateOrUpdateBehavior.Calls()).To(Equal(1))
+		})
+
+		It("should fail when all the subnets are full", func() {
+			nodeClass.Status.Subnets = []v1beta1.Subnet{
+				{ID: vnetID + "/subnets/all-full", AvailableIPAddressCount: lo.ToPtr[int64](1000)},
+			}
+			azureEnv.NetworkInterfacesAPI.NetworkInterfacesCreateOrUpdateBehavior.BeginError.Set(&azcore.ResponseError{ErrorCode: "SubnetIsFull", StatusCode: http.StatusBadRequest})
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = azureEnv.VMInstanceProvider.BeginCreate(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).To(HaveOccurred())
+			Expect(azureEnv.NetworkInterfacesAPI.NetworkInterfacesCreateOrUpdateBehavior.Calls()).To(Equal(1))
+			Expect(azureEnv.VirtualMachinesAPI.VirtualMachineCreateOrUpdateBehavior.Calls()).To(Equal(0))
+		})
+	})
+
+	Context("SubnetCapacity", func() {
+		var originalOptions *options.Options
+
+		BeforeEach(func() {
+			originalOptions = options.FromContext(ctx)
+			// Azure CNI without overlay, where a node needs MaxPods+1 free IPs
+			ctx = options.ToContext(
+				ctx,
+				test.Options(test.OptionsFields{
+					NetworkPlugin:     lo.ToPtr(consts.NetworkPluginAzure),
+					NetworkPluginMode: lo.ToPtr(consts.NetworkPluginModeNone),
+				}))
+			nodeClass.Spec.MaxPods = lo.ToPtr[int32](30)
+		})
+
+		AfterEach(func() {
+			ctx = options.ToContext(ctx, originalOptions)
+		})
+
+		refreshSubnet := func(addressPrefix string) {
+			azureEnv.SubnetsAPI.GetFunc = func(_ context.Context, _ string, _ string, _ string, _ *armnetwork.SubnetsClientGetOptions) (armnetwork.SubnetsClientGetResponse, error) {
+				return armnetwork.SubnetsClientGetResponse{
+					Subnet: armnetwork.Subnet{Properties: &armnetwork.SubnetPropertiesFormat{AddressPrefix: lo.ToPtr(addressPrefix)}},
+				}, nil
+			}
+			_, err := azureEnv.SubnetCapacityProvider.Refresh(ctx, options.FromContext(ctx).SubnetID)
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		It("should fail with an insufficient capacity error when the subnet can't fit a node", func() {
+			refreshSubnet("10.0.0.0/27") // 27 free IPs
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = azureEnv.VMInstanceProvider.BeginCreate(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).To(HaveOccurred())
+			Expect(corecloudprovider.IsInsufficientCapacityError(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("a node needs 31 free IP addresses"))
+			Expect(azureEnv.NetworkInterfacesAPI.NetworkInterfacesCreateOrUpdateBehavior.Calls()).To(Equal(0))
+		})
+
+		It("should reserve the IPs of the node in its subnet", func() {
+			refreshSubnet("10.0.0.0/26") // 59 free IPs
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+
+			promise, err := azureEnv.VMInstanceProvider.BeginCreate(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(promise.Wait()).To(Succeed())
+
+			available, ok := azureEnv.SubnetCapacityProvider.Available(options.FromContext(ctx).SubnetID)
+			Expect(ok).To(BeTrue())
+			Expect(available).To(Equal(int64(28)))
+			// the next node doesn't fit anymore
+			Expect(azureEnv.SubnetCapacityProvider.Fits(options.FromContext(ctx).SubnetID, 31)).To(BeFalse())
+		})
+	})
+
 	Context("Update", func() {
 		It("should update only VM when no tags are included", func() {
 			// Ensure that the VM already exists in the fake environment
@@ -871,6 +1142,341 @@ var _ = Describe("VMInstanceProvider", func() {
 		})
 	})
 
+	Context("CapacityReservationGroup", func() {
+		const capacityReservationGroupID = "/subscriptions/subscriptionID/resourceGroups/reservations/providers/Microsoft.Compute/capacityReservationGroups/crg"
+		var instanceTypes []*corecloudprovider.InstanceType
+
+		reserve := func(instanceType, zone string, capacity, allocated int64) {
+			azureEnv.CapacityReservationsAPI.CapacityReservations.Append(&armcompute.CapacityReservation{
+				Name:  lo.ToPtr(instanceType),
+				SKU:   &armcompute.SKU{Name: lo.ToPtr(instanceType), Capacity: lo.ToPtr(capacity)},
+				Zones: zones.MakeARMZonesFromAKSLabelZone(zone),
+				Properties: &armcompute.CapacityReservationProperties{
+					InstanceView: &armcompute.CapacityReservationInstanceView{
+						UtilizationInfo: &armcompute.CapacityReservationUtilization{
+							VirtualMachinesAllocated: lo.Times(int(allocated), func(_ int) *armcompute.SubResourceReadOnly {
+								return &armcompute.SubResourceReadOnly{}
+							}),
+						},
+					},
+				},
+			})
+			// refreshed by the AKSNodeClass status controller
+			azureEnv.CapacityReservationProvider.Reset()
+			Expect(azureEnv.CapacityReservationProvider.Refresh(ctx, capacityReservationGroupID)).To(Succeed())
+		}
+
+		BeforeEach(func() {
+			nodeClass.Spec.CapacityReservationGroupID = lo.ToPtr(capacityReservationGroupID)
+			nodeClaim.Spec.Requirements = []karpv1.NodeSelectorRequirementWithMinValues{
+				{Key: karpv1.CapacityTypeLabelKey, Operator: v1.NodeSelectorOpIn, Values: []string{karpv1.CapacityTypeOnDemand}},
+			}
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+
+			allInstanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			instanceTypes = lo.Filter(allInstanceTypes, func(i *corecloudprovider.InstanceType, _ int) bool {
+				return i.Name == "Standard_D2_v2" || i.Name == "Standard_F16s_v2"
+			})
+			Expect(instanceTypes).To(HaveLen(2))
+		})
+
+		It("should place the VM into the reservation group, preferring reserved offerings over cheaper ones", func() {
+			reserve("Standard_F16s_v2", fake.Region+"-2", 2, 1)
+
+			_, err := azureEnv.VMInstanceProvider.BeginCreate(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+
+			vm := azureEnv.VirtualMachinesAPI.VirtualMachineCreateOrUpdateBehavior.CalledWithInput.Pop().VM
+			Expect(string(lo.FromPtr(vm.Properties.HardwareProfile.VMSize))).To(Equal("Standard_F16s_v2"))
+			Expect(vm.Zones).To(ConsistOf(lo.ToPtr("2")))
+			Expect(vm.Properties.CapacityReservation).ToNot(BeNil())
+			Expect(lo.FromPtr(vm.Properties.CapacityReservation.CapacityReservationGroup.ID)).To(Equal(capacityReservationGroupID))
+		})
+
+		It("should place the VM into the reservation group in any zone when the reservation is regional", func() {
+			reserve("Standard_F16s_v2", zones.Regional, 2, 1)
+
+			_, err := azureEnv.VMInstanceProvider.BeginCreate(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+
+			vm := azureEnv.VirtualMachinesAPI.VirtualMachineCreateOrUpdateBehavior.CalledWithInput.Pop().VM
+			Expect(string(lo.FromPtr(vm.Properties.HardwareProfile.VMSize))).To(Equal("Standard_F16s_v2"))
+			Expect(vm.Properties.CapacityReservation).ToNot(BeNil())
+			Expect(lo.FromPtr(vm.Properties.CapacityReservation.CapacityReservationGroup.ID)).To(Equal(capacityReservationGroupID))
+		})
+
+		It("should create the VM without the reservation group when the reserved capacity is used", func() {
+			reserve("Standard_F16s_v2", fake.Region+"-2", 2, 2)
+
+			_, err := azureEnv.VMInstanceProvider.BeginCreate(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+
+			vm := azureEnv.VirtualMachinesAPI.VirtualMachineCreateOrUpdateBehavior.CalledWithInput.Pop().VM
+			Expect(string(lo.FromPtr(vm.Properties.HardwareProfile.VMSize))).To(Equal("Standard_D2_v2"))
+			Expect(vm.Properties.CapacityReservation).To(BeNil())
+		})
+
+		It("should create the VM without the reservation group when the reservations have not been refreshed", func() {
+			azureEnv.CapacityReservationsAPI.CapacityReservations.Append(&armcompute.CapacityReservation{
+				Name: lo.ToPtr("Standard_F16s_v2"),
+				SKU:  &armcompute.SKU{Name: lo.ToPtr("Standard_F16s_v2"), Capacity: lo.ToPtr[int64](2)},
+			})
+
+			_, err := azureEnv.VMInstanceProvider.BeginCreate(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+
+			vm := azureEnv.VirtualMachinesAPI.VirtualMachineCreateOrUpdateBehavior.CalledWithInput.Pop().VM
+			Expect(string(lo.FromPtr(vm.Properties.HardwareProfile.VMSize))).To(Equal("Standard_D2_v2"))
+			Expect(vm.Properties.CapacityReservation).To(BeNil())
+		})
+
+		It("should create the VM without the reservation group when the group can't be read", func() {
+			reserve("Standard_F16s_v2", fake.Region+"-2", 2, 0)
+			azureEnv.CapacityReservationProvider.Reset()
+			azureEnv.CapacityReservationsAPI.Error = &azcore.ResponseError{ErrorCode: "AuthorizationFailed", StatusCode: http.StatusForbidden}
+			Expect(azureEnv.CapacityReservationProvider.Refresh(ctx, capacityReservationGroupID)).ToNot(Succeed())
+
+			_, err := azureEnv.VMInstanceProvider.BeginCreate(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+
+			vm := azureEnv.VirtualMachinesAPI.VirtualMachineCreateOrUpdateBehavior.CalledWithInput.Pop().VM
+			Expect(string(lo.FromPtr(vm.Properties.HardwareProfile.VMSize))).To(Equal("Standard_D2_v2"))
+			Expect(vm.Properties.CapacityReservation).To(BeNil())
+		})
+
+		It("should fall back to on-demand without the reservation group when the reservation is exhausted", func() {
+			reserve("Standard_F16s_v2", fake.Region+"-2", 2, 0)
+			azureEnv.VirtualMachinesAPI.VirtualMachineCreateOrUpdateBehavior.BeginError.Set(
+				&azcore.ResponseError{ErrorCode: "CapacityReservationExhausted"}, fake.MaxCalls(1))
+
+			_, err := azureEnv.VMInstanceProvider.BeginCreate(ctx, nodeClass, nodeClaim, instanceTypes)
+			var createErr *corecloudprovider.CreateError
+			Expect(errors.As(err, &createErr)).To(BeTrue())
+			Expect(createErr.ConditionReason).To(Equal(offerings.CapacityReservationExhaustedReason))
+			// the offering itself remains available
+			Expect(azureEnv.UnavailableOfferingsCache.IsUnavailable(fake.MakeSKU("Standard_F16s_v2"), fake.Region+"-2", karpv1.CapacityTypeOnDemand)).To(BeFalse())
+			azureEnv.VirtualMachinesAPI.VirtualMachineCreateOrUpdateBehavior.CalledWithInput.Reset()
+
+			_, err = azureEnv.VMInstanceProvider.BeginCreate(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+
+			vm := azureEnv.VirtualMachinesAPI.VirtualMachineCreateOrUpdateBehavior.CalledWithInput.Pop().VM
+			Expect(string(lo.FromPtr(vm.Properties.HardwareProfile.VMSize))).To(Equal("Standard_D2_v2"))
+			Expect(vm.Properties.CapacityReservation).To(BeNil())
+		})
+
+		It("should stop using the reservation group for every VM size when the group is not found", func() {
+			reserve("Standard_F16s_v2", fake.Region+"-2", 2, 0)
+			azureEnv.VirtualMachinesAPI.VirtualMachineCreateOrUpdateBehavior.BeginError.Set(
+				&azcore.ResponseError{ErrorCode: "CapacityReservationGroupNotFound"}, fake.MaxCalls(1))
+
+			_, err := azureEnv.VMInstanceProvider.BeginCreate(ctx, nodeClass, nodeClaim, instanceTypes)
+			var createErr *corecloudprovider.CreateError
+			Expect(errors.As(err, &createErr)).To(BeTrue())
+			Expect(createErr.ConditionReason).To(Equal(offerings.CapacityReservationGroupUnavailableReason))
+			Expect(azureEnv.CapacityReservationProvider.Get(capacityReservationGroupID)).To(BeNil())
+		})
+	})
+
+	Context("Placement", func() {
+		const (
+			proximityPlacementGroupID = "/subscriptions/subscriptionID/resourceGroups/placement/providers/Microsoft.Compute/proximityPlacementGroups/ppg"
+			hostGroupID               = "/subscriptions/subscriptionID/resourceGroups/placement/providers/Microsoft.Compute/hostGroups/hg"
+		)
+
+		It("should place the VM into the proximity placement group and host group", func() {
+			nodeClass.Spec.Placement = &v1beta1.Placement{
+				ProximityPlacementGroupID: lo.ToPtr(proximityPlacementGroupID),
+				HostGroupID:               lo.ToPtr(hostGroupID),
+			}
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = azureEnv.VMInstanceProvider.BeginCreate(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+
+			vm := azureEnv.VirtualMachinesAPI.VirtualMachineCreateOrUpdateBehavior.CalledWithInput.Pop().VM
+			Expect(lo.FromPtr(vm.Properties.ProximityPlacementGroup.ID)).To(Equal(proximityPlacementGroupID))
+			Expect(lo.FromPtr(vm.Properties.HostGroup.ID)).To(Equal(hostGroupID))
+			Expect(vm.Properties.Host).To(BeNil())
+			// spot VMs can't be placed onto dedicated hosts
+			Expect(lo.FromPtr(vm.Properties.Priority)).To(Equal(armcompute.VirtualMachinePriorityTypesRegular))
+		})
+
+		It("should only exclude the offering within the proximity placement group on an overconstrained allocation", func() {
+			nodeClass.Spec.Placement = &v1beta1.Placement{ProximityPlacementGroupID: lo.ToPtr(proximityPlacementGroupID)}
+			nodeClaim.Spec.Requirements = []karpv1.NodeSelectorRequirementWithMinValues{
+				{Key: karpv1.CapacityTypeLabelKey, Operator: v1.NodeSelectorOpIn, Values: []string{karpv1.CapacityTypeOnDemand}},
+			}
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			azureEnv.VirtualMachinesAPI.VirtualMachineCreateOrUpdateBehavior.BeginError.Set(
+				&azcore.ResponseError{ErrorCode: "OverconstrainedAllocationRequest"}, fake.MaxCalls(1))
+
+			_, err = azureEnv.VMInstanceProvider.BeginCreate(ctx, nodeClass, nodeClaim, instanceTypes)
+			var createErr *corecloudprovider.CreateError
+			Expect(errors.As(err, &createErr)).To(BeTrue())
+			Expect(createErr.ConditionReason).To(Equal(offerings.PlacementAllocationFailureReason))
+
+			vm := azureEnv.VirtualMachinesAPI.VirtualMachineCreateOrUpdateBehavior.CalledWithInput.Pop().VM
+			failedSize := string(lo.FromPtr(vm.Properties.HardwareProfile.VMSize))
+			failedZone, err := zones.MakeAKSLabelZoneFromARMZones(fake.Region, vm.Zones)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(azureEnv.UnavailableOfferingsCache.IsUnavailableForPlacement(nodeClass.PlacementKey(), failedSize, failedZone, karpv1.CapacityTypeOnDemand)).To(BeTrue())
+			// the offering itself remains available outside of the proximity placement group
+			Expect(azureEnv.UnavailableOfferingsCache.IsUnavailable(fake.MakeSKU(failedSize), failedZone, karpv1.CapacityTypeOnDemand)).To(BeFalse())
+		})
+	})
+
+	Context("Spot", func() {
+		BeforeEach(func() {
+			nodeClaim.Spec.Requirements = []karpv1.NodeSelectorRequirementWithMinValues{
+				{Key: karpv1.CapacityTypeLabelKey, Operator: v1.NodeSelectorOpIn, Values: []string{karpv1.CapacityTypeSpot}},
+			}
+		})
+
+		It("should pay up to the on-demand price and delete evicted spot VMs by default", func() {
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = azureEnv.VMInstanceProvider.BeginCreate(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+
+			vm := azureEnv.VirtualMachinesAPI.VirtualMachineCreateOrUpdateBehavior.CalledWithInput.Pop().VM
+			Expect(lo.FromPtr(vm.Properties.Priority)).To(Equal(armcompute.VirtualMachinePriorityTypesSpot))
+			Expect(lo.FromPtr(vm.Properties.BillingProfile.MaxPrice)).To(Equal(float64(-1)))
+			Expect(lo.FromPtr(vm.Properties.EvictionPolicy)).To(Equal(armcompute.VirtualMachineEvictionPolicyTypesDelete))
+		})
+
+		It("should set the max price and eviction policy of the AKSNodeClass", func() {
+			nodeClass.Spec.Spot = &v1beta1.Spot{
+				MaxPrice:       lo.ToPtr("5.25"),
+				EvictionPolicy: lo.ToPtr(v1beta1.SpotEvictionPolicyDeallocate),
+			}
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = azureEnv.VMInstanceProvider.BeginCreate(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+
+			vm := azureEnv.VirtualMachinesAPI.VirtualMachineCreateOrUpdateBehavior.CalledWithInput.Pop().VM
+			Expect(lo.FromPtr(vm.Properties.BillingProfile.MaxPrice)).To(Equal(5.25))
+			Expect(lo.FromPtr(vm.Properties.EvictionPolicy)).To(Equal(armcompute.VirtualMachineEvictionPolicyTypesDeallocate))
+		})
+
+		It("should cap the max price at a percentage of the on-demand price of the VM size", func() {
+			nodeClass.Spec.Spot = &v1beta1.Spot{MaxPricePercentOfOnDemand: lo.ToPtr(int32(80))}
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = azureEnv.VMInstanceProvider.BeginCreate(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+
+			vm := azureEnv.VirtualMachinesAPI.VirtualMachineCreateOrUpdateBehavior.CalledWithInput.Pop().VM
+			onDemandPrice, ok := azureEnv.PricingProvider.OnDemandPrice(string(lo.FromPtr(vm.Properties.HardwareProfile.VMSize)))
+			Expect(ok).To(BeTrue())
+			Expect(lo.FromPtr(vm.Properties.BillingProfile.MaxPrice)).To(BeNumerically("~", onDemandPrice*0.8, 0.00001))
+			Expect(lo.FromPtr(vm.Properties.BillingProfile.MaxPrice)).To(BeNumerically("<=", onDemandPrice*0.8))
+		})
+	})
+
+	Context("DataDisks", func() {
+		It("should attach the data disks as empty managed disks deleted with the VM", func() {
+			nodeClass.Spec.DataDisks = []v1beta1.DataDisk{
+				{SizeGB: 128, MountPath: "/data"},
+				{SizeGB: 256, SKU: lo.ToPtr(v1beta1.DataDiskSKUStandardSSDLRS), Caching: lo.ToPtr(v1beta1.DataDiskCachingReadOnly), MountPath: "/mnt/cache"},
+			}
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = azureEnv.VMInstanceProvider.BeginCreate(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+
+			vm := azureEnv.VirtualMachinesAPI.VirtualMachineCreateOrUpdateBehavior.CalledWithInput.Pop().VM
+			dataDisks := vm.Properties.StorageProfile.DataDisks
+			Expect(dataDisks).To(HaveLen(2))
+			for i, dataDisk := range dataDisks {
+				Expect(lo.FromPtr(dataDisk.Lun)).To(BeEquivalentTo(i))
+				Expect(lo.FromPtr(dataDisk.Name)).To(Equal(fmt.Sprintf("%s-data-%d", lo.FromPtr(vm.Name), i)))
+				Expect(lo.FromPtr(dataDisk.CreateOption)).To(Equal(armcompute.DiskCreateOptionTypesEmpty))
+				Expect(lo.FromPtr(dataDisk.DeleteOption)).To(Equal(armcompute.DiskDeleteOptionTypesDelete))
+			}
+			Expect(lo.FromPtr(dataDisks[0].DiskSizeGB)).To(BeEquivalentTo(128))
+			Expect(lo.FromPtr(dataDisks[0].ManagedDisk.StorageAccountType)).To(Equal(armcompute.StorageAccountTypesPremiumLRS))
+			Expect(lo.FromPtr(dataDisks[0].Caching)).To(Equal(armcompute.CachingTypesNone))
+			Expect(lo.FromPtr(dataDisks[1].DiskSizeGB)).To(BeEquivalentTo(256))
+			Expect(lo.FromPtr(dataDisks[1].ManagedDisk.StorageAccountType)).To(Equal(armcompute.StorageAccountTypesStandardSSDLRS))
+			Expect(lo.FromPtr(dataDisks[1].Caching)).To(Equal(armcompute.CachingTypesReadOnly))
+			Expect(vm.Properties.AdditionalCapabilities).To(BeNil())
+		})
+
+		It("should create a zonal VM for Premium SSD v2 disks", func() {
+			nodeClass.Spec.DataDisks = []v1beta1.DataDisk{
+				{SizeGB: 128, SKU: lo.ToPtr(v1beta1.DataDiskSKUPremiumV2LRS), DiskIOPSReadWrite: lo.ToPtr(int64(5000)), MountPath: "/data"},
+			}
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = azureEnv.VMInstanceProvider.BeginCreate(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+
+			vm := azureEnv.VirtualMachinesAPI.VirtualMachineCreateOrUpdateBehavior.CalledWithInput.Pop().VM
+			Expect(vm.Zones).To(HaveLen(1))
+			Expect(lo.FromPtr(vm.Properties.StorageProfile.DataDisks[0].ManagedDisk.StorageAccountType)).To(Equal(armcompute.StorageAccountTypesPremiumV2LRS))
+			Expect(lo.FromPtr(vm.Properties.StorageProfile.DataDisks[0].DiskIOPSReadWrite)).To(BeEquivalentTo(5000))
+		})
+	})
+
+	Context("UserData", func() {
+		It("should run the scripts around the bootstrap and merge the cloud-config into the custom data", func() {
+			nodeClass.Spec.UserData = &v1beta1.UserData{
+				CloudConfig:         lo.ToPtr("#cloud-config\npackages:\n- jq\n"),
+				PreBootstrapScript:  lo.ToPtr("echo pre"),
+				PostBootstrapScript: lo.ToPtr("echo post"),
+			}
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = azureEnv.VMInstanceProvider.BeginCreate(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+
+			customData := ExpectDecodedCustomData(azureEnv)
+			Expect(customData).To(HavePrefix("Content-Type: multipart/mixed"))
+			Expect(customData).To(ContainSubstring("Content-Type: text/cloud-config"))
+			Expect(customData).To(ContainSubstring("- jq"))
+			preBootstrap := strings.Index(customData, bootstrap.HookCommand(bootstrap.HookPreBootstrap, "echo pre"))
+			provisionStart := strings.Index(customData, "/opt/azure/containers/provision_start.sh")
+			postBootstrap := strings.Index(customData, bootstrap.HookCommand(bootstrap.HookPostBootstrap, "echo post"))
+			Expect(preBootstrap).To(BeNumerically(">", 0))
+			Expect(provisionStart).To(BeNumerically(">", preBootstrap))
+			Expect(postBootstrap).To(BeNumerically(">", provisionStart))
+		})
+
+		It("should fail when the custom data is too large for a VM", func() {
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			// larger than the CRD allows, so that the generated custom data doesn't matter
+			nodeClass.Spec.UserData = &v1beta1.UserData{
+				CloudConfig: lo.ToPtr("#cloud-config\n# " + strings.Repeat("a", bootstrap.MaxCustomDataSize)),
+			}
+
+			_, err = azureEnv.VMInstanceProvider.BeginCreate(ctx, nodeClass, nodeClaim, instanceTypes)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("consider reducing the size of userData"))
+			Expect(azureEnv.VirtualMachinesAPI.VirtualMachineCreateOrUpdateBehavior.Calls()).To(Equal(0))
+		})
+	})
+
 	Context("large-scale provisioning with quota exhaustion", func() {
 		It("should fall back to a different SKU family when quota is exhausted mid-provisioning", func() {
 			// Restrict NodePool to the Ds_v3 and D_v5 SKU series (standardDSv3Family and standardDv5Family).
@@ -18,9 +18,12 @@ package instance
 
 import (
 	"context"
+	"crypto/rand"
 	"errors"
 	"fmt"
+	"math/big"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
@@ -28,6 +31,7 @@ import (
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v7"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork"
+	gocache "github.com/patrickmn/go-cache"
 	"github.com/samber/lo"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -46,12 +50,14 @@ import (
 	"github.com/Azure/karpenter-provider-azure/pkg/operator/options"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/allocationstrategy"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/azclient"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/capacityreservation"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/instance/offerings"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/instancetype"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/labels"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/launchtemplate"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/loadbalancer"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/networksecuritygroup"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/subnetcapacity"
 	"github.com/Azure/karpenter-provider-azure/pkg/utils"
 	"github.com/Azure/karpenter-provider-azure/pkg/utils/zones"
 )
@@ -87,10 +93,18 @@ var (
 )
 
 const (
-	aksIdentifyingExtensionName = "computeAksLinuxBilling"
+	aksIdentifyingExtensionNameLinux   = "computeAksLinuxBilling"
+	aksIdentifyingExtensionNameWindows = "computeAksWindowsBilling"
 	// TODO: Why bother with a different CSE name for Windows?
 	cseNameWindows = "windows-cse-agent-karpenter"
 	cseNameLinux   = "cse-agent-karpenter"
+
+	// Windows requires an admin password, which is randomly generated per VM and never surfaced;
+	// nodes are accessed the same way as Linux ones, through the node debugging tools.
+	windowsAdminUsername       = "azureuser"
+	windowsAdminPasswordLength = 32
+	// Windows computer names are limited to 15 characters
+	windowsComputerNameMaxLength = 15
 )
 
 // ErrorCodeForMetrics extracts a stable Azure error code for metric labeling when possible.
@@ -108,19 +122,26 @@ func ErrorCodeForMetrics(err error) string {
 }
 
 // GetManagedExtensionNames gets the names of the VM extensions managed by Karpenter.
-// This is a set of 1 or 2 extensions (depending on provisionMode): aksIdentifyingExtension and (sometimes) cse.
-func GetManagedExtensionNames(provisionMode string, env *auth.Environment) []string {
+// This is a set of 1 or 2 extensions (depending on provisionMode and OS): aksIdentifyingExtension and (sometimes) cse.
+// Windows VMs always have a cse, as Windows doesn't run custom data by itself.
+func GetManagedExtensionNames(provisionMode string, env *auth.Environment, isWindows bool) []string {
 	var result []string
 	// Only including AKS identifying extension in the clouds it is supported in
 	if isAKSIdentifyingExtensionEnabled(env) {
-		result = append(result, aksIdentifyingExtensionName)
+		result = append(result, aksIdentifyingExtensionName(isWindows))
 	}
-	if provisionMode == consts.ProvisionModeBootstrappingClient {
-		result = append(result, cseNameLinux) // TODO: Windows
+	if isWindows {
+		result = append(result, cseNameWindows)
+	} else if provisionMode == consts.ProvisionModeBootstrappingClient {
+		result = append(result, cseNameLinux)
 	}
 	return result
 }
 
+func aksIdentifyingExtensionName(isWindows bool) string {
+	return lo.Ternary(isWindows, aksIdentifyingExtensionNameWindows, aksIdentifyingExtensionNameLinux)
+}
+
 func isAKSIdentifyingExtensionEnabled(env *auth.Environment) bool {
 	return aksIdentifyingExtensionEnvs.Has(env.Environment.Name)
 }
@@ -155,8 +176,11 @@ type VMProvider interface {
 	Delete(context.Context, string) error
 	Update(context.Context, string, armcompute.VirtualMachineUpdate) error
 	GetNic(context.Context, string, string) (*armnetwork.Interface, error)
+	UpdateNic(context.Context, string, armnetwork.Interface) error
 	DeleteNic(context.Context, string) error
 	ListNics(context.Context) ([]*armnetwork.Interface, error)
+	DeletePublicIP(context.Context, string) error
+	ListPublicIPs(context.Context) ([]*armnetwork.PublicIPAddress, error)
 }
 
 // assert that DefaultProvider implements Provider interface
@@ -175,11 +199,17 @@ type DefaultVMProvider struct {
 	provisionMode                string
 	diskEncryptionSetID          string
 	errorHandling                *offerings.ResponseErrorHandler
+	capacityReservationProvider  *capacityreservation.Provider
+	capacityReservationErrors    *offerings.CapacityReservationErrorHandler
+	subnetCapacityProvider       *subnetcapacity.Provider
+	placementErrors              *offerings.PlacementErrorHandler
 	env                          *auth.Environment
+	// fullSubnets are the subnets that recently ran out of IPs, keyed by lowercase ID
+	fullSubnets *gocache.Cache
 
-	vmListQuery, nicListQuery string
-	deletingVMs               sets.Set[string] // tracks in-flight delete operations by VM name
-	deletingVMsMu             sync.RWMutex
+	vmListQuery, nicListQuery, publicIPListQuery string
+	deletingVMs                                  sets.Set[string] // tracks in-flight delete operations by VM name
+	deletingVMsMu                                sync.RWMutex
 }
 
 func NewDefaultVMProvider(
@@ -190,6 +220,8 @@ func NewDefaultVMProvider(
 	loadBalancerProvider *loadbalancer.Provider,
 	networkSecurityGroupProvider *networksecuritygroup.Provider,
 	offeringsCache *cache.UnavailableOfferings,
+	capacityReservationProvider *capacityreservation.Provider,
+	subnetCapacityProvider *subnetcapacity.Provider,
 	location string,
 	resourceGroup string,
 	subscriptionID string,
@@ -211,11 +243,17 @@ func NewDefaultVMProvider(
 		diskEncryptionSetID:          diskEncryptionSetID,
 		env:                          env,
 
-		vmListQuery:  GetVMListQueryBuilder(resourceGroup).String(),
-		nicListQuery: GetNICListQueryBuilder(resourceGroup).String(),
+		vmListQuery:       GetVMListQueryBuilder(resourceGroup).String(),
+		nicListQuery:      GetNICListQueryBuilder(resourceGroup).String(),
+		publicIPListQuery: GetPublicIPListQueryBuilder(resourceGroup).String(),
 
-		errorHandling: offerings.NewResponseErrorHandler(offeringsCache),
-		deletingVMs:   sets.New[string](),
+		errorHandling:               offerings.NewResponseErrorHandler(offeringsCache),
+		capacityReservationProvider: capacityReservationProvider,
+		capacityReservationErrors:   offerings.NewCapacityReservationErrorHandler(capacityReservationProvider),
+		placementErrors:             offerings.NewPlacementErrorHandler(offeringsCache),
+		subnetCapacityProvider:      subnetCapacityProvider,
+		fullSubnets:                 gocache.New(cache.FullSubnetsTTL, cache.DefaultCleanupInterval),
+		deletingVMs:                 sets.New[string](),
 	}
 }
 
@@ -277,7 +315,9 @@ func (p *DefaultVMProvider) Update(ctx context.Context, vmName string, update ar
 			return fmt.Errorf("updating NIC tags for %q: %w", vmName, err)
 		}
 
-		extensionNames := GetManagedExtensionNames(p.provisionMode, p.env)
+		// The billing tag is always part of the tags being updated, see launchtemplate.Tags
+		isWindows := lo.FromPtr(update.Tags[launchtemplate.BillingTagKey]) == launchtemplate.BillingTagValueWindows
+		extensionNames := GetManagedExtensionNames(p.provisionMode, p.env, isWindows)
 		pollers := make(map[string]*runtime.Poller[armcompute.VirtualMachineExtensionsClientUpdateResponse], len(extensionNames))
 		// Update tags on VM extensions
 		for _, extName := range extensionNames {
@@ -297,7 +337,7 @@ func (p *DefaultVMProvider) Update(ctx context.Context, vmName string, update ar
 				// The aksIdentifyingExtensionName is not currently guaranteed to be on the VM though, as Karpenter could have failed over during the initial VM create
 				// after CSE but before aksIdentifyingExtensionName. So, for now, we just ignore NotFound errors for the aksIdentifyingExtensionName.
 				azErr := sdkerrors.IsResponseError(err)
-				if extName == aksIdentifyingExtensionName && azErr != nil && azErr.StatusCode == http.StatusNotFound {
+				if extName == aksIdentifyingExtensionName(isWindows) && azErr != nil && azErr.StatusCode == http.StatusNotFound {
 					log.FromContext(ctx).V(0).Info("extension not found when updating tags", "extensionName", extName, "vmName", vmName)
 					continue
 				}
@@ -391,6 +431,15 @@ func (p *DefaultVMProvider) GetNic(ctx context.Context, rg, nicName string) (*ar
 	return &nicResponse.Interface, nil
 }
 
+// UpdateNic replaces the network interface with the given one, which is expected to be a modified copy of the current network interface,
+// as network interfaces don't support PATCH for anything but tags
+func (p *DefaultVMProvider) UpdateNic(ctx context.Context, nicName string, nic armnetwork.Interface) error {
+	if _, err := createNic(ctx, p.azClient.NetworkInterfacesClient(), p.resourceGroup, nicName, nic); err != nil {
+		return fmt.Errorf("updating NIC %q: %w", nicName, err)
+	}
+	return nil
+}
+
 // ListNics returns all network interfaces in the resource group that have the nodepool tag
 func (p *DefaultVMProvider) ListNics(ctx context.Context) ([]*armnetwork.Interface, error) {
 	req := NewQueryRequest(&(p.subscriptionID), p.nicListQuery)
@@ -414,9 +463,32 @@ func (p *DefaultVMProvider) DeleteNic(ctx context.Context, nicName string) error
 	return deleteNicIfExists(ctx, p.azClient.NetworkInterfacesClient(), p.resourceGroup, nicName)
 }
 
+// ListPublicIPs returns all public IP addresses in the resource group that have the nodepool tag
+func (p *DefaultVMProvider) ListPublicIPs(ctx context.Context) ([]*armnetwork.PublicIPAddress, error) {
+	req := NewQueryRequest(&(p.subscriptionID), p.publicIPListQuery)
+	client := p.azClient.AzureResourceGraphClient()
+	data, err := GetResourceData(ctx, client, *req)
+	if err != nil {
+		return nil, fmt.Errorf("querying azure resource graph, %w", err)
+	}
+	var publicIPList []*armnetwork.PublicIPAddress
+	for i := range data {
+		publicIP, err := createPublicIPFromQueryResponseData(data[i])
+		if err != nil {
+			return nil, fmt.Errorf("creating public IP address object from query response data, %w", err)
+		}
+		publicIPList = append(publicIPList, publicIP)
+	}
+	return publicIPList, nil
+}
+
+func (p *DefaultVMProvider) DeletePublicIP(ctx context.Context, publicIPName string) error {
+	return deletePublicIPIfExists(ctx, p.azClient.PublicIPAddressesClient(), p.resourceGroup, publicIPName)
+}
+
 // createAKSIdentifyingExtension attaches a VM extension to identify that this VM participates in an AKS cluster
-func (p *DefaultVMProvider) createAKSIdentifyingExtension(ctx context.Context, vmName string, tags map[string]*string) (err error) {
-	vmExt := p.getAKSIdentifyingExtension(tags)
+func (p *DefaultVMProvider) createAKSIdentifyingExtension(ctx context.Context, vmName string, isWindows bool, tags map[string]*string) (err error) {
+	vmExt := p.getAKSIdentifyingExtension(isWindows, tags)
 	vmExtName := *vmExt.Name
 	log.FromContext(ctx).V(1).Info("creating virtual machine AKS identifying extension", "vmName", vmName)
 	v, err := createVirtualMachineExtension(ctx, p.azClient.VirtualMachineExtensionsClient(), p.resourceGroup, vmName, vmExtName, *vmExt)
@@ -468,6 +540,13 @@ func (p *DefaultVMProvider) newNetworkInterfaceForVM(opts *createNICOptions) arm
 		}
 	}
 
+	var publicIPRef *armnetwork.PublicIPAddress
+	if opts.PublicIPAddressID != "" {
+		publicIPRef = &armnetwork.PublicIPAddress{
+			ID: &opts.PublicIPAddressID,
+		}
+	}
+
 	nic := armnetwork.Interface{
 		Location: lo.ToPtr(p.location),
 		Properties: &armnetwork.InterfacePropertiesFormat{
@@ -477,6 +556,7 @@ func (p *DefaultVMProvider) newNetworkInterfaceForVM(opts *createNICOptions) arm
 					Properties: &armnetwork.InterfaceIPConfigurationPropertiesFormat{
 						Primary:                   lo.ToPtr(true),
 						PrivateIPAllocationMethod: lo.ToPtr(armnetwork.IPAllocationMethodDynamic),
+						PublicIPAddress:           publicIPRef,
 
 						LoadBalancerBackendAddressPools: ipv4BackendPools,
 					},
@@ -487,8 +567,30 @@ func (p *DefaultVMProvider) newNetworkInterfaceForVM(opts *createNICOptions) arm
 			EnableIPForwarding:          lo.ToPtr(false),
 		},
 	}
-	if opts.NetworkPlugin == consts.NetworkPluginAzure && opts.NetworkPluginMode != consts.NetworkPluginModeOverlay {
-		// AzureCNI without overlay requires secondary IPs, for pods. (These IPs are not included in backend address pools.)
+	if opts.IPv6DualStackEnabled {
+		// IPv6 addresses, and the IPv6 backend pools, can't be on the primary ipconfig, which must be IPv4
+		var ipv6BackendPools []*armnetwork.BackendAddressPool
+		for _, poolID := range opts.BackendPools.IPv6PoolIDs {
+			ipv6BackendPools = append(ipv6BackendPools, &armnetwork.BackendAddressPool{
+				ID: &poolID,
+			})
+		}
+		nic.Properties.IPConfigurations = append(
+			nic.Properties.IPConfigurations,
+			&armnetwork.InterfaceIPConfiguration{
+				Name: lo.ToPtr(IPv6IPConfigName),
+				Properties: &armnetwork.InterfaceIPConfigurationPropertiesFormat{
+					Primary:                   lo.ToPtr(false),
+					PrivateIPAllocationMethod: lo.ToPtr(armnetwork.IPAllocationMethodDynamic),
+					PrivateIPAddressVersion:   lo.ToPtr(armnetwork.IPVersionIPv6),
+
+					LoadBalancerBackendAddressPools: ipv6BackendPools,
+				},
+			},
+		)
+	}
+	if opts.NetworkPlugin == consts.NetworkPluginAzure && opts.NetworkPluginMode != consts.NetworkPluginModeOverlay && !opts.PodSubnetEnabled {
+		// AzureCNI without overlay requires secondary IPs, for pods, unless they are allocated dynamically from a pod subnet. (These IPs are not included in backend address pools.)
 		// NOTE: Unlike AKS RP, this logic does not reduce secondary IP count by the number of expected hostNetwork pods, favoring simplicity instead
 		for i := 1; i < int(opts.MaxPods); i++ {
 			nic.Properties.IPConfigurations = append(
@@ -503,23 +605,48 @@ func (p *DefaultVMProvider) newNetworkInterfaceForVM(opts *createNICOptions) arm
 			)
 		}
 	}
+	// Application security group membership is per ipconfig. All of them join the groups, so that the rules also apply to pod IPs.
+	applicationSecurityGroups := ApplicationSecurityGroupReferences(opts.ApplicationSecurityGroupIDs)
+	for _, ipConfig := range nic.Properties.IPConfigurations {
+		ipConfig.Properties.ApplicationSecurityGroups = applicationSecurityGroups
+	}
 	return nic
 }
 
+// ApplicationSecurityGroupReferences returns the references to the application security groups with the given IDs, for a NIC ipconfig
+func ApplicationSecurityGroupReferences(ids []string) []*armnetwork.ApplicationSecurityGroup {
+	if len(ids) == 0 {
+		return nil
+	}
+	return lo.Map(ids, func(id string, _ int) *armnetwork.ApplicationSecurityGroup {
+		return &armnetwork.ApplicationSecurityGroup{ID: lo.ToPtr(id)}
+	})
+}
+
+// IPv6IPConfigName is the name of the IPv6 ipconfig of the NICs of dual-stack nodes, matching AKS RP
+const IPv6IPConfigName = "ipv6config"
+
 // E.g., aks-default-2jf98
 func GenerateResourceName(nodeClaimName string) string {
 	return fmt.Sprintf("aks-%s", nodeClaimName)
 }
 
 type createNICOptions struct {
-	NICName                string
-	BackendPools           *loadbalancer.BackendAddressPools
-	InstanceType           *corecloudprovider.InstanceType
-	LaunchTemplate         *launchtemplate.Template
-	NetworkPlugin          string
-	NetworkPluginMode      string
-	MaxPods                int32
-	NetworkSecurityGroupID string
+	NICName                     string
+	BackendPools                *loadbalancer.BackendAddressPools
+	InstanceType                *corecloudprovider.InstanceType
+	LaunchTemplate              *launchtemplate.Template
+	NetworkPlugin               string
+	NetworkPluginMode           string
+	MaxPods                     int32
+	NetworkSecurityGroupID      string
+	ApplicationSecurityGroupIDs []string
+	// PublicIPAddressID is the instance-level public IP attached to the primary ipconfig, if any
+	PublicIPAddressID string
+	// IPv6DualStackEnabled adds an IPv6 ipconfig to the NIC, which joins the IPv6 backend pools
+	IPv6DualStackEnabled bool
+	// PodSubnetEnabled means pods get their IPs from a dedicated pod subnet, so the NIC has no secondary ipconfigs for them
+	PodSubnetEnabled bool
 }
 
 func (p *DefaultVMProvider) createNetworkInterface(ctx context.Context, opts *createNICOptions) (string, error) {
@@ -534,6 +661,53 @@ func (p *DefaultVMProvider) createNetworkInterface(ctx context.Context, opts *cr
 	return *res.ID, nil
 }
 
+type createPublicIPOptions struct {
+	PublicIPName   string
+	Zone           string
+	PublicIP       *v1beta1.PublicIPConfiguration
+	LaunchTemplate *launchtemplate.Template
+}
+
+func (p *DefaultVMProvider) newPublicIPAddressForVM(opts *createPublicIPOptions) armnetwork.PublicIPAddress {
+	// The tags of all the resources of the instance take precedence, as garbage collection finds leaked public IPs by the nodepool tag
+	tags := lo.Assign(
+		lo.MapValues(opts.PublicIP.Tags, func(value string, _ string) *string { return lo.ToPtr(value) }),
+		opts.LaunchTemplate.Tags,
+	)
+	publicIP := armnetwork.PublicIPAddress{
+		Location: lo.ToPtr(p.location),
+		Tags:     tags,
+		SKU: &armnetwork.PublicIPAddressSKU{
+			Name: lo.ToPtr(armnetwork.PublicIPAddressSKUName(opts.PublicIP.GetPublicIPSKU())),
+			Tier: lo.ToPtr(armnetwork.PublicIPAddressSKUTierRegional),
+		},
+		// A zonal public IP can only be attached to a NIC of a VM in the same zone
+		Zones: zones.MakeARMZonesFromAKSLabelZone(opts.Zone),
+		Properties: &armnetwork.PublicIPAddressPropertiesFormat{
+			PublicIPAllocationMethod: lo.ToPtr(armnetwork.IPAllocationMethodStatic),
+			PublicIPAddressVersion:   lo.ToPtr(armnetwork.IPVersionIPv4),
+			IPTags: lo.Map(opts.PublicIP.IPTags, func(ipTag v1beta1.PublicIPTag, _ int) *armnetwork.IPTag {
+				return &armnetwork.IPTag{IPTagType: lo.ToPtr(ipTag.Type), Tag: lo.ToPtr(ipTag.Tag)}
+			}),
+		},
+	}
+	if opts.PublicIP.PublicIPPrefixID != nil {
+		publicIP.Properties.PublicIPPrefix = &armnetwork.SubResource{ID: opts.PublicIP.PublicIPPrefixID}
+	}
+	return publicIP
+}
+
+func (p *DefaultVMProvider) createPublicIPAddress(ctx context.Context, opts *createPublicIPOptions) (string, error) {
+	publicIP := p.newPublicIPAddressForVM(opts)
+	log.FromContext(ctx).V(1).Info("creating public IP address", "publicIPName", opts.PublicIPName)
+	res, err := createPublicIP(ctx, p.azClient.PublicIPAddressesClient(), p.resourceGroup, opts.PublicIPName, publicIP)
+	if err != nil {
+		return "", err
+	}
+	log.FromContext(ctx).V(1).Info("successfully created public IP address", "publicIPName", opts.PublicIPName, "publicIPID", *res.ID)
+	return *res.ID, nil
+}
+
 // createVMOptions contains all the parameters needed to create a VM
 type createVMOptions struct {
 	VMName              string
@@ -552,14 +726,14 @@ type createVMOptions struct {
 	DiskEncryptionSetID string
 	NodePoolName        string
 	UltraSSDEnabled     bool
+	// CapacityReservationGroupID is the capacity reservation group the VM is placed into, if any
+	CapacityReservationGroupID string
+	// SpotMaxPrice is the maximum price paid for a spot VM, -1 meaning up to the on-demand price
+	SpotMaxPrice float64
 }
 
 // newVMObject creates a new armcompute.VirtualMachine from the provided options
-func newVMObject(opts *createVMOptions) *armcompute.VirtualMachine {
-	if opts.LaunchTemplate.IsWindows {
-		return &armcompute.VirtualMachine{} // TODO(Windows)
-	}
-
+func newVMObject(opts *createVMOptions) (*armcompute.VirtualMachine, error) {
 	vm := &armcompute.VirtualMachine{
 		Name:     lo.ToPtr(opts.VMName), // TODO: I think it's safe to set this, even though it's read only
 		Location: lo.ToPtr(opts.Location),
@@ -612,10 +786,18 @@ func newVMObject(opts *createVMOptions) *armcompute.VirtualMachine {
 	}
 	setVMPropertiesOSDiskType(vm.Properties, opts.LaunchTemplate)
 	setVMPropertiesOSDiskEncryption(vm.Properties, opts.DiskEncryptionSetID)
+	setVMPropertiesDataDisks(vm.Properties, opts.VMName, opts.LaunchTemplate.DataDisks, opts.DiskEncryptionSetID)
 	setImageReference(vm.Properties, opts.LaunchTemplate.ImageID, opts.UseSIG)
-	setVMPropertiesBillingProfile(vm.Properties, opts.CapacityType)
+	setVMPropertiesBillingProfile(vm.Properties, opts.CapacityType, opts.SpotMaxPrice, opts.NodeClass.GetSpotEvictionPolicy())
+	setVMPropertiesCapacityReservation(vm.Properties, opts.CapacityReservationGroupID)
+	setVMPropertiesPlacement(vm.Properties, opts.NodeClass)
 	setVMPropertiesSecurityProfile(vm.Properties, opts.NodeClass)
 	setVMPropertiesAdditionalCapabilities(vm.Properties, opts.UltraSSDEnabled)
+	if opts.LaunchTemplate.IsWindows {
+		if err := setVMPropertiesWindowsOSProfile(vm.Properties, opts.VMName); err != nil {
+			return nil, err
+		}
+	}
 
 	if opts.ProvisionMode == consts.ProvisionModeBootstrappingClient {
 		vm.Properties.OSProfile.CustomData = lo.ToPtr(opts.LaunchTemplate.CustomScriptsCustomData)
@@ -623,7 +805,73 @@ func newVMObject(opts *createVMOptions) *armcompute.VirtualMachine {
 		vm.Properties.OSProfile.CustomData = lo.ToPtr(opts.LaunchTemplate.ScriptlessCustomData)
 	}
 
-	return vm
+	return vm, nil
+}
+
+// setVMPropertiesWindowsOSProfile replaces the Linux OS profile with a Windows one
+func setVMPropertiesWindowsOSProfile(vmProperties *armcompute.VirtualMachineProperties, vmName string) error {
+	password, err := generateWindowsAdminPassword()
+	if err != nil {
+		return fmt.Errorf("generating windows admin password, %w", err)
+	}
+	vmProperties.OSProfile = &armcompute.OSProfile{
+		AdminUsername: lo.ToPtr(windowsAdminUsername),
+		AdminPassword: lo.ToPtr(password),
+		ComputerName:  lo.ToPtr(windowsComputerName(vmName)),
+		WindowsConfiguration: &armcompute.WindowsConfiguration{
+			ProvisionVMAgent: lo.ToPtr(true),
+			// Nodes are updated by upgrading the node image, like on AKS
+			EnableAutomaticUpdates: lo.ToPtr(false),
+		},
+	}
+	return nil
+}
+
+// windowsComputerName shortens the VM name to fit the Windows computer name limit, keeping the
+// leading characters (the "aks" prefix and the node pool name) and the random suffix of the node claim name.
+// e.g. "aks-windows-pool-x7k2p" becomes "akswindowsx7k2p"
+func windowsComputerName(vmName string) string {
+	name := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return -1
+	}, vmName)
+	if len(name) <= windowsComputerNameMaxLength {
+		return name
+	}
+	const suffixLength = 5
+	return name[:windowsComputerNameMaxLength-suffixLength] + name[len(name)-suffixLength:]
+}
+
+// generateWindowsAdminPassword generates a random password meeting the Azure Windows password complexity requirements
+func generateWindowsAdminPassword() (string, error) {
+	const (
+		lower   = "abcdefghijklmnopqrstuvwxyz"
+		upper   = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+		digits  = "0123456789"
+		special = "!@#%^*()-_=+[]{}:,.?"
+	)
+	// Guarantee one character of each class, then fill up from all of them
+	classes := []string{lower, upper, digits, special, lower + upper + digits + special}
+	password := make([]byte, windowsAdminPasswordLength)
+	for i := range password {
+		class := classes[min(i, len(classes)-1)]
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(class))))
+		if err != nil {
+			return "", err
+		}
+		password[i] = class[n.Int64()]
+	}
+	// Shuffle, so that the guaranteed characters aren't at predictable positions (Fisher-Yates)
+	for i := len(password) - 1; i > 0; i-- {
+		j, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			return "", err
+		}
+		password[i], password[j.Int64()] = password[j.Int64()], password[i]
+	}
+	return string(password), nil
 }
 
 func setVMPropertiesOSDiskType(vmProperties *armcompute.VirtualMachineProperties, launchTemplate *launchtemplate.Template) {
@@ -648,9 +896,37 @@ func setVMPropertiesOSDiskEncryption(vmProperties *armcompute.VirtualMachineProp
 	}
 }
 
-// setImageReference sets the image reference for the VM based on if we are using self hosted karpenter or the node auto provisioning addon
+// setVMPropertiesDataDisks attaches the data disks of the AKSNodeClass as empty managed disks, deleted with the VM.
+// The LUN of each disk is its index, which the bootstrap script relies on to find and mount it.
+func setVMPropertiesDataDisks(vmProperties *armcompute.VirtualMachineProperties, vmName string, dataDisks []v1beta1.DataDisk, diskEncryptionSetID string) {
+	for i, dataDisk := range dataDisks {
+		lun := int32(i) //nolint:gosec // G115: bounded by the CRD
+		managedDisk := &armcompute.ManagedDiskParameters{
+			StorageAccountType: lo.ToPtr(armcompute.StorageAccountTypes(dataDisk.GetDataDiskSKU())),
+		}
+		if diskEncryptionSetID != "" {
+			managedDisk.DiskEncryptionSet = &armcompute.DiskEncryptionSetParameters{
+				ID: lo.ToPtr(diskEncryptionSetID),
+			}
+		}
+		vmProperties.StorageProfile.DataDisks = append(vmProperties.StorageProfile.DataDisks, &armcompute.DataDisk{
+			Lun:               lo.ToPtr(lun),
+			Name:              lo.ToPtr(fmt.Sprintf("%s-data-%d", vmName, lun)),
+			CreateOption:      lo.ToPtr(armcompute.DiskCreateOptionTypesEmpty),
+			DeleteOption:      lo.ToPtr(armcompute.DiskDeleteOptionTypesDelete),
+			DiskSizeGB:        lo.ToPtr(dataDisk.SizeGB),
+			Caching:           lo.ToPtr(armcompute.CachingTypes(dataDisk.GetCaching())),
+			ManagedDisk:       managedDisk,
+			DiskIOPSReadWrite: dataDisk.DiskIOPSReadWrite,
+			DiskMBpsReadWrite: dataDisk.DiskMBpsReadWrite,
+		})
+	}
+}
+
+// setImageReference sets the image reference for the VM based on if we are using self hosted karpenter or the node auto provisioning addon.
+// Custom images (AKSNodeClass spec.imageID) always come from a Shared Image Gallery, regardless of which one is in use.
 func setImageReference(vmProperties *armcompute.VirtualMachineProperties, imageID string, useSIG bool) {
-	if useSIG {
+	if useSIG || !strings.HasPrefix(imageID, "/CommunityGalleries") {
 		vmProperties.StorageProfile.ImageReference = &armcompute.ImageReference{
 			ID: lo.ToPtr(imageID),
 		}
@@ -661,16 +937,42 @@ func setImageReference(vmProperties *armcompute.VirtualMachineProperties, imageI
 	}
 }
 
-// setVMPropertiesBillingProfile sets a default MaxPrice of -1 for Spot
-func setVMPropertiesBillingProfile(vmProperties *armcompute.VirtualMachineProperties, capacityType string) {
+// setVMPropertiesBillingProfile sets the MaxPrice and EvictionPolicy for Spot, a MaxPrice of -1 paying up to the on-demand price
+func setVMPropertiesBillingProfile(vmProperties *armcompute.VirtualMachineProperties, capacityType string, maxPrice float64, evictionPolicy v1beta1.SpotEvictionPolicy) {
 	if capacityType == karpv1.CapacityTypeSpot {
-		vmProperties.EvictionPolicy = lo.ToPtr(armcompute.VirtualMachineEvictionPolicyTypesDelete)
+		vmProperties.EvictionPolicy = lo.ToPtr(lo.Ternary(evictionPolicy == v1beta1.SpotEvictionPolicyDeallocate,
+			armcompute.VirtualMachineEvictionPolicyTypesDeallocate, armcompute.VirtualMachineEvictionPolicyTypesDelete))
 		vmProperties.BillingProfile = &armcompute.BillingProfile{
-			MaxPrice: lo.ToPtr(float64(-1)),
+			MaxPrice: lo.ToPtr(maxPrice),
 		}
 	}
 }
 
+// setVMPropertiesCapacityReservation places the VM into the capacity reservation group, if any
+func setVMPropertiesCapacityReservation(vmProperties *armcompute.VirtualMachineProperties, capacityReservationGroupID string) {
+	if capacityReservationGroupID == "" {
+		return
+	}
+	vmProperties.CapacityReservation = &armcompute.CapacityReservationProfile{
+		CapacityReservationGroup: &armcompute.SubResource{
+			ID: lo.ToPtr(capacityReservationGroupID),
+		},
+	}
+}
+
+// setVMPropertiesPlacement places the VM into the proximity placement group and onto the dedicated hosts of the AKSNodeClass, if any
+func setVMPropertiesPlacement(vmProperties *armcompute.VirtualMachineProperties, nodeClass *v1beta1.AKSNodeClass) {
+	if id := nodeClass.GetProximityPlacementGroupID(); id != "" {
+		vmProperties.ProximityPlacementGroup = &armcompute.SubResource{ID: lo.ToPtr(id)}
+	}
+	if id := nodeClass.GetHostGroupID(); id != "" {
+		vmProperties.HostGroup = &armcompute.SubResource{ID: lo.ToPtr(id)}
+	}
+	if id := nodeClass.GetHostID(); id != "" {
+		vmProperties.Host = &armcompute.SubResource{ID: lo.ToPtr(id)}
+	}
+}
+
 func setVMPropertiesSecurityProfile(vmProperties *armcompute.VirtualMachineProperties, nodeClass *v1beta1.AKSNodeClass) {
 	if nodeClass.Spec.Security == nil {
 		return
@@ -737,7 +1039,10 @@ func (p *DefaultVMProvider) createVirtualMachine(ctx context.Context, opts *crea
 	if !sdkerrors.IsNotFoundErr(err) {
 		return nil, fmt.Errorf("getting VM %q: %w", opts.VMName, err)
 	}
-	vm := newVMObject(opts)
+	vm, err := newVMObject(opts)
+	if err != nil {
+		return nil, err
+	}
 	log.FromContext(ctx).V(1).Info("creating virtual machine", "vmName", opts.VMName, logging.InstanceType, opts.InstanceType.Name)
 	VMCreateStartMetric.With(map[string]string{
 		metrics.ImageLabel:        opts.LaunchTemplate.ImageID,
@@ -786,10 +1091,16 @@ func (p *DefaultVMProvider) beginLaunchInstance(
 	nodeClaim *karpv1.NodeClaim,
 	instanceTypes []*corecloudprovider.InstanceType,
 ) (*VirtualMachinePromise, error) {
+	strategy := p.allocationStrategyProvider.ResolveStrategy(ctx, nodeClass, nodeClaim)
+	reservations := p.getCapacityReservations(nodeClass)
+	if reservations != nil {
+		strategy.CapacityReservations = reservations
+	}
 	selection := p.allocationStrategyProvider.Allocate(
 		ctx,
 		instanceTypes,
 		scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...),
+		strategy,
 	)
 	if selection == nil {
 		return nil, corecloudprovider.NewInsufficientCapacityError(fmt.Errorf("no instance types available"))
@@ -797,10 +1108,34 @@ func (p *DefaultVMProvider) beginLaunchInstance(
 	instanceType := selection.InstanceType
 	capacityType := selection.CapacityType()
 
-	ultraSSD := resolveUltraSSDRequested(nodeClaim)
+	// Ultra Disk data disks require Ultra SSD to be enabled on the VM, offerings that don't support it are unavailable.
+	ultraSSD := resolveUltraSSDRequested(nodeClaim) || nodeClass.HasUltraSSDDataDisks()
 	zone := selection.Zone()
 	placementScope := selection.PlacementScope()
-	launchTemplate, err := p.getLaunchTemplate(ctx, nodeClass, nodeClaim, instanceType, capacityType, placementScope, ultraSSD)
+	// Only on-demand VMs can be placed into a capacity reservation group, and only while it has unused reserved capacity.
+	// Otherwise the VM is created as plain on-demand (or spot) capacity.
+	var capacityReservationGroupID string
+	if capacityType == karpv1.CapacityTypeOnDemand && reservations.Available(instanceType.Name, zone) {
+		capacityReservationGroupID = reservations.GroupID()
+	}
+	// Spot offerings whose max price can't be determined are dropped, this only happens if the on-demand price was lost since
+	spotMaxPrice, ok := p.instanceTypeProvider.SpotMaxPrice(nodeClass, instanceType.Name)
+	if capacityType == karpv1.CapacityTypeSpot && !ok {
+		return nil, fmt.Errorf("determining the spot max price of instance type %q, its on-demand price is unknown", instanceType.Name)
+	}
+	networkPlugin := options.FromContext(ctx).NetworkPlugin
+	networkPluginMode := options.FromContext(ctx).NetworkPluginMode
+	maxPods := utils.GetMaxPods(nodeClass, networkPlugin, networkPluginMode)
+	requiredIPs := subnetcapacity.RequiredIPAddressCount(maxPods, networkPlugin, networkPluginMode, nodeClass.Spec.PodSubnetID != nil)
+	subnetIDs := p.candidateSubnetIDs(ctx, nodeClass, zone)
+	if len(subnetIDs) == 0 {
+		return nil, corecloudprovider.NewInsufficientCapacityError(fmt.Errorf("no subnets of the AKSNodeClass can be used in zone %q", zone))
+	}
+	subnetIDs, err := subnetsWithCapacity(p.subnetCapacityProvider, subnetIDs, requiredIPs)
+	if err != nil {
+		return nil, err
+	}
+	launchTemplate, err := p.getLaunchTemplate(ctx, nodeClass, nodeClaim, instanceType, capacityType, placementScope, ultraSSD, subnetIDs[0])
 	if err != nil {
 		return nil, fmt.Errorf("getting launch template: %w", err)
 	}
@@ -812,15 +1147,15 @@ func (p *DefaultVMProvider) beginLaunchInstance(
 	if err != nil {
 		return nil, fmt.Errorf("getting backend pools: %w", err)
 	}
-	networkPlugin := options.FromContext(ctx).NetworkPlugin
-	networkPluginMode := options.FromContext(ctx).NetworkPluginMode
-
 	isAKSManagedVNET, err := utils.IsAKSManagedVNET(options.FromContext(ctx).NodeResourceGroup, launchTemplate.SubnetID)
 	if err != nil {
 		return nil, fmt.Errorf("checking if vnet is managed: %w", err)
 	}
 	var nsgID string
-	if !isAKSManagedVNET {
+	if nodeClass.Spec.NetworkSecurityGroupID != nil {
+		// The NodeClass NSG takes the place of the managed one. On a managed VNET the managed NSG is still associated with the subnet.
+		nsgID = *nodeClass.Spec.NetworkSecurityGroupID
+	} else if !isAKSManagedVNET {
 		nsg, err := p.networkSecurityGroupProvider.ManagedNetworkSecurityGroup(ctx)
 		if err != nil {
 			return nil, fmt.Errorf("getting managed network security group: %w", err)
@@ -828,19 +1163,37 @@ func (p *DefaultVMProvider) beginLaunchInstance(
 		nsgID = lo.FromPtr(nsg.ID)
 	}
 
+	// The public IP is named the same as the NIC and the VM, and is cleaned up with them
+	var publicIPAddressID string
+	if nodeClass.Spec.PublicIP != nil {
+		publicIPAddressID, err = p.createPublicIPAddress(ctx, &createPublicIPOptions{
+			PublicIPName:   resourceName,
+			Zone:           zone,
+			PublicIP:       nodeClass.Spec.PublicIP,
+			LaunchTemplate: launchTemplate,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("creating public IP address: %w", err)
+		}
+	}
+
 	// TODO: Not returning after launching this LRO because
 	// TODO: doing so would bypass the capacity and other errors that are currently handled by
 	// TODO: core pkg/controllers/nodeclaim/lifecycle/controller.go - in particular, there are metrics/events
 	// TODO: emitted in capacity failure cases that we probably want.
 	nicOpts := &createNICOptions{
-		NICName:                resourceName,
-		NetworkPlugin:          networkPlugin,
-		NetworkPluginMode:      networkPluginMode,
-		MaxPods:                utils.GetMaxPods(nodeClass, networkPlugin, networkPluginMode),
-		LaunchTemplate:         launchTemplate,
-		BackendPools:           backendPools,
-		InstanceType:           instanceType,
-		NetworkSecurityGroupID: nsgID,
+		NICName:                     resourceName,
+		NetworkPlugin:               networkPlugin,
+		NetworkPluginMode:           networkPluginMode,
+		MaxPods:                     maxPods,
+		LaunchTemplate:              launchTemplate,
+		BackendPools:                backendPools,
+		InstanceType:                instanceType,
+		NetworkSecurityGroupID:      nsgID,
+		ApplicationSecurityGroupIDs: nodeClass.Spec.ApplicationSecurityGroupIDs,
+		PublicIPAddressID:           publicIPAddressID,
+		IPv6DualStackEnabled:        options.FromContext(ctx).IPv6DualStackEnabled,
+		PodSubnetEnabled:            nodeClass.Spec.PodSubnetID != nil,
 	}
 
 	nicReference, err := p.createNetworkInterface(ctx, nicOpts)
@@ -856,9 +1209,26 @@ func (p *DefaultVMProvider) beginLaunchInstance(
 		// Try again
 		nicReference, err = p.createNetworkInterface(ctx, nicOpts)
 	}
+	// The subnet is baked into the bootstrapping of the node, so falling back to another subnet takes a new launch template
+	for _, subnetID := range subnetIDs[1:] {
+		if !isSubnetFullError(err) {
+			break
+		}
+		p.markSubnetFull(ctx, launchTemplate.SubnetID, err)
+		launchTemplate, err = p.getLaunchTemplate(ctx, nodeClass, nodeClaim, instanceType, capacityType, placementScope, ultraSSD, subnetID)
+		if err != nil {
+			return nil, fmt.Errorf("getting launch template: %w", err)
+		}
+		nicOpts.LaunchTemplate = launchTemplate
+		nicReference, err = p.createNetworkInterface(ctx, nicOpts)
+	}
 	if err != nil {
+		if isSubnetFullError(err) {
+			p.markSubnetFull(ctx, launchTemplate.SubnetID, err)
+		}
 		return nil, err
 	}
+	p.subnetCapacityProvider.Reserve(launchTemplate.SubnetID, resourceName, requiredIPs)
 
 	result, err := p.createVirtualMachine(ctx, &createVMOptions{
 		VMName:              resourceName,
@@ -877,8 +1247,17 @@ func (p *DefaultVMProvider) beginLaunchInstance(
 		DiskEncryptionSetID: p.diskEncryptionSetID,
 		NodePoolName:        nodeClaim.Labels[karpv1.NodePoolLabelKey],
 		UltraSSDEnabled:     ultraSSD,
+
+		CapacityReservationGroupID: capacityReservationGroupID,
+		SpotMaxPrice:               spotMaxPrice,
 	})
 	if err != nil {
+		if handledError := p.capacityReservationErrors.Handle(ctx, capacityReservationGroupID, instanceType, zone, err); handledError != nil {
+			return nil, handledError
+		}
+		if handledError := p.placementErrors.Handle(ctx, nodeClass.PlacementKey(), instanceType, zone, capacityType, err); handledError != nil {
+			return nil, handledError
+		}
 		sku, skuErr := p.instanceTypeProvider.Get(ctx, instanceType.Name)
 		if skuErr != nil {
 			return nil, fmt.Errorf("failed to get instance type %q: %w", instanceType.Name, err)
@@ -923,6 +1302,12 @@ func (p *DefaultVMProvider) beginLaunchInstance(
 					metrics.ErrorCodeLabel:    ErrorCodeForMetrics(err),
 				}).Inc()
 
+				if handledError := p.capacityReservationErrors.Handle(ctx, capacityReservationGroupID, instanceType, zone, err); handledError != nil {
+					return handledError
+				}
+				if handledError := p.placementErrors.Handle(ctx, nodeClass.PlacementKey(), instanceType, zone, capacityType, err); handledError != nil {
+					return handledError
+				}
 				sku, skuErr := p.instanceTypeProvider.Get(ctx, instanceType.Name)
 				if skuErr != nil {
 					return fmt.Errorf("failed to get instance type %q: %w", instanceType.Name, err)
@@ -943,9 +1328,15 @@ func (p *DefaultVMProvider) beginLaunchInstance(
 					// An error here is handled by CloudProvider create and calls vmInstanceProvider.Delete (which cleans up the azure resources)
 					return err
 				}
+			} else if launchTemplate.IsWindows {
+				// Windows doesn't run custom data by itself, so even scriptless nodes need a CSE to bootstrap
+				err = p.createCSExtension(ctx, resourceName, launchTemplate.ScriptlessCSE, launchTemplate.IsWindows, launchTemplate.Tags)
+				if err != nil {
+					return err
+				}
 			}
 			if isAKSIdentifyingExtensionEnabled(p.env) {
-				err = p.createAKSIdentifyingExtension(ctx, resourceName, launchTemplate.Tags)
+				err = p.createAKSIdentifyingExtension(ctx, resourceName, launchTemplate.IsWindows, launchTemplate.Tags)
 				if err != nil {
 					return err
 				}
@@ -957,6 +1348,17 @@ func (p *DefaultVMProvider) beginLaunchInstance(
 	}, nil
 }
 
+// getCapacityReservations returns the unused reserved capacity of the AKSNodeClass capacity reservation group, or nil if there is
+// none. The reservations are refreshed by the AKSNodeClass status controller; until they are, or while the group is unavailable,
+// VMs are created without the reservation, as plain on-demand capacity.
+func (p *DefaultVMProvider) getCapacityReservations(nodeClass *v1beta1.AKSNodeClass) *capacityreservation.Reservations {
+	groupID := nodeClass.GetCapacityReservationGroupID()
+	if groupID == "" || p.capacityReservationProvider == nil {
+		return nil
+	}
+	return p.capacityReservationProvider.Get(groupID)
+}
+
 func (p *DefaultVMProvider) applyTemplateToNic(nic *armnetwork.Interface, template *launchtemplate.Template) {
 	// set tags
 	nic.Tags = template.Tags
@@ -973,6 +1375,7 @@ func (p *DefaultVMProvider) getLaunchTemplate(
 	capacityType string,
 	placementScope string,
 	ultraSSD bool,
+	subnetID string,
 ) (*launchtemplate.Template, error) {
 	// We need to get all single-valued requirement labels from the instance type and the nodeClaim to pass down to kubelet.
 	// We don't just include single-value labels from the instance type because in the case where the label is NOT single-value on the instance
@@ -992,7 +1395,7 @@ func (p *DefaultVMProvider) getLaunchTemplate(
 		},
 	)
 
-	launchTemplate, err := p.launchTemplateProvider.GetTemplate(ctx, nodeClass, nodeClaim, instanceType, additionalLabels)
+	launchTemplate, err := p.launchTemplateProvider.GetTemplate(ctx, nodeClass, nodeClaim, instanceType, subnetID, additionalLabels)
 	if err != nil {
 		return nil, fmt.Errorf("getting launch templates, %w", err)
 	}
@@ -1013,12 +1416,18 @@ func (p *DefaultVMProvider) cleanupAzureResources(ctx context.Context, resourceN
 	// then we attempt to delete the nic.
 
 	nicErr := deleteNicIfExists(ctx, p.azClient.NetworkInterfacesClient(), p.resourceGroup, resourceName)
+	// The public IP, if any, can only be deleted once it is no longer attached to the NIC. If the NIC is left behind,
+	// the public IP garbage collector is expected to delete it after the NIC garbage collector deletes the NIC.
+	var publicIPErr error
+	if nicErr == nil {
+		publicIPErr = deletePublicIPIfExists(ctx, p.azClient.PublicIPAddressesClient(), p.resourceGroup, resourceName)
+	}
 
 	if mustDeleteNic {
 		// Don't log NIC error here since mustDeleteNic is true (critical cleanup scenario).
 		// Both VM and NIC errors are returned to the caller for proper handling and logging.
 		// Logging here would create duplicate logs when the caller processes the joined error.
-		return errors.Join(vmErr, nicErr)
+		return errors.Join(vmErr, nicErr, publicIPErr)
 	} else {
 		// Log NIC error here since mustDeleteNic is false (best-effort cleanup scenario).
 		// Because we're not returning nicErr to the caller we need to log here.
@@ -1026,6 +1435,9 @@ func (p *DefaultVMProvider) cleanupAzureResources(ctx context.Context, resourceN
 		if nicErr != nil {
 			log.FromContext(ctx).Error(nicErr, "networkinterface.Delete failed", "nicName", resourceName)
 		}
+		if publicIPErr != nil {
+			log.FromContext(ctx).Error(publicIPErr, "publicipaddress.Delete failed", "publicIPName", resourceName)
+		}
 		return vmErr
 	}
 }
@@ -1074,22 +1486,23 @@ func (p *DefaultVMProvider) deleteVirtualMachine(ctx context.Context, vmName str
 	return nil
 }
 
-func (p *DefaultVMProvider) getAKSIdentifyingExtension(tags map[string]*string) *armcompute.VirtualMachineExtension {
+func (p *DefaultVMProvider) getAKSIdentifyingExtension(isWindows bool, tags map[string]*string) *armcompute.VirtualMachineExtension {
 	const (
-		vmExtensionType                  = "Microsoft.Compute/virtualMachines/extensions"
-		aksIdentifyingExtensionPublisher = "Microsoft.AKS"
-		aksIdentifyingExtensionTypeLinux = "Compute.AKS.Linux.Billing"
+		vmExtensionType                    = "Microsoft.Compute/virtualMachines/extensions"
+		aksIdentifyingExtensionPublisher   = "Microsoft.AKS"
+		aksIdentifyingExtensionTypeLinux   = "Compute.AKS.Linux.Billing"
+		aksIdentifyingExtensionTypeWindows = "Compute.AKS.Windows.Billing"
 	)
 
 	vmExtension := &armcompute.VirtualMachineExtension{
 		Location: lo.ToPtr(p.location),
-		Name:     lo.ToPtr(aksIdentifyingExtensionName),
+		Name:     lo.ToPtr(aksIdentifyingExtensionName(isWindows)),
 		Properties: &armcompute.VirtualMachineExtensionProperties{
 			Publisher:               lo.ToPtr(aksIdentifyingExtensionPublisher),
 			TypeHandlerVersion:      lo.ToPtr("1.0"),
 			AutoUpgradeMinorVersion: lo.ToPtr(true),
 			Settings:                &map[string]interface{}{},
-			Type:                    lo.ToPtr(aksIdentifyingExtensionTypeLinux),
+			Type:                    lo.ToPtr(lo.Ternary(isWindows, aksIdentifyingExtensionTypeWindows, aksIdentifyingExtensionTypeLinux)),
 		},
 		Type: lo.ToPtr(vmExtensionType),
 		Tags: tags,
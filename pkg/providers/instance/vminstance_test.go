@@ -17,6 +17,7 @@ limitations under the License.
 package instance
 
 import (
+	"regexp"
 	"testing"
 
 	. "github.com/onsi/gomega"
@@ -106,6 +107,7 @@ func TestGetManagedExtensionNames(t *testing.T) {
 		name          string
 		provisionMode string
 		env           *auth.Environment
+		isWindows     bool
 		expected      []string
 	}{
 		{
@@ -156,15 +158,80 @@ func TestGetManagedExtensionNames(t *testing.T) {
 			env:           noBillingExtensionEnv,
 			expected:      nil,
 		},
+		{
+			name:          "PublicCloud Windows with BootstrappingClient mode returns Windows billing extension and CSE",
+			provisionMode: consts.ProvisionModeBootstrappingClient,
+			env:           publicCloudEnv,
+			isWindows:     true,
+			expected:      []string{"computeAksWindowsBilling", "windows-cse-agent-karpenter"},
+		},
+		{
+			name:          "PublicCloud Windows with AKSScriptless mode returns Windows billing extension and CSE",
+			provisionMode: consts.ProvisionModeAKSScriptless,
+			env:           publicCloudEnv,
+			isWindows:     true,
+			expected:      []string{"computeAksWindowsBilling", "windows-cse-agent-karpenter"},
+		},
+		{
+			name:          "Nonstandard cloud Windows with AKSScriptless mode returns only CSE",
+			provisionMode: consts.ProvisionModeAKSScriptless,
+			env:           noBillingExtensionEnv,
+			isWindows:     true,
+			expected:      []string{"windows-cse-agent-karpenter"},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			g := NewWithT(t)
 
-			result := GetManagedExtensionNames(tt.provisionMode, tt.env)
+			result := GetManagedExtensionNames(tt.provisionMode, tt.env, tt.isWindows)
 
 			g.Expect(result).To(Equal(tt.expected))
 		})
 	}
 }
+
+func TestWindowsComputerName(t *testing.T) {
+	tests := []struct {
+		vmName   string
+		expected string
+	}{
+		{vmName: "aks-win-x7k2p", expected: "akswinx7k2p"},
+		{vmName: "aks-windows-pool-x7k2p", expected: "akswindowsx7k2p"},
+		{vmName: "aks-default-abcde", expected: "aksdefaultabcde"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.vmName, func(t *testing.T) {
+			g := NewWithT(t)
+			name := windowsComputerName(tt.vmName)
+			g.Expect(name).To(Equal(tt.expected))
+			g.Expect(len(name)).To(BeNumerically("<=", windowsComputerNameMaxLength))
+		})
+	}
+}
+
+func TestGenerateWindowsAdminPassword(t *testing.T) {
+	g := NewWithT(t)
+
+	password, err := generateWindowsAdminPassword()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(password).To(HaveLen(windowsAdminPasswordLength))
+	g.Expect(password).To(MatchRegexp(`[a-z]`))
+	g.Expect(password).To(MatchRegexp(`[A-Z]`))
+	g.Expect(password).To(MatchRegexp(`[0-9]`))
+	g.Expect(password).To(MatchRegexp(`[^a-zA-Z0-9]`))
+
+	other, err := generateWindowsAdminPassword()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(other).ToNot(Equal(password))
+
+	// the guaranteed characters aren't always at the start of the password
+	fixedPrefix := regexp.MustCompile(`^[a-z][A-Z][0-9][^a-zA-Z0-9]`)
+	g.Expect(lo.Times(20, func(_ int) bool {
+		password, err := generateWindowsAdminPassword()
+		g.Expect(err).ToNot(HaveOccurred())
+		return fixedPrefix.MatchString(password)
+	})).To(ContainElement(false))
+}
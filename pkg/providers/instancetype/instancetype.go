@@ -20,6 +20,8 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"strconv"
+	"strings"
 
 	"github.com/Azure/skewer"
 	"github.com/samber/lo"
@@ -39,6 +41,7 @@ import (
 
 const (
 	MemoryAvailable        = "memory.available"
+	NodeFSAvailable        = "nodefs.available"
 	DefaultMemoryAvailable = "750Mi"
 )
 
@@ -125,16 +128,18 @@ func newInstanceType(
 	offerings cloudprovider.Offerings,
 	params *instanceTypeParameters,
 	architecture string,
+	vmMemoryOverheadPercent float64,
 ) *cloudprovider.InstanceType {
+	capacity := computeCapacity(sku, params, vmMemoryOverheadPercent)
 	return &cloudprovider.InstanceType{
 		Name:         sku.GetName(),
 		Requirements: computeRequirements(options.FromContext(ctx), sku, vmsize, architecture, offerings, region, params),
 		Offerings:    offerings,
-		Capacity:     computeCapacity(ctx, sku, params),
+		Capacity:     capacity,
 		Overhead: &cloudprovider.InstanceTypeOverhead{
-			KubeReserved:      KubeReservedResources(lo.Must(sku.VCPU()), lo.Must(sku.Memory())),
-			SystemReserved:    SystemReservedResources(),
-			EvictionThreshold: EvictionThreshold(),
+			KubeReserved:      KubeReservedResources(lo.Must(sku.VCPU()), lo.Must(sku.Memory()), params.KubeReserved),
+			SystemReserved:    SystemReservedResources(params.SystemReserved),
+			EvictionThreshold: EvictionThreshold(capacity.Memory(), capacity.StorageEphemeral(), params.EvictionHard, params.EvictionSoft),
 		},
 	}
 }
@@ -152,7 +157,7 @@ func computeRequirements(
 		// Well Known Upstream
 		scheduling.NewRequirement(corev1.LabelInstanceTypeStable, corev1.NodeSelectorOpIn, sku.GetName()),
 		scheduling.NewRequirement(corev1.LabelArchStable, corev1.NodeSelectorOpIn, getArchitecture(architecture)),
-		scheduling.NewRequirement(corev1.LabelOSStable, corev1.NodeSelectorOpIn, string(corev1.Linux)),
+		scheduling.NewRequirement(corev1.LabelOSStable, corev1.NodeSelectorOpIn, v1beta1.GetOSFromImageFamily(params.ImageFamily)),
 		scheduling.NewRequirement(corev1.LabelTopologyZone, corev1.NodeSelectorOpIn, lo.Map(offerings.Available(), func(o *cloudprovider.Offering, _ int) string {
 			return o.Requirements.Get(corev1.LabelTopologyZone).Any()
 		})...),
@@ -215,6 +220,9 @@ func computeRequirements(
 	if params.FIPSMode == v1beta1.FIPSModeFIPS {
 		requirements[v1beta1.AKSLabelFIPSEnabled].Insert("true")
 	}
+	if build := v1beta1.GetWindowsBuildFromImageFamily(params.ImageFamily); build != "" {
+		requirements.Add(scheduling.NewRequirement(corev1.LabelWindowsBuild, corev1.NodeSelectorOpIn, build))
+	}
 
 	return requirements
 }
@@ -266,10 +274,10 @@ func getArchitecture(architecture string) string {
 	return architecture // unrecognized
 }
 
-func computeCapacity(ctx context.Context, sku *skewer.SKU, params *instanceTypeParameters) corev1.ResourceList {
+func computeCapacity(sku *skewer.SKU, params *instanceTypeParameters, vmMemoryOverheadPercent float64) corev1.ResourceList {
 	return corev1.ResourceList{
 		corev1.ResourceCPU:                    *cpu(sku),
-		corev1.ResourceMemory:                 *memoryWithoutOverhead(ctx, sku),
+		corev1.ResourceMemory:                 *CalculateMemoryWithoutOverhead(vmMemoryOverheadPercent, memoryGiB(sku)),
 		corev1.ResourceEphemeralStorage:       *ephemeralStorage(params),
 		corev1.ResourcePods:                   *pods(params),
 		corev1.ResourceName("nvidia.com/gpu"): *gpuNvidiaCount(sku),
@@ -323,10 +331,6 @@ func memoryMiB(sku *skewer.SKU) int64 {
 	return int64(memoryGiB(sku) * 1024)
 }
 
-func memoryWithoutOverhead(ctx context.Context, sku *skewer.SKU) *resource.Quantity {
-	return CalculateMemoryWithoutOverhead(options.FromContext(ctx).VMMemoryOverheadPercent, memoryGiB(sku))
-}
-
 func CalculateMemoryWithoutOverhead(vmMemoryOverheadPercent float64, skuMemoryGiB float64) *resource.Quantity {
 	// Consistency in abstractions could be improved here (e.g., units, returning types)
 	memory := resources.Quantity(fmt.Sprintf("%dGi", int64(skuMemoryGiB)))
@@ -343,16 +347,18 @@ func pods(params *instanceTypeParameters) *resource.Quantity {
 	return resource.NewQuantity(int64(params.MaxPods), resource.DecimalSI)
 }
 
-func SystemReservedResources() corev1.ResourceList {
+// SystemReservedResources returns the resources reserved for OS system daemons, with the overrides of the node class applied
+func SystemReservedResources(overrides map[string]string) corev1.ResourceList {
 	// AKS does not set system-reserved values and only CPU and memory are considered
 	// https://learn.microsoft.com/en-us/azure/aks/concepts-clusters-workloads#resource-reservations
-	return corev1.ResourceList{
+	return lo.Assign(corev1.ResourceList{
 		corev1.ResourceCPU:    resource.Quantity{},
 		corev1.ResourceMemory: resource.Quantity{},
-	}
+	}, reservedResources(overrides))
 }
 
-func KubeReservedResources(vcpus int64, memoryGib float64) corev1.ResourceList {
+// KubeReservedResources returns the resources reserved for Kubernetes system components, with the overrides of the node class applied
+func KubeReservedResources(vcpus int64, memoryGib float64, overrides map[string]string) corev1.ResourceList {
 	reservedMemoryMi := int64(1024 * reservedMemoryTaxGi.Calculate(memoryGib))
 	reservedCPUMilli := int64(1000 * reservedCPUTaxVCPU.Calculate(float64(vcpus)))
 
@@ -361,11 +367,58 @@ func KubeReservedResources(vcpus int64, memoryGib float64) corev1.ResourceList {
 		corev1.ResourceMemory: *resource.NewQuantity(reservedMemoryMi*1024*1024, resource.BinarySI),
 	}
 
-	return resources
+	return lo.Assign(resources, reservedResources(overrides))
 }
 
-func EvictionThreshold() corev1.ResourceList {
-	return corev1.ResourceList{
+// EvictionThreshold returns the memory and ephemeral storage kubelet keeps available by evicting pods. It is the largest of
+// the hard and soft eviction thresholds of the node class, percentages being relative to the capacity of the instance type.
+func EvictionThreshold(memory *resource.Quantity, storage *resource.Quantity, evictionHard map[string]string, evictionSoft map[string]string) corev1.ResourceList {
+	threshold := corev1.ResourceList{
 		corev1.ResourceMemory: resource.MustParse(DefaultMemoryAvailable),
 	}
+	overrides := corev1.ResourceList{}
+	for _, signals := range []map[string]string{evictionHard, evictionSoft} {
+		signalThreshold := corev1.ResourceList{}
+		if v, ok := evictionSignalThreshold(*memory, signals[MemoryAvailable]); ok {
+			signalThreshold[corev1.ResourceMemory] = v
+		}
+		if v, ok := evictionSignalThreshold(*storage, signals[NodeFSAvailable]); ok {
+			signalThreshold[corev1.ResourceEphemeralStorage] = v
+		}
+		overrides = resources.MaxResources(overrides, signalThreshold)
+	}
+	return lo.Assign(threshold, overrides)
+}
+
+// reservedResources converts the reserved resources of the node class to a resource list, for the resources that count
+// towards allocatable (pid is not one of them). Values are validated by the CRD, invalid ones are ignored.
+func reservedResources(reserved map[string]string) corev1.ResourceList {
+	result := corev1.ResourceList{}
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory, corev1.ResourceEphemeralStorage} {
+		if v, ok := reserved[string(name)]; ok {
+			if quantity, err := resource.ParseQuantity(v); err == nil {
+				result[name] = quantity
+			}
+		}
+	}
+	return result
+}
+
+// evictionSignalThreshold returns the quantity of an eviction signal value, which is either a quantity or a percentage of capacity
+func evictionSignalThreshold(capacity resource.Quantity, value string) (resource.Quantity, bool) {
+	if value == "" {
+		return resource.Quantity{}, false
+	}
+	if percentage, ok := strings.CutSuffix(value, "%"); ok {
+		p, err := strconv.ParseFloat(percentage, 64)
+		if err != nil {
+			return resource.Quantity{}, false
+		}
+		return *resource.NewQuantity(int64(math.Ceil(capacity.AsApproximateFloat64()/100*p)), resource.BinarySI), true
+	}
+	quantity, err := resource.ParseQuantity(value)
+	if err != nil {
+		return resource.Quantity{}, false
+	}
+	return quantity, true
 }
@@ -19,7 +19,9 @@ package instancetype
 import (
 	"context"
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -69,11 +71,31 @@ type instanceTypeParameters struct {
 	ArtifactStreamingEnabled bool
 	FIPSMode                 v1beta1.FIPSMode
 	LocalDNSEnabled          bool
+	// PlacementKey identifies the placement constraints of instances, offerings that can't be allocated within them are unavailable
+	PlacementKey string
+	// DedicatedHost is true if instances are placed onto dedicated hosts, which don't support spot
+	DedicatedHost bool
+	// UltraSSDDataDisks is true if instances attach Ultra Disks, which require Ultra SSD support in the zone
+	UltraSSDDataDisks bool
+	// ZonalDataDisks is true if instances attach disks that can only be attached to zonal instances
+	ZonalDataDisks bool
+	// KubeReserved, SystemReserved, EvictionHard and EvictionSoft are the kubelet overrides of the node class, they
+	// reduce the allocatable resources of instances
+	KubeReserved   map[string]string
+	SystemReserved map[string]string
+	EvictionHard   map[string]string
+	EvictionSoft   map[string]string
+	// SpotMaxPrice and SpotMaxPricePercentOfOnDemand cap the price paid for spot instances, spot offerings priced above
+	// the cap are dropped
+	SpotMaxPrice                  string
+	SpotMaxPricePercentOfOnDemand int32
 }
 
 type instanceTypesSourceDataGeneration struct {
 	unavailableOfferings uint64
 	quota                uint64
+	memoryOverheads      uint64
+	pricing              uint64
 }
 
 type Provider interface {
@@ -86,6 +108,10 @@ type Provider interface {
 	// UpdateInstanceTypes fetches instance types from Azure and updates the cache
 	UpdateInstanceTypes(ctx context.Context) error
 
+	// SpotMaxPrice returns the maximum price paid for spot instances of the instance type, -1 meaning up to the on-demand
+	// price. It returns false if the price is capped at a percentage of an unknown on-demand price.
+	SpotMaxPrice(*v1beta1.AKSNodeClass, string) (float64, bool)
+
 	// UpdateInstanceTypeOfferings(ctx context.Context) error
 }
 
@@ -98,6 +124,7 @@ type DefaultProvider struct {
 	pricingProvider      *pricing.Provider
 	unavailableOfferings *kcache.UnavailableOfferings
 	quotaProvider        quota.Provider
+	memoryOverheads      *kcache.MemoryOverheads
 
 	// Fully initialized instance types are cached by the parameters that affect their construction.
 	// Changes in the source data generation invalidate the cache as a whole instead of creating unreachable keys.
@@ -118,6 +145,7 @@ func NewDefaultProvider(
 	pricingProvider *pricing.Provider,
 	offeringsCache *kcache.UnavailableOfferings,
 	quotaProvider quota.Provider,
+	memoryOverheads *kcache.MemoryOverheads,
 ) *DefaultProvider {
 	return &DefaultProvider{
 		// TODO: skewer api, subnetprovider, pricing provider, unavailable offerings, ...
@@ -126,6 +154,7 @@ func NewDefaultProvider(
 		pricingProvider:      pricingProvider,
 		unavailableOfferings: offeringsCache,
 		quotaProvider:        quotaProvider,
+		memoryOverheads:      memoryOverheads,
 		instanceTypesCache:   cache,
 		cm:                   pretty.NewChangeMonitor(),
 	}
@@ -154,6 +183,19 @@ func (p *DefaultProvider) List(
 		ArtifactStreamingEnabled: nodeClass.IsArtifactStreamingExplicitlyEnabled(),
 		FIPSMode:                 lo.FromPtr(nodeClass.Spec.FIPSMode),
 		LocalDNSEnabled:          nodeClass.IsLocalDNSEnabled(),
+		PlacementKey:             nodeClass.PlacementKey(),
+		DedicatedHost:            nodeClass.UsesDedicatedHosts(),
+		UltraSSDDataDisks:        nodeClass.HasUltraSSDDataDisks(),
+		ZonalDataDisks:           nodeClass.HasZonalOnlyDataDisks(),
+
+		SpotMaxPrice:                  nodeClass.GetSpotMaxPrice(),
+		SpotMaxPricePercentOfOnDemand: nodeClass.GetSpotMaxPricePercentOfOnDemand(),
+	}
+	if nodeClass.Spec.Kubelet != nil {
+		instanceTypeParams.KubeReserved = nodeClass.Spec.Kubelet.KubeReserved
+		instanceTypeParams.SystemReserved = nodeClass.Spec.Kubelet.SystemReserved
+		instanceTypeParams.EvictionHard = nodeClass.Spec.Kubelet.EvictionHard
+		instanceTypeParams.EvictionSoft = nodeClass.Spec.Kubelet.EvictionSoft
 	}
 	paramsHash, _ := hashstructure.Hash(instanceTypeParams, hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
 	key := fmt.Sprintf("%016x", paramsHash)
@@ -193,7 +235,7 @@ func (p *DefaultProvider) buildInstanceTypes(ctx context.Context, params *instan
 			continue
 		}
 		instanceTypeZones := p.instanceTypeZones(sku)
-		instanceType := newInstanceType(ctx, sku, vmsize, p.region, p.createOfferings(ctx, sku, instanceTypeZones), params, architecture)
+		instanceType := newInstanceType(ctx, sku, vmsize, p.region, p.createOfferings(ctx, sku, instanceTypeZones, params), params, architecture, p.vmMemoryOverheadPercent(ctx, sku))
 		if len(instanceType.Offerings) == 0 {
 			continue
 		}
@@ -209,7 +251,39 @@ func (p *DefaultProvider) currentInstanceTypesSourceDataGeneration() instanceTyp
 	return instanceTypesSourceDataGeneration{
 		unavailableOfferings: p.unavailableOfferings.SeqNum(),
 		quota:                p.quotaProvider.SeqNum(),
+		memoryOverheads:      p.memoryOverheads.SeqNum(),
+		pricing:              p.pricingProvider.SeqNum(),
+	}
+}
+
+// vmMemoryOverheadPercent returns the memory overhead learned from the nodes of the SKU, falling back to the flat
+// VMMemoryOverheadPercent for SKUs that have none
+func (p *DefaultProvider) vmMemoryOverheadPercent(ctx context.Context, sku *skewer.SKU) float64 {
+	if overhead, ok := p.memoryOverheads.Get(sku.GetName()); ok {
+		return overhead
 	}
+	return options.FromContext(ctx).VMMemoryOverheadPercent
+}
+
+func (p *DefaultProvider) SpotMaxPrice(nodeClass *v1beta1.AKSNodeClass, instanceType string) (float64, bool) {
+	return p.spotMaxPrice(instanceType, nodeClass.GetSpotMaxPrice(), nodeClass.GetSpotMaxPricePercentOfOnDemand())
+}
+
+func (p *DefaultProvider) spotMaxPrice(instanceType string, maxPrice string, maxPricePercentOfOnDemand int32) (float64, bool) {
+	if maxPrice != "" {
+		price, err := strconv.ParseFloat(maxPrice, 64)
+		return price, err == nil
+	}
+	if maxPricePercentOfOnDemand > 0 {
+		// Azure evicts spot instances based on the retail prices, regardless of the pricing overrides
+		onDemandPrice, ok := p.pricingProvider.RetailOnDemandPrice(instanceType)
+		if !ok {
+			return 0, false
+		}
+		// Azure accepts up to 5 decimal places, rounding down keeps the price within the cap
+		return math.Floor(onDemandPrice*float64(maxPricePercentOfOnDemand)/100*1e5) / 1e5, true
+	}
+	return -1, true
 }
 
 func (p *DefaultProvider) LivenessProbe(req *http.Request) error {
@@ -260,16 +334,22 @@ func (p *DefaultProvider) instanceTypeZones(sku *skewer.SKU) sets.Set[string] {
 // offering, you can do the following thanks to this invariant:
 //
 //	offering.Requirements.Get(v1.TopologyLabelZone).Any()
-func (p *DefaultProvider) createOfferings(ctx context.Context, sku *skewer.SKU, offeringZones sets.Set[string]) cloudprovider.Offerings {
+func (p *DefaultProvider) createOfferings(ctx context.Context, sku *skewer.SKU, offeringZones sets.Set[string], params *instanceTypeParameters) cloudprovider.Offerings {
 	offerings := []*cloudprovider.Offering{}
 
 	for zone := range offeringZones {
 		placementScope := zones.PlacementScopeForZone(zone)
 
-		// Get prices for ordering; missing prices return MissingPrice (de-prioritized but not excluded).
+		// Get prices for ordering, with the pricing overrides applied; missing prices return MissingPrice (de-prioritized but not excluded).
 		onDemandPrice, _ := p.pricingProvider.OnDemandPrice(*sku.Name)
 		spotPrice, _ := p.pricingProvider.SpotPrice(*sku.Name)
 
+		// Spot offerings priced above the cap of the node class are dropped, their instances would be evicted right away.
+		// So are those whose cap can't be determined, the cap being a percentage of an unknown on-demand price.
+		retailSpotPrice, retailSpotPriceKnown := p.pricingProvider.RetailSpotPrice(*sku.Name)
+		spotMaxPrice, spotMaxPriceKnown := p.spotMaxPrice(*sku.Name, params.SpotMaxPrice, params.SpotMaxPricePercentOfOnDemand)
+		spotWithinMaxPrice := spotMaxPriceKnown && (spotMaxPrice < 0 || !retailSpotPriceKnown || retailSpotPrice <= spotMaxPrice)
+
 		// Unknown SKUs (not in known_skus.yaml) are deprioritized to prevent them from
 		// winning scheduling over known-good SKUs. Users can override via NodeOverlay.
 		if !IsKnownSKU(*sku.Name) {
@@ -279,7 +359,7 @@ func (p *DefaultProvider) createOfferings(ctx context.Context, sku *skewer.SKU,
 
 		// Determine allocatability from SKU capabilities.
 		// On-demand is always allocatable if the SKU passed UpdateInstanceTypes filters, we just need to check the
-		// unavailableOfferings cache and per-family quota.
+		// unavailableOfferings cache and the family and regional vCPU quota, including in-flight creates.
 		availableOnDemand := !p.unavailableOfferings.IsUnavailable(sku, zone, karpv1.CapacityTypeOnDemand) &&
 			p.quotaProvider.HasQuotaFor(ctx, sku)
 		// Spot is only allocatable if the SKU reports LowPriorityCapable=True and the offering is not in the unavailableOfferings cache.
@@ -294,6 +374,24 @@ func (p *DefaultProvider) createOfferings(ctx context.Context, sku *skewer.SKU,
 		// supporting signal that actually the VM can be allocated as spot at the regional level. This seems an over-optimization for now though,
 		// so not doing it.
 		availableSpot := sku.IsLowPriorityCapable() && !p.unavailableOfferings.IsUnavailable(sku, zone, karpv1.CapacityTypeSpot)
+		// Placement constraints bring their own restrictions: spot VMs can't be placed onto dedicated hosts, and offerings that
+		// failed to allocate within the proximity placement group or on the dedicated hosts are unavailable for the same constraints.
+		availableOnDemand = availableOnDemand && !p.unavailableOfferings.IsUnavailableForPlacement(params.PlacementKey, sku.GetName(), zone, karpv1.CapacityTypeOnDemand)
+		availableSpot = availableSpot && !params.DedicatedHost &&
+			!p.unavailableOfferings.IsUnavailableForPlacement(params.PlacementKey, sku.GetName(), zone, karpv1.CapacityTypeSpot)
+		// Data disks bring their own restrictions too: Ultra Disks can only be attached where the VM size supports Ultra SSD,
+		// and Premium SSD v2 and Ultra Disks can only be attached to zonal instances.
+		ultraSSD := ultraSSDOptions(sku, zone)
+		if params.UltraSSDDataDisks {
+			dataDisksAllocatable := lo.Contains(ultraSSD, "true")
+			availableOnDemand = availableOnDemand && dataDisksAllocatable
+			availableSpot = availableSpot && dataDisksAllocatable
+			ultraSSD = []string{"true"}
+		}
+		if params.ZonalDataDisks && zone == zones.Regional {
+			availableOnDemand = false
+			availableSpot = false
+		}
 
 		onDemandOffering := &cloudprovider.Offering{
 			Requirements: scheduling.NewRequirements(
@@ -302,7 +400,7 @@ func (p *DefaultProvider) createOfferings(ctx context.Context, sku *skewer.SKU,
 				scheduling.NewRequirement(v1beta1.AKSLabelPriority, corev1.NodeSelectorOpIn, v1beta1.PriorityRegular),
 				scheduling.NewRequirement(corev1.LabelTopologyZone, corev1.NodeSelectorOpIn, zone),
 				scheduling.NewRequirement(v1beta1.LabelPlacementScope, corev1.NodeSelectorOpIn, placementScope),
-				scheduling.NewRequirement(v1beta1.LabelUltraSSD, corev1.NodeSelectorOpIn, ultraSSDOptions(sku, zone)...),
+				scheduling.NewRequirement(v1beta1.LabelUltraSSD, corev1.NodeSelectorOpIn, ultraSSD...),
 			),
 			Price:     onDemandPrice,
 			Available: availableOnDemand,
@@ -315,13 +413,16 @@ func (p *DefaultProvider) createOfferings(ctx context.Context, sku *skewer.SKU,
 				scheduling.NewRequirement(v1beta1.AKSLabelPriority, corev1.NodeSelectorOpIn, v1beta1.PrioritySpot),
 				scheduling.NewRequirement(corev1.LabelTopologyZone, corev1.NodeSelectorOpIn, zone),
 				scheduling.NewRequirement(v1beta1.LabelPlacementScope, corev1.NodeSelectorOpIn, placementScope),
-				scheduling.NewRequirement(v1beta1.LabelUltraSSD, corev1.NodeSelectorOpIn, ultraSSDOptions(sku, zone)...),
+				scheduling.NewRequirement(v1beta1.LabelUltraSSD, corev1.NodeSelectorOpIn, ultraSSD...),
 			),
 			Price:     spotPrice,
 			Available: availableSpot,
 		}
 
-		offerings = append(offerings, onDemandOffering, spotOffering)
+		offerings = append(offerings, onDemandOffering)
+		if spotWithinMaxPrice {
+			offerings = append(offerings, spotOffering)
+		}
 
 		/*
 			instanceTypeOfferingAvailable.With(prometheus.Labels{
@@ -347,7 +448,16 @@ func (p *DefaultProvider) isInstanceTypeSupportedByFilters(sku *skewer.SKU, arch
 		p.isInstanceTypeSupportedByLocalDNS(sku, params) &&
 		p.isInstanceTypeSupportedByGPUDriverMode(sku, params) &&
 		p.isInstanceTypeSupportedByArtifactStreaming(architecture, params) &&
-		p.isInstanceTypeSupportedByTrustedLaunch(sku, params)
+		p.isInstanceTypeSupportedByTrustedLaunch(sku, params) &&
+		p.isInstanceTypeSupportedByOS(architecture, params)
+}
+
+// isInstanceTypeSupportedByOS filters out ARM64 instance types for Windows, as there are no ARM64 Windows node images.
+func (p *DefaultProvider) isInstanceTypeSupportedByOS(architecture string, params *instanceTypeParameters) bool {
+	if !v1beta1.IsWindowsImageFamily(params.ImageFamily) {
+		return true
+	}
+	return getArchitecture(architecture) != karpv1.ArchitectureArm64
 }
 
 func (p *DefaultProvider) isInstanceTypeSupportedByImageFamily(skuName, imageFamily string) bool {
@@ -479,6 +589,7 @@ func (p *DefaultProvider) UpdateInstanceTypes(ctx context.Context) error {
 	}
 
 	logUnknownSKUFamilies(ctx, instanceTypes)
+	p.pricingProvider.SetFamilies(lo.MapValues(instanceTypes, func(sku *skewer.SKU, _ string) string { return sku.GetFamilyName() }))
 
 	if p.cm.HasChanged("instance-types", instanceTypes) {
 		p.muInstanceTypesCache.Lock()
@@ -22,6 +22,7 @@ import (
 	"encoding/base64"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"strconv"
 	"strings"
@@ -74,6 +75,7 @@ import (
 	"github.com/Azure/karpenter-provider-azure/pkg/fake"
 	"github.com/Azure/karpenter-provider-azure/pkg/operator/options"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/instancetype"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/launchtemplate"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/loadbalancer"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/pricing"
 	"github.com/Azure/karpenter-provider-azure/pkg/test"
@@ -116,9 +118,9 @@ func TestAzure(t *testing.T) {
 	azureEnvBootstrap = test.NewEnvironment(ctxBootstrap, env)
 
 	fakeClock = &clock.FakeClock{}
-	cloudProvider = cloudprovider.New(azureEnv.InstanceTypesProvider, azureEnv.VMInstanceProvider, azureEnv.AKSMachineProvider, events.NewRecorder(&record.FakeRecorder{}), env.Client, azureEnv.ImageProvider, azureEnv.InstanceTypeStore)
-	cloudProviderNonZonal = cloudprovider.New(azureEnvNonZonal.InstanceTypesProvider, azureEnvNonZonal.VMInstanceProvider, azureEnvNonZonal.AKSMachineProvider, events.NewRecorder(&record.FakeRecorder{}), env.Client, azureEnvNonZonal.ImageProvider, azureEnv.InstanceTypeStore)
-	cloudProviderBootstrap = cloudprovider.New(azureEnvBootstrap.InstanceTypesProvider, azureEnvBootstrap.VMInstanceProvider, azureEnvBootstrap.AKSMachineProvider, events.NewRecorder(&record.FakeRecorder{}), env.Client, azureEnvBootstrap.ImageProvider, azureEnv.InstanceTypeStore)
+	cloudProvider = cloudprovider.New(azureEnv.InstanceTypesProvider, azureEnv.VMInstanceProvider, azureEnv.AKSMachineProvider, events.NewRecorder(&record.FakeRecorder{}), env.Client, azureEnv.ImageProvider, azureEnv.InstanceTypeStore, azureEnv.SpotEvictionsCache, azureEnv.QuotaProvider)
+	cloudProviderNonZonal = cloudprovider.New(azureEnvNonZonal.InstanceTypesProvider, azureEnvNonZonal.VMInstanceProvider, azureEnvNonZonal.AKSMachineProvider, events.NewRecorder(&record.FakeRecorder{}), env.Client, azureEnvNonZonal.ImageProvider, azureEnv.InstanceTypeStore, azureEnvNonZonal.SpotEvictionsCache, azureEnvNonZonal.QuotaProvider)
+	cloudProviderBootstrap = cloudprovider.New(azureEnvBootstrap.InstanceTypesProvider, azureEnvBootstrap.VMInstanceProvider, azureEnvBootstrap.AKSMachineProvider, events.NewRecorder(&record.FakeRecorder{}), env.Client, azureEnvBootstrap.ImageProvider, azureEnv.InstanceTypeStore, azureEnvBootstrap.SpotEvictionsCache, azureEnvBootstrap.QuotaProvider)
 
 	cluster = state.NewCluster(fakeClock, env.Client, cloudProvider)
 	clusterNonZonal = state.NewCluster(fakeClock, env.Client, cloudProviderNonZonal)
@@ -1258,6 +1260,40 @@ var _ = Describe("InstanceType Provider", func() {
 					ContainSubstring("--kube-reserved=memory=1843Mi,cpu=100m"),
 				))
 			})
+			It("should pass the reserved resources and eviction thresholds of the node class to kubelet", func() {
+				nodeClass.Spec.Kubelet = &v1beta1.KubeletConfiguration{
+					KubeReserved:              map[string]string{"memory": "2Gi"},
+					SystemReserved:            map[string]string{"cpu": "200m"},
+					EvictionHard:              map[string]string{"memory.available": "1Gi"},
+					EvictionSoft:              map[string]string{"nodefs.available": "15%"},
+					EvictionSoftGracePeriod:   map[string]metav1.Duration{"nodefs.available": {Duration: 2 * time.Minute}},
+					EvictionMaxPodGracePeriod: lo.ToPtr[int32](60),
+				}
+
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisionedAndWaitForPromises(ctx, env.Client, cluster, cloudProvider, coreProvisioner, azureEnv, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+
+				customData := ExpectDecodedCustomData(azureEnv)
+
+				expectedFlags := map[string]string{
+					"eviction-hard":                 "memory.available<1Gi",
+					"eviction-soft":                 "nodefs.available<15%",
+					"eviction-soft-grace-period":    "nodefs.available=2m0s",
+					"eviction-max-pod-grace-period": "60",
+				}
+
+				ExpectKubeletFlags(azureEnv, customData, expectedFlags)
+				Expect(customData).To(SatisfyAny(
+					ContainSubstring("--system-reserved=cpu=200m,memory=0"),
+					ContainSubstring("--system-reserved=memory=0,cpu=200m"),
+				))
+				Expect(customData).To(SatisfyAny( // the cpu reserved by default is kept
+					ContainSubstring("--kube-reserved=cpu=100m,memory=2Gi"),
+					ContainSubstring("--kube-reserved=memory=2Gi,cpu=100m"),
+				))
+			})
 		})
 
 		Context("Nodepool with KubeletConfig on a kubenet Cluster", func() {
@@ -1385,7 +1421,7 @@ var _ = Describe("InstanceType Provider", func() {
 					UseSIG: lo.ToPtr(true),
 				})
 				ctx = options.ToContext(ctx)
-				statusController := status.NewController(env.Client, azureEnv.KubernetesVersionProvider, azureEnv.ImageProvider, env.KubernetesInterface, env.KubernetesInterface, azureEnv.DynamicInterface, azureEnv.SubnetsAPI, azureEnv.DiskEncryptionSetsAPI, options.ParsedDiskEncryptionSetID, options.NetworkPolicy, options.NetworkPlugin)
+				statusController := status.NewController(env.Client, azureEnv.KubernetesVersionProvider, azureEnv.ImageProvider, env.KubernetesInterface, env.KubernetesInterface, azureEnv.DynamicInterface, azureEnv.SubnetsAPI, azureEnv.ApplicationSecurityGroupsAPI, azureEnv.NetworkSecurityGroupAPI, azureEnv.DiskEncryptionSetsAPI, options.ParsedDiskEncryptionSetID, options.NetworkPolicy, options.NetworkPlugin, azureEnv.CapacityReservationProvider, azureEnv.SubnetCapacityProvider, azureEnv.PricingProvider)
 
 				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
 				ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
@@ -1434,7 +1470,7 @@ var _ = Describe("InstanceType Provider", func() {
 					UseSIG: lo.ToPtr(true),
 				})
 				ctx = options.ToContext(ctx)
-				statusController := status.NewController(env.Client, azureEnv.KubernetesVersionProvider, azureEnv.ImageProvider, env.KubernetesInterface, env.KubernetesInterface, azureEnv.DynamicInterface, azureEnv.SubnetsAPI, azureEnv.DiskEncryptionSetsAPI, options.ParsedDiskEncryptionSetID, options.NetworkPolicy, options.NetworkPlugin)
+				statusController := status.NewController(env.Client, azureEnv.KubernetesVersionProvider, azureEnv.ImageProvider, env.KubernetesInterface, env.KubernetesInterface, azureEnv.DynamicInterface, azureEnv.SubnetsAPI, azureEnv.ApplicationSecurityGroupsAPI, azureEnv.NetworkSecurityGroupAPI, azureEnv.DiskEncryptionSetsAPI, options.ParsedDiskEncryptionSetID, options.NetworkPolicy, options.NetworkPlugin, azureEnv.CapacityReservationProvider, azureEnv.SubnetCapacityProvider, azureEnv.PricingProvider)
 
 				nodeClass.Spec.ImageFamily = lo.ToPtr(imageFamily)
 				coretest.ReplaceRequirements(nodePool, karpv1.NodeSelectorRequirementWithMinValues{
@@ -1464,9 +1500,49 @@ var _ = Describe("InstanceType Provider", func() {
 				Entry("Gen1 instance type with AzureLinux image family", "Standard_D2_v3", v1beta1.AzureLinuxImageFamily, azureLinuxGen1ImageDefinition, imagefamily.AKSAzureLinuxResourceGroup, imagefamily.AKSAzureLinuxGalleryName),
 				Entry("ARM instance type with AzureLinux image family", "Standard_D16plds_v5", v1beta1.AzureLinuxImageFamily, azureLinuxGen2ArmImageDefinition, imagefamily.AKSAzureLinuxResourceGroup, imagefamily.AKSAzureLinuxGalleryName),
 			)
+			DescribeTable("should provision Windows nodes",
+				func(imageFamily string, expectedImageDefinition string) {
+					options := test.Options(test.OptionsFields{
+						UseSIG: lo.ToPtr(true),
+					})
+					ctx = options.ToContext(ctx)
+					statusController := status.NewController(env.Client, azureEnv.KubernetesVersionProvider, azureEnv.ImageProvider, env.KubernetesInterface, env.KubernetesInterface, azureEnv.DynamicInterface, azureEnv.SubnetsAPI, azureEnv.ApplicationSecurityGroupsAPI, azureEnv.NetworkSecurityGroupAPI, azureEnv.DiskEncryptionSetsAPI, options.ParsedDiskEncryptionSetID, options.NetworkPolicy, options.NetworkPlugin, azureEnv.CapacityReservationProvider, azureEnv.SubnetCapacityProvider, azureEnv.PricingProvider)
+
+					nodeClass.Spec.ImageFamily = lo.ToPtr(imageFamily)
+					coretest.ReplaceRequirements(nodePool, karpv1.NodeSelectorRequirementWithMinValues{
+						Key:      v1.LabelInstanceTypeStable,
+						Operator: v1.NodeSelectorOpIn,
+						Values:   []string{"Standard_D2_v5"}})
+
+					ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+					ExpectObjectReconciled(ctx, env.Client, statusController, nodeClass)
+					pod := coretest.UnschedulablePod(coretest.PodOptions{NodeSelector: map[string]string{v1.LabelOSStable: string(v1.Windows)}})
+					ExpectProvisionedAndWaitForPromises(ctx, env.Client, cluster, cloudProvider, coreProvisioner, azureEnv, pod)
+					node := ExpectScheduled(ctx, env.Client, pod)
+					Expect(node.Labels).To(HaveKeyWithValue(v1.LabelOSStable, string(v1.Windows)))
+					Expect(node.Labels).To(HaveKey(v1.LabelWindowsBuild))
+
+					Expect(azureEnv.VirtualMachinesAPI.VirtualMachineCreateOrUpdateBehavior.CalledWithInput.Len()).To(Equal(1))
+					vm := azureEnv.VirtualMachinesAPI.VirtualMachineCreateOrUpdateBehavior.CalledWithInput.Pop().VM
+					Expect(*vm.Properties.StorageProfile.ImageReference.ID).To(ContainSubstring(fmt.Sprintf("/galleries/%s/images/%s/", imagefamily.AKSWindowsGalleryName, expectedImageDefinition)))
+					Expect(vm.Properties.OSProfile.WindowsConfiguration).ToNot(BeNil())
+					Expect(vm.Properties.OSProfile.LinuxConfiguration).To(BeNil())
+					Expect(len(lo.FromPtr(vm.Properties.OSProfile.ComputerName))).To(BeNumerically("<=", 15))
+					Expect(vm.Tags).To(HaveKeyWithValue(launchtemplate.BillingTagKey, lo.ToPtr(launchtemplate.BillingTagValueWindows)))
+
+					// Windows doesn't run custom data by itself, so a CSE must be created to bootstrap the node
+					extensionNames := []string{}
+					for azureEnv.VirtualMachineExtensionsAPI.VirtualMachineExtensionsCreateOrUpdateBehavior.CalledWithInput.Len() > 0 {
+						extensionNames = append(extensionNames, azureEnv.VirtualMachineExtensionsAPI.VirtualMachineExtensionsCreateOrUpdateBehavior.CalledWithInput.Pop().VirtualMachineExtensionName)
+					}
+					Expect(extensionNames).To(ContainElements("windows-cse-agent-karpenter", "computeAksWindowsBilling"))
+				},
+				Entry("Windows2022", v1beta1.Windows2022ImageFamily, imagefamily.Windows2022Gen2ImageDefinition),
+				Entry("Windows2025", v1beta1.Windows2025ImageFamily, imagefamily.Windows2025Gen2ImageDefinition),
+			)
 			DescribeTable("should select the right image for a given instance type",
 				func(instanceType string, imageFamily string, expectedImageDefinition string, expectedGalleryURL string) {
-					statusController := status.NewController(env.Client, azureEnv.KubernetesVersionProvider, azureEnv.ImageProvider, env.KubernetesInterface, env.KubernetesInterface, azureEnv.DynamicInterface, azureEnv.SubnetsAPI, azureEnv.DiskEncryptionSetsAPI, testOptions.ParsedDiskEncryptionSetID, options.FromContext(ctx).NetworkPolicy, options.FromContext(ctx).NetworkPlugin)
+					statusController := status.NewController(env.Client, azureEnv.KubernetesVersionProvider, azureEnv.ImageProvider, env.KubernetesInterface, env.KubernetesInterface, azureEnv.DynamicInterface, azureEnv.SubnetsAPI, azureEnv.ApplicationSecurityGroupsAPI, azureEnv.NetworkSecurityGroupAPI, azureEnv.DiskEncryptionSetsAPI, testOptions.ParsedDiskEncryptionSetID, options.FromContext(ctx).NetworkPolicy, options.FromContext(ctx).NetworkPlugin, azureEnv.CapacityReservationProvider, azureEnv.SubnetCapacityProvider, azureEnv.PricingProvider)
 					nodeClass.Spec.ImageFamily = lo.ToPtr(imageFamily)
 					coretest.ReplaceRequirements(nodePool, karpv1.NodeSelectorRequirementWithMinValues{
 						Key:      v1.LabelInstanceTypeStable,
@@ -2000,7 +2076,7 @@ var _ = Describe("InstanceType Provider", func() {
 
 			It("should return error when instance type resolution fails", func() {
 				// Create and set up the status controller
-				statusController := status.NewController(env.Client, azureEnv.KubernetesVersionProvider, azureEnv.ImageProvider, env.KubernetesInterface, env.KubernetesInterface, azureEnv.DynamicInterface, azureEnv.SubnetsAPI, azureEnv.DiskEncryptionSetsAPI, testOptions.ParsedDiskEncryptionSetID, options.FromContext(ctx).NetworkPolicy, options.FromContext(ctx).NetworkPlugin)
+				statusController := status.NewController(env.Client, azureEnv.KubernetesVersionProvider, azureEnv.ImageProvider, env.KubernetesInterface, env.KubernetesInterface, azureEnv.DynamicInterface, azureEnv.SubnetsAPI, azureEnv.ApplicationSecurityGroupsAPI, azureEnv.NetworkSecurityGroupAPI, azureEnv.DiskEncryptionSetsAPI, testOptions.ParsedDiskEncryptionSetID, options.FromContext(ctx).NetworkPolicy, options.FromContext(ctx).NetworkPlugin, azureEnv.CapacityReservationProvider, azureEnv.SubnetCapacityProvider, azureEnv.PricingProvider)
 
 				// Set NodeClass to Ready
 				nodeClass.StatusConditions().SetTrue(karpv1.ConditionTypeLaunched)
@@ -2363,6 +2439,260 @@ var _ = Describe("InstanceType Provider", func() {
 			})
 		})
 
+		Context("Filtering for Windows", func() {
+			var instanceTypes corecloudprovider.InstanceTypes
+			var err error
+
+			BeforeEach(func() {
+				nodeClassWindows := test.AKSNodeClass()
+				nodeClassWindows.Spec.ImageFamily = lo.ToPtr(v1beta1.Windows2022ImageFamily)
+				ExpectApplied(ctx, env.Client, nodeClassWindows)
+				instanceTypes, err = azureEnv.InstanceTypesProvider.List(ctx, nodeClassWindows)
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("should require the windows OS and build on every instance type", func() {
+				Expect(instanceTypes).ToNot(BeEmpty())
+				for _, instanceType := range instanceTypes {
+					Expect(instanceType.Requirements.Get(v1.LabelOSStable).Values()).To(ConsistOf(string(v1.Windows)))
+					Expect(instanceType.Requirements.Get(v1.LabelWindowsBuild).Values()).To(ConsistOf("10.0.20348"))
+				}
+			})
+			It("should not include ARM64 instance types", func() {
+				for _, instanceType := range instanceTypes {
+					Expect(instanceType.Requirements.Get(v1.LabelArchStable).Values()).To(ConsistOf(karpv1.ArchitectureAmd64))
+				}
+			})
+		})
+
+		Context("Placement constraints", func() {
+			isSpot := func(o *corecloudprovider.Offering) bool {
+				return o.Requirements.Get(karpv1.CapacityTypeLabelKey).Has(karpv1.CapacityTypeSpot)
+			}
+
+			It("should not have spot offerings available on dedicated hosts", func() {
+				nodeClassWithHost := test.AKSNodeClass()
+				nodeClassWithHost.Spec.Placement = &v1beta1.Placement{
+					HostGroupID: lo.ToPtr("/subscriptions/subscriptionID/resourceGroups/hosts/providers/Microsoft.Compute/hostGroups/hg"),
+				}
+				ExpectApplied(ctx, env.Client, nodeClassWithHost)
+				instanceTypes, err := azureEnv.InstanceTypesProvider.List(ctx, nodeClassWithHost)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(instanceTypes).ToNot(BeEmpty())
+				for _, instanceType := range instanceTypes {
+					Expect(lo.Filter(instanceType.Offerings.Available(), func(o *corecloudprovider.Offering, _ int) bool { return isSpot(o) })).To(BeEmpty())
+				}
+			})
+			It("should only exclude offerings unavailable within the proximity placement group for the same group", func() {
+				nodeClassWithPPG := test.AKSNodeClass()
+				nodeClassWithPPG.Spec.Placement = &v1beta1.Placement{
+					ProximityPlacementGroupID: lo.ToPtr("/subscriptions/subscriptionID/resourceGroups/ppgs/providers/Microsoft.Compute/proximityPlacementGroups/ppg"),
+				}
+				ExpectApplied(ctx, env.Client, nodeClassWithPPG)
+				zone := fmt.Sprintf("%s-1", fake.Region)
+				azureEnv.UnavailableOfferingsCache.MarkUnavailableForPlacement(ctx, "test", "OverconstrainedAllocationRequest", nodeClassWithPPG.PlacementKey(), "Standard_D2_v2", zone, karpv1.CapacityTypeOnDemand, time.Minute)
+
+				isUnavailableOnDemandInZone := func(instanceTypes corecloudprovider.InstanceTypes) bool {
+					instanceType, ok := lo.Find(instanceTypes, func(i *corecloudprovider.InstanceType) bool { return i.Name == "Standard_D2_v2" })
+					Expect(ok).To(BeTrue())
+					return lo.ContainsBy(instanceType.Offerings, func(o *corecloudprovider.Offering) bool {
+						return !isSpot(o) && o.Requirements.Get(v1.LabelTopologyZone).Any() == zone && !o.Available
+					})
+				}
+				withPPG, err := azureEnv.InstanceTypesProvider.List(ctx, nodeClassWithPPG)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(isUnavailableOnDemandInZone(withPPG)).To(BeTrue())
+				withoutPPG, err := azureEnv.InstanceTypesProvider.List(ctx, nodeClass)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(isUnavailableOnDemandInZone(withoutPPG)).To(BeFalse())
+			})
+		})
+
+		Context("Spot max price", func() {
+			hasSpotOffering := func(instanceType *corecloudprovider.InstanceType) bool {
+				return lo.ContainsBy(instanceType.Offerings, func(o *corecloudprovider.Offering) bool {
+					return o.Requirements.Get(karpv1.CapacityTypeLabelKey).Has(karpv1.CapacityTypeSpot)
+				})
+			}
+
+			It("should drop the spot offerings priced above the max price", func() {
+				nodeClassWithMaxPrice := test.AKSNodeClass()
+				nodeClassWithMaxPrice.Spec.Spot = &v1beta1.Spot{MaxPrice: lo.ToPtr("0.1")}
+				ExpectApplied(ctx, env.Client, nodeClassWithMaxPrice)
+				instanceTypes, err := azureEnv.InstanceTypesProvider.List(ctx, nodeClassWithMaxPrice)
+				Expect(err).ToNot(HaveOccurred())
+
+				var dropped, kept int
+				for _, instanceType := range instanceTypes {
+					spotPrice, ok := azureEnv.PricingProvider.RetailSpotPrice(instanceType.Name)
+					Expect(hasSpotOffering(instanceType)).To(Equal(!ok || spotPrice <= 0.1), instanceType.Name)
+					if hasSpotOffering(instanceType) {
+						kept++
+					} else {
+						dropped++
+					}
+				}
+				Expect(dropped).To(BeNumerically(">", 0))
+				Expect(kept).To(BeNumerically(">", 0))
+			})
+			It("should drop the spot offerings priced above a percentage of the on-demand price", func() {
+				nodeClassWithMaxPrice := test.AKSNodeClass()
+				nodeClassWithMaxPrice.Spec.Spot = &v1beta1.Spot{MaxPricePercentOfOnDemand: lo.ToPtr(int32(50))}
+				ExpectApplied(ctx, env.Client, nodeClassWithMaxPrice)
+				instanceTypes, err := azureEnv.InstanceTypesProvider.List(ctx, nodeClassWithMaxPrice)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(instanceTypes).ToNot(BeEmpty())
+
+				for _, instanceType := range instanceTypes {
+					maxPrice, ok := azureEnv.InstanceTypesProvider.SpotMaxPrice(nodeClassWithMaxPrice, instanceType.Name)
+					onDemandPrice, onDemandPriceKnown := azureEnv.PricingProvider.RetailOnDemandPrice(instanceType.Name)
+					Expect(ok).To(Equal(onDemandPriceKnown))
+					if !ok {
+						// the max price can't be determined without the on-demand price
+						Expect(hasSpotOffering(instanceType)).To(BeFalse(), instanceType.Name)
+						continue
+					}
+					Expect(maxPrice).To(BeNumerically("~", onDemandPrice/2, 0.00001))
+					spotPrice, spotPriceKnown := azureEnv.PricingProvider.RetailSpotPrice(instanceType.Name)
+					Expect(hasSpotOffering(instanceType)).To(Equal(!spotPriceKnown || spotPrice <= maxPrice), instanceType.Name)
+				}
+			})
+			It("should compare the max price with the retail prices, regardless of the pricing overrides", func() {
+				azureEnv.PricingProvider.SetOverrides(&pricing.Overrides{Default: &pricing.Override{SpotDiscountPercent: lo.ToPtr(90.0)}})
+				nodeClassWithMaxPrice := test.AKSNodeClass()
+				nodeClassWithMaxPrice.Spec.Spot = &v1beta1.Spot{MaxPrice: lo.ToPtr("0.1")}
+				ExpectApplied(ctx, env.Client, nodeClassWithMaxPrice)
+				instanceTypes, err := azureEnv.InstanceTypesProvider.List(ctx, nodeClassWithMaxPrice)
+				Expect(err).ToNot(HaveOccurred())
+				for _, instanceType := range instanceTypes {
+					spotPrice, ok := azureEnv.PricingProvider.RetailSpotPrice(instanceType.Name)
+					Expect(hasSpotOffering(instanceType)).To(Equal(!ok || spotPrice <= 0.1), instanceType.Name)
+				}
+			})
+			It("should keep the spot offerings without a max price", func() {
+				instanceTypes, err := azureEnv.InstanceTypesProvider.List(ctx, nodeClass)
+				Expect(err).ToNot(HaveOccurred())
+				for _, instanceType := range instanceTypes {
+					Expect(hasSpotOffering(instanceType)).To(BeTrue(), instanceType.Name)
+				}
+				maxPrice, ok := azureEnv.InstanceTypesProvider.SpotMaxPrice(nodeClass, "Standard_D2_v2")
+				Expect(ok).To(BeTrue())
+				Expect(maxPrice).To(Equal(float64(-1)))
+			})
+		})
+		Context("Pricing overrides", func() {
+			offeringPrice := func(instanceTypes []*corecloudprovider.InstanceType, name string, capacityType string) float64 {
+				GinkgoHelper()
+				instanceType, ok := lo.Find(instanceTypes, func(it *corecloudprovider.InstanceType) bool { return it.Name == name })
+				Expect(ok).To(BeTrue(), name)
+				offering, ok := lo.Find(instanceType.Offerings, func(o *corecloudprovider.Offering) bool {
+					return o.Requirements.Get(karpv1.CapacityTypeLabelKey).Has(capacityType)
+				})
+				Expect(ok).To(BeTrue(), name)
+				return offering.Price
+			}
+
+			It("should price the offerings with the pricing overrides", func() {
+				instanceTypes, err := azureEnv.InstanceTypesProvider.List(ctx, nodeClass)
+				Expect(err).ToNot(HaveOccurred())
+				onDemandPrice := offeringPrice(instanceTypes, "Standard_D2s_v3", karpv1.CapacityTypeOnDemand)
+				spotPrice := offeringPrice(instanceTypes, "Standard_D2s_v3", karpv1.CapacityTypeSpot)
+				otherOnDemandPrice := offeringPrice(instanceTypes, "Standard_D4s_v3", karpv1.CapacityTypeOnDemand)
+
+				// the cached instance types are invalidated when the overrides change
+				Expect(azureEnv.PricingProvider.SetOverrides(&pricing.Overrides{
+					Default: &pricing.Override{OnDemandDiscountPercent: lo.ToPtr(10.0)},
+					SKUs: map[string]pricing.Override{
+						"Standard_D2s_v3": {ReservedInstanceCoveragePercent: lo.ToPtr(100.0), ReservedInstanceDiscountPercent: lo.ToPtr(50.0)},
+					},
+				})).To(BeTrue())
+				instanceTypes, err = azureEnv.InstanceTypesProvider.List(ctx, nodeClass)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(offeringPrice(instanceTypes, "Standard_D2s_v3", karpv1.CapacityTypeOnDemand)).To(BeNumerically("~", onDemandPrice*0.9*0.5, 1e-9))
+				Expect(offeringPrice(instanceTypes, "Standard_D2s_v3", karpv1.CapacityTypeSpot)).To(BeNumerically("~", spotPrice, 1e-9))
+				Expect(offeringPrice(instanceTypes, "Standard_D4s_v3", karpv1.CapacityTypeOnDemand)).To(BeNumerically("~", otherOnDemandPrice*0.9, 1e-9))
+			})
+			It("should apply the overrides of the VM family", func() {
+				instanceTypes, err := azureEnv.InstanceTypesProvider.List(ctx, nodeClass)
+				Expect(err).ToNot(HaveOccurred())
+				onDemandPrice := offeringPrice(instanceTypes, "Standard_D2s_v3", karpv1.CapacityTypeOnDemand)
+
+				Expect(azureEnv.PricingProvider.SetOverrides(&pricing.Overrides{
+					Families: map[string]pricing.Override{"standardDSv3Family": {OnDemandDiscountPercent: lo.ToPtr(20.0)}},
+				})).To(BeTrue())
+				instanceTypes, err = azureEnv.InstanceTypesProvider.List(ctx, nodeClass)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(offeringPrice(instanceTypes, "Standard_D2s_v3", karpv1.CapacityTypeOnDemand)).To(BeNumerically("~", onDemandPrice*0.8, 1e-9))
+			})
+		})
+
+		Context("Data disks", func() {
+			It("should not have regional offerings available for Premium SSD v2 disks", func() {
+				nodeClassWithDisks := test.AKSNodeClass()
+				nodeClassWithDisks.Spec.DataDisks = []v1beta1.DataDisk{{SizeGB: 128, SKU: lo.ToPtr(v1beta1.DataDiskSKUPremiumV2LRS), MountPath: "/data"}}
+				ExpectApplied(ctx, env.Client, nodeClassWithDisks)
+				instanceTypes, err := azureEnv.InstanceTypesProvider.List(ctx, nodeClassWithDisks)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(instanceTypes).ToNot(BeEmpty())
+				for _, instanceType := range instanceTypes {
+					for _, offering := range instanceType.Offerings.Available() {
+						Expect(offering.Requirements.Get(v1.LabelTopologyZone).Any()).ToNot(Equal(zones.Regional))
+					}
+				}
+			})
+			It("should only have offerings supporting Ultra SSD available for Ultra Disks", func() {
+				nodeClassWithDisks := test.AKSNodeClass()
+				nodeClassWithDisks.Spec.DataDisks = []v1beta1.DataDisk{{SizeGB: 128, SKU: lo.ToPtr(v1beta1.DataDiskSKUUltraSSDLRS), MountPath: "/data"}}
+				ExpectApplied(ctx, env.Client, nodeClassWithDisks)
+				instanceTypes, err := azureEnv.InstanceTypesProvider.List(ctx, nodeClassWithDisks)
+				Expect(err).ToNot(HaveOccurred())
+				for _, instanceType := range instanceTypes {
+					for _, offering := range instanceType.Offerings {
+						Expect(offering.Requirements.Get(v1beta1.LabelUltraSSD).Values()).To(ConsistOf("true"))
+					}
+					for _, offering := range instanceType.Offerings.Available() {
+						Expect(offering.Requirements.Get(v1.LabelTopologyZone).Any()).ToNot(Equal(zones.Regional))
+					}
+				}
+			})
+		})
+
+		Context("Kubelet reservations", func() {
+			It("should reduce allocatable by the reserved resources and eviction thresholds of the node class", func() {
+				nodeClassWithReservations := test.AKSNodeClass()
+				nodeClassWithReservations.Spec.Kubelet = &v1beta1.KubeletConfiguration{
+					KubeReserved:            map[string]string{"cpu": "500m", "pid": "1000"},
+					SystemReserved:          map[string]string{"memory": "1Gi"},
+					EvictionHard:            map[string]string{"memory.available": "5%"},
+					EvictionSoft:            map[string]string{"memory.available": "1Gi", "nodefs.available": "10%"},
+					EvictionSoftGracePeriod: map[string]metav1.Duration{"memory.available": {Duration: time.Minute}, "nodefs.available": {Duration: time.Minute}},
+				}
+				ExpectApplied(ctx, env.Client, nodeClassWithReservations)
+				instanceTypes, err := azureEnv.InstanceTypesProvider.List(ctx, nodeClassWithReservations)
+				Expect(err).ToNot(HaveOccurred())
+				instanceType, ok := lo.Find(instanceTypes, func(i *corecloudprovider.InstanceType) bool { return i.Name == "Standard_D2_v2" })
+				Expect(ok).To(BeTrue())
+
+				Expect(instanceType.Overhead.KubeReserved.Cpu().String()).To(Equal("500m"))
+				// the memory reserved by default is kept
+				Expect(instanceType.Overhead.KubeReserved.Memory().String()).To(Equal("1638Mi"))
+				Expect(instanceType.Overhead.KubeReserved).ToNot(HaveKey(v1.ResourceName("pid")))
+				Expect(instanceType.Overhead.SystemReserved.Memory().String()).To(Equal("1Gi"))
+				// the largest of the hard and soft thresholds
+				Expect(instanceType.Overhead.EvictionThreshold.Memory().String()).To(Equal("1Gi"))
+				storage := instanceType.Capacity[v1.ResourceEphemeralStorage]
+				Expect(instanceType.Overhead.EvictionThreshold.StorageEphemeral().Value()).To(Equal(int64(math.Ceil(storage.AsApproximateFloat64() / 10))))
+				Expect(instanceType.Allocatable().Cpu().String()).To(Equal("1500m"))
+
+				defaultInstanceTypes, err := azureEnv.InstanceTypesProvider.List(ctx, nodeClass)
+				Expect(err).ToNot(HaveOccurred())
+				defaultInstanceType, ok := lo.Find(defaultInstanceTypes, func(i *corecloudprovider.InstanceType) bool { return i.Name == "Standard_D2_v2" })
+				Expect(ok).To(BeTrue())
+				Expect(defaultInstanceType.Overhead.KubeReserved.Cpu().String()).To(Equal("100m"))
+				Expect(defaultInstanceType.Overhead.EvictionThreshold.Memory().String()).To(Equal(instancetype.DefaultMemoryAvailable))
+			})
+		})
+
 		Context("Filtering by GPU Driver Mode", func() {
 			var instanceTypes corecloudprovider.InstanceTypes
 			var err error
@@ -3420,14 +3750,42 @@ var _ = Describe("InstanceType Provider", func() {
 })
 
 var _ = Describe("Tax Calculator", func() {
+	Context("EvictionThreshold", func() {
+		It("should default to the memory.available threshold of AKS", func() {
+			threshold := instancetype.EvictionThreshold(resource.NewQuantity(8*1024*1024*1024, resource.BinarySI), resource.NewScaledQuantity(128, resource.Giga), nil, nil)
+			Expect(threshold.Memory().String()).To(Equal("750Mi"))
+			Expect(threshold).ToNot(HaveKey(v1.ResourceEphemeralStorage))
+		})
+		It("should use the largest of the hard and soft thresholds, percentages being relative to capacity", func() {
+			threshold := instancetype.EvictionThreshold(
+				resource.NewQuantity(8*1024*1024*1024, resource.BinarySI),
+				resource.NewScaledQuantity(128, resource.Giga),
+				map[string]string{"memory.available": "100Mi", "nodefs.available": "10%"},
+				map[string]string{"memory.available": "25%", "nodefs.available": "1Gi"},
+			)
+			Expect(threshold.Memory().String()).To(Equal("2Gi"))
+			Expect(threshold.StorageEphemeral().Value()).To(Equal(int64(12800000000)))
+		})
+	})
+
 	Context("KubeReservedResources", func() {
+		It("should replace the computed reservations with the overrides", func() {
+			resources := instancetype.KubeReservedResources(4, 7.0, map[string]string{"memory": "1Gi", "pid": "100"})
+			gotCPU := resources[v1.ResourceCPU]
+			gotMemory := resources[v1.ResourceMemory]
+
+			Expect(gotCPU.String()).To(Equal("140m"))
+			Expect(gotMemory.String()).To(Equal("1Gi"))
+			Expect(resources).ToNot(HaveKey(v1.ResourceName("pid")))
+		})
+
 		It("should have 4 cores, 7GiB", func() {
 			cpus := int64(4) // 4 cores
 			memory := 7.0    // 7 GiB
 			expectedCPU := "140m"
 			expectedMemory := "1638Mi"
 
-			resources := instancetype.KubeReservedResources(cpus, memory)
+			resources := instancetype.KubeReservedResources(cpus, memory, nil)
 			gotCPU := resources[v1.ResourceCPU]
 			gotMemory := resources[v1.ResourceMemory]
 
@@ -3441,7 +3799,7 @@ var _ = Describe("Tax Calculator", func() {
 			expectedCPU := "100m"
 			expectedMemory := "1843Mi"
 
-			resources := instancetype.KubeReservedResources(cpus, memory)
+			resources := instancetype.KubeReservedResources(cpus, memory, nil)
 			gotCPU := resources[v1.ResourceCPU]
 			gotMemory := resources[v1.ResourceMemory]
 
@@ -3455,7 +3813,7 @@ var _ = Describe("Tax Calculator", func() {
 			expectedCPU := "120m"
 			expectedMemory := "5611Mi"
 
-			resources := instancetype.KubeReservedResources(cpus, memory)
+			resources := instancetype.KubeReservedResources(cpus, memory, nil)
 			gotCPU := resources[v1.ResourceCPU]
 			gotMemory := resources[v1.ResourceMemory]
 
@@ -84,16 +84,16 @@ const (
 	LabelArch = "beta.kubernetes.io/arch"
 )
 
+// Get returns the labels of nodes launched from the AKSNodeClass into the subnet
 func Get(
 	ctx context.Context,
 	nodeClass *v1beta1.AKSNodeClass,
 	arch string,
+	subnetID string,
 ) (map[string]string, error) {
 	labels := map[string]string{}
 	opts := options.FromContext(ctx)
 
-	subnetID := lo.Ternary(nodeClass.Spec.VNETSubnetID != nil, lo.FromPtr(nodeClass.Spec.VNETSubnetID), opts.SubnetID)
-
 	kubernetesVersion, err := nodeClass.GetKubernetesVersion()
 	if err != nil {
 		return nil, err
@@ -18,10 +18,13 @@ package launchtemplate
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
+	"strings"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v7"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/imagefamily"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/imagefamily/bootstrap"
 	karplabels "github.com/Azure/karpenter-provider-azure/pkg/providers/labels"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/launchtemplate/parameters"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/networksecuritygroup"
@@ -30,6 +33,7 @@ import (
 	v1 "k8s.io/api/core/v1"
 
 	"github.com/Azure/karpenter-provider-azure/pkg/apis/v1beta1"
+	"github.com/Azure/karpenter-provider-azure/pkg/auth"
 	"github.com/Azure/karpenter-provider-azure/pkg/consts"
 	"github.com/Azure/karpenter-provider-azure/pkg/operator/options"
 	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
@@ -42,6 +46,7 @@ import (
 // Refactoring for code unification is not being invested immediately.
 type Template struct {
 	ScriptlessCustomData      string
+	ScriptlessCSE             string // Only set for Windows, which needs a CSE to run the custom data
 	ImageID                   string
 	SubnetID                  string
 	Tags                      map[string]*string
@@ -52,6 +57,8 @@ type Template struct {
 	StorageProfileIsEphemeral bool
 	StorageProfilePlacement   armcompute.DiffDiskPlacement
 	StorageProfileSizeGB      int32
+	// DataDisks are attached to the VM in order, the index of each disk being its LUN
+	DataDisks []v1beta1.DataDisk
 }
 
 type Provider struct {
@@ -67,6 +74,7 @@ type Provider struct {
 	clusterResourceGroup    string
 	location                string
 	provisionMode           string
+	env                     *auth.Environment
 }
 
 // TODO: add caching of launch templates
@@ -87,6 +95,7 @@ func NewProvider(
 	resourceGroup,
 	location,
 	provisionMode string,
+	env *auth.Environment,
 ) *Provider {
 	return &Provider{
 		imageFamily:             imageFamily,
@@ -101,6 +110,7 @@ func NewProvider(
 		clusterResourceGroup:    clusterResourceGroup,
 		location:                location,
 		provisionMode:           provisionMode,
+		env:                     env,
 	}
 }
 
@@ -111,9 +121,10 @@ func (p *Provider) GetTemplate(
 	nodeClass *v1beta1.AKSNodeClass,
 	nodeClaim *karpv1.NodeClaim,
 	instanceType *cloudprovider.InstanceType,
+	subnetID string,
 	additionalLabels map[string]string,
 ) (*Template, error) {
-	staticParameters, err := p.getStaticParameters(ctx, instanceType, nodeClass, lo.Assign(nodeClaim.Labels, additionalLabels))
+	staticParameters, err := p.getStaticParameters(ctx, instanceType, nodeClass, subnetID, lo.Assign(nodeClaim.Labels, additionalLabels))
 	if err != nil {
 		return nil, err
 	}
@@ -134,6 +145,7 @@ func (p *Provider) GetTemplate(
 	}
 
 	launchTemplate.Tags = Tags(options.FromContext(ctx), nodeClass, nodeClaim)
+	launchTemplate.DataDisks = nodeClass.Spec.DataDisks
 
 	return launchTemplate, nil
 }
@@ -144,6 +156,7 @@ func (p *Provider) getStaticParameters(
 	ctx context.Context,
 	instanceType *cloudprovider.InstanceType,
 	nodeClass *v1beta1.AKSNodeClass,
+	subnetID string,
 	labels map[string]string,
 ) (*parameters.StaticParameters, error) {
 	var arch = karpv1.ArchitectureAmd64
@@ -151,8 +164,7 @@ func (p *Provider) getStaticParameters(
 		arch = karpv1.ArchitectureArm64
 	}
 
-	subnetID := lo.Ternary(nodeClass.Spec.VNETSubnetID != nil, lo.FromPtr(nodeClass.Spec.VNETSubnetID), options.FromContext(ctx).SubnetID)
-	baseLabels, err := karplabels.Get(ctx, nodeClass, arch)
+	baseLabels, err := karplabels.Get(ctx, nodeClass, arch, subnetID)
 	if err != nil {
 		return nil, err
 	}
@@ -196,8 +208,17 @@ func (p *Provider) getStaticParameters(
 		KubeletClientTLSBootstrapToken: options.FromContext(ctx).KubeletClientTLSBootstrapToken,
 		NetworkPlugin:                  getAgentbakerNetworkPlugin(ctx),
 		NetworkPolicy:                  options.FromContext(ctx).NetworkPolicy,
+		IPv6DualStackEnabled:           options.FromContext(ctx).IPv6DualStackEnabled,
 		SubnetID:                       subnetID,
+		PodSubnetID:                    lo.FromPtr(nodeClass.Spec.PodSubnetID),
 		ClusterResourceGroup:           p.clusterResourceGroup,
+		TargetEnvironment:              p.env.Name(),
+		DataDisks: lo.Map(nodeClass.Spec.DataDisks, func(dataDisk v1beta1.DataDisk, lun int) bootstrap.DataDisk {
+			return bootstrap.DataDisk{LUN: int32(lun), MountPath: dataDisk.MountPath} //nolint:gosec // G115: bounded by the CRD
+		}),
+		CloudConfig:         nodeClass.GetCloudConfig(),
+		PreBootstrapScript:  nodeClass.GetPreBootstrapScript(),
+		PostBootstrapScript: nodeClass.GetPostBootstrapScript(),
 	}, nil
 }
 
@@ -228,15 +249,45 @@ func (p *Provider) createLaunchTemplate(ctx context.Context, params *parameters.
 		if err != nil {
 			return nil, err
 		}
-		template.CustomScriptsCustomData = customData
+		if template.CustomScriptsCustomData, err = bootstrap.MergeCloudConfig(customData, params.CloudConfig); err != nil {
+			return nil, err
+		}
+		if err := bootstrap.ValidateCustomDataSize(template.CustomScriptsCustomData); err != nil {
+			return nil, err
+		}
 		template.CustomScriptsCSE = cse
+		// The node bootstrapping API doesn't know about data disks, nor about the user data scripts, so they run around the CSE it returns
+		if !params.IsWindows {
+			commands := []string{}
+			if len(params.DataDisks) > 0 {
+				dataDisksScript, err := bootstrap.DataDisksScript(params.DataDisks)
+				if err != nil {
+					return nil, err
+				}
+				commands = append(commands, fmt.Sprintf("echo %s | base64 -d | /bin/bash", base64.StdEncoding.EncodeToString([]byte(dataDisksScript))))
+			}
+			commands = append(commands, bootstrap.HookCommand(bootstrap.HookPreBootstrap, params.PreBootstrapScript), cse, bootstrap.HookCommand(bootstrap.HookPostBootstrap, params.PostBootstrapScript))
+			template.CustomScriptsCSE = strings.Join(lo.Compact(commands), " && ")
+		}
 	case consts.ProvisionModeAKSScriptless:
 		// render user data
 		userData, err := params.ScriptlessCustomData.Script()
 		if err != nil {
 			return nil, err
 		}
-		template.ScriptlessCustomData = userData
+		if template.ScriptlessCustomData, err = bootstrap.MergeCloudConfig(userData, params.CloudConfig); err != nil {
+			return nil, err
+		}
+		if err := bootstrap.ValidateCustomDataSize(template.ScriptlessCustomData); err != nil {
+			return nil, err
+		}
+		if windowsBootstrapper, ok := params.ScriptlessCustomData.(bootstrap.WindowsBootstrapper); ok {
+			cse, err := windowsBootstrapper.CSE()
+			if err != nil {
+				return nil, err
+			}
+			template.ScriptlessCSE = cse
+		}
 	}
 
 	return template, nil
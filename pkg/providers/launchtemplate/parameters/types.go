@@ -46,9 +46,20 @@ type StaticParameters struct {
 	KubeletClientTLSBootstrapToken string
 	NetworkPlugin                  string
 	NetworkPolicy                  string
+	IPv6DualStackEnabled           bool
 	KubernetesVersion              string
 	SubnetID                       string
-	ClusterResourceGroup           string
+	// PodSubnetID is the subnet pods get their IPs from with Azure CNI dynamic IP allocation, if any
+	PodSubnetID          string
+	ClusterResourceGroup string
+	// TargetEnvironment is the name of the Azure environment nodes are provisioned in, e.g. AzurePublicCloud
+	TargetEnvironment string
+	// DataDisks are the data disks to mount on the node, in LUN order
+	DataDisks []bootstrap.DataDisk
+	// CloudConfig, PreBootstrapScript and PostBootstrapScript are the user data of the AKSNodeClass
+	CloudConfig         string
+	PreBootstrapScript  string
+	PostBootstrapScript string
 
 	Labels map[string]string
 }
@@ -31,6 +31,7 @@ const (
 	KarpenterAKSMachineNodeClaimTagKey = "karpenter.azure.com_aksmachine_nodeclaim"
 	BillingTagKey                      = "compute.aks.billing"
 	BillingTagValueLinux               = "linux"
+	BillingTagValueWindows             = "windows"
 )
 
 var (
@@ -46,7 +47,7 @@ func Tags(
 ) map[string]*string {
 	defaultTags := map[string]string{
 		KarpenterManagedTagKey: options.ClusterName,
-		BillingTagKey:          BillingTagValueLinux,
+		BillingTagKey:          lo.Ternary(nodeClass.IsWindows(), BillingTagValueWindows, BillingTagValueLinux),
 	}
 	// Note: Be careful depending on nodeClaim.Labels here, as we assign some additional labels during the creation
 	// of the static parameters for the launch template. Those labels haven't actually been applied to the nodeClaim yet,
@@ -37,6 +37,11 @@ const (
 	// when the pod launches/Karpenter is created. We query it as an optimization to save cloudprovider work, as otherwise cloudprovider must edit
 	// ever VM we deploy to include this LB.
 	InternalSLBName = "kubernetes-internal"
+	// InternalSLBNameIPv6 is the name of the internal SLB created by cloudprovider for IPv6 Services in dual-stack clusters
+	InternalSLBNameIPv6 = "kubernetes-internal-ipv6"
+
+	// ipv6Suffix is the suffix cloudprovider and AKS RP give the names of IPv6 LBs and backend pools
+	ipv6Suffix = "-ipv6"
 
 	// SLBOutboundBackendPoolName is the AKS SLB outbound backend pool name
 	SLBOutboundBackendPoolName = "aksOutboundBackendPool"
@@ -69,7 +74,7 @@ type Provider struct {
 
 type BackendAddressPools struct {
 	IPv4PoolIDs []string
-	IPv6PoolIDs []string // TODO: This is always empty currently
+	IPv6PoolIDs []string
 
 	// generation is the generation of the LB list that was used to build this pool collection.
 	generation uint64
@@ -139,27 +144,36 @@ func (p *Provider) getLoadBalancersLocked(ctx context.Context) ([]*armnetwork.Lo
 // newPoolsLocked extracts pool IDs from LBs and tags the result with the current generation.
 // Must be called with p.mu held (reads p.generation).
 func (p *Provider) newPoolsLocked(ctx context.Context, loadBalancers []*armnetwork.LoadBalancer) *BackendAddressPools {
-	backendAddressPools := lo.FlatMap(loadBalancers, extractBackendAddressPools)
-	ipv4PoolIDs := lo.FilterMap(backendAddressPools, func(backendPool *armnetwork.BackendAddressPool, idx int) (string, bool) {
-		if !isBackendAddressPoolApplicable(backendPool, idx) {
-			return "", false
-		}
-		id := lo.FromPtr(backendPool.ID)
-		if id == "" {
-			return "", false
+	var ipv4PoolIDs, ipv6PoolIDs []string
+	for _, lb := range loadBalancers {
+		for _, backendPool := range extractBackendAddressPools(lb) {
+			if !isBackendAddressPoolApplicable(backendPool) {
+				continue
+			}
+			id := lo.FromPtr(backendPool.ID)
+			if id == "" {
+				continue
+			}
+			if isIPv6BackendAddressPool(lb, backendPool) {
+				ipv6PoolIDs = append(ipv6PoolIDs, id)
+			} else {
+				ipv4PoolIDs = append(ipv4PoolIDs, id)
+			}
 		}
-		return id, true
-	})
+	}
 
-	log.FromContext(ctx).V(1).Info("returning IPv4 backend pools", "ipv4PoolCount", len(ipv4PoolIDs), "ipv4PoolIDs", ipv4PoolIDs)
+	log.FromContext(ctx).V(1).Info("returning backend pools",
+		"ipv4PoolCount", len(ipv4PoolIDs), "ipv4PoolIDs", ipv4PoolIDs,
+		"ipv6PoolCount", len(ipv6PoolIDs), "ipv6PoolIDs", ipv6PoolIDs)
 
 	// RP only actually assigns the LB backend pools to VMs if OutboundType is LoadBalancer,
 	// but that's also the only OutboundType which creates the LoadBalancer, so as long as we're not allowing
 	// OutboundType changes, we can just infer that if the LBs exist we should assign them.
+	// IPv6 pools are only assigned to nodes of dual-stack clusters, and only ever onto the IPv6 ipconfig of the NIC.
 	return &BackendAddressPools{
 		IPv4PoolIDs: ipv4PoolIDs,
-		// TODO: IPv6 deferred for now. When they're used they must be put onto a non-primary NIC.
-		generation: p.generation,
+		IPv6PoolIDs: ipv6PoolIDs,
+		generation:  p.generation,
 	}
 }
 
@@ -185,10 +199,11 @@ func (p *Provider) loadFromAzure(ctx context.Context) ([]*armnetwork.LoadBalance
 
 func isClusterLoadBalancer(lb *armnetwork.LoadBalancer, _ int) bool {
 	name := lo.FromPtr(lb.Name)
-	return strings.EqualFold(name, SLBName) || strings.EqualFold(name, InternalSLBName) // TODO: Not currently supporting IPv6
+	return strings.EqualFold(name, SLBName) || strings.EqualFold(name, SLBNameIPv6) ||
+		strings.EqualFold(name, InternalSLBName) || strings.EqualFold(name, InternalSLBNameIPv6)
 }
 
-func extractBackendAddressPools(lb *armnetwork.LoadBalancer, _ int) []*armnetwork.BackendAddressPool {
+func extractBackendAddressPools(lb *armnetwork.LoadBalancer) []*armnetwork.BackendAddressPool {
 	if lb.Properties == nil {
 		return nil
 	}
@@ -196,17 +211,19 @@ func extractBackendAddressPools(lb *armnetwork.LoadBalancer, _ int) []*armnetwor
 	return lb.Properties.BackendAddressPools
 }
 
-func isBackendAddressPoolApplicable(backendPool *armnetwork.BackendAddressPool, _ int) bool {
+// isIPv6BackendAddressPool returns whether the backend pool is for IPv6 traffic, either because it is one of the well-known
+// IPv6 pools of a dual-stack LB, or because it belongs to a separate IPv6 LB.
+func isIPv6BackendAddressPool(lb *armnetwork.LoadBalancer, backendPool *armnetwork.BackendAddressPool) bool {
+	name := lo.FromPtr(backendPool.Name)
+	return strings.EqualFold(name, SLBOutboundBackendPoolNameIPv6) || strings.EqualFold(name, SLBInboundBackendPoolNameIPv6) ||
+		strings.HasSuffix(strings.ToLower(lo.FromPtr(lb.Name)), ipv6Suffix)
+}
+
+func isBackendAddressPoolApplicable(backendPool *armnetwork.BackendAddressPool) bool {
 	if backendPool.Properties == nil || backendPool.Name == nil {
 		return false // shouldn't ever happen
 	}
 
-	name := *backendPool.Name
-	// Ignore well-known named ipv6 pools for now
-	if strings.EqualFold(name, SLBOutboundBackendPoolNameIPv6) || strings.EqualFold(name, SLBInboundBackendPoolNameIPv6) {
-		return false
-	}
-
 	// Ignore IP-based pools, which are a thing in NodeIP mode. We don't need to assign these pools.
 	// See isIPBasedBackendPool in RP.
 	for _, backendAddress := range backendPool.Properties.LoadBalancerBackendAddresses {
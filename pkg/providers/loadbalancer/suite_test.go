@@ -71,7 +71,7 @@ func TestLoadBalancerBackendPools_ReturnsOnlyWellKnownPools(t *testing.T) {
 	g.Expect(pools.IPv4PoolIDs[2]).To(Equal("/subscriptions/subscriptionID/resourceGroups/test-rg/providers/Microsoft.Network/loadBalancers/kubernetes-internal/backendAddressPools/kubernetes"))
 }
 
-func TestLoadBalancerBackendPools_DoesNotReturnIPv6Pools(t *testing.T) {
+func TestLoadBalancerBackendPools_ReturnsIPv6PoolsOfIPv6LoadBalancers(t *testing.T) {
 	g := NewWithT(t)
 	f := newTestFixture(t)
 
@@ -88,11 +88,42 @@ func TestLoadBalancerBackendPools_DoesNotReturnIPv6Pools(t *testing.T) {
 	pools, err := f.provider.LoadBalancerBackendPools(f.ctx)
 	g.Expect(err).ToNot(HaveOccurred())
 
-	g.Expect(pools.IPv4PoolIDs).To(HaveLen(3))
-	g.Expect(pools.IPv6PoolIDs).To(HaveLen(0))
-	g.Expect(pools.IPv4PoolIDs[0]).To(Equal("/subscriptions/subscriptionID/resourceGroups/test-rg/providers/Microsoft.Network/loadBalancers/kubernetes/backendAddressPools/kubernetes"))
-	g.Expect(pools.IPv4PoolIDs[1]).To(Equal("/subscriptions/subscriptionID/resourceGroups/test-rg/providers/Microsoft.Network/loadBalancers/kubernetes/backendAddressPools/aksOutboundBackendPool"))
-	g.Expect(pools.IPv4PoolIDs[2]).To(Equal("/subscriptions/subscriptionID/resourceGroups/test-rg/providers/Microsoft.Network/loadBalancers/kubernetes-internal/backendAddressPools/kubernetes"))
+	g.Expect(pools.IPv4PoolIDs).To(ConsistOf(
+		"/subscriptions/subscriptionID/resourceGroups/test-rg/providers/Microsoft.Network/loadBalancers/kubernetes/backendAddressPools/kubernetes",
+		"/subscriptions/subscriptionID/resourceGroups/test-rg/providers/Microsoft.Network/loadBalancers/kubernetes/backendAddressPools/aksOutboundBackendPool",
+		"/subscriptions/subscriptionID/resourceGroups/test-rg/providers/Microsoft.Network/loadBalancers/kubernetes-internal/backendAddressPools/kubernetes",
+	))
+	g.Expect(pools.IPv6PoolIDs).To(ConsistOf(
+		"/subscriptions/subscriptionID/resourceGroups/test-rg/providers/Microsoft.Network/loadBalancers/kubernetes-ipv6/backendAddressPools/kubernetes",
+		"/subscriptions/subscriptionID/resourceGroups/test-rg/providers/Microsoft.Network/loadBalancers/kubernetes-ipv6/backendAddressPools/aksOutboundBackendPool",
+	))
+}
+
+func TestLoadBalancerBackendPools_ReturnsIPv6PoolsOfDualStackLoadBalancers(t *testing.T) {
+	g := NewWithT(t)
+	f := newTestFixture(t)
+
+	standardLB := test.MakeStandardLoadBalancer(resourceGroup, loadbalancer.SLBName, true)
+	for _, poolName := range []string{loadbalancer.SLBInboundBackendPoolNameIPv6, loadbalancer.SLBOutboundBackendPoolNameIPv6} {
+		standardLB.Properties.BackendAddressPools = append(standardLB.Properties.BackendAddressPools, &armnetwork.BackendAddressPool{
+			ID:         lo.ToPtr(fake.MakeBackendAddressPoolID(resourceGroup, loadbalancer.SLBName, poolName)),
+			Name:       lo.ToPtr(poolName),
+			Properties: &armnetwork.BackendAddressPoolPropertiesFormat{},
+		})
+	}
+	f.api.LoadBalancers.Store(lo.FromPtr(standardLB.ID), standardLB)
+
+	pools, err := f.provider.LoadBalancerBackendPools(f.ctx)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	g.Expect(pools.IPv4PoolIDs).To(ConsistOf(
+		"/subscriptions/subscriptionID/resourceGroups/test-rg/providers/Microsoft.Network/loadBalancers/kubernetes/backendAddressPools/kubernetes",
+		"/subscriptions/subscriptionID/resourceGroups/test-rg/providers/Microsoft.Network/loadBalancers/kubernetes/backendAddressPools/aksOutboundBackendPool",
+	))
+	g.Expect(pools.IPv6PoolIDs).To(ConsistOf(
+		"/subscriptions/subscriptionID/resourceGroups/test-rg/providers/Microsoft.Network/loadBalancers/kubernetes/backendAddressPools/kubernetes-ipv6",
+		"/subscriptions/subscriptionID/resourceGroups/test-rg/providers/Microsoft.Network/loadBalancers/kubernetes/backendAddressPools/aksOutboundBackendPool-ipv6",
+	))
 }
 
 func TestLoadBalancerBackendPools_DoesNotReturnIPBasedPools(t *testing.T) {
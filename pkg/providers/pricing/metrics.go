@@ -0,0 +1,61 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pricing
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	metrics "github.com/Azure/karpenter-provider-azure/pkg/metrics"
+)
+
+const (
+	pricingSubsystem = "pricing"
+)
+
+var (
+	// PricesLastUpdated tracks when the on-demand and spot prices were last updated.
+	//
+	// STABILITY: ALPHA - This metric may change or be removed without notice.
+	PricesLastUpdated = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: pricingSubsystem,
+			Name:      "last_updated_timestamp_seconds",
+			Help:      "Unix timestamp of when the prices were last updated, or retrieved for offline prices.",
+		},
+		[]string{metrics.CapacityTypeLabel},
+	)
+	// PricesStale tracks whether the prices are stale, i.e. pricing updates keep failing.
+	//
+	// STABILITY: ALPHA - This metric may change or be removed without notice.
+	PricesStale = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: pricingSubsystem,
+			Name:      "stale",
+			Help:      "1 if the prices should have been updated from the Azure retail prices API but were not for a while, 0 otherwise. Offline prices are never stale.",
+		},
+	)
+)
+
+func init() {
+	crmetrics.Registry.MustRegister(
+		PricesLastUpdated,
+		PricesStale,
+	)
+}
@@ -0,0 +1,84 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pricing
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// PriceFile holds the prices of a region, for offline pricing. It is read from a JSON file, e.g. mounted from a ConfigMap.
+type PriceFile struct {
+	// LastUpdated is when the prices were retrieved, it defaults to the modification time of the file
+	LastUpdated time.Time `json:"lastUpdated,omitempty"`
+	// OnDemand are the hourly on-demand prices, keyed by instance type (e.g. "Standard_D2s_v3")
+	OnDemand map[string]float64 `json:"onDemand"`
+	// Spot are the hourly spot prices, keyed by instance type. They default to the on-demand prices, like the embedded ones.
+	Spot map[string]float64 `json:"spot,omitempty"`
+}
+
+// LoadPriceFile reads and validates the prices of a JSON price file
+func LoadPriceFile(path string) (*PriceFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading price file %s, %w", path, err)
+	}
+	prices := &PriceFile{}
+	if err := json.Unmarshal(data, prices); err != nil {
+		return nil, fmt.Errorf("parsing price file %s, %w", path, err)
+	}
+	if len(prices.OnDemand) == 0 {
+		return nil, fmt.Errorf("price file %s has no on-demand prices", path)
+	}
+	for _, instancePrices := range []map[string]float64{prices.OnDemand, prices.Spot} {
+		for instanceType, price := range instancePrices {
+			if price < 0 {
+				return nil, fmt.Errorf("price file %s has a negative price for %s", path, instanceType)
+			}
+		}
+	}
+	if len(prices.Spot) == 0 {
+		prices.Spot = prices.OnDemand
+	}
+	if prices.LastUpdated.IsZero() {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading price file %s, %w", path, err)
+		}
+		prices.LastUpdated = info.ModTime()
+	}
+	return prices, nil
+}
+
+type providerOptions struct {
+	offline bool
+	prices  *PriceFile
+}
+
+// Option configures the pricing Provider
+type Option func(*providerOptions)
+
+// WithOfflinePricing makes the Provider use the given prices, or the embedded ones if nil, and never call the Azure retail
+// prices API, e.g. in air-gapped clusters where it isn't reachable
+func WithOfflinePricing(prices *PriceFile) Option {
+	return func(o *providerOptions) {
+		o.offline = true
+		o.prices = prices
+	}
+}
@@ -0,0 +1,189 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pricing
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/samber/lo"
+)
+
+// Overrides adjust the Azure retail prices to the prices actually paid, e.g. because of negotiated discounts, Reserved
+// Instances or Savings Plans. Each field of an override is taken from the most specific entry that sets it: the one of the
+// instance type, then the one of its VM family (e.g. "standardDSv3Family"), then the default one.
+type Overrides struct {
+	// Default applies to all instance types
+	Default *Override `json:"default,omitempty"`
+	// Families are keyed by VM family name, as reported by the SKU API and used by vCPU quotas (e.g. "standardDSv3Family")
+	Families map[string]Override `json:"families,omitempty"`
+	// SKUs are keyed by instance type (e.g. "Standard_D2s_v3")
+	SKUs map[string]Override `json:"skus,omitempty"`
+}
+
+// Override adjusts the retail prices of the instance types it applies to. Absolute prices take precedence over discounts.
+type Override struct {
+	// OnDemandPrice replaces the hourly retail on-demand price
+	OnDemandPrice *float64 `json:"onDemandPrice,omitempty"`
+	// OnDemandDiscountPercent is deducted from the retail on-demand price, e.g. an Enterprise Agreement discount
+	OnDemandDiscountPercent *float64 `json:"onDemandDiscountPercent,omitempty"`
+	// SpotPrice replaces the hourly retail spot price
+	SpotPrice *float64 `json:"spotPrice,omitempty"`
+	// SpotDiscountPercent is deducted from the retail spot price
+	SpotDiscountPercent *float64 `json:"spotDiscountPercent,omitempty"`
+	// ReservedInstanceCoveragePercent is the share of the on-demand usage covered by Reserved Instances
+	ReservedInstanceCoveragePercent *float64 `json:"reservedInstanceCoveragePercent,omitempty"`
+	// ReservedInstanceDiscountPercent is the discount of the Reserved Instances on the on-demand price
+	ReservedInstanceDiscountPercent *float64 `json:"reservedInstanceDiscountPercent,omitempty"`
+	// SavingsPlanCoveragePercent is the share of the on-demand usage covered by Savings Plans
+	SavingsPlanCoveragePercent *float64 `json:"savingsPlanCoveragePercent,omitempty"`
+	// SavingsPlanDiscountPercent is the discount of the Savings Plans on the on-demand price
+	SavingsPlanDiscountPercent *float64 `json:"savingsPlanDiscountPercent,omitempty"`
+}
+
+// ParseOverrides parses and validates JSON serialized overrides
+func ParseOverrides(data []byte) (*Overrides, error) {
+	overrides := &Overrides{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(overrides); err != nil {
+		return nil, fmt.Errorf("parsing pricing overrides, %w", err)
+	}
+	var errs []error
+	if overrides.Default != nil {
+		errs = append(errs, overrides.Default.validate("default"))
+	}
+	for family, override := range overrides.Families {
+		errs = append(errs, override.validate(fmt.Sprintf("families[%s]", family)))
+	}
+	for instanceType, override := range overrides.SKUs {
+		errs = append(errs, override.validate(fmt.Sprintf("skus[%s]", instanceType)))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return overrides, nil
+}
+
+// normalized returns the overrides keyed by lowercase family and instance type, for case-insensitive lookups
+func (o *Overrides) normalized() *Overrides {
+	normalized := &Overrides{Default: o.Default, Families: map[string]Override{}, SKUs: map[string]Override{}}
+	for family, override := range o.Families {
+		normalized.Families[strings.ToLower(family)] = override
+	}
+	for instanceType, override := range o.SKUs {
+		normalized.SKUs[strings.ToLower(instanceType)] = override
+	}
+	return normalized
+}
+
+// resolve merges the entries that apply to the instance type, the most specific one winning field by field
+func (o *Overrides) resolve(instanceType string, family string) Override {
+	resolved := Override{}
+	if o == nil {
+		return resolved
+	}
+	if override, ok := o.SKUs[strings.ToLower(instanceType)]; ok {
+		resolved = resolved.mergedWith(override)
+	}
+	if override, ok := o.Families[strings.ToLower(family)]; ok && family != "" {
+		resolved = resolved.mergedWith(override)
+	}
+	if o.Default != nil {
+		resolved = resolved.mergedWith(*o.Default)
+	}
+	return resolved
+}
+
+// mergedWith returns the override with the fields it doesn't set taken from the fallback
+func (o Override) mergedWith(fallback Override) Override {
+	return Override{
+		OnDemandPrice:                   lo.CoalesceOrEmpty(o.OnDemandPrice, fallback.OnDemandPrice),
+		OnDemandDiscountPercent:         lo.CoalesceOrEmpty(o.OnDemandDiscountPercent, fallback.OnDemandDiscountPercent),
+		SpotPrice:                       lo.CoalesceOrEmpty(o.SpotPrice, fallback.SpotPrice),
+		SpotDiscountPercent:             lo.CoalesceOrEmpty(o.SpotDiscountPercent, fallback.SpotDiscountPercent),
+		ReservedInstanceCoveragePercent: lo.CoalesceOrEmpty(o.ReservedInstanceCoveragePercent, fallback.ReservedInstanceCoveragePercent),
+		ReservedInstanceDiscountPercent: lo.CoalesceOrEmpty(o.ReservedInstanceDiscountPercent, fallback.ReservedInstanceDiscountPercent),
+		SavingsPlanCoveragePercent:      lo.CoalesceOrEmpty(o.SavingsPlanCoveragePercent, fallback.SavingsPlanCoveragePercent),
+		SavingsPlanDiscountPercent:      lo.CoalesceOrEmpty(o.SavingsPlanDiscountPercent, fallback.SavingsPlanDiscountPercent),
+	}
+}
+
+// onDemandPrice returns the effective on-demand price: the (discounted) price blended with the Reserved Instance and
+// Savings Plan prices, weighted by their coverage. The coverage of Reserved Instances applies first, Savings Plans cover
+// at most the rest.
+func (o Override) onDemandPrice(retailPrice float64, retailPriceKnown bool) (float64, bool) {
+	price := retailPrice
+	switch {
+	case o.OnDemandPrice != nil:
+		price = *o.OnDemandPrice
+	case !retailPriceKnown:
+		return retailPrice, false
+	case o.OnDemandDiscountPercent != nil:
+		price = retailPrice * (1 - *o.OnDemandDiscountPercent/100)
+	}
+	reservedInstanceCoverage := percent(o.ReservedInstanceCoveragePercent)
+	savingsPlanCoverage := min(percent(o.SavingsPlanCoveragePercent), 1-reservedInstanceCoverage)
+	return reservedInstanceCoverage*price*(1-percent(o.ReservedInstanceDiscountPercent)) +
+		savingsPlanCoverage*price*(1-percent(o.SavingsPlanDiscountPercent)) +
+		(1-reservedInstanceCoverage-savingsPlanCoverage)*price, true
+}
+
+// spotPrice returns the effective spot price. Reserved Instances and Savings Plans don't apply to spot instances.
+func (o Override) spotPrice(retailPrice float64, retailPriceKnown bool) (float64, bool) {
+	switch {
+	case o.SpotPrice != nil:
+		return *o.SpotPrice, true
+	case !retailPriceKnown:
+		return retailPrice, false
+	case o.SpotDiscountPercent != nil:
+		return retailPrice * (1 - *o.SpotDiscountPercent/100), true
+	}
+	return retailPrice, true
+}
+
+func (o Override) validate(path string) error {
+	var errs []error
+	for name, price := range map[string]*float64{"onDemandPrice": o.OnDemandPrice, "spotPrice": o.SpotPrice} {
+		if price != nil && *price < 0 {
+			errs = append(errs, fmt.Errorf("%s.%s must not be negative, got %v", path, name, *price))
+		}
+	}
+	for name, pct := range map[string]*float64{
+		"onDemandDiscountPercent":         o.OnDemandDiscountPercent,
+		"spotDiscountPercent":             o.SpotDiscountPercent,
+		"reservedInstanceCoveragePercent": o.ReservedInstanceCoveragePercent,
+		"reservedInstanceDiscountPercent": o.ReservedInstanceDiscountPercent,
+		"savingsPlanCoveragePercent":      o.SavingsPlanCoveragePercent,
+		"savingsPlanDiscountPercent":      o.SavingsPlanDiscountPercent,
+	} {
+		if pct != nil && (*pct < 0 || *pct > 100) {
+			errs = append(errs, fmt.Errorf("%s.%s must be between 0 and 100, got %v", path, name, *pct))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func percent(pct *float64) float64 {
+	if pct == nil {
+		return 0
+	}
+	return *pct / 100
+}
@@ -19,13 +19,17 @@ package pricing
 import (
 	"context"
 	"errors"
+	"maps"
 	"net/http"
+	"reflect"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/samber/lo"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	karpv1 "sigs.k8s.io/karpenter/pkg/apis/v1"
 	"sigs.k8s.io/karpenter/pkg/utils/pretty"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
@@ -38,6 +42,10 @@ const pricingUpdatePeriod = 12 * time.Hour
 
 const defaultRegion = "eastus"
 
+// StalenessThreshold is how long prices can go without being updated before they are considered stale, i.e. after
+// several consecutive pricing updates failed
+const StalenessThreshold = 3 * pricingUpdatePeriod
+
 // MissingPrice is a high penalty price assigned to SKUs with no known pricing data.
 // Setting to a high value means it won't be chosen automatically,
 // but will still be available if chosen explicitly.
@@ -48,18 +56,31 @@ const MissingPrice float64 = 999.0
 // support running in locations where pricing data is unavailable.  In those cases the static pricing data provides a
 // relative ordering that is still more accurate than our previous pricing model.  In the event that a pricing update
 // fails, the previous pricing information is retained and used which may be the static initial pricing data if pricing
-// updates never succeed.
+// updates never succeed. The retail prices can be overridden to reflect the prices actually paid, see Overrides.
+//
+// In offline pricing mode, the prices come from a price file or the static pricing data, and are never updated.
 type Provider struct {
 	pricing client.PricingAPI
 	region  string
 	cm      *pretty.ChangeMonitor
+	// offline is true when prices are not updated from the pricing API: in offline pricing mode, and outside of the public cloud
+	offline       bool
+	offlinePrices *PriceFile
 
 	mu                 sync.RWMutex
 	onDemandUpdateTime time.Time
 	onDemandPrices     map[string]float64
 	spotUpdateTime     time.Time
 	spotPrices         map[string]float64
-	done               chan struct{}
+	// pollingSince is when the periodic pricing updates started, zero until the provider is signaled to start them
+	pollingSince time.Time
+	overrides    *Overrides
+	// key: instance type, value: VM family, for the family overrides
+	families map[string]string
+	done     chan struct{}
+
+	// seqNum is updated on any change to the effective prices, i.e. to the retail prices or to the overrides
+	seqNum atomic.Uint64
 }
 
 // NewPricingAPI returns a pricing API
@@ -73,29 +94,29 @@ func NewProvider(
 	pricing client.PricingAPI,
 	region string,
 	startAsync <-chan struct{},
+	opts ...Option,
 ) *Provider {
-	// see if we've got region specific pricing data
-	staticPricing, ok := initialOnDemandPrices[region]
-	if !ok {
-		// and if not, fall back to the always available eastus
-		staticPricing = initialOnDemandPrices[defaultRegion]
+	o := &providerOptions{}
+	for _, opt := range opts {
+		opt(o)
 	}
 
 	p := &Provider{
-		region:             region,
-		onDemandUpdateTime: InitialPriceUpdate,
-		onDemandPrices:     staticPricing,
-		spotUpdateTime:     InitialPriceUpdate,
-		// default our spot pricing to the same as the on-demand pricing until a price update
-		spotPrices: staticPricing,
-		pricing:    pricing,
-		cm:         pretty.NewChangeMonitor(),
-		done:       make(chan struct{}),
+		region: region,
+		// Only poll in public cloud. Other clouds aren't supported currently
+		offline:       o.offline || !auth.IsPublic(env.Cloud),
+		offlinePrices: o.prices,
+		pricing:       pricing,
+		cm:            pretty.NewChangeMonitor(),
+		done:          make(chan struct{}),
 	}
+	p.setInitialPrices()
 	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithName("pricing").WithValues("region", region))
 
-	// Only poll in public cloud. Other clouds aren't supported currently
-	if auth.IsPublic(env.Cloud) {
+	if o.offline {
+		log.FromContext(ctx).V(0).Info("using offline pricing", "source", p.Source(), "lastUpdated", p.OnDemandLastUpdated())
+	}
+	if !p.offline {
 		go func() {
 			log.FromContext(ctx).V(0).Info("starting pricing update loop")
 			// perform an initial price update at startup
@@ -109,6 +130,9 @@ func NewProvider(
 				close(p.done)
 				return
 			}
+			p.mu.Lock()
+			p.pollingSince = time.Now()
+			p.mu.Unlock()
 			// if it took many hours to be elected leader, we want to re-fetch pricing before we start our periodic
 			// polling
 			if time.Since(startup) > pricingUpdatePeriod {
@@ -152,36 +176,142 @@ func (p *Provider) SpotLastUpdated() time.Time {
 	return p.spotUpdateTime
 }
 
-// OnDemandPrice returns the last known on-demand price for a given instance type.
+// LastUpdated returns the time that the least recently updated of the on-demand and spot pricing was last updated
+func (p *Provider) LastUpdated() time.Time {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return lo.Earliest(p.onDemandUpdateTime, p.spotUpdateTime)
+}
+
+func (p *Provider) SeqNum() uint64 {
+	return p.seqNum.Load()
+}
+
+// Offline returns true if the prices are never updated from the pricing API
+func (p *Provider) Offline() bool {
+	return p.offline
+}
+
+// Source describes where the prices come from
+func (p *Provider) Source() string {
+	switch {
+	case !p.offline:
+		return "Azure retail prices API"
+	case p.offlinePrices != nil:
+		return "price file"
+	default:
+		return "static pricing data"
+	}
+}
+
+// Stale returns true if the prices should have been updated from the pricing API, but weren't for StalenessThreshold,
+// and records it in the PricesStale metric. Offline prices are never stale, they are not expected to be updated, and
+// neither are the prices of a provider that wasn't signaled to start its periodic updates (e.g. a replica that isn't
+// the leader).
+func (p *Provider) Stale() bool {
+	stale := p.stale()
+	PricesStale.Set(lo.Ternary(stale, 1.0, 0.0))
+	return stale
+}
+
+func (p *Provider) stale() bool {
+	if p.offline {
+		return false
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.pollingSince.IsZero() {
+		return false
+	}
+	// the static pricing data predates the updates, they can't be expected to have replaced it before they started
+	lastUpdated := lo.Latest(lo.Earliest(p.onDemandUpdateTime, p.spotUpdateTime), p.pollingSince)
+	return time.Since(lastUpdated) > StalenessThreshold
+}
+
+// OnDemandPrice returns the last known on-demand price for a given instance type, with the overrides applied.
 // The boolean return indicates whether a real price was found (true) or the penalty
 // MissingPrice is being returned (false).
 func (p *Provider) OnDemandPrice(instanceType string) (float64, bool) {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
-	price, ok := p.onDemandPrices[instanceType]
-	if !ok {
-		return MissingPrice, false
-	}
-	return price, true
+	price, ok := p.retailPrice(p.onDemandPrices, instanceType)
+	return p.overrides.resolve(instanceType, p.families[instanceType]).onDemandPrice(price, ok)
 }
 
-// SpotPrice returns the last known spot price for a given instance type.
+// SpotPrice returns the last known spot price for a given instance type, with the overrides applied.
 // The boolean return indicates whether a real price was found (true) or the penalty
 // MissingPrice is being returned (false).
 func (p *Provider) SpotPrice(instanceType string) (float64, bool) {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
-	price, ok := p.spotPrices[instanceType]
+	price, ok := p.retailPrice(p.spotPrices, instanceType)
+	return p.overrides.resolve(instanceType, p.families[instanceType]).spotPrice(price, ok)
+}
+
+// RetailOnDemandPrice returns the last known retail on-demand price for a given instance type, ignoring the overrides.
+// Azure compares spot max prices with retail prices, not with the prices actually paid.
+func (p *Provider) RetailOnDemandPrice(instanceType string) (float64, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.retailPrice(p.onDemandPrices, instanceType)
+}
+
+// RetailSpotPrice returns the last known retail spot price for a given instance type, ignoring the overrides.
+func (p *Provider) RetailSpotPrice(instanceType string) (float64, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.retailPrice(p.spotPrices, instanceType)
+}
+
+func (p *Provider) retailPrice(prices map[string]float64, instanceType string) (float64, bool) {
+	price, ok := prices[instanceType]
 	if !ok {
 		return MissingPrice, false
 	}
 	return price, true
 }
 
+// Overrides returns the overrides applied to the retail prices, nil if there are none
+func (p *Provider) Overrides() *Overrides {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.overrides
+}
+
+// SetOverrides replaces the overrides applied to the retail prices, nil removing them. It returns true if they changed.
+func (p *Provider) SetOverrides(overrides *Overrides) bool {
+	if overrides != nil {
+		overrides = overrides.normalized()
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if reflect.DeepEqual(p.overrides, overrides) {
+		return false
+	}
+	p.overrides = overrides
+	p.seqNum.Add(1)
+	return true
+}
+
+// SetFamilies records the VM family of each instance type, which the family overrides apply to
+func (p *Provider) SetFamilies(families map[string]string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if maps.Equal(p.families, families) {
+		return
+	}
+	p.families = families
+	if p.overrides != nil && len(p.overrides.Families) > 0 {
+		p.seqNum.Add(1)
+	}
+}
+
 func (p *Provider) updatePricing(ctx context.Context) {
 	if ctx.Err() != nil {
 		return
 	}
+	// refresh the staleness metric once the update succeeded or failed, and the lock below is released
+	defer p.Stale()
 
 	onDemandPrices, spotPrices, err := FetchPricing(ctx, p.pricing, p.region)
 	if err != nil {
@@ -198,7 +328,9 @@ func (p *Provider) updatePricing(ctx context.Context) {
 	if len(onDemandPrices) > 0 {
 		p.onDemandPrices = onDemandPrices
 		p.onDemandUpdateTime = time.Now()
+		PricesLastUpdated.WithLabelValues(karpv1.CapacityTypeOnDemand).Set(float64(p.onDemandUpdateTime.Unix()))
 		if p.cm.HasChanged("on-demand-prices", p.onDemandPrices) {
+			p.seqNum.Add(1)
 			log.FromContext(ctx).Info("updated on-demand pricing",
 				"instanceTypeCount", len(p.onDemandPrices),
 			)
@@ -210,7 +342,9 @@ func (p *Provider) updatePricing(ctx context.Context) {
 	if len(spotPrices) > 0 {
 		p.spotPrices = spotPrices
 		p.spotUpdateTime = time.Now()
+		PricesLastUpdated.WithLabelValues(karpv1.CapacityTypeSpot).Set(float64(p.spotUpdateTime.Unix()))
 		if p.cm.HasChanged("spot-prices", p.spotPrices) {
+			p.seqNum.Add(1)
 			log.FromContext(ctx).Info("updated spot pricing",
 				"instanceTypeCount", len(p.spotPrices),
 			)
@@ -296,19 +430,35 @@ func (p *Provider) LivenessProbe(_ *http.Request) error {
 }
 
 func (p *Provider) Reset() {
-	// see if we've got region specific pricing data
-	staticPricing, ok := initialOnDemandPrices[p.region]
-	if !ok {
-		// and if not, fall back to the always available eastus
-		staticPricing = initialOnDemandPrices[defaultRegion]
-	}
-
 	p.mu.Lock()
 	defer p.mu.Unlock()
-	p.onDemandPrices = staticPricing
-	p.onDemandUpdateTime = InitialPriceUpdate
-	p.spotPrices = staticPricing
-	p.spotUpdateTime = InitialPriceUpdate
+	p.setInitialPrices()
+	p.overrides = nil
+	p.seqNum.Add(1)
+}
+
+// setInitialPrices sets the prices of the price file in offline pricing mode, and the static pricing data otherwise
+func (p *Provider) setInitialPrices() {
+	if p.offlinePrices != nil {
+		p.onDemandPrices = p.offlinePrices.OnDemand
+		p.onDemandUpdateTime = p.offlinePrices.LastUpdated
+		p.spotPrices = p.offlinePrices.Spot
+		p.spotUpdateTime = p.offlinePrices.LastUpdated
+	} else {
+		// see if we've got region specific pricing data
+		staticPricing, ok := initialOnDemandPrices[p.region]
+		if !ok {
+			// and if not, fall back to the always available eastus
+			staticPricing = initialOnDemandPrices[defaultRegion]
+		}
+		p.onDemandPrices = staticPricing
+		p.onDemandUpdateTime = InitialPriceUpdate
+		// default our spot pricing to the same as the on-demand pricing until a price update
+		p.spotPrices = staticPricing
+		p.spotUpdateTime = InitialPriceUpdate
+	}
+	PricesLastUpdated.WithLabelValues(karpv1.CapacityTypeOnDemand).Set(float64(p.onDemandUpdateTime.Unix()))
+	PricesLastUpdated.WithLabelValues(karpv1.CapacityTypeSpot).Set(float64(p.spotUpdateTime.Unix()))
 }
 
 // WaitUntilDone should be called after canceling the context passed to NewProvider to wait until all goroutines have exited
@@ -19,6 +19,8 @@ package pricing_test
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -29,6 +31,7 @@ import (
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
 	"github.com/Azure/karpenter-provider-azure/pkg/auth"
 	"github.com/Azure/karpenter-provider-azure/pkg/fake"
+	"github.com/Azure/karpenter-provider-azure/pkg/metrics"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/instancetype"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/pricing"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/pricing/client"
@@ -236,4 +239,222 @@ var _ = Describe("Pricing", func() {
 		Expect(ok).To(BeFalse(), "spot pricing should not be known for a non-existent SKU")
 		Expect(price).To(Equal(pricing.MissingPrice), "unknown SKU should get MissingPrice for spot")
 	})
+
+	Context("Offline", func() {
+		BeforeEach(func() {
+			// offline providers must not pick these up
+			fakePricingAPI.ProductsPricePage.Set(&client.ProductsPricePage{
+				Items: []client.Item{
+					fake.NewProductPrice("Standard_D1", 1.20),
+					fake.NewSpotProductPrice("Standard_D1", 1.10),
+				},
+			})
+		})
+
+		writePriceFile := func(data string) string {
+			GinkgoHelper()
+			path := filepath.Join(GinkgoT().TempDir(), "prices.json")
+			Expect(os.WriteFile(path, []byte(data), 0600)).To(Succeed())
+			return path
+		}
+
+		It("should use the static pricing data without calling the pricing API", func() {
+			start := make(chan struct{}, 1)
+			p := pricing.NewProvider(ctx, env, fakePricingAPI, "", start, pricing.WithOfflinePricing(nil))
+			providers = append(providers, p)
+			start <- struct{}{}
+
+			Consistently(func(g Gomega) {
+				g.Expect(p.OnDemandLastUpdated()).To(Equal(pricing.InitialPriceUpdate))
+				g.Expect(p.SpotLastUpdated()).To(Equal(pricing.InitialPriceUpdate))
+			}, 3*time.Second).Should(Succeed())
+			price, ok := p.OnDemandPrice("Standard_D1")
+			Expect(ok).To(BeTrue())
+			Expect(price).ToNot(BeNumerically("==", 1.20))
+
+			Expect(p.Offline()).To(BeTrue())
+			Expect(p.Source()).To(Equal("static pricing data"))
+			Expect(p.Stale()).To(BeFalse())
+			Expect(p.LivenessProbe(nil)).To(Succeed())
+		})
+		It("should use the prices of a price file", func() {
+			prices, err := pricing.LoadPriceFile(writePriceFile(`{
+				"lastUpdated": "2026-01-02T03:04:05Z",
+				"onDemand": {"Standard_D1": 0.5, "Standard_D2": 1.0},
+				"spot": {"Standard_D1": 0.1}
+			}`))
+			Expect(err).ToNot(HaveOccurred())
+			p := pricing.NewProvider(ctx, env, fakePricingAPI, "", make(chan struct{}), pricing.WithOfflinePricing(prices))
+			providers = append(providers, p)
+
+			price, ok := p.OnDemandPrice("Standard_D1")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("==", 0.5))
+			price, ok = p.SpotPrice("Standard_D1")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("==", 0.1))
+			_, ok = p.SpotPrice("Standard_D2")
+			Expect(ok).To(BeFalse())
+			_, ok = p.OnDemandPrice("Standard_D14")
+			Expect(ok).To(BeFalse())
+			Expect(p.OnDemandLastUpdated()).To(Equal(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)))
+			Expect(p.Source()).To(Equal("price file"))
+
+			// the price file survives resets
+			p.Reset()
+			price, ok = p.OnDemandPrice("Standard_D2")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("==", 1.0))
+		})
+		It("should default the spot prices and the update time of a price file", func() {
+			path := writePriceFile(`{"onDemand": {"Standard_D1": 0.5}}`)
+			info, err := os.Stat(path)
+			Expect(err).ToNot(HaveOccurred())
+			prices, err := pricing.LoadPriceFile(path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(prices.Spot).To(Equal(prices.OnDemand))
+			Expect(prices.LastUpdated).To(Equal(info.ModTime()))
+		})
+		It("should reject invalid price files", func() {
+			_, err := pricing.LoadPriceFile(filepath.Join(GinkgoT().TempDir(), "missing.json"))
+			Expect(err).To(MatchError(ContainSubstring("reading price file")))
+			_, err = pricing.LoadPriceFile(writePriceFile(`not json`))
+			Expect(err).To(MatchError(ContainSubstring("parsing price file")))
+			_, err = pricing.LoadPriceFile(writePriceFile(`{"spot": {"Standard_D1": 0.1}}`))
+			Expect(err).To(MatchError(ContainSubstring("has no on-demand prices")))
+			_, err = pricing.LoadPriceFile(writePriceFile(`{"onDemand": {"Standard_D1": -1}}`))
+			Expect(err).To(MatchError(ContainSubstring("has a negative price for Standard_D1")))
+		})
+		It("should not consider prices stale before the periodic updates start", func() {
+			fakePricingAPI.NextError.Set(fmt.Errorf("failed"))
+			pricing.PricesStale.Set(1)
+			start := make(chan struct{}, 1)
+			p := pricing.NewProvider(ctx, env, fakePricingAPI, "", start)
+			providers = append(providers, p)
+			Expect(p.Offline()).To(BeFalse())
+			// the static pricing data may be older than the staleness threshold, but updates didn't start yet
+			Expect(p.Stale()).To(BeFalse())
+			metric, err := metrics.FindMetricWithLabelValues("karpenter_pricing_stale", map[string]string{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(metric).ToNot(BeNil())
+			Expect(metric.GetGauge().GetValue()).To(BeNumerically("==", 0))
+
+			start <- struct{}{}
+			Consistently(func(g Gomega) {
+				g.Expect(p.Stale()).To(BeFalse())
+				g.Expect(p.LivenessProbe(nil)).To(Succeed())
+			}, time.Second).Should(Succeed())
+		})
+		It("should consider prices outside of the public cloud offline", func() {
+			p := pricing.NewProvider(ctx, &auth.Environment{Cloud: cloud.AzureGovernment}, fakePricingAPI, "", make(chan struct{}))
+			providers = append(providers, p)
+			Expect(p.Offline()).To(BeTrue())
+			Expect(p.Stale()).To(BeFalse())
+		})
+	})
+
+	Context("Overrides", func() {
+		var p *pricing.Provider
+
+		BeforeEach(func() {
+			fakePricingAPI.ProductsPricePage.Set(&client.ProductsPricePage{
+				Items: []client.Item{
+					fake.NewProductPrice("Standard_D1", 1.00),
+					fake.NewProductPrice("Standard_D14", 2.00),
+					fake.NewSpotProductPrice("Standard_D1", 0.40),
+					fake.NewSpotProductPrice("Standard_D14", 0.80),
+				},
+			})
+			updateStart := time.Now()
+			p = pricing.NewProvider(ctx, env, fakePricingAPI, "", make(chan struct{}))
+			providers = append(providers, p)
+			Eventually(func() bool {
+				return p.OnDemandLastUpdated().After(updateStart) && p.SpotLastUpdated().After(updateStart)
+			}).Should(BeTrue())
+			p.SetFamilies(map[string]string{"Standard_D1": "standardDFamily", "Standard_D14": "standardDFamily"})
+		})
+
+		setOverrides := func(data string) {
+			GinkgoHelper()
+			overrides, err := pricing.ParseOverrides([]byte(data))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(p.SetOverrides(overrides)).To(BeTrue())
+		}
+		expectPrices := func(instanceType string, onDemandPrice, spotPrice float64) {
+			GinkgoHelper()
+			price, ok := p.OnDemandPrice(instanceType)
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("~", onDemandPrice, 1e-9))
+			price, ok = p.SpotPrice(instanceType)
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("~", spotPrice, 1e-9))
+		}
+
+		It("should apply discounts to the retail prices", func() {
+			setOverrides(`{"default": {"onDemandDiscountPercent": 10, "spotDiscountPercent": 25}}`)
+			expectPrices("Standard_D1", 0.90, 0.30)
+			expectPrices("Standard_D14", 1.80, 0.60)
+		})
+		It("should prefer the overrides of the instance type, then of its family, field by field", func() {
+			setOverrides(`{
+				"default": {"onDemandDiscountPercent": 10, "spotDiscountPercent": 25},
+				"families": {"StandardDFamily": {"onDemandDiscountPercent": 20}},
+				"skus": {"standard_d14": {"onDemandPrice": 1.5}}
+			}`)
+			expectPrices("Standard_D1", 0.80, 0.30)
+			expectPrices("Standard_D14", 1.50, 0.60)
+		})
+		It("should blend the prices of Reserved Instances and Savings Plans by their coverage", func() {
+			setOverrides(`{"default": {
+				"reservedInstanceCoveragePercent": 50, "reservedInstanceDiscountPercent": 40,
+				"savingsPlanCoveragePercent": 80, "savingsPlanDiscountPercent": 20
+			}}`)
+			// 50% at 0.6, the remaining 50% covered by Savings Plans at 0.8; spot is not covered
+			expectPrices("Standard_D1", 0.70, 0.40)
+			expectPrices("Standard_D14", 1.40, 0.80)
+		})
+		It("should keep the retail prices available", func() {
+			setOverrides(`{"default": {"onDemandDiscountPercent": 50, "spotDiscountPercent": 50}}`)
+			price, ok := p.RetailOnDemandPrice("Standard_D1")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("==", 1.00))
+			price, ok = p.RetailSpotPrice("Standard_D1")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("==", 0.40))
+		})
+		It("should only make the prices of unknown instance types known with absolute prices", func() {
+			setOverrides(`{
+				"default": {"onDemandDiscountPercent": 10},
+				"skus": {"Standard_NonExistent_SKU": {"onDemandPrice": 0.5}}
+			}`)
+			price, ok := p.OnDemandPrice("Standard_NonExistent_SKU")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("==", 0.5))
+			price, ok = p.SpotPrice("Standard_NonExistent_SKU")
+			Expect(ok).To(BeFalse())
+			Expect(price).To(Equal(pricing.MissingPrice))
+		})
+		It("should update the sequence number when the overrides change", func() {
+			seqNum := p.SeqNum()
+			setOverrides(`{"default": {"onDemandDiscountPercent": 10}}`)
+			Expect(p.SeqNum()).ToNot(Equal(seqNum))
+
+			seqNum = p.SeqNum()
+			overrides, err := pricing.ParseOverrides([]byte(`{"default": {"onDemandDiscountPercent": 10}}`))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(p.SetOverrides(overrides)).To(BeFalse())
+			Expect(p.SeqNum()).To(Equal(seqNum))
+
+			Expect(p.SetOverrides(nil)).To(BeTrue())
+			expectPrices("Standard_D1", 1.00, 0.40)
+		})
+		It("should reject invalid overrides", func() {
+			_, err := pricing.ParseOverrides([]byte(`{"default": {"onDemandDiscountPercent": 110}}`))
+			Expect(err).To(MatchError(ContainSubstring("default.onDemandDiscountPercent must be between 0 and 100")))
+			_, err = pricing.ParseOverrides([]byte(`{"skus": {"Standard_D1": {"spotPrice": -1}}}`))
+			Expect(err).To(MatchError(ContainSubstring("skus[Standard_D1].spotPrice must not be negative")))
+			_, err = pricing.ParseOverrides([]byte(`{"default": {"onDemandDiscount": 10}}`))
+			Expect(err).To(MatchError(ContainSubstring("unknown field")))
+		})
+	})
 })
@@ -19,6 +19,7 @@ package quota
 import (
 	"context"
 	"fmt"
+	"math"
 	"sort"
 	"strings"
 	"sync"
@@ -32,6 +33,9 @@ import (
 	"sigs.k8s.io/karpenter/pkg/utils/pretty"
 )
 
+// regionalUsageName is the name of the usage entry for the total regional vCPUs
+const regionalUsageName = "cores"
+
 type UsageAPI interface {
 	NewListPager(location string, options *armcompute.UsageClientListOptions) *runtime.Pager[armcompute.UsageClientListResponse]
 }
@@ -46,11 +50,19 @@ type Provider interface {
 	// GetTotalRegionalUsage returns the total regional vCPU usage (the "cores" entry).
 	// The bool indicates whether the entry was found.
 	GetTotalRegionalUsage() (bool, *armcompute.Usage)
-	// HasQuotaFor returns true if the SKU's family has enough remaining quota
-	// to accommodate the SKU's vCPU count. Returns true (fail-open) if quota
-	// data is unavailable, the family is not found in the cached data, or
-	// the SKU's family name or vCPU count cannot be determined.
+	// ListFamilyUsages returns the usage entries of all VM families, sorted by name.
+	ListFamilyUsages() []*armcompute.Usage
+	// HasQuotaFor returns true if both the SKU's family and the regional vCPU quota have
+	// enough remaining quota to accommodate the SKU's vCPU count, after accounting for
+	// in-flight creates. Each quota check fails open if its quota data is unavailable
+	// or not found in the cached data, and HasQuotaFor returns true if the SKU's family
+	// name or vCPU count cannot be determined.
 	HasQuotaFor(ctx context.Context, sku *skewer.SKU) bool
+	// Reserve counts the SKU's vCPUs against its family and the regional quota. It is used for
+	// in-flight creates, whose vCPUs are not reflected in the usage data yet. The returned function
+	// is called once the create completes: if it failed, the vCPUs are released immediately,
+	// otherwise they stay counted until the next Update, which reflects them in the usage data.
+	Reserve(sku *skewer.SKU) (release func(created bool))
 	// SeqNum returns a monotonically increasing counter that is incremented
 	// each time quota data is successfully refreshed. Consumers can use this
 	// to invalidate caches that depend on quota state.
@@ -68,6 +80,17 @@ type DefaultProvider struct {
 	usages      map[string]*armcompute.Usage
 	cm          *pretty.ChangeMonitor
 	seqNum      atomic.Uint64
+
+	// vCPUs of in-flight creates, and of completed creates not reflected in the usage data yet, by VM family name
+	inFlight      map[string]int64
+	inFlightTotal int64
+	// completed creates, in order of completion, whose vCPUs are released by the next Update
+	created []reservation
+}
+
+type reservation struct {
+	familyName string
+	vcpus      int64
 }
 
 func NewProvider(usageClient UsageAPI, location string) *DefaultProvider {
@@ -76,10 +99,16 @@ func NewProvider(usageClient UsageAPI, location string) *DefaultProvider {
 		location:    location,
 		usages:      map[string]*armcompute.Usage{},
 		cm:          pretty.NewChangeMonitor(),
+		inFlight:    map[string]int64{},
 	}
 }
 
 func (p *DefaultProvider) Update(ctx context.Context) error {
+	// only the creates completed before fetching the usage data are reflected in it
+	p.mu.RLock()
+	created := len(p.created)
+	p.mu.RUnlock()
+
 	freshUsages := map[string]*armcompute.Usage{}
 
 	pager := p.usageClient.NewListPager(p.location, nil)
@@ -101,10 +130,19 @@ func (p *DefaultProvider) Update(ctx context.Context) error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 	p.usages = freshUsages
-	if p.cm.HasChanged("quota-usages", freshUsages) {
-		p.seqNum.Add(1)
+	changed := p.cm.HasChanged("quota-usages", freshUsages)
+	if changed {
 		log.FromContext(ctx).V(1).Info("updated quota usages", "familyQuotas", formatFamilyQuotas(freshUsages))
 	}
+	// an Update running concurrently may have released some of them already
+	created = min(created, len(p.created))
+	for _, r := range p.created[:created] {
+		changed = p.updateInFlight(r.familyName, -r.vcpus) || changed
+	}
+	p.created = p.created[created:]
+	if changed {
+		p.seqNum.Add(1)
+	}
 	return nil
 }
 
@@ -116,7 +154,15 @@ func (p *DefaultProvider) GetUsage(familyName string) (bool, *armcompute.Usage)
 }
 
 func (p *DefaultProvider) GetTotalRegionalUsage() (bool, *armcompute.Usage) {
-	return p.GetUsage("cores")
+	return p.GetUsage(regionalUsageName)
+}
+
+func (p *DefaultProvider) ListFamilyUsages() []*armcompute.Usage {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	names := lo.Filter(lo.Keys(p.usages), func(name string, _ int) bool { return isFamilyUsage(name) })
+	sort.Strings(names)
+	return lo.Map(names, func(name string, _ int) *armcompute.Usage { return p.usages[name] })
 }
 
 func (p *DefaultProvider) HasQuotaFor(ctx context.Context, sku *skewer.SKU) bool {
@@ -130,16 +176,74 @@ func (p *DefaultProvider) HasQuotaFor(ctx context.Context, sku *skewer.SKU) bool
 		log.FromContext(ctx).V(1).Info("WARNING: cannot check quota for SKU, vCPU count unavailable; assuming quota available", "sku", sku.GetName(), "error", err)
 		return true // fail open
 	}
-	found, usage := p.GetUsage(familyName)
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return vcpus <= p.remaining(ctx, familyName, p.inFlight[familyName]) &&
+		vcpus <= p.remaining(ctx, regionalUsageName, p.inFlightTotal)
+}
+
+// remaining returns the quota left for the usage entry with the given name, minus the vCPUs of in-flight creates.
+// It returns math.MaxInt64 (fail open) if the entry is not found or incomplete. Must be called with p.mu held.
+func (p *DefaultProvider) remaining(ctx context.Context, name string, inFlight int64) int64 {
+	usage, found := p.usages[name]
 	if !found {
-		return true // fail open
+		return math.MaxInt64 // fail open
 	}
 	if usage.Limit == nil || usage.CurrentValue == nil {
-		log.FromContext(ctx).V(1).Info("WARNING: quota entry has nil Limit or CurrentValue; assuming quota available", "family", familyName)
-		return true // fail open
+		log.FromContext(ctx).V(1).Info("WARNING: quota entry has nil Limit or CurrentValue; assuming quota available", "name", name)
+		return math.MaxInt64 // fail open
 	}
-	remaining := *usage.Limit - int64(*usage.CurrentValue)
-	return vcpus <= remaining
+	return *usage.Limit - int64(*usage.CurrentValue) - inFlight
+}
+
+func (p *DefaultProvider) Reserve(sku *skewer.SKU) func(created bool) {
+	familyName := sku.GetFamilyName()
+	vcpus, err := sku.VCPU()
+	if familyName == "" || err != nil {
+		return func(bool) {}
+	}
+	p.mu.Lock()
+	if p.updateInFlight(familyName, vcpus) {
+		p.seqNum.Add(1)
+	}
+	p.mu.Unlock()
+	var once sync.Once
+	return func(created bool) {
+		once.Do(func() {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			if created {
+				p.created = append(p.created, reservation{familyName: familyName, vcpus: vcpus})
+				return
+			}
+			if p.updateInFlight(familyName, -vcpus) {
+				p.seqNum.Add(1)
+			}
+		})
+	}
+}
+
+// updateInFlight adds vcpus to the in-flight vCPUs of the family, and returns true if that can change
+// the answer of HasQuotaFor for any SKU. Must be called with p.mu held.
+func (p *DefaultProvider) updateInFlight(familyName string, vcpus int64) bool {
+	changed := p.canFit(familyName, p.inFlight[familyName], vcpus) || p.canFit(regionalUsageName, p.inFlightTotal, vcpus)
+	p.inFlight[familyName] += vcpus
+	if p.inFlight[familyName] <= 0 {
+		delete(p.inFlight, familyName)
+	}
+	p.inFlightTotal += vcpus
+	return changed
+}
+
+// canFit returns true if any vCPUs fit the quota of the usage entry with the given name, either before or after adding
+// vcpus to its in-flight vCPUs. Otherwise, no SKU fits either way, and HasQuotaFor answers the same. Must be called with p.mu held.
+func (p *DefaultProvider) canFit(name string, inFlight, vcpus int64) bool {
+	usage, found := p.usages[name]
+	if !found || usage.Limit == nil || usage.CurrentValue == nil {
+		// HasQuotaFor fails open either way
+		return false
+	}
+	return *usage.Limit-int64(*usage.CurrentValue)-min(inFlight, inFlight+vcpus) > 0
 }
 
 func (p *DefaultProvider) SeqNum() uint64 {
@@ -162,7 +266,7 @@ func (p *DefaultProvider) Reset() {
 func formatFamilyQuotas(usages map[string]*armcompute.Usage) string {
 	var parts []string
 	for name, usage := range usages {
-		if !strings.Contains(strings.ToLower(name), "family") {
+		if !isFamilyUsage(name) {
 			continue
 		}
 		parts = append(parts, fmt.Sprintf("%s: %d/%d", name, lo.FromPtr(usage.CurrentValue), lo.FromPtr(usage.Limit)))
@@ -170,3 +274,8 @@ func formatFamilyQuotas(usages map[string]*armcompute.Usage) string {
 	sort.Strings(parts)
 	return strings.Join(parts, ", ")
 }
+
+// isFamilyUsage returns true if the usage entry with the given name is the vCPU quota of a VM family
+func isFamilyUsage(name string) bool {
+	return strings.Contains(strings.ToLower(name), "family")
+}
@@ -109,6 +109,27 @@ func Test_GetTotalRegionalUsage_ReturnsFalseWhenEmpty(t *testing.T) {
 	g.Expect(found).To(BeFalse())
 }
 
+func Test_ListFamilyUsages_ReturnsOnlyFamiliesSortedByName(t *testing.T) {
+	t.Parallel()
+	ctx := TestContextWithLogger(t)
+	g := NewWithT(t)
+	usageAPI, quotaProvider := newTestProvider(t)
+
+	for _, name := range []string{"standardDSv3Family", "cores", "standardBSFamily", "PremiumDiskCount"} {
+		usageAPI.Usages.Append(&armcompute.Usage{
+			Name:         &armcompute.UsageName{Value: lo.ToPtr(name)},
+			CurrentValue: lo.ToPtr[int32](10),
+			Limit:        lo.ToPtr[int64](100),
+		})
+	}
+
+	err := quotaProvider.Update(ctx)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	names := lo.Map(quotaProvider.ListFamilyUsages(), func(usage *armcompute.Usage, _ int) string { return *usage.Name.Value })
+	g.Expect(names).To(Equal([]string{"standardBSFamily", "standardDSv3Family"}))
+}
+
 func Test_Update_PreservesCachedDataOnFailure(t *testing.T) {
 	t.Parallel()
 	ctx := TestContextWithLogger(t)
@@ -217,6 +238,33 @@ func Test_HasQuotaFor(t *testing.T) {
 			update:   true,
 			expected: true,
 		},
+		{
+			name: "blocks when insufficient regional quota",
+			usages: []*armcompute.Usage{
+				{
+					Name:         &armcompute.UsageName{Value: lo.ToPtr(sku.GetFamilyName())},
+					CurrentValue: lo.ToPtr[int32](10),
+					Limit:        lo.ToPtr[int64](100),
+				},
+				{
+					Name:         &armcompute.UsageName{Value: lo.ToPtr("cores")},
+					CurrentValue: lo.ToPtr[int32](98),
+					Limit:        lo.ToPtr[int64](100),
+				},
+			},
+			update:   true,
+			expected: false, // family has headroom, but 100-98=2 regional vCPUs remaining, SKU needs 4
+		},
+		{
+			name: "allows when enough regional quota and family not found",
+			usages: []*armcompute.Usage{{
+				Name:         &armcompute.UsageName{Value: lo.ToPtr("cores")},
+				CurrentValue: lo.ToPtr[int32](50),
+				Limit:        lo.ToPtr[int64](100),
+			}},
+			update:   true,
+			expected: true,
+		},
 		{
 			name: "fails open when CurrentValue is nil",
 			usages: []*armcompute.Usage{{
@@ -248,6 +296,116 @@ func Test_HasQuotaFor(t *testing.T) {
 	}
 }
 
+func Test_Reserve_CountsInFlightCreatesAgainstQuota(t *testing.T) {
+	t.Parallel()
+	ctx := TestContextWithLogger(t)
+	g := NewWithT(t)
+	usageAPI, quotaProvider := newTestProvider(t)
+	sku := fake.MakeSKU("Standard_D4s_v3")     // 4 vCPUs, standardDSv3Family
+	otherSKU := fake.MakeSKU("Standard_D2_v5") // 2 vCPUs, another family
+
+	usageAPI.Usages.Append(
+		&armcompute.Usage{
+			Name:         &armcompute.UsageName{Value: lo.ToPtr(sku.GetFamilyName())},
+			CurrentValue: lo.ToPtr[int32](92),
+			Limit:        lo.ToPtr[int64](100),
+		},
+		&armcompute.Usage{
+			Name:         &armcompute.UsageName{Value: lo.ToPtr("cores")},
+			CurrentValue: lo.ToPtr[int32](92),
+			Limit:        lo.ToPtr[int64](102),
+		},
+	)
+	lo.Must0(quotaProvider.Update(ctx))
+	g.Expect(quotaProvider.HasQuotaFor(ctx, sku)).To(BeTrue())
+	seqNum := quotaProvider.SeqNum()
+
+	// 8 family vCPUs remaining, one in-flight create leaves room for exactly one more
+	releaseFirst := quotaProvider.Reserve(sku)
+	g.Expect(quotaProvider.SeqNum()).To(BeNumerically(">", seqNum))
+	g.Expect(quotaProvider.HasQuotaFor(ctx, sku)).To(BeTrue())
+	releaseSecond := quotaProvider.Reserve(sku)
+	g.Expect(quotaProvider.HasQuotaFor(ctx, sku)).To(BeFalse())
+	// 10 regional vCPUs remaining, so the other family still fits
+	g.Expect(quotaProvider.HasQuotaFor(ctx, otherSKU)).To(BeTrue())
+	releaseThird := quotaProvider.Reserve(otherSKU)
+	g.Expect(quotaProvider.HasQuotaFor(ctx, otherSKU)).To(BeFalse())
+
+	// a failed create is released immediately, and releasing is idempotent
+	releaseSecond(false)
+	releaseSecond(false)
+	g.Expect(quotaProvider.HasQuotaFor(ctx, sku)).To(BeTrue())
+	releaseFirst(false)
+	releaseThird(false)
+	g.Expect(quotaProvider.HasQuotaFor(ctx, otherSKU)).To(BeTrue())
+}
+
+func Test_Reserve_KeepsCreatedUntilUpdate(t *testing.T) {
+	t.Parallel()
+	ctx := TestContextWithLogger(t)
+	g := NewWithT(t)
+	usageAPI, quotaProvider := newTestProvider(t)
+	sku := fake.MakeSKU("Standard_D4s_v3") // 4 vCPUs, standardDSv3Family
+
+	familyUsage := &armcompute.Usage{
+		Name:         &armcompute.UsageName{Value: lo.ToPtr(sku.GetFamilyName())},
+		CurrentValue: lo.ToPtr[int32](92),
+		Limit:        lo.ToPtr[int64](100),
+	}
+	usageAPI.Usages.Append(familyUsage)
+	lo.Must0(quotaProvider.Update(ctx))
+
+	release := quotaProvider.Reserve(sku)
+	quotaProvider.Reserve(sku)(true)
+	g.Expect(quotaProvider.HasQuotaFor(ctx, sku)).To(BeFalse())
+
+	// a successful create stays counted, as it isn't reflected in the usage data yet
+	release(true)
+	g.Expect(quotaProvider.HasQuotaFor(ctx, sku)).To(BeFalse())
+
+	// the next update reflects both creates in the usage data, and releases them
+	familyUsage.CurrentValue = lo.ToPtr[int32](100)
+	lo.Must0(quotaProvider.Update(ctx))
+	g.Expect(quotaProvider.HasQuotaFor(ctx, sku)).To(BeFalse())
+	familyUsage.Limit = lo.ToPtr[int64](104)
+	lo.Must0(quotaProvider.Update(ctx))
+	g.Expect(quotaProvider.HasQuotaFor(ctx, sku)).To(BeTrue())
+}
+
+func Test_Reserve_IncrementsSeqNumOnlyWhenQuotaAnswerCanChange(t *testing.T) {
+	t.Parallel()
+	ctx := TestContextWithLogger(t)
+	g := NewWithT(t)
+	usageAPI, quotaProvider := newTestProvider(t)
+	sku := fake.MakeSKU("Standard_D4s_v3")     // 4 vCPUs, standardDSv3Family
+	otherSKU := fake.MakeSKU("Standard_D2_v5") // 2 vCPUs, no quota data
+
+	usageAPI.Usages.Append(&armcompute.Usage{
+		Name:         &armcompute.UsageName{Value: lo.ToPtr(sku.GetFamilyName())},
+		CurrentValue: lo.ToPtr[int32](96),
+		Limit:        lo.ToPtr[int64](100),
+	})
+	lo.Must0(quotaProvider.Update(ctx))
+	seqNum := quotaProvider.SeqNum()
+
+	// without quota data, HasQuotaFor fails open either way
+	quotaProvider.Reserve(otherSKU)(false)
+	g.Expect(quotaProvider.SeqNum()).To(Equal(seqNum))
+
+	// reserving the last vCPUs of the family changes the answer
+	releaseFirst := quotaProvider.Reserve(sku)
+	g.Expect(quotaProvider.SeqNum()).To(Equal(seqNum + 1))
+	// once the family is out of quota, reserving or releasing more doesn't
+	releaseSecond := quotaProvider.Reserve(sku)
+	releaseSecond(false)
+	g.Expect(quotaProvider.SeqNum()).To(Equal(seqNum + 1))
+	// completing a create doesn't either, until the next update
+	releaseFirst(true)
+	g.Expect(quotaProvider.SeqNum()).To(Equal(seqNum + 1))
+	lo.Must0(quotaProvider.Update(ctx))
+	g.Expect(quotaProvider.SeqNum()).To(Equal(seqNum + 2))
+}
+
 func Test_SeqNum_IncrementsOnlyWhenDataChanges(t *testing.T) {
 	t.Parallel()
 	ctx := TestContextWithLogger(t)
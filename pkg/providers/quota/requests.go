@@ -0,0 +1,194 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package quota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+)
+
+const quotaAPIVersion = "2023-02-01"
+
+// RequestsAPI submits and tracks vCPU quota increase requests through the Microsoft.Quota API.
+type RequestsAPI interface {
+	// RequestIncrease requests the limit of the named vCPU quota (e.g. "standardDSv3Family") to be raised to limit.
+	RequestIncrease(ctx context.Context, name string, limit int64) (*Request, error)
+	// GetRequest returns the current state of the quota increase request with the given ID.
+	GetRequest(ctx context.Context, id string) (*Request, error)
+}
+
+type RequestState string
+
+const (
+	RequestStateAccepted   RequestState = "Accepted"
+	RequestStateInProgress RequestState = "InProgress"
+	RequestStateSucceeded  RequestState = "Succeeded"
+	RequestStateFailed     RequestState = "Failed"
+	RequestStateInvalid    RequestState = "Invalid"
+)
+
+// IsTerminal returns true if the request won't change state anymore
+func (s RequestState) IsTerminal() bool {
+	return s == RequestStateSucceeded || s == RequestStateFailed || s == RequestStateInvalid
+}
+
+// Request is a quota increase request
+type Request struct {
+	// ID identifies the request, to track its state. It is empty if the quota was increased synchronously.
+	ID      string       `json:"id,omitempty"`
+	State   RequestState `json:"state,omitempty"`
+	Message string       `json:"message,omitempty"`
+}
+
+var _ RequestsAPI = &RequestsClient{}
+
+// RequestsClient is a minimal client for the Microsoft.Quota API, covering the vCPU quota increase requests for Microsoft.Compute
+// in a single location. Unlike the Microsoft.Compute/locations/usages API used to read quota, it requires the Quota Request Operator role.
+type RequestsClient struct {
+	client         *arm.Client
+	subscriptionID string
+	location       string
+}
+
+func NewRequestsClient(subscriptionID, location string, cred azcore.TokenCredential, opts *arm.ClientOptions) (*RequestsClient, error) {
+	client, err := arm.NewClient("armquota", "v1.0.0", cred, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &RequestsClient{
+		client:         client,
+		subscriptionID: subscriptionID,
+		location:       location,
+	}, nil
+}
+
+type quotaLimit struct {
+	LimitObjectType string `json:"limitObjectType"`
+	Value           int64  `json:"value"`
+}
+
+type quotaName struct {
+	Value string `json:"value"`
+}
+
+type quotaProperties struct {
+	Limit        quotaLimit `json:"limit"`
+	Name         quotaName  `json:"name"`
+	ResourceType string     `json:"resourceType"`
+}
+
+type quotaResource struct {
+	Properties quotaProperties `json:"properties"`
+}
+
+type quotaRequestError struct {
+	Message string `json:"message"`
+}
+
+type quotaRequestProperties struct {
+	ProvisioningState RequestState      `json:"provisioningState"`
+	Message           string            `json:"message"`
+	Error             quotaRequestError `json:"error"`
+}
+
+type quotaRequestDetails struct {
+	Name       string                 `json:"name"`
+	Properties quotaRequestProperties `json:"properties"`
+}
+
+func (c *RequestsClient) RequestIncrease(ctx context.Context, name string, limit int64) (*Request, error) {
+	req, err := runtime.NewRequest(ctx, http.MethodPut, runtime.JoinPaths(c.client.Endpoint(), c.scope(), "providers/Microsoft.Quota/quotas", url.PathEscape(name)))
+	if err != nil {
+		return nil, err
+	}
+	c.setAPIVersion(req)
+	if err := runtime.MarshalAsJSON(req, quotaResource{
+		Properties: quotaProperties{
+			Limit:        quotaLimit{LimitObjectType: "LimitValue", Value: limit},
+			Name:         quotaName{Value: name},
+			ResourceType: "dedicated",
+		},
+	}); err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Pipeline().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return &Request{State: RequestStateSucceeded}, nil
+	case http.StatusCreated, http.StatusAccepted:
+		// The request is processed asynchronously, and tracked as a quota request named after the last segment of the operation URL
+		operation := resp.Header.Get("Azure-AsyncOperation")
+		if operation == "" {
+			operation = resp.Header.Get("Location")
+		}
+		operationURL, err := url.Parse(operation)
+		if err != nil || operation == "" {
+			return nil, errors.New("quota increase request accepted without an operation to track it")
+		}
+		return &Request{ID: path.Base(operationURL.Path), State: RequestStateAccepted}, nil
+	default:
+		return nil, runtime.NewResponseError(resp)
+	}
+}
+
+func (c *RequestsClient) GetRequest(ctx context.Context, id string) (*Request, error) {
+	req, err := runtime.NewRequest(ctx, http.MethodGet, runtime.JoinPaths(c.client.Endpoint(), c.scope(), "providers/Microsoft.Quota/quotaRequests", url.PathEscape(id)))
+	if err != nil {
+		return nil, err
+	}
+	c.setAPIVersion(req)
+	resp, err := c.client.Pipeline().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if !runtime.HasStatusCode(resp, http.StatusOK) {
+		return nil, runtime.NewResponseError(resp)
+	}
+	details := quotaRequestDetails{}
+	if err := runtime.UnmarshalAsJSON(resp, &details); err != nil {
+		return nil, fmt.Errorf("reading quota request %s, %w", id, err)
+	}
+	message := details.Properties.Message
+	if message == "" {
+		message = details.Properties.Error.Message
+	}
+	return &Request{ID: id, State: details.Properties.ProvisioningState, Message: message}, nil
+}
+
+// scope is the Microsoft.Compute scope the vCPU quotas of the location belong to
+func (c *RequestsClient) scope() string {
+	return fmt.Sprintf("subscriptions/%s/providers/Microsoft.Compute/locations/%s", url.PathEscape(c.subscriptionID), url.PathEscape(c.location))
+}
+
+func (c *RequestsClient) setAPIVersion(req *policy.Request) {
+	query := req.Raw().URL.Query()
+	query.Set("api-version", quotaAPIVersion)
+	req.Raw().URL.RawQuery = query.Encode()
+	req.Raw().Header["Accept"] = []string{"application/json"}
+}
@@ -0,0 +1,49 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package subnetcapacity
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	metrics "github.com/Azure/karpenter-provider-azure/pkg/metrics"
+)
+
+const (
+	subnetSubsystem = "subnet"
+)
+
+var (
+	// AvailableIPAddresses tracks the free IPs of the subnets of each AKSNodeClass.
+	//
+	// STABILITY: ALPHA - This metric may change or be removed without notice.
+	AvailableIPAddresses = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: subnetSubsystem,
+			Name:      "available_ip_addresses",
+			Help:      "Number of free IP addresses of the subnets of an AKSNodeClass, less the ones of the NICs Karpenter created since the subnet was last observed.",
+		},
+		[]string{metrics.NodeClassLabel, metrics.SubnetLabel},
+	)
+)
+
+func init() {
+	crmetrics.Registry.MustRegister(
+		AvailableIPAddresses,
+	)
+}
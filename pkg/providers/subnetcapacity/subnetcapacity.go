@@ -0,0 +1,177 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package subnetcapacity
+
+import (
+	"context"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork"
+	"github.com/patrickmn/go-cache"
+	"github.com/samber/lo"
+
+	"github.com/Azure/karpenter-provider-azure/pkg/consts"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/azclient/azapi"
+	"github.com/Azure/karpenter-provider-azure/pkg/utils"
+)
+
+const (
+	// CapacityCacheTTL is how long the free IPs of a subnet are used without being refreshed
+	CapacityCacheTTL = 10 * time.Minute
+	// ReservationTTL is how long the IPs of a NIC created by Karpenter are subtracted from the free IPs of its subnet,
+	// in case the subnet is not refreshed in the meantime
+	ReservationTTL = 10 * time.Minute
+)
+
+type capacity struct {
+	available  int64
+	observedAt time.Time
+}
+
+type reservation struct {
+	subnetID   string
+	count      int64
+	reservedAt time.Time
+}
+
+// Provider tracks the free IPs of subnets. They are observed from the subnet API, through Refresh and Update, and the IPs
+// of the NICs Karpenter created since then are subtracted from them, so that a scale-up doesn't run a subnet out of IPs
+// between two refreshes.
+type Provider struct {
+	api azapi.SubnetsAPI
+
+	mu sync.Mutex
+	// key: subnet ID (lowercase), value: capacity
+	capacities *cache.Cache
+	// key: <subnet ID>/<NIC name> (lowercase), value: reservation
+	reservations *cache.Cache
+}
+
+func NewProvider(api azapi.SubnetsAPI) *Provider {
+	return &Provider{
+		api:          api,
+		capacities:   cache.New(CapacityCacheTTL, time.Minute),
+		reservations: cache.New(ReservationTTL, time.Minute),
+	}
+}
+
+// Refresh gets the subnet and records its free IPs
+func (p *Provider) Refresh(ctx context.Context, subnetID string) (*armnetwork.Subnet, error) {
+	components, err := utils.GetVnetSubnetIDComponents(subnetID)
+	if err != nil {
+		return nil, err
+	}
+	observedAt := time.Now()
+	resp, err := p.api.Get(ctx, components.ResourceGroupName, components.VNetName, components.SubnetName, nil)
+	if err != nil {
+		return nil, err
+	}
+	p.Update(subnetID, &resp.Subnet, observedAt)
+	return &resp.Subnet, nil
+}
+
+// Update records the free IPs of a subnet retrieved from the subnet API at observedAt, e.g., when listing the subnets of a VNet.
+// The NICs created before observedAt are already accounted for in the subnet.
+func (p *Provider) Update(subnetID string, subnet *armnetwork.Subnet, observedAt time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	available := AvailableIPAddressCount(subnet)
+	if available == nil {
+		p.capacities.Delete(strings.ToLower(subnetID))
+		return
+	}
+	p.capacities.SetDefault(strings.ToLower(subnetID), capacity{available: *available, observedAt: observedAt})
+}
+
+// Reserve records that a NIC using count IPs of the subnet was created. Reserving again for the same NIC replaces its reservation.
+func (p *Provider) Reserve(subnetID, nicName string, count int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.reservations.SetDefault(strings.ToLower(subnetID+"/"+nicName), reservation{subnetID: strings.ToLower(subnetID), count: count, reservedAt: time.Now()})
+}
+
+// Available returns the number of free IPs of the subnet, less the IPs of the NICs created since it was observed.
+// It returns false if the free IPs of the subnet are unknown.
+func (p *Provider) Available(subnetID string) (int64, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	cached, ok := p.capacities.Get(strings.ToLower(subnetID))
+	if !ok {
+		return 0, false
+	}
+	c := cached.(capacity)
+	available := c.available
+	for _, item := range p.reservations.Items() {
+		r := item.Object.(reservation)
+		if r.subnetID == strings.ToLower(subnetID) && r.reservedAt.After(c.observedAt) {
+			available -= r.count
+		}
+	}
+	return max(available, 0), true
+}
+
+// Fits returns true if the subnet has at least count free IPs, or if its free IPs are unknown
+func (p *Provider) Fits(subnetID string, count int64) bool {
+	available, ok := p.Available(subnetID)
+	return !ok || available >= count
+}
+
+func (p *Provider) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.capacities.Flush()
+	p.reservations.Flush()
+}
+
+// AvailableIPAddressCount returns the number of free IPv4 addresses of the subnet, or nil if it has no IPv4 prefix.
+// Azure reserves 5 addresses of each prefix, and each ipconfig in the subnet uses one.
+func AvailableIPAddressCount(subnet *armnetwork.Subnet) *int64 {
+	if subnet == nil || subnet.Properties == nil {
+		return nil
+	}
+	prefixes := lo.FromSlicePtr(subnet.Properties.AddressPrefixes)
+	if subnet.Properties.AddressPrefix != nil {
+		prefixes = append(prefixes, *subnet.Properties.AddressPrefix)
+	}
+	var total int64
+	var hasIPv4 bool
+	for _, prefix := range lo.Uniq(prefixes) {
+		parsed, err := netip.ParsePrefix(prefix)
+		if err != nil || !parsed.Addr().Is4() {
+			continue
+		}
+		hasIPv4 = true
+		total += (int64(1) << (32 - parsed.Bits())) - 5
+	}
+	if !hasIPv4 {
+		return nil
+	}
+	return lo.ToPtr(max(total-int64(len(subnet.Properties.IPConfigurations)), 0))
+}
+
+// RequiredIPAddressCount returns the number of free IPs a subnet needs for a node to be launched into it. With Azure CNI
+// without overlay, the NIC of the node reserves an IP per pod, on top of the IP of the node, unless pods get their IPs
+// from a dedicated pod subnet.
+func RequiredIPAddressCount(maxPods int32, networkPlugin, networkPluginMode string, podSubnet bool) int64 {
+	if networkPlugin == consts.NetworkPluginAzure && networkPluginMode != consts.NetworkPluginModeOverlay && !podSubnet {
+		return int64(maxPods) + 1
+	}
+	return 1
+}
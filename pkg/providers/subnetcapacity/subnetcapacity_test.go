@@ -0,0 +1,159 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package subnetcapacity_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+
+	"github.com/Azure/karpenter-provider-azure/pkg/consts"
+	"github.com/Azure/karpenter-provider-azure/pkg/fake"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/subnetcapacity"
+)
+
+const testSubnetID = "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet/subnets/nodes"
+
+func newSubnet(ipConfigurations int, prefixes ...string) armnetwork.Subnet {
+	return armnetwork.Subnet{
+		Properties: &armnetwork.SubnetPropertiesFormat{
+			AddressPrefixes:  lo.ToSlicePtr(prefixes),
+			IPConfigurations: lo.Times(ipConfigurations, func(_ int) *armnetwork.IPConfiguration { return &armnetwork.IPConfiguration{} }),
+		},
+	}
+}
+
+func TestRefresh_RecordsAvailableIPs(t *testing.T) {
+	g := NewWithT(t)
+	api := &fake.SubnetsAPI{}
+	api.Subnets.Store(testSubnetID, newSubnet(10, "10.0.0.0/24"))
+	provider := subnetcapacity.NewProvider(api)
+
+	// not refreshed yet
+	_, ok := provider.Available(testSubnetID)
+	g.Expect(ok).To(BeFalse())
+	g.Expect(provider.Fits(testSubnetID, 1000)).To(BeTrue())
+
+	subnet, err := provider.Refresh(context.Background(), testSubnetID)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(lo.FromPtr(subnet.Name)).To(Equal("nodes"))
+	available, ok := provider.Available(testSubnetID)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(available).To(Equal(int64(241)))
+	g.Expect(provider.Fits(testSubnetID, 241)).To(BeTrue())
+	g.Expect(provider.Fits(testSubnetID, 242)).To(BeFalse())
+}
+
+func TestRefresh_ReturnsSubnetErrors(t *testing.T) {
+	g := NewWithT(t)
+	api := &fake.SubnetsAPI{}
+	api.GetFunc = func(_ context.Context, _ string, _ string, _ string, _ *armnetwork.SubnetsClientGetOptions) (armnetwork.SubnetsClientGetResponse, error) {
+		return armnetwork.SubnetsClientGetResponse{}, &azcore.ResponseError{ErrorCode: "NotFound", StatusCode: http.StatusNotFound}
+	}
+	provider := subnetcapacity.NewProvider(api)
+
+	_, err := provider.Refresh(context.Background(), testSubnetID)
+	g.Expect(err).To(HaveOccurred())
+	_, ok := provider.Available(testSubnetID)
+	g.Expect(ok).To(BeFalse())
+}
+
+func TestAvailable_SubtractsReservationsUntilTheSubnetIsObservedAgain(t *testing.T) {
+	g := NewWithT(t)
+	provider := subnetcapacity.NewProvider(nil)
+	subnet := newSubnet(0, "10.0.0.0/24")
+	provider.Update(testSubnetID, &subnet, time.Now().Add(-time.Minute))
+
+	provider.Reserve(testSubnetID, "nic-1", 31)
+	provider.Reserve(testSubnetID, "nic-2", 31)
+	// reserving again for the same NIC doesn't use more IPs
+	provider.Reserve(testSubnetID, "nic-2", 31)
+	// subnet IDs are case insensitive
+	available, ok := provider.Available(strings.ToUpper(testSubnetID))
+	g.Expect(ok).To(BeTrue())
+	g.Expect(available).To(Equal(int64(251 - 62)))
+
+	// the subnet now has the ipconfigs of the NICs
+	subnet = newSubnet(62, "10.0.0.0/24")
+	provider.Update(testSubnetID, &subnet, time.Now().Add(time.Second))
+	available, _ = provider.Available(testSubnetID)
+	g.Expect(available).To(Equal(int64(251 - 62)))
+
+	provider.Reset()
+	_, ok = provider.Available(testSubnetID)
+	g.Expect(ok).To(BeFalse())
+}
+
+func TestAvailableIPAddressCount(t *testing.T) {
+	tests := []struct {
+		name   string
+		subnet armnetwork.Subnet
+		expect *int64
+	}{
+		{
+			name:   "without properties",
+			subnet: armnetwork.Subnet{},
+			expect: nil,
+		},
+		{
+			name:   "address prefix",
+			subnet: armnetwork.Subnet{Properties: &armnetwork.SubnetPropertiesFormat{AddressPrefix: lo.ToPtr("10.0.0.0/16")}},
+			expect: lo.ToPtr[int64](65531),
+		},
+		{
+			name:   "address prefixes, less the ipconfigs",
+			subnet: newSubnet(3, "10.0.0.0/24", "10.0.1.0/28"),
+			expect: lo.ToPtr[int64](251 + 11 - 3),
+		},
+		{
+			name:   "IPv6 prefixes are not counted",
+			subnet: newSubnet(0, "10.0.0.0/24", "fd00::/64"),
+			expect: lo.ToPtr[int64](251),
+		},
+		{
+			name:   "only IPv6 prefixes",
+			subnet: newSubnet(0, "fd00::/64"),
+			expect: nil,
+		},
+		{
+			name:   "more ipconfigs than addresses",
+			subnet: newSubnet(20, "10.0.0.0/28"),
+			expect: lo.ToPtr[int64](0),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(subnetcapacity.AvailableIPAddressCount(&tt.subnet)).To(Equal(tt.expect))
+		})
+	}
+}
+
+func TestRequiredIPAddressCount(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(subnetcapacity.RequiredIPAddressCount(30, consts.NetworkPluginAzure, "", false)).To(Equal(int64(31)))
+	g.Expect(subnetcapacity.RequiredIPAddressCount(30, consts.NetworkPluginAzure, "", true)).To(Equal(int64(1)))
+	g.Expect(subnetcapacity.RequiredIPAddressCount(250, consts.NetworkPluginAzure, consts.NetworkPluginModeOverlay, false)).To(Equal(int64(1)))
+	g.Expect(subnetcapacity.RequiredIPAddressCount(250, consts.NetworkPluginNone, "", false)).To(Equal(int64(1)))
+}
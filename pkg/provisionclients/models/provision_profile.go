@@ -106,6 +106,9 @@ type ProvisionProfile struct {
 	// Required: true
 	OsType *int32 `json:"osType"`
 
+	// pod subnet ID
+	PodSubnetID *string `json:"podSubnetID,omitempty"`
+
 	// security profile
 	SecurityProfile *AgentPoolSecurityProfile `json:"securityProfile,omitempty"`
 
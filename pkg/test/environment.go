@@ -26,6 +26,7 @@ import (
 
 	"github.com/patrickmn/go-cache"
 	"k8s.io/client-go/dynamic"
+	"k8s.io/utils/clock"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	coretest "sigs.k8s.io/karpenter/pkg/test"
 
@@ -36,8 +37,10 @@ import (
 	"github.com/Azure/karpenter-provider-azure/pkg/fake"
 	"github.com/Azure/karpenter-provider-azure/pkg/operator/options"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/allocationstrategy"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/allocationstrategy/stages"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/azclient"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/azclient/aksmachinesheaderbatch"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/capacityreservation"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/imagefamily"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/instance"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/instance/machinecache"
@@ -48,6 +51,7 @@ import (
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/networksecuritygroup"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/pricing"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/quota"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/subnetcapacity"
 	"github.com/Azure/karpenter-provider-azure/pkg/utils/batcher"
 	"github.com/Azure/karpenter-provider-azure/pkg/utils/zones"
 )
@@ -67,25 +71,30 @@ const (
 
 type Environment struct {
 	// API
-	VirtualMachinesAPI          *fake.VirtualMachinesAPI
-	AzureResourceGraphAPI       *fake.AzureResourceGraphAPI
-	VirtualMachineExtensionsAPI *fake.VirtualMachineExtensionsAPI
-	NetworkInterfacesAPI        *fake.NetworkInterfacesAPI
-	CommunityImageVersionsAPI   *fake.CommunityGalleryImageVersionsAPI
-	NodeImageVersionsAPI        *fake.NodeImageVersionsAPI
-	SKUsAPI                     *fake.ResourceSKUsAPI
-	PricingAPI                  *fake.PricingAPI
-	LoadBalancersAPI            *fake.LoadBalancersAPI
-	NetworkSecurityGroupAPI     *fake.NetworkSecurityGroupAPI
-	SubnetsAPI                  *fake.SubnetsAPI
-	DiskEncryptionSetsAPI       *fake.DiskEncryptionSetsAPI
-	AuxiliaryTokenServer        *fake.AuxiliaryTokenServer
-	SubscriptionAPI             *fake.SubscriptionsAPI
-	NodeBootstrappingAPI        *fake.NodeBootstrappingAPI
-	AKSMachinesAPI              *fake.AKSMachinesAPI
-	AKSAgentPoolsAPI            *fake.AKSAgentPoolsAPI
-	UsageAPI                    *fake.UsageAPI
-	DynamicInterface            dynamic.Interface
+	VirtualMachinesAPI           *fake.VirtualMachinesAPI
+	AzureResourceGraphAPI        *fake.AzureResourceGraphAPI
+	VirtualMachineExtensionsAPI  *fake.VirtualMachineExtensionsAPI
+	NetworkInterfacesAPI         *fake.NetworkInterfacesAPI
+	PublicIPAddressesAPI         *fake.PublicIPAddressesAPI
+	CommunityImageVersionsAPI    *fake.CommunityGalleryImageVersionsAPI
+	NodeImageVersionsAPI         *fake.NodeImageVersionsAPI
+	GalleryImagesAPI             *fake.GalleryImagesAPI
+	SKUsAPI                      *fake.ResourceSKUsAPI
+	PricingAPI                   *fake.PricingAPI
+	LoadBalancersAPI             *fake.LoadBalancersAPI
+	NetworkSecurityGroupAPI      *fake.NetworkSecurityGroupAPI
+	SubnetsAPI                   *fake.SubnetsAPI
+	ApplicationSecurityGroupsAPI *fake.ApplicationSecurityGroupsAPI
+	DiskEncryptionSetsAPI        *fake.DiskEncryptionSetsAPI
+	AuxiliaryTokenServer         *fake.AuxiliaryTokenServer
+	SubscriptionAPI              *fake.SubscriptionsAPI
+	NodeBootstrappingAPI         *fake.NodeBootstrappingAPI
+	AKSMachinesAPI               *fake.AKSMachinesAPI
+	AKSAgentPoolsAPI             *fake.AKSAgentPoolsAPI
+	UsageAPI                     *fake.UsageAPI
+	QuotaRequestsAPI             *fake.QuotaRequestsAPI
+	CapacityReservationsAPI      *fake.CapacityReservationsAPI
+	DynamicInterface             dynamic.Interface
 
 	// Fake data stores for the APIs
 	AKSDataStorage *fake.AKSDataStorage
@@ -97,6 +106,9 @@ type Environment struct {
 	InstanceTypeCache         *cache.Cache
 	LoadBalancerCache         *cache.Cache
 	UnavailableOfferingsCache *azurecache.UnavailableOfferings
+	SpotEvictionsCache        *azurecache.SpotEvictions
+	MemoryOverheadsCache      *azurecache.MemoryOverheads
+	SpotPriceHistoryCache     *azurecache.SpotPriceHistory
 
 	// Providers
 	InstanceTypesProvider        *instancetype.DefaultProvider
@@ -111,6 +123,8 @@ type Environment struct {
 	NetworkSecurityGroupProvider *networksecuritygroup.Provider
 	AllocationStrategyProvider   allocationstrategy.Provider
 	QuotaProvider                *quota.DefaultProvider
+	CapacityReservationProvider  *capacityreservation.Provider
+	SubnetCapacityProvider       *subnetcapacity.Provider
 
 	InstanceTypeStore *nodeoverlay.InstanceTypeStore
 
@@ -144,6 +158,7 @@ func NewRegionalEnvironment(ctx context.Context, env *coretest.Environment, regi
 	virtualMachinesAPI := &fake.VirtualMachinesAPI{AuxiliaryTokenPolicy: auxTokenPolicy}
 
 	networkInterfacesAPI := &fake.NetworkInterfacesAPI{}
+	publicIPAddressesAPI := &fake.PublicIPAddressesAPI{}
 	virtualMachinesExtensionsAPI := &fake.VirtualMachineExtensionsAPI{}
 	pricingAPI := &fake.PricingAPI{}
 	skusAPI := &fake.ResourceSKUsAPI{Location: region}
@@ -151,34 +166,42 @@ func NewRegionalEnvironment(ctx context.Context, env *coretest.Environment, regi
 	loadBalancersAPI := &fake.LoadBalancersAPI{}
 	networkSecurityGroupAPI := &fake.NetworkSecurityGroupAPI{}
 	nodeImageVersionsAPI := &fake.NodeImageVersionsAPI{}
+	galleryImagesAPI := &fake.GalleryImagesAPI{}
 	nodeBootstrappingAPI := &fake.NodeBootstrappingAPI{}
 	subscriptionAPI := &fake.SubscriptionsAPI{}
 	usageAPI := &fake.UsageAPI{}
+	quotaRequestsAPI := &fake.QuotaRequestsAPI{}
+	capacityReservationsAPI := &fake.CapacityReservationsAPI{}
 
 	aksDataStorage := fake.NewAKSDataStorage()
 	aksAgentPoolsAPI := fake.NewAKSAgentPoolsAPI(aksDataStorage)
 	aksMachinesAPI := fake.NewAKSMachinesAPI(aksDataStorage)
 
-	azureResourceGraphAPI := fake.NewAzureResourceGraphAPI(resourceGroup, virtualMachinesAPI, networkInterfacesAPI)
+	azureResourceGraphAPI := fake.NewAzureResourceGraphAPI(resourceGroup, virtualMachinesAPI, networkInterfacesAPI, publicIPAddressesAPI)
 	// Cache
 	kubernetesVersionCache := cache.New(azurecache.KubernetesVersionTTL, azurecache.DefaultCleanupInterval)
 	nodeImagesCache := cache.New(imagefamily.ImageExpirationInterval, imagefamily.ImageCacheCleaningInterval)
 	instanceTypeCache := cache.New(instancetype.InstanceTypesCacheTTL, azurecache.DefaultCleanupInterval)
 	loadBalancerCache := cache.New(loadbalancer.LoadBalancersCacheTTL, azurecache.DefaultCleanupInterval)
 	unavailableOfferingsCache := azurecache.NewUnavailableOfferings()
+	spotEvictionsCache := azurecache.NewSpotEvictions(clock.RealClock{})
+	memoryOverheadsCache := azurecache.NewMemoryOverheads()
+	spotPriceHistoryCache := azurecache.NewSpotPriceHistory()
 
 	// Providers
 	pricingProvider := pricing.NewProvider(ctx, azureEnv, pricingAPI, region, make(chan struct{}))
 	kubernetesVersionProvider := kubernetesversion.NewKubernetesVersionProvider(env.KubernetesInterface, kubernetesVersionCache)
-	imageFamilyProvider := imagefamily.NewProvider(communityImageVersionsAPI, region, subscription, nodeImageVersionsAPI, nodeImagesCache)
+	imageFamilyProvider := imagefamily.NewProvider(communityImageVersionsAPI, region, subscription, nodeImageVersionsAPI, galleryImagesAPI, nodeImagesCache)
 	quotaProvider := quota.NewProvider(usageAPI, region)
+	capacityReservationProvider := capacityreservation.NewProvider(capacityReservationsAPI, region)
 	instanceTypesProvider := instancetype.NewDefaultProvider(
 		region,
 		instanceTypeCache,
 		skusAPI,
 		pricingProvider,
 		unavailableOfferingsCache,
-		quotaProvider)
+		quotaProvider,
+		memoryOverheadsCache)
 	imageFamilyResolver := imagefamily.NewDefaultResolver(env.Client, imageFamilyProvider, instanceTypesProvider, nodeBootstrappingAPI)
 	networkSecurityGroupProvider := networksecuritygroup.NewProvider(
 		networkSecurityGroupAPI,
@@ -198,6 +221,7 @@ func NewRegionalEnvironment(ctx context.Context, env *coretest.Environment, regi
 		testOptions.NodeResourceGroup,
 		region,
 		testOptions.ProvisionMode,
+		azureEnv,
 	)
 	loadBalancerProvider := loadbalancer.NewProvider(
 		loadBalancersAPI,
@@ -205,6 +229,7 @@ func NewRegionalEnvironment(ctx context.Context, env *coretest.Environment, regi
 		testOptions.NodeResourceGroup,
 	)
 	subnetsAPI := &fake.SubnetsAPI{}
+	applicationSecurityGroupsAPI := &fake.ApplicationSecurityGroupsAPI{}
 	diskEncryptionSetsAPI := &fake.DiskEncryptionSetsAPI{}
 
 	// Set up batching if provision mode is header batch
@@ -225,18 +250,28 @@ func NewRegionalEnvironment(ctx context.Context, env *coretest.Environment, regi
 		aksAgentPoolsAPI,
 		virtualMachinesExtensionsAPI,
 		networkInterfacesAPI,
+		publicIPAddressesAPI,
 		subnetsAPI,
+		applicationSecurityGroupsAPI,
 		diskEncryptionSetsAPI,
 		loadBalancersAPI,
 		networkSecurityGroupAPI,
 		communityImageVersionsAPI,
 		nodeImageVersionsAPI,
+		galleryImagesAPI,
 		nodeBootstrappingAPI,
 		skusAPI,
 		subscriptionAPI,
 		usageAPI,
+		quotaRequestsAPI,
+		capacityReservationsAPI,
 	)
-	allocationStrategyProvider := allocationstrategy.NewProvider()
+	subnetCapacityProvider := subnetcapacity.NewProvider(subnetsAPI)
+	var spotPriceTrends stages.SpotPriceTrends
+	if testOptions.AvoidRisingSpotPrices {
+		spotPriceTrends = spotPriceHistoryCache
+	}
+	allocationStrategyProvider := allocationstrategy.NewProvider(env.Client, unavailableOfferingsCache, spotEvictionsCache, spotPriceTrends)
 	vmInstanceProvider := instance.NewDefaultVMProvider(
 		azClient,
 		instanceTypesProvider,
@@ -245,6 +280,8 @@ func NewRegionalEnvironment(ctx context.Context, env *coretest.Environment, regi
 		loadBalancerProvider,
 		networkSecurityGroupProvider,
 		unavailableOfferingsCache,
+		capacityReservationProvider,
+		subnetCapacityProvider,
 		region,
 		testOptions.NodeResourceGroup,
 		subscription,
@@ -291,6 +328,7 @@ func NewRegionalEnvironment(ctx context.Context, env *coretest.Environment, regi
 		region,
 		batchCreationEnabled,
 		aksMachineCache,
+		subnetCapacityProvider,
 	)
 
 	store := nodeoverlay.NewInstanceTypeStore()
@@ -304,25 +342,30 @@ func NewRegionalEnvironment(ctx context.Context, env *coretest.Environment, regi
 	networkSecurityGroupAPI.NSGs.Store(lo.FromPtr(nsg.ID), nsg)
 
 	return &Environment{
-		VirtualMachinesAPI:          virtualMachinesAPI,
-		AuxiliaryTokenServer:        auxiliaryTokenServer,
-		AzureResourceGraphAPI:       azureResourceGraphAPI,
-		VirtualMachineExtensionsAPI: virtualMachinesExtensionsAPI,
-		NetworkInterfacesAPI:        networkInterfacesAPI,
-		CommunityImageVersionsAPI:   communityImageVersionsAPI,
-		NodeImageVersionsAPI:        nodeImageVersionsAPI,
-		LoadBalancersAPI:            loadBalancersAPI,
-		NetworkSecurityGroupAPI:     networkSecurityGroupAPI,
-		SubnetsAPI:                  subnetsAPI,
-		DiskEncryptionSetsAPI:       diskEncryptionSetsAPI,
-		SKUsAPI:                     skusAPI,
-		PricingAPI:                  pricingAPI,
-		SubscriptionAPI:             subscriptionAPI,
-		NodeBootstrappingAPI:        nodeBootstrappingAPI,
-		AKSMachinesAPI:              aksMachinesAPI,
-		AKSAgentPoolsAPI:            aksAgentPoolsAPI,
-		UsageAPI:                    usageAPI,
-		DynamicInterface:            dynamic.NewForConfigOrDie(env.Config),
+		VirtualMachinesAPI:           virtualMachinesAPI,
+		AuxiliaryTokenServer:         auxiliaryTokenServer,
+		AzureResourceGraphAPI:        azureResourceGraphAPI,
+		VirtualMachineExtensionsAPI:  virtualMachinesExtensionsAPI,
+		NetworkInterfacesAPI:         networkInterfacesAPI,
+		PublicIPAddressesAPI:         publicIPAddressesAPI,
+		CommunityImageVersionsAPI:    communityImageVersionsAPI,
+		NodeImageVersionsAPI:         nodeImageVersionsAPI,
+		GalleryImagesAPI:             galleryImagesAPI,
+		LoadBalancersAPI:             loadBalancersAPI,
+		NetworkSecurityGroupAPI:      networkSecurityGroupAPI,
+		SubnetsAPI:                   subnetsAPI,
+		ApplicationSecurityGroupsAPI: applicationSecurityGroupsAPI,
+		DiskEncryptionSetsAPI:        diskEncryptionSetsAPI,
+		SKUsAPI:                      skusAPI,
+		PricingAPI:                   pricingAPI,
+		SubscriptionAPI:              subscriptionAPI,
+		NodeBootstrappingAPI:         nodeBootstrappingAPI,
+		AKSMachinesAPI:               aksMachinesAPI,
+		AKSAgentPoolsAPI:             aksAgentPoolsAPI,
+		UsageAPI:                     usageAPI,
+		QuotaRequestsAPI:             quotaRequestsAPI,
+		CapacityReservationsAPI:      capacityReservationsAPI,
+		DynamicInterface:             dynamic.NewForConfigOrDie(env.Config),
 
 		AKSDataStorage: aksDataStorage,
 
@@ -331,6 +374,9 @@ func NewRegionalEnvironment(ctx context.Context, env *coretest.Environment, regi
 		NodeImagesCache:           nodeImagesCache,
 		InstanceTypeCache:         instanceTypeCache,
 		UnavailableOfferingsCache: unavailableOfferingsCache,
+		SpotEvictionsCache:        spotEvictionsCache,
+		MemoryOverheadsCache:      memoryOverheadsCache,
+		SpotPriceHistoryCache:     spotPriceHistoryCache,
 		LoadBalancerCache:         loadBalancerCache,
 
 		InstanceTypesProvider:        instanceTypesProvider,
@@ -345,6 +391,8 @@ func NewRegionalEnvironment(ctx context.Context, env *coretest.Environment, regi
 		NetworkSecurityGroupProvider: networkSecurityGroupProvider,
 		AllocationStrategyProvider:   allocationStrategyProvider,
 		QuotaProvider:                quotaProvider,
+		CapacityReservationProvider:  capacityReservationProvider,
+		SubnetCapacityProvider:       subnetCapacityProvider,
 
 		InstanceTypeStore: store,
 
@@ -363,11 +411,14 @@ func (env *Environment) Reset(ctx context.Context) {
 	env.AzureResourceGraphAPI.Reset()
 	env.VirtualMachineExtensionsAPI.Reset()
 	env.NetworkInterfacesAPI.Reset()
+	env.PublicIPAddressesAPI.Reset()
 	env.LoadBalancersAPI.Reset()
 	env.NetworkSecurityGroupAPI.Reset()
 	env.SubnetsAPI.Reset()
+	env.ApplicationSecurityGroupsAPI.Reset()
 	env.CommunityImageVersionsAPI.Reset()
 	env.NodeImageVersionsAPI.Reset()
+	env.GalleryImagesAPI.Reset()
 	env.NodeBootstrappingAPI.Reset()
 	env.SKUsAPI.Reset()
 	env.PricingAPI.Reset()
@@ -375,12 +426,19 @@ func (env *Environment) Reset(ctx context.Context) {
 	env.AKSMachinesAPI.Reset()
 	env.AKSAgentPoolsAPI.Reset()
 	env.UsageAPI.Reset()
+	env.QuotaRequestsAPI.Reset()
+	env.CapacityReservationsAPI.Reset()
 	env.QuotaProvider.Reset()
+	env.CapacityReservationProvider.Reset()
+	env.SubnetCapacityProvider.Reset()
 
 	env.KubernetesVersionCache.Flush()
 	env.NodeImagesCache.Flush()
 	env.InstanceTypeCache.Flush()
 	env.UnavailableOfferingsCache.Flush()
+	env.SpotEvictionsCache.Flush()
+	env.MemoryOverheadsCache.Flush()
+	env.SpotPriceHistoryCache.Flush()
 	env.AKSMachineCache.InvalidateAll()
 	env.LoadBalancerCache.Flush()
 
@@ -77,6 +77,8 @@ func ApplyDefaultStatus(nodeClass *v1beta1.AKSNodeClass, env *coretest.Environme
 	nodeClass.StatusConditions().SetTrue(v1beta1.ConditionTypeSubnetsReady)
 	nodeClass.StatusConditions().SetTrue(v1beta1.ConditionTypeValidationSucceeded)
 	nodeClass.StatusConditions().SetTrue(v1beta1.ConditionTypeLocalDNSReady)
+	nodeClass.StatusConditions().SetTrue(v1beta1.ConditionTypeNetworkSecurityGroupsReady)
+	nodeClass.StatusConditions().SetTrue(v1beta1.ConditionTypeCapacityReservationGroupReady)
 
 	conditions := []opstatus.Condition{}
 	for _, condition := range nodeClass.GetConditions() {
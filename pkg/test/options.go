@@ -53,6 +53,13 @@ type OptionsFields struct {
 	ProviderBatchIdleDuration      *time.Duration
 	ProviderBatchMaxDuration       *time.Duration
 	ProviderBatchMaxSize           *int
+	PersistUnavailableOfferings    *bool
+	QuotaIncreaseMaxLimit          *int
+	PricingOverridesConfigMap      *string
+	OfflinePricing                 *bool
+	PricingFile                    *string
+	AvoidRisingSpotPrices          *bool
+	IPv6DualStackEnabled           *bool
 
 	// SIG Flags not required by the self hosted offering
 	UseSIG                  *bool
@@ -97,5 +104,12 @@ func Options(overrides ...OptionsFields) *azoptions.Options {
 		ProviderBatchIdleDuration:      lo.FromPtrOr(options.ProviderBatchIdleDuration, time.Second),
 		ProviderBatchMaxDuration:       lo.FromPtrOr(options.ProviderBatchMaxDuration, 5*time.Second),
 		ProviderBatchMaxSize:           lo.FromPtrOr(options.ProviderBatchMaxSize, 50),
+		PersistUnavailableOfferings:    lo.FromPtrOr(options.PersistUnavailableOfferings, false),
+		QuotaIncreaseMaxLimit:          lo.FromPtrOr(options.QuotaIncreaseMaxLimit, 0),
+		PricingOverridesConfigMap:      lo.FromPtrOr(options.PricingOverridesConfigMap, ""),
+		OfflinePricing:                 lo.FromPtrOr(options.OfflinePricing, false),
+		PricingFile:                    lo.FromPtrOr(options.PricingFile, ""),
+		AvoidRisingSpotPrices:          lo.FromPtrOr(options.AvoidRisingSpotPrices, false),
+		IPv6DualStackEnabled:           lo.FromPtrOr(options.IPv6DualStackEnabled, false),
 	}
 }
@@ -0,0 +1,110 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configmapstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// Store persists state, serialized to JSON, to a key of a ConfigMap so that it survives controller restarts and leader
+// failovers. It is only used by singleton controllers, and isn't safe for concurrent use.
+type Store struct {
+	kubernetesInterface kubernetes.Interface
+	namespace           string
+	name                string
+	key                 string
+
+	lastPersisted string
+}
+
+func New(kubernetesInterface kubernetes.Interface, namespace, name, key string) *Store {
+	return &Store{
+		kubernetesInterface: kubernetesInterface,
+		namespace:           namespace,
+		name:                name,
+		key:                 key,
+	}
+}
+
+// Restore reads the persisted state into v, and returns true if there was one. The state is only an optimization, so
+// one that can't be read is logged and ignored, it is overwritten on the next Persist.
+func (s *Store) Restore(ctx context.Context, v any) (bool, error) {
+	configMap, err := s.kubernetesInterface.CoreV1().ConfigMaps(s.namespace).Get(ctx, s.name, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("getting ConfigMap %s/%s, %w", s.namespace, s.name, err)
+	}
+	data, ok := configMap.Data[s.key]
+	if !ok {
+		return false, nil
+	}
+	if err := json.Unmarshal([]byte(data), v); err != nil {
+		log.FromContext(ctx).Error(err, "ignoring unreadable persisted state", "ConfigMap", s.name, "key", s.key)
+		return false, nil
+	}
+	s.lastPersisted = data
+	return true, nil
+}
+
+// Persist writes v to the ConfigMap, creating it if needed. Nothing is written if v didn't change since it was last
+// restored or persisted.
+func (s *Store) Persist(ctx context.Context, v any) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("serializing %s of ConfigMap %s/%s, %w", s.key, s.namespace, s.name, err)
+	}
+	data := string(raw)
+	if data == s.lastPersisted {
+		return nil
+	}
+
+	configMaps := s.kubernetesInterface.CoreV1().ConfigMaps(s.namespace)
+	configMap, err := configMaps.Get(ctx, s.name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		_, err = configMaps.Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: s.name, Namespace: s.namespace},
+			Data:       map[string]string{s.key: data},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return fmt.Errorf("creating ConfigMap %s/%s, %w", s.namespace, s.name, err)
+		}
+		s.lastPersisted = data
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("getting ConfigMap %s/%s, %w", s.namespace, s.name, err)
+	}
+	if configMap.Data == nil {
+		configMap.Data = map[string]string{}
+	}
+	configMap.Data[s.key] = data
+	if _, err = configMaps.Update(ctx, configMap, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("updating ConfigMap %s/%s, %w", s.namespace, s.name, err)
+	}
+	s.lastPersisted = data
+	return nil
+}
@@ -0,0 +1,113 @@
+/*
+Portions Copyright (c) Microsoft Corporation.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configmapstore_test
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/Azure/karpenter-provider-azure/pkg/utils/configmapstore"
+)
+
+const (
+	namespace = "karpenter"
+	name      = "karpenter-state"
+	key       = "state"
+)
+
+func TestPersistAndRestore(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	kubernetesInterface := kubernetesfake.NewClientset()
+
+	state := map[string]int{}
+	restored, err := configmapstore.New(kubernetesInterface, namespace, name, key).Restore(ctx, &state)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(restored).To(BeFalse())
+
+	store := configmapstore.New(kubernetesInterface, namespace, name, key)
+	g.Expect(store.Persist(ctx, map[string]int{"a": 1})).To(Succeed())
+	configMap, err := kubernetesInterface.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(configMap.Data).To(HaveKeyWithValue(key, `{"a":1}`))
+
+	g.Expect(store.Persist(ctx, map[string]int{"a": 2})).To(Succeed())
+	restored, err = configmapstore.New(kubernetesInterface, namespace, name, key).Restore(ctx, &state)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(restored).To(BeTrue())
+	g.Expect(state).To(Equal(map[string]int{"a": 2}))
+}
+
+func TestPersistUnchanged(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	kubernetesInterface := kubernetesfake.NewClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Data:       map[string]string{key: `{"a":1}`},
+	})
+	store := configmapstore.New(kubernetesInterface, namespace, name, key)
+
+	state := map[string]int{}
+	restored, err := store.Restore(ctx, &state)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(restored).To(BeTrue())
+	kubernetesInterface.ClearActions()
+
+	// the restored state isn't written back
+	g.Expect(store.Persist(ctx, state)).To(Succeed())
+	g.Expect(kubernetesInterface.Actions()).To(BeEmpty())
+}
+
+func TestPersistKeepsOtherKeys(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	kubernetesInterface := kubernetesfake.NewClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Data:       map[string]string{"other": "value"},
+	})
+
+	g.Expect(configmapstore.New(kubernetesInterface, namespace, name, key).Persist(ctx, []string{"a"})).To(Succeed())
+	configMap, err := kubernetesInterface.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(configMap.Data).To(Equal(map[string]string{"other": "value", key: `["a"]`}))
+}
+
+func TestRestoreIgnoresUnreadableState(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+	kubernetesInterface := kubernetesfake.NewClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Data:       map[string]string{key: "not json"},
+	})
+	store := configmapstore.New(kubernetesInterface, namespace, name, key)
+
+	state := map[string]int{}
+	restored, err := store.Restore(ctx, &state)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(restored).To(BeFalse())
+
+	// and it is overwritten on the next persist
+	g.Expect(store.Persist(ctx, map[string]int{"a": 1})).To(Succeed())
+	configMap, err := kubernetesInterface.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(configMap.Data).To(HaveKeyWithValue(key, `{"a":1}`))
+}
@@ -22,6 +22,10 @@ import (
 	"strings"
 )
 
+const (
+	windowsPrefix = "AKSWindows"
+)
+
 var (
 	sigImageIDRegex = regexp.MustCompile(`(?i)/subscriptions/(\S+)/resourceGroups/(\S+)/providers/Microsoft.Compute/galleries/(\S+)/images/(\S+)/versions/(\S+)`)
 )
@@ -52,9 +56,18 @@ func GetAKSMachineNodeImageVersionFromSIGImageID(imageID string) (string, error)
 
 	prefix := gallery
 	osVersion := definition
-	// if strings.Contains(prefix, windowsPrefix) {		// TODO(Windows)
-	// 	osVersion = extractOsVersionForWindows(definition)
-	// }
+	if strings.Contains(prefix, windowsPrefix) {
+		osVersion = extractOsVersionForWindows(definition)
+	}
 
 	return strings.Join([]string{prefix, osVersion, version}, "-"), nil
 }
+
+// Windows image definitions carry a "windows-" prefix (e.g. "windows-2022-containerd-gen2"), which is redundant with
+// the gallery name in the node image version (e.g. "AKSWindows-2022-containerd-gen2-20348.4529.251212").
+func extractOsVersionForWindows(imageDefinition string) string {
+	if len(imageDefinition) > len("windows-") && strings.EqualFold(imageDefinition[:len("windows-")], "windows-") {
+		return imageDefinition[len("windows-"):]
+	}
+	return imageDefinition
+}
@@ -96,6 +96,12 @@ func TestGetAKSMachineNodeImageVersionFromSIGImageID(t *testing.T) {
 			expectedError:  "",
 			expectedResult: "AKSUbuntu-2204gen2containerd-2022.10.03-build.1",
 		},
+		{
+			name:           "Valid Windows SIG image ID",
+			imageID:        "/subscriptions/10945678-1234-1234-1234-123456789012/resourceGroups/AKS-Windows/providers/Microsoft.Compute/galleries/AKSWindows/images/windows-2022-containerd-gen2/versions/20348.4529.251212",
+			expectedError:  "",
+			expectedResult: "AKSWindows-2022-containerd-gen2-20348.4529.251212",
+		},
 		{
 			name:           "Invalid SIG image ID - missing subscription",
 			imageID:        "/resourceGroups/AKS-Ubuntu/providers/Microsoft.Compute/galleries/AKSUbuntu/images/2204gen2containerd/versions/2022.10.03",
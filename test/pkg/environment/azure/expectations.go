@@ -34,6 +34,7 @@ import (
 	"github.com/Azure/karpenter-provider-azure/pkg/auth"
 	"github.com/Azure/karpenter-provider-azure/pkg/consts"
 	"github.com/Azure/karpenter-provider-azure/pkg/providers/instance"
+	"github.com/Azure/karpenter-provider-azure/pkg/providers/launchtemplate"
 )
 
 func (env *Environment) EventuallyExpectKarpenterNicsToBeDeleted() {
@@ -272,10 +273,7 @@ func (env *Environment) EventuallyExpectAzureResources(
 		// Note that disks also exist, but are automatically created and managed by Azure so we don't check them here.
 
 		// VMs
-		managedExtensionNames := instance.GetManagedExtensionNames(
-			lo.Ternary(env.InClusterController, consts.ProvisionModeAKSScriptless, consts.ProvisionModeBootstrappingClient),
-			lo.Must(auth.EnvironmentFromName("AzurePublicCloud")),
-		)
+		provisionMode := lo.Ternary(env.InClusterController, consts.ProvisionModeAKSScriptless, consts.ProvisionModeBootstrappingClient)
 		vmPager := env.vmClient.NewListPager(env.NodeResourceGroup, nil)
 		for vmPager.More() {
 			resp, err := vmPager.NextPage(env.Context)
@@ -290,6 +288,8 @@ func (env *Environment) EventuallyExpectAzureResources(
 				g.Expect(err).ToNot(HaveOccurred())
 
 				// Extensions
+				isWindows := lo.FromPtr(vm.Tags[launchtemplate.BillingTagKey]) == launchtemplate.BillingTagValueWindows
+				managedExtensionNames := instance.GetManagedExtensionNames(provisionMode, lo.Must(auth.EnvironmentFromName("AzurePublicCloud")), isWindows)
 				for _, ext := range vm.Resources {
 					// Only check extensions are that managed by Karpenter
 					if !slices.Contains(managedExtensionNames, lo.FromPtr(ext.Name)) {